@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -44,7 +43,7 @@ func (s *azureClient) GetAzureADAppRoleAssignments(ctx context.Context, serviceP
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -59,43 +58,22 @@ func (s *azureClient) ListAzureADAppRoleAssignments(ctx context.Context, service
 
 		var (
 			errResult = azure.AppRoleAssignmentResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADAppRoleAssignments(ctx, servicePrincipal, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.AppRoleAssignmentList, error) {
+				return s.GetAzureADAppRoleAssignments(ctx, servicePrincipal, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.AppRoleAssignmentList) ([]azure.AppRoleAssignment, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.AppRoleAssignment) string { return u.Id },
+			func(u azure.AppRoleAssignment) {
+				out <- azure.AppRoleAssignmentResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.AppRoleAssignmentResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.AppRoleAssignmentList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.AppRoleAssignmentResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -19,8 +19,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -38,7 +38,7 @@ func (s *azureClient) GetAzureADApp(ctx context.Context, objectId string, select
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -53,7 +53,7 @@ func (s *azureClient) GetAzureADAppOwners(ctx context.Context, objectId string,
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,7 +70,7 @@ func (s *azureClient) GetAzureADAppMemberObjects(ctx context.Context, objectId s
 	)
 	if res, err := s.msgraph.Post(ctx, path, body, nil, nil); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -93,7 +93,7 @@ func (s *azureClient) GetAzureADApps(ctx context.Context, filter, search, orderB
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -108,43 +108,20 @@ func (s *azureClient) ListAzureADApps(ctx context.Context, filter, search, order
 
 		var (
 			errResult = azure.ApplicationResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADApps(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.ApplicationList, error) {
+				return s.GetAzureADApps(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.ApplicationList) ([]azure.Application, string) { return list.Value, list.NextLink },
+			func(u azure.Application) string { return u.Id },
+			func(u azure.Application) {
+				out <- azure.ApplicationResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.ApplicationResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.ApplicationList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ApplicationResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -158,49 +135,23 @@ func (s *azureClient) ListAzureADAppOwners(ctx context.Context, objectId string,
 
 		var (
 			errResult = azure.AppOwnerResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADAppOwners(ctx, objectId, filter, search, orderBy, selectCols, 999, false); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range list.Value {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DirectoryObjectList, error) {
+				return s.GetAzureADAppOwners(ctx, objectId, filter, search, orderBy, selectCols, 999, false)
+			},
+			func(list azure.DirectoryObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
 				out <- azure.AppOwnerResult{
 					AppId: objectId,
 					Ok:    u,
 				}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.DirectoryObjectList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					return
-				} else {
-					for _, u := range list.Value {
-						out <- azure.AppOwnerResult{
-							AppId: objectId,
-							Ok:    u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -217,50 +168,24 @@ func (s *azureClient) ListAzureADAppMemberObjects(ctx context.Context, objectId
 				ParentId:   objectId,
 				ParentType: string(enums.EntityApplication),
 			}
-			nextLink string
 		)
-		if list, err := s.GetAzureADAppMemberObjects(ctx, objectId, securityEnabledOnly); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range list.Value {
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.MemberObjectList, error) {
+				return s.GetAzureADAppMemberObjects(ctx, objectId, securityEnabledOnly)
+			},
+			func(list azure.MemberObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
 				out <- azure.MemberObjectResult{
 					ParentId:   objectId,
 					ParentType: string(enums.EntityApplication),
 					Ok:         u,
 				}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.MemberObjectList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.MemberObjectResult{
-							ParentId:   objectId,
-							ParentType: string(enums.EntityApplication),
-							Ok:         u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
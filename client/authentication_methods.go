@@ -0,0 +1,72 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureADUserAuthenticationMethods(ctx context.Context, userId string) (azure.AuthenticationMethodList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/users/%s/authentication/methods", constants.GraphApiVersion, userId)
+		response azure.AuthenticationMethodList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, nil); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+// ListAzureADUserAuthenticationMethods lists the authentication methods the user has registered
+func (s *azureClient) ListAzureADUserAuthenticationMethods(ctx context.Context, userId string) <-chan azure.UserAuthenticationMethodResult {
+	out := make(chan azure.UserAuthenticationMethodResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.UserAuthenticationMethodResult{UserId: userId}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.AuthenticationMethodList, error) {
+				return s.GetAzureADUserAuthenticationMethods(ctx, userId)
+			},
+			func(list azure.AuthenticationMethodList) ([]azure.AuthenticationMethod, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.AuthenticationMethod) string { return u.Id },
+			func(u azure.AuthenticationMethod) {
+				out <- azure.UserAuthenticationMethodResult{UserId: userId, Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureAutomationAccount(ctx context.Context, subscriptio
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureAutomationAccounts(ctx context.Context, subscripti
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,46 +69,22 @@ func (s *azureClient) ListAzureAutomationAccounts(ctx context.Context, subscript
 			errResult = azure.AutomationAccountResult{
 				SubscriptionId: subscriptionId,
 			}
-			nextLink string
 		)
 
-		if result, err := s.GetAzureAutomationAccounts(ctx, subscriptionId); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.AutomationAccountList, error) {
+				return s.GetAzureAutomationAccounts(ctx, subscriptionId)
+			},
+			func(list azure.AutomationAccountList) ([]azure.AutomationAccount, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.AutomationAccount) string { return u.Id },
+			func(u azure.AutomationAccount) {
+				out <- azure.AutomationAccountResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.AutomationAccountResult{SubscriptionId: subscriptionId, Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.AutomationAccountList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.AutomationAccountResult{
-							SubscriptionId: "/subscriptions/" + subscriptionId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -21,17 +21,43 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
 
 	"encoding/json"
 
 	"github.com/bloodhoundad/azurehound/v2/client/config"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
 	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/go-logr/logr"
 )
 
+// maxResyncRestarts bounds how often a single listing may start over after the service discards its paging state
+const maxResyncRestarts = 3
+
+var (
+	log            = logr.Discard()
+	resyncRestarts int64
+)
+
+// SetLogger sets the logger used to report conditions the client recovers from without surfacing an error
+func SetLogger(logger logr.Logger) {
+	log = logger
+	rest.SetLogger(logger)
+}
+
+// ResyncRestarts returns the number of listings restarted because the service required a resync
+func ResyncRestarts() int64 {
+	return atomic.LoadInt64(&resyncRestarts)
+}
+
 func NewClient(config config.Config) (AzureClient, error) {
-	if msgraph, err := rest.NewRestClient(config.GraphUrl(), config); err != nil {
+	if config.GraphToken != "" || config.ArmToken != "" {
+		return newClientFromTokens(config)
+	} else if msgraph, err := rest.NewRestClient(config.GraphUrl(), config); err != nil {
 		return nil, err
 	} else if resourceManager, err := rest.NewRestClient(config.ResourceManagerUrl(), config); err != nil {
 		return nil, err
@@ -57,10 +83,74 @@ func NewClient(config config.Config) (AzureClient, error) {
 	}
 }
 
+// newClientFromTokens creates a client that uses the supplied Graph and Resource Manager access tokens as-is. Either
+// may be omitted, in which case only requests to the other API can succeed.
+func newClientFromTokens(cfg config.Config) (AzureClient, error) {
+	if cfg.JWT != "" {
+		return nil, fmt.Errorf("--jwt cannot be combined with --graph-token or --arm-token")
+	} else if err := validateToken(cfg.GraphToken, cfg.GraphUrl()); err != nil {
+		return nil, fmt.Errorf("invalid graph token: %w", err)
+	} else if err := validateToken(cfg.ArmToken, cfg.ResourceManagerUrl()); err != nil {
+		return nil, fmt.Errorf("invalid arm token: %w", err)
+	}
+
+	// requests to an API without a token fail instead of falling back to other configured credentials
+	tokenConfig := func(token string) config.Config {
+		return config.Config{
+			Authority:          cfg.Authority,
+			AuthorityOverride:  cfg.AuthorityOverride,
+			CacheDir:           cfg.CacheDir,
+			CacheTTL:           cfg.CacheTTL,
+			Graph:              cfg.Graph,
+			GraphOverride:      cfg.GraphOverride,
+			HTTPTimeout:        cfg.HTTPTimeout,
+			JWT:                token,
+			Management:         cfg.Management,
+			ManagementOverride: cfg.ManagementOverride,
+			MaxRetries:         cfg.MaxRetries,
+			ProxyUrl:           cfg.ProxyUrl,
+			Region:             cfg.Region,
+			RetryMaxBackoff:    cfg.RetryMaxBackoff,
+		}
+	}
+
+	if msgraph, err := rest.NewRestClient(cfg.GraphUrl(), tokenConfig(cfg.GraphToken)); err != nil {
+		return nil, err
+	} else if resourceManager, err := rest.NewRestClient(cfg.ResourceManagerUrl(), tokenConfig(cfg.ArmToken)); err != nil {
+		return nil, err
+	} else if cfg.GraphToken != "" {
+		return initClientViaGraph(msgraph, resourceManager)
+	} else if body, err := rest.ParseBody(cfg.ArmToken); err != nil {
+		return nil, err
+	} else if tid, ok := body["tid"].(string); !ok {
+		return nil, fmt.Errorf("invalid arm token: no tenant id")
+	} else {
+		return initClientViaRM(msgraph, resourceManager, tid)
+	}
+}
+
+// validateToken fails fast on a supplied token that is for another API or has already expired
+func validateToken(token, api string) error {
+	if token == "" {
+		return nil
+	} else if aud, err := rest.ParseAud(token); err != nil {
+		return err
+	} else if aud != api {
+		return fmt.Errorf("token audience is %s, expected %s", aud, api)
+	} else if expires, err := rest.ParseExpiry(token); err != nil {
+		return err
+	} else if time.Now().After(expires) {
+		return fmt.Errorf("%w at %s", rest.ErrTokenExpired, expires.Format(time.RFC3339))
+	} else {
+		return nil
+	}
+}
+
 func initClientViaRM(msgraph, resourceManager rest.RestClient, tid interface{}) (AzureClient, error) {
 	client := &azureClient{
 		msgraph:         msgraph,
 		resourceManager: resourceManager,
+		principalTypes:  newPrincipalTypeCache(),
 	}
 	if result, err := client.GetAzureADTenants(context.Background(), true); err != nil {
 		return nil, err
@@ -79,6 +169,7 @@ func initClientViaGraph(msgraph, resourceManager rest.RestClient) (AzureClient,
 	client := &azureClient{
 		msgraph:         msgraph,
 		resourceManager: resourceManager,
+		principalTypes:  newPrincipalTypeCache(),
 	}
 	if org, err := client.GetAzureADOrganization(context.Background(), nil); err != nil {
 		return nil, err
@@ -88,25 +179,192 @@ func initClientViaGraph(msgraph, resourceManager rest.RestClient) (AzureClient,
 	}
 }
 
+// listPages enumerates a paged collection. The first page is fetched by first and each following page by requesting
+// the nextLink reported by page. If the service responds that the paging state is gone (see rest.ErrResyncRequired)
+// the enumeration starts over from the first page, skipping items whose key has already been emitted. Under a
+// context from WithCursor the enumeration resumes from the cursor and reports its progress after every page, and under
+// one from WithLimit it stops once the limit has been emitted.
+func listPages[L, T any](ctx context.Context, api rest.RestClient, first func() (L, error), page func(L) ([]T, string), key func(T) string, emit func(T)) error {
+	var (
+		cursor, _ = ctx.Value(cursorKey{}).(cursorOptions)
+		limit, _  = ctx.Value(limitKey{}).(int)
+		emitted   = 0
+		seen      = make(map[string]struct{})
+		restarts  = 0
+		resumeAt  = cursor.start.NextLink
+		skipUntil string
+		lastKey   = cursor.start.LastId
+	)
+
+	start := func() (L, error) {
+		if resumeAt != "" {
+			link := resumeAt
+			resumeAt = ""
+			if list, err := fetchPage[L](ctx, api, link); err == nil || rest.Aborted(ctx) != nil {
+				return list, err
+			} else {
+				log.Info("unable to resume from the checkpointed page, skipping ahead from the first page instead", "reason", err.Error(), "lastId", cursor.start.LastId)
+			}
+		}
+		// starting from the first page while resuming, everything up to the last object already collected is skipped
+		skipUntil = cursor.start.LastId
+		return first()
+	}
+
+	emitUnseen := func(items []T, link string) error {
+		for _, item := range items {
+			id := key(item)
+			if err := rest.Aborted(ctx); err != nil {
+				return err
+			} else if skipUntil != "" {
+				if id == skipUntil {
+					skipUntil = ""
+				}
+			} else if id == "" {
+				emitted++
+				emit(item)
+			} else if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				emitted++
+				emit(item)
+			}
+
+			if limit > 0 && emitted >= limit {
+				return errLimitReached
+			}
+		}
+
+		// the cursor trails by a page so items still in flight when collection stops are collected again, not lost
+		if cursor.record != nil && link != "" && skipUntil == "" {
+			cursor.record(Cursor{NextLink: link, LastId: lastKey})
+		}
+		if len(items) > 0 {
+			lastKey = key(items[len(items)-1])
+		}
+		return nil
+	}
+
+	for {
+		if restart, err := followPages(ctx, api, start, page, emitUnseen); errors.Is(err, errLimitReached) {
+			// a limited enumeration did not run to the end, so the cursor is not marked complete
+			return nil
+		} else if err != nil {
+			return err
+		} else if restart == nil {
+			if skipUntil != "" {
+				return fmt.Errorf("unable to resume: object %s was not found", skipUntil)
+			} else if cursor.record != nil {
+				cursor.record(Cursor{Complete: true})
+			}
+			return nil
+		} else if restarts++; restarts > maxResyncRestarts {
+			return fmt.Errorf("exceeded %d restarts: %w", maxResyncRestarts, restart)
+		} else {
+			total := atomic.AddInt64(&resyncRestarts, 1)
+			log.Info("paging state discarded by service, restarting enumeration", "reason", restart.Error(), "restart", restarts, "totalRestarts", total)
+		}
+	}
+}
+
+// followPages emits the items of the first and all subsequent pages along with the nextLink each page was requested
+// by, which is empty for the first page. A non-nil restart reports that the service required a resync while
+// following a nextLink.
+func followPages[L, T any](ctx context.Context, api rest.RestClient, first func() (L, error), page func(L) ([]T, string), emit func([]T, string) error) (restart error, err error) {
+	if list, err := first(); err != nil {
+		return nil, err
+	} else {
+		items, nextLink := page(list)
+		if err := emit(items, ""); err != nil {
+			return nil, err
+		}
+
+		for nextLink != "" {
+			if err := rest.Aborted(ctx); err != nil {
+				return nil, err
+			} else if list, err := fetchPage[L](ctx, api, nextLink); errors.Is(err, rest.ErrResyncRequired) {
+				return err, nil
+			} else if err != nil {
+				return nil, err
+			} else {
+				link := nextLink
+				items, nextLink = page(list)
+				if err := emit(items, link); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+// fetchPage requests the page at link, a nextLink reported by the service
+func fetchPage[L any](ctx context.Context, api rest.RestClient, link string) (L, error) {
+	var list L
+	if url, err := url.Parse(link); err != nil {
+		return list, err
+	} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
+		return list, err
+	} else if res, err := api.Send(req); err != nil {
+		return list, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &list); err != nil {
+		return list, err
+	} else {
+		return list, nil
+	}
+}
+
+// directoryObjectId extracts the id of a raw directory object to use as a listPages key
+func directoryObjectId(raw json.RawMessage) string {
+	var object struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return ""
+	} else {
+		return object.Id
+	}
+}
+
 type azureClient struct {
 	msgraph         rest.RestClient
 	resourceManager rest.RestClient
 	tenant          azure.Tenant
+
+	// resolves the principal type of Azure role assignments that don't report it; nil to leave them as reported
+	principalTypes *principalTypeCache
 }
 
 func (s azureClient) TenantInfo() azure.Tenant {
 	return s.tenant
 }
 
+// GraphAuthorization returns the Authorization header sent with requests to Graph, signing in first if needed
+func (s azureClient) GraphAuthorization() (string, error) {
+	if source, ok := s.msgraph.(rest.TokenSource); !ok {
+		return "", fmt.Errorf("the graph client does not expose its token")
+	} else {
+		return source.Authorization()
+	}
+}
+
 type AzureClient interface {
 	GetAzureADApp(ctx context.Context, objectId string, selectCols []string) (*azure.Application, error)
 	GetAzureADApps(ctx context.Context, filter, search, orderBy, expand string, selectCols []string, top int32, count bool) (azure.ApplicationList, error)
+	GetAzureADAuthorizationPolicy(ctx context.Context) (*azure.AuthorizationPolicy, error)
+	GetAzureADCrossTenantAccessPolicyDefault(ctx context.Context) (*azure.CrossTenantAccessPolicyConfigurationDefault, error)
+	GetAzureADCrossTenantAccessPolicyPartners(ctx context.Context) (azure.CrossTenantAccessPolicyConfigurationPartnerList, error)
+	GetAzureADDelegatedAdminAccessAssignments(ctx context.Context, relationshipId string) (azure.DelegatedAdminAccessAssignmentList, error)
+	GetAzureADDelegatedAdminRelationships(ctx context.Context, filter string, top int32) (azure.DelegatedAdminRelationshipList, error)
 	GetAzureADDirectoryObject(ctx context.Context, objectId string) (json.RawMessage, error)
 	GetAzureADGroup(ctx context.Context, objectId string, selectCols []string) (*azure.Group, error)
 	GetAzureADGroupEligibilityScheduleInstance(ctx context.Context, objectId string, selectCols []string) (*azure.PrivilegedAccessGroupEligibilityScheduleInstance, error)
+	GetAzureADGroupLifecyclePolicies(ctx context.Context) (azure.GroupLifecyclePolicyList, error)
 	GetAzureADGroupEligibilityScheduleInstances(ctx context.Context, filter, search, orderBy, expand string, selectCols []string, top int32, count bool) (azure.PrivilegedAccessGroupEligibilityScheduleInstanceList, error)
 	GetAzureADGroupOwners(ctx context.Context, objectId string, filter string, search string, orderBy string, selectCols []string, top int32, count bool) (azure.DirectoryObjectList, error)
+	GetAzureADGroupSettings(ctx context.Context) (azure.GroupSettingList, error)
 	GetAzureADGroups(ctx context.Context, filter, search, orderBy, expand string, selectCols []string, top int32, count bool) (azure.GroupList, error)
+	GetAzureADNamedLocations(ctx context.Context, filter string, top int32) (azure.NamedLocationList, error)
+	GetAzureADOAuth2PermissionGrants(ctx context.Context, filter string, top int32) (azure.OAuth2PermissionGrantList, error)
 	GetAzureADOrganization(ctx context.Context, selectCols []string) (*azure.Organization, error)
 	GetAzureADRole(ctx context.Context, roleId string, selectCols []string) (*azure.Role, error)
 	GetAzureADRoleAssignment(ctx context.Context, objectId string, selectCols []string) (*azure.UnifiedRoleAssignment, error)
@@ -119,6 +377,7 @@ type AzureClient interface {
 	GetAzureADServicePrincipals(ctx context.Context, filter, search, orderBy, expand string, selectCols []string, top int32, count bool) (azure.ServicePrincipalList, error)
 	GetAzureADTenants(ctx context.Context, includeAllTenantCategories bool) (azure.TenantList, error)
 	GetAzureADUser(ctx context.Context, objectId string, selectCols []string) (*azure.User, error)
+	GetAzureADUserAuthenticationMethods(ctx context.Context, userId string) (azure.AuthenticationMethodList, error)
 	GetAzureADUsers(ctx context.Context, filter string, search string, orderBy string, selectCols []string, top int32, count bool) (azure.UserList, error)
 	GetAzureDevice(ctx context.Context, objectId string, selectCols []string) (*azure.Device, error)
 	GetAzureDevices(ctx context.Context, filter, search, orderBy, expand string, selectCols []string, top int32, count bool) (azure.DeviceList, error)
@@ -128,38 +387,53 @@ type AzureClient interface {
 	GetAzureManagementGroups(ctx context.Context) (azure.ManagementGroupList, error)
 	GetAzureResourceGroup(ctx context.Context, subscriptionId, groupName string) (*azure.ResourceGroup, error)
 	GetAzureResourceGroups(ctx context.Context, subscriptionId string, filter string, top int32) (azure.ResourceGroupList, error)
+	GetAzureResourceGraph(ctx context.Context, request azure.ResourceGraphRequest) (azure.ResourceGraphResponse, error)
+	GetAzureResources(ctx context.Context, subscriptionId, expand string, top int32) (azure.GenericResourceList, error)
 	GetAzureSubscription(ctx context.Context, objectId string) (*azure.Subscription, error)
 	GetAzureSubscriptions(ctx context.Context) (azure.SubscriptionList, error)
 	GetAzureVirtualMachine(ctx context.Context, subscriptionId, groupName, vmName, expand string) (*azure.VirtualMachine, error)
 	GetAzureVirtualMachines(ctx context.Context, subscriptionId string, statusOnly bool) (azure.VirtualMachineList, error)
 	GetAzureStorageAccount(ctx context.Context, subscriptionId, groupName, saName, expand string) (*azure.StorageAccount, error)
 	GetAzureStorageAccounts(ctx context.Context, subscriptionId string) (azure.StorageAccountList, error)
+	GetAzureWebAppAuthSettings(ctx context.Context, siteId string) (*azure.WebAppAuthSettings, error)
 	GetResourceRoleAssignments(ctx context.Context, subscriptionId string, filter string, expand string) (azure.RoleAssignmentList, error)
 	GetRoleAssignmentsForResource(ctx context.Context, resourceId string, filter string) (azure.RoleAssignmentList, error)
 	ListAzureADAppMemberObjects(ctx context.Context, objectId string, securityEnabledOnly bool) <-chan azure.MemberObjectResult
 	ListAzureADAppOwners(ctx context.Context, objectId string, filter, search, orderBy string, selectCols []string) <-chan azure.AppOwnerResult
 	ListAzureADApps(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.ApplicationResult
+	ListAzureADCrossTenantAccessPolicyPartners(ctx context.Context) <-chan azure.CrossTenantAccessPolicyConfigurationPartnerResult
+	ListAzureADDelegatedAdminAccessAssignments(ctx context.Context, relationshipId string) <-chan azure.DelegatedAdminAccessAssignmentResult
+	ListAzureADDelegatedAdminRelationships(ctx context.Context, filter string) <-chan azure.DelegatedAdminRelationshipResult
+	ListAzureADGroupLifecyclePolicies(ctx context.Context) <-chan azure.GroupLifecyclePolicyResult
 	ListAzureADGroupMembers(ctx context.Context, objectId string, filter, search, orderBy string, selectCols []string) <-chan azure.MemberObjectResult
 	ListAzureADGroupOwners(ctx context.Context, objectId string, filter, search, orderBy string, selectCols []string) <-chan azure.GroupOwnerResult
+	ListAzureADGroupSettings(ctx context.Context) <-chan azure.GroupSettingResult
 	ListAzureADGroups(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.GroupResult
 	ListAzureADGroupEligibilityScheduleInstances(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.PrivilegedAccessGroupEligibilityScheduleInstanceResult
+	ListAzureADNamedLocations(ctx context.Context, filter string) <-chan azure.NamedLocationResult
+	ListAzureADOAuth2PermissionGrants(ctx context.Context, filter string) <-chan azure.OAuth2PermissionGrantResult
 	ListAzureADRoleAssignments(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.UnifiedRoleAssignmentResult
 	ListAzureADRoleEligibilityScheduleInstances(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.UnifiedRoleEligibilityScheduleInstanceResult
 	ListAzureADRoles(ctx context.Context, filter, expand string) <-chan azure.RoleResult
 	ListAzureADServicePrincipalOwners(ctx context.Context, objectId string, filter, search, orderBy string, selectCols []string) <-chan azure.ServicePrincipalOwnerResult
 	ListAzureADServicePrincipals(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.ServicePrincipalResult
 	ListAzureADTenants(ctx context.Context, includeAllTenantCategories bool) <-chan azure.TenantResult
+	ListAzureADUserAuthenticationMethods(ctx context.Context, userId string) <-chan azure.UserAuthenticationMethodResult
 	ListAzureADUsers(ctx context.Context, filter string, search string, orderBy string, selectCols []string) <-chan azure.UserResult
 	ListAzureContainerRegistries(ctx context.Context, subscriptionId string) <-chan azure.ContainerRegistryResult
 	ListAzureWebApps(ctx context.Context, subscriptionId string) <-chan azure.WebAppResult
 	ListAzureManagedClusters(ctx context.Context, subscriptionId string, statusOnly bool) <-chan azure.ManagedClusterResult
 	ListAzureVMScaleSets(ctx context.Context, subscriptionId string, statusOnly bool) <-chan azure.VMScaleSetResult
 	ListAzureDeviceRegisteredOwners(ctx context.Context, objectId string, securityEnabledOnly bool) <-chan azure.DeviceRegisteredOwnerResult
+	ListAzureDeviceRegisteredUsers(ctx context.Context, objectId string) <-chan azure.DeviceRegisteredUserResult
 	ListAzureDevices(ctx context.Context, filter, search, orderBy, expand string, selectCols []string) <-chan azure.DeviceResult
 	ListAzureKeyVaults(ctx context.Context, subscriptionId string, top int32) <-chan azure.KeyVaultResult
 	ListAzureManagementGroupDescendants(ctx context.Context, groupId string) <-chan azure.DescendantInfoResult
 	ListAzureManagementGroups(ctx context.Context) <-chan azure.ManagementGroupResult
+	ListAzureRegistrationAssignments(ctx context.Context, subscriptionId string) <-chan azure.RegistrationAssignmentResult
 	ListAzureResourceGroups(ctx context.Context, subscriptionId, filter string) <-chan azure.ResourceGroupResult
+	ListAzureResources(ctx context.Context, subscriptionId, expand string) <-chan azure.GenericResourceResult
+	ListAzureResourceGraph(ctx context.Context, subscriptionIds, managementGroupIds []string, query string) <-chan azure.ResourceGraphResult
 	ListAzureSubscriptions(ctx context.Context) <-chan azure.SubscriptionResult
 	ListAzureVirtualMachines(ctx context.Context, subscriptionId string, statusOnly bool) <-chan azure.VirtualMachineResult
 	ListAzureStorageAccounts(ctx context.Context, subscriptionId string) <-chan azure.StorageAccountResult
@@ -171,4 +445,5 @@ type AzureClient interface {
 	ListRoleAssignmentsForResource(ctx context.Context, resourceId string, filter string) <-chan azure.RoleAssignmentResult
 	ListAzureADAppRoleAssignments(ctx context.Context, servicePrincipal, filter, search, orderBy, expand string, selectCols []string) <-chan azure.AppRoleAssignmentResult
 	TenantInfo() azure.Tenant
+	GraphAuthorization() (string, error)
 }
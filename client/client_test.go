@@ -0,0 +1,492 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/client/rest/mocks"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/golang/mock/gomock"
+)
+
+func response(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+const (
+	firstUserPage  = `{"value":[{"id":"1"},{"id":"2"}],"@odata.nextLink":"https://graph.microsoft.com/v1.0/users?$skiptoken=a"}`
+	secondUserPage = `{"value":[{"id":"3"}]}`
+)
+
+func TestListAzureADUsersResync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	gomock.InOrder(
+		mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil),
+		mockRestClient.EXPECT().Send(gomock.Any()).Return(nil, rest.ResyncRequiredError{StatusCode: http.StatusGone, Code: "resyncRequired"}),
+		mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil),
+		mockRestClient.EXPECT().Send(gomock.Any()).Return(response(secondUserPage), nil),
+	)
+
+	before := ResyncRestarts()
+	ids := []string{}
+	for result := range client.ListAzureADUsers(context.Background(), "", "", "", nil) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			ids = append(ids, result.Ok.Id)
+		}
+	}
+
+	if actual := strings.Join(ids, ","); actual != "1,2,3" {
+		t.Errorf("got %v, want %v", actual, "1,2,3")
+	}
+
+	if actual := ResyncRestarts() - before; actual != 1 {
+		t.Errorf("got %v, want %v", actual, 1)
+	}
+}
+
+func TestListAzureADUsersResyncLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _, _, _ interface{}) (*http.Response, error) {
+		return response(firstUserPage), nil
+	}).Times(maxResyncRestarts + 1)
+	mockRestClient.EXPECT().Send(gomock.Any()).Return(nil, rest.ResyncRequiredError{StatusCode: http.StatusGone}).Times(maxResyncRestarts + 1)
+
+	var (
+		count   = 0
+		lastErr error
+	)
+	for result := range client.ListAzureADUsers(context.Background(), "", "", "", nil) {
+		if result.Error != nil {
+			lastErr = result.Error
+		} else {
+			count++
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("got %v, want %v", count, 2)
+	}
+
+	if !errors.Is(lastErr, rest.ErrResyncRequired) {
+		t.Errorf("got %v, want %v", lastErr, rest.ErrResyncRequired)
+	}
+}
+
+func TestListAzureADUsersCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		mockRestClient = mocks.NewMockRestClient(ctrl)
+		client         = &azureClient{msgraph: mockRestClient}
+		ctx, cancel    = context.WithCancel(context.Background())
+		reader, writer = io.Pipe()
+	)
+	defer cancel()
+
+	// the second page never finishes so the enumeration is cancelled mid-decode
+	go func() {
+		writer.Write([]byte(`{"value":[`))
+		for {
+			if _, err := writer.Write([]byte(`{"id":"00000000-0000-0000-0000-000000000000"},`)); err != nil {
+				return
+			}
+		}
+	}()
+	defer reader.Close()
+
+	mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil)
+	mockRestClient.EXPECT().Send(gomock.Any()).Return(&http.Response{StatusCode: http.StatusOK, Body: reader}, nil)
+
+	var (
+		results   = client.ListAzureADUsers(ctx, "", "", "", nil)
+		count     = 0
+		lastErr   error
+		cancelled time.Time
+	)
+	for result := range results {
+		if result.Error != nil {
+			lastErr = result.Error
+		} else if count++; count == 2 {
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				cancelled = time.Now()
+				cancel()
+			}()
+		}
+	}
+
+	if elapsed := time.Since(cancelled); elapsed > 250*time.Millisecond {
+		t.Errorf("enumeration took %v to return after cancellation", elapsed)
+	}
+	if !errors.Is(lastErr, context.Canceled) || !errors.Is(lastErr, rest.ErrAborted) {
+		t.Errorf("got %v, want %v", lastErr, rest.ErrAborted)
+	}
+}
+
+func TestListAzureADUsersCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	gomock.InOrder(
+		mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil),
+		mockRestClient.EXPECT().Send(gomock.Any()).Return(response(secondUserPage), nil),
+	)
+
+	var recorded []Cursor
+	ctx := WithCursor(context.Background(), Cursor{}, func(cursor Cursor) { recorded = append(recorded, cursor) })
+	for result := range client.ListAzureADUsers(ctx, "", "", "", nil) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+	}
+
+	want := []Cursor{{NextLink: "https://graph.microsoft.com/v1.0/users?$skiptoken=a", LastId: "2"}, {Complete: true}}
+	if len(recorded) != len(want) {
+		t.Fatalf("got %v, want %v", recorded, want)
+	}
+	for i := range want {
+		if recorded[i] != want[i] {
+			t.Errorf("got %v, want %v", recorded[i], want[i])
+		}
+	}
+}
+
+func TestListAzureADUsersLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	// the limit is reached on the first page so the nextLink is never requested
+	mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil)
+
+	var (
+		ids      = []string{}
+		recorded []Cursor
+	)
+	ctx := WithCursor(WithLimit(context.Background(), 2), Cursor{}, func(cursor Cursor) { recorded = append(recorded, cursor) })
+	for result := range client.ListAzureADUsers(ctx, "", "", "", nil) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			ids = append(ids, result.Ok.Id)
+		}
+	}
+
+	if actual := strings.Join(ids, ","); actual != "1,2" {
+		t.Errorf("got %v, want %v", actual, "1,2")
+	}
+	for _, cursor := range recorded {
+		if cursor.Complete {
+			t.Error("expected a limited enumeration not to be marked complete")
+		}
+	}
+}
+
+func TestListAzureADUsersResumeNextLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	mockRestClient.EXPECT().Send(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("$skiptoken") != "a" {
+			t.Errorf("got request %v, want the checkpointed nextLink", req.URL)
+		}
+		return response(secondUserPage), nil
+	})
+
+	ids := []string{}
+	ctx := WithCursor(context.Background(), Cursor{NextLink: "https://graph.microsoft.com/v1.0/users?$skiptoken=a", LastId: "2"}, nil)
+	for result := range client.ListAzureADUsers(ctx, "", "", "", nil) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			ids = append(ids, result.Ok.Id)
+		}
+	}
+
+	if actual := strings.Join(ids, ","); actual != "3" {
+		t.Errorf("got %v, want %v", actual, "3")
+	}
+}
+
+func TestListAzureADUsersResumeSkipAhead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	// the checkpointed nextLink has expired so the enumeration skips ahead from the first page
+	gomock.InOrder(
+		mockRestClient.EXPECT().Send(gomock.Any()).Return(nil, rest.ResyncRequiredError{StatusCode: http.StatusGone}),
+		mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil),
+		mockRestClient.EXPECT().Send(gomock.Any()).Return(response(secondUserPage), nil),
+	)
+
+	ids := []string{}
+	ctx := WithCursor(context.Background(), Cursor{NextLink: "https://graph.microsoft.com/v1.0/users?$skiptoken=expired", LastId: "1"}, nil)
+	for result := range client.ListAzureADUsers(ctx, "", "", "", nil) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			ids = append(ids, result.Ok.Id)
+		}
+	}
+
+	if actual := strings.Join(ids, ","); actual != "2,3" {
+		t.Errorf("got %v, want %v", actual, "2,3")
+	}
+}
+
+func TestListAzureADUsersResumeNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	gomock.InOrder(
+		mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstUserPage), nil),
+		mockRestClient.EXPECT().Send(gomock.Any()).Return(response(secondUserPage), nil),
+	)
+
+	var (
+		count   = 0
+		lastErr error
+	)
+	ctx := WithCursor(context.Background(), Cursor{LastId: "4"}, nil)
+	for result := range client.ListAzureADUsers(ctx, "", "", "", nil) {
+		if result.Error != nil {
+			lastErr = result.Error
+		} else {
+			count++
+		}
+	}
+
+	if count != 0 {
+		t.Errorf("got %v, want %v", count, 0)
+	} else if lastErr == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+// unsignedToken returns an access token carrying claims; signatures aren't verified by the client
+func unsignedToken(t *testing.T, claims map[string]interface{}) string {
+	if payload, err := json.Marshal(claims); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+	}
+}
+
+func TestListAzureADGroupLifecyclePoliciesEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{msgraph: mockRestClient}
+
+	// tenants without a policy answer with an empty list, or with nothing at all
+	for _, body := range []string{`{"value":[]}`, `{}`, ""} {
+		mockRestClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(body), nil)
+		for result := range client.ListAzureADGroupLifecyclePolicies(context.Background()) {
+			if result.Error != nil {
+				t.Errorf("%q: unexpected error: %v", body, result.Error)
+			} else {
+				t.Errorf("%q: got policy %+v, want none", body, result.Ok)
+			}
+		}
+	}
+}
+
+func TestNewClientFromTokens(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		headers = map[string]string{}
+	)
+	newServer := func(path, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			headers[r.URL.Path] = r.Header.Get("Authorization")
+			if r.URL.Path != path {
+				t.Errorf("unexpected request: %v", r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				w.Write([]byte(body))
+			}
+		}))
+	}
+	graph := newServer("/v1.0/organization", `{"value":[{"id":"tenant","displayName":"Tenant"}]}`)
+	defer graph.Close()
+	resourceManager := newServer("/tenants", `{"value":[{"tenantId":"tenant","displayName":"Tenant"}]}`)
+	defer resourceManager.Close()
+
+	var (
+		expires    = float64(time.Now().Add(time.Hour).Unix())
+		graphToken = unsignedToken(t, map[string]interface{}{"aud": graph.URL, "tid": "tenant", "exp": expires})
+		armToken   = unsignedToken(t, map[string]interface{}{"aud": resourceManager.URL + "/", "tid": "tenant", "exp": expires})
+		cfg        = config.Config{Graph: graph.URL, Management: resourceManager.URL, ClientSecret: "ignored"}
+	)
+
+	// only the arm token is supplied; the tenant comes from the token
+	cfg.ArmToken = armToken
+	if client, err := NewClient(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if client.TenantInfo().TenantId != "tenant" {
+		t.Errorf("got %v, want %v", client.TenantInfo().TenantId, "tenant")
+	} else if headers["/tenants"] != "Bearer "+armToken {
+		t.Errorf("unexpected authorization: %v", headers["/tenants"])
+	} else if _, err := client.GetAzureADOrganization(context.Background(), nil); err == nil {
+		t.Error("expected graph requests to fail without a graph token")
+	}
+
+	cfg.GraphToken = graphToken
+	if client, err := NewClient(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if client.TenantInfo().TenantId != "tenant" {
+		t.Errorf("got %v, want %v", client.TenantInfo().TenantId, "tenant")
+	} else if headers["/v1.0/organization"] != "Bearer "+graphToken {
+		t.Errorf("unexpected authorization: %v", headers["/v1.0/organization"])
+	}
+}
+
+func TestSendTokenExpired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %v", r.URL)
+	}))
+	defer server.Close()
+
+	// the token expires after the client was created, mid-run
+	token := unsignedToken(t, map[string]interface{}{"aud": server.URL, "exp": float64(time.Now().Add(-time.Minute).Unix())})
+	if client, err := rest.NewRestClient(server.URL, config.Config{JWT: token}); err != nil {
+		t.Fatal(err)
+	} else if _, err := client.Get(context.Background(), "/v1.0/users", nil, nil); !errors.Is(err, rest.ErrTokenExpired) {
+		t.Errorf("got %v, want %v", err, rest.ErrTokenExpired)
+	}
+}
+
+func TestNewClientFromTokensInvalid(t *testing.T) {
+	var (
+		future = float64(time.Now().Add(time.Hour).Unix())
+		past   = float64(time.Now().Add(-time.Hour).Unix())
+	)
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+	}{
+		{"expired", config.Config{Graph: "https://graph.microsoft.com", GraphToken: unsignedToken(t, map[string]interface{}{"aud": "https://graph.microsoft.com", "exp": past})}},
+		{"wrong audience", config.Config{Graph: "https://graph.microsoft.com", GraphToken: unsignedToken(t, map[string]interface{}{"aud": "https://management.azure.com", "exp": future})}},
+		{"no tenant", config.Config{Management: "https://management.azure.com", ArmToken: unsignedToken(t, map[string]interface{}{"aud": "https://management.azure.com", "exp": future})}},
+		{"combined with jwt", config.Config{JWT: "jwt", ArmToken: "token"}},
+		{"malformed", config.Config{GraphToken: "not a token"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewClient(test.cfg); err == nil {
+				t.Error("expected an error but did not receive one")
+			}
+		})
+	}
+}
+
+func TestNewClientEndpointOverrides(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests = map[string]string{}
+	)
+	newServer := func(name, path, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			requests[name] = r.URL.Path
+			if r.URL.Path != path {
+				t.Errorf("unexpected request to %s: %v", name, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				w.Write([]byte(body))
+			}
+		}))
+	}
+	authority := newServer("authority", "/tenant/oauth2/v2.0/token", `{"access_token":"foo","expires_in":3599,"token_type":"Bearer"}`)
+	defer authority.Close()
+	graph := newServer("graph", "/v1.0/organization", `{"value":[{"id":"tenant","displayName":"Tenant"}]}`)
+	defer graph.Close()
+	resourceManager := newServer("resource manager", "/subscriptions", `{"value":[]}`)
+	defer resourceManager.Close()
+
+	// the overrides take precedence over the endpoints of the region
+	cfg := config.Config{
+		ApplicationId:      "app",
+		AuthorityOverride:  authority.URL,
+		ClientSecret:       "secret",
+		GraphOverride:      graph.URL + "/",
+		ManagementOverride: resourceManager.URL,
+		Region:             constants.China,
+		Tenant:             "tenant",
+	}
+
+	if client, err := NewClient(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if client.TenantInfo().TenantId != "tenant" {
+		t.Errorf("got %v, want %v", client.TenantInfo().TenantId, "tenant")
+	} else if _, err := client.GetAzureSubscriptions(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(requests) != 3 {
+		t.Errorf("got requests %v, want requests to the authority, graph and resource manager overrides", requests)
+	}
+}
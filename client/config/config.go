@@ -18,29 +18,64 @@
 package config
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bloodhoundad/azurehound/v2/constants"
 )
 
 type Config struct {
-	ApplicationId  string   // The Application Id that the  Azure app registration portal assigned when the app was registered.
-	Authority      string   // The Azure ActiveDirectory Authority URL
-	ClientSecret   string   // The Application Secret that was generated for the app in the app registration portal.
-	ClientCert     string   // The certificate uploaded to the app registration portal."
-	ClientKey      string   // The key for a certificate uploaded to the app registration portal."
-	ClientKeyPass  string   // The passphrase to use in conjuction with the associated key of a certificate uploaded to the app registration portal."
-	Graph          string   // The Microsoft Graph URL
-	JWT            string   // The JSON web token that will be used to authenticate requests sent to Azure APIs
-	Management     string   // The Azure ResourceManager URL
-	MgmtGroupId    []string // The Management Group Id to use as a filter
-	Password       string   // The password associated with the user principal name associated with the Azure portal.
-	ProxyUrl       string   // The forward proxy url
-	RefreshToken   string   // The refresh token that will be used to authenticate requests sent to Azure APIs
-	Region         string   // The region of the Azure Cloud deployment.
-	SubscriptionId []string // The Subscription Id(s) to use as a filter
-	Tenant         string   // The directory tenant that you want to request permission from. This can be in GUID or friendly name format
-	Username       string   // The user principal name associated with the Azure portal.
+	ApplicationId           string        // The Application Id that the  Azure app registration portal assigned when the app was registered.
+	Authority               string        // The Azure ActiveDirectory Authority URL
+	AuthorityOverride       string        // Replaces the Authority URL of the region, e.g. for Azure Stack Hub
+	AzureCli                bool          // Authenticate using the signed in Azure CLI session
+	CacheDir                string        // A directory GET responses are cached in between runs; for development and testing only
+	CacheTTL                time.Duration // How long cached responses are served before they are requested again
+	ClientAssertion         string        // A signed JWT used in place of a secret or certificate, e.g. a federated workload identity token
+	ClientAssertionFile     string        // The path to a file containing a client assertion; read on every token request
+	ClientSecret            string        // The Application Secret that was generated for the app in the app registration portal.
+	ClientCert              string        // The certificate uploaded to the app registration portal."
+	ClientKey               string        // The key for a certificate uploaded to the app registration portal."
+	ClientKeyPass           string        // The passphrase to use in conjuction with the associated key of a certificate uploaded to the app registration portal."
+	Graph                   string        // The Microsoft Graph URL
+	GraphOverride           string        // Replaces the Microsoft Graph URL of the region, e.g. for an internal API gateway
+	GraphToken              string        // An access token for Microsoft Graph used as-is, bypassing authentication
+	HTTPTimeout             time.Duration // The timeout of each request to the Azure APIs; zero for no timeout
+	ArmToken                string        // An access token for Azure Resource Manager used as-is, bypassing authentication
+	JWT                     string        // The JSON web token that will be used to authenticate requests sent to Azure APIs
+	Management              string        // The Azure ResourceManager URL
+	ManagementOverride      string        // Replaces the Azure ResourceManager URL of the region, e.g. for Azure Stack Hub
+	ManagedIdentity         bool          // Authenticate using the managed identity of the Azure host
+	ManagedIdentityClientId string        // The client id of the user-assigned managed identity to authenticate with
+	MaxRetries              int           // The number of times a throttled, failed or dropped request to the Azure APIs is retried
+	MgmtGroupId             []string      // The Management Group Id to use as a filter
+	Password                string        // The password associated with the user principal name associated with the Azure portal.
+	ProxyUrl                string        // The forward proxy url
+	RefreshToken            string        // The refresh token that will be used to authenticate requests sent to Azure APIs
+	Region                  string        // The region of the Azure Cloud deployment.
+	RetryMaxBackoff         time.Duration // The longest backoff between retries of a request; zero for no limit
+	SubscriptionId          []string      // The Subscription Id(s) to use as a filter
+	Tenant                  string        // The directory tenant that you want to request permission from. This can be in GUID or friendly name format
+	TokenCache              string        // The path to a file that acquired tokens are cached in between runs
+	TokenCachePassphrase    string        // The passphrase the token cache is encrypted with; DPAPI is used on Windows if empty
+	Username                string        // The user principal name associated with the Azure portal.
+}
+
+// CloudRegion returns the deployment region that serves the named Azure cloud environment
+func CloudRegion(cloud string) (string, error) {
+	switch cloud {
+	case constants.CloudChina:
+		return constants.China, nil
+	case constants.CloudPublic:
+		return constants.Cloud, nil
+	case constants.CloudUSGov:
+		return constants.USGovL4, nil
+	case constants.CloudUSGovDoD:
+		return constants.USGovL5, nil
+	default:
+		return "", fmt.Errorf("unsupported cloud environment: %s", cloud)
+	}
 }
 
 func AuthorityUrl(region string, defaultUrl string) string {
@@ -61,6 +96,9 @@ func AuthorityUrl(region string, defaultUrl string) string {
 }
 
 func (s Config) AuthorityUrl() string {
+	if s.AuthorityOverride != "" {
+		return s.AuthorityOverride
+	}
 	return AuthorityUrl(s.Region, s.Authority)
 }
 
@@ -82,6 +120,9 @@ func GraphUrl(region string, defaultUrl string) string {
 }
 
 func (s Config) GraphUrl() string {
+	if s.GraphOverride != "" {
+		return strings.TrimSuffix(s.GraphOverride, "/")
+	}
 	return strings.TrimSuffix(GraphUrl(s.Region, s.Graph), "/")
 }
 
@@ -103,5 +144,8 @@ func ResourceManagerUrl(region string, defaultUrl string) string {
 }
 
 func (s Config) ResourceManagerUrl() string {
-	return strings.TrimSuffix(ResourceManagerUrl(s.Region, s.Graph), "/")
+	if s.ManagementOverride != "" {
+		return strings.TrimSuffix(s.ManagementOverride, "/")
+	}
+	return strings.TrimSuffix(ResourceManagerUrl(s.Region, s.Management), "/")
 }
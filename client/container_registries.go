@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureContainerRegistry(ctx context.Context, subscriptio
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureContainerRegistries(ctx context.Context, subscript
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,46 +69,22 @@ func (s *azureClient) ListAzureContainerRegistries(ctx context.Context, subscrip
 			errResult = azure.ContainerRegistryResult{
 				SubscriptionId: subscriptionId,
 			}
-			nextLink string
 		)
 
-		if result, err := s.GetAzureContainerRegistries(ctx, subscriptionId); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.ContainerRegistryList, error) {
+				return s.GetAzureContainerRegistries(ctx, subscriptionId)
+			},
+			func(list azure.ContainerRegistryList) ([]azure.ContainerRegistry, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.ContainerRegistry) string { return u.Id },
+			func(u azure.ContainerRegistry) {
+				out <- azure.ContainerRegistryResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.ContainerRegistryResult{SubscriptionId: subscriptionId, Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.ContainerRegistryList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ContainerRegistryResult{
-							SubscriptionId: "/subscriptions/" + subscriptionId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
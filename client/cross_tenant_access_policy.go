@@ -0,0 +1,88 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureADCrossTenantAccessPolicyDefault(ctx context.Context) (*azure.CrossTenantAccessPolicyConfigurationDefault, error) {
+	var (
+		path     = fmt.Sprintf("/%s/policies/crossTenantAccessPolicy/default", constants.GraphApiVersion)
+		response azure.CrossTenantAccessPolicyConfigurationDefault
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, nil); err != nil {
+		return nil, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return nil, err
+	} else {
+		return &response, nil
+	}
+}
+
+func (s *azureClient) GetAzureADCrossTenantAccessPolicyPartners(ctx context.Context) (azure.CrossTenantAccessPolicyConfigurationPartnerList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/policies/crossTenantAccessPolicy/partners", constants.GraphApiVersion)
+		response azure.CrossTenantAccessPolicyConfigurationPartnerList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, nil); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+// ListAzureADCrossTenantAccessPolicyPartners lists the partner tenants that have cross-tenant access settings of
+// their own; tenants without any emit nothing
+func (s *azureClient) ListAzureADCrossTenantAccessPolicyPartners(ctx context.Context) <-chan azure.CrossTenantAccessPolicyConfigurationPartnerResult {
+	out := make(chan azure.CrossTenantAccessPolicyConfigurationPartnerResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.CrossTenantAccessPolicyConfigurationPartnerResult{}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.CrossTenantAccessPolicyConfigurationPartnerList, error) {
+				return s.GetAzureADCrossTenantAccessPolicyPartners(ctx)
+			},
+			func(list azure.CrossTenantAccessPolicyConfigurationPartnerList) ([]azure.CrossTenantAccessPolicyConfigurationPartner, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.CrossTenantAccessPolicyConfigurationPartner) string { return u.TenantId },
+			func(u azure.CrossTenantAccessPolicyConfigurationPartner) {
+				out <- azure.CrossTenantAccessPolicyConfigurationPartnerResult{Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import "context"
+
+// Cursor is a position within a paged enumeration from which it can be resumed
+type Cursor struct {
+	// NextLink requests the page to resume from
+	NextLink string `json:"nextLink,omitempty"`
+	// LastId is the key of the last item before that page. It identifies where to resume from when the service no
+	// longer accepts NextLink and the enumeration has to skip ahead from the first page instead.
+	LastId string `json:"lastId,omitempty"`
+	// Complete reports that the enumeration ran to the end
+	Complete bool `json:"complete,omitempty"`
+}
+
+type cursorKey struct{}
+
+type cursorOptions struct {
+	start  Cursor
+	record func(Cursor)
+}
+
+// WithCursor returns a context under which a paged enumeration resumes from start, or begins from the first page when
+// start is empty, and calls record with the cursor to resume from after every page. Only pass it to a single List
+// call; nested enumerations would share the cursor.
+func WithCursor(ctx context.Context, start Cursor, record func(Cursor)) context.Context {
+	return context.WithValue(ctx, cursorKey{}, cursorOptions{start: start, record: record})
+}
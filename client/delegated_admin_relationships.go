@@ -0,0 +1,121 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/query"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureADDelegatedAdminRelationships(ctx context.Context, filter string, top int32) (azure.DelegatedAdminRelationshipList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/tenantRelationships/delegatedAdminRelationships", constants.GraphApiVersion)
+		params   = query.Params{Filter: filter, Top: top}.AsMap()
+		headers  map[string]string
+		response azure.DelegatedAdminRelationshipList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, params, headers); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureADDelegatedAdminRelationships(ctx context.Context, filter string) <-chan azure.DelegatedAdminRelationshipResult {
+	out := make(chan azure.DelegatedAdminRelationshipResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.DelegatedAdminRelationshipResult{}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DelegatedAdminRelationshipList, error) {
+				return s.GetAzureADDelegatedAdminRelationships(ctx, filter, 300)
+			},
+			func(list azure.DelegatedAdminRelationshipList) ([]azure.DelegatedAdminRelationship, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.DelegatedAdminRelationship) string { return u.Id },
+			func(u azure.DelegatedAdminRelationship) {
+				out <- azure.DelegatedAdminRelationshipResult{Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
+
+func (s *azureClient) GetAzureADDelegatedAdminAccessAssignments(ctx context.Context, relationshipId string) (azure.DelegatedAdminAccessAssignmentList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/tenantRelationships/delegatedAdminRelationships/%s/accessAssignments", constants.GraphApiVersion, relationshipId)
+		headers  map[string]string
+		response azure.DelegatedAdminAccessAssignmentList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, headers); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureADDelegatedAdminAccessAssignments(ctx context.Context, relationshipId string) <-chan azure.DelegatedAdminAccessAssignmentResult {
+	out := make(chan azure.DelegatedAdminAccessAssignmentResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.DelegatedAdminAccessAssignmentResult{
+				RelationshipId: relationshipId,
+			}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DelegatedAdminAccessAssignmentList, error) {
+				return s.GetAzureADDelegatedAdminAccessAssignments(ctx, relationshipId)
+			},
+			func(list azure.DelegatedAdminAccessAssignmentList) ([]azure.DelegatedAdminAccessAssignment, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.DelegatedAdminAccessAssignment) string { return u.Id },
+			func(u azure.DelegatedAdminAccessAssignment) {
+				out <- azure.DelegatedAdminAccessAssignmentResult{RelationshipId: relationshipId, Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
@@ -19,8 +19,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -37,7 +37,7 @@ func (s *azureClient) GetAzureDevice(ctx context.Context, objectId string, selec
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -52,7 +52,22 @@ func (s *azureClient) GetAzureDeviceRegisteredOwners(ctx context.Context, object
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) GetAzureDeviceRegisteredUsers(ctx context.Context, objectId string, filter, search string, count bool) (azure.DirectoryObjectList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/devices/%s/registeredUsers", constants.GraphApiBetaVersion, objectId)
+		params   = query.Params{Filter: filter, Search: search, Count: count}.AsMap()
+		response azure.DirectoryObjectList
+	)
+	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -74,7 +89,7 @@ func (s *azureClient) GetAzureDevices(ctx context.Context, filter, search, order
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -89,43 +104,20 @@ func (s *azureClient) ListAzureDevices(ctx context.Context, filter, search, orde
 
 		var (
 			errResult = azure.DeviceResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureDevices(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DeviceList, error) {
+				return s.GetAzureDevices(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.DeviceList) ([]azure.Device, string) { return list.Value, list.NextLink },
+			func(u azure.Device) string { return u.Id },
+			func(u azure.Device) {
+				out <- azure.DeviceResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.DeviceResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.DeviceList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.DeviceResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -141,49 +133,55 @@ func (s *azureClient) ListAzureDeviceRegisteredOwners(ctx context.Context, objec
 			errResult = azure.DeviceRegisteredOwnerResult{
 				DeviceId: objectId,
 			}
-			nextLink string
 		)
 
-		if list, err := s.GetAzureDeviceRegisteredOwners(ctx, objectId, "", "", false); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range list.Value {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DirectoryObjectList, error) {
+				return s.GetAzureDeviceRegisteredOwners(ctx, objectId, "", "", false)
+			},
+			func(list azure.DirectoryObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
 				out <- azure.DeviceRegisteredOwnerResult{
 					DeviceId: objectId,
 					Ok:       u,
 				}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
+
+func (s *azureClient) ListAzureDeviceRegisteredUsers(ctx context.Context, objectId string) <-chan azure.DeviceRegisteredUserResult {
+	out := make(chan azure.DeviceRegisteredUserResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.DeviceRegisteredUserResult{
+				DeviceId: objectId,
 			}
+		)
 
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.DirectoryObjectList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.DeviceRegisteredOwnerResult{
-							DeviceId: objectId,
-							Ok:       u,
-						}
-					}
-					nextLink = list.NextLink
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DirectoryObjectList, error) {
+				return s.GetAzureDeviceRegisteredUsers(ctx, objectId, "", "", false)
+			},
+			func(list azure.DirectoryObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
+				out <- azure.DeviceRegisteredUserResult{
+					DeviceId: objectId,
+					Ok:       u,
 				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureFunctionApp(ctx context.Context, subscriptionId, g
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureFunctionApps(ctx context.Context, subscriptionId s
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,46 +69,20 @@ func (s *azureClient) ListAzureFunctionApps(ctx context.Context, subscriptionId
 			errResult = azure.FunctionAppResult{
 				SubscriptionId: subscriptionId,
 			}
-			nextLink string
 		)
 
-		if result, err := s.GetAzureFunctionApps(ctx, subscriptionId); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.FunctionAppList, error) {
+				return s.GetAzureFunctionApps(ctx, subscriptionId)
+			},
+			func(list azure.FunctionAppList) ([]azure.FunctionApp, string) { return list.Value, list.NextLink },
+			func(u azure.FunctionApp) string { return u.Id },
+			func(u azure.FunctionApp) {
+				out <- azure.FunctionAppResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.FunctionAppResult{SubscriptionId: subscriptionId, Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.FunctionAppList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.FunctionAppResult{
-							SubscriptionId: "/subscriptions/" + subscriptionId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -37,7 +36,7 @@ func (s *azureClient) GetAzureADGroupEligibilityScheduleInstance(ctx context.Con
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -58,7 +57,7 @@ func (s *azureClient) GetAzureADGroupEligibilityScheduleInstances(ctx context.Co
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -73,43 +72,22 @@ func (s *azureClient) ListAzureADGroupEligibilityScheduleInstances(ctx context.C
 
 		var (
 			errResult = azure.PrivilegedAccessGroupEligibilityScheduleInstanceResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADGroupEligibilityScheduleInstances(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.PrivilegedAccessGroupEligibilityScheduleInstanceList, error) {
+				return s.GetAzureADGroupEligibilityScheduleInstances(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.PrivilegedAccessGroupEligibilityScheduleInstanceList) ([]azure.PrivilegedAccessGroupEligibilityScheduleInstance, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.PrivilegedAccessGroupEligibilityScheduleInstance) string { return u.Id },
+			func(u azure.PrivilegedAccessGroupEligibilityScheduleInstance) {
+				out <- azure.PrivilegedAccessGroupEligibilityScheduleInstanceResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.PrivilegedAccessGroupEligibilityScheduleInstanceResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.PrivilegedAccessGroupEligibilityScheduleInstanceList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.PrivilegedAccessGroupEligibilityScheduleInstanceResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
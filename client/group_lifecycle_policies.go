@@ -0,0 +1,77 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// GetAzureADGroupLifecyclePolicies gets the group expiration policy of the tenant. Tenants without one may answer
+// with an empty body, which is treated as an empty list.
+func (s *azureClient) GetAzureADGroupLifecyclePolicies(ctx context.Context) (azure.GroupLifecyclePolicyList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/groupLifecyclePolicies", constants.GraphApiVersion)
+		response azure.GroupLifecyclePolicyList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, nil); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); errors.Is(err, io.EOF) {
+		return azure.GroupLifecyclePolicyList{}, nil
+	} else if err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureADGroupLifecyclePolicies(ctx context.Context) <-chan azure.GroupLifecyclePolicyResult {
+	out := make(chan azure.GroupLifecyclePolicyResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.GroupLifecyclePolicyResult{}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.GroupLifecyclePolicyList, error) {
+				return s.GetAzureADGroupLifecyclePolicies(ctx)
+			},
+			func(list azure.GroupLifecyclePolicyList) ([]azure.GroupLifecyclePolicy, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.GroupLifecyclePolicy) string { return u.Id },
+			func(u azure.GroupLifecyclePolicy) {
+				out <- azure.GroupLifecyclePolicyResult{Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
@@ -19,8 +19,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -38,7 +38,7 @@ func (s *azureClient) GetAzureADGroup(ctx context.Context, objectId string, sele
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -53,7 +53,7 @@ func (s *azureClient) GetAzureADGroupOwners(ctx context.Context, objectId string
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -68,7 +68,7 @@ func (s *azureClient) GetAzureADGroupMembers(ctx context.Context, objectId strin
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -90,7 +90,7 @@ func (s *azureClient) GetAzureADGroups(ctx context.Context, filter, search, orde
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -105,43 +105,20 @@ func (s *azureClient) ListAzureADGroups(ctx context.Context, filter, search, ord
 
 		var (
 			errResult = azure.GroupResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADGroups(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.GroupList, error) {
+				return s.GetAzureADGroups(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.GroupList) ([]azure.Group, string) { return list.Value, list.NextLink },
+			func(u azure.Group) string { return u.Id },
+			func(u azure.Group) {
+				out <- azure.GroupResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.GroupResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.GroupList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.GroupResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -155,49 +132,23 @@ func (s *azureClient) ListAzureADGroupOwners(ctx context.Context, objectId strin
 
 		var (
 			errResult = azure.GroupOwnerResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADGroupOwners(ctx, objectId, filter, search, orderBy, selectCols, 999, false); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range list.Value {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DirectoryObjectList, error) {
+				return s.GetAzureADGroupOwners(ctx, objectId, filter, search, orderBy, selectCols, 999, false)
+			},
+			func(list azure.DirectoryObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
 				out <- azure.GroupOwnerResult{
 					GroupId: objectId,
 					Ok:      u,
 				}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.DirectoryObjectList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.GroupOwnerResult{
-							GroupId: objectId,
-							Ok:      u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -214,51 +165,24 @@ func (s *azureClient) ListAzureADGroupMembers(ctx context.Context, objectId stri
 				ParentId:   objectId,
 				ParentType: string(enums.EntityGroup),
 			}
-			nextLink string
 		)
 
-		if list, err := s.GetAzureADGroupMembers(ctx, objectId, filter, search, false); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range list.Value {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.MemberObjectList, error) {
+				return s.GetAzureADGroupMembers(ctx, objectId, filter, search, false)
+			},
+			func(list azure.MemberObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
 				out <- azure.MemberObjectResult{
 					ParentId:   objectId,
 					ParentType: string(enums.EntityGroup),
 					Ok:         u,
 				}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.MemberObjectList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.MemberObjectResult{
-							ParentId:   objectId,
-							ParentType: string(enums.EntityGroup),
-							Ok:         u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureKeyVault(ctx context.Context, subscriptionId, grou
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureKeyVaults(ctx context.Context, subscriptionId stri
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,49 +69,23 @@ func (s *azureClient) ListAzureKeyVaults(ctx context.Context, subscriptionId str
 			errResult = azure.KeyVaultResult{
 				SubscriptionId: subscriptionId,
 			}
-			nextLink string
 		)
 
-		if result, err := s.GetAzureKeyVaults(ctx, subscriptionId, top); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range result.Value {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.KeyVaultList, error) {
+				return s.GetAzureKeyVaults(ctx, subscriptionId, top)
+			},
+			func(list azure.KeyVaultList) ([]azure.KeyVault, string) { return list.Value, list.NextLink },
+			func(u azure.KeyVault) string { return u.Id },
+			func(u azure.KeyVault) {
 				out <- azure.KeyVaultResult{
 					SubscriptionId: subscriptionId,
 					Ok:             u,
 				}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.KeyVaultList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.KeyVaultResult{
-							SubscriptionId: subscriptionId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
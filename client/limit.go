@@ -0,0 +1,35 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+type limitKey struct{}
+
+// errLimitReached stops a paged enumeration once the limit from WithLimit has been emitted
+var errLimitReached = errors.New("limit reached")
+
+// WithLimit returns a context under which each paged enumeration stops after emitting limit items, without requesting
+// any further pages. A limit below 1 leaves enumerations unlimited. Unlike WithCursor it applies to every List call
+// made with the context.
+func WithLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, limitKey{}, limit)
+}
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureManagedCluster(ctx context.Context, subscriptionId
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureManagedClusters(ctx context.Context, subscriptionI
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,46 +69,20 @@ func (s *azureClient) ListAzureManagedClusters(ctx context.Context, subscription
 			errResult = azure.ManagedClusterResult{
 				SubscriptionId: subscriptionId,
 			}
-			nextLink string
 		)
 
-		if result, err := s.GetAzureManagedClusters(ctx, subscriptionId, statusOnly); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.ManagedClusterList, error) {
+				return s.GetAzureManagedClusters(ctx, subscriptionId, statusOnly)
+			},
+			func(list azure.ManagedClusterList) ([]azure.ManagedCluster, string) { return list.Value, list.NextLink },
+			func(u azure.ManagedCluster) string { return u.Id },
+			func(u azure.ManagedCluster) {
+				out <- azure.ManagedClusterResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.ManagedClusterResult{SubscriptionId: subscriptionId, Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.ManagedClusterList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ManagedClusterResult{
-							SubscriptionId: "/subscriptions/" + subscriptionId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
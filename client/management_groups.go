@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureManagementGroup(ctx context.Context, groupId, filt
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureManagementGroups(ctx context.Context) (azure.Manag
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -70,7 +69,7 @@ func (s *azureClient) GetAzureManagementGroupDescendants(ctx context.Context, gr
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -85,43 +84,22 @@ func (s *azureClient) ListAzureManagementGroups(ctx context.Context) <-chan azur
 
 		var (
 			errResult = azure.ManagementGroupResult{}
-			nextLink  string
 		)
 
-		if result, err := s.GetAzureManagementGroups(ctx); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.ManagementGroupList, error) {
+				return s.GetAzureManagementGroups(ctx)
+			},
+			func(list azure.ManagementGroupList) ([]azure.ManagementGroup, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.ManagementGroup) string { return u.Id },
+			func(u azure.ManagementGroup) {
+				out <- azure.ManagementGroupResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.ManagementGroupResult{Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.ManagementGroupList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ManagementGroupResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -135,43 +113,20 @@ func (s *azureClient) ListAzureManagementGroupDescendants(ctx context.Context, g
 
 		var (
 			errResult = azure.DescendantInfoResult{}
-			nextLink  string
 		)
 
-		if result, err := s.GetAzureManagementGroupDescendants(ctx, groupId, 3000); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.DescendantInfoList, error) {
+				return s.GetAzureManagementGroupDescendants(ctx, groupId, 3000)
+			},
+			func(list azure.DescendantInfoList) ([]azure.DescendantInfo, string) { return list.Value, list.NextLink },
+			func(u azure.DescendantInfo) string { return u.Id },
+			func(u azure.DescendantInfo) {
+				out <- azure.DescendantInfoResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.DescendantInfoResult{Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.DescendantInfoList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.DescendantInfoResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -66,6 +66,81 @@ func (mr *MockAzureClientMockRecorder) GetAzureADApps(arg0, arg1, arg2, arg3, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADApps", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADApps), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 }
 
+// GetAzureADAuthorizationPolicy mocks base method.
+func (m *MockAzureClient) GetAzureADAuthorizationPolicy(arg0 context.Context) (*azure.AuthorizationPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADAuthorizationPolicy", arg0)
+	ret0, _ := ret[0].(*azure.AuthorizationPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADAuthorizationPolicy indicates an expected call of GetAzureADAuthorizationPolicy.
+func (mr *MockAzureClientMockRecorder) GetAzureADAuthorizationPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADAuthorizationPolicy", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADAuthorizationPolicy), arg0)
+}
+
+// GetAzureADCrossTenantAccessPolicyDefault mocks base method.
+func (m *MockAzureClient) GetAzureADCrossTenantAccessPolicyDefault(arg0 context.Context) (*azure.CrossTenantAccessPolicyConfigurationDefault, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADCrossTenantAccessPolicyDefault", arg0)
+	ret0, _ := ret[0].(*azure.CrossTenantAccessPolicyConfigurationDefault)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADCrossTenantAccessPolicyDefault indicates an expected call of GetAzureADCrossTenantAccessPolicyDefault.
+func (mr *MockAzureClientMockRecorder) GetAzureADCrossTenantAccessPolicyDefault(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADCrossTenantAccessPolicyDefault", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADCrossTenantAccessPolicyDefault), arg0)
+}
+
+// GetAzureADCrossTenantAccessPolicyPartners mocks base method.
+func (m *MockAzureClient) GetAzureADCrossTenantAccessPolicyPartners(arg0 context.Context) (azure.CrossTenantAccessPolicyConfigurationPartnerList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADCrossTenantAccessPolicyPartners", arg0)
+	ret0, _ := ret[0].(azure.CrossTenantAccessPolicyConfigurationPartnerList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADCrossTenantAccessPolicyPartners indicates an expected call of GetAzureADCrossTenantAccessPolicyPartners.
+func (mr *MockAzureClientMockRecorder) GetAzureADCrossTenantAccessPolicyPartners(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADCrossTenantAccessPolicyPartners", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADCrossTenantAccessPolicyPartners), arg0)
+}
+
+// GetAzureADDelegatedAdminAccessAssignments mocks base method.
+func (m *MockAzureClient) GetAzureADDelegatedAdminAccessAssignments(arg0 context.Context, arg1 string) (azure.DelegatedAdminAccessAssignmentList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADDelegatedAdminAccessAssignments", arg0, arg1)
+	ret0, _ := ret[0].(azure.DelegatedAdminAccessAssignmentList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADDelegatedAdminAccessAssignments indicates an expected call of GetAzureADDelegatedAdminAccessAssignments.
+func (mr *MockAzureClientMockRecorder) GetAzureADDelegatedAdminAccessAssignments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADDelegatedAdminAccessAssignments", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADDelegatedAdminAccessAssignments), arg0, arg1)
+}
+
+// GetAzureADDelegatedAdminRelationships mocks base method.
+func (m *MockAzureClient) GetAzureADDelegatedAdminRelationships(arg0 context.Context, arg1 string, arg2 int32) (azure.DelegatedAdminRelationshipList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADDelegatedAdminRelationships", arg0, arg1, arg2)
+	ret0, _ := ret[0].(azure.DelegatedAdminRelationshipList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADDelegatedAdminRelationships indicates an expected call of GetAzureADDelegatedAdminRelationships.
+func (mr *MockAzureClientMockRecorder) GetAzureADDelegatedAdminRelationships(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADDelegatedAdminRelationships", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADDelegatedAdminRelationships), arg0, arg1, arg2)
+}
+
 // GetAzureADDirectoryObject mocks base method.
 func (m *MockAzureClient) GetAzureADDirectoryObject(arg0 context.Context, arg1 string) (json.RawMessage, error) {
 	m.ctrl.T.Helper()
@@ -126,6 +201,21 @@ func (mr *MockAzureClientMockRecorder) GetAzureADGroupEligibilityScheduleInstanc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADGroupEligibilityScheduleInstances", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADGroupEligibilityScheduleInstances), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 }
 
+// GetAzureADGroupLifecyclePolicies mocks base method.
+func (m *MockAzureClient) GetAzureADGroupLifecyclePolicies(arg0 context.Context) (azure.GroupLifecyclePolicyList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADGroupLifecyclePolicies", arg0)
+	ret0, _ := ret[0].(azure.GroupLifecyclePolicyList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADGroupLifecyclePolicies indicates an expected call of GetAzureADGroupLifecyclePolicies.
+func (mr *MockAzureClientMockRecorder) GetAzureADGroupLifecyclePolicies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADGroupLifecyclePolicies", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADGroupLifecyclePolicies), arg0)
+}
+
 // GetAzureADGroupOwners mocks base method.
 func (m *MockAzureClient) GetAzureADGroupOwners(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string, arg6 int32, arg7 bool) (azure.DirectoryObjectList, error) {
 	m.ctrl.T.Helper()
@@ -141,6 +231,21 @@ func (mr *MockAzureClientMockRecorder) GetAzureADGroupOwners(arg0, arg1, arg2, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADGroupOwners", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADGroupOwners), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 }
 
+// GetAzureADGroupSettings mocks base method.
+func (m *MockAzureClient) GetAzureADGroupSettings(arg0 context.Context) (azure.GroupSettingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADGroupSettings", arg0)
+	ret0, _ := ret[0].(azure.GroupSettingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADGroupSettings indicates an expected call of GetAzureADGroupSettings.
+func (mr *MockAzureClientMockRecorder) GetAzureADGroupSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADGroupSettings", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADGroupSettings), arg0)
+}
+
 // GetAzureADGroups mocks base method.
 func (m *MockAzureClient) GetAzureADGroups(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string, arg6 int32, arg7 bool) (azure.GroupList, error) {
 	m.ctrl.T.Helper()
@@ -156,6 +261,36 @@ func (mr *MockAzureClientMockRecorder) GetAzureADGroups(arg0, arg1, arg2, arg3,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADGroups", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADGroups), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 }
 
+// GetAzureADNamedLocations mocks base method.
+func (m *MockAzureClient) GetAzureADNamedLocations(arg0 context.Context, arg1 string, arg2 int32) (azure.NamedLocationList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADNamedLocations", arg0, arg1, arg2)
+	ret0, _ := ret[0].(azure.NamedLocationList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADNamedLocations indicates an expected call of GetAzureADNamedLocations.
+func (mr *MockAzureClientMockRecorder) GetAzureADNamedLocations(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADNamedLocations", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADNamedLocations), arg0, arg1, arg2)
+}
+
+// GetAzureADOAuth2PermissionGrants mocks base method.
+func (m *MockAzureClient) GetAzureADOAuth2PermissionGrants(arg0 context.Context, arg1 string, arg2 int32) (azure.OAuth2PermissionGrantList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADOAuth2PermissionGrants", arg0, arg1, arg2)
+	ret0, _ := ret[0].(azure.OAuth2PermissionGrantList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADOAuth2PermissionGrants indicates an expected call of GetAzureADOAuth2PermissionGrants.
+func (mr *MockAzureClientMockRecorder) GetAzureADOAuth2PermissionGrants(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADOAuth2PermissionGrants", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADOAuth2PermissionGrants), arg0, arg1, arg2)
+}
+
 // GetAzureADOrganization mocks base method.
 func (m *MockAzureClient) GetAzureADOrganization(arg0 context.Context, arg1 []string) (*azure.Organization, error) {
 	m.ctrl.T.Helper()
@@ -336,6 +471,21 @@ func (mr *MockAzureClientMockRecorder) GetAzureADUser(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADUser", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADUser), arg0, arg1, arg2)
 }
 
+// GetAzureADUserAuthenticationMethods mocks base method.
+func (m *MockAzureClient) GetAzureADUserAuthenticationMethods(arg0 context.Context, arg1 string) (azure.AuthenticationMethodList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureADUserAuthenticationMethods", arg0, arg1)
+	ret0, _ := ret[0].(azure.AuthenticationMethodList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureADUserAuthenticationMethods indicates an expected call of GetAzureADUserAuthenticationMethods.
+func (mr *MockAzureClientMockRecorder) GetAzureADUserAuthenticationMethods(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureADUserAuthenticationMethods", reflect.TypeOf((*MockAzureClient)(nil).GetAzureADUserAuthenticationMethods), arg0, arg1)
+}
+
 // GetAzureADUsers mocks base method.
 func (m *MockAzureClient) GetAzureADUsers(arg0 context.Context, arg1, arg2, arg3 string, arg4 []string, arg5 int32, arg6 bool) (azure.UserList, error) {
 	m.ctrl.T.Helper()
@@ -471,6 +621,36 @@ func (mr *MockAzureClientMockRecorder) GetAzureResourceGroups(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureResourceGroups", reflect.TypeOf((*MockAzureClient)(nil).GetAzureResourceGroups), arg0, arg1, arg2, arg3)
 }
 
+// GetAzureResourceGraph mocks base method.
+func (m *MockAzureClient) GetAzureResourceGraph(arg0 context.Context, arg1 azure.ResourceGraphRequest) (azure.ResourceGraphResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureResourceGraph", arg0, arg1)
+	ret0, _ := ret[0].(azure.ResourceGraphResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureResourceGraph indicates an expected call of GetAzureResourceGraph.
+func (mr *MockAzureClientMockRecorder) GetAzureResourceGraph(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureResourceGraph", reflect.TypeOf((*MockAzureClient)(nil).GetAzureResourceGraph), arg0, arg1)
+}
+
+// GetAzureResources mocks base method.
+func (m *MockAzureClient) GetAzureResources(arg0 context.Context, arg1, arg2 string, arg3 int32) (azure.GenericResourceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureResources", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(azure.GenericResourceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureResources indicates an expected call of GetAzureResources.
+func (mr *MockAzureClientMockRecorder) GetAzureResources(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureResources", reflect.TypeOf((*MockAzureClient)(nil).GetAzureResources), arg0, arg1, arg2, arg3)
+}
+
 // GetAzureStorageAccount mocks base method.
 func (m *MockAzureClient) GetAzureStorageAccount(arg0 context.Context, arg1, arg2, arg3, arg4 string) (*azure.StorageAccount, error) {
 	m.ctrl.T.Helper()
@@ -561,6 +741,21 @@ func (mr *MockAzureClientMockRecorder) GetAzureVirtualMachines(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureVirtualMachines", reflect.TypeOf((*MockAzureClient)(nil).GetAzureVirtualMachines), arg0, arg1, arg2)
 }
 
+// GetAzureWebAppAuthSettings mocks base method.
+func (m *MockAzureClient) GetAzureWebAppAuthSettings(arg0 context.Context, arg1 string) (*azure.WebAppAuthSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAzureWebAppAuthSettings", arg0, arg1)
+	ret0, _ := ret[0].(*azure.WebAppAuthSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAzureWebAppAuthSettings indicates an expected call of GetAzureWebAppAuthSettings.
+func (mr *MockAzureClientMockRecorder) GetAzureWebAppAuthSettings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAzureWebAppAuthSettings", reflect.TypeOf((*MockAzureClient)(nil).GetAzureWebAppAuthSettings), arg0, arg1)
+}
+
 // GetResourceRoleAssignments mocks base method.
 func (m *MockAzureClient) GetResourceRoleAssignments(arg0 context.Context, arg1, arg2, arg3 string) (azure.RoleAssignmentList, error) {
 	m.ctrl.T.Helper()
@@ -591,6 +786,21 @@ func (mr *MockAzureClientMockRecorder) GetRoleAssignmentsForResource(arg0, arg1,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleAssignmentsForResource", reflect.TypeOf((*MockAzureClient)(nil).GetRoleAssignmentsForResource), arg0, arg1, arg2)
 }
 
+// GraphAuthorization mocks base method.
+func (m *MockAzureClient) GraphAuthorization() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GraphAuthorization")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GraphAuthorization indicates an expected call of GraphAuthorization.
+func (mr *MockAzureClientMockRecorder) GraphAuthorization() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GraphAuthorization", reflect.TypeOf((*MockAzureClient)(nil).GraphAuthorization))
+}
+
 // ListAzureADAppMemberObjects mocks base method.
 func (m *MockAzureClient) ListAzureADAppMemberObjects(arg0 context.Context, arg1 string, arg2 bool) <-chan azure.MemberObjectResult {
 	m.ctrl.T.Helper()
@@ -647,6 +857,48 @@ func (mr *MockAzureClientMockRecorder) ListAzureADApps(arg0, arg1, arg2, arg3, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADApps", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADApps), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// ListAzureADCrossTenantAccessPolicyPartners mocks base method.
+func (m *MockAzureClient) ListAzureADCrossTenantAccessPolicyPartners(arg0 context.Context) <-chan azure.CrossTenantAccessPolicyConfigurationPartnerResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADCrossTenantAccessPolicyPartners", arg0)
+	ret0, _ := ret[0].(<-chan azure.CrossTenantAccessPolicyConfigurationPartnerResult)
+	return ret0
+}
+
+// ListAzureADCrossTenantAccessPolicyPartners indicates an expected call of ListAzureADCrossTenantAccessPolicyPartners.
+func (mr *MockAzureClientMockRecorder) ListAzureADCrossTenantAccessPolicyPartners(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADCrossTenantAccessPolicyPartners", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADCrossTenantAccessPolicyPartners), arg0)
+}
+
+// ListAzureADDelegatedAdminAccessAssignments mocks base method.
+func (m *MockAzureClient) ListAzureADDelegatedAdminAccessAssignments(arg0 context.Context, arg1 string) <-chan azure.DelegatedAdminAccessAssignmentResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADDelegatedAdminAccessAssignments", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.DelegatedAdminAccessAssignmentResult)
+	return ret0
+}
+
+// ListAzureADDelegatedAdminAccessAssignments indicates an expected call of ListAzureADDelegatedAdminAccessAssignments.
+func (mr *MockAzureClientMockRecorder) ListAzureADDelegatedAdminAccessAssignments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADDelegatedAdminAccessAssignments", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADDelegatedAdminAccessAssignments), arg0, arg1)
+}
+
+// ListAzureADDelegatedAdminRelationships mocks base method.
+func (m *MockAzureClient) ListAzureADDelegatedAdminRelationships(arg0 context.Context, arg1 string) <-chan azure.DelegatedAdminRelationshipResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADDelegatedAdminRelationships", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.DelegatedAdminRelationshipResult)
+	return ret0
+}
+
+// ListAzureADDelegatedAdminRelationships indicates an expected call of ListAzureADDelegatedAdminRelationships.
+func (mr *MockAzureClientMockRecorder) ListAzureADDelegatedAdminRelationships(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADDelegatedAdminRelationships", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADDelegatedAdminRelationships), arg0, arg1)
+}
+
 // ListAzureADGroupEligibilityScheduleInstances mocks base method.
 func (m *MockAzureClient) ListAzureADGroupEligibilityScheduleInstances(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string) <-chan azure.PrivilegedAccessGroupEligibilityScheduleInstanceResult {
 	m.ctrl.T.Helper()
@@ -661,6 +913,20 @@ func (mr *MockAzureClientMockRecorder) ListAzureADGroupEligibilityScheduleInstan
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADGroupEligibilityScheduleInstances", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADGroupEligibilityScheduleInstances), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// ListAzureADGroupLifecyclePolicies mocks base method.
+func (m *MockAzureClient) ListAzureADGroupLifecyclePolicies(arg0 context.Context) <-chan azure.GroupLifecyclePolicyResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADGroupLifecyclePolicies", arg0)
+	ret0, _ := ret[0].(<-chan azure.GroupLifecyclePolicyResult)
+	return ret0
+}
+
+// ListAzureADGroupLifecyclePolicies indicates an expected call of ListAzureADGroupLifecyclePolicies.
+func (mr *MockAzureClientMockRecorder) ListAzureADGroupLifecyclePolicies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADGroupLifecyclePolicies", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADGroupLifecyclePolicies), arg0)
+}
+
 // ListAzureADGroupMembers mocks base method.
 func (m *MockAzureClient) ListAzureADGroupMembers(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string) <-chan azure.MemberObjectResult {
 	m.ctrl.T.Helper()
@@ -689,6 +955,20 @@ func (mr *MockAzureClientMockRecorder) ListAzureADGroupOwners(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADGroupOwners", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADGroupOwners), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// ListAzureADGroupSettings mocks base method.
+func (m *MockAzureClient) ListAzureADGroupSettings(arg0 context.Context) <-chan azure.GroupSettingResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADGroupSettings", arg0)
+	ret0, _ := ret[0].(<-chan azure.GroupSettingResult)
+	return ret0
+}
+
+// ListAzureADGroupSettings indicates an expected call of ListAzureADGroupSettings.
+func (mr *MockAzureClientMockRecorder) ListAzureADGroupSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADGroupSettings", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADGroupSettings), arg0)
+}
+
 // ListAzureADGroups mocks base method.
 func (m *MockAzureClient) ListAzureADGroups(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string) <-chan azure.GroupResult {
 	m.ctrl.T.Helper()
@@ -703,6 +983,34 @@ func (mr *MockAzureClientMockRecorder) ListAzureADGroups(arg0, arg1, arg2, arg3,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADGroups", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADGroups), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// ListAzureADNamedLocations mocks base method.
+func (m *MockAzureClient) ListAzureADNamedLocations(arg0 context.Context, arg1 string) <-chan azure.NamedLocationResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADNamedLocations", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.NamedLocationResult)
+	return ret0
+}
+
+// ListAzureADNamedLocations indicates an expected call of ListAzureADNamedLocations.
+func (mr *MockAzureClientMockRecorder) ListAzureADNamedLocations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADNamedLocations", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADNamedLocations), arg0, arg1)
+}
+
+// ListAzureADOAuth2PermissionGrants mocks base method.
+func (m *MockAzureClient) ListAzureADOAuth2PermissionGrants(arg0 context.Context, arg1 string) <-chan azure.OAuth2PermissionGrantResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADOAuth2PermissionGrants", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.OAuth2PermissionGrantResult)
+	return ret0
+}
+
+// ListAzureADOAuth2PermissionGrants indicates an expected call of ListAzureADOAuth2PermissionGrants.
+func (mr *MockAzureClientMockRecorder) ListAzureADOAuth2PermissionGrants(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADOAuth2PermissionGrants", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADOAuth2PermissionGrants), arg0, arg1)
+}
+
 // ListAzureADRoleAssignments mocks base method.
 func (m *MockAzureClient) ListAzureADRoleAssignments(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string) <-chan azure.UnifiedRoleAssignmentResult {
 	m.ctrl.T.Helper()
@@ -787,6 +1095,20 @@ func (mr *MockAzureClientMockRecorder) ListAzureADTenants(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADTenants", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADTenants), arg0, arg1)
 }
 
+// ListAzureADUserAuthenticationMethods mocks base method.
+func (m *MockAzureClient) ListAzureADUserAuthenticationMethods(arg0 context.Context, arg1 string) <-chan azure.UserAuthenticationMethodResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureADUserAuthenticationMethods", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.UserAuthenticationMethodResult)
+	return ret0
+}
+
+// ListAzureADUserAuthenticationMethods indicates an expected call of ListAzureADUserAuthenticationMethods.
+func (mr *MockAzureClientMockRecorder) ListAzureADUserAuthenticationMethods(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureADUserAuthenticationMethods", reflect.TypeOf((*MockAzureClient)(nil).ListAzureADUserAuthenticationMethods), arg0, arg1)
+}
+
 // ListAzureADUsers mocks base method.
 func (m *MockAzureClient) ListAzureADUsers(arg0 context.Context, arg1, arg2, arg3 string, arg4 []string) <-chan azure.UserResult {
 	m.ctrl.T.Helper()
@@ -843,6 +1165,20 @@ func (mr *MockAzureClientMockRecorder) ListAzureDeviceRegisteredOwners(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureDeviceRegisteredOwners", reflect.TypeOf((*MockAzureClient)(nil).ListAzureDeviceRegisteredOwners), arg0, arg1, arg2)
 }
 
+// ListAzureDeviceRegisteredUsers mocks base method.
+func (m *MockAzureClient) ListAzureDeviceRegisteredUsers(arg0 context.Context, arg1 string) <-chan azure.DeviceRegisteredUserResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureDeviceRegisteredUsers", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.DeviceRegisteredUserResult)
+	return ret0
+}
+
+// ListAzureDeviceRegisteredUsers indicates an expected call of ListAzureDeviceRegisteredUsers.
+func (mr *MockAzureClientMockRecorder) ListAzureDeviceRegisteredUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureDeviceRegisteredUsers", reflect.TypeOf((*MockAzureClient)(nil).ListAzureDeviceRegisteredUsers), arg0, arg1)
+}
+
 // ListAzureDevices mocks base method.
 func (m *MockAzureClient) ListAzureDevices(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 []string) <-chan azure.DeviceResult {
 	m.ctrl.T.Helper()
@@ -941,6 +1277,20 @@ func (mr *MockAzureClientMockRecorder) ListAzureManagementGroups(arg0 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureManagementGroups", reflect.TypeOf((*MockAzureClient)(nil).ListAzureManagementGroups), arg0)
 }
 
+// ListAzureRegistrationAssignments mocks base method.
+func (m *MockAzureClient) ListAzureRegistrationAssignments(arg0 context.Context, arg1 string) <-chan azure.RegistrationAssignmentResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureRegistrationAssignments", arg0, arg1)
+	ret0, _ := ret[0].(<-chan azure.RegistrationAssignmentResult)
+	return ret0
+}
+
+// ListAzureRegistrationAssignments indicates an expected call of ListAzureRegistrationAssignments.
+func (mr *MockAzureClientMockRecorder) ListAzureRegistrationAssignments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureRegistrationAssignments", reflect.TypeOf((*MockAzureClient)(nil).ListAzureRegistrationAssignments), arg0, arg1)
+}
+
 // ListAzureResourceGroups mocks base method.
 func (m *MockAzureClient) ListAzureResourceGroups(arg0 context.Context, arg1, arg2 string) <-chan azure.ResourceGroupResult {
 	m.ctrl.T.Helper()
@@ -955,6 +1305,34 @@ func (mr *MockAzureClientMockRecorder) ListAzureResourceGroups(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureResourceGroups", reflect.TypeOf((*MockAzureClient)(nil).ListAzureResourceGroups), arg0, arg1, arg2)
 }
 
+// ListAzureResourceGraph mocks base method.
+func (m *MockAzureClient) ListAzureResourceGraph(arg0 context.Context, arg1, arg2 []string, arg3 string) <-chan azure.ResourceGraphResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureResourceGraph", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(<-chan azure.ResourceGraphResult)
+	return ret0
+}
+
+// ListAzureResourceGraph indicates an expected call of ListAzureResourceGraph.
+func (mr *MockAzureClientMockRecorder) ListAzureResourceGraph(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureResourceGraph", reflect.TypeOf((*MockAzureClient)(nil).ListAzureResourceGraph), arg0, arg1, arg2, arg3)
+}
+
+// ListAzureResources mocks base method.
+func (m *MockAzureClient) ListAzureResources(arg0 context.Context, arg1, arg2 string) <-chan azure.GenericResourceResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAzureResources", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan azure.GenericResourceResult)
+	return ret0
+}
+
+// ListAzureResources indicates an expected call of ListAzureResources.
+func (mr *MockAzureClientMockRecorder) ListAzureResources(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAzureResources", reflect.TypeOf((*MockAzureClient)(nil).ListAzureResources), arg0, arg1, arg2)
+}
+
 // ListAzureStorageAccounts mocks base method.
 func (m *MockAzureClient) ListAzureStorageAccounts(arg0 context.Context, arg1 string) <-chan azure.StorageAccountResult {
 	m.ctrl.T.Helper()
@@ -0,0 +1,74 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/query"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureADNamedLocations(ctx context.Context, filter string, top int32) (azure.NamedLocationList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/identity/conditionalAccess/namedLocations", constants.GraphApiVersion)
+		params   = query.Params{Filter: filter, Top: top}.AsMap()
+		headers  map[string]string
+		response azure.NamedLocationList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, params, headers); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureADNamedLocations(ctx context.Context, filter string) <-chan azure.NamedLocationResult {
+	out := make(chan azure.NamedLocationResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.NamedLocationResult{}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.NamedLocationList, error) {
+				return s.GetAzureADNamedLocations(ctx, filter, 999)
+			},
+			func(list azure.NamedLocationList) ([]azure.NamedLocation, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.NamedLocation) string { return u.Id },
+			func(u azure.NamedLocation) {
+				out <- azure.NamedLocationResult{Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// The principal types of Azure role assignments, keyed by the @odata.type of the directory object
+var principalTypes = map[string]string{
+	"#microsoft.graph.user":             "User",
+	"#microsoft.graph.group":            "Group",
+	"#microsoft.graph.servicePrincipal": "ServicePrincipal",
+	"#microsoft.graph.device":           "Device",
+}
+
+// principalTypeCache resolves the type of each principal with a single directory lookup however many role assignments
+// reference it, including lookups made concurrently. Principals that can't be resolved, e.g. because they have been
+// deleted, are remembered too and left without a type.
+type principalTypeCache struct {
+	mutex   sync.Mutex
+	entries map[string]*principalTypeEntry
+}
+
+type principalTypeEntry struct {
+	done          chan struct{}
+	principalType string
+}
+
+func newPrincipalTypeCache() *principalTypeCache {
+	return &principalTypeCache{entries: make(map[string]*principalTypeEntry)}
+}
+
+// resolve returns the principal type of id, calling lookup unless it has already been, or is being, looked up
+func (s *principalTypeCache) resolve(ctx context.Context, id string, lookup func(ctx context.Context, id string) (string, error)) string {
+	s.mutex.Lock()
+	if entry, ok := s.entries[id]; ok {
+		s.mutex.Unlock()
+		select {
+		case <-entry.done:
+			return entry.principalType
+		case <-ctx.Done():
+			return ""
+		}
+	}
+
+	entry := &principalTypeEntry{done: make(chan struct{})}
+	s.entries[id] = entry
+	s.mutex.Unlock()
+
+	defer close(entry.done)
+	if principalType, err := lookup(ctx, id); err == nil {
+		entry.principalType = principalType
+	} else if ctx.Err() != nil {
+		// the lookup was abandoned rather than failed, so a later one may still succeed
+		s.mutex.Lock()
+		delete(s.entries, id)
+		s.mutex.Unlock()
+	} else {
+		log.V(1).Info("unable to resolve the principal type of a role assignee", "principalId", id, "error", err.Error())
+	}
+	return entry.principalType
+}
+
+// resolvePrincipalType fills in the principal type of assignment when the role assignment payload lacks it
+func (s *azureClient) resolvePrincipalType(ctx context.Context, assignment *azure.RoleAssignment) {
+	if s.principalTypes == nil || assignment.Properties.PrincipalType != "" || assignment.Properties.PrincipalId == "" {
+		return
+	}
+	assignment.Properties.PrincipalType = s.principalTypes.resolve(ctx, assignment.Properties.PrincipalId, s.lookupPrincipalType)
+}
+
+func (s *azureClient) lookupPrincipalType(ctx context.Context, id string) (string, error) {
+	var object struct {
+		Type string `json:"@odata.type"`
+	}
+	if raw, err := s.GetAzureADDirectoryObject(ctx, id); err != nil {
+		return "", err
+	} else if err := json.Unmarshal(raw, &object); err != nil {
+		return "", err
+	} else if principalType, ok := principalTypes[object.Type]; ok {
+		return principalType, nil
+	} else {
+		// other object types keep their name, e.g. #microsoft.graph.orgContact becomes OrgContact
+		name := strings.TrimPrefix(object.Type, "#microsoft.graph.")
+		if name == "" {
+			return "", nil
+		}
+		return strings.ToUpper(name[:1]) + name[1:], nil
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func roleAssignmentsPage(principals ...string) string {
+	var assignments []string
+	for i, principal := range principals {
+		id, principalType, _ := strings.Cut(principal, ":")
+		assignments = append(assignments, fmt.Sprintf(`{"id":"%d","properties":{"principalId":"%s","principalType":"%s","roleDefinitionId":"role","scope":"/subscriptions/a"}}`, i, id, principalType))
+	}
+	return fmt.Sprintf(`{"value":[%s]}`, strings.Join(assignments, ","))
+}
+
+func TestListRoleAssignmentsForResourcePrincipalTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		graph           = mocks.NewMockRestClient(ctrl)
+		resourceManager = mocks.NewMockRestClient(ctrl)
+		client          = &azureClient{msgraph: graph, resourceManager: resourceManager, principalTypes: newPrincipalTypeCache()}
+	)
+
+	resourceManager.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(roleAssignmentsPage("group", "group", "user:User", "sp", "group", "deleted")), nil)
+	graph.EXPECT().Get(gomock.Any(), "/v1.0/directoryObjects/group", gomock.Any(), gomock.Any()).Return(response(`{"@odata.type":"#microsoft.graph.group","id":"group"}`), nil)
+	graph.EXPECT().Get(gomock.Any(), "/v1.0/directoryObjects/sp", gomock.Any(), gomock.Any()).Return(response(`{"@odata.type":"#microsoft.graph.servicePrincipal","id":"sp"}`), nil)
+	graph.EXPECT().Get(gomock.Any(), "/v1.0/directoryObjects/deleted", gomock.Any(), gomock.Any()).Return(nil, errors.New("not found"))
+
+	var got []string
+	for result := range client.ListRoleAssignmentsForResource(context.Background(), "/subscriptions/a", "atScope()") {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		got = append(got, result.Ok.Properties.PrincipalType)
+	}
+
+	if want := "Group,Group,User,ServicePrincipal,Group,"; strings.Join(got, ",") != want {
+		t.Errorf("got principal types %v, want %v", strings.Join(got, ","), want)
+	}
+}
+
+func TestPrincipalTypeCacheDedupes(t *testing.T) {
+	var (
+		cache   = newPrincipalTypeCache()
+		lookups atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+		lookup  = func(ctx context.Context, id string) (string, error) {
+			lookups.Add(1)
+			<-release
+			return "Group", nil
+		}
+		results = make(chan string, 1000)
+	)
+
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- cache.resolve(context.Background(), "group", lookup)
+		}()
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for result := range results {
+		if result != "Group" {
+			t.Fatalf("got %v, want %v", result, "Group")
+		}
+	}
+	if lookups.Load() != 1 {
+		t.Errorf("got %v lookups, want 1", lookups.Load())
+	}
+}
+
+func TestPrincipalTypeCacheCancelled(t *testing.T) {
+	var (
+		cache       = newPrincipalTypeCache()
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	cancel()
+
+	if got := cache.resolve(ctx, "group", func(ctx context.Context, id string) (string, error) { return "", ctx.Err() }); got != "" {
+		t.Errorf("got %v, want no principal type", got)
+	} else if got := cache.resolve(context.Background(), "group", func(ctx context.Context, id string) (string, error) { return "Group", nil }); got != "Group" {
+		t.Errorf("got %v, want an abandoned lookup to be retried", got)
+	}
+}
@@ -26,6 +26,7 @@ const (
 	ApiVersion                 string = "api-version"
 	Count                      string = "$count"
 	Expand                     string = "$expand"
+	ExpandRegistrationDef      string = "$expandRegistrationDefinition"
 	Filter                     string = "$filter"
 	Format                     string = "$format"
 	IncludeDeleted             string = "$include"
@@ -45,6 +46,7 @@ type Params struct {
 	ApiVersion                 string
 	Count                      bool
 	Expand                     string
+	ExpandRegistrationDef      bool
 	Filter                     string
 	IncludeDeleted             string
 	IncludeAllTenantCategories bool
@@ -74,6 +76,10 @@ func (s Params) AsMap() map[string]string {
 		params[Expand] = s.Expand
 	}
 
+	if s.ExpandRegistrationDef {
+		params[ExpandRegistrationDef] = "true"
+	}
+
 	if s.Filter != "" {
 		params[Filter] = s.Filter
 	}
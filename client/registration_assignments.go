@@ -0,0 +1,74 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/query"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureRegistrationAssignments(ctx context.Context, subscriptionId string) (azure.RegistrationAssignmentList, error) {
+	var (
+		path     = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.ManagedServices/registrationAssignments", subscriptionId)
+		params   = query.Params{ApiVersion: "2022-10-01", ExpandRegistrationDef: true}.AsMap()
+		headers  map[string]string
+		response azure.RegistrationAssignmentList
+	)
+
+	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureRegistrationAssignments(ctx context.Context, subscriptionId string) <-chan azure.RegistrationAssignmentResult {
+	out := make(chan azure.RegistrationAssignmentResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.RegistrationAssignmentResult{
+				SubscriptionId: subscriptionId,
+			}
+		)
+
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.RegistrationAssignmentList, error) {
+				return s.GetAzureRegistrationAssignments(ctx, subscriptionId)
+			},
+			func(list azure.RegistrationAssignmentList) ([]azure.RegistrationAssignment, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.RegistrationAssignment) string { return u.Id },
+			func(u azure.RegistrationAssignment) {
+				out <- azure.RegistrationAssignmentResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
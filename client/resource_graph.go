@@ -0,0 +1,336 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bloodhoundad/azurehound/v2/client/query"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// Resource Graph runs a query against at most this many subscriptions per request.
+const resourceGraphMaxSubscriptions = 1000
+
+func (s *azureClient) GetAzureResourceGraph(ctx context.Context, request azure.ResourceGraphRequest) (azure.ResourceGraphResponse, error) {
+	var (
+		path     = "/providers/Microsoft.ResourceGraph/resources"
+		params   = query.Params{ApiVersion: "2021-03-01"}.AsMap()
+		headers  map[string]string
+		response azure.ResourceGraphResponse
+	)
+
+	if res, err := s.resourceManager.Post(ctx, path, request, params, headers); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+// ListAzureResourceGraph runs a Resource Graph query and streams its rows, following $skipToken across pages. When
+// neither subscriptions nor management groups are given the query runs against every subscription the principal can
+// read.
+func (s *azureClient) ListAzureResourceGraph(ctx context.Context, subscriptionIds, managementGroupIds []string, kql string) <-chan azure.ResourceGraphResult {
+	out := make(chan azure.ResourceGraphResult)
+
+	go func() {
+		defer close(out)
+
+		scopes := [][]string{subscriptionIds}
+		if len(subscriptionIds) > resourceGraphMaxSubscriptions {
+			scopes = nil
+			for start := 0; start < len(subscriptionIds); start += resourceGraphMaxSubscriptions {
+				end := start + resourceGraphMaxSubscriptions
+				if end > len(subscriptionIds) {
+					end = len(subscriptionIds)
+				}
+				scopes = append(scopes, subscriptionIds[start:end])
+			}
+		}
+
+		for _, scope := range scopes {
+			request := azure.ResourceGraphRequest{
+				Subscriptions: scope,
+				Query:         kql,
+				Options:       azure.ResourceGraphRequestOptions{ResultFormat: "objectArray", Top: 1000},
+			}
+			if len(scope) == 0 {
+				request.ManagementGroups = managementGroupIds
+			}
+
+			for {
+				if list, err := s.GetAzureResourceGraph(ctx, request); err != nil {
+					out <- azure.ResourceGraphResult{Error: err}
+					return
+				} else if list.ResultTruncated == "true" {
+					out <- azure.ResourceGraphResult{Error: fmt.Errorf("resource graph truncated the results of %q", kql)}
+					return
+				} else {
+					for _, row := range list.Data {
+						out <- azure.ResourceGraphResult{Ok: row}
+					}
+
+					if list.SkipToken == "" {
+						break
+					} else if err := rest.Aborted(ctx); err != nil {
+						out <- azure.ResourceGraphResult{Error: err}
+						return
+					} else {
+						request.Options.SkipToken = list.SkipToken
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// NewResourceGraphClient returns a client that lists common ARM resource types with one Azure Resource Graph query per
+// type rather than one listing per subscription, which is much faster for tenants with many subscriptions. The query
+// runs against the given subscriptions, or the given management groups when no subscriptions are given. A query scopes
+// to one or the other, so when both are given it runs against every subscription the principal can read and the
+// listings only return the rows of the subscriptions asked for.
+//
+// Rows are decoded into the same models as the per-subscription listings, so callers hydrate them as before. If a
+// query fails, for example because the principal can't read Resource Graph, that type and every type after it is
+// listed per subscription by the wrapped client instead.
+func NewResourceGraphClient(base AzureClient, subscriptionIds, managementGroupIds []string) AzureClient {
+	if len(subscriptionIds) > 0 && len(managementGroupIds) > 0 {
+		subscriptionIds, managementGroupIds = nil, nil
+	}
+	return &resourceGraphClient{
+		AzureClient:        base,
+		subscriptionIds:    subscriptionIds,
+		managementGroupIds: managementGroupIds,
+		types:              map[string]*resourceGraphRows{},
+	}
+}
+
+type resourceGraphClient struct {
+	AzureClient
+	subscriptionIds    []string
+	managementGroupIds []string
+	unavailable        atomic.Bool
+	mutex              sync.Mutex
+	types              map[string]*resourceGraphRows
+}
+
+// resourceGraphRows holds the rows of a resource type, keyed by lowercased subscription id, queried once on first use.
+type resourceGraphRows struct {
+	once sync.Once
+	rows map[string][]json.RawMessage
+	err  error
+}
+
+func (s *resourceGraphClient) rows(ctx context.Context, resourceType string) (map[string][]json.RawMessage, error) {
+	if s.unavailable.Load() {
+		return nil, fmt.Errorf("resource graph is unavailable")
+	}
+
+	s.mutex.Lock()
+	rows, ok := s.types[resourceType]
+	if !ok {
+		rows = &resourceGraphRows{}
+		s.types[resourceType] = rows
+	}
+	s.mutex.Unlock()
+
+	rows.once.Do(func() {
+		rows.rows, rows.err = s.query(ctx, resourceType)
+		if rows.err != nil && s.unavailable.CompareAndSwap(false, true) {
+			log.Info("warning: unable to query azure resource graph, listing resources per subscription instead", "type", resourceType, "reason", rows.err.Error())
+		}
+	})
+	return rows.rows, rows.err
+}
+
+func (s *resourceGraphClient) query(ctx context.Context, resourceType string) (map[string][]json.RawMessage, error) {
+	var (
+		kql  = fmt.Sprintf("resources | where type =~ '%s'", resourceType)
+		rows = map[string][]json.RawMessage{}
+	)
+
+	for item := range s.AzureClient.ListAzureResourceGraph(ctx, s.subscriptionIds, s.managementGroupIds, kql) {
+		var row struct {
+			SubscriptionId string `json:"subscriptionId"`
+		}
+		if item.Error != nil {
+			return nil, item.Error
+		} else if err := json.Unmarshal(item.Ok, &row); err != nil {
+			return nil, err
+		} else {
+			key := strings.ToLower(row.SubscriptionId)
+			rows[key] = append(rows[key], item.Ok)
+		}
+	}
+	return rows, nil
+}
+
+// listResourceGraph streams the rows of resourceType in subscriptionId decoded as T, or the results of fallback when
+// Resource Graph is unavailable or the rows don't decode.
+func listResourceGraph[T, R any](ctx context.Context, s *resourceGraphClient, resourceType, subscriptionId string, result func(T) R, fallback func() <-chan R) <-chan R {
+	rows, err := s.rows(ctx, resourceType)
+	if err != nil {
+		return fallback()
+	}
+
+	items := make([]T, 0, len(rows[strings.ToLower(subscriptionId)]))
+	for _, row := range rows[strings.ToLower(subscriptionId)] {
+		var item T
+		if err := json.Unmarshal(row, &item); err != nil {
+			log.Info("warning: unable to decode azure resource graph rows, listing the subscription instead", "type", resourceType, "subscriptionId", subscriptionId, "reason", err.Error())
+			return fallback()
+		} else {
+			items = append(items, item)
+		}
+	}
+
+	out := make(chan R)
+	go func() {
+		defer close(out)
+		for _, item := range items {
+			out <- result(item)
+		}
+	}()
+	return out
+}
+
+func (s *resourceGraphClient) ListAzureAutomationAccounts(ctx context.Context, subscriptionId string) <-chan azure.AutomationAccountResult {
+	return listResourceGraph(ctx, s, "microsoft.automation/automationaccounts", subscriptionId,
+		func(u azure.AutomationAccount) azure.AutomationAccountResult {
+			return azure.AutomationAccountResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.AutomationAccountResult {
+			return s.AzureClient.ListAzureAutomationAccounts(ctx, subscriptionId)
+		},
+	)
+}
+
+func (s *resourceGraphClient) ListAzureContainerRegistries(ctx context.Context, subscriptionId string) <-chan azure.ContainerRegistryResult {
+	return listResourceGraph(ctx, s, "microsoft.containerregistry/registries", subscriptionId,
+		func(u azure.ContainerRegistry) azure.ContainerRegistryResult {
+			return azure.ContainerRegistryResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.ContainerRegistryResult {
+			return s.AzureClient.ListAzureContainerRegistries(ctx, subscriptionId)
+		},
+	)
+}
+
+func (s *resourceGraphClient) ListAzureFunctionApps(ctx context.Context, subscriptionId string) <-chan azure.FunctionAppResult {
+	return listResourceGraph(ctx, s, "microsoft.web/sites", subscriptionId,
+		func(u azure.FunctionApp) azure.FunctionAppResult {
+			return azure.FunctionAppResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.FunctionAppResult { return s.AzureClient.ListAzureFunctionApps(ctx, subscriptionId) },
+	)
+}
+
+func (s *resourceGraphClient) ListAzureKeyVaults(ctx context.Context, subscriptionId string, top int32) <-chan azure.KeyVaultResult {
+	return listResourceGraph(ctx, s, "microsoft.keyvault/vaults", subscriptionId,
+		func(u azure.KeyVault) azure.KeyVaultResult {
+			return azure.KeyVaultResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.KeyVaultResult { return s.AzureClient.ListAzureKeyVaults(ctx, subscriptionId, top) },
+	)
+}
+
+func (s *resourceGraphClient) ListAzureLogicApps(ctx context.Context, subscriptionId string, filter string, top int32) <-chan azure.LogicAppResult {
+	if filter != "" {
+		return s.AzureClient.ListAzureLogicApps(ctx, subscriptionId, filter, top)
+	}
+	return listResourceGraph(ctx, s, "microsoft.logic/workflows", subscriptionId,
+		func(u azure.LogicApp) azure.LogicAppResult {
+			return azure.LogicAppResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.LogicAppResult {
+			return s.AzureClient.ListAzureLogicApps(ctx, subscriptionId, filter, top)
+		},
+	)
+}
+
+// Resource Graph doesn't hold the instance view, so status only listings always go to the wrapped client.
+func (s *resourceGraphClient) ListAzureManagedClusters(ctx context.Context, subscriptionId string, statusOnly bool) <-chan azure.ManagedClusterResult {
+	if statusOnly {
+		return s.AzureClient.ListAzureManagedClusters(ctx, subscriptionId, statusOnly)
+	}
+	return listResourceGraph(ctx, s, "microsoft.containerservice/managedclusters", subscriptionId,
+		func(u azure.ManagedCluster) azure.ManagedClusterResult {
+			return azure.ManagedClusterResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.ManagedClusterResult {
+			return s.AzureClient.ListAzureManagedClusters(ctx, subscriptionId, statusOnly)
+		},
+	)
+}
+
+func (s *resourceGraphClient) ListAzureStorageAccounts(ctx context.Context, subscriptionId string) <-chan azure.StorageAccountResult {
+	return listResourceGraph(ctx, s, "microsoft.storage/storageaccounts", subscriptionId,
+		func(u azure.StorageAccount) azure.StorageAccountResult {
+			return azure.StorageAccountResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.StorageAccountResult {
+			return s.AzureClient.ListAzureStorageAccounts(ctx, subscriptionId)
+		},
+	)
+}
+
+func (s *resourceGraphClient) ListAzureVirtualMachines(ctx context.Context, subscriptionId string, statusOnly bool) <-chan azure.VirtualMachineResult {
+	if statusOnly {
+		return s.AzureClient.ListAzureVirtualMachines(ctx, subscriptionId, statusOnly)
+	}
+	return listResourceGraph(ctx, s, "microsoft.compute/virtualmachines", subscriptionId,
+		func(u azure.VirtualMachine) azure.VirtualMachineResult {
+			return azure.VirtualMachineResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.VirtualMachineResult {
+			return s.AzureClient.ListAzureVirtualMachines(ctx, subscriptionId, statusOnly)
+		},
+	)
+}
+
+func (s *resourceGraphClient) ListAzureVMScaleSets(ctx context.Context, subscriptionId string, statusOnly bool) <-chan azure.VMScaleSetResult {
+	if statusOnly {
+		return s.AzureClient.ListAzureVMScaleSets(ctx, subscriptionId, statusOnly)
+	}
+	return listResourceGraph(ctx, s, "microsoft.compute/virtualmachinescalesets", subscriptionId,
+		func(u azure.VMScaleSet) azure.VMScaleSetResult {
+			return azure.VMScaleSetResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.VMScaleSetResult {
+			return s.AzureClient.ListAzureVMScaleSets(ctx, subscriptionId, statusOnly)
+		},
+	)
+}
+
+func (s *resourceGraphClient) ListAzureWebApps(ctx context.Context, subscriptionId string) <-chan azure.WebAppResult {
+	return listResourceGraph(ctx, s, "microsoft.web/sites", subscriptionId,
+		func(u azure.WebApp) azure.WebAppResult {
+			return azure.WebAppResult{SubscriptionId: subscriptionId, Ok: u}
+		},
+		func() <-chan azure.WebAppResult { return s.AzureClient.ListAzureWebApps(ctx, subscriptionId) },
+	)
+}
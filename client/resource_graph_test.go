@@ -0,0 +1,137 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/client/rest/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+const (
+	firstResourceGraphPage  = `{"count":2,"totalRecords":3,"$skipToken":"next","data":[{"id":"/subscriptions/a/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/1","subscriptionId":"A"},{"id":"/subscriptions/b/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/2","subscriptionId":"b"}]}`
+	secondResourceGraphPage = `{"count":1,"totalRecords":3,"data":[{"id":"/subscriptions/a/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/3","subscriptionId":"a"}]}`
+)
+
+func TestListAzureResourceGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := &azureClient{resourceManager: mockRestClient}
+
+	var tokens []string
+	mockRestClient.EXPECT().Post(gomock.Any(), "/providers/Microsoft.ResourceGraph/resources", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, body interface{}, _, _ map[string]string) (*http.Response, error) {
+			request := body.(azure.ResourceGraphRequest)
+			tokens = append(tokens, request.Options.SkipToken)
+			if request.Options.SkipToken == "" {
+				return response(firstResourceGraphPage), nil
+			} else {
+				return response(secondResourceGraphPage), nil
+			}
+		}).Times(2)
+
+	count := 0
+	for result := range client.ListAzureResourceGraph(context.Background(), []string{"a", "b"}, nil, "resources") {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			count++
+		}
+	}
+
+	if count != 3 {
+		t.Errorf("got %v rows, want 3", count)
+	} else if actual := strings.Join(tokens, ","); actual != ",next" {
+		t.Errorf("got skip tokens %v, want %v", actual, ",next")
+	}
+}
+
+func TestResourceGraphClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := NewResourceGraphClient(&azureClient{resourceManager: mockRestClient}, nil, nil)
+
+	// every subscription is answered from a single query of the type
+	gomock.InOrder(
+		mockRestClient.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(firstResourceGraphPage), nil),
+		mockRestClient.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(response(secondResourceGraphPage), nil),
+	)
+
+	for subscriptionId, want := range map[string]string{"a": "1,3", "B": "2", "c": ""} {
+		ids := []string{}
+		for result := range client.ListAzureVirtualMachines(context.Background(), subscriptionId, false) {
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			} else if result.SubscriptionId != subscriptionId {
+				t.Errorf("got subscription %v, want %v", result.SubscriptionId, subscriptionId)
+			} else {
+				ids = append(ids, path.Base(result.Ok.Id))
+			}
+		}
+		sort.Strings(ids)
+
+		if actual := strings.Join(ids, ","); actual != want {
+			t.Errorf("%s: got %v, want %v", subscriptionId, actual, want)
+		}
+	}
+}
+
+func TestResourceGraphClientFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRestClient := mocks.NewMockRestClient(ctrl)
+	client := NewResourceGraphClient(&azureClient{resourceManager: mockRestClient}, []string{"a"}, nil)
+
+	// once resource graph is found unavailable it isn't queried for later types
+	mockRestClient.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, rest.ErrForbidden).Times(1)
+	mockRestClient.EXPECT().Get(gomock.Any(), "/subscriptions/a/providers/Microsoft.Compute/virtualMachines", gomock.Any(), gomock.Any()).Return(response(`{"value":[{"id":"1"}]}`), nil)
+	mockRestClient.EXPECT().Get(gomock.Any(), "/subscriptions/a/providers/Microsoft.KeyVault/vaults", gomock.Any(), gomock.Any()).Return(response(`{"value":[{"id":"2"}]}`), nil)
+
+	ids := []string{}
+	for result := range client.ListAzureVirtualMachines(context.Background(), "a", false) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			ids = append(ids, result.Ok.Id)
+		}
+	}
+	for result := range client.ListAzureKeyVaults(context.Background(), "a", 999) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		} else {
+			ids = append(ids, result.Ok.Id)
+		}
+	}
+
+	if actual := strings.Join(ids, ","); actual != "1,2" {
+		t.Errorf("got %v, want %v", actual, "1,2")
+	}
+}
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureResourceGroup(ctx context.Context, subscriptionId,
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureResourceGroups(ctx context.Context, subscriptionId
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -69,49 +68,23 @@ func (s *azureClient) ListAzureResourceGroups(ctx context.Context, subscriptionI
 		var (
 			objectId  = fmt.Sprintf("/subscriptions/%s", subscriptionId)
 			errResult = azure.ResourceGroupResult{SubscriptionId: objectId}
-			nextLink  string
 		)
 
-		if result, err := s.GetAzureResourceGroups(ctx, subscriptionId, filter, 1000); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range result.Value {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.ResourceGroupList, error) {
+				return s.GetAzureResourceGroups(ctx, subscriptionId, filter, 1000)
+			},
+			func(list azure.ResourceGroupList) ([]azure.ResourceGroup, string) { return list.Value, list.NextLink },
+			func(u azure.ResourceGroup) string { return u.Id },
+			func(u azure.ResourceGroup) {
 				out <- azure.ResourceGroupResult{
 					SubscriptionId: objectId,
 					Ok:             u,
 				}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.ResourceGroupList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ResourceGroupResult{
-							SubscriptionId: objectId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -0,0 +1,73 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/query"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureResources(ctx context.Context, subscriptionId, expand string, top int32) (azure.GenericResourceList, error) {
+	var (
+		path     = fmt.Sprintf("/subscriptions/%s/resources", subscriptionId)
+		params   = query.Params{ApiVersion: "2021-04-01", Expand: expand, Top: top}.AsMap()
+		headers  map[string]string
+		response azure.GenericResourceList
+	)
+
+	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureResources(ctx context.Context, subscriptionId, expand string) <-chan azure.GenericResourceResult {
+	out := make(chan azure.GenericResourceResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.GenericResourceResult{SubscriptionId: subscriptionId}
+		)
+
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.GenericResourceList, error) {
+				return s.GetAzureResources(ctx, subscriptionId, expand, 1000)
+			},
+			func(list azure.GenericResourceList) ([]azure.GenericResource, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.GenericResource) string { return u.Id },
+			func(u azure.GenericResource) {
+				out <- azure.GenericResourceResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
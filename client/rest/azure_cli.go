@@ -0,0 +1,112 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// The Azure CLI executable; resolved through PATH (and PATHEXT on Windows)
+var azureCliPath = "az"
+
+// The format of expiresOn reported by older versions of the Azure CLI, in local time
+const azureCliExpiresOnFormat = "2006-01-02 15:04:05.999999"
+
+type azureCliToken struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresOn   string `json:"expiresOn"`
+	// Unix timestamp reported by Azure CLI 2.54.0 and later
+	ExpiresOnUnix int64  `json:"expires_on"`
+	Tenant        string `json:"tenant"`
+	TokenType     string `json:"tokenType"`
+}
+
+func (s azureCliToken) expires() (time.Time, error) {
+	if s.ExpiresOnUnix > 0 {
+		return time.Unix(s.ExpiresOnUnix, 0), nil
+	} else {
+		return time.ParseInLocation(azureCliExpiresOnFormat, s.ExpiresOn, time.Local)
+	}
+}
+
+// authenticateAzureCli obtains a token for this client's API from the signed in Azure CLI session. The CLI serves
+// cached tokens until they approach expiry and refreshes them itself, so calling it again once the token expires is
+// all that is needed for long running collections.
+func (s *restClient) authenticateAzureCli() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var (
+		resource = strings.TrimSuffix(s.api.String(), "/") + "/"
+		args     = []string{"account", "get-access-token", "--resource", resource, "--output", "json"}
+		stdout   bytes.Buffer
+		stderr   bytes.Buffer
+		response azureCliToken
+	)
+
+	if s.tenant != "" {
+		args = append(args, "--tenant", s.tenant)
+	}
+
+	cmd := exec.CommandContext(ctx, azureCliPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("unable to authenticate with the azure cli: %s was not found; install the azure cli and run 'az login' or choose another authentication method", azureCliPath)
+	} else if err != nil {
+		return azureCliError(err, stderr.String())
+	} else if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return fmt.Errorf("unable to parse azure cli access token: %w", err)
+	} else if response.AccessToken == "" {
+		return fmt.Errorf("azure cli did not return an access token for %s", resource)
+	} else if expires, err := response.expires(); err != nil {
+		return fmt.Errorf("unable to parse azure cli access token expiry: %w", err)
+	} else {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.token = Token{
+			accessToken: response.AccessToken,
+			expiresIn:   int(time.Until(expires).Seconds()),
+			expires:     expires,
+		}
+		return nil
+	}
+}
+
+func azureCliError(err error, stderr string) error {
+	var (
+		message = strings.TrimSpace(stderr)
+		lower   = strings.ToLower(message)
+	)
+
+	if strings.Contains(lower, "az login") || strings.Contains(lower, "expired") || strings.Contains(lower, "aadsts70043") || strings.Contains(lower, "aadsts700082") {
+		return fmt.Errorf("the azure cli session is missing or expired; run 'az login' (with --tenant for the target directory) and try again: %s", message)
+	} else if message != "" {
+		return fmt.Errorf("azure cli failed to provide an access token: %s", message)
+	} else {
+		return fmt.Errorf("azure cli failed to provide an access token: %w", err)
+	}
+}
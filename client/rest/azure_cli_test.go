@@ -0,0 +1,115 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeAzureCli(t *testing.T, script string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake azure cli requires a posix shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "az")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	original := azureCliPath
+	azureCliPath = path
+	t.Cleanup(func() { azureCliPath = original })
+}
+
+func TestAuthenticateAzureCli(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Unix()
+	fakeAzureCli(t, `echo "$@" > "$(dirname "$0")/args"
+echo '{"accessToken":"foo","expiresOn":"2023-01-01 00:00:00.000000","expires_on":`+strconv.FormatInt(expires, 10)+`,"tenant":"tenant","tokenType":"Bearer"}'
+`)
+
+	api, _ := url.Parse("https://management.azure.com")
+	client := &restClient{api: *api, azureCli: true, tenant: "contoso.onmicrosoft.com"}
+
+	if err := client.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if client.token.String() != "Bearer foo" {
+		t.Errorf("got %v, want %v", client.token.String(), "Bearer foo")
+	} else if client.token.expires.Unix() != expires {
+		t.Errorf("got %v, want %v", client.token.expires.Unix(), expires)
+	} else if client.token.IsExpired() {
+		t.Error("expected token to be valid")
+	}
+
+	if args, err := os.ReadFile(filepath.Join(filepath.Dir(azureCliPath), "args")); err != nil {
+		t.Fatal(err)
+	} else if want := "account get-access-token --resource https://management.azure.com/ --output json --tenant contoso.onmicrosoft.com"; strings.TrimSpace(string(args)) != want {
+		t.Errorf("got %q, want %q", strings.TrimSpace(string(args)), want)
+	}
+}
+
+func TestAuthenticateAzureCliLegacyExpiry(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	fakeAzureCli(t, `echo '{"accessToken":"foo","expiresOn":"`+expires.Format(azureCliExpiresOnFormat)+`","tenant":"tenant","tokenType":"Bearer"}'
+`)
+
+	api, _ := url.Parse("https://graph.microsoft.com")
+	client := &restClient{api: *api, azureCli: true}
+
+	if err := client.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !client.token.expires.Equal(expires) {
+		t.Errorf("got %v, want %v", client.token.expires, expires)
+	}
+}
+
+func TestAuthenticateAzureCliExpiredSession(t *testing.T) {
+	fakeAzureCli(t, `echo "ERROR: AADSTS70043: The refresh token has expired. Please run 'az login' to setup account." >&2
+exit 1
+`)
+
+	api, _ := url.Parse("https://graph.microsoft.com")
+	client := &restClient{api: *api, azureCli: true}
+
+	if err := client.Authenticate(); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "session is missing or expired; run 'az login'") {
+		t.Errorf("expected an actionable error, got %v", err)
+	}
+}
+
+func TestAuthenticateAzureCliNotInstalled(t *testing.T) {
+	original := azureCliPath
+	azureCliPath = "azurehound-test-missing-az"
+	defer func() { azureCliPath = original }()
+
+	api, _ := url.Parse("https://graph.microsoft.com")
+	client := &restClient{api: *api, azureCli: true}
+
+	if err := client.Authenticate(); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "install the azure cli") {
+		t.Errorf("expected an actionable error, got %v", err)
+	}
+}
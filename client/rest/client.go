@@ -23,17 +23,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
 	"net/url"
-	"strconv"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/bloodhoundad/azurehound/v2/client/config"
 	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
 )
 
 type RestClient interface {
@@ -47,13 +50,25 @@ type RestClient interface {
 }
 
 func NewRestClient(apiUrl string, config config.Config) (RestClient, error) {
+	var managedIdentity *http.Client
+	if config.ManagedIdentity {
+		managedIdentity = NewManagedIdentityHTTPClient()
+		managedIdentity.Transport = tracing.Transport{Base: managedIdentity.Transport}
+	}
+
 	if auth, err := url.Parse(config.AuthorityUrl()); err != nil {
 		return nil, err
 	} else if api, err := url.Parse(apiUrl); err != nil {
 		return nil, err
 	} else if http, err := NewHTTPClient(config.ProxyUrl); err != nil {
 		return nil, err
+	} else if tokenCache, err := openTokenCache(config); err != nil {
+		return nil, err
+	} else if responseCache, err := openResponseCache(apiUrl, config); err != nil {
+		return nil, err
 	} else {
+		http.Transport = tracing.Transport{Base: http.Transport}
+		http.Timeout = config.HTTPTimeout
 		client := &restClient{
 			*api,
 			*auth,
@@ -72,6 +87,18 @@ func NewRestClient(apiUrl string, config config.Config) (RestClient, error) {
 			Token{},
 			config.SubscriptionId,
 			config.MgmtGroupId,
+			managedIdentity,
+			config.ManagedIdentityClientId,
+			config.AzureCli,
+			config.ClientAssertion,
+			config.ClientAssertionFile,
+			tokenCache,
+			false,
+			config.MaxRetries,
+			config.RetryMaxBackoff,
+			responseCache,
+			sync.Map{},
+			endpointLimiter{},
 		}
 		return client, nil
 	}
@@ -95,9 +122,106 @@ type restClient struct {
 	token         Token
 	subId         []string
 	mgmtGroupId   []string
+
+	// set when authenticating with the managed identity of the Azure host
+	managedIdentity         *http.Client
+	managedIdentityClientId string
+
+	// set when authenticating with the signed in Azure CLI session
+	azureCli bool
+
+	// set when authenticating with a client assertion, e.g. a federated workload identity token
+	clientAssertion     string
+	clientAssertionFile string
+
+	// set when acquired tokens are cached between runs; tokenFromCache reports whether the current token came from it
+	tokenCache     *TokenCache
+	tokenFromCache bool
+
+	// the number of times a throttled, failed or dropped request is retried and the longest backoff between retries;
+	// zero for no limit
+	maxRetries int
+	maxBackoff time.Duration
+
+	// set when GET responses are cached between runs, for development and testing only
+	responseCache *ResponseCache
+
+	// the throttling guidance already logged, so each is logged once
+	throttleGuidance sync.Map
+
+	// holds back the requests to the endpoints that were throttled
+	endpoints endpointLimiter
+}
+
+// openTokenCache opens the configured token cache. Supplied tokens, managed identities and the Azure CLI don't
+// sign in with the token endpoint, so there is nothing to cache for them.
+func openTokenCache(config config.Config) (*TokenCache, error) {
+	if config.TokenCache == "" || config.JWT != "" || config.ManagedIdentity || config.AzureCli {
+		return nil, nil
+	} else {
+		return OpenTokenCache(config.TokenCache, config.TokenCachePassphrase)
+	}
 }
 
 func (s *restClient) Authenticate() error {
+	return s.authenticateContext(context.Background())
+}
+
+// authenticateContext is Authenticate for a request made under ctx, so waiting on the managed identity endpoint stops
+// once ctx is done
+func (s *restClient) authenticateContext(ctx context.Context) error {
+	if s.managedIdentity != nil {
+		return s.authenticateManagedIdentity(ctx)
+	} else if s.azureCli {
+		return s.authenticateAzureCli()
+	} else if s.tokenCache == nil {
+		return s.authenticate(s.refreshToken)
+	}
+
+	key := s.tokenCacheKey()
+	if cached, ok, err := s.tokenCache.Load(key); err != nil {
+		return err
+	} else if ok && !cached.IsExpired() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.token = cached
+		s.tokenFromCache = true
+		return nil
+	} else {
+		// the cached refresh token may have been revoked or expired, in which case the configured credential is used
+		redeemed := s.refreshToken
+		if ok && cached.refreshToken != "" && s.authenticate(cached.refreshToken) == nil {
+			redeemed = cached.refreshToken
+		} else if err := s.authenticate(s.refreshToken); err != nil {
+			return err
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.tokenFromCache = false
+		if s.token.refreshToken == "" {
+			// refresh tokens aren't always rotated, in which case the one that was redeemed remains valid
+			s.token.refreshToken = redeemed
+		}
+		return s.tokenCache.Store(key, s.token)
+	}
+}
+
+// tokenCacheKey identifies the tokens of this client in the token cache by tenant, client and audience. The client
+// is the one authenticate ends up requesting tokens for.
+func (s *restClient) tokenCacheKey() string {
+	clientId := s.clientId
+	if s.refreshToken != "" || clientId == "" {
+		clientId = constants.AzPowerShellClientID
+	} else if s.clientAssertion == "" && s.clientAssertionFile == "" && s.clientSecret == "" && (s.clientCert == "" || s.clientKey == "") {
+		// username and password
+		clientId = constants.AzPowerShellClientID
+	}
+	return newTokenCacheKey(s.tenant, clientId, s.api.String())
+}
+
+// authenticate requests a token from the token endpoint, redeeming refreshToken if it is set
+func (s *restClient) authenticate(refreshToken string) error {
 	var (
 		path         = url.URL{Path: fmt.Sprintf("/%s/oauth2/v2.0/token", s.tenant)}
 		endpoint     = s.authUrl.ResolveReference(&path)
@@ -114,10 +238,18 @@ func (s *restClient) Authenticate() error {
 
 	body.Add("scope", scope.ResolveReference(&defaultScope).String())
 
-	if s.refreshToken != "" {
+	if refreshToken != "" {
 		body.Add("grant_type", "refresh_token")
-		body.Add("refresh_token", s.refreshToken)
+		body.Add("refresh_token", refreshToken)
 		body.Set("client_id", constants.AzPowerShellClientID)
+	} else if s.clientAssertion != "" || s.clientAssertionFile != "" {
+		if clientAssertion, err := s.getClientAssertion(); err != nil {
+			return err
+		} else {
+			body.Add("grant_type", "client_credentials")
+			body.Add("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+			body.Add("client_assertion", clientAssertion)
+		}
 	} else if s.clientSecret != "" {
 		body.Add("grant_type", "client_credentials")
 		body.Add("client_secret", s.clientSecret)
@@ -154,6 +286,20 @@ func (s *restClient) Authenticate() error {
 	}
 }
 
+// getClientAssertion returns the configured client assertion. Assertion files are read on every call since
+// federated tokens are short lived and rotated by whatever provisions them.
+func (s *restClient) getClientAssertion() (string, error) {
+	if s.clientAssertionFile == "" {
+		return s.clientAssertion, nil
+	} else if content, err := os.ReadFile(s.clientAssertionFile); err != nil {
+		return "", fmt.Errorf("unable to read client assertion file: %w", err)
+	} else if assertion := strings.TrimSpace(string(content)); assertion == "" {
+		return "", fmt.Errorf("client assertion file %s is empty", s.clientAssertionFile)
+	} else {
+		return assertion, nil
+	}
+}
+
 func (s *restClient) Delete(ctx context.Context, path string, body interface{}, params, headers map[string]string) (*http.Response, error) {
 	endpoint := s.api.ResolveReference(&url.URL{Path: path})
 	if req, err := NewRequest(ctx, http.MethodDelete, endpoint, body, params, headers); err != nil {
@@ -200,83 +346,155 @@ func (s *restClient) Put(ctx context.Context, path string, body interface{}, par
 }
 
 func (s *restClient) Send(req *http.Request) (*http.Response, error) {
+	if s.responseCache == nil || req.Method != http.MethodGet {
+		return s.sendAuthorized(req)
+	} else if res, ok := s.responseCache.Get(req); ok {
+		return res, nil
+	} else if res, err := s.sendAuthorized(req); err != nil {
+		return nil, err
+	} else {
+		return s.responseCache.Put(req, res)
+	}
+}
+
+func (s *restClient) sendAuthorized(req *http.Request) (*http.Response, error) {
 	if s.jwt != "" {
 		if aud, err := ParseAud(s.jwt); err != nil {
 			return nil, err
 		} else if aud != s.api.String() {
 			return nil, fmt.Errorf("invalid audience")
+		} else if expires, err := ParseExpiry(s.jwt); err == nil && time.Now().After(expires) {
+			return nil, fmt.Errorf("%w: the token for %s expired at %s; supply a new token to continue", ErrTokenExpired, s.api.String(), expires.Format(time.RFC3339))
 		}
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.jwt))
 	} else {
 		if s.token.IsExpired() {
-			if err := s.Authenticate(); err != nil {
-				return nil, err
+			if err := s.authenticateContext(req.Context()); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
 			}
 		}
 		req.Header.Set("Authorization", s.token.String())
 	}
-	return s.send(req)
+
+	// tokens rejected by the service are dropped from the token cache
+	res, err := s.send(req)
+	if !errors.Is(err, ErrUnauthorized) || s.tokenCache == nil {
+		return res, err
+	} else if removeErr := s.tokenCache.Remove(s.tokenCacheKey()); removeErr != nil {
+		return nil, removeErr
+	} else if !s.tokenFromCache || (req.GetBody == nil && req.Body != nil) {
+		return nil, err
+	} else {
+		// the cached token was rejected, e.g. because it was revoked; sign in again and retry once with a fresh token
+		s.mutex.Lock()
+		s.token = Token{}
+		s.tokenFromCache = false
+		s.mutex.Unlock()
+
+		if err := s.authenticateContext(req.Context()); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+		} else if err := RewindBody(req); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", s.token.String())
+		return s.send(req)
+	}
 }
 
-func copyBody(req *http.Request) ([]byte, error) {
-	var (
-		body []byte
-		err  error
-	)
-	if req.Body != nil {
-		body, err = io.ReadAll(req.Body)
-		if body != nil {
-			req.Body = io.NopCloser(bytes.NewBuffer(body))
+// copyBody reads the body of req into memory and replaces it, along with GetBody, so that it can be rewound
+func copyBody(req *http.Request) error {
+	if req.Body == nil {
+		return nil
+	} else if body, err := io.ReadAll(req.Body); err != nil {
+		return err
+	} else {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
 		}
+		return nil
 	}
-	return body, err
 }
 
 func (s *restClient) send(req *http.Request) (*http.Response, error) {
 	// copy the bytes in case we need to retry the request
-	if body, err := copyBody(req); err != nil {
+	if err := copyBody(req); err != nil {
 		return nil, err
 	} else {
 		var (
-			res        *http.Response
-			err        error
-			maxRetries = 3
+			res         *http.Response
+			err         error
+			maxAttempts = s.maxRetries + 1
+			endpoint    = tracing.TemplatePath(req.URL.Path)
 		)
 		// Try the request up to a set number of times
-		for retry := 0; retry < maxRetries; retry++ {
+		for retry := 0; retry < maxAttempts; retry++ {
 
 			// Reusing http.Request requires rewinding the request body
 			// back to a working state
-			if body != nil && retry > 0 {
-				req.Body = io.NopCloser(bytes.NewBuffer(body))
+			if retry > 0 {
+				if err := RewindBody(req); err != nil {
+					return nil, err
+				}
+			}
+
+			// Wait for a throttle of the endpoint to lift, whichever request ran into it
+			if delay := s.endpoints.delay(endpoint, time.Now()); delay > 0 {
+				if err := wait(req.Context(), delay); err != nil {
+					return nil, err
+				}
 			}
 
 			// Try the request
 			if res, err = s.http.Do(req); err != nil {
+				if retry+1 < maxAttempts && isTransientNetworkError(req.Context(), err) {
+					// Wait the time calculated by the 2 second exponential backoff
+					if err := wait(req.Context(), s.backoff(2, retry)); err != nil {
+						return nil, err
+					}
+					continue
+				}
 				// client error
 				return nil, err
 			} else if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
 				// Error response code handling
 				// See official Retry guidance (https://learn.microsoft.com/en-us/azure/architecture/best-practices/retry-service-specific#retry-usage-guidance)
 				if res.StatusCode == http.StatusTooManyRequests {
-					retryAfterHeader := res.Header.Get("Retry-After")
-					if retryAfter, err := strconv.ParseInt(retryAfterHeader, 10, 64); err != nil {
-						return nil, fmt.Errorf("attempting to handle 429 but unable to parse retry-after header: %w", err)
+					hints := parseThrottleHints(res.Body)
+					res.Body.Close()
+					s.logThrottleGuidance(endpoint, hints)
+					if delay, err := throttleDelay(res.Header.Get("Retry-After"), hints, time.Now(), s.maxBackoff); err != nil {
+						return nil, err
 					} else {
-						// Wait the time indicated in the retry-after header
-						time.Sleep(time.Second * time.Duration(retryAfter))
+						// Hold back the requests to the endpoint for the time indicated by the retry-after header or
+						// the throttling hints
+						s.endpoints.throttle(endpoint, time.Now().Add(delay))
 						continue
 					}
 				} else if res.StatusCode >= http.StatusInternalServerError {
 					// Wait the time calculated by the 5 second exponential backoff
-					backoff := math.Pow(5, float64(retry+1))
-					time.Sleep(time.Second * time.Duration(backoff))
+					if err := wait(req.Context(), s.backoff(5, retry)); err != nil {
+						return nil, err
+					}
 					continue
 				} else {
 					// Not a status code that warrants a retry
 					var errRes map[string]interface{}
 					if err := Decode(res.Body, &errRes); err != nil {
+						if res.StatusCode == http.StatusGone {
+							return nil, ResyncRequiredError{StatusCode: res.StatusCode}
+						} else if res.StatusCode == http.StatusForbidden {
+							return nil, fmt.Errorf("%w, status code: %d", ErrForbidden, res.StatusCode)
+						} else if res.StatusCode == http.StatusUnauthorized {
+							return nil, fmt.Errorf("%w, status code: %d", ErrUnauthorized, res.StatusCode)
+						}
 						return nil, fmt.Errorf("malformed error response, status code: %d", res.StatusCode)
+					} else if resyncErr, ok := resyncRequired(res.StatusCode, errRes); ok {
+						return nil, resyncErr
+					} else if res.StatusCode == http.StatusForbidden {
+						return nil, fmt.Errorf("%w: %v", ErrForbidden, errRes)
+					} else if res.StatusCode == http.StatusUnauthorized {
+						return nil, fmt.Errorf("%w: %v", ErrUnauthorized, errRes)
 					} else {
 						return nil, fmt.Errorf("%v", errRes)
 					}
@@ -286,6 +504,28 @@ func (s *restClient) send(req *http.Request) (*http.Response, error) {
 				return res, nil
 			}
 		}
-		return nil, fmt.Errorf("unable to complete the request after %d attempts: %w", maxRetries, err)
+		return nil, fmt.Errorf("unable to complete the request after %d attempts: %w", maxAttempts, err)
+	}
+}
+
+// backoff returns the exponential backoff of base seconds before the given retry, bounded by the maximum backoff
+func (s *restClient) backoff(base float64, retry int) time.Duration {
+	backoff := time.Second * time.Duration(math.Pow(base, float64(retry+1)))
+	if s.maxBackoff > 0 && backoff > s.maxBackoff {
+		return s.maxBackoff
+	} else {
+		return backoff
+	}
+}
+
+// wait pauses for the given duration, returning early with the context's error if it is cancelled first
+func wait(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
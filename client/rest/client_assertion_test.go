@@ -0,0 +1,103 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+)
+
+func TestAuthenticateClientAssertionFile(t *testing.T) {
+	var (
+		mutex      sync.Mutex
+		assertions = map[string][]string{}
+	)
+
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		} else if r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			t.Errorf("unexpected request: %v", r.URL)
+		} else if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" || r.Form.Get("client_id") != "app" {
+			t.Errorf("unexpected token request: %v", r.Form)
+		} else if r.Form.Get("client_secret") != "" {
+			t.Error("expected no client secret to be sent")
+		}
+		assertions[r.Form.Get("scope")] = append(assertions[r.Form.Get("scope")], r.Form.Get("client_assertion"))
+		w.Write([]byte(`{"access_token":"token","expires_in":3599}`))
+	}))
+	defer authority.Close()
+
+	assertionFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(assertionFile, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{Authority: authority.URL, ApplicationId: "app", Tenant: "tenant", ClientAssertionFile: assertionFile}
+	graph, err := NewRestClient("https://graph.microsoft.com", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourceManager, err := NewRestClient("https://management.azure.com", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := graph.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// projected tokens are rotated in place; the next token request has to pick up the new assertion
+	if err := os.WriteFile(assertionFile, []byte("second"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := resourceManager.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := assertions["https://graph.microsoft.com/.default"]; len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("unexpected graph assertions: %v", got)
+	}
+	if got := assertions["https://management.azure.com/.default"]; len(got) != 1 || got[0] != "second" {
+		t.Errorf("unexpected resource manager assertions: %v", got)
+	}
+}
+
+func TestAuthenticateClientAssertionEmptyFile(t *testing.T) {
+	assertionFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(assertionFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{Authority: "https://login.microsoftonline.com", ApplicationId: "app", Tenant: "tenant", ClientAssertionFile: assertionFile}
+	if client, err := NewRestClient("https://graph.microsoft.com", cfg); err != nil {
+		t.Fatal(err)
+	} else if err := client.Authenticate(); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+func TestSendResyncRequired(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		resync     bool
+		code       string
+	}{
+		{
+			name:       "graph delta resync",
+			statusCode: http.StatusGone,
+			body:       `{"error":{"code":"resyncRequired","message":"Resync required. Replace any local items with the server's items."}}`,
+			resync:     true,
+			code:       "resyncRequired",
+		},
+		{
+			name:       "sync state not found",
+			statusCode: http.StatusGone,
+			body:       `{"error":{"code":"SyncStateNotFound","message":"The sync state generation is not found."}}`,
+			resync:     true,
+			code:       "SyncStateNotFound",
+		},
+		{
+			name:       "apply differences on inner error",
+			statusCode: http.StatusGone,
+			body:       `{"error":{"code":"Gone","message":"The resource is gone.","innerError":{"code":"resyncChangesApplyDifferences"}}}`,
+			resync:     true,
+			code:       "resyncChangesApplyDifferences",
+		},
+		{
+			name:       "gone without body",
+			statusCode: http.StatusGone,
+			body:       ``,
+			resync:     true,
+		},
+		{
+			name:       "gone with unknown code",
+			statusCode: http.StatusGone,
+			body:       `{"error":{"code":"Gone","message":"The skip token is no longer valid."}}`,
+			resync:     true,
+			code:       "Gone",
+		},
+		{
+			name:       "resync code on bad request",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"code":"syncStateInvalid","message":"The sync state is invalid."}}`,
+			resync:     true,
+			code:       "syncStateInvalid",
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       `{"error":{"code":"Request_ResourceNotFound","message":"Resource does not exist."}}`,
+			resync:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			client := &restClient{http: server.Client()}
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+			var resyncErr ResyncRequiredError
+			if _, err := client.send(req); err == nil {
+				t.Fatalf("expected an error")
+			} else if actual := errors.Is(err, ErrResyncRequired); actual != test.resync {
+				t.Errorf("got %v, want %v: %v", actual, test.resync, err)
+			} else if test.resync && !errors.As(err, &resyncErr) {
+				t.Errorf("failed type assertion: got %T, want %T", err, resyncErr)
+			} else if resyncErr.Code != test.code {
+				t.Errorf("got %v, want %v", resyncErr.Code, test.code)
+			}
+		})
+	}
+}
+
+func TestSendForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":"Authorization_RequestDenied","message":"Insufficient privileges to complete the operation."}}`))
+	}))
+	defer server.Close()
+
+	client := &restClient{http: server.Client()}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if _, err := client.send(req); err == nil {
+		t.Fatalf("expected an error")
+	} else if !errors.Is(err, ErrForbidden) {
+		t.Errorf("got %v, want %v", err, ErrForbidden)
+	} else if errors.Is(err, ErrResyncRequired) {
+		t.Errorf("forbidden response should not require a resync: %v", err)
+	}
+}
+
+// recordingTransport answers every request without sending it, recording the requested urls and token scopes
+type recordingTransport struct {
+	mutex    sync.Mutex
+	requests []*url.URL
+	scopes   []string
+}
+
+func (s *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, req.URL)
+
+	body := "{}"
+	if strings.HasSuffix(req.URL.Path, "/oauth2/v2.0/token") {
+		req.ParseForm()
+		s.scopes = append(s.scopes, req.PostForm.Get("scope"))
+		body = `{"access_token":"foo","expires_in":3599,"token_type":"Bearer"}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestRequestsUseCloudEndpoints(t *testing.T) {
+	tests := []struct {
+		cloud string
+		env   constants.Environment
+	}{
+		{constants.CloudPublic, constants.AzureCloud()},
+		{constants.CloudUSGov, constants.AzureUSGovernment()},
+		{constants.CloudUSGovDoD, constants.AzureUSGovernmentL5()},
+		{constants.CloudChina, constants.AzureChina()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cloud, func(t *testing.T) {
+			region, err := config.CloudRegion(test.cloud)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// the configured urls belong to another cloud and must not be used
+			cfg := config.Config{
+				ApplicationId: "app",
+				Authority:     "https://login.example.com",
+				ClientSecret:  "secret",
+				Graph:         "https://graph.example.com",
+				Management:    "https://management.example.com",
+				Region:        region,
+				Tenant:        "tenant",
+			}
+
+			if cfg.AuthorityUrl() != test.env.ActiveDirectoryAuthority {
+				t.Errorf("got authority url %v, want %v", cfg.AuthorityUrl(), test.env.ActiveDirectoryAuthority)
+			} else if cfg.GraphUrl() != test.env.MicrosoftGraphUrl {
+				t.Errorf("got graph url %v, want %v", cfg.GraphUrl(), test.env.MicrosoftGraphUrl)
+			} else if cfg.ResourceManagerUrl() != test.env.ResourceManagerUrl {
+				t.Errorf("got resource manager url %v, want %v", cfg.ResourceManagerUrl(), test.env.ResourceManagerUrl)
+			}
+
+			for _, api := range []string{cfg.GraphUrl(), cfg.ResourceManagerUrl()} {
+				client, err := NewRestClient(api, cfg)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				transport := &recordingTransport{}
+				client.(*restClient).http.Transport = transport
+
+				ctx := context.Background()
+				client.Get(ctx, "/resource", nil, nil)
+				client.Post(ctx, "/resource", map[string]string{}, nil, nil)
+				client.Patch(ctx, "/resource", map[string]string{}, nil, nil)
+				client.Put(ctx, "/resource", map[string]string{}, nil, nil)
+				client.Delete(ctx, "/resource", nil, nil, nil)
+
+				wantAuthority, _ := url.Parse(test.env.ActiveDirectoryAuthority)
+				wantApi, _ := url.Parse(api)
+				if len(transport.requests) != 6 {
+					t.Fatalf("got %v requests, want 6", len(transport.requests))
+				} else if token := transport.requests[0]; token.Host != wantAuthority.Host || token.Path != "/tenant/oauth2/v2.0/token" {
+					t.Errorf("got token endpoint %v, want host %v", token, wantAuthority.Host)
+				} else if scope := transport.scopes[0]; scope != api+"/.default" {
+					t.Errorf("got scope %v, want %v", scope, api+"/.default")
+				}
+
+				for _, request := range transport.requests[1:] {
+					if request.Host != wantApi.Host {
+						t.Errorf("got request to %v, want host %v", request, wantApi.Host)
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := config.CloudRegion("germany"); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestSendBackoffCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := &restClient{http: server.Client(), maxRetries: 2}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	if _, err := client.send(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	} else if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("send waited %v after its context was cancelled", elapsed)
+	}
+}
+
+func TestNewRestClientHTTPTimeout(t *testing.T) {
+	if client, err := NewRestClient("https://graph.microsoft.com", config.Config{HTTPTimeout: 90 * time.Second}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if timeout := client.(*restClient).http.Timeout; timeout != 90*time.Second {
+		t.Errorf("got %v, want %v", timeout, 90*time.Second)
+	}
+}
+
+// flakyTransport fails the first failures round trips with err before passing requests on to base
+type flakyTransport struct {
+	base     http.RoundTripper
+	failures int
+	err      error
+	attempts int
+}
+
+func (s *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.attempts++
+	if s.attempts <= s.failures {
+		return nil, s.err
+	} else {
+		return s.base.RoundTrip(req)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func newFlakyClient(server *httptest.Server, failures int, err error) (*restClient, *flakyTransport) {
+	transport := &flakyTransport{base: server.Client().Transport, failures: failures, err: err}
+	return &restClient{http: &http.Client{Transport: transport}, maxRetries: 2, maxBackoff: time.Millisecond}, transport
+}
+
+func TestSendRetriesTransientNetworkErrors(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bytes, _ := io.ReadAll(r.Body)
+		body = string(bytes)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	for name, err := range map[string]error{
+		"connection reset": &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+		"i/o timeout":      &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+		"temporary dns":    &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "server misbehaving", Name: "graph.microsoft.com", IsTemporary: true}},
+		"dropped":          io.EOF,
+	} {
+		body = ""
+		client, transport := newFlakyClient(server, 2, err)
+		req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+
+		if res, err := client.send(req); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		} else if res.Body.Close(); transport.attempts != 3 {
+			t.Errorf("%s: got %v attempts, want 3", name, transport.attempts)
+		} else if body != "payload" {
+			t.Errorf("%s: got body %q after retrying, want %q", name, body, "payload")
+		}
+	}
+}
+
+func TestSendRetriesServerErrorsWithBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bytes, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(bytes))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	client := &restClient{http: server.Client(), maxRetries: 2, maxBackoff: time.Millisecond}
+	req, _ := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("payload")))
+
+	if res, err := client.send(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); strings.Join(bodies, ",") != "payload,payload,payload" {
+		t.Errorf("got bodies %q, want the payload for every attempt", bodies)
+	}
+}
+
+func TestRewindBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://graph.microsoft.com", strings.NewReader("payload"))
+	io.ReadAll(req.Body)
+
+	if err := RewindBody(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if body, _ := io.ReadAll(req.Body); string(body) != "payload" {
+		t.Errorf("got %q, want %q", body, "payload")
+	}
+
+	// a body without GetBody can't be resent
+	req, _ = http.NewRequest(http.MethodPost, "https://graph.microsoft.com", io.NopCloser(strings.NewReader("payload")))
+	if err := RewindBody(req); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://graph.microsoft.com", nil)
+	if err := RewindBody(req); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSendTransientNetworkErrorRetryLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, transport := newFlakyClient(server, 5, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if _, err := client.send(req); !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("got %v, want %v", err, syscall.ECONNRESET)
+	} else if transport.attempts != 3 {
+		t.Errorf("got %v attempts, want 3", transport.attempts)
+	}
+
+	client, transport = newFlakyClient(server, 5, io.EOF)
+	client.maxRetries = 0
+	if _, err := client.send(req); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if transport.attempts != 1 {
+		t.Errorf("got %v attempts, want 1", transport.attempts)
+	}
+}
+
+func TestSendNonRetryableNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	for name, err := range map[string]error{
+		"unknown host":        &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "graph.microsoft.com", IsNotFound: true}},
+		"connection refused":  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+		"unreachable network": &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)},
+		"untrusted authority": x509.UnknownAuthorityError{},
+		"other":               errors.New("unsupported protocol scheme"),
+	} {
+		client, transport := newFlakyClient(server, 1, err)
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+		if _, err := client.send(req); err == nil {
+			t.Errorf("%s: expected an error but did not receive one", name)
+		} else if transport.attempts != 1 {
+			t.Errorf("%s: got %v attempts, want 1", name, transport.attempts)
+		}
+	}
+}
+
+func TestIsTransientNetworkErrorCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if isTransientNetworkError(ctx, &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}) {
+		t.Error("expected errors of cancelled requests not to be retried")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := &restClient{maxBackoff: time.Minute}
+	for retry, want := range []time.Duration{5 * time.Second, 25 * time.Second, time.Minute, time.Minute} {
+		if actual := client.backoff(5, retry); actual != want {
+			t.Errorf("retry %d: got %v, want %v", retry, actual, want)
+		}
+	}
+
+	client.maxBackoff = 0
+	if actual := client.backoff(2, 3); actual != 16*time.Second {
+		t.Errorf("got %v, want %v", actual, 16*time.Second)
+	}
+}
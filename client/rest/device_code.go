@@ -0,0 +1,155 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// The number of seconds added to the polling interval each time the authority responds with slow_down
+const deviceCodeSlowDown = 5
+
+// The unit of the interval and expiry reported by the authority; a package var so tests don't have to wait on it
+var deviceCodeUnit = time.Second
+
+// DeviceCode is the device authorization response; the user completes sign in by entering UserCode at
+// VerificationUri. Message is a ready to display, localized instruction from the authority.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationUri string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+	Message         string `json:"message"`
+}
+
+type deviceCodeError struct {
+	Code             string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// DeviceCodeRefreshToken runs the OAuth device authorization grant against the configured tenant and returns the
+// resulting refresh token. prompt is called once with the code the user has to enter; polling then continues until
+// the user signs in, the code expires or ctx is cancelled.
+//
+// The refresh token is issued to the Azure PowerShell client, so it can be redeemed for both the Graph and the
+// Resource Manager audience using the refresh token flow.
+func DeviceCodeRefreshToken(ctx context.Context, config config.Config, prompt func(DeviceCode)) (string, error) {
+	var (
+		scope = fmt.Sprintf("%s/.default offline_access", config.GraphUrl())
+		code  DeviceCode
+	)
+
+	if authUrl, err := url.Parse(config.AuthorityUrl()); err != nil {
+		return "", err
+	} else if client, err := NewHTTPClient(config.ProxyUrl); err != nil {
+		return "", err
+	} else if err := postForm(ctx, client, authUrl.ResolveReference(&url.URL{Path: fmt.Sprintf("/%s/oauth2/v2.0/devicecode", config.Tenant)}), url.Values{
+		"client_id": {constants.AzPowerShellClientID},
+		"scope":     {scope},
+	}, &code); err != nil {
+		return "", fmt.Errorf("unable to request a device code: %w", err)
+	} else {
+		prompt(code)
+		return pollDeviceCode(ctx, client, authUrl.ResolveReference(&url.URL{Path: fmt.Sprintf("/%s/oauth2/v2.0/token", config.Tenant)}), code)
+	}
+}
+
+func pollDeviceCode(ctx context.Context, client *http.Client, endpoint *url.URL, code DeviceCode) (string, error) {
+	var (
+		interval = time.Duration(code.Interval) * deviceCodeUnit
+		expired  = time.After(time.Duration(code.ExpiresIn) * deviceCodeUnit)
+		body     = url.Values{
+			"grant_type":  {deviceCodeGrantType},
+			"client_id":   {constants.AzPowerShellClientID},
+			"device_code": {code.DeviceCode},
+		}
+	)
+
+	if interval <= 0 {
+		interval = deviceCodeSlowDown * deviceCodeUnit
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-expired:
+			return "", fmt.Errorf("the device code expired before sign in was completed")
+		case <-time.After(interval):
+		}
+
+		var res struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := postForm(ctx, client, endpoint, body, &res); err == nil {
+			if res.RefreshToken == "" {
+				return "", fmt.Errorf("no refresh token was issued for the device code")
+			}
+			return res.RefreshToken, nil
+		} else if errRes, ok := err.(deviceCodeError); !ok {
+			return "", err
+		} else if errRes.Code == "authorization_pending" {
+			continue
+		} else if errRes.Code == "slow_down" {
+			interval += deviceCodeSlowDown * deviceCodeUnit
+		} else if errRes.Code == "authorization_declined" {
+			return "", fmt.Errorf("sign in was declined")
+		} else if errRes.Code == "expired_token" {
+			return "", fmt.Errorf("the device code expired before sign in was completed")
+		} else {
+			return "", errRes
+		}
+	}
+}
+
+// postForm posts body to endpoint and decodes a successful response into v. OAuth error responses are returned as a
+// deviceCodeError so callers can act on the error code.
+func postForm(ctx context.Context, client *http.Client, endpoint *url.URL, body url.Values, v interface{}) error {
+	if req, err := NewRequest(ctx, http.MethodPost, endpoint, body, nil, nil); err != nil {
+		return err
+	} else if res, err := client.Do(req); err != nil {
+		return err
+	} else if res.StatusCode != http.StatusOK {
+		var errRes deviceCodeError
+		if err := Decode(res.Body, &errRes); err != nil || errRes.Code == "" {
+			return fmt.Errorf("unexpected response, status code: %d", res.StatusCode)
+		} else {
+			return errRes
+		}
+	} else {
+		return Decode(res.Body, v)
+	}
+}
+
+func (s deviceCodeError) Error() string {
+	if s.ErrorDescription != "" {
+		return fmt.Sprintf("%s: %s", s.Code, s.ErrorDescription)
+	} else {
+		return s.Code
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+// newDeviceCodeServer responds to token polls with each of the given oauth error codes in turn before issuing a
+// refresh token
+func newDeviceCodeServer(t *testing.T, pending ...string) (*httptest.Server, *int32) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		} else if r.Form.Get("client_id") != constants.AzPowerShellClientID {
+			t.Errorf("unexpected client_id: %v", r.Form.Get("client_id"))
+		}
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tenant/oauth2/v2.0/devicecode"):
+			if scope := r.Form.Get("scope"); scope != "https://graph.microsoft.com/.default offline_access" {
+				t.Errorf("unexpected scope: %v", scope)
+			}
+			json.NewEncoder(w).Encode(DeviceCode{DeviceCode: "device", UserCode: "ABC123", VerificationUri: "https://microsoft.com/devicelogin", ExpiresIn: 900, Interval: 1})
+		case strings.HasSuffix(r.URL.Path, "/tenant/oauth2/v2.0/token"):
+			if r.Form.Get("grant_type") != deviceCodeGrantType || r.Form.Get("device_code") != "device" {
+				t.Errorf("unexpected token request: %v", r.Form)
+			}
+			if poll := int(atomic.AddInt32(&polls, 1)); poll <= len(pending) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": pending[poll-1], "error_description": "description"})
+			} else {
+				json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access", "refresh_token": "refresh", "expires_in": 3599})
+			}
+		default:
+			t.Errorf("unexpected request: %v", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &polls
+}
+
+func setDeviceCodeUnit(t *testing.T) {
+	unit := deviceCodeUnit
+	deviceCodeUnit = time.Millisecond
+	t.Cleanup(func() { deviceCodeUnit = unit })
+}
+
+func TestDeviceCodeRefreshToken(t *testing.T) {
+	setDeviceCodeUnit(t)
+	server, polls := newDeviceCodeServer(t, "authorization_pending", "slow_down")
+	cfg := config.Config{Authority: server.URL, Graph: "https://graph.microsoft.com", Tenant: "tenant"}
+
+	var prompted DeviceCode
+	if token, err := DeviceCodeRefreshToken(context.Background(), cfg, func(code DeviceCode) { prompted = code }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if token != "refresh" {
+		t.Errorf("got %v, want %v", token, "refresh")
+	} else if prompted.UserCode != "ABC123" {
+		t.Errorf("got %v, want %v", prompted.UserCode, "ABC123")
+	} else if *polls != 3 {
+		t.Errorf("got %v polls, want %v", *polls, 3)
+	}
+}
+
+func TestDeviceCodeRefreshTokenDeclined(t *testing.T) {
+	setDeviceCodeUnit(t)
+	server, _ := newDeviceCodeServer(t, "authorization_pending", "authorization_declined")
+	cfg := config.Config{Authority: server.URL, Graph: "https://graph.microsoft.com", Tenant: "tenant"}
+
+	if _, err := DeviceCodeRefreshToken(context.Background(), cfg, func(DeviceCode) {}); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "declined") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDeviceCodeRefreshTokenCancelled(t *testing.T) {
+	setDeviceCodeUnit(t)
+	pending := make([]string, 1000)
+	for i := range pending {
+		pending[i] = "authorization_pending"
+	}
+	server, _ := newDeviceCodeServer(t, pending...)
+	cfg := config.Config{Authority: server.URL, Graph: "https://graph.microsoft.com", Tenant: "tenant"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := DeviceCodeRefreshToken(ctx, cfg, func(DeviceCode) { cancel() }); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestDeviceCodeRefreshTokenProxy(t *testing.T) {
+	setDeviceCodeUnit(t)
+	// the proxy answers for the authority itself, which does not resolve
+	proxy, _ := newDeviceCodeServer(t)
+	cfg := config.Config{Authority: "http://login.azurehound.invalid", Graph: "https://graph.microsoft.com", Tenant: "tenant", ProxyUrl: proxy.URL}
+
+	if token, err := DeviceCodeRefreshToken(context.Background(), cfg, func(DeviceCode) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if token != "refresh" {
+		t.Errorf("got %v, want %v", token, "refresh")
+	}
+}
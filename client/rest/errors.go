@@ -0,0 +1,137 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// ErrResyncRequired is matched by errors returned when the service has discarded the paging or sync state a request
+// depended on. The request cannot be retried as-is; enumeration has to start over.
+var ErrResyncRequired = errors.New("resync required")
+
+// ErrForbidden is matched by errors returned when the service refuses a request because the caller lacks the
+// permission, role or license it requires.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrUnauthorized is matched by errors returned when the service rejects the token a request was sent with, e.g.
+// because it was revoked.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrAuthenticationFailed is matched by errors returned when a token can't be acquired with the configured
+// credential, e.g. because the secret is wrong or the account is disabled.
+var ErrAuthenticationFailed = errors.New("authentication failed")
+
+// ErrTokenExpired is matched by errors returned when a supplied access token has expired. Supplied tokens cannot be
+// refreshed, so the request cannot succeed until a new token is provided.
+var ErrTokenExpired = errors.New("access token expired")
+
+// Error codes documented by Microsoft Graph for 410 Gone responses to delta and paged requests.
+var resyncErrorCodes = []string{
+	"resyncRequired",
+	"resyncChangesApplyDifferences",
+	"resyncChangesUploadDifferences",
+	"resyncApplyDifferences",
+	"resyncUploadDifferences",
+	"syncStateNotFound",
+	"syncStateInvalid",
+	"fullSyncRequired",
+}
+
+type ResyncRequiredError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (s ResyncRequiredError) Error() string {
+	if s.Code == "" {
+		return fmt.Sprintf("resync required, status code: %d", s.StatusCode)
+	} else {
+		return fmt.Sprintf("resync required, status code: %d, code: %s, message: %s", s.StatusCode, s.Code, s.Message)
+	}
+}
+
+func (s ResyncRequiredError) Is(target error) bool {
+	return target == ErrResyncRequired
+}
+
+// resyncRequired inspects an error response and reports whether the service is asking the caller to restart
+// enumeration. Any 410 Gone qualifies, as does a documented resync error code on any other status.
+func resyncRequired(statusCode int, errRes map[string]interface{}) (ResyncRequiredError, bool) {
+	var (
+		result = ResyncRequiredError{StatusCode: statusCode}
+		found  = false
+	)
+
+	if odataErr, ok := errRes["error"].(map[string]interface{}); ok {
+		result.Code, _ = odataErr["code"].(string)
+		result.Message, _ = odataErr["message"].(string)
+		found = isResyncErrorCode(result.Code)
+
+		// some services report the resync reason on the inner error only
+		if innerErr, ok := odataErr["innerError"].(map[string]interface{}); ok && !found {
+			if code, ok := innerErr["code"].(string); ok && isResyncErrorCode(code) {
+				result.Code = code
+				found = true
+			}
+		}
+	}
+
+	return result, found || statusCode == http.StatusGone
+}
+
+func isResyncErrorCode(code string) bool {
+	for _, item := range resyncErrorCodes {
+		if strings.EqualFold(item, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransientNetworkError reports whether a request failed because of a network blip that is worth retrying: a
+// dropped connection, a timeout, including TLS handshake timeouts, or a temporary DNS failure. Errors caused by
+// cancelling the request's context, refused connections, names that don't resolve and certificate errors are not
+// retried.
+func isTransientNetworkError(ctx context.Context, err error) bool {
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+
+	if ctx.Err() != nil {
+		return false
+	} else if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	} else if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	} else if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	} else {
+		// the connection was closed before a response was read
+		return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+}
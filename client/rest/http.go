@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
@@ -51,8 +52,9 @@ func NewHTTPClient(proxyUrl string) (*http.Client, error) {
 
 	// setup forward proxy
 	if proxyUrl != "" {
+		// the url may contain credentials so it is left out of the error
 		if url, err := url.Parse(proxyUrl); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("unable to parse proxy url")
 		} else {
 			transport.Proxy = http.ProxyURL(url)
 		}
@@ -126,3 +128,18 @@ func NewRequest(
 		return req, nil
 	}
 }
+
+// RewindBody replaces the body of a request that has already been sent with a fresh copy from GetBody so it can be
+// sent again. A retried request whose body can't be recreated would otherwise go out empty, so that is an error.
+func RewindBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	} else if req.GetBody == nil {
+		return fmt.Errorf("unable to resend %s %s: the request body cannot be rewound", req.Method, req.URL.Redacted())
+	} else if body, err := req.GetBody(); err != nil {
+		return err
+	} else {
+		req.Body = body
+		return nil
+	}
+}
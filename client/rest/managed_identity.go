@@ -0,0 +1,113 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+// NewManagedIdentityHTTPClient returns an http.Client for requesting tokens from the local managed identity endpoint.
+// The endpoint is link-local so neither the configured forward proxy nor proxy environment variables are applied.
+func NewManagedIdentityHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}
+}
+
+// managedIdentityRequest builds a token request for the given resource. Hosts that expose the identity endpoint
+// through the environment (App Service, Functions, Container Apps) are preferred over IMDS.
+func managedIdentityRequest(ctx context.Context, resource, clientId string) (*http.Request, error) {
+	var (
+		endpoint = constants.IMDSTokenUrl
+		params   = url.Values{}
+		headers  = map[string]string{}
+	)
+
+	if identityEndpoint, identityHeader := os.Getenv(constants.IdentityEndpointEnv), os.Getenv(constants.IdentityHeaderEnv); identityEndpoint != "" && identityHeader != "" {
+		endpoint = identityEndpoint
+		params.Set("api-version", constants.IdentityEndpointApiVersion)
+		headers["X-IDENTITY-HEADER"] = identityHeader
+	} else {
+		params.Set("api-version", constants.IMDSTokenApiVersion)
+		headers["Metadata"] = "true"
+	}
+
+	params.Set("resource", resource)
+	if clientId != "" {
+		params.Set("client_id", clientId)
+	}
+
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil); err != nil {
+		return nil, err
+	} else {
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return req, nil
+	}
+}
+
+// authenticateManagedIdentity requests a token from the managed identity endpoint, retrying while it is throttled or
+// unavailable until ctx is done
+func (s *restClient) authenticateManagedIdentity(ctx context.Context) error {
+	var (
+		resource   = strings.TrimSuffix(s.api.String(), "/") + "/"
+		maxRetries = 3
+	)
+
+	for retry := 0; retry < maxRetries; retry++ {
+		if req, err := managedIdentityRequest(ctx, resource, s.managedIdentityClientId); err != nil {
+			return err
+		} else if res, err := s.managedIdentity.Do(req); err != nil {
+			return fmt.Errorf("unable to reach the managed identity endpoint: %w", err)
+		} else if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+			// IMDS asks callers to back off and retry when throttled or while the identity is still being provisioned
+			res.Body.Close()
+			if err := wait(ctx, time.Second*time.Duration(retry+1)); err != nil {
+				return err
+			}
+			continue
+		} else if res.StatusCode != http.StatusOK {
+			defer res.Body.Close()
+			var errRes map[string]interface{}
+			if err := json.NewDecoder(res.Body).Decode(&errRes); err != nil {
+				return fmt.Errorf("unable to acquire managed identity token, status code: %d", res.StatusCode)
+			} else {
+				return fmt.Errorf("unable to acquire managed identity token, status code: %d: %v", res.StatusCode, errRes)
+			}
+		} else {
+			defer res.Body.Close()
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			return json.NewDecoder(res.Body).Decode(&s.token)
+		}
+	}
+	return fmt.Errorf("unable to acquire managed identity token after %d attempts", maxRetries)
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+func TestTokenUnmarshalManagedIdentity(t *testing.T) {
+	var token Token
+	// managed identity endpoints encode the expiry as a string
+	if err := json.Unmarshal([]byte(`{"access_token":"foo","expires_in":"3599","token_type":"Bearer"}`), &token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if token.expiresIn != 3599 {
+		t.Errorf("got %v, want %v", token.expiresIn, 3599)
+	} else if token.IsExpired() {
+		t.Error("expected token to be valid")
+	}
+
+	if err := json.Unmarshal([]byte(`{"access_token":"foo","expires_in":3599,"ext_expires_in":3599}`), &token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if token.expiresIn != 3599 || token.extExpiresIn != 3599 {
+		t.Errorf("got %v, want %v", token.expiresIn, 3599)
+	}
+}
+
+func TestManagedIdentityRequest(t *testing.T) {
+	t.Setenv(constants.IdentityEndpointEnv, "")
+	t.Setenv(constants.IdentityHeaderEnv, "")
+
+	if req, err := managedIdentityRequest(context.Background(), "https://management.azure.com/", "client"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if endpoint := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path; endpoint != constants.IMDSTokenUrl {
+		t.Errorf("got %v, want %v", endpoint, constants.IMDSTokenUrl)
+	} else if req.Header.Get("Metadata") != "true" {
+		t.Error("expected Metadata header to be set")
+	} else if query := req.URL.Query(); query.Get("resource") != "https://management.azure.com/" || query.Get("client_id") != "client" {
+		t.Errorf("unexpected query: %v", query)
+	}
+
+	t.Setenv(constants.IdentityEndpointEnv, "http://localhost:42356/msi/token")
+	t.Setenv(constants.IdentityHeaderEnv, "secret")
+
+	if req, err := managedIdentityRequest(context.Background(), "https://graph.microsoft.com/", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if req.URL.Host != "localhost:42356" {
+		t.Errorf("got %v, want %v", req.URL.Host, "localhost:42356")
+	} else if req.Header.Get("X-IDENTITY-HEADER") != "secret" {
+		t.Error("expected X-IDENTITY-HEADER header to be set")
+	} else if query := req.URL.Query(); query.Get("api-version") != constants.IdentityEndpointApiVersion || query.Has("client_id") {
+		t.Errorf("unexpected query: %v", query)
+	}
+}
+
+func TestManagedIdentityHTTPClientIgnoresProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://proxy.example.com:8080")
+
+	client := NewManagedIdentityHTTPClient()
+	if transport, ok := client.Transport.(*http.Transport); !ok {
+		t.Fatalf("failed type assertion: got %T, want %T", client.Transport, &http.Transport{})
+	} else if transport.Proxy != nil {
+		t.Error("expected no proxy to be configured for the managed identity endpoint")
+	}
+}
+
+func TestAuthenticateManagedIdentity(t *testing.T) {
+	var resource string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource = r.URL.Query().Get("resource")
+		w.Write([]byte(`{"access_token":"foo","expires_in":"3599","resource":"https://graph.microsoft.com/","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(constants.IdentityEndpointEnv, server.URL)
+	t.Setenv(constants.IdentityHeaderEnv, "secret")
+
+	api, _ := url.Parse("https://graph.microsoft.com")
+	client := &restClient{api: *api, managedIdentity: server.Client()}
+
+	if err := client.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if resource != "https://graph.microsoft.com/" {
+		t.Errorf("got %v, want %v", resource, "https://graph.microsoft.com/")
+	} else if client.token.String() != "Bearer foo" {
+		t.Errorf("got %v, want %v", client.token.String(), "Bearer foo")
+	} else if client.token.IsExpired() {
+		t.Error("expected token to be valid")
+	}
+}
+
+func TestAuthenticateManagedIdentityCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the identity is still being provisioned; the caller gives up while waiting to retry
+		time.AfterFunc(50*time.Millisecond, cancel)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv(constants.IdentityEndpointEnv, server.URL)
+	t.Setenv(constants.IdentityHeaderEnv, "secret")
+
+	api, _ := url.Parse("https://graph.microsoft.com")
+	client := &restClient{api: *api, managedIdentity: server.Client()}
+
+	start := time.Now()
+	if err := client.authenticateContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	} else if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected the retry to stop once the context was cancelled, took %v", elapsed)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+var ErrPKCS12Password = errors.New("incorrect PKCS#12 password")
+
+// ParsePKCS12 decodes a PKCS#12 (.pfx) bundle into the PEM encoded certificate chain and unencrypted PKCS#8 private
+// key used by NewClientAssertion. The certificate belonging to the private key comes first, followed by the rest of
+// the chain in the order it appears in the bundle.
+func ParsePKCS12(pfx []byte, password string) (string, string, error) {
+	var (
+		notImplemented pkcs12.NotImplementedError
+		key            *rsa.PrivateKey
+		leaf           []byte
+		chain          bytes.Buffer
+	)
+
+	blocks, err := pkcs12.ToPEM(pfx, password)
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) || errors.Is(err, pkcs12.ErrDecryption) {
+		return "", "", ErrPKCS12Password
+	} else if errors.As(err, &notImplemented) {
+		return "", "", fmt.Errorf("unsupported PKCS#12 encryption, re-export the bundle using 3DES (e.g. openssl pkcs12 -export -legacy): %w", err)
+	} else if err != nil {
+		return "", "", fmt.Errorf("unable to decode PKCS#12 bundle: %w", err)
+	}
+
+	for _, block := range blocks {
+		if block.Type == "PRIVATE KEY" {
+			if key != nil {
+				return "", "", fmt.Errorf("PKCS#12 bundle contains more than one private key")
+			} else if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return "", "", fmt.Errorf("unsupported PKCS#12 private key, only RSA keys can sign client assertions")
+			}
+		}
+	}
+
+	if key == nil {
+		return "", "", fmt.Errorf("PKCS#12 bundle does not contain a private key")
+	}
+
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			encoded := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes})
+			if cert, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return "", "", fmt.Errorf("unable to parse PKCS#12 certificate: %w", err)
+			} else if leaf == nil && key.PublicKey.Equal(cert.PublicKey) {
+				leaf = encoded
+			} else {
+				chain.Write(encoded)
+			}
+		}
+	}
+
+	if leaf == nil {
+		return "", "", fmt.Errorf("PKCS#12 bundle does not contain a certificate for its private key")
+	} else if der, err := x509.MarshalPKCS8PrivateKey(key); err != nil {
+		return "", "", fmt.Errorf("unable to encode PKCS#12 private key: %w", err)
+	} else {
+		return string(leaf) + chain.String(), string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	}
+}
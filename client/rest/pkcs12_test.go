@@ -0,0 +1,86 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func readPKCS12(t *testing.T, path string) []byte {
+	if content, err := os.ReadFile(path); err != nil {
+		t.Fatalf("unable to read %s: %v", path, err)
+		return nil
+	} else {
+		return content
+	}
+}
+
+func TestParsePKCS12(t *testing.T) {
+	cert, key, err := ParsePKCS12(readPKCS12(t, "testdata/chain.pfx"), "password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var subjects []string
+	for rest := []byte(cert); ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		} else if parsed, err := x509.ParseCertificate(block.Bytes); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else {
+			subjects = append(subjects, parsed.Subject.CommonName)
+		}
+	}
+
+	if strings.Join(subjects, ",") != "azurehound,AzureHound Test CA" {
+		t.Errorf("got certificates %v, want the signing certificate followed by its chain", subjects)
+	} else if _, err := parseRSAPrivateKey(key, ""); err != nil {
+		t.Errorf("unexpected error parsing key: %v", err)
+	}
+
+	if assertion, err := NewClientAssertion("https://login.microsoftonline.com/tenant/oauth2/v2.0/token", "client", cert, key, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if token, _, err := new(jwt.Parser).ParseUnverified(assertion, &jwt.StandardClaims{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if chain, ok := token.Header["x5c"].([]interface{}); !ok || len(chain) != 2 {
+		t.Errorf("got x5c %v, want 2 certificates", token.Header["x5c"])
+	}
+}
+
+func TestParsePKCS12WrongPassword(t *testing.T) {
+	if _, _, err := ParsePKCS12(readPKCS12(t, "testdata/chain.pfx"), "wrong"); !errors.Is(err, ErrPKCS12Password) {
+		t.Errorf("got %v, want %v", err, ErrPKCS12Password)
+	}
+}
+
+func TestParsePKCS12UnsupportedEncryption(t *testing.T) {
+	// OpenSSL 3 encrypts with AES by default, which is not supported
+	if _, _, err := ParsePKCS12(readPKCS12(t, "testdata/aes.pfx"), "password"); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if errors.Is(err, ErrPKCS12Password) || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("expected an unsupported encryption error: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+)
+
+// ResponseCache stores successful GET responses on disk so that repeated runs against the same tenant within the TTL
+// skip the network. It is meant for developing and testing collection only: cached responses go stale, are stored
+// unencrypted and are served without checking that the signed in principal may still read them.
+//
+// Responses are keyed by the full request URL, so each page of a listing, followed by its nextLink, is cached on its
+// own, along with the headers that change what is returned and the tenant and principal the client signs in as.
+type ResponseCache struct {
+	dir   string
+	ttl   time.Duration
+	scope string
+}
+
+type cachedResponse struct {
+	Url        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// the request headers that change the response of the same URL
+var responseCacheHeaders = []string{"ConsistencyLevel", "Prefer"}
+
+func NewResponseCache(dir string, ttl time.Duration, scope string) (*ResponseCache, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("the response cache ttl must be positive")
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	} else {
+		return &ResponseCache{dir: dir, ttl: ttl, scope: scope}, nil
+	}
+}
+
+// openResponseCache opens the configured response cache, scoped to the tenant and principal the client signs in as
+func openResponseCache(apiUrl string, config config.Config) (*ResponseCache, error) {
+	if config.CacheDir == "" {
+		return nil, nil
+	} else {
+		scope := []string{apiUrl, config.Tenant, config.ApplicationId, config.Username, config.ManagedIdentityClientId}
+		if body, err := ParseBody(config.JWT); err == nil {
+			// supplied tokens change between runs, the tenant and principal they were issued for don't
+			scope = append(scope, fmt.Sprint(body["tid"]), fmt.Sprint(body["oid"]))
+		}
+		return NewResponseCache(config.CacheDir, config.CacheTTL, strings.Join(scope, "\n"))
+	}
+}
+
+func (s *ResponseCache) path(req *http.Request) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s %s\n", s.scope, req.Method, req.URL.String())
+	for _, header := range responseCacheHeaders {
+		fmt.Fprintf(hash, "%s: %s\n", header, req.Header.Get(header))
+	}
+	return filepath.Join(s.dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// Get returns the cached response to req if one was stored within the TTL
+func (s *ResponseCache) Get(req *http.Request) (*http.Response, bool) {
+	var (
+		path   = s.path(req)
+		cached cachedResponse
+	)
+
+	if info, err := os.Stat(path); err != nil || time.Since(info.ModTime()) > s.ttl {
+		return nil, false
+	} else if content, err := os.ReadFile(path); err != nil {
+		return nil, false
+	} else if err := json.Unmarshal(content, &cached); err != nil || cached.Url != req.URL.String() {
+		return nil, false
+	} else {
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode)),
+			StatusCode:    cached.StatusCode,
+			Header:        cached.Header,
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, true
+	}
+}
+
+// Put stores res as the response to req, returning it with its body buffered in memory. The cache is best effort; a
+// response that can't be written is still returned.
+func (s *ResponseCache) Put(req *http.Request, res *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if content, err := json.Marshal(cachedResponse{Url: req.URL.String(), StatusCode: res.StatusCode, Header: res.Header, Body: body}); err != nil {
+		return res, nil
+	} else if file, err := os.CreateTemp(s.dir, ".response-*"); err != nil {
+		return res, nil
+	} else if _, err := file.Write(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+	} else if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+	} else if err := os.Rename(file.Name(), s.path(req)); err != nil {
+		os.Remove(file.Name())
+	}
+	return res, nil
+}
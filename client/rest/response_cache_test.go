@@ -0,0 +1,116 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.RawQuery]++
+		if r.URL.Query().Get("$skiptoken") == "a" {
+			w.Write([]byte(`{"value":[{"id":"2"}]}`))
+		} else {
+			w.Write([]byte(`{"value":[{"id":"1"}],"@odata.nextLink":"?$skiptoken=a"}`))
+		}
+	}))
+	defer server.Close()
+
+	cache, err := NewResponseCache(t.TempDir(), time.Hour, "tenant")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	api, _ := url.Parse(server.URL)
+	client := &restClient{api: *api, http: server.Client(), token: Token{accessToken: "token", expires: time.Now().Add(time.Hour)}, responseCache: cache}
+
+	get := func(skipToken string) string {
+		params := map[string]string{}
+		if skipToken != "" {
+			params["$skiptoken"] = skipToken
+		}
+		if res, err := client.Get(context.Background(), "/users", params, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if body, err := io.ReadAll(res.Body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else {
+			return string(body)
+		}
+		return ""
+	}
+
+	// each page is cached under its own link
+	for i := 0; i < 2; i++ {
+		if body := get(""); body != `{"value":[{"id":"1"}],"@odata.nextLink":"?$skiptoken=a"}` {
+			t.Errorf("got %v", body)
+		} else if body := get("a"); body != `{"value":[{"id":"2"}]}` {
+			t.Errorf("got %v", body)
+		}
+	}
+	if requests[""] != 1 || requests["%24skiptoken=a"] != 1 {
+		t.Errorf("expected each page to be requested once, got %v", requests)
+	}
+
+	// responses are requested again once they are older than the ttl
+	entries, _ := os.ReadDir(cache.dir)
+	for _, entry := range entries {
+		os.Chtimes(filepath.Join(cache.dir, entry.Name()), time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))
+	}
+	get("")
+	if requests[""] != 2 {
+		t.Errorf("expected an expired response to be requested again, got %v", requests)
+	}
+}
+
+func TestResponseCacheScope(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		req, _ = http.NewRequest(http.MethodGet, "https://graph.microsoft.com/v1.0/users", nil)
+		res    = &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(http.NoBody)}
+	)
+
+	first, _ := NewResponseCache(dir, time.Hour, "tenant1")
+	second, _ := NewResponseCache(dir, time.Hour, "tenant2")
+	if _, err := first.Put(req, res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok := first.Get(req); !ok {
+		t.Error("expected a cached response")
+	} else if _, ok := second.Get(req); ok {
+		t.Error("expected responses not to be shared across scopes")
+	}
+
+	req.Header.Set("ConsistencyLevel", "eventual")
+	if _, ok := first.Get(req); ok {
+		t.Error("expected responses to be keyed by the consistency level")
+	}
+}
+
+func TestNewResponseCacheInvalidTTL(t *testing.T) {
+	if _, err := NewResponseCache(t.TempDir(), 0, ""); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+var log = logr.Discard()
+
+// SetLogger sets the logger used to report the throttling guidance of the APIs
+func SetLogger(logger logr.Logger) {
+	log = logger
+}
+
+// throttleHints are the machine readable hints some 429 responses of Graph carry in their body beyond Retry-After
+type throttleHints struct {
+	// when requests are accepted again, as an RFC 3339 time
+	ThrottledUntil string `json:"throttledUntil"`
+	// which query pattern triggered the throttle and how to avoid it
+	RequestGuidance string `json:"requestGuidance"`
+}
+
+// parseThrottleHints reads the hints from the body of a 429 response, on its error or the error's inner error. A
+// body without them, or that isn't JSON, has no hints.
+func parseThrottleHints(body io.Reader) throttleHints {
+	var res struct {
+		Error struct {
+			throttleHints
+			InnerError throttleHints `json:"innerError"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(body, 1<<20)).Decode(&res); err != nil {
+		return throttleHints{}
+	}
+
+	hints := res.Error.throttleHints
+	if hints.ThrottledUntil == "" {
+		hints.ThrottledUntil = res.Error.InnerError.ThrottledUntil
+	}
+	if hints.RequestGuidance == "" {
+		hints.RequestGuidance = res.Error.InnerError.RequestGuidance
+	}
+	return hints
+}
+
+// throttleDelay returns how long to wait before retrying a throttled request: the seconds of its Retry-After header,
+// or without one the time until the throttle lifts according to hints, bounded by maxDelay
+func throttleDelay(retryAfterHeader string, hints throttleHints, now time.Time, maxDelay time.Duration) (time.Duration, error) {
+	if retryAfter, err := strconv.ParseInt(retryAfterHeader, 10, 64); err == nil {
+		return time.Second * time.Duration(retryAfter), nil
+	} else if until, untilErr := time.Parse(time.RFC3339, hints.ThrottledUntil); retryAfterHeader != "" || untilErr != nil {
+		return 0, fmt.Errorf("attempting to handle 429 but unable to parse retry-after header: %w", err)
+	} else if delay := until.Sub(now); delay <= 0 {
+		return 0, nil
+	} else if maxDelay > 0 && delay > maxDelay {
+		return maxDelay, nil
+	} else {
+		return delay, nil
+	}
+}
+
+// logThrottleGuidance logs the guidance of a throttled request the first time the API gives it
+func (s *restClient) logThrottleGuidance(endpoint string, hints throttleHints) {
+	if hints.RequestGuidance == "" {
+		return
+	} else if _, logged := s.throttleGuidance.LoadOrStore(hints.RequestGuidance, struct{}{}); !logged {
+		log.Info("warning: requests are being throttled", "api", s.api.String(), "endpoint", endpoint, "guidance", hints.RequestGuidance)
+	}
+}
+
+// endpointLimiter holds back the requests to a throttled endpoint until its throttle lifts. Endpoints are keyed by the
+// template of their path, e.g. /v1.0/groups/{id}/members, so a throttle of one family of requests doesn't slow the
+// requests to the rest of the API.
+type endpointLimiter struct {
+	mutex sync.Mutex
+	until map[string]time.Time
+}
+
+// throttle holds back the requests to endpoint until the given time
+func (s *endpointLimiter) throttle(endpoint string, until time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.until == nil {
+		s.until = make(map[string]time.Time)
+	}
+	if until.After(s.until[endpoint]) {
+		s.until[endpoint] = until
+	}
+}
+
+// delay returns how long a request to endpoint has to wait for its throttle to lift
+func (s *endpointLimiter) delay(endpoint string, now time.Time) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if until, ok := s.until[endpoint]; !ok {
+		return 0
+	} else if now.Before(until) {
+		return until.Sub(now)
+	} else {
+		delete(s.until, endpoint)
+		return 0
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+)
+
+// a throttled Graph response with the hints on the inner error, and one with them on the error itself
+const (
+	throttledBody      = `{"error":{"code":"TooManyRequests","message":"Too many requests.","innerError":{"code":"throttledRequest","date":"2024-05-02T10:15:00","request-id":"6f2c7a9e-1b3d-4f6a-9c1e-2d7b8a4e5f10","throttledUntil":"2024-05-02T10:15:30Z","requestGuidance":"Avoid $expand=members on /groups; page group members per group instead."}}}`
+	throttledBodyFlat  = `{"error":{"code":"TooManyRequests","message":"Too many requests.","throttledUntil":"2024-05-02T10:16:00Z","requestGuidance":"Reduce $top on /users/delta."}}`
+	throttledBodyPlain = `{"error":{"code":"TooManyRequests","message":"Too many requests.","innerError":{"date":"2024-05-02T10:15:00","request-id":"0d3b5c1a-7e2f-4a8b-b6c9-1f0e2d3c4b5a"}}}`
+)
+
+func TestParseThrottleHints(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want throttleHints
+	}{
+		{"inner error", throttledBody, throttleHints{"2024-05-02T10:15:30Z", "Avoid $expand=members on /groups; page group members per group instead."}},
+		{"error", throttledBodyFlat, throttleHints{"2024-05-02T10:16:00Z", "Reduce $top on /users/delta."}},
+		{"no hints", throttledBodyPlain, throttleHints{}},
+		{"not json", "Too Many Requests", throttleHints{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseThrottleHints(strings.NewReader(test.body)); got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	var (
+		now   = time.Date(2024, 5, 2, 10, 15, 0, 0, time.UTC)
+		hints = throttleHints{ThrottledUntil: "2024-05-02T10:15:30Z"}
+	)
+
+	if delay, err := throttleDelay("5", hints, now, time.Minute); err != nil || delay != 5*time.Second {
+		t.Errorf("got %v, %v; want the retry-after header to take precedence", delay, err)
+	} else if delay, err := throttleDelay("", hints, now, time.Minute); err != nil || delay != 30*time.Second {
+		t.Errorf("got %v, %v; want a wait until the throttle lifts", delay, err)
+	} else if delay, err := throttleDelay("", hints, now, 10*time.Second); err != nil || delay != 10*time.Second {
+		t.Errorf("got %v, %v; want the wait bounded by the maximum delay", delay, err)
+	} else if delay, err := throttleDelay("", hints, now.Add(time.Minute), time.Minute); err != nil || delay != 0 {
+		t.Errorf("got %v, %v; want no wait once the throttle has lifted", delay, err)
+	} else if _, err := throttleDelay("", throttleHints{}, now, time.Minute); err == nil {
+		t.Error("expected an error without a retry-after header or hints")
+	} else if _, err := throttleDelay("soon", hints, now, time.Minute); err == nil {
+		t.Error("expected an error for a malformed retry-after header")
+	}
+}
+
+func TestSendLogsThrottleGuidanceOnce(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		logged   []string
+		requests = map[string]int{}
+	)
+	SetLogger(funcr.New(func(prefix, args string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		logged = append(logged, args)
+	}, funcr.Options{}))
+	t.Cleanup(func() { SetLogger(logr.Discard()) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.URL.Path]++
+		attempt := requests[r.URL.Path]
+		mutex.Unlock()
+
+		if attempt > 1 {
+			w.Write([]byte(`{"value":[]}`))
+			return
+		}
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		if r.URL.Path == "/users" {
+			w.Write([]byte(throttledBodyFlat))
+		} else {
+			w.Write([]byte(throttledBody))
+		}
+	}))
+	defer server.Close()
+
+	client := &restClient{http: server.Client(), maxRetries: 1}
+	for _, path := range []string{"/groups", "/groups/1/members", "/users"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if res, err := client.send(req); err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		} else {
+			res.Body.Close()
+		}
+	}
+
+	if len(logged) != 2 {
+		t.Fatalf("got %v log lines, want one for each guidance: %v", len(logged), logged)
+	} else if !strings.Contains(logged[0], "Avoid $expand=members on /groups") || !strings.Contains(logged[0], `"endpoint"="/groups"`) {
+		t.Errorf("unexpected log line: %s", logged[0])
+	} else if !strings.Contains(logged[1], "Reduce $top on /users/delta.") {
+		t.Errorf("unexpected log line: %s", logged[1])
+	}
+}
+
+func TestSendThrottlesEndpoint(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests = map[string]int{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.URL.Path]++
+		mutex.Unlock()
+
+		if r.URL.Path == "/v1.0/groups/1/members" {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(throttledBody))
+		} else {
+			w.Write([]byte(`{"value":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &restClient{http: server.Client(), maxRetries: 1}
+	send := func(path string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		if res, err := client.send(req); err != nil {
+			return err
+		} else {
+			return res.Body.Close()
+		}
+	}
+
+	if err := send("/v1.0/groups/1/members"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the throttled request to wait past its deadline", err)
+	} else if err := send("/v1.0/groups/2/members"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want a request to the throttled endpoint held back", err)
+	} else if err := send("/v1.0/users"); err != nil {
+		t.Errorf("got %v, want requests to other endpoints to proceed", err)
+	} else if err := send("/v1.0/groups/2/owners"); err != nil {
+		t.Errorf("got %v, want requests to other endpoints to proceed", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if requests["/v1.0/groups/1/members"] != 1 {
+		t.Errorf("got %v requests to the throttled endpoint, want 1", requests["/v1.0/groups/1/members"])
+	} else if requests["/v1.0/groups/2/members"] != 0 {
+		t.Errorf("got %v requests held back by the throttle, want 0", requests["/v1.0/groups/2/members"])
+	} else if delay := client.endpoints.delay("/v1.0/groups/{id}/members", time.Now()); delay <= 0 || delay > 30*time.Second {
+		t.Errorf("got %v, want the group members endpoint throttled for up to 30s", delay)
+	}
+}
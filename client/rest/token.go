@@ -25,6 +25,7 @@ import (
 
 type Token struct {
 	accessToken  string
+	refreshToken string
 	expiresIn    int
 	extExpiresIn int
 	expires      time.Time
@@ -40,19 +41,35 @@ func (s Token) String() string {
 
 func (s *Token) UnmarshalJSON(data []byte) error {
 	var res struct {
-		AccessToken  string `json:"access_token"`   // The token to use in calls to Microsoft Graph API
-		ExpiresIn    int    `json:"expires_in"`     // How long the access token is valid in seconds
-		ExtExpiresIn int    `json:"ext_expires_in"` // How long the access token is valid in seconds
-		TokenType    string `json:"token_type"`     // Indicates the token type value. The only type currently supported by Azure AD is `bearer`
+		AccessToken  string      `json:"access_token"`   // The token to use in calls to Microsoft Graph API
+		ExpiresIn    json.Number `json:"expires_in"`     // How long the access token is valid in seconds; managed identity endpoints send this as a string
+		ExtExpiresIn json.Number `json:"ext_expires_in"` // How long the access token is valid in seconds
+		TokenType    string      `json:"token_type"`     // Indicates the token type value. The only type currently supported by Azure AD is `bearer`
+		RefreshToken string      `json:"refresh_token"`  // Only issued to user flows; can be redeemed for a new access token
 	}
 
 	if err := json.Unmarshal(data, &res); err != nil {
 		return err
+	} else if expiresIn, err := parseSeconds(res.ExpiresIn); err != nil {
+		return fmt.Errorf("invalid expires_in: %w", err)
+	} else if extExpiresIn, err := parseSeconds(res.ExtExpiresIn); err != nil {
+		return fmt.Errorf("invalid ext_expires_in: %w", err)
 	} else {
 		s.accessToken = res.AccessToken
-		s.expiresIn = res.ExpiresIn
-		s.extExpiresIn = res.ExtExpiresIn
-		s.expires = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+		s.refreshToken = res.RefreshToken
+		s.expiresIn = expiresIn
+		s.extExpiresIn = extExpiresIn
+		s.expires = time.Now().Add(time.Duration(expiresIn) * time.Second)
 		return nil
 	}
 }
+
+func parseSeconds(value json.Number) (int, error) {
+	if value == "" {
+		return 0, nil
+	} else if seconds, err := value.Int64(); err != nil {
+		return 0, err
+	} else {
+		return int(seconds), nil
+	}
+}
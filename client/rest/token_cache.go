@@ -0,0 +1,300 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	tokenCacheVersion    = 1
+	protectionPassphrase = "passphrase"
+	protectionDPAPI      = "dpapi"
+)
+
+// ErrTokenCacheDecrypt is matched by errors returned when the token cache can't be decrypted, most likely because it
+// was written with another passphrase or by another Windows user.
+var ErrTokenCacheDecrypt = errors.New("unable to decrypt the token cache")
+
+// TokenCache persists the tokens acquired by a client in an encrypted file so that later runs can reuse them instead
+// of signing in again. The file is encrypted with a key derived from a passphrase or, on Windows without a passphrase,
+// protected with DPAPI for the current user.
+type TokenCache struct {
+	path       string
+	passphrase string
+	mutex      sync.Mutex
+
+	// the key derived from the passphrase, along with the salt it was derived with
+	salt []byte
+	key  []byte
+}
+
+type tokenCacheFile struct {
+	Version    int    `json:"version"`
+	Protection string `json:"protection"`
+	Salt       []byte `json:"salt,omitempty"`
+	Data       []byte `json:"data"`
+}
+
+type cachedToken struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Expires      time.Time `json:"expires"`
+}
+
+var (
+	tokenCachesMutex sync.Mutex
+	tokenCaches      = map[string]*TokenCache{}
+)
+
+// OpenTokenCache returns the token cache stored at path, which is created on first use. Clients opening the same path
+// share a TokenCache so that their writes don't race.
+func OpenTokenCache(path, passphrase string) (*TokenCache, error) {
+	if passphrase == "" && !dpapiSupported {
+		return nil, fmt.Errorf("a passphrase is required to encrypt the token cache")
+	} else if absolute, err := filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("invalid token cache path: %w", err)
+	} else {
+		tokenCachesMutex.Lock()
+		defer tokenCachesMutex.Unlock()
+		if cache, ok := tokenCaches[absolute]; ok && cache.passphrase == passphrase {
+			return cache, nil
+		} else {
+			cache := &TokenCache{path: absolute, passphrase: passphrase}
+			tokenCaches[absolute] = cache
+			return cache, nil
+		}
+	}
+}
+
+// CachedRefreshToken returns the refresh token cached for signing in users of the configured tenant, if any. User
+// tokens are issued to the Azure PowerShell client, so the refresh token cached for Microsoft Graph can be redeemed for
+// the Resource Manager audience as well.
+func CachedRefreshToken(config config.Config) (string, error) {
+	if cache, err := OpenTokenCache(config.TokenCache, config.TokenCachePassphrase); err != nil {
+		return "", err
+	} else if token, _, err := cache.Load(newTokenCacheKey(config.Tenant, constants.AzPowerShellClientID, config.GraphUrl())); err != nil {
+		return "", err
+	} else {
+		return token.refreshToken, nil
+	}
+}
+
+func newTokenCacheKey(tenant, clientId, audience string) string {
+	return strings.Join([]string{tenant, clientId, audience}, "|")
+}
+
+// Load returns the token cached under key
+func (s *TokenCache) Load(key string) (Token, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entries, err := s.read(); err != nil {
+		return Token{}, false, err
+	} else if entry, ok := entries[key]; !ok {
+		return Token{}, false, nil
+	} else {
+		return Token{accessToken: entry.AccessToken, refreshToken: entry.RefreshToken, expires: entry.Expires}, true, nil
+	}
+}
+
+// Store caches token under key, replacing the token cached before
+func (s *TokenCache) Store(key string, token Token) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entries, err := s.read(); err != nil {
+		return err
+	} else {
+		entries[key] = cachedToken{AccessToken: token.accessToken, RefreshToken: token.refreshToken, Expires: token.expires}
+		return s.write(entries)
+	}
+}
+
+// Remove drops the token cached under key
+func (s *TokenCache) Remove(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entries, err := s.read(); err != nil {
+		return err
+	} else if _, ok := entries[key]; !ok {
+		return nil
+	} else {
+		delete(entries, key)
+		return s.write(entries)
+	}
+}
+
+// read decrypts the cache file; a missing file is an empty cache. The file is read on every call since other
+// processes may have updated it.
+func (s *TokenCache) read() (map[string]cachedToken, error) {
+	var (
+		file    tokenCacheFile
+		entries = map[string]cachedToken{}
+	)
+
+	if content, err := os.ReadFile(s.path); errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read token cache: %w", err)
+	} else if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse token cache %s: %w", s.path, err)
+	} else if file.Version != tokenCacheVersion {
+		return nil, fmt.Errorf("unsupported token cache version %d in %s", file.Version, s.path)
+	} else if plaintext, err := s.open(file); err != nil {
+		return nil, fmt.Errorf("%w %s: %v; delete it to start over", ErrTokenCacheDecrypt, s.path, err)
+	} else if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("unable to parse token cache %s: %w", s.path, err)
+	} else {
+		return entries, nil
+	}
+}
+
+// write encrypts entries and replaces the cache file with them. Tokens that expired and can't be refreshed are
+// dropped along the way.
+func (s *TokenCache) write(entries map[string]cachedToken) error {
+	for key, entry := range entries {
+		if entry.RefreshToken == "" && time.Now().After(entry.Expires) {
+			delete(entries, key)
+		}
+	}
+
+	if plaintext, err := json.Marshal(entries); err != nil {
+		return err
+	} else if file, err := s.seal(plaintext); err != nil {
+		return fmt.Errorf("unable to encrypt token cache: %w", err)
+	} else if content, err := json.Marshal(file); err != nil {
+		return err
+	} else if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("unable to create token cache directory: %w", err)
+	} else if temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*"); err != nil {
+		return fmt.Errorf("unable to write token cache: %w", err)
+	} else {
+		// write to a temporary file first so that other processes never read a partially written cache
+		defer os.Remove(temp.Name())
+		if _, err := temp.Write(content); err != nil {
+			temp.Close()
+			return fmt.Errorf("unable to write token cache: %w", err)
+		} else if err := temp.Close(); err != nil {
+			return fmt.Errorf("unable to write token cache: %w", err)
+		} else if err := os.Chmod(temp.Name(), 0600); err != nil {
+			return fmt.Errorf("unable to write token cache: %w", err)
+		} else if err := os.Rename(temp.Name(), s.path); err != nil {
+			return fmt.Errorf("unable to write token cache: %w", err)
+		} else {
+			return nil
+		}
+	}
+}
+
+func (s *TokenCache) seal(plaintext []byte) (tokenCacheFile, error) {
+	file := tokenCacheFile{Version: tokenCacheVersion}
+	if s.passphrase == "" {
+		file.Protection = protectionDPAPI
+		if data, err := dpapiProtect(plaintext); err != nil {
+			return file, err
+		} else {
+			file.Data = data
+			return file, nil
+		}
+	}
+
+	if s.key == nil {
+		salt := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return file, err
+		} else if err := s.deriveKey(salt); err != nil {
+			return file, err
+		}
+	}
+
+	if gcm, err := newGCM(s.key); err != nil {
+		return file, err
+	} else {
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return file, err
+		}
+		file.Protection = protectionPassphrase
+		file.Salt = s.salt
+		file.Data = gcm.Seal(nonce, nonce, plaintext, nil)
+		return file, nil
+	}
+}
+
+func (s *TokenCache) open(file tokenCacheFile) ([]byte, error) {
+	switch file.Protection {
+	case protectionDPAPI:
+		if s.passphrase != "" {
+			return nil, fmt.Errorf("the cache is protected with dpapi but a passphrase was supplied")
+		}
+		return dpapiUnprotect(file.Data)
+	case protectionPassphrase:
+		if s.passphrase == "" {
+			return nil, fmt.Errorf("the cache is encrypted with a passphrase but none was supplied")
+		} else if err := s.deriveKey(file.Salt); err != nil {
+			return nil, err
+		} else if gcm, err := newGCM(s.key); err != nil {
+			return nil, err
+		} else if len(file.Data) < gcm.NonceSize() {
+			return nil, fmt.Errorf("the cache is truncated")
+		} else {
+			nonce, ciphertext := file.Data[:gcm.NonceSize()], file.Data[gcm.NonceSize():]
+			return gcm.Open(nil, nonce, ciphertext, nil)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported protection %q", file.Protection)
+	}
+}
+
+// deriveKey derives the encryption key from the passphrase, reusing the previous key if the salt didn't change
+func (s *TokenCache) deriveKey(salt []byte) error {
+	if s.key != nil && string(s.salt) == string(salt) {
+		return nil
+	} else if key, err := scrypt.Key([]byte(s.passphrase), salt, 1<<15, 8, 1, 32); err != nil {
+		return err
+	} else {
+		s.salt = salt
+		s.key = key
+		return nil
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if block, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	} else {
+		return cipher.NewGCM(block)
+	}
+}
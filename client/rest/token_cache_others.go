@@ -0,0 +1,33 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !windows
+// +build !windows
+
+package rest
+
+import "fmt"
+
+const dpapiSupported = false
+
+func dpapiProtect(data []byte) ([]byte, error) {
+	return nil, fmt.Errorf("dpapi is only available on windows")
+}
+
+func dpapiUnprotect(data []byte) ([]byte, error) {
+	return nil, fmt.Errorf("dpapi is only available on windows")
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+func TestTokenCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	cache := &TokenCache{path: path, passphrase: "passphrase"}
+
+	token := Token{accessToken: "access", refreshToken: "refresh", expires: time.Now().Add(time.Hour)}
+	if err := cache.Store("key", token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := cache.Store("expired", Token{accessToken: "expired", expires: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if content, err := os.ReadFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if strings.Contains(string(content), "access") || strings.Contains(string(content), "refresh") {
+		t.Error("expected the tokens to be encrypted")
+	}
+
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	// a new cache has to derive the key from the passphrase again
+	reopened := &TokenCache{path: path, passphrase: "passphrase"}
+	if got, ok, err := reopened.Load("key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !ok {
+		t.Error("expected the token to be cached")
+	} else if got.accessToken != token.accessToken || got.refreshToken != token.refreshToken || !got.expires.Equal(token.expires) {
+		t.Errorf("got %+v, want %+v", got, token)
+	} else if _, ok, _ := reopened.Load("expired"); ok {
+		t.Error("expected expired tokens that can't be refreshed to be dropped")
+	}
+
+	if err := reopened.Remove("key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok, _ := cache.Load("key"); ok {
+		t.Error("expected the token to be removed")
+	}
+
+	wrong := &TokenCache{path: path, passphrase: "wrong"}
+	if _, _, err := wrong.Load("key"); !errors.Is(err, ErrTokenCacheDecrypt) {
+		t.Errorf("got error %v, want %v", err, ErrTokenCacheDecrypt)
+	}
+}
+
+func TestOpenTokenCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if first, err := OpenTokenCache(path, "passphrase"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if second, err := OpenTokenCache(path, "passphrase"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if first != second {
+		t.Error("expected clients of the same cache file to share it")
+	}
+
+	if _, err := OpenTokenCache(path, ""); !dpapiSupported && err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+// tokenServer serves the token endpoint and an API that only accepts the most recently issued access token
+type tokenServer struct {
+	mutex    sync.Mutex
+	issued   int
+	current  string
+	redeemed []string
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+		r.ParseForm()
+		if r.Form.Get("grant_type") == "refresh_token" {
+			s.redeemed = append(s.redeemed, r.Form.Get("refresh_token"))
+		}
+		s.issued++
+		s.current = "token" + string(rune('0'+s.issued))
+		w.Write([]byte(`{"access_token":"` + s.current + `","refresh_token":"refresh-` + s.current + `","expires_in":3599}`))
+	} else if r.Header.Get("Authorization") != "Bearer "+s.current {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"code":"InvalidAuthenticationToken"}}`))
+	} else {
+		w.Write([]byte(`{}`))
+	}
+}
+
+func newTokenCacheClient(t *testing.T, server *httptest.Server, path string) *restClient {
+	cfg := config.Config{
+		Authority:            server.URL,
+		Password:             "password",
+		Tenant:               "tenant",
+		TokenCache:           path,
+		TokenCachePassphrase: "passphrase",
+		Username:             "user",
+	}
+	if client, err := NewRestClient(server.URL, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+		return nil
+	} else {
+		return client.(*restClient)
+	}
+}
+
+func TestAuthenticateTokenCache(t *testing.T) {
+	var (
+		tokens = &tokenServer{}
+		server = httptest.NewServer(tokens)
+		path   = filepath.Join(t.TempDir(), "tokens.json")
+	)
+	defer server.Close()
+
+	if err := newTokenCacheClient(t, server, path).Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a later run reuses the unexpired token
+	client := newTokenCacheClient(t, server, path)
+	if err := client.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if tokens.issued != 1 {
+		t.Errorf("got %d token requests, want 1", tokens.issued)
+	} else if client.token.accessToken != "token1" || !client.tokenFromCache {
+		t.Errorf("got token %s, want the cached token1", client.token.accessToken)
+	}
+
+	// once the access token expires, the cached refresh token is redeemed instead of signing in again
+	key := newTokenCacheKey("tenant", constants.AzPowerShellClientID, server.URL)
+	client.tokenCache.Store(key, Token{accessToken: "token1", refreshToken: "refresh-token1", expires: time.Now().Add(-time.Minute)})
+	if err := client.Authenticate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(tokens.redeemed) != 1 || tokens.redeemed[0] != "refresh-token1" {
+		t.Errorf("got redeemed refresh tokens %v, want [refresh-token1]", tokens.redeemed)
+	} else if cached, _, _ := client.tokenCache.Load(key); cached.accessToken != "token2" || cached.refreshToken != "refresh-token2" {
+		t.Errorf("got cached token %+v, want token2", cached)
+	}
+}
+
+func TestSendTokenCacheUnauthorized(t *testing.T) {
+	var (
+		tokens = &tokenServer{current: "token0"}
+		server = httptest.NewServer(tokens)
+		path   = filepath.Join(t.TempDir(), "tokens.json")
+		key    = newTokenCacheKey("tenant", constants.AzPowerShellClientID, server.URL)
+	)
+	defer server.Close()
+
+	// the cached token has been revoked
+	client := newTokenCacheClient(t, server, path)
+	client.tokenCache.Store(key, Token{accessToken: "revoked", expires: time.Now().Add(time.Hour)})
+
+	if res, err := client.Get(context.Background(), "/v1.0/users", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); tokens.issued != 1 {
+		t.Errorf("got %d token requests, want 1", tokens.issued)
+	} else if cached, _, _ := client.tokenCache.Load(key); cached.accessToken != "token1" {
+		t.Errorf("got cached token %s, want token1", cached.accessToken)
+	}
+
+	// tokens that weren't cached are still dropped from the cache when they are rejected
+	tokens.current = "other"
+	if _, err := client.Get(context.Background(), "/v1.0/users", nil, nil); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got error %v, want %v", err, ErrUnauthorized)
+	} else if _, ok, _ := client.tokenCache.Load(key); ok {
+		t.Error("expected the rejected token to be removed from the cache")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const dpapiSupported = true
+
+// dpapiProtect encrypts data for the current Windows user
+func dpapiProtect(data []byte) ([]byte, error) {
+	var out windows.DataBlob
+	if err := windows.CryptProtectData(newDataBlob(data), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	} else {
+		defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+		return append([]byte{}, unsafe.Slice(out.Data, out.Size)...), nil
+	}
+}
+
+// dpapiUnprotect decrypts data encrypted by dpapiProtect for the same user
+func dpapiUnprotect(data []byte) ([]byte, error) {
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(newDataBlob(data), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	} else {
+		defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+		return append([]byte{}, unsafe.Slice(out.Data, out.Size)...), nil
+	}
+}
+
+func newDataBlob(data []byte) *windows.DataBlob {
+	if len(data) == 0 {
+		return &windows.DataBlob{}
+	} else {
+		return &windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+)
+
+// TokenSource authorizes requests to a service other than Graph and Resource Manager with the configured credential
+type TokenSource interface {
+	// Authorization returns the value of the Authorization header, signing in again once the token has expired
+	Authorization() (string, error)
+}
+
+// NewTokenSource returns a TokenSource for resource that signs in the same way as a RestClient for config would.
+// Supplied tokens are only valid for the API they were issued for, so they cannot be used.
+func NewTokenSource(resource string, config config.Config) (TokenSource, error) {
+	if config.JWT != "" {
+		return nil, fmt.Errorf("a supplied token cannot be used for %s", resource)
+	} else if client, err := NewRestClient(resource, config); err != nil {
+		return nil, err
+	} else {
+		return client.(*restClient), nil
+	}
+}
+
+func (s *restClient) Authorization() (string, error) {
+	if s.token.IsExpired() {
+		if err := s.Authenticate(); err != nil {
+			return "", fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+		}
+	}
+	return s.token.String(), nil
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+func TestTokenSourceManagedIdentity(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("resource"))
+		w.Write([]byte(`{"access_token":"foo","expires_in":"3599","resource":"https://storage.azure.com/","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(constants.IdentityEndpointEnv, server.URL)
+	t.Setenv(constants.IdentityHeaderEnv, "secret")
+
+	if source, err := NewTokenSource(constants.StorageResourceUrl, config.Config{ManagedIdentity: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if authorization, err := source.Authorization(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if authorization != "Bearer foo" {
+		t.Errorf("got %v, want %v", authorization, "Bearer foo")
+	} else if _, err := source.Authorization(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(requests) != 1 || requests[0] != "https://storage.azure.com/" {
+		t.Errorf("got token requests for %v, want a single one for %v", requests, "https://storage.azure.com/")
+	}
+}
+
+func TestTokenSourceSuppliedToken(t *testing.T) {
+	if _, err := NewTokenSource(constants.StorageResourceUrl, config.Config{JWT: "foo"}); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
@@ -18,11 +18,14 @@
 package rest
 
 import (
+	"context"
 	"crypto/sha1"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -33,11 +36,48 @@ import (
 	"github.com/youmark/pkcs8"
 )
 
+// ErrAborted is wrapped together with the context error by operations that stopped because their context ended
+var ErrAborted = errors.New("operation aborted")
+
 func Decode(body io.ReadCloser, v interface{}) error {
 	defer body.Close()
 	return json.NewDecoder(body).Decode(v)
 }
 
+// DecodeContext decodes body the same way as Decode but stops between reads once ctx is done
+func DecodeContext(ctx context.Context, body io.ReadCloser, v interface{}) error {
+	defer body.Close()
+	return json.NewDecoder(NewContextReader(ctx, body)).Decode(v)
+}
+
+// Aborted returns an error wrapping both ErrAborted and the context error once ctx is done, otherwise nil
+func Aborted(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrAborted, err)
+	} else {
+		return nil
+	}
+}
+
+// NewContextReader returns a reader that fails with Aborted(ctx) once ctx is done. Decoders read in small chunks so
+// a large body stops decoding promptly instead of after the whole value has been read.
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return contextReader{ctx: ctx, reader: r}
+}
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (s contextReader) Read(p []byte) (int, error) {
+	if err := Aborted(s.ctx); err != nil {
+		return 0, err
+	} else {
+		return s.reader.Read(p)
+	}
+}
+
 func NewClientAssertion(tokenUrl string, clientId string, clientCert string, signingKey string, keyPassphrase string) (string, error) {
 	if key, err := parseRSAPrivateKey(signingKey, keyPassphrase); err != nil {
 		return "", fmt.Errorf("Unable to parse private key: %w", err)
@@ -45,6 +85,8 @@ func NewClientAssertion(tokenUrl string, clientId string, clientCert string, sig
 		return "", fmt.Errorf("Unable to generate JWT ID: %w", err)
 	} else if thumbprint, err := x5t(clientCert); err != nil {
 		return "", fmt.Errorf("Unable to create X.509 certificate thumbprint: %w", err)
+	} else if chain, err := x5c(clientCert); err != nil {
+		return "", fmt.Errorf("Unable to create X.509 certificate chain: %w", err)
 	} else {
 		iat := time.Now()
 		exp := iat.Add(1 * time.Minute)
@@ -62,6 +104,7 @@ func NewClientAssertion(tokenUrl string, clientId string, clientCert string, sig
 			"alg": "RS256",
 			"typ": "JWT",
 			"x5t": thumbprint,
+			"x5c": chain,
 		}
 
 		if signedToken, err := token.SignedString(key); err != nil {
@@ -80,7 +123,7 @@ func ParseBody(accessToken string) (map[string]interface{}, error) {
 
 	if len(parts) != 3 {
 		return body, fmt.Errorf("invalid access token")
-	} else if bytes, err := base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+	} else if bytes, err := base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
 		return body, err
 	} else if err := json.Unmarshal(bytes, &body); err != nil {
 		return body, err
@@ -99,6 +142,17 @@ func ParseAud(accessToken string) (string, error) {
 	}
 }
 
+// ParseExpiry returns the time an access token expires at, taken from its exp claim
+func ParseExpiry(accessToken string) (time.Time, error) {
+	if body, err := ParseBody(accessToken); err != nil {
+		return time.Time{}, err
+	} else if exp, ok := body["exp"].(float64); !ok {
+		return time.Time{}, fmt.Errorf("invalid 'exp' type: %T", body["exp"])
+	} else {
+		return time.Unix(int64(exp), 0), nil
+	}
+}
+
 func parseRSAPrivateKey(signingKey string, password string) (interface{}, error) {
 	if decodedBlock, _ := pem.Decode([]byte(signingKey)); decodedBlock == nil {
 		return nil, fmt.Errorf("Unable to decode private key")
@@ -109,6 +163,20 @@ func parseRSAPrivateKey(signingKey string, password string) (interface{}, error)
 	}
 }
 
+// X509KeyPair parses a PEM encoded certificate chain and its private key, which is decrypted with password when it
+// is an encrypted PKCS#8 key, for TLS client authentication
+func X509KeyPair(certPEM, keyPEM []byte, password string) (tls.Certificate, error) {
+	if password == "" {
+		return tls.X509KeyPair(certPEM, keyPEM)
+	} else if key, err := parseRSAPrivateKey(string(keyPEM), password); err != nil {
+		return tls.Certificate{}, fmt.Errorf("unable to decrypt private key: %w", err)
+	} else if der, err := x509.MarshalPKCS8PrivateKey(key); err != nil {
+		return tls.Certificate{}, err
+	} else {
+		return tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	}
+}
+
 func x5t(certificate string) (string, error) {
 	if decoded, _ := pem.Decode([]byte(certificate)); decoded == nil {
 		return "", fmt.Errorf("Unable to decode certificate")
@@ -119,3 +187,27 @@ func x5t(certificate string) (string, error) {
 		return base64.StdEncoding.EncodeToString(checksum[:]), nil
 	}
 }
+
+// x5c returns the certificate chain for the x5c header, one base64 encoded DER certificate per PEM block with the
+// signing certificate first
+func x5c(certificate string) ([]string, error) {
+	var (
+		chain []string
+		rest  = []byte(certificate)
+		block *pem.Block
+	)
+
+	for {
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		} else if block.Type == "CERTIFICATE" {
+			chain = append(chain, base64.StdEncoding.EncodeToString(block.Bytes))
+		}
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("Unable to decode certificate")
+	} else {
+		return chain, nil
+	}
+}
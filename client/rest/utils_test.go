@@ -0,0 +1,85 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// repeatReader endlessly repeats chunk, simulating a body too large to finish decoding
+type repeatReader struct {
+	chunk  string
+	offset int
+}
+
+func (s *repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		copied := copy(p[n:], s.chunk[s.offset:])
+		s.offset = (s.offset + copied) % len(s.chunk)
+		n += copied
+	}
+	return n, nil
+}
+
+func TestDecodeContextCancelled(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		body        = io.NopCloser(io.MultiReader(strings.NewReader(`{"value":[`), &repeatReader{chunk: `{"id":"00000000-0000-0000-0000-000000000000"},`}))
+		done        = make(chan error, 1)
+		value       interface{}
+	)
+	defer cancel()
+
+	go func() {
+		done <- DecodeContext(ctx, body, &value)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	cancelled := time.Now()
+
+	select {
+	case err := <-done:
+		if elapsed := time.Since(cancelled); elapsed > 250*time.Millisecond {
+			t.Errorf("decode took %v to return after cancellation", elapsed)
+		}
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrAborted) {
+			t.Errorf("got %v, want %v", err, ErrAborted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("decode did not return after cancellation")
+	}
+}
+
+func TestAborted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := Aborted(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cancel()
+	if err := Aborted(ctx); !errors.Is(err, context.Canceled) || !errors.Is(err, ErrAborted) {
+		t.Errorf("got %v, want %v", err, ErrAborted)
+	}
+}
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -37,7 +36,7 @@ func (s *azureClient) GetAzureADRoleAssignment(ctx context.Context, objectId str
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -59,7 +58,7 @@ func (s *azureClient) GetAzureADRoleAssignments(ctx context.Context, filter, sea
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -74,43 +73,22 @@ func (s *azureClient) ListAzureADRoleAssignments(ctx context.Context, filter, se
 
 		var (
 			errResult = azure.UnifiedRoleAssignmentResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADRoleAssignments(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.UnifiedRoleAssignmentList, error) {
+				return s.GetAzureADRoleAssignments(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.UnifiedRoleAssignmentList) ([]azure.UnifiedRoleAssignment, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.UnifiedRoleAssignment) string { return u.Id },
+			func(u azure.UnifiedRoleAssignment) {
+				out <- azure.UnifiedRoleAssignmentResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.UnifiedRoleAssignmentResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.UnifiedRoleAssignmentList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.UnifiedRoleAssignmentResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -126,7 +104,7 @@ func (s *azureClient) GetRoleAssignmentsForResource(ctx context.Context, resourc
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -142,49 +120,24 @@ func (s *azureClient) ListRoleAssignmentsForResource(ctx context.Context, resour
 
 		var (
 			errResult = azure.RoleAssignmentResult{ParentId: resourceId}
-			nextLink  string
 		)
 
-		if result, err := s.GetRoleAssignmentsForResource(ctx, resourceId, filter); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range result.Value {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.RoleAssignmentList, error) {
+				return s.GetRoleAssignmentsForResource(ctx, resourceId, filter)
+			},
+			func(list azure.RoleAssignmentList) ([]azure.RoleAssignment, string) { return list.Value, list.NextLink },
+			func(u azure.RoleAssignment) string { return u.Id },
+			func(u azure.RoleAssignment) {
+				s.resolvePrincipalType(ctx, &u)
 				out <- azure.RoleAssignmentResult{
 					ParentId: resourceId,
 					Ok:       u,
 				}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.RoleAssignmentList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.RoleAssignmentResult{
-							ParentId: resourceId,
-							Ok:       u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -200,7 +153,7 @@ func (s *azureClient) GetResourceRoleAssignments(ctx context.Context, subscripti
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -215,49 +168,24 @@ func (s *azureClient) ListResourceRoleAssignments(ctx context.Context, subscript
 
 		var (
 			errResult = azure.RoleAssignmentResult{ParentId: subscriptionId}
-			nextLink  string
 		)
 
-		if result, err := s.GetResourceRoleAssignments(ctx, subscriptionId, filter, expand); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range result.Value {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.RoleAssignmentList, error) {
+				return s.GetResourceRoleAssignments(ctx, subscriptionId, filter, expand)
+			},
+			func(list azure.RoleAssignmentList) ([]azure.RoleAssignment, string) { return list.Value, list.NextLink },
+			func(u azure.RoleAssignment) string { return u.Id },
+			func(u azure.RoleAssignment) {
+				s.resolvePrincipalType(ctx, &u)
 				out <- azure.RoleAssignmentResult{
 					ParentId: subscriptionId,
 					Ok:       u,
 				}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.RoleAssignmentList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.RoleAssignmentResult{
-							ParentId: subscriptionId,
-							Ok:       u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
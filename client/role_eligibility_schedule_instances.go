@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -37,7 +36,7 @@ func (s *azureClient) GetAzureADRoleEligibilityScheduleInstance(ctx context.Cont
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -58,7 +57,7 @@ func (s *azureClient) GetAzureADRoleEligibilityScheduleInstances(ctx context.Con
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -73,43 +72,22 @@ func (s *azureClient) ListAzureADRoleEligibilityScheduleInstances(ctx context.Co
 
 		var (
 			errResult = azure.UnifiedRoleEligibilityScheduleInstanceResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADRoleEligibilityScheduleInstances(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.UnifiedRoleEligibilityScheduleInstanceList, error) {
+				return s.GetAzureADRoleEligibilityScheduleInstances(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.UnifiedRoleEligibilityScheduleInstanceList) ([]azure.UnifiedRoleEligibilityScheduleInstance, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.UnifiedRoleEligibilityScheduleInstance) string { return u.Id },
+			func(u azure.UnifiedRoleEligibilityScheduleInstance) {
+				out <- azure.UnifiedRoleEligibilityScheduleInstanceResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.UnifiedRoleEligibilityScheduleInstanceResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.UnifiedRoleEligibilityScheduleInstanceList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.UnifiedRoleEligibilityScheduleInstanceResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
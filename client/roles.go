@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureADRole(ctx context.Context, roleId string, selectC
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureADRoles(ctx context.Context, filter, expand string
 
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -68,43 +67,20 @@ func (s *azureClient) ListAzureADRoles(ctx context.Context, filter, expand strin
 
 		var (
 			errResult = azure.RoleResult{}
-			nextLink  string
 		)
 
-		if users, err := s.GetAzureADRoles(ctx, filter, expand); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.RoleList, error) {
+				return s.GetAzureADRoles(ctx, filter, expand)
+			},
+			func(list azure.RoleList) ([]azure.Role, string) { return list.Value, list.NextLink },
+			func(u azure.Role) string { return u.Id },
+			func(u azure.Role) {
+				out <- azure.RoleResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range users.Value {
-				out <- azure.RoleResult{Ok: u}
-			}
-
-			nextLink = users.NextLink
-			for nextLink != "" {
-				var users azure.RoleList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &users); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range users.Value {
-						out <- azure.RoleResult{Ok: u}
-					}
-					nextLink = users.NextLink
-				}
-			}
 		}
 	}()
 	return out
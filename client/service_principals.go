@@ -19,8 +19,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -37,7 +37,7 @@ func (s *azureClient) GetAzureADServicePrincipal(ctx context.Context, objectId s
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -52,7 +52,7 @@ func (s *azureClient) GetAzureADServicePrincipalOwners(ctx context.Context, obje
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -74,7 +74,7 @@ func (s *azureClient) GetAzureADServicePrincipals(ctx context.Context, filter, s
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -89,43 +89,22 @@ func (s *azureClient) ListAzureADServicePrincipals(ctx context.Context, filter,
 
 		var (
 			errResult = azure.ServicePrincipalResult{}
-			nextLink  string
 		)
 
-		if list, err := s.GetAzureADServicePrincipals(ctx, filter, search, orderBy, expand, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.ServicePrincipalList, error) {
+				return s.GetAzureADServicePrincipals(ctx, filter, search, orderBy, expand, selectCols, 999, false)
+			},
+			func(list azure.ServicePrincipalList) ([]azure.ServicePrincipal, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.ServicePrincipal) string { return u.Id },
+			func(u azure.ServicePrincipal) {
+				out <- azure.ServicePrincipalResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range list.Value {
-				out <- azure.ServicePrincipalResult{Ok: u}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.ServicePrincipalList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ServicePrincipalResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -141,49 +120,23 @@ func (s *azureClient) ListAzureADServicePrincipalOwners(ctx context.Context, obj
 			errResult = azure.ServicePrincipalOwnerResult{
 				ServicePrincipalId: objectId,
 			}
-			nextLink string
 		)
 
-		if list, err := s.GetAzureADServicePrincipalOwners(ctx, objectId, filter, search, orderBy, selectCols, 999, false); err != nil {
-			errResult.Error = err
-			out <- errResult
-		} else {
-			for _, u := range list.Value {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.DirectoryObjectList, error) {
+				return s.GetAzureADServicePrincipalOwners(ctx, objectId, filter, search, orderBy, selectCols, 999, false)
+			},
+			func(list azure.DirectoryObjectList) ([]json.RawMessage, string) { return list.Value, list.NextLink },
+			func(u json.RawMessage) string { return directoryObjectId(u) },
+			func(u json.RawMessage) {
 				out <- azure.ServicePrincipalOwnerResult{
 					ServicePrincipalId: objectId,
 					Ok:                 u,
 				}
-			}
-
-			nextLink = list.NextLink
-			for nextLink != "" {
-				var list azure.DirectoryObjectList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.ServicePrincipalOwnerResult{
-							ServicePrincipalId: objectId,
-							Ok:                 u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
 		}
 	}()
 	return out
@@ -0,0 +1,88 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func (s *azureClient) GetAzureADAuthorizationPolicy(ctx context.Context) (*azure.AuthorizationPolicy, error) {
+	var (
+		path     = fmt.Sprintf("/%s/policies/authorizationPolicy", constants.GraphApiVersion)
+		response azure.AuthorizationPolicy
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, nil); err != nil {
+		return nil, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return nil, err
+	} else {
+		return &response, nil
+	}
+}
+
+// GetAzureADGroupSettings gets the directory settings of the tenant, which graph v1.0 serves as /groupSettings in
+// place of the beta /settings
+func (s *azureClient) GetAzureADGroupSettings(ctx context.Context) (azure.GroupSettingList, error) {
+	var (
+		path     = fmt.Sprintf("/%s/groupSettings", constants.GraphApiVersion)
+		response azure.GroupSettingList
+	)
+
+	if res, err := s.msgraph.Get(ctx, path, nil, nil); err != nil {
+		return response, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return response, err
+	} else {
+		return response, nil
+	}
+}
+
+func (s *azureClient) ListAzureADGroupSettings(ctx context.Context) <-chan azure.GroupSettingResult {
+	out := make(chan azure.GroupSettingResult)
+
+	go func() {
+		defer close(out)
+
+		var (
+			errResult = azure.GroupSettingResult{}
+		)
+
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.GroupSettingList, error) {
+				return s.GetAzureADGroupSettings(ctx)
+			},
+			func(list azure.GroupSettingList) ([]azure.GroupSetting, string) {
+				return list.Value, list.NextLink
+			},
+			func(u azure.GroupSetting) string { return u.Id },
+			func(u azure.GroupSetting) {
+				out <- azure.GroupSettingResult{Ok: u}
+			},
+		); err != nil {
+			errResult.Error = err
+			out <- errResult
+		}
+	}()
+	return out
+}
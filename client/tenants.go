@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureADOrganization(ctx context.Context, selectCols []s
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -53,7 +52,7 @@ func (s *azureClient) GetAzureADTenants(ctx context.Context, includeAllTenantCat
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -68,43 +67,20 @@ func (s *azureClient) ListAzureADTenants(ctx context.Context, includeAllTenantCa
 
 		var (
 			errResult = azure.TenantResult{}
-			nextLink  string
 		)
 
-		if result, err := s.GetAzureADTenants(ctx, includeAllTenantCategories); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.TenantList, error) {
+				return s.GetAzureADTenants(ctx, includeAllTenantCategories)
+			},
+			func(list azure.TenantList) ([]azure.Tenant, string) { return list.Value, list.NextLink },
+			func(u azure.Tenant) string { return u.Id },
+			func(u azure.Tenant) {
+				out <- azure.TenantResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.TenantResult{Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.TenantList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.TenantResult{Ok: u}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
@@ -37,7 +36,7 @@ func (s *azureClient) GetAzureADUser(ctx context.Context, objectId string, selec
 	)
 	if res, err := s.msgraph.Get(ctx, path, params, nil); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response.Value[0], nil
@@ -59,7 +58,7 @@ func (s *azureClient) GetAzureADUsers(ctx context.Context, filter string, search
 	}
 	if res, err := s.msgraph.Get(ctx, path, params.AsMap(), headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
@@ -74,43 +73,20 @@ func (s *azureClient) ListAzureADUsers(ctx context.Context, filter string, searc
 
 		var (
 			errResult = azure.UserResult{}
-			nextLink  string
 		)
 
-		if users, err := s.GetAzureADUsers(ctx, filter, search, orderBy, selectCols, 999, false); err != nil {
+		if err := listPages(ctx, s.msgraph,
+			func() (azure.UserList, error) {
+				return s.GetAzureADUsers(ctx, filter, search, orderBy, selectCols, 999, false)
+			},
+			func(list azure.UserList) ([]azure.User, string) { return list.Value, list.NextLink },
+			func(u azure.User) string { return u.Id },
+			func(u azure.User) {
+				out <- azure.UserResult{Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range users.Value {
-				out <- azure.UserResult{Ok: u}
-			}
-
-			nextLink = users.NextLink
-			for nextLink != "" {
-				var users azure.UserList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.msgraph.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &users); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range users.Value {
-						out <- azure.UserResult{Ok: u}
-					}
-					nextLink = users.NextLink
-				}
-			}
 		}
 	}()
 	return out
@@ -20,7 +20,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"net/url"
 
 	"github.com/bloodhoundad/azurehound/v2/client/query"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
@@ -36,7 +35,7 @@ func (s *azureClient) GetAzureWebApp(ctx context.Context, subscriptionId, groupN
 	)
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return nil, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return nil, err
 	} else {
 		return &response, nil
@@ -53,13 +52,32 @@ func (s *azureClient) GetAzureWebApps(ctx context.Context, subscriptionId string
 
 	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
 		return response, err
-	} else if err := rest.Decode(res.Body, &response); err != nil {
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
 		return response, err
 	} else {
 		return response, nil
 	}
 }
 
+// GetAzureWebAppAuthSettings reads the authentication settings of a web or function app. The v2 settings are read with
+// a GET, unlike the v1 settings which are only returned, secrets included, by a list action.
+func (s *azureClient) GetAzureWebAppAuthSettings(ctx context.Context, siteId string) (*azure.WebAppAuthSettings, error) {
+	var (
+		path     = fmt.Sprintf("%s/config/authsettingsV2", siteId)
+		params   = query.Params{ApiVersion: "2022-03-01"}.AsMap()
+		headers  map[string]string
+		response azure.WebAppAuthSettings
+	)
+
+	if res, err := s.resourceManager.Get(ctx, path, params, headers); err != nil {
+		return nil, err
+	} else if err := rest.DecodeContext(ctx, res.Body, &response); err != nil {
+		return nil, err
+	} else {
+		return &response, nil
+	}
+}
+
 func (s *azureClient) ListAzureWebApps(ctx context.Context, subscriptionId string) <-chan azure.WebAppResult {
 	out := make(chan azure.WebAppResult)
 
@@ -70,46 +88,20 @@ func (s *azureClient) ListAzureWebApps(ctx context.Context, subscriptionId strin
 			errResult = azure.WebAppResult{
 				SubscriptionId: subscriptionId,
 			}
-			nextLink string
 		)
 
-		if result, err := s.GetAzureWebApps(ctx, subscriptionId); err != nil {
+		if err := listPages(ctx, s.resourceManager,
+			func() (azure.WebAppList, error) {
+				return s.GetAzureWebApps(ctx, subscriptionId)
+			},
+			func(list azure.WebAppList) ([]azure.WebApp, string) { return list.Value, list.NextLink },
+			func(u azure.WebApp) string { return u.Id },
+			func(u azure.WebApp) {
+				out <- azure.WebAppResult{SubscriptionId: subscriptionId, Ok: u}
+			},
+		); err != nil {
 			errResult.Error = err
 			out <- errResult
-		} else {
-			for _, u := range result.Value {
-				out <- azure.WebAppResult{SubscriptionId: subscriptionId, Ok: u}
-			}
-
-			nextLink = result.NextLink
-			for nextLink != "" {
-				var list azure.WebAppList
-				if url, err := url.Parse(nextLink); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if req, err := rest.NewRequest(ctx, "GET", url, nil, nil, nil); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if res, err := s.resourceManager.Send(req); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else if err := rest.Decode(res.Body, &list); err != nil {
-					errResult.Error = err
-					out <- errResult
-					nextLink = ""
-				} else {
-					for _, u := range list.Value {
-						out <- azure.WebAppResult{
-							SubscriptionId: "/subscriptions/" + subscriptionId,
-							Ok:             u,
-						}
-					}
-					nextLink = list.NextLink
-				}
-			}
 		}
 	}()
 	return out
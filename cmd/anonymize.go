@@ -0,0 +1,474 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	config.Init(anonymizeCmd, []config.Config{config.AnonymizeInput, config.OutputFile, config.AnonymizeMap, config.AnonymizeKey})
+	rootCmd.AddCommand(anonymizeCmd)
+}
+
+var anonymizeCmd = &cobra.Command{
+	Use:               "anonymize",
+	Short:             "Pseudonymize a collection file for sharing",
+	Long:              "Replaces names, user principal names, mail addresses and domain names in a file written by the list command with consistent pseudonyms, leaving ids and the shape of the data intact. The mapping back to the original values is written to a separate file.",
+	Run:               anonymizeCmdImpl,
+	PersistentPreRunE: persistentPreRunE,
+	SilenceUsage:      true,
+}
+
+func anonymizeCmdImpl(cmd *cobra.Command, args []string) {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	defer gracefulShutdown(stop)
+
+	var (
+		input  = config.AnonymizeInput.Value().(string)
+		output = config.OutputFile.Value().(string)
+		mapped = config.AnonymizeMap.Value().(string)
+		key    = config.AnonymizeKey.Value().(string)
+	)
+
+	log.Info("anonymizing collection file...", "input", input)
+	start := time.Now()
+	if count, err := anonymizeFile(ctx, input, output, mapped, []byte(key)); err != nil {
+		exit(fmt.Errorf("failed to anonymize %s: %w", input, err))
+	} else {
+		duration := time.Since(start)
+		log.Info("anonymization completed", "duration", duration.String(), "pseudonyms", count, "output", output, "map", mapped)
+	}
+}
+
+// anonymizeFile pseudonymizes the items of a file written by the list command one at a time. Memory grows with the
+// number of distinct values pseudonymized rather than the size of the file. Pseudonyms are derived from key, or from a
+// random key if it is empty. Returns the number of pseudonyms created.
+func anonymizeFile(ctx context.Context, input string, output string, mapped string, key []byte) (int, error) {
+	if input == "" || output == "" || mapped == "" {
+		return 0, fmt.Errorf("--input, --output and --map are all required")
+	} else if samePath(input, output) || samePath(input, mapped) || samePath(output, mapped) {
+		return 0, fmt.Errorf("--input, --output and --map must be different files")
+	}
+
+	in, err := sinks.OpenFile(input)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	// the mapping reverses the pseudonymization so it is only readable by its owner
+	mapFile, err := os.OpenFile(mapped, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer mapFile.Close()
+
+	anonymizer, err := newAnonymizer(mapFile, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		readCtx, cancel = context.WithCancel(ctx)
+		stream, errs    = readDataFile(readCtx, in)
+		items           = make(chan string)
+		anonymizeErr    error
+	)
+	defer cancel()
+
+	go func() {
+		defer close(items)
+		for item := range stream {
+			if result, err := anonymizer.anonymize(item.(json.RawMessage)); err != nil {
+				anonymizeErr = err
+				cancel()
+				return
+			} else {
+				select {
+				case items <- result:
+				case <-readCtx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	writeErr := sinks.WriteToFile(readCtx, output, items)
+	cancel()
+	readErr := <-errs
+
+	if anonymizeErr != nil {
+		return 0, anonymizeErr
+	} else if writeErr != nil {
+		return 0, writeErr
+	} else if readErr != nil {
+		return 0, readErr
+	} else if err := rest.Aborted(ctx); err != nil {
+		return 0, err
+	} else if err := anonymizer.close(); err != nil {
+		return 0, fmt.Errorf("unable to write mapping: %w", err)
+	} else {
+		return len(anonymizer.pseudonyms), nil
+	}
+}
+
+func samePath(a string, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// Fields holding the names of principals, tenants and other objects
+var anonymizedNames = map[string]bool{
+	"appDisplayName":           true,
+	"companyName":              true,
+	"computerName":             true,
+	"displayName":              true,
+	"givenName":                true,
+	"mailNickname":             true,
+	"managedByTenantName":      true,
+	"manageeTenantName":        true,
+	"onPremisesSamAccountName": true,
+	"principalDisplayName":     true,
+	"principalIdDisplayName":   true,
+	"resourceDisplayName":      true,
+	"samAccountName":           true,
+	"surname":                  true,
+	"tenantName":               true,
+}
+
+// Fields holding user principal names and mail addresses, optionally prefixed by an address type e.g. SMTP:
+var anonymizedAddresses = map[string]bool{
+	"contactEmail":                        true,
+	"imAddresses":                         true,
+	"mail":                                true,
+	"onPremisesUserPrincipalName":         true,
+	"otherMails":                          true,
+	"proxyAddresses":                      true,
+	"securityComplianceNotificationMails": true,
+	"technicalNotificationMails":          true,
+	"userPrincipalName":                   true,
+}
+
+// Fields holding domain and host names
+var anonymizedDomains = map[string]bool{
+	"defaultDomain":        true,
+	"defaultHostName":      true,
+	"domainName":           true,
+	"domains":              true,
+	"enabledHostnames":     true,
+	"hostNames":            true,
+	"onPremisesDomainName": true,
+	"publisherDomain":      true,
+}
+
+// Domain suffixes owned by Microsoft or shared by every tenant; pseudonymizing them would only obscure which cloud
+// the data came from
+var preservedDomainSuffixes = []string{
+	"onmicrosoft.com",
+	"onmicrosoft.us",
+	"onmicrosoft.de",
+	"partner.onmschina.cn",
+	"azurewebsites.net",
+}
+
+// The number of times a colliding pseudonym is rederived before it is disambiguated with a suffix instead
+const maxDeriveAttempts = 64
+
+type anonymizer struct {
+	key        []byte
+	pseudonyms map[string]string // original to pseudonym
+	originals  map[string]string // pseudonym to original
+	mapping    *bufio.Writer
+	err        error
+}
+
+func newAnonymizer(mapping io.Writer, key []byte) (*anonymizer, error) {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("unable to generate pseudonymization key: %w", err)
+		}
+	}
+
+	writer := bufio.NewWriter(mapping)
+	_, err := writer.WriteString("{")
+	return &anonymizer{
+		key:        key,
+		pseudonyms: map[string]string{},
+		originals:  map[string]string{},
+		mapping:    writer,
+		err:        err,
+	}, nil
+}
+
+// anonymize pseudonymizes a single item of a collection file
+func (s *anonymizer) anonymize(item json.RawMessage) (string, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(item))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	} else if result, err := json.Marshal(s.walk(value, "")); err != nil {
+		return "", err
+	} else {
+		return string(result), nil
+	}
+}
+
+// walk pseudonymizes every value nested in value, where field is the name of the field value was found in
+func (s *anonymizer) walk(value interface{}, field string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if field == "verifiedDomains" && key == "name" {
+				value[key] = s.walk(child, "domainName")
+			} else {
+				value[key] = s.walk(child, key)
+			}
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = s.walk(child, field)
+		}
+		return value
+	case string:
+		if value == "" {
+			return value
+		} else if anonymizedNames[field] {
+			return s.pseudonym(value)
+		} else if anonymizedAddresses[field] {
+			return s.address(value)
+		} else if anonymizedDomains[field] {
+			return s.domain(value)
+		} else if field == "onPremisesDistinguishedName" {
+			return s.distinguishedName(value)
+		} else {
+			return value
+		}
+	default:
+		return value
+	}
+}
+
+// address pseudonymizes a user principal name or mail address, keeping any address type prefix and the #EXT# marker
+// of guest user principal names
+func (s *anonymizer) address(value string) string {
+	prefix := ""
+	if i := strings.Index(value, ":"); i >= 0 && !strings.Contains(value[:i], "@") {
+		prefix, value = value[:i+1], value[i+1:]
+	}
+
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return prefix + s.pseudonym(value)
+	}
+
+	local, domain := value[:at], value[at+1:]
+	if guest := strings.Index(local, "#EXT#"); guest >= 0 {
+		// guests are named after their home address, e.g. jdoe_contoso.com#EXT#
+		home, marker := local[:guest], local[guest:]
+		if underscore := strings.LastIndex(home, "_"); underscore >= 0 {
+			local = s.pseudonym(home[:underscore]) + "_" + s.domain(home[underscore+1:]) + marker
+		} else {
+			local = s.pseudonym(home) + marker
+		}
+	} else {
+		local = s.pseudonym(local)
+	}
+
+	return prefix + local + "@" + s.domain(domain)
+}
+
+// domain pseudonymizes each label of a domain name apart from the top level domain and any preserved suffix, so
+// subdomains stay recognizable as belonging to the same pseudonymized parent
+func (s *anonymizer) domain(value string) string {
+	var (
+		lower  = strings.ToLower(value)
+		suffix = ""
+	)
+
+	for _, preserved := range preservedDomainSuffixes {
+		if lower == preserved || strings.HasSuffix(lower, "."+preserved) {
+			suffix = preserved
+			break
+		}
+	}
+
+	if lower == "" {
+		return lower
+	} else if suffix == "" {
+		if dot := strings.LastIndex(lower, "."); dot < 0 {
+			return s.pseudonym(lower)
+		} else {
+			suffix = lower[dot+1:]
+		}
+	}
+
+	if lower == suffix {
+		return lower
+	}
+
+	labels := strings.Split(strings.TrimSuffix(lower, "."+suffix), ".")
+	for i := range labels {
+		labels[i] = s.pseudonym(labels[i])
+	}
+	return strings.Join(labels, ".") + "." + suffix
+}
+
+// distinguishedName pseudonymizes the value of each relative distinguished name, treating the domain components
+// together as a domain name
+func (s *anonymizer) distinguishedName(value string) string {
+	var (
+		parts      = strings.Split(value, ",")
+		components = []int{}
+		labels     = []string{}
+	)
+
+	for i, part := range parts {
+		if eq := strings.Index(part, "="); eq < 0 {
+			continue
+		} else if attribute, name := part[:eq], part[eq+1:]; strings.EqualFold(strings.TrimSpace(attribute), "DC") {
+			components = append(components, i)
+			labels = append(labels, name)
+		} else {
+			parts[i] = attribute + "=" + s.pseudonym(name)
+		}
+	}
+
+	if len(labels) > 0 {
+		domain := strings.Split(s.domain(strings.Join(labels, ".")), ".")
+		for i, part := range components {
+			parts[part] = parts[part][:strings.Index(parts[part], "=")+1] + domain[i]
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// pseudonym returns the pseudonym for value, creating and recording it if it hasn't been seen before. Pseudonyms
+// preserve the format of the original; letters are replaced with letters of the same case and digits with digits.
+func (s *anonymizer) pseudonym(value string) string {
+	if value == "" {
+		return value
+	} else if pseudonym, ok := s.pseudonyms[value]; ok {
+		return pseudonym
+	}
+
+	for attempt := uint32(0); ; attempt++ {
+		pseudonym := s.derive(value, attempt)
+		if attempt >= maxDeriveAttempts {
+			// short values can exhaust their format, e.g. there are only ten single digits
+			pseudonym = fmt.Sprintf("%s~%d", pseudonym, attempt)
+		}
+
+		if original, taken := s.originals[pseudonym]; !taken || original == value {
+			s.pseudonyms[value] = pseudonym
+			s.originals[pseudonym] = value
+			s.record(pseudonym, value)
+			return pseudonym
+		}
+	}
+}
+
+// derive replaces each letter and digit of value using a keyed hash of value, so the same value always derives the
+// same pseudonym while the pseudonyms can't be reversed by hashing guessed names
+func (s *anonymizer) derive(value string, attempt uint32) string {
+	var (
+		result  strings.Builder
+		stream  []byte
+		counter uint32
+	)
+
+	next := func() byte {
+		if len(stream) == 0 {
+			mac := hmac.New(sha256.New, s.key)
+			binary.Write(mac, binary.BigEndian, attempt)
+			binary.Write(mac, binary.BigEndian, counter)
+			mac.Write([]byte(value))
+			stream = mac.Sum(nil)
+			counter++
+		}
+		b := stream[0]
+		stream = stream[1:]
+		return b
+	}
+
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			result.WriteRune('0' + rune(next()%10))
+		case unicode.IsUpper(r):
+			result.WriteRune('A' + rune(next()%26))
+		case unicode.IsLetter(r):
+			result.WriteRune('a' + rune(next()%26))
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+func (s *anonymizer) record(pseudonym string, original string) {
+	if s.err != nil {
+		return
+	}
+
+	separator := ",\n\t"
+	if len(s.originals) == 1 {
+		separator = "\n\t"
+	}
+
+	if key, err := json.Marshal(pseudonym); err != nil {
+		s.err = err
+	} else if value, err := json.Marshal(original); err != nil {
+		s.err = err
+	} else {
+		_, s.err = fmt.Fprintf(s.mapping, "%s%s: %s", separator, key, value)
+	}
+}
+
+// close finishes writing the mapping, returning the first error encountered while writing it
+func (s *anonymizer) close() error {
+	if s.err != nil {
+		return s.err
+	} else if _, err := s.mapping.WriteString("\n}\n"); err != nil {
+		return err
+	} else {
+		return s.mapping.Flush()
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+const testAnonymizeFile = `{
+	"data": [
+		{"kind":"AZTenant","data":{"tenantId":"6c12b0b0-b2cc-4a73-8252-0b94bfca76b2","displayName":"Contoso","defaultDomain":"contoso.com","verifiedDomains":[{"name":"contoso.com"},{"name":"contoso.onmicrosoft.com"}]}},
+		{"kind":"AZUser","data":{"id":"1","displayName":"John Smith","userPrincipalName":"john.smith@contoso.com","mail":"John.Smith@Contoso.com","proxyAddresses":["SMTP:john.smith@contoso.com"],"tenantName":"Contoso","accountEnabled":true}},
+		{"kind":"AZUser","data":{"id":"2","displayName":"Jane Doe","userPrincipalName":"jane.doe_fabrikam.com#EXT#@contoso.onmicrosoft.com","onPremisesDistinguishedName":"CN=Jane Doe,OU=Users,DC=contoso,DC=com"}},
+		{"kind":"AZGroupMember","data":{"groupId":"3","members":[{"member":{"id":"1","displayName":"John Smith","userPrincipalName":"john.smith@contoso.com"},"groupId":"3"}]}},
+		{"kind":"AZRoleAssignment","data":{"roleDefinitionId":"62e90394-69f5-4237-9190-012177145e10","scope":"/subscriptions/0b1f6471-1bf0-4dda-aec3-cb9272f09590"}}
+	],
+	"meta": {"type":"azure","version":5,"count":5}
+}
+`
+
+func TestAnonymizeFile(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		input  = filepath.Join(dir, "in.json")
+		output = filepath.Join(dir, "out.json")
+		mapped = filepath.Join(dir, "map.json")
+	)
+
+	if err := os.WriteFile(input, []byte(testAnonymizeFile), 0600); err != nil {
+		t.Fatalf("unable to write input: %v", err)
+	} else if _, err := anonymizeFile(context.Background(), input, output, mapped, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer file.Close()
+
+	var items []map[string]interface{}
+	stream, errs := readDataFile(context.Background(), file)
+	for item := range stream {
+		var value map[string]interface{}
+		if err := json.Unmarshal(item.(json.RawMessage), &value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		items = append(items, value["data"].(map[string]interface{}))
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(items) != 5 {
+		t.Fatalf("got %v items, want 5", len(items))
+	}
+
+	var (
+		tenant   = items[0]
+		john     = items[1]
+		jane     = items[2]
+		member   = items[3]["members"].([]interface{})[0].(map[string]interface{})["member"].(map[string]interface{})
+		role     = items[4]
+		domain   = tenant["defaultDomain"].(string)
+		verified = tenant["verifiedDomains"].([]interface{})
+	)
+
+	// names are replaced but keep their format
+	if name := john["displayName"].(string); name == "John Smith" || !regexp.MustCompile(`^[A-Z][a-z]{3} [A-Z][a-z]{4}$`).MatchString(name) {
+		t.Errorf("got %v, want a format preserving pseudonym", name)
+	} else if member["displayName"] != name {
+		t.Errorf("got %v, want the same pseudonym %v everywhere", member["displayName"], name)
+	}
+
+	// domains are replaced consistently, including inside addresses
+	if domain == "contoso.com" || !strings.HasSuffix(domain, ".com") {
+		t.Errorf("got %v, want a pseudonymized domain keeping its top level domain", domain)
+	} else if verified[0].(map[string]interface{})["name"] != domain {
+		t.Errorf("got %v, want %v", verified[0].(map[string]interface{})["name"], domain)
+	} else if onmicrosoft := verified[1].(map[string]interface{})["name"].(string); !strings.HasSuffix(onmicrosoft, ".onmicrosoft.com") || onmicrosoft == "contoso.onmicrosoft.com" {
+		t.Errorf("got %v, want a pseudonymized label under onmicrosoft.com", onmicrosoft)
+	} else if upn := john["userPrincipalName"].(string); !strings.HasSuffix(upn, "@"+domain) || member["userPrincipalName"] != upn {
+		t.Errorf("got %v, want a consistent address in %v", upn, domain)
+	} else if proxy := john["proxyAddresses"].([]interface{})[0].(string); proxy != "SMTP:"+john["userPrincipalName"].(string) {
+		t.Errorf("got %v, want the address type prefix to be kept", proxy)
+	} else if tenant["displayName"] != john["tenantName"] {
+		t.Errorf("got %v, want %v", john["tenantName"], tenant["displayName"])
+	}
+
+	// guest addresses keep the #EXT# marker
+	if upn := jane["userPrincipalName"].(string); !strings.Contains(upn, "#EXT#@") || strings.Contains(upn, "fabrikam") || strings.Contains(upn, "jane") {
+		t.Errorf("got %v, want a pseudonymized guest user principal name", upn)
+	} else if dn := jane["onPremisesDistinguishedName"].(string); !strings.HasPrefix(dn, "CN="+jane["displayName"].(string)+",") || !strings.HasSuffix(dn, ",DC="+strings.ReplaceAll(domain, ".", ",DC=")) {
+		t.Errorf("got %v, want the distinguished name to match the pseudonymized name and domain", dn)
+	}
+
+	// structural fields are untouched
+	if john["id"] != "1" || member["id"] != "1" || john["accountEnabled"] != true {
+		t.Errorf("expected ids and flags to be untouched: %v", john)
+	} else if role["scope"] != "/subscriptions/0b1f6471-1bf0-4dda-aec3-cb9272f09590" || tenant["tenantId"] != "6c12b0b0-b2cc-4a73-8252-0b94bfca76b2" {
+		t.Errorf("expected structural fields to be untouched: %v", role)
+	}
+
+	// the mapping reverses every pseudonym
+	var mapping map[string]string
+	if content, err := os.ReadFile(mapped); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.Unmarshal(content, &mapping); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if mapping[john["displayName"].(string)] != "John Smith" {
+		t.Errorf("got %v, want %v", mapping[john["displayName"].(string)], "John Smith")
+	} else if mapping[strings.TrimSuffix(domain, ".com")] != "contoso" {
+		t.Errorf("got %v, want %v", mapping[strings.TrimSuffix(domain, ".com")], "contoso")
+	}
+
+	if info, err := os.Stat(mapped); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestAnonymizeFileKey(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		input = filepath.Join(dir, "in.json")
+	)
+	if err := os.WriteFile(input, []byte(testAnonymizeFile), 0600); err != nil {
+		t.Fatalf("unable to write input: %v", err)
+	}
+
+	anonymize := func(name string, key []byte) string {
+		output := filepath.Join(dir, name+".json")
+		if _, err := anonymizeFile(context.Background(), input, output, filepath.Join(dir, name+".map.json"), key); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data, err := os.ReadFile(output); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+			return ""
+		} else {
+			return string(data)
+		}
+	}
+
+	if first, second := anonymize("first", []byte("key")), anonymize("second", []byte("key")); first != second {
+		t.Error("expected the same pseudonyms with the same key")
+	} else if random := anonymize("random", nil); random == first {
+		t.Error("expected different pseudonyms without a key")
+	}
+}
+
+func TestAnonymizeKeyConfigFile(t *testing.T) {
+	// a config file authenticating with a certificate sets the path of its key, which must not become the pseudonym key
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"key": "/etc/azurehound/cert.key"}`), 0600); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+	defer config.AzKey.Set("")
+
+	options := config.Options()
+	options.ConfigFile = configFile
+	if err := config.LoadValues(nil, options); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if key := config.AzKey.Value(); key != "/etc/azurehound/cert.key" {
+		t.Errorf("got certificate key %v, want the one in the config file", key)
+	} else if key := config.AnonymizeKey.Value(); key != "" {
+		t.Errorf("got pseudonym key %v, want none", key)
+	}
+}
+
+func TestAnonymizeFileSamePath(t *testing.T) {
+	if _, err := anonymizeFile(context.Background(), "in.json", "out.json", "./out.json", nil); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestAnonymizerCollision(t *testing.T) {
+	var mapping bytes.Buffer
+	anonymizer, err := newAnonymizer(&mapping, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// there are only ten single digit pseudonyms for eleven single digits, every one must still be distinct for the
+	// mapping to be reversible
+	seen := map[string]bool{}
+	for _, digit := range "0123456789٠" {
+		pseudonym := anonymizer.pseudonym(string(digit))
+		if seen[pseudonym] {
+			t.Errorf("pseudonym %v was assigned more than once", pseudonym)
+		}
+		seen[pseudonym] = true
+	}
+
+	if err := anonymizer.close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !json.Valid(mapping.Bytes()) {
+		t.Errorf("expected a valid json mapping: %s", mapping.String())
+	}
+}
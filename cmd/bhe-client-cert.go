@@ -0,0 +1,114 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+)
+
+// clientCertificate is the certificate presented to BloodHound Enterprise for mutual TLS. Services run for longer
+// than short lived certificates are valid, so the files are checked on every handshake and reloaded once either one
+// changes. A certificate that fails to reload, e.g. because only one of the files has been replaced so far, leaves the
+// previous one in use until the next handshake.
+type clientCertificate struct {
+	certFile string
+	keyFile  string
+	password string
+
+	mutex    sync.Mutex
+	cert     *tls.Certificate
+	modified [2]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newClientCertificate(certFile, keyFile, password string) (*clientCertificate, error) {
+	cert := &clientCertificate{certFile: certFile, keyFile: keyFile, password: password}
+	if err := cert.reload(); err != nil {
+		return nil, fmt.Errorf("unable to load bloodhound enterprise client certificate: %w", err)
+	} else {
+		return cert, nil
+	}
+}
+
+// get is a tls.Config GetClientCertificate callback
+func (s *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if modified, err := s.stamps(); err != nil {
+		log.Error(err, "unable to check the bloodhound enterprise client certificate for changes")
+	} else if modified != s.modified {
+		if err := s.load(modified); err != nil {
+			log.Error(err, "unable to reload the bloodhound enterprise client certificate; presenting the previous one")
+		} else {
+			log.Info("reloaded bloodhound enterprise client certificate", "certificate", s.certFile, "expires", s.cert.Leaf.NotAfter.Format(time.RFC3339))
+		}
+	}
+	return s.cert, nil
+}
+
+func (s *clientCertificate) reload() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if modified, err := s.stamps(); err != nil {
+		return err
+	} else {
+		return s.load(modified)
+	}
+}
+
+func (s *clientCertificate) load(modified [2]fileStamp) error {
+	if certPEM, err := os.ReadFile(s.certFile); err != nil {
+		return err
+	} else if keyPEM, err := os.ReadFile(s.keyFile); err != nil {
+		return err
+	} else if cert, err := rest.X509KeyPair(certPEM, keyPEM, s.password); err != nil {
+		return err
+	} else if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return err
+	} else {
+		cert.Leaf = leaf
+		s.cert = &cert
+		s.modified = modified
+		return nil
+	}
+}
+
+func (s *clientCertificate) stamps() ([2]fileStamp, error) {
+	var stamps [2]fileStamp
+	for i, path := range []string{s.certFile, s.keyFile} {
+		if info, err := os.Stat(path); err != nil {
+			return stamps, err
+		} else {
+			stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps, nil
+}
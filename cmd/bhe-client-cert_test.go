@@ -0,0 +1,202 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+// newMutualTLSServer returns an instance that requires a client certificate, reporting the serial number of each one
+// presented, trusted through --bhe-ca-cert
+func newMutualTLSServer(t *testing.T) (*httptest.Server, func() *big.Int) {
+	var (
+		mutex  sync.Mutex
+		serial *big.Int
+	)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		serial = r.TLS.PeerCertificates[0].SerialNumber
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config.BHECACert.Set(caCert)
+	t.Cleanup(func() {
+		config.BHECACert.Set("")
+		config.BHEClientCert.Set("")
+		config.BHEClientKey.Set("")
+		config.BHEClientKeyPass.Set("")
+	})
+
+	return server, func() *big.Int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return serial
+	}
+}
+
+// writeClientCert writes a new certificate and key encrypted with passphrase, returning the certificate's serial
+func writeClientCert(t *testing.T, certFile, keyFile, passphrase string, modified time.Time) *big.Int {
+	if certPEM, keyPEM, err := generateCert(passphrase); err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	} else if err := os.Chtimes(certFile, modified, modified); err != nil {
+		t.Fatal(err)
+	} else if err := os.Chtimes(keyFile, modified, modified); err != nil {
+		t.Fatal(err)
+	} else if cert, err := newClientCertificate(certFile, keyFile, passphrase); err != nil {
+		t.Fatal(err)
+	} else {
+		return cert.cert.Leaf.SerialNumber
+	}
+	return nil
+}
+
+func TestNewBHEHttpClientClientCert(t *testing.T) {
+	var (
+		server, presented = newMutualTLSServer(t)
+		dir               = t.TempDir()
+		certFile          = filepath.Join(dir, "client.pem")
+		keyFile           = filepath.Join(dir, "client.key")
+		now               = time.Now()
+		serial            = writeClientCert(t, certFile, keyFile, "passphrase", now.Add(-time.Hour))
+	)
+	config.BHEClientCert.Set(certFile)
+	config.BHEClientKey.Set(keyFile)
+	config.BHEClientKeyPass.Set("passphrase")
+
+	client, err := newBHEHttpClient("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res, err := client.Get(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); presented().Cmp(serial) != 0 {
+		t.Errorf("got certificate %v, want %v", presented(), serial)
+	}
+
+	// a renewed certificate is presented on the next connection without recreating the client
+	renewed := writeClientCert(t, certFile, keyFile, "passphrase", now)
+	client.CloseIdleConnections()
+	if res, err := client.Get(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); presented().Cmp(renewed) != 0 {
+		t.Errorf("got certificate %v, want the renewed %v", presented(), renewed)
+	}
+
+	// a half written renewal leaves the previous certificate in use
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client.CloseIdleConnections()
+	if res, err := client.Get(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); presented().Cmp(renewed) != 0 {
+		t.Errorf("got certificate %v, want the previous %v", presented(), renewed)
+	}
+}
+
+func TestNewBHEHttpClientClientCertThroughProxy(t *testing.T) {
+	var (
+		server, presented = newMutualTLSServer(t)
+		dir               = t.TempDir()
+		certFile          = filepath.Join(dir, "client.pem")
+		keyFile           = filepath.Join(dir, "client.key")
+		serial            = writeClientCert(t, certFile, keyFile, "", time.Now())
+		tunnels           atomic.Int32
+	)
+	config.BHEClientCert.Set(certFile)
+	config.BHEClientKey.Set(keyFile)
+
+	// the proxy only tunnels the connection, so the instance sees the client certificate as if connected directly
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		} else if upstream, err := net.Dial("tcp", r.Host); err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+		} else if conn, _, err := w.(http.Hijacker).Hijack(); err != nil {
+			upstream.Close()
+		} else {
+			tunnels.Add(1)
+			conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+			go func() {
+				io.Copy(upstream, conn)
+				upstream.Close()
+			}()
+			go func() {
+				io.Copy(conn, upstream)
+				conn.Close()
+			}()
+		}
+	}))
+	defer proxy.Close()
+
+	if client, err := newBHEHttpClient(proxy.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res, err := client.Get(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); tunnels.Load() != 1 {
+		t.Errorf("got %v tunnels, want the request sent through the proxy", tunnels.Load())
+	} else if presented().Cmp(serial) != 0 {
+		t.Errorf("got certificate %v, want %v", presented(), serial)
+	}
+}
+
+func TestNewBHEHttpClientClientCertInvalid(t *testing.T) {
+	newMutualTLSServer(t)
+	var (
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "client.pem")
+		keyFile  = filepath.Join(dir, "client.key")
+	)
+	writeClientCert(t, certFile, keyFile, "passphrase", time.Now())
+
+	config.BHEClientCert.Set(certFile)
+	if _, err := newBHEHttpClient(""); err == nil {
+		t.Error("expected an error without a key but did not receive one")
+	}
+
+	config.BHEClientKey.Set(keyFile)
+	config.BHEClientKeyPass.Set("wrong")
+	if _, err := newBHEHttpClient(""); err == nil {
+		t.Error("expected an error with the wrong passphrase but did not receive one")
+	}
+}
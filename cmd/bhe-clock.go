@@ -0,0 +1,63 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// signingSkewThreshold is how far the server's clock may be from the time a rejected request was signed with
+// before the rejection is attributed to this host's clock; the Date header only has second precision
+const signingSkewThreshold = 30 * time.Second
+
+// signingClock is the clock BloodHound Enterprise requests are signed with. It follows the local clock until the
+// instance rejects a signature and its Date header shows the local clock has drifted, then follows the server's.
+type signingClock struct {
+	offset atomic.Int64
+}
+
+func (s *signingClock) now() time.Time {
+	return time.Now().Add(time.Duration(s.offset.Load()))
+}
+
+// adjust inspects the response to a request signed at signedAt. When the instance rejected the signature and its
+// clock is outside the threshold of signedAt, the offset to the server's clock is stored for subsequent requests
+// and adjust reports that the request should be signed again.
+func (s *signingClock) adjust(res *http.Response, signedAt time.Time) bool {
+	if res.StatusCode != http.StatusUnauthorized {
+		return false
+	} else if serverTime, err := http.ParseTime(res.Header.Get("Date")); err != nil {
+		return false
+	} else if skew := serverTime.Sub(signedAt); skew > -signingSkewThreshold && skew < signingSkewThreshold {
+		return false
+	} else {
+		// the offset is measured against the local clock rather than accumulated so concurrent rejections agree
+		offset := time.Until(serverTime)
+		s.offset.Store(int64(offset))
+
+		drift, direction := offset, "behind"
+		if drift < 0 {
+			drift, direction = -drift, "ahead of"
+		}
+		log.Info(fmt.Sprintf("WARNING: bloodhound enterprise rejected a request signature because this host's clock is %s %s the server's; signing requests with the server's time, synchronize this host's clock", drift.Round(time.Second), direction), "skew", (-offset).Round(time.Second).String())
+		return true
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSkewedServer returns an instance whose clock is skew ahead of the local one and which rejects requests that
+// are not signed within five minutes of its clock, or whose signature does not match their RequestDate
+func newSkewedServer(t *testing.T, skew time.Duration, token string, bodies *[]string) *httptest.Server {
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverTime := time.Now().Add(skew)
+		w.Header().Set("Date", serverTime.UTC().Format(http.TimeFormat))
+
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		*bodies = append(*bodies, string(body))
+		mutex.Unlock()
+
+		datetime := r.Header.Get("RequestDate")
+		if requestDate, err := time.Parse(time.RFC3339, datetime); err != nil || requestDate.Sub(serverTime).Abs() > 5*time.Minute {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else if r.Header.Get("Signature") != testSignature(token, r.Method+r.URL.Path, datetime, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else {
+			w.Write([]byte("{}"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testSignature(token, path, datetime string, body []byte) string {
+	digester := hmac.New(sha256.New, []byte(token))
+	digester.Write([]byte(path))
+	digester = hmac.New(sha256.New, digester.Sum(nil))
+	digester.Write([]byte(datetime[:13]))
+	digester = hmac.New(sha256.New, digester.Sum(nil))
+	digester.Write(body)
+	return base64.StdEncoding.EncodeToString(digester.Sum(nil))
+}
+
+func TestSigningTransportClockSkew(t *testing.T) {
+	var (
+		bodies []string
+		server = newSkewedServer(t, 10*time.Minute, "token", &bodies)
+		clock  = &signingClock{}
+		client = &http.Client{Transport: signingTransport{base: server.Client().Transport, tokenId: "id", token: "token", signature: BHEAuthSignature, clock: clock}}
+	)
+
+	// the first request is rejected and signed again with the server's time
+	if res, err := client.Post(server.URL+"/api/v2/ingest", "application/json", strings.NewReader(`{"data":[]}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); res.StatusCode != http.StatusOK {
+		t.Fatalf("got status %v, want %v", res.StatusCode, http.StatusOK)
+	} else if len(bodies) != 2 || bodies[1] != `{"data":[]}` {
+		t.Fatalf("got requests %q, want the rejected request and its retry", bodies)
+	} else if offset := time.Duration(clock.offset.Load()); offset < 9*time.Minute || offset > 11*time.Minute {
+		t.Errorf("got offset %v, want about %v", offset, 10*time.Minute)
+	}
+
+	// subsequent requests are signed with the offset up front
+	bodies = nil
+	if res, err := client.Get(server.URL + "/api/v2/jobs/current"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); res.StatusCode != http.StatusOK {
+		t.Errorf("got status %v, want %v", res.StatusCode, http.StatusOK)
+	} else if len(bodies) != 1 {
+		t.Errorf("got %v requests, want 1", len(bodies))
+	}
+}
+
+func TestSigningTransportRejectedWithoutSkew(t *testing.T) {
+	var (
+		bodies []string
+		server = newSkewedServer(t, 0, "other", &bodies)
+		clock  = &signingClock{}
+		client = &http.Client{Transport: signingTransport{base: server.Client().Transport, tokenId: "id", token: "token", signature: BHEAuthSignature, clock: clock}}
+	)
+
+	// a rejection that is not explained by the clock is returned as is
+	if res, err := client.Get(server.URL + "/api/v2/jobs/current"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res.Body.Close(); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %v, want %v", res.StatusCode, http.StatusUnauthorized)
+	} else if len(bodies) != 1 {
+		t.Errorf("got %v requests, want 1", len(bodies))
+	} else if offset := clock.offset.Load(); offset != 0 {
+		t.Errorf("got offset %v, want 0", time.Duration(offset))
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+)
+
+// bheMaxPages bounds how many pages of a list are requested, in case an instance keeps reporting more results
+const bheMaxPages = 1000
+
+// bheEnvelope is how newer BloodHound Enterprise API versions wrap results. Lists are paginated: count is the total
+// number of results and skip and limit describe the page returned.
+type bheEnvelope struct {
+	Data  json.RawMessage `json:"data"`
+	Count *int            `json:"count"`
+	Skip  int             `json:"skip"`
+	Limit int             `json:"limit"`
+}
+
+// decodeBHEResponse decodes the result in body into v, whether it is wrapped in a {data, count, skip, limit}
+// envelope or not. The envelope is returned so lists can follow its pagination; it is nil for bare results.
+func decodeBHEResponse(body io.Reader, v any) (*bheEnvelope, error) {
+	var (
+		raw      json.RawMessage
+		envelope bheEnvelope
+		fields   map[string]json.RawMessage
+	)
+
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, err
+	} else if trimmed := bytes.TrimSpace(raw); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, json.Unmarshal(raw, v)
+	} else if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	} else if _, ok := fields["data"]; !ok {
+		return nil, json.Unmarshal(raw, v)
+	} else if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(envelope.Data, v); err != nil {
+		return nil, err
+	} else {
+		return &envelope, nil
+	}
+}
+
+// getBHEList requests every page of the list at path, which instances return either as a bare array or as pages of
+// an envelope. The first page is requested without paging parameters so instances that don't paginate are unaffected.
+func getBHEList[T any](ctx context.Context, bheUrl url.URL, bheClient *http.Client, path string) ([]T, error) {
+	var (
+		results []T
+		params  map[string]string
+	)
+
+	for pages := 0; pages < bheMaxPages; pages++ {
+		if page, envelope, err := getBHEPage[T](ctx, bheUrl, bheClient, path, params); err != nil {
+			return nil, err
+		} else if results = append(results, page...); envelope == nil || envelope.Count == nil || len(page) == 0 {
+			return results, nil
+		} else if next := envelope.Skip + len(page); next >= *envelope.Count {
+			return results, nil
+		} else {
+			params = map[string]string{"skip": strconv.Itoa(next)}
+			if envelope.Limit > 0 {
+				params["limit"] = strconv.Itoa(envelope.Limit)
+			}
+		}
+	}
+	return nil, fmt.Errorf("unable to read %s: more than %d pages of results", path, bheMaxPages)
+}
+
+func getBHEPage[T any](ctx context.Context, bheUrl url.URL, bheClient *http.Client, path string, params map[string]string) ([]T, *bheEnvelope, error) {
+	var page []T
+	if req, err := rest.NewRequest(ctx, "GET", bheEndpoint(bheUrl, path), nil, params, nil); err != nil {
+		return nil, nil, err
+	} else if res, err := do(bheClient, req); err != nil {
+		return nil, nil, err
+	} else {
+		defer res.Body.Close()
+		if envelope, err := decodeBHEResponse(res.Body, &page); err != nil {
+			return nil, nil, fmt.Errorf("unable to read %s: %w", path, err)
+		} else {
+			return page, envelope, nil
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+// serveFixtures answers each request with the fixture named for its skip parameter, or page1 without one
+func serveFixtures(t *testing.T, fixtures map[string]string) (*httptest.Server, *[]string) {
+	var (
+		mutex   sync.Mutex
+		queries []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		queries = append(queries, r.URL.RawQuery)
+
+		if name, ok := fixtures[r.URL.Query().Get("skip")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		} else if content, err := os.ReadFile(filepath.Join("testdata", name)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.Write(content)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func taskIds(tasks []models.ClientTask) []int {
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.Id
+	}
+	return ids
+}
+
+func TestGetAvailableTasksBare(t *testing.T) {
+	server, queries := serveFixtures(t, map[string]string{"": "availabletasks.bare.json"})
+	bheUrl, _ := url.Parse(server.URL)
+
+	if tasks, err := getAvailableTasks(context.Background(), *bheUrl, server.Client()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if ids := taskIds(tasks); len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("got tasks %v, want [1 2 3]", ids)
+	} else if tasks[0].ExectionTime.IsZero() {
+		t.Error("expected the execution time to be decoded")
+	} else if tasks[0].CollectionKinds != nil || strings.Join(tasks[1].CollectionKinds, ",") != "AZUser,AZGroup" {
+		t.Errorf("got collection kinds %v and %v, want none and [AZUser AZGroup]", tasks[0].CollectionKinds, tasks[1].CollectionKinds)
+	} else if len(*queries) != 1 {
+		t.Errorf("got %v requests, want 1", len(*queries))
+	}
+}
+
+func TestGetAvailableTasksPaginated(t *testing.T) {
+	server, queries := serveFixtures(t, map[string]string{"": "availabletasks.page1.json", "2": "availabletasks.page2.json"})
+	bheUrl, _ := url.Parse(server.URL)
+
+	if tasks, err := getAvailableTasks(context.Background(), *bheUrl, server.Client()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if ids := taskIds(tasks); len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("got tasks %v, want [1 2 3]", ids)
+	} else if got := strings.Join(*queries, ","); got != ",limit=2&skip=2" {
+		t.Errorf("got queries %q, want the second page requested with skip and limit", got)
+	}
+}
+
+func TestDecodeBHEResponse(t *testing.T) {
+	for _, tc := range []struct {
+		body     string
+		id       int
+		envelope bool
+	}{
+		{`{"id":1,"status":1}`, 1, false},
+		{`{"data":{"id":2,"status":1}}`, 2, true},
+		{`{"data":{"id":3},"count":1,"skip":0,"limit":10}`, 3, true},
+	} {
+		var task models.ClientTask
+		if envelope, err := decodeBHEResponse(strings.NewReader(tc.body), &task); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.body, err)
+		} else if task.Id != tc.id {
+			t.Errorf("%s: got id %v, want %v", tc.body, task.Id, tc.id)
+		} else if (envelope != nil) != tc.envelope {
+			t.Errorf("%s: got envelope %v, want %v", tc.body, envelope != nil, tc.envelope)
+		}
+	}
+
+	var tasks []models.ClientTask
+	if _, err := decodeBHEResponse(strings.NewReader(`{"data":"not a list"}`), &tasks); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+var ErrCanaryFailed = errors.New("bloodhound enterprise ingest canary failed")
+
+// ingestCapabilities are what BloodHound Enterprise reported about its ingest endpoint in response to the canary
+type ingestCapabilities struct {
+	// The request content encodings the instance accepts, from the Accept-Encoding response header
+	Compression []string
+
+	// Whether the instance honored the request to wait for the batch to be processed before responding
+	CompletionMarkers bool
+}
+
+// accepts reports whether the instance accepts request bodies with encoding; one that didn't list any encodings is
+// assumed to accept them all
+func (s ingestCapabilities) accepts(encoding string) bool {
+	return len(s.Compression) == 0 || internal.Contains(s.Compression, encoding)
+}
+
+// discoveredIngestCapabilities holds the capabilities reported in response to the canary, or nil if it wasn't sent
+var discoveredIngestCapabilities atomic.Pointer[ingestCapabilities]
+
+// knownIngestCapabilities returns the capabilities of the instance the batches are ingested into. Without a canary
+// nothing is known, so everything is assumed to be supported and ingestBatch falls back on the instance's responses.
+func knownIngestCapabilities() ingestCapabilities {
+	if capabilities := discoveredIngestCapabilities.Load(); capabilities != nil {
+		return *capabilities
+	} else {
+		return ingestCapabilities{CompletionMarkers: true}
+	}
+}
+
+// runIngestCanary sends the ingest canary when --ingest-canary is set and keeps the capabilities the instance reports
+// for the batches that follow
+func runIngestCanary(ctx context.Context, bheUrl url.URL, bheClient *http.Client) error {
+	if !config.IngestCanary.Value().(bool) {
+		return nil
+	} else if capabilities, err := ingestCanary(ctx, bheUrl, bheClient); err != nil {
+		log.Error(err, "ingest canary failed; aborting before collection")
+		return err
+	} else {
+		log.Info("ingest canary accepted", "compression", strings.Join(capabilities.Compression, ","), "completionMarkers", capabilities.CompletionMarkers)
+		discoveredIngestCapabilities.Store(&capabilities)
+		if config.IngestCompression.Value().(bool) && !capabilities.accepts("gzip") {
+			log.Info("note: sending ingest requests uncompressed; BloodHound Enterprise did not accept gzip compressed requests", "reason", "the instance does not list gzip in Accept-Encoding")
+		}
+		return nil
+	}
+}
+
+// ingestCanary sends a batch with no objects through the same signed request path as real ingest batches to verify
+// that ingest will be accepted before any collection starts. It is sent once, without the retries of ingestBatch, so
+// a busy instance fails fast.
+func ingestCanary(ctx context.Context, bheUrl url.URL, bheClient *http.Client) (ingestCapabilities, error) {
+	var (
+		endpoint = bheEndpoint(bheUrl, "/api/v2/ingest")
+		body     = models.IngestRequest{
+			Meta: models.Meta{
+				Type: "azure",
+			},
+			Data: []interface{}{},
+		}
+		headers = map[string]string{"Prefer": "wait=60"}
+	)
+
+	if req, err := rest.NewRequest(ctx, http.MethodPost, endpoint, body, nil, headers); err != nil {
+		return ingestCapabilities{}, err
+	} else if res, err := bheClient.Do(req); err != nil {
+		return ingestCapabilities{}, fmt.Errorf("%w: %w", ErrCanaryFailed, err)
+	} else {
+		defer res.Body.Close()
+		switch {
+		case res.StatusCode == http.StatusAccepted || res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNoContent:
+			return newIngestCapabilities(res.Header), nil
+		case res.StatusCode == http.StatusUnauthorized:
+			return ingestCapabilities{}, fmt.Errorf("%w: %w, %s: the ingest request was not authorized, verify the token id and token of this client", ErrCanaryFailed, rest.ErrUnauthorized, res.Status)
+		case res.StatusCode == http.StatusForbidden:
+			return ingestCapabilities{}, fmt.Errorf("%w: %w, %s: the ingest request was not authorized, verify the token id and token of this client", ErrCanaryFailed, rest.ErrForbidden, res.Status)
+		case res.StatusCode == http.StatusRequestEntityTooLarge:
+			return ingestCapabilities{}, fmt.Errorf("%w: %s: the instance or a proxy in front of it limits the request size; ingest batches of up to %d bytes must be accepted", ErrCanaryFailed, res.Status, ingestBatchMaxBytes)
+		case res.StatusCode == http.StatusUnsupportedMediaType:
+			return ingestCapabilities{}, fmt.Errorf("%w: %s: the instance did not accept the ingest content type", ErrCanaryFailed, res.Status)
+		default:
+			message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+			return ingestCapabilities{}, fmt.Errorf("%w: received unexpected response code from %v: %s %s", ErrCanaryFailed, endpoint, res.Status, message)
+		}
+	}
+}
+
+func newIngestCapabilities(header http.Header) ingestCapabilities {
+	var capabilities ingestCapabilities
+	for _, value := range header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			if encoding = strings.TrimSpace(encoding); encoding != "" {
+				capabilities.Compression = append(capabilities.Compression, encoding)
+			}
+		}
+	}
+	for _, value := range header.Values("Preference-Applied") {
+		if strings.HasPrefix(strings.TrimSpace(value), "wait") {
+			capabilities.CompletionMarkers = true
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,153 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func init() {
+	setupLogger()
+}
+
+func TestIngestCanary(t *testing.T) {
+	var (
+		path          string
+		authorization string
+		body          models.IngestRequest
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Accept-Encoding", "gzip, br")
+		w.Header().Set("Preference-Applied", "wait=60")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	bheClient, err := newSigningHttpClient(BHEAuthSignature, "tokenId", "token", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if capabilities, err := ingestCanary(context.Background(), *bheUrl, bheClient); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if path != "/api/v2/ingest" {
+		t.Errorf("got path %v, want %v", path, "/api/v2/ingest")
+	} else if !strings.HasPrefix(authorization, BHEAuthSignature) {
+		t.Errorf("expected a signed request, got authorization %q", authorization)
+	} else if data, ok := body.Data.([]interface{}); !ok || len(data) != 0 {
+		t.Errorf("got data %v, want an empty batch", body.Data)
+	} else if strings.Join(capabilities.Compression, ",") != "gzip,br" {
+		t.Errorf("got compression %v, want %v", capabilities.Compression, "gzip,br")
+	} else if !capabilities.CompletionMarkers {
+		t.Error("expected completion markers to be reported")
+	}
+}
+
+func TestIngestCanaryRejected(t *testing.T) {
+	for status, diagnostic := range map[int]string{
+		http.StatusUnauthorized:          "not authorized",
+		http.StatusForbidden:             "not authorized",
+		http.StatusRequestEntityTooLarge: "limits the request size",
+		http.StatusUnsupportedMediaType:  "content type",
+		http.StatusServiceUnavailable:    "unexpected response code",
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		bheUrl, _ := url.Parse(server.URL)
+		if _, err := ingestCanary(context.Background(), *bheUrl, server.Client()); err == nil {
+			t.Errorf("%d: expected an error but did not receive one", status)
+		} else if !errors.Is(err, ErrCanaryFailed) {
+			t.Errorf("%d: got %v, want %v", status, err, ErrCanaryFailed)
+		} else if !strings.Contains(err.Error(), diagnostic) {
+			t.Errorf("%d: expected a %q diagnostic, got %v", status, diagnostic, err)
+		}
+		server.Close()
+	}
+}
+
+func TestIngestCanaryUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	bheUrl, _ := url.Parse(server.URL)
+	server.Close()
+
+	if _, err := ingestCanary(context.Background(), *bheUrl, http.DefaultClient); !errors.Is(err, ErrCanaryFailed) {
+		t.Errorf("got %v, want %v", err, ErrCanaryFailed)
+	}
+}
+
+func TestIngestCanaryDisablesCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Encoding", "br")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	config.IngestCanary.Set(true)
+	t.Cleanup(func() {
+		config.IngestCanary.Set(false)
+		discoveredIngestCapabilities.Store(nil)
+	})
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := runIngestCanary(context.Background(), *bheUrl, server.Client()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if ingestCompression() {
+		t.Error("expected compression to be disabled for an instance that does not accept gzip")
+	}
+}
+
+func TestIngestBatchCanaryCapabilities(t *testing.T) {
+	var encodings, preferences []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		preferences = append(preferences, r.Header.Get("Prefer"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Cleanup(func() { discoveredIngestCapabilities.Store(nil) })
+
+	// the batches follow what the canary found out: no gzip and no waiting for an instance that supports neither
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	for _, capabilities := range []*ingestCapabilities{nil, {Compression: []string{"gzip"}, CompletionMarkers: true}, {Compression: []string{"br"}}} {
+		discoveredIngestCapabilities.Store(capabilities)
+		if err := ingestBatch(context.Background(), endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if strings.Join(encodings, ",") != "gzip,gzip," {
+		t.Errorf("got content encodings %q, want %q", encodings, []string{"gzip", "gzip", ""})
+	} else if strings.Join(preferences, ",") != "wait=60,wait=60," {
+		t.Errorf("got preferences %q, want %q", preferences, []string{"wait=60", "wait=60", ""})
+	}
+}
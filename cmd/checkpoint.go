@@ -0,0 +1,48 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/spf13/cobra"
+)
+
+// The checkpoint of the running collection, if --checkpoint is set
+var activeCheckpoint *collector.Checkpoint
+
+// The commands collecting a single checkpointed object type, which are the only ones --resume-from-object-id applies to
+func resumableCommand(cmd *cobra.Command) bool {
+	return cmd == listUsersCmd || cmd == listGroupsCmd || cmd == listServicePrincipalsCmd
+}
+
+// setupCheckpoint loads the checkpoint, if any, before collection starts
+func setupCheckpoint(cmd *cobra.Command) error {
+	if id := config.ResumeFromObjectId.Value().(string); id != "" && !resumableCommand(cmd) {
+		return fmt.Errorf("--resume-from-object-id only applies to the users, groups and service-principals commands")
+	} else if path := config.Checkpoint.Value().(string); path == "" {
+		return nil
+	} else if state, err := collector.LoadCheckpoint(path, log); err != nil {
+		return err
+	} else {
+		activeCheckpoint = state
+		return nil
+	}
+}
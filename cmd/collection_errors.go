@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+// collectionOutcome returns the status and message a collection job is ended with. A job that stopped ingesting at
+// an unrecoverable error failed; one that is missing data, whether to collection errors, dropped batches, missing
+// permissions or the deadline, is partially complete.
+func collectionOutcome(stats ingestStats, deadlineExceeded bool) (models.JobStatus, string) {
+	var (
+		problems = []string{}
+		failed   = collector.FailedKinds()
+		skipped  = collector.SkippedKinds()
+	)
+
+	if stats.hasErrors() {
+		problems = append(problems, "ingest "+stats.String())
+	}
+	if len(failed) > 0 {
+		problems = append(problems, "errors collecting "+strings.Join(failed, ", "))
+	}
+	if deadlineExceeded {
+		problems = append(problems, "stopped at the collection deadline, only part of the tenant was collected")
+	}
+	if len(skipped) > 0 {
+		problems = append(problems, "skipped due to insufficient permissions: "+strings.Join(skipped, ", "))
+	}
+
+	if stats.aborted {
+		return models.JobStatusFailed, "Collection failed; " + strings.Join(problems, "; ")
+	} else if len(problems) > 0 {
+		return models.JobStatusPartiallyComplete, "Collection partially completed; " + strings.Join(problems, "; ")
+	} else {
+		return models.JobStatusComplete, "Collection completed successfully"
+	}
+}
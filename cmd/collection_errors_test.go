@@ -0,0 +1,82 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+// failUsers runs the user collector once for each of errs, which it fails with, so users are counted as a failed kind
+func failUsers(t *testing.T, errs ...error) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	for _, err := range errs {
+		mockChannel := make(chan azure.UserResult, 1)
+		mockChannel <- azure.UserResult{Error: err}
+		close(mockChannel)
+		mockClient.EXPECT().ListAzureADUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockChannel)
+		for range collector.ListUsers(withCollectionOptions(context.Background()), mockClient) {
+		}
+	}
+}
+
+func TestCollectionOutcome(t *testing.T) {
+	collector.ResetKinds()
+	t.Cleanup(collector.ResetKinds)
+
+	if status, message := collectionOutcome(ingestStats{}, false); status != models.JobStatusComplete {
+		t.Errorf("got %v, want %v", status, models.JobStatusComplete)
+	} else if message != "Collection completed successfully" {
+		t.Errorf("unexpected message: %v", message)
+	}
+
+	failUsers(t, errors.New("forbidden"), errors.New("timeout"))
+
+	status, message := collectionOutcome(ingestStats{dropped: 2, spooled: 1}, true)
+	if status != models.JobStatusPartiallyComplete {
+		t.Errorf("got %v, want %v", status, models.JobStatusPartiallyComplete)
+	}
+	for _, want := range []string{"ingest 2 batches dropped, 1 batch spooled for the next job", "errors collecting AZUser (2 errors)", "collection deadline"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("expected %q to contain %q", message, want)
+		}
+	}
+
+	if status, message := collectionOutcome(ingestStats{aborted: true}, false); status != models.JobStatusFailed {
+		t.Errorf("got %v, want %v", status, models.JobStatusFailed)
+	} else if !strings.HasPrefix(message, "Collection failed; ingest stopped at an unrecoverable error") {
+		t.Errorf("unexpected message: %v", message)
+	}
+
+	collector.ResetKinds()
+	if kinds := collector.FailedKinds(); len(kinds) != 0 {
+		t.Errorf("got %v, want none", kinds)
+	}
+}
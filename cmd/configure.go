@@ -152,6 +152,17 @@ func configure() error {
 				}
 			}
 		}
+
+		if confirm("Set proxy credentials", false) {
+			if username, err := prompt("Proxy Username", nil, false); err != nil {
+				return err
+			} else if password, err := prompt("Proxy Password", nil, true); err != nil {
+				return err
+			} else {
+				config.ProxyUsername.Set(username)
+				config.ProxyPassword.Set(password)
+			}
+		}
 	}
 
 	// Configure Logging
@@ -163,7 +174,12 @@ func configure() error {
 		} else {
 			config.VerbosityLevel.Set(idx - 1)
 			config.LogFile.Set(logFile)
-			config.JsonLogs.Set(confirm("Enable Structured Logs", false))
+			config.JsonLogs.Set(false)
+			if confirm("Enable Structured Logs", false) {
+				config.LogFormat.Set(enums.LogFormatJson)
+			} else {
+				config.LogFormat.Set(enums.LogFormatText)
+			}
 		}
 	}
 
@@ -0,0 +1,190 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+)
+
+const (
+	directUploadBatchSize    = 1000
+	directUploadBatchTimeout = 10 * time.Second
+)
+
+// outputDirectUpload streams the collection to the BloodHound file upload API while writing it to a local file. The
+// file is kept when --output is set or the upload fails, so the collection can always be uploaded again later.
+func outputDirectUpload(ctx context.Context, stream <-chan string) {
+	var (
+		outputFile = config.OutputFile.Value().(string)
+		path       = outputFile
+		toFile     = make(chan string)
+		toUpload   = make(chan string)
+		fileErr    = make(chan error, 1)
+		uploaded   int
+		uploadErr  error
+	)
+
+	if path == "" {
+		path = fmt.Sprintf("azurehound-%d.json", time.Now().Unix())
+	}
+
+	pipeline.Tee(ctx.Done(), stream, toFile, toUpload)
+	go func() {
+		err := sinks.WriteToFile(ctx, path, toFile)
+		// a file that can't be written must not hold up the upload
+		for range toFile {
+		}
+		fileErr <- err
+	}()
+
+	if bheInstance, bheClient, err := newUploadClient(); err != nil {
+		uploadErr = err
+		for range toUpload {
+		}
+	} else {
+		uploaded, uploadErr = directUpload(ctx, *bheInstance, bheClient, toUpload)
+	}
+
+	if err := <-fileErr; err != nil && uploadErr != nil {
+		exitWithCode(ExitCodeUploadFailure, fmt.Errorf("direct upload failed and the collection could not be written to %s: %w: %w", path, err, uploadErr))
+	} else if uploadErr != nil {
+		exitWithCode(ExitCodeUploadFailure, fmt.Errorf("direct upload failed after %d objects; the collection was written to %s instead: %w", uploaded, path, uploadErr))
+	} else if err != nil {
+		log.Error(err, "unable to write the local copy of the uploaded collection", "path", path)
+	}
+
+	log.Info("uploaded collected data to bloodhound", "objects", uploaded)
+	if outputFile == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Error(err, "unable to remove the local copy of the uploaded collection", "path", path)
+		}
+	}
+}
+
+// directUpload runs a file upload job that ingests stream in batches and returns the number of objects uploaded. The
+// stream is drained even after a failure so that the items keep flowing to any other consumer.
+func directUpload(ctx context.Context, bheUrl url.URL, bheClient *http.Client, stream <-chan string) (int, error) {
+	var (
+		batches  = pipeline.Batch(ctx.Done(), stream, directUploadBatchSize, directUploadBatchTimeout)
+		uploaded = 0
+	)
+
+	log.Info("uploading collected data to bloodhound...")
+	jobId, err := startFileUpload(ctx, bheUrl, bheClient)
+	if err != nil {
+		err = fmt.Errorf("unable to start file upload job: %w", err)
+	}
+
+	for batch := range batches {
+		if err != nil {
+			continue
+		} else if err = uploadFileChunk(ctx, bheUrl, bheClient, jobId, batch); err != nil {
+			err = fmt.Errorf("unable to upload batch to file upload job %d: %w", jobId, err)
+		} else {
+			uploaded += len(batch)
+			log.V(1).Info("uploaded batch", "jobId", jobId, "count", len(batch), "uploaded", uploaded)
+		}
+	}
+
+	if err != nil {
+		return uploaded, err
+	} else if ctx.Err() != nil {
+		// an interrupted collection is left for the local file rather than ingested partially
+		return uploaded, rest.Aborted(ctx)
+	} else if err := endFileUpload(ctx, bheUrl, bheClient, jobId); err != nil {
+		return uploaded, fmt.Errorf("unable to end file upload job %d: %w", jobId, err)
+	} else {
+		return uploaded, nil
+	}
+}
+
+func startFileUpload(ctx context.Context, bheUrl url.URL, bheClient *http.Client) (int, error) {
+	var (
+		endpoint = bheEndpoint(bheUrl, "/api/v2/file-upload/start")
+		response models.FileUploadJobResponse
+	)
+
+	if req, err := rest.NewRequest(ctx, "POST", endpoint, nil, nil, nil); err != nil {
+		return 0, err
+	} else if res, err := do(bheClient, req); err != nil {
+		return 0, err
+	} else {
+		defer res.Body.Close()
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			return 0, fmt.Errorf("unable to read file upload job: %w", err)
+		} else {
+			log.V(1).Info("started file upload job", "jobId", response.Data.Id)
+			return response.Data.Id, nil
+		}
+	}
+}
+
+// uploadFileChunk uploads a batch as a collection file of its own, which is how the file upload API expects data
+func uploadFileChunk(ctx context.Context, bheUrl url.URL, bheClient *http.Client, jobId int, batch []string) error {
+	var (
+		endpoint = bheEndpoint(bheUrl, fmt.Sprintf("/api/v2/file-upload/%d", jobId))
+		data     = make([]json.RawMessage, len(batch))
+	)
+
+	for i, item := range batch {
+		data[i] = json.RawMessage(item)
+	}
+
+	body := models.IngestRequest{
+		Meta: models.Meta{
+			Type:    "azure",
+			Version: 5,
+			Count:   len(data),
+			Sample:  sampleSize,
+		},
+		Data: data,
+	}
+
+	if req, err := rest.NewRequest(ctx, "POST", endpoint, body, nil, nil); err != nil {
+		return err
+	} else if res, err := do(bheClient, req); err != nil {
+		return err
+	} else {
+		res.Body.Close()
+		return nil
+	}
+}
+
+func endFileUpload(ctx context.Context, bheUrl url.URL, bheClient *http.Client, jobId int) error {
+	endpoint := bheEndpoint(bheUrl, fmt.Sprintf("/api/v2/file-upload/%d/end", jobId))
+	if req, err := rest.NewRequest(ctx, "POST", endpoint, nil, nil, nil); err != nil {
+		return err
+	} else if res, err := do(bheClient, req); err != nil {
+		return err
+	} else {
+		res.Body.Close()
+		return nil
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func init() {
+	setupLogger()
+}
+
+type fileUploadServer struct {
+	mutex     sync.Mutex
+	requests  []string
+	uploaded  int
+	failAfter int
+}
+
+func (s *fileUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, r.URL.Path)
+
+	switch r.URL.Path {
+	case "/api/v2/file-upload/start":
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.FileUploadJobResponse{Data: models.FileUploadJob{Id: 7}})
+	case "/api/v2/file-upload/7":
+		var body struct {
+			Meta models.Meta       `json:"meta"`
+			Data []json.RawMessage `json:"data"`
+		}
+		if s.failAfter > 0 && s.uploaded >= s.failAfter {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"errors":[{"message":"ingest failed"}]}`))
+		} else if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Meta.Count != len(body.Data) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{}`))
+		} else {
+			s.uploaded += len(body.Data)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	case "/api/v2/file-upload/7/end":
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{}`))
+	}
+}
+
+func newUploadStream(count int) <-chan string {
+	stream := make(chan string)
+	go func() {
+		defer close(stream)
+		for i := 0; i < count; i++ {
+			stream <- fmt.Sprintf(`{"kind":"AZUser","data":{"id":"%d"}}`, i)
+		}
+	}()
+	return stream
+}
+
+func TestDirectUpload(t *testing.T) {
+	handler := &fileUploadServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if uploaded, err := directUpload(context.Background(), *bheUrl, server.Client(), newUploadStream(2500)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if uploaded != 2500 || handler.uploaded != 2500 {
+		t.Errorf("got %v uploaded and %v received, want 2500", uploaded, handler.uploaded)
+	} else if first, last := handler.requests[0], handler.requests[len(handler.requests)-1]; first != "/api/v2/file-upload/start" || last != "/api/v2/file-upload/7/end" {
+		t.Errorf("unexpected requests: %v", handler.requests)
+	} else if len(handler.requests) != 5 {
+		t.Errorf("got %v requests, want a start, 3 batches and an end", len(handler.requests))
+	}
+}
+
+func TestDirectUploadFailure(t *testing.T) {
+	handler := &fileUploadServer{failAfter: directUploadBatchSize}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if uploaded, err := directUpload(context.Background(), *bheUrl, server.Client(), newUploadStream(2500)); err == nil {
+		t.Fatal("expected an error but did not receive one")
+	} else if uploaded != directUploadBatchSize {
+		t.Errorf("got %v uploaded, want %v", uploaded, directUploadBatchSize)
+	} else if !strings.Contains(err.Error(), "ingest failed") {
+		t.Errorf("expected the server's error, got %v", err)
+	} else if last := handler.requests[len(handler.requests)-1]; last == "/api/v2/file-upload/7/end" {
+		t.Error("expected a failed upload job not to be ended")
+	}
+}
+
+func TestOutputDirectUploadKeepsOutputFile(t *testing.T) {
+	handler := &fileUploadServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "output.json")
+	config.BHEUrl.Set(server.URL)
+	config.BHETokenId.Set("tokenId")
+	config.BHEToken.Set("token")
+	config.OutputFile.Set(path)
+	t.Cleanup(func() {
+		config.BHEUrl.Set("")
+		config.BHETokenId.Set("")
+		config.BHEToken.Set("")
+		config.OutputFile.Set("")
+	})
+
+	outputDirectUpload(context.Background(), newUploadStream(10))
+
+	var collection struct {
+		Meta models.Meta `json:"meta"`
+	}
+	if data, err := os.ReadFile(path); err != nil {
+		t.Fatalf("expected the output file to be kept: %v", err)
+	} else if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if collection.Meta.Count != 10 || handler.uploaded != 10 {
+		t.Errorf("got %v written and %v uploaded, want 10", collection.Meta.Count, handler.uploaded)
+	}
+}
+
+func TestOutputDirectUploadUnwritableOutputFile(t *testing.T) {
+	handler := &fileUploadServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	config.BHEUrl.Set(server.URL)
+	config.BHETokenId.Set("tokenId")
+	config.BHEToken.Set("token")
+	config.OutputFile.Set(filepath.Join(t.TempDir(), "missing", "output.json"))
+	t.Cleanup(func() {
+		config.BHEUrl.Set("")
+		config.BHETokenId.Set("")
+		config.BHEToken.Set("")
+		config.OutputFile.Set("")
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		outputDirectUpload(context.Background(), newUploadStream(10))
+	}()
+
+	select {
+	case <-done:
+		if handler.uploaded != 10 {
+			t.Errorf("got %v uploaded, want 10", handler.uploaded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("direct upload did not finish after the output file failed to open")
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	client_config "github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	config.Init(doctorCmd, config.AzureConfig)
+	rootCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:               "doctor",
+	Short:             "Test the configuration before collecting",
+	Long:              "Validates the configuration, signs in to Graph and Azure Resource Manager and makes one small request for each permission collection relies on, then prints a table of the results with hints for fixing any failures. BloodHound Enterprise connectivity is tested too when an instance is configured. Exits with a non-zero code if any check fails.",
+	Run:               doctorCmdImpl,
+	PersistentPreRunE: persistentPreRunE,
+	SilenceUsage:      true,
+}
+
+var errCheckSkipped = errors.New("skipped")
+
+// doctorCheck is a single check of the doctor command
+type doctorCheck struct {
+	name string
+	// run performs the check and returns a short description of what it found. Checks that can't run because an
+	// earlier check failed, or that don't apply to the configuration, return an error wrapping errCheckSkipped.
+	run func(ctx context.Context) (string, error)
+	// hint explains how to fix the failure err
+	hint func(err error) string
+}
+
+type doctorResult struct {
+	name   string
+	detail string
+	err    error
+	hint   string
+}
+
+func (s doctorResult) failed() bool {
+	return s.err != nil && !errors.Is(s.err, errCheckSkipped)
+}
+
+func doctorCmdImpl(cmd *cobra.Command, args []string) {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	defer gracefulShutdown(stop)
+
+	results := runDoctorChecks(ctx, (&doctor{}).checks())
+	if err := writeDoctorResults(os.Stdout, results); err != nil {
+		exit(err)
+	} else if err := doctorError(results); err != nil {
+		exit(err)
+	}
+}
+
+// runDoctorChecks runs checks in order. Every check runs so a single run reports as many problems as possible; checks
+// that depend on a failed one report themselves skipped.
+func runDoctorChecks(ctx context.Context, checks []doctorCheck) []doctorResult {
+	results := make([]doctorResult, 0, len(checks))
+	for _, check := range checks {
+		log.V(1).Info("running check", "check", check.name)
+		detail, err := check.run(ctx)
+		result := doctorResult{name: check.name, detail: detail, err: err}
+		if result.failed() && check.hint != nil {
+			result.hint = check.hint(err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func writeDoctorResults(w io.Writer, results []doctorResult) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CHECK\tRESULT\tDETAIL")
+	for _, result := range results {
+		if errors.Is(result.err, errCheckSkipped) {
+			fmt.Fprintf(table, "%s\tSKIP\t%s\n", result.name, strings.TrimPrefix(result.err.Error(), errCheckSkipped.Error()+": "))
+		} else if result.err != nil {
+			fmt.Fprintf(table, "%s\tFAIL\t%s\n", result.name, result.err)
+		} else {
+			fmt.Fprintf(table, "%s\tPASS\t%s\n", result.name, result.detail)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.failed() && result.hint != "" {
+			if _, err := fmt.Fprintf(w, "\n%s: %s\n", result.name, result.hint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// doctorError returns an error wrapping the first failure when any check failed, so the exit code reflects its cause
+func doctorError(results []doctorResult) error {
+	var (
+		failed int
+		first  error
+	)
+	for _, result := range results {
+		if result.failed() {
+			if failed++; first == nil {
+				first = fmt.Errorf("%s: %w", result.name, result.err)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed; %w", failed, len(results), first)
+	}
+	return nil
+}
+
+// doctor holds what earlier checks found for the checks that depend on them
+type doctor struct {
+	config         *client_config.Config
+	client         client.AzureClient
+	subscriptionId string
+}
+
+func (s *doctor) checks() []doctorCheck {
+	return []doctorCheck{
+		{name: "configuration", run: s.checkConfiguration, hint: func(error) string {
+			return "fix the flag, environment variable or config file value named in the error; run azurehound configure to write a new config file"
+		}},
+		{name: "connectivity", run: s.checkConnectivity, hint: func(error) string {
+			return "allow outbound https traffic to the login, graph and management endpoints of the configured cloud, or configure a proxy with --proxy"
+		}},
+		{name: "graph token", run: s.tokenCheck(func(c client_config.Config) string { return c.GraphUrl() }), hint: credentialHint},
+		{name: "arm token", run: s.tokenCheck(func(c client_config.Config) string { return c.ResourceManagerUrl() }), hint: credentialHint},
+		{name: "organization", run: s.checkOrganization, hint: graphHint("Organization.Read.All")},
+		{name: "users", run: s.checkUsers, hint: graphHint("User.Read.All")},
+		{name: "directory role assignments", run: s.checkDirectoryRoleAssignments, hint: graphHint("RoleManagement.Read.Directory")},
+		{name: "subscriptions", run: s.checkSubscriptions, hint: armHint},
+		{name: "azure role assignments", run: s.checkRoleAssignments, hint: armHint},
+		{name: "bloodhound enterprise", run: checkBloodHoundEnterprise, hint: func(error) string {
+			return "see the preflight messages logged above for the failing step"
+		}},
+	}
+}
+
+func credentialHint(err error) string {
+	if errors.Is(err, rest.ErrAuthenticationFailed) {
+		return "the credential was rejected; verify the tenant, application id and secret or certificate, and that the secret or certificate hasn't expired"
+	}
+	return "verify the tenant and the configured credential"
+}
+
+func graphHint(permission string) func(error) string {
+	return func(err error) string {
+		if errors.Is(err, rest.ErrForbidden) {
+			return fmt.Sprintf("grant the application the %s Microsoft Graph application permission and grant admin consent", permission)
+		}
+		return "retry once the failure above is resolved; verify the credential if it persists"
+	}
+}
+
+func armHint(err error) string {
+	if errors.Is(err, rest.ErrForbidden) {
+		return "assign the Reader role to the application on the subscriptions to collect, or on a management group above them"
+	}
+	return "retry once the failure above is resolved; verify the credential if it persists"
+}
+
+func (s *doctor) checkConfiguration(ctx context.Context) (string, error) {
+	if cfg, err := newAzureClientConfig(); err != nil {
+		return "", err
+	} else {
+		s.config = &cfg
+		return fmt.Sprintf("tenant %s, region %s", valueOrUnset(cfg.Tenant), cfg.Region), nil
+	}
+}
+
+func (s *doctor) checkConnectivity(ctx context.Context) (string, error) {
+	if err := testConnections(); err != nil {
+		return "", err
+	}
+	return "reached the login, graph and management endpoints", nil
+}
+
+func (s *doctor) tokenCheck(resource func(client_config.Config) string) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		if s.config == nil {
+			return "", fmt.Errorf("%w: the configuration is invalid", errCheckSkipped)
+		} else if s.config.JWT != "" || s.config.GraphToken != "" || s.config.ArmToken != "" {
+			return "", fmt.Errorf("%w: a supplied token is used as is", errCheckSkipped)
+		} else if source, err := rest.NewTokenSource(resource(*s.config), *s.config); err != nil {
+			return "", err
+		} else if _, err := source.Authorization(); err != nil {
+			return "", err
+		} else {
+			return "signed in to " + resource(*s.config), nil
+		}
+	}
+}
+
+func (s *doctor) checkOrganization(ctx context.Context) (string, error) {
+	if s.config == nil {
+		return "", fmt.Errorf("%w: the configuration is invalid", errCheckSkipped)
+	} else if azClient, err := client.NewClient(*s.config); err != nil {
+		return "", err
+	} else if org, err := azClient.GetAzureADOrganization(ctx, []string{"id", "displayName"}); err != nil {
+		return "", err
+	} else {
+		s.client = azClient
+		return fmt.Sprintf("%s (%s)", org.DisplayName, org.Id), nil
+	}
+}
+
+func (s *doctor) checkUsers(ctx context.Context) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("%w: no client", errCheckSkipped)
+	} else if _, err := s.client.GetAzureADUsers(ctx, "", "", "", []string{"id"}, 1, false); err != nil {
+		return "", err
+	} else {
+		return "listed users", nil
+	}
+}
+
+func (s *doctor) checkDirectoryRoleAssignments(ctx context.Context) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("%w: no client", errCheckSkipped)
+	} else if _, err := s.client.GetAzureADRoleAssignments(ctx, "", "", "", "", []string{"id"}, 1, false); err != nil {
+		return "", err
+	} else {
+		return "listed directory role assignments", nil
+	}
+}
+
+func (s *doctor) checkSubscriptions(ctx context.Context) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("%w: no client", errCheckSkipped)
+	} else if list, err := s.client.GetAzureSubscriptions(ctx); err != nil {
+		return "", err
+	} else if len(list.Value) == 0 {
+		return "no subscriptions are visible to the application; assign it the Reader role to collect Azure resources", nil
+	} else {
+		s.subscriptionId = list.Value[0].SubscriptionId
+		return fmt.Sprintf("%d subscriptions on the first page", len(list.Value)), nil
+	}
+}
+
+func (s *doctor) checkRoleAssignments(ctx context.Context) (string, error) {
+	if s.subscriptionId == "" {
+		return "", fmt.Errorf("%w: no subscription", errCheckSkipped)
+	} else if _, err := s.client.GetRoleAssignmentsForResource(ctx, "/subscriptions/"+s.subscriptionId, "atScope()"); err != nil {
+		return "", err
+	} else {
+		return "listed role assignments of subscription " + s.subscriptionId, nil
+	}
+}
+
+// checkBloodHoundEnterprise runs the start command's preflight checks when an instance is configured
+func checkBloodHoundEnterprise(ctx context.Context) (string, error) {
+	if config.BHEUrl.Value().(string) == "" {
+		return "", fmt.Errorf("%w: no instance is configured", errCheckSkipped)
+	} else if bheInstance, err := parseBHEUrl(config.BHEUrl.Value().(string)); err != nil {
+		return "", err
+	} else if bheClient, err := newSigningHttpClient(BHEAuthSignature, config.BHETokenId.Value().(string), config.BHEToken.Value().(string), config.ProxyUrl()); err != nil {
+		return "", err
+	} else if httpClient, err := newBHEHttpClient(config.ProxyUrl()); err != nil {
+		return "", err
+	} else if err := preflight(ctx, *bheInstance, httpClient, bheClient); err != nil {
+		return "", err
+	} else {
+		return "connected to " + bheInstance.String(), nil
+	}
+}
+
+func valueOrUnset(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return value
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func init() {
+	setupLogger()
+}
+
+func TestDoctorResults(t *testing.T) {
+	checks := []doctorCheck{
+		{name: "pass", run: func(context.Context) (string, error) { return "fine", nil }},
+		{name: "fail", run: func(context.Context) (string, error) { return "", fmt.Errorf("listing: %w", rest.ErrForbidden) }, hint: graphHint("User.Read.All")},
+		{name: "skip", run: func(context.Context) (string, error) { return "", fmt.Errorf("%w: not configured", errCheckSkipped) }},
+	}
+
+	var (
+		results = runDoctorChecks(context.Background(), checks)
+		out     bytes.Buffer
+	)
+	if err := writeDoctorResults(&out, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"pass   PASS    fine", "fail   FAIL    listing: forbidden", "skip   SKIP    not configured", "fail: grant the application the User.Read.All"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	if err := doctorError(results); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !errors.Is(err, rest.ErrForbidden) {
+		t.Errorf("got %v, want it to wrap %v", err, rest.ErrForbidden)
+	} else if exitCode(ExitCodeFailure, err) != ExitCodeAuthFailure {
+		t.Errorf("got exit code %v, want %v", exitCode(ExitCodeFailure, err), ExitCodeAuthFailure)
+	}
+
+	if err := doctorError(runDoctorChecks(context.Background(), []doctorCheck{checks[0], checks[2]})); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDoctorAzureChecks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockClient.EXPECT().GetAzureADUsers(gomock.Any(), "", "", "", []string{"id"}, int32(1), false).Return(azure.UserList{}, fmt.Errorf("%w: Authorization_RequestDenied", rest.ErrForbidden))
+	mockClient.EXPECT().GetAzureADRoleAssignments(gomock.Any(), "", "", "", "", []string{"id"}, int32(1), false).Return(azure.UnifiedRoleAssignmentList{}, nil)
+	mockClient.EXPECT().GetAzureSubscriptions(gomock.Any()).Return(azure.SubscriptionList{Value: []azure.Subscription{{SubscriptionId: "sub"}}}, nil)
+	mockClient.EXPECT().GetRoleAssignmentsForResource(gomock.Any(), "/subscriptions/sub", "atScope()").Return(azure.RoleAssignmentList{}, nil)
+
+	var (
+		doctor = &doctor{client: mockClient}
+		checks []doctorCheck
+	)
+	for _, check := range doctor.checks() {
+		switch check.name {
+		case "users", "directory role assignments", "subscriptions", "azure role assignments":
+			checks = append(checks, check)
+		}
+	}
+
+	results := runDoctorChecks(context.Background(), checks)
+	if len(results) != 4 {
+		t.Fatalf("got %v results, want 4", len(results))
+	} else if !results[0].failed() || !strings.Contains(results[0].hint, "User.Read.All") {
+		t.Errorf("expected the users check to fail with a permission hint, got %+v", results[0])
+	}
+	for _, result := range results[1:] {
+		if result.err != nil {
+			t.Errorf("%s: unexpected error: %v", result.name, result.err)
+		}
+	}
+}
+
+func TestDoctorChecksSkipWithoutClient(t *testing.T) {
+	for _, result := range runDoctorChecks(context.Background(), (&doctor{}).checks()[4:9]) {
+		if !errors.Is(result.err, errCheckSkipped) {
+			t.Errorf("%s: got %v, want %v", result.name, result.err, errCheckSkipped)
+		}
+	}
+}
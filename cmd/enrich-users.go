@@ -0,0 +1,226 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+const (
+	enrichmentFormatCsv    = "csv"
+	enrichmentFormatNdjson = "ndjson"
+)
+
+// The number of users joined with a row of the --enrich-users file during the current collection
+var enrichedUsers int64
+
+// The lookup table loaded from --enrich-users, or nil when enrichment is disabled
+var enrichment *userEnrichment
+
+// userEnrichment holds the whitelisted columns of every row of an --enrich-users file keyed by the lowercased value of
+// its key column. The table is kept in memory for the whole collection, so its size is bounded by the size of the file.
+type userEnrichment struct {
+	key  string
+	rows map[string]map[string]string
+}
+
+func loadUserEnrichment() error {
+	enrichment = nil
+	if source := config.EnrichUsers.Value().(string); source == "" {
+		return nil
+	} else if format, path, ok := strings.Cut(source, ":"); !ok || path == "" {
+		return fmt.Errorf("expected %s:<path> or %s:<path> but got %q", enrichmentFormatCsv, enrichmentFormatNdjson, source)
+	} else if file, err := os.Open(path); err != nil {
+		return err
+	} else {
+		defer file.Close()
+		table, err := readUserEnrichment(file, format, config.EnrichKey.Value().(string), config.EnrichColumns.Value().([]string))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		log.Info("loaded user enrichment", "source", path, "rows", len(table.rows), "key", table.key)
+		enrichment = table
+		return nil
+	}
+}
+
+// readUserEnrichment reads the rows of an enrichment source. Malformed rows, rows without a key and rows repeating a key
+// are logged with their line number and skipped.
+func readUserEnrichment(r io.Reader, format string, key string, columns []string) (*userEnrichment, error) {
+	table := &userEnrichment{key: key, rows: map[string]map[string]string{}}
+	if key == "" {
+		return nil, fmt.Errorf("--%s is required", config.EnrichKey.Name)
+	} else if len(columns) == 0 {
+		return nil, fmt.Errorf("--%s is required", config.EnrichColumns.Name)
+	}
+
+	add := func(line int, row map[string]string) {
+		value := strings.ToLower(strings.TrimSpace(row[key]))
+		if value == "" {
+			log.Info("warning: skipping enrichment row without a key", "line", line, "key", key)
+		} else if _, ok := table.rows[value]; ok {
+			log.Info("warning: skipping enrichment row with a duplicate key", "line", line, "key", key, "value", row[key])
+		} else {
+			columnValues := make(map[string]string, len(columns))
+			for _, column := range columns {
+				if value, ok := row[column]; ok {
+					columnValues[column] = value
+				}
+			}
+			table.rows[value] = columnValues
+		}
+	}
+
+	switch format {
+	case enrichmentFormatCsv:
+		return table, readEnrichmentCsv(r, key, columns, add)
+	case enrichmentFormatNdjson:
+		return table, readEnrichmentNdjson(r, add)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected %s or %s", format, enrichmentFormatCsv, enrichmentFormatNdjson)
+	}
+}
+
+// readEnrichmentCsv reads a csv file with a header row naming its columns
+func readEnrichmentCsv(r io.Reader, key string, columns []string, add func(int, map[string]string)) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("unable to read header: %w", err)
+	} else if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	for _, column := range append([]string{key}, columns...) {
+		if !internal.Contains(header, column) {
+			return fmt.Errorf("header has no %q column", column)
+		}
+	}
+
+	for {
+		var parseErr *csv.ParseError
+		if record, err := reader.Read(); errors.Is(err, io.EOF) {
+			return nil
+		} else if errors.As(err, &parseErr) {
+			log.Info("warning: skipping malformed enrichment row", "line", parseErr.Line, "reason", parseErr.Err.Error())
+		} else if err != nil {
+			return err
+		} else {
+			line, _ := reader.FieldPos(0)
+			row := make(map[string]string, len(header))
+			for i, column := range header {
+				row[column] = record[i]
+			}
+			add(line, row)
+		}
+	}
+}
+
+// readEnrichmentNdjson reads a file with one JSON object per line. Values that aren't strings are added as JSON.
+func readEnrichmentNdjson(r io.Reader, add func(int, map[string]string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		var object map[string]interface{}
+		if text := strings.TrimSpace(scanner.Text()); text == "" {
+			continue
+		} else if err := json.Unmarshal([]byte(text), &object); err != nil {
+			log.Info("warning: skipping malformed enrichment row", "line", line, "reason", err.Error())
+		} else {
+			row := make(map[string]string, len(object))
+			for column, value := range object {
+				if text, ok := value.(string); ok {
+					row[column] = text
+				} else if value != nil {
+					bytes, _ := json.Marshal(value)
+					row[column] = string(bytes)
+				}
+			}
+			add(line, row)
+		}
+	}
+	return scanner.Err()
+}
+
+// lookup returns the enrichment row matching the key field of user
+func (s *userEnrichment) lookup(user models.User) (map[string]string, bool) {
+	var fields map[string]interface{}
+	if bytes, err := json.Marshal(user.User); err != nil {
+		return nil, false
+	} else if err := json.Unmarshal(bytes, &fields); err != nil {
+		return nil, false
+	} else if value, ok := fields[s.key].(string); !ok {
+		return nil, false
+	} else {
+		row, ok := s.rows[strings.ToLower(strings.TrimSpace(value))]
+		return row, ok
+	}
+}
+
+// enrichUsers adds the matching row of enrichment to each user. Native user fields are never modified; the row is
+// only added to the enrichment object of the user.
+func enrichUsers(ctx context.Context, in <-chan interface{}, enrichment *userEnrichment) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		var matched, unmatched int
+		for item := range pipeline.OrDone(ctx.Done(), in) {
+			if wrapper, ok := item.(collector.AzureWrapper); ok && wrapper.Kind == enums.KindAZUser {
+				if user, ok := wrapper.Data.(models.User); ok {
+					if row, ok := enrichment.lookup(user); ok {
+						user.Enrichment = row
+						wrapper.Data = user
+						item = wrapper
+						matched++
+						atomic.AddInt64(&enrichedUsers, 1)
+					} else {
+						unmatched++
+					}
+				}
+			}
+
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+		log.Info("finished enriching users", "matched", matched, "unmatched", unmatched, "rows", len(enrichment.rows))
+	}()
+
+	return out
+}
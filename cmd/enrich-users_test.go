@@ -0,0 +1,116 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func init() {
+	setupLogger()
+}
+
+const testEnrichmentCsv = "\ufeffuserPrincipalName,department,status,salary\n" +
+	"Alice@contoso.com,Finance,active,100\n" +
+	"bob@contoso.com,Engineering\n" +
+	"alice@contoso.com,Duplicate,active,200\n" +
+	",Nobody,active,0\n" +
+	"\"carol@contoso.com,Sales,leaver,50\n"
+
+func TestReadUserEnrichmentCsv(t *testing.T) {
+	table, err := readUserEnrichment(strings.NewReader(testEnrichmentCsv), enrichmentFormatCsv, "userPrincipalName", []string{"department", "status"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(table.rows) != 1 {
+		t.Fatalf("got %v rows, want 1 without the malformed, duplicate and keyless rows", len(table.rows))
+	} else if row := table.rows["alice@contoso.com"]; row["department"] != "Finance" || row["status"] != "active" {
+		t.Errorf("got %v, want the first alice row", row)
+	} else if _, ok := row["salary"]; ok {
+		t.Error("expected columns that aren't whitelisted to be dropped")
+	}
+}
+
+func TestReadUserEnrichmentInvalid(t *testing.T) {
+	if _, err := readUserEnrichment(strings.NewReader(testEnrichmentCsv), enrichmentFormatCsv, "userPrincipalName", []string{"manager"}); err == nil {
+		t.Error("expected an error for a missing column but did not receive one")
+	}
+	if _, err := readUserEnrichment(strings.NewReader(testEnrichmentCsv), enrichmentFormatCsv, "userPrincipalName", nil); err == nil {
+		t.Error("expected an error without columns but did not receive one")
+	}
+	if _, err := readUserEnrichment(strings.NewReader(testEnrichmentCsv), "xml", "userPrincipalName", []string{"department"}); err == nil {
+		t.Error("expected an error for an unsupported format but did not receive one")
+	}
+}
+
+func TestReadUserEnrichmentNdjson(t *testing.T) {
+	source := `{"mail":"alice@contoso.com","department":"Finance","level":3}
+not json
+
+{"mail":"bob@contoso.com","department":"Engineering","level":null}
+`
+	if table, err := readUserEnrichment(strings.NewReader(source), enrichmentFormatNdjson, "mail", []string{"department", "level"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(table.rows) != 2 {
+		t.Errorf("got %v rows, want 2", len(table.rows))
+	} else if row := table.rows["alice@contoso.com"]; row["level"] != "3" {
+		t.Errorf("got %v, want %v", row["level"], "3")
+	} else if _, ok := table.rows["bob@contoso.com"]["level"]; ok {
+		t.Error("expected null values to be dropped")
+	}
+}
+
+func TestEnrichUsers(t *testing.T) {
+	table, err := readUserEnrichment(strings.NewReader(testEnrichmentCsv), enrichmentFormatCsv, "userPrincipalName", []string{"department", "userPrincipalName"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		in <- collector.AzureWrapper{Kind: enums.KindAZUser, Data: models.User{User: azure.User{DirectoryObject: azure.DirectoryObject{Id: "alice"}, UserPrincipalName: "alice@CONTOSO.com", Department: "native"}}}
+		in <- collector.AzureWrapper{Kind: enums.KindAZUser, Data: models.User{User: azure.User{DirectoryObject: azure.DirectoryObject{Id: "dave"}, UserPrincipalName: "dave@contoso.com"}}}
+		in <- collector.AzureWrapper{Kind: enums.KindAZGroup, Data: models.Group{}}
+	}()
+
+	before := atomic.LoadInt64(&enrichedUsers)
+	var results []interface{}
+	for item := range enrichUsers(context.Background(), in, table) {
+		results = append(results, item)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got %v items, want 3", len(results))
+	} else if alice := results[0].(collector.AzureWrapper).Data.(models.User); alice.Enrichment["department"] != "Finance" {
+		t.Errorf("got %v, want the enrichment of alice", alice.Enrichment)
+	} else if alice.Department != "native" || alice.UserPrincipalName != "alice@CONTOSO.com" {
+		t.Errorf("expected native fields to be kept but got %+v", alice.User)
+	} else if dave := results[1].(collector.AzureWrapper).Data.(models.User); dave.Enrichment != nil {
+		t.Errorf("got %v, want no enrichment", dave.Enrichment)
+	} else if actual := atomic.LoadInt64(&enrichedUsers) - before; actual != 1 {
+		t.Errorf("got %v enriched users, want 1", actual)
+	}
+}
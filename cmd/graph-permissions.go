@@ -0,0 +1,314 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+var ErrMissingPermissions = errors.New("the credential lacks permissions the enabled collectors need")
+
+// The object kinds not collected because the Graph token lacks the permission to list them, found by
+// checkCollectorPermissions before collection starts. They hold for the life of the process.
+var missingPermissionKinds sync.Map
+
+// missingPermissions returns the kinds in missingPermissionKinds for collector.Options.MissingPermissions
+func missingPermissions() []enums.Kind {
+	kinds := []enums.Kind{}
+	missingPermissionKinds.Range(func(key, _ interface{}) bool {
+		kinds = append(kinds, key.(enums.Kind))
+		return true
+	})
+	return kinds
+}
+
+// Graph permissions that include read access covered by other permissions in collector.RequiredPermissions
+var impliedPermissions = map[string][]string{
+	"Directory.Read.All": {"Application.Read.All", "Device.Read.All", "Group.Read.All", "GroupMember.Read.All", "RoleManagement.Read.Directory", "User.Read.All"},
+}
+
+// Directory roles whose members can read everything collection needs, whatever Graph permissions they were granted.
+// They appear in the wids claim of the token.
+var directoryReadRoles = map[string]string{
+	"62e90394-69f5-4237-9190-012177145e10": "Global Administrator",
+	"f2ef992c-3afb-46b9-b7cf-a126ee74c451": "Global Reader",
+}
+
+// graphPermissionKinds returns the object kinds collected from Graph with the current configuration, grouped by the
+// permission they need
+func graphPermissionKinds() map[string][]enums.Kind {
+	permissions := map[string][]enums.Kind{}
+	for kind, permission := range collector.RequiredPermissions {
+		if kind == enums.KindAZUserAuthenticationMethod && !config.AuthMethods.Value().(bool) {
+			continue
+		}
+		permissions[permission] = append(permissions[permission], kind)
+	}
+	for _, kinds := range permissions {
+		sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	}
+	return permissions
+}
+
+// grantedPermissions returns the Graph permissions granted by accessToken's roles and scp claims, including those
+// implied by broader ones, and the directory role that grants read access to everything, if any
+func grantedPermissions(accessToken string) (map[string]bool, string, error) {
+	var (
+		granted  = map[string]bool{}
+		declared []string
+	)
+
+	body, err := rest.ParseBody(accessToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to decode the graph token: %w", err)
+	}
+
+	// application permissions
+	if roles, ok := body["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if role, ok := role.(string); ok {
+				declared = append(declared, role)
+			}
+		}
+	}
+
+	// delegated permissions
+	if scp, ok := body["scp"].(string); ok {
+		declared = append(declared, strings.Fields(scp)...)
+	}
+
+	for _, permission := range declared {
+		granted[permission] = true
+		granted[strings.Replace(permission, ".ReadWrite.", ".Read.", 1)] = true
+	}
+	for permission, implied := range impliedPermissions {
+		if granted[permission] {
+			for _, permission := range implied {
+				granted[permission] = true
+			}
+		}
+	}
+
+	if wids, ok := body["wids"].([]interface{}); ok {
+		for _, wid := range wids {
+			if role, ok := directoryReadRoles[fmt.Sprint(wid)]; ok {
+				return granted, role, nil
+			}
+		}
+	}
+	return granted, "", nil
+}
+
+// resourceManagerPermission names the permission Azure resource collection needs in the permission report; it is
+// an Azure role rather than a claim of the token, so it is checked by listing subscriptions
+const resourceManagerPermission = "Reader (Azure role)"
+
+// permissionReport compares the permissions of a credential to those the enabled collectors need
+type permissionReport struct {
+	rows []permissionRow
+	// the Graph permissions the token lacks, sorted
+	missing []string
+	// the collectors that won't collect everything, in the order of rows
+	affected []affectedCollector
+	// the kinds that need each Graph permission
+	permissionKinds map[string][]enums.Kind
+}
+
+type permissionRow struct {
+	permission string
+	status     string
+	kinds      string
+}
+
+type affectedCollector struct {
+	collector string
+	// skipped collectors collect nothing; degraded ones are likely to collect only part of their objects
+	result string
+	reason string
+}
+
+// checkPermissions builds the permission report of the collectors enabled by scope. Graph permissions are read from
+// the roles and scp claims of the Graph token, the Reader role by listing subscriptions. Permissions that can't be
+// checked, e.g. because a token can't be decoded, are left out of the report with a note in the log.
+func checkPermissions(ctx context.Context, azClient client.AzureClient, scope collector.Scope) permissionReport {
+	report := permissionReport{permissionKinds: graphPermissionKinds()}
+
+	if scope.AzureAD {
+		if authorization, err := azClient.GraphAuthorization(); err != nil {
+			log.Info("note: unable to check the graph permissions of the credential before collecting", "error", err.Error())
+		} else if granted, role, err := grantedPermissions(strings.TrimPrefix(authorization, "Bearer ")); err != nil {
+			log.Info("note: unable to check the graph permissions of the credential before collecting", "error", err.Error())
+		} else {
+			report.addGraph(granted, role)
+		}
+	}
+
+	if scope.AzureRM {
+		if list, err := azClient.GetAzureSubscriptions(ctx); errors.Is(err, rest.ErrForbidden) {
+			report.addResourceManager("missing", "listing subscriptions was denied; assign the credential the Reader role on the subscriptions to collect, or on a management group above them")
+		} else if err != nil {
+			log.Info("note: unable to check the azure resource manager permissions of the credential before collecting", "error", err.Error())
+		} else if len(list.Value) == 0 {
+			report.addResourceManager("missing", "no subscriptions are visible; assign the credential the Reader role on the subscriptions to collect, or on a management group above them")
+		} else {
+			report.addResourceManager(fmt.Sprintf("have (%d subscriptions visible)", len(list.Value)), "")
+		}
+	}
+
+	return report
+}
+
+func (s *permissionReport) addGraph(granted map[string]bool, role string) {
+	var (
+		permissions = make([]string, 0, len(s.permissionKinds))
+		skipped     = map[enums.Kind]string{}
+		kinds       []enums.Kind
+	)
+	for permission := range s.permissionKinds {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+
+	for _, permission := range permissions {
+		names := make([]string, 0, len(s.permissionKinds[permission]))
+		for _, kind := range s.permissionKinds[permission] {
+			names = append(names, string(kind))
+			kinds = append(kinds, kind)
+		}
+
+		status := "have"
+		if role != "" && !granted[permission] {
+			status = "have (" + role + ")"
+		} else if !granted[permission] {
+			status = "missing"
+			s.missing = append(s.missing, permission)
+			for _, kind := range s.permissionKinds[permission] {
+				skipped[kind] = "missing " + permission
+			}
+		}
+		s.rows = append(s.rows, permissionRow{permission, status, strings.Join(names, ", ")})
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	for _, kind := range kinds {
+		if reason, ok := skipped[kind]; ok {
+			s.affected = append(s.affected, affectedCollector{string(kind), "skipped", reason})
+		} else if parent, ok := collector.ParentKinds[kind]; ok && skipped[parent] != "" {
+			s.affected = append(s.affected, affectedCollector{string(kind), "skipped", fmt.Sprintf("%s is skipped, so there is nothing to collect it for", parent)})
+		}
+	}
+}
+
+func (s *permissionReport) addResourceManager(status string, reason string) {
+	s.rows = append(s.rows, permissionRow{resourceManagerPermission, status, "azure resources"})
+	if reason != "" {
+		s.affected = append(s.affected, affectedCollector{"azure resources", "degraded", reason})
+	}
+}
+
+// write writes the report to w as a table of permissions followed by a table of the affected collectors, if any
+func (s permissionReport) write(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PERMISSION\tSTATUS\tKINDS")
+	for _, row := range s.rows {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", row.permission, row.status, row.kinds)
+	}
+	if len(s.affected) > 0 {
+		fmt.Fprintln(table, "\t\t")
+		fmt.Fprintln(table, "COLLECTOR\tRESULT\tREASON")
+		for _, collector := range s.affected {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", collector.collector, collector.result, collector.reason)
+		}
+	}
+	return table.Flush()
+}
+
+// err returns an error naming the missing permissions, if any
+func (s permissionReport) err() error {
+	var missing = s.missing
+	for _, row := range s.rows {
+		if row.permission == resourceManagerPermission && row.status == "missing" {
+			missing = append(missing, resourceManagerPermission)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrMissingPermissions, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// skip logs a warning for each missing Graph permission and skips the kinds that need it for the rest of the process
+func (s permissionReport) skip() {
+	for _, permission := range s.missing {
+		log.Info("warning: skipping collection; the graph token lacks the "+permission+" permission", "kinds", s.permissionKinds[permission])
+		for _, kind := range s.permissionKinds[permission] {
+			missingPermissionKinds.Store(kind, struct{}{})
+		}
+	}
+}
+
+// checkCollectorPermissions writes the permission report of the collectors enabled by scope to w before collection
+// starts. With strict, a missing Graph permission is returned as an error; otherwise the kinds that need it are
+// skipped. A missing Reader role is only reported, as it may be granted on some subscriptions and not others.
+func checkCollectorPermissions(ctx context.Context, w io.Writer, azClient client.AzureClient, scope collector.Scope, strict bool) error {
+	report := checkPermissions(ctx, azClient, scope)
+	if len(report.rows) == 0 {
+		return nil
+	} else if err := report.write(w); err != nil {
+		return err
+	} else if len(report.missing) > 0 && strict {
+		return fmt.Errorf("%w; grant the missing permissions and admin consent, or drop --%s to skip the collectors that need them", report.err(), config.StrictPermissions.Name)
+	} else if len(report.affected) == 0 {
+		log.Info("the credential has the permissions the enabled collectors need")
+	}
+	report.skip()
+	return nil
+}
+
+// warmUpPermissions runs checkCollectorPermissions for a list command about to collect scope, exiting when
+// --strict-permissions is set and a permission is missing. With --check-permissions, the report is written to stdout
+// and the command exits without collecting, with a non-zero code if any collector is affected.
+func warmUpPermissions(ctx context.Context, azClient client.AzureClient, scope collector.Scope) {
+	if !config.CheckPermissions.Value().(bool) {
+		if err := checkCollectorPermissions(ctx, os.Stderr, azClient, scope, config.StrictPermissions.Value().(bool)); err != nil {
+			exit(err)
+		}
+	} else if report := checkPermissions(ctx, azClient, scope); len(report.rows) == 0 {
+		exit(fmt.Errorf("unable to check the permissions of the credential"))
+	} else if err := report.write(os.Stdout); err != nil {
+		exit(err)
+	} else if len(report.affected) > 0 {
+		exit(report.err())
+	} else {
+		exitWithCode(0, nil)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func resetMissingPermissionKinds() {
+	missingPermissionKinds.Range(func(key, _ interface{}) bool {
+		missingPermissionKinds.Delete(key)
+		return true
+	})
+}
+
+func TestGrantedPermissions(t *testing.T) {
+	token := testToken(t, map[string]interface{}{
+		"roles": []string{"Directory.Read.All", "Policy.ReadWrite.All"},
+		"scp":   "UserAuthenticationMethod.Read.All",
+	})
+
+	if granted, role, err := grantedPermissions(token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if role != "" {
+		t.Errorf("got directory role %v, want none", role)
+	} else {
+		for _, permission := range []string{"Directory.Read.All", "User.Read.All", "GroupMember.Read.All", "Policy.Read.All", "UserAuthenticationMethod.Read.All"} {
+			if !granted[permission] {
+				t.Errorf("expected %s to be granted", permission)
+			}
+		}
+		if granted["DelegatedPermissionGrant.Read.All"] {
+			t.Error("expected DelegatedPermissionGrant.Read.All not to be granted")
+		}
+	}
+
+	if _, role, err := grantedPermissions(testToken(t, map[string]interface{}{"wids": []string{"f2ef992c-3afb-46b9-b7cf-a126ee74c451"}})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if role != "Global Reader" {
+		t.Errorf("got directory role %v, want %v", role, "Global Reader")
+	}
+
+	if _, _, err := grantedPermissions("opaque"); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestCheckGraphPermissions(t *testing.T) {
+	resetMissingPermissionKinds()
+	defer resetMissingPermissionKinds()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	token := testToken(t, map[string]interface{}{"roles": []string{"Application.Read.All", "Group.Read.All"}})
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockClient.EXPECT().GraphAuthorization().Return("Bearer "+token, nil).Times(2)
+
+	var out bytes.Buffer
+	if err := checkCollectorPermissions(context.Background(), &out, mockClient, collector.Scope{AzureAD: true}, true); !errors.Is(err, ErrMissingPermissions) {
+		t.Errorf("got %v, want %v", err, ErrMissingPermissions)
+	} else if missing := missingPermissions(); len(missing) != 0 {
+		t.Errorf("got skipped kinds %v, want none when strict", missing)
+	}
+
+	out.Reset()
+	if err := checkCollectorPermissions(context.Background(), &out, mockClient, collector.Scope{AzureAD: true}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`Application\.Read\.All +have +AZApp, AZAppOwner`, `User\.Read\.All +missing +AZUser`} {
+		if !regexp.MustCompile(want).MatchString(out.String()) {
+			t.Errorf("expected output to match %q, got:\n%s", want, out.String())
+		}
+	}
+	missing := collectionOptions().MissingPermissions
+	if !internal.Contains(missing, enums.KindAZUser) || !internal.Contains(missing, enums.KindAZGroupMember) {
+		t.Error("expected kinds lacking a permission to be skipped")
+	} else if internal.Contains(missing, enums.KindAZGroup) {
+		t.Error("expected kinds with their permission not to be skipped")
+	}
+}
+
+func TestCheckPermissionsReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	token := testToken(t, map[string]interface{}{"roles": []string{"Directory.Read.All", "Policy.Read.All", "DelegatedPermissionGrant.Read.All", "DelegatedAdminRelationship.Read.All", "RoleEligibilitySchedule.Read.Directory"}})
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockClient.EXPECT().GraphAuthorization().Return("Bearer "+token, nil).AnyTimes()
+	mockClient.EXPECT().GetAzureSubscriptions(gomock.Any()).Return(azure.SubscriptionList{}, nil)
+
+	report := checkPermissions(context.Background(), mockClient, collector.FullScope)
+	if strings.Join(report.missing, ",") != "PrivilegedEligibilitySchedule.Read.AzureADGroup" {
+		t.Errorf("got missing permissions %v", report.missing)
+	}
+
+	want := []affectedCollector{
+		{"AZGroupEligibilityScheduleInstance", "skipped", "missing PrivilegedEligibilitySchedule.Read.AzureADGroup"},
+		{"azure resources", "degraded", "no subscriptions are visible; assign the credential the Reader role on the subscriptions to collect, or on a management group above them"},
+	}
+	if !reflect.DeepEqual(report.affected, want) {
+		t.Errorf("got affected collectors %+v, want %+v", report.affected, want)
+	} else if err := report.err(); !errors.Is(err, ErrMissingPermissions) || exitCode(ExitCodeFailure, err) != ExitCodeMissingPermissions || !strings.Contains(err.Error(), resourceManagerPermission) {
+		t.Errorf("got %v, want an error naming %s", err, resourceManagerPermission)
+	}
+
+	var out bytes.Buffer
+	if err := report.write(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !regexp.MustCompile(`COLLECTOR +RESULT +REASON\n`).MatchString(out.String()) {
+		t.Errorf("expected a table of affected collectors, got:\n%s", out.String())
+	}
+
+	// collectors whose parent is skipped have nothing to collect
+	mockClient.EXPECT().GetAzureSubscriptions(gomock.Any()).Return(azure.SubscriptionList{}, fmt.Errorf("%w: AuthorizationFailed", rest.ErrForbidden))
+	report = permissionReport{permissionKinds: graphPermissionKinds()}
+	report.addGraph(map[string]bool{"GroupMember.Read.All": true}, "")
+	for _, collector := range report.affected {
+		if collector.collector == string(enums.KindAZGroupMember) && collector.reason != "AZGroup is skipped, so there is nothing to collect it for" {
+			t.Errorf("got reason %q for %s", collector.reason, collector.collector)
+		}
+	}
+	if report = checkPermissions(context.Background(), mockClient, collector.Scope{AzureRM: true}); len(report.affected) != 1 || !strings.HasPrefix(report.affected[0].reason, "listing subscriptions was denied") {
+		t.Errorf("got affected collectors %+v", report.affected)
+	}
+}
+
+func TestCheckGraphPermissionsOpaqueToken(t *testing.T) {
+	resetMissingPermissionKinds()
+	defer resetMissingPermissionKinds()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockClient.EXPECT().GraphAuthorization().Return("Bearer opaque", nil)
+
+	var out bytes.Buffer
+	if err := checkCollectorPermissions(context.Background(), &out, mockClient, collector.Scope{AzureAD: true}, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if out.Len() != 0 {
+		t.Errorf("expected no table for a token that can't be inspected, got:\n%s", out.String())
+	}
+}
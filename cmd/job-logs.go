@@ -0,0 +1,86 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/logger"
+)
+
+const (
+	// the most recent warnings and errors of a job kept for --upload-logs
+	jobLogRecords = 100
+	// records are cut to this many bytes, and the summary to jobLogMaxBytes, to keep the job message readable
+	jobLogMaxRecordBytes = 512
+	jobLogMaxBytes       = 8 * 1024
+)
+
+// the warnings and errors of the running job with --upload-logs
+var jobLogs *logger.Buffer
+
+// captureJobLogs keeps the warnings and errors logged by every collector from now on for jobLogSummary
+func captureJobLogs() {
+	jobLogs = logger.NewBuffer(jobLogRecords)
+	log = logger.WithBuffer(log, jobLogs)
+}
+
+// jobLogSummary returns the warnings and errors kept in buffer to append to a job message, most recent last and with
+// secrets redacted, or an empty string if there are none
+func jobLogSummary(buffer *logger.Buffer) string {
+	records := buffer.Records()
+	if len(records) == 0 {
+		return ""
+	}
+
+	var (
+		errorCount, warningCount = buffer.Counts()
+		lines                    = make([]string, 0, len(records))
+		size                     = 0
+	)
+
+	// the most recent records are the likeliest to explain how the job ended, so older ones are cut first
+	for i := len(records) - 1; i >= 0; i-- {
+		line := redactSecrets(records[i].String())
+		if len(line) > jobLogMaxRecordBytes {
+			line = strings.ToValidUTF8(line[:jobLogMaxRecordBytes], "") + "..."
+		}
+		if size+len(line) > jobLogMaxBytes {
+			break
+		}
+		size += len(line) + len(" | ")
+		lines = append(lines, line)
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	plural := func(count int, noun string) string {
+		if count == 1 {
+			return fmt.Sprintf("%d %s", count, noun)
+		} else {
+			return fmt.Sprintf("%d %ss", count, noun)
+		}
+	}
+	summary := "; logged " + plural(errorCount, "error") + " and " + plural(warningCount, "warning")
+	if len(lines) < errorCount+warningCount {
+		summary += fmt.Sprintf(", the last %d", len(lines))
+	}
+	return summary + ": " + strings.Join(lines, " | ")
+}
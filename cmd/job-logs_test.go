@@ -0,0 +1,90 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/logger"
+	"github.com/go-logr/logr"
+)
+
+func init() {
+	setupLogger()
+}
+
+func TestJobLogSummary(t *testing.T) {
+	secret := config.AzSecret.Value()
+	config.AzSecret.Set("hunter2")
+	t.Cleanup(func() { config.AzSecret.Set(secret) })
+
+	var (
+		buffer = logger.NewBuffer(jobLogRecords)
+		log    = logger.WithBuffer(logr.Discard(), buffer)
+	)
+	if summary := jobLogSummary(buffer); summary != "" {
+		t.Errorf("got %q, want no summary without records", summary)
+	}
+
+	log.Info("warning: skipping collection due to insufficient permissions", "kind", "AZDevice")
+	log.Error(errors.New("invalid client secret hunter2"), "unable to authenticate")
+	want := "; logged 1 error and 1 warning: warning: skipping collection due to insufficient permissions (kind=AZDevice) | error: unable to authenticate: invalid client secret xxxxx"
+	if summary := jobLogSummary(buffer); summary != want {
+		t.Errorf("got %q, want %q", summary, want)
+	}
+
+	// the most recent records are kept within the size limit
+	for i := 0; i < jobLogRecords*2; i++ {
+		log.Error(errors.New(strings.Repeat("a", jobLogMaxRecordBytes)), "unable to list users")
+	}
+	log.Error(errors.New("the last error"), "unable to end the job")
+	if summary := jobLogSummary(buffer); len(summary) > jobLogMaxBytes+100 {
+		t.Errorf("got a summary of %v bytes, want at most %v", len(summary), jobLogMaxBytes)
+	} else if !strings.HasPrefix(summary, "; logged 202 errors and 1 warning, the last ") {
+		t.Errorf("got %q, want the counts of every record", summary[:100])
+	} else if !strings.HasSuffix(summary, "error: unable to end the job: the last error") {
+		t.Errorf("got %q, want the most recent record last", summary[len(summary)-100:])
+	}
+}
+
+func TestCaptureJobLogs(t *testing.T) {
+	previous := log
+	t.Cleanup(func() {
+		log = previous
+		jobLogs = nil
+	})
+	log = logr.Discard()
+	collector.ResetKinds()
+	defer collector.ResetKinds()
+
+	captureJobLogs()
+	// the collectors log to the job logs too
+	failUsers(t, errors.New("boom"))
+	if records := jobLogs.Records(); len(records) != 1 || records[0].Err != "boom" {
+		t.Errorf("got %v, want the collection error", records)
+	}
+
+	jobLogs.Reset()
+	if summary := jobLogSummary(jobLogs); summary != "" {
+		t.Errorf("got %q, want no summary after a reset", summary)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+// includedKinds returns the kinds given with --include-kinds, or nil if every kind is collected
+func includedKinds() ([]enums.Kind, error) {
+	names := config.IncludeKinds.Value().([]string)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[enums.Kind]bool)
+	for _, kind := range enums.Kinds() {
+		known[kind] = true
+	}
+
+	kinds := make([]enums.Kind, 0, len(names))
+	for _, name := range names {
+		if kind := enums.Kind(name); !known[kind] {
+			return nil, fmt.Errorf("unknown object kind in --include-kinds: %s", name)
+		} else {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
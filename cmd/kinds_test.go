@@ -0,0 +1,46 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+func TestIncludedKinds(t *testing.T) {
+	defer config.IncludeKinds.Set([]string{})
+
+	config.IncludeKinds.Set([]string{})
+	if kinds, err := includedKinds(); err != nil || kinds != nil {
+		t.Errorf("got %v, %v; want every kind collected without --include-kinds", kinds, err)
+	}
+
+	config.IncludeKinds.Set([]string{"AZGroupMember", "AZUser"})
+	if kinds, err := includedKinds(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(kinds) != 2 || kinds[0] != enums.KindAZGroupMember || kinds[1] != enums.KindAZUser {
+		t.Errorf("got %v, want the given kinds", kinds)
+	}
+
+	config.IncludeKinds.Set([]string{"AZGroupMembers"})
+	if _, err := includedKinds(); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/logger"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// The number of directory roles with the most holders recorded per run
+	ledgerTopRoles = 10
+
+	ledgerFormatTable = "table"
+)
+
+func init() {
+	config.Init(ledgerCmd, []config.Config{config.Ledger})
+	config.Init(ledgerShowCmd, []config.Config{config.LedgerLast, config.OutputFormat})
+	ledgerCmd.AddCommand(ledgerShowCmd)
+	rootCmd.AddCommand(ledgerCmd)
+}
+
+var ledgerCmd = &cobra.Command{
+	Use:               "ledger",
+	Short:             "Inspect the ledger of collection runs",
+	PersistentPreRunE: persistentPreRunE,
+	SilenceUsage:      true,
+}
+
+var ledgerShowCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "Show the most recent collection runs recorded in the --ledger file",
+	Long:         fmt.Sprintf("Shows the most recent collection runs recorded in the --ledger file, either as a %s or as %s", ledgerFormatTable, enums.OutputFormatJson),
+	Run:          ledgerShowCmdImpl,
+	SilenceUsage: true,
+}
+
+func ledgerShowCmdImpl(cmd *cobra.Command, args []string) {
+	if path := config.Ledger.Value().(string); path == "" {
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("--ledger is required"))
+	} else if file, err := os.Open(path); err != nil {
+		exit(fmt.Errorf("unable to open ledger: %w", err))
+	} else {
+		defer file.Close()
+		if records, invalid, err := sinks.ReadLedger(file); err != nil {
+			exit(fmt.Errorf("unable to read ledger: %w", err))
+		} else {
+			if invalid > 0 {
+				log.Info("warning: skipped unreadable ledger lines", "count", invalid)
+			}
+			if err := showLedger(os.Stdout, records, config.LedgerLast.Value().(int), config.OutputFormat.Value().(string)); err != nil {
+				exit(err)
+			}
+		}
+	}
+}
+
+// showLedger renders the last records of a ledger, oldest first. Records of any schema version are shown; fields a
+// record's version predates are left blank.
+func showLedger(w io.Writer, records []sinks.LedgerRecord, last int, format string) error {
+	if last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+
+	for _, record := range records {
+		if record.Version > sinks.LedgerVersion {
+			log.Info("note: the ledger contains records written by a newer version of azurehound; fields it added are not shown", "version", record.Version)
+			break
+		}
+	}
+
+	switch format {
+	case enums.OutputFormatJson:
+		if records == nil {
+			records = []sinks.LedgerRecord{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case ledgerFormatTable:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "FINISHED\tCOMMAND\tTENANT\tDURATION\tOBJECTS\tUSERS\tGROUPS\tSERVICE PRINCIPALS\tERRORS\tCOMPLETE\tTOP ROLE\tVERSION")
+		for _, record := range records {
+			topRole := ""
+			if len(record.TopRoleHolders) > 0 {
+				topRole = fmt.Sprintf("%s (%d)", record.TopRoleHolders[0].RoleName, record.TopRoleHolders[0].Holders)
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%t\t%s\t%d\n",
+				record.Finished.Local().Format(time.RFC3339),
+				record.Command,
+				record.TenantId,
+				record.Finished.Sub(record.Started).Round(time.Second),
+				record.Total(),
+				record.Counts[string(enums.KindAZUser)],
+				record.Counts[string(enums.KindAZGroup)],
+				record.Counts[string(enums.KindAZServicePrincipal)],
+				record.Errors.Logged,
+				record.Coverage.Complete,
+				topRole,
+				record.Version,
+			)
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unsupported ledger format: %s; use %s or %s", format, ledgerFormatTable, enums.OutputFormatJson)
+	}
+}
+
+// runSummary tallies the objects of a collection run for its ledger record
+type runSummary struct {
+	mutex       sync.Mutex
+	counts      map[enums.Kind]int
+	roleNames   map[string]string
+	roleHolders map[string]map[string]struct{}
+
+	// the process wide error counters when the run started; the start command runs many collections
+	loggedErrors   int64
+	resyncRestarts int64
+}
+
+// newRunSummary returns a summary when --ledger is set and nil otherwise
+func newRunSummary() *runSummary {
+	if config.Ledger.Value().(string) == "" {
+		return nil
+	} else {
+		return &runSummary{
+			counts:         map[enums.Kind]int{},
+			roleNames:      map[string]string{},
+			roleHolders:    map[string]map[string]struct{}{},
+			loggedErrors:   logger.ErrorCount(),
+			resyncRestarts: client.ResyncRestarts(),
+		}
+	}
+}
+
+type summaryKey struct{}
+
+// withRunSummary returns a context under which collectTenant tallies the items it emits into summary
+func withRunSummary(ctx context.Context, summary *runSummary) context.Context {
+	return context.WithValue(ctx, summaryKey{}, summary)
+}
+
+// runSummaryOf returns the summary of the run with ctx, or nil if it isn't summarized
+func runSummaryOf(ctx context.Context) *runSummary {
+	summary, _ := ctx.Value(summaryKey{}).(*runSummary)
+	return summary
+}
+
+// summarize tallies the items of stream as they pass through; a nil summary passes stream through untouched
+func (s *runSummary) summarize(ctx context.Context, stream <-chan interface{}) <-chan interface{} {
+	if s == nil {
+		return stream
+	} else {
+		return pipeline.Map(ctx.Done(), stream, func(item interface{}) interface{} {
+			s.observe(item)
+			return item
+		})
+	}
+}
+
+func (s *runSummary) observe(item interface{}) {
+	wrapper, ok := item.(collector.Wrapper)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	untyped := wrapper.Untyped()
+	s.counts[untyped.Kind]++
+	if _, ok := item.(collector.AzureWrapper); ok {
+		switch data := untyped.Data.(type) {
+		case models.Role:
+			s.roleNames[data.Id] = data.DisplayName
+		case models.RoleAssignments:
+			holders, ok := s.roleHolders[data.RoleDefinitionId]
+			if !ok {
+				holders = map[string]struct{}{}
+				s.roleHolders[data.RoleDefinitionId] = holders
+			}
+			for _, assignment := range data.RoleAssignments {
+				holders[assignment.PrincipalId] = struct{}{}
+			}
+		}
+	}
+}
+
+func (s *runSummary) record(command, tenantId string, started, finished time.Time, deadlineExceeded bool) sinks.LedgerRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var (
+		skipped = collector.SkippedKinds()
+		record  = sinks.LedgerRecord{
+			Version:  sinks.LedgerVersion,
+			RunId:    uuid.Must(uuid.NewV4()).String(),
+			Command:  command,
+			TenantId: tenantId,
+			Started:  started.UTC(),
+			Finished: finished.UTC(),
+			Counts:   map[string]int{},
+			Coverage: sinks.LedgerCoverage{
+				Complete:              !deadlineExceeded && len(skipped) == 0 && sampleSize == 0,
+				DeadlineExceeded:      deadlineExceeded,
+				SkippedForPermissions: skipped,
+				Sample:                sampleSize,
+			},
+			Errors: sinks.LedgerErrors{
+				Logged:         logger.ErrorCount() - s.loggedErrors,
+				ResyncRestarts: client.ResyncRestarts() - s.resyncRestarts,
+			},
+			TopRoleHolders: []sinks.LedgerRoleHolder{},
+		}
+	)
+
+	for kind, count := range s.counts {
+		record.Counts[string(kind)] = count
+	}
+
+	for roleId, holders := range s.roleHolders {
+		if len(holders) > 0 {
+			record.TopRoleHolders = append(record.TopRoleHolders, sinks.LedgerRoleHolder{RoleId: roleId, RoleName: s.roleNames[roleId], Holders: len(holders)})
+		}
+	}
+	sort.Slice(record.TopRoleHolders, func(i, j int) bool {
+		if a, b := record.TopRoleHolders[i], record.TopRoleHolders[j]; a.Holders != b.Holders {
+			return a.Holders > b.Holders
+		} else {
+			return strings.ToLower(a.RoleName) < strings.ToLower(b.RoleName)
+		}
+	})
+	if len(record.TopRoleHolders) > ledgerTopRoles {
+		record.TopRoleHolders = record.TopRoleHolders[:ledgerTopRoles]
+	}
+
+	return record
+}
+
+// appendToLedger appends the record of a completed run to the --ledger file; a nil summary records nothing. A run
+// that can't be recorded is not treated as failed.
+func (s *runSummary) appendToLedger(command, tenantId string, started time.Time, deadlineExceeded bool) {
+	if s == nil {
+		return
+	}
+
+	path := config.Ledger.Value().(string)
+	if err := sinks.AppendLedger(path, s.record(command, tenantId, started, time.Now(), deadlineExceeded)); err != nil {
+		log.Error(err, "unable to append run to ledger", "ledger", path)
+	} else {
+		log.V(1).Info("appended run to ledger", "ledger", path)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+)
+
+func init() {
+	setupLogger()
+}
+
+func newTestRole(id, name string) collector.AzureWrapper {
+	role := models.Role{}
+	role.Id = id
+	role.DisplayName = name
+	return collector.AzureWrapper{Kind: enums.KindAZRole, Data: role}
+}
+
+func newTestRoleAssignments(roleId string, principals ...string) collector.AzureWrapper {
+	assignments := models.RoleAssignments{RoleDefinitionId: roleId}
+	for _, principal := range principals {
+		assignments.RoleAssignments = append(assignments.RoleAssignments, azure.UnifiedRoleAssignment{RoleDefinitionId: roleId, PrincipalId: principal})
+	}
+	return collector.AzureWrapper{Kind: enums.KindAZRoleAssignment, Data: assignments}
+}
+
+func TestRunSummary(t *testing.T) {
+	config.Ledger.Set("ledger.ndjson")
+	t.Cleanup(func() { config.Ledger.Set("") })
+
+	var (
+		summary = newRunSummary()
+		in      = make(chan interface{}, 8)
+	)
+	in <- collector.NewAzureWrapper(enums.KindAZUser, models.User{})
+	in <- collector.NewAzureWrapper(enums.KindAZUser, models.User{})
+	in <- newTestRole("ga", "Global Administrator")
+	in <- newTestRole("reader", "Global Reader")
+	in <- newTestRoleAssignments("ga", "1", "2", "2")
+	in <- newTestRoleAssignments("reader", "1", "2", "3")
+	in <- newTestRoleAssignments("empty")
+	close(in)
+
+	count := 0
+	for range summary.summarize(context.Background(), in) {
+		count++
+	}
+
+	record := summary.record("list", "tenant", time.Now().Add(-time.Minute), time.Now(), false)
+	if count != 7 {
+		t.Errorf("got %v items, want 7", count)
+	} else if record.Counts[string(enums.KindAZUser)] != 2 || record.Counts[string(enums.KindAZRoleAssignment)] != 3 || record.Total() != 7 {
+		t.Errorf("unexpected counts: %v", record.Counts)
+	} else if len(record.TopRoleHolders) != 2 {
+		t.Fatalf("got %v top role holders, want 2", len(record.TopRoleHolders))
+	} else if top := record.TopRoleHolders[0]; top.RoleName != "Global Reader" || top.Holders != 3 {
+		t.Errorf("got %+v, want Global Reader with 3 holders", top)
+	} else if next := record.TopRoleHolders[1]; next.RoleName != "Global Administrator" || next.Holders != 2 {
+		t.Errorf("got %+v, want Global Administrator with 2 holders", next)
+	} else if record.Version != sinks.LedgerVersion || record.RunId == "" || !record.Coverage.Complete {
+		t.Errorf("unexpected record: %+v", record)
+	}
+}
+
+func TestRunSummaryRedactPii(t *testing.T) {
+	config.Ledger.Set("ledger.ndjson")
+	config.RedactPii.Set(true)
+	config.RedactSalt.Set("salt")
+	t.Cleanup(func() {
+		config.Ledger.Set("")
+		config.RedactPii.Set(false)
+		config.RedactSalt.Set("")
+	})
+
+	var (
+		summary = newRunSummary()
+		ctx     = withRunSummary(context.Background(), summary)
+		in      = make(chan interface{}, 2)
+	)
+	in <- newTestRole("ga", "Global Administrator")
+	in <- newTestRoleAssignments("ga", "1", "2")
+	close(in)
+
+	for item := range summarizeAndRedact(ctx, in) {
+		if wrapper, ok := item.(collector.AzureWrapper); ok && wrapper.Kind == enums.KindAZRoleAssignment {
+			if _, ok := wrapper.Data.(json.RawMessage); !ok {
+				t.Errorf("expected the role assignments to be redacted, got %T", wrapper.Data)
+			}
+		}
+	}
+
+	record := summary.record("list", "tenant", time.Now().Add(-time.Minute), time.Now(), false)
+	if len(record.TopRoleHolders) != 1 {
+		t.Fatalf("got %v top role holders, want 1", len(record.TopRoleHolders))
+	} else if top := record.TopRoleHolders[0]; top.RoleName != "Global Administrator" || top.Holders != 2 {
+		t.Errorf("got %+v, want Global Administrator with 2 holders", top)
+	}
+}
+
+func TestRunSummaryDisabled(t *testing.T) {
+	var summary *runSummary
+	if summary = newRunSummary(); summary != nil {
+		t.Fatal("expected no summary without --ledger")
+	}
+
+	in := make(chan interface{})
+	if out := summary.summarize(context.Background(), in); out != in {
+		t.Error("expected the stream to be passed through untouched")
+	}
+	summary.appendToLedger("list", "tenant", time.Now(), false)
+}
+
+func TestShowLedger(t *testing.T) {
+	var (
+		start   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		records = []sinks.LedgerRecord{}
+	)
+	for i := 0; i < 5; i++ {
+		records = append(records, sinks.LedgerRecord{
+			Version:        1,
+			RunId:          string(rune('a' + i)),
+			Started:        start.AddDate(0, 0, i),
+			Finished:       start.AddDate(0, 0, i).Add(time.Hour),
+			Counts:         map[string]int{string(enums.KindAZUser): 10 + i},
+			TopRoleHolders: []sinks.LedgerRoleHolder{{RoleName: "Global Administrator", Holders: i}},
+		})
+	}
+	// a record written by a newer version without the fields this version expects
+	records = append(records, sinks.LedgerRecord{Version: 2, RunId: "f"})
+
+	var table bytes.Buffer
+	if err := showLedger(&table, records, 3, ledgerFormatTable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if lines := strings.Split(strings.TrimSpace(table.String()), "\n"); len(lines) != 4 {
+		t.Errorf("got %v lines, want a header and 3 runs:\n%s", len(lines), table.String())
+	} else if !strings.Contains(lines[1], "Global Administrator (3)") || !strings.Contains(lines[1], "13") {
+		t.Errorf("unexpected row: %s", lines[1])
+	}
+
+	var (
+		output bytes.Buffer
+		shown  []sinks.LedgerRecord
+	)
+	if err := showLedger(&output, records, 0, enums.OutputFormatJson); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.Unmarshal(output.Bytes(), &shown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(shown) != len(records) {
+		t.Errorf("got %v records, want %v", len(shown), len(records))
+	}
+
+	if err := showLedger(&output, records, 0, enums.OutputFormatInventory); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
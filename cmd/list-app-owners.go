@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,66 +38,15 @@ var listAppOwnersCmd = &cobra.Command{
 }
 
 func listAppOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure app owners...")
 	start := time.Now()
-	stream := listAppOwners(ctx, azClient, listApps(ctx, azClient))
+	stream := collector.ListAppOwners(ctx, azClient, collector.ListApps(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listAppOwners(ctx context.Context, client client.AzureClient, apps <-chan azureWrapper[models.App]) <-chan azureWrapper[models.AppOwners] {
-	var (
-		out     = make(chan azureWrapper[models.AppOwners])
-		streams = pipeline.Demux(ctx.Done(), apps, 25)
-		wg      sync.WaitGroup
-	)
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for app := range stream {
-				var (
-					data = models.AppOwners{
-						AppId: app.Data.AppId,
-					}
-					count = 0
-				)
-				for item := range client.ListAzureADAppOwners(ctx, app.Data.Id, "", "", "", nil) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing owners for this app", "appId", app.Data.AppId)
-					} else {
-						appOwner := models.AppOwner{
-							Owner: item.Ok,
-							AppId: item.AppId,
-						}
-						log.V(2).Info("found app owner", "appOwner", appOwner)
-						count++
-						data.Owners = append(data.Owners, appOwner)
-					}
-				}
-
-				out <- NewAzureWrapper(
-					enums.KindAZAppOwner,
-					data,
-				)
-				log.V(1).Info("finished listing app owners", "appId", app.Data.AppId, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all app owners")
-	}()
-
-	return out
-}
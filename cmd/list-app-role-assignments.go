@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,78 +38,16 @@ var listAppRoleAssignmentsCmd = &cobra.Command{
 }
 
 func listAppRoleAssignmentsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory app role assignments...")
 	start := time.Now()
-	servicePrincipals := listServicePrincipals(ctx, azClient)
-	stream := listAppRoleAssignments(ctx, azClient, servicePrincipals)
+	servicePrincipals := collector.ListServicePrincipals(ctx, azClient)
+	stream := collector.ListAppRoleAssignments(ctx, azClient, servicePrincipals)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listAppRoleAssignments(ctx context.Context, client client.AzureClient, servicePrincipals <-chan interface{}) <-chan interface{} {
-	var (
-		out         = make(chan interface{})
-		filteredSPs = make(chan models.ServicePrincipal)
-		streams     = pipeline.Demux(ctx.Done(), filteredSPs, 25)
-		wg          sync.WaitGroup
-	)
-
-	go func() {
-		defer close(filteredSPs)
-
-		for result := range pipeline.OrDone(ctx.Done(), servicePrincipals) {
-			if servicePrincipal, ok := result.(AzureWrapper).Data.(models.ServicePrincipal); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating app role assignments", "result", result)
-				return
-			} else {
-				if len(servicePrincipal.AppRoles) != 0 {
-					filteredSPs <- servicePrincipal
-				}
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for servicePrincipal := range stream {
-				var (
-					count = 0
-				)
-				for item := range client.ListAzureADAppRoleAssignments(ctx, servicePrincipal.Id, "", "", "", "", nil) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing app role assignments for this service principal", "servicePrincipalId", servicePrincipal)
-					} else {
-						log.V(2).Info("found app role assignment", "roleAssignments", item)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZAppRoleAssignment,
-							Data: models.AppRoleAssignment{
-								AppRoleAssignment: item.Ok,
-								AppId:             servicePrincipal.AppId,
-								TenantId:          client.TenantInfo().TenantId,
-							},
-						}
-					}
-				}
-				log.V(1).Info("finished listing app role assignments", "appId", servicePrincipal.AppId, "servicePrincipalId", servicePrincipal.Id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all app role assignments")
-	}()
-
-	return out
-}
@@ -18,14 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -41,44 +38,15 @@ var listAppsCmd = &cobra.Command{
 }
 
 func listAppsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory applications...")
 	start := time.Now()
-	stream := listApps(ctx, azClient)
+	stream := collector.ListApps(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listApps(ctx context.Context, client client.AzureClient) <-chan azureWrapper[models.App] {
-	out := make(chan azureWrapper[models.App])
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureADApps(ctx, "", "", "", "", nil) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing applications")
-				return
-			} else {
-				log.V(2).Info("found application", "app", item)
-				count++
-				out <- NewAzureWrapper(
-					enums.KindAZApp,
-					models.App{
-						Application: item.Ok,
-						TenantId:    client.TenantInfo().TenantId,
-						TenantName:  client.TenantInfo().DisplayName,
-					},
-				)
-			}
-		}
-		log.Info("finished listing all apps", "count", count)
-	}()
-
-	return out
-}
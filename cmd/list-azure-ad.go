@@ -18,14 +18,14 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/logger"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -36,14 +36,14 @@ func init() {
 var listAzureADCmd = &cobra.Command{
 	Use:               "az-ad",
 	Long:              "Lists All Azure AD Entities",
-	PersistentPreRunE: persistentPreRunE,
+	PersistentPreRunE: listPersistentPreRunE,
 	Run:               listAzureADCmdImpl,
 	SilenceUsage:      true,
 }
 
 func listAzureADCmdImpl(cmd *cobra.Command, args []string) {
 	if len(args) > 0 {
-		exit(fmt.Errorf("unsupported subcommand: %v", args))
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("unsupported subcommand: %v", args))
 	}
 
 	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
@@ -51,88 +51,22 @@ func listAzureADCmdImpl(cmd *cobra.Command, args []string) {
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
+	warmUpPermissions(ctx, azClient, collector.Scope{AzureAD: true})
+	loggedErrors := logger.ErrorCount()
 	log.Info("collecting azure ad objects...")
 	start := time.Now()
-	stream := listAllAD(ctx, azClient)
+	ctx, span := tracing.Start(ctx, "collection", "tenantId", azClient.TenantInfo().TenantId)
+	collectionCtx, cancel := collectionContext(ctx)
+	defer cancel()
+	options := collectionOptions()
+	options.Scope = &collector.Scope{AzureAD: true}
+	stream, _ := collector.Collect(collectionCtx, azClient, options)
 	outputStream(ctx, stream)
+	hasDeadlineExceeded := deadlineExceeded(collectionCtx)
+	span.SetAttributes("deadlineExceeded", hasDeadlineExceeded)
+	span.End()
 	duration := time.Since(start)
+	collector.LogForbiddenSummary(log)
 	log.Info("collection completed", "duration", duration.String())
-}
-
-func listAllAD(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	var (
-		devices  = make(chan interface{})
-		devices2 = make(chan interface{})
-
-		groups  = make(chan interface{})
-		groups2 = make(chan interface{})
-		groups3 = make(chan interface{})
-		groups4 = make(chan interface{})
-
-		roles  = make(chan interface{})
-		roles2 = make(chan interface{})
-		roles3 = make(chan interface{})
-
-		servicePrincipals  = make(chan interface{})
-		servicePrincipals2 = make(chan interface{})
-		servicePrincipals3 = make(chan interface{})
-
-		tenants = make(chan interface{})
-	)
-
-	// Enumerate Apps, AppOwners and AppMembers
-	appChans := pipeline.TeeFixed(ctx.Done(), listApps(ctx, client), 2)
-	apps := pipeline.ToAny(ctx.Done(), appChans[0])
-	appOwners := pipeline.ToAny(ctx.Done(), listAppOwners(ctx, client, appChans[1]))
-
-	// Enumerate Devices and DeviceOwners
-	pipeline.Tee(ctx.Done(), listDevices(ctx, client), devices, devices2)
-	deviceOwners := listDeviceOwners(ctx, client, devices2)
-
-	// Enumerate Groups, GroupOwners and GroupMembers
-	pipeline.Tee(ctx.Done(), listGroups(ctx, client), groups, groups2, groups3, groups4)
-	groupOwners := listGroupOwners(ctx, client, groups2)
-	groupMembers := listGroupMembers(ctx, client, groups3)
-
-	// Enumerate Groups Eligibility Schedule Instances
-	groupEligibilityScheduleInstances := listGroupEligibilityScheduleInstances(ctx, client, groups4)
-
-	// Enumerate ServicePrincipals and ServicePrincipalOwners
-	pipeline.Tee(ctx.Done(), listServicePrincipals(ctx, client), servicePrincipals, servicePrincipals2, servicePrincipals3)
-	servicePrincipalOwners := listServicePrincipalOwners(ctx, client, servicePrincipals2)
-
-	// Enumerate Tenants
-	pipeline.Tee(ctx.Done(), listTenants(ctx, client), tenants)
-
-	// Enumerate Users
-	users := listUsers(ctx, client)
-
-	// Enumerate Roles and RoleAssignments
-	pipeline.Tee(ctx.Done(), listRoles(ctx, client), roles, roles2, roles3)
-	roleAssignments := listRoleAssignments(ctx, client, roles2)
-
-	// Enumerate Roles Eligibility Schedule Instances
-	roleEligibilityScheduleInstances := listRoleEligibilityScheduleInstances(ctx, client, roles3)
-
-	// Enumerate AppRoleAssignments
-	appRoleAssignments := listAppRoleAssignments(ctx, client, servicePrincipals3)
-
-	return pipeline.Mux(ctx.Done(),
-		appOwners,
-		appRoleAssignments,
-		apps,
-		deviceOwners,
-		devices,
-		groupEligibilityScheduleInstances,
-		groupMembers,
-		groupOwners,
-		groups,
-		roleEligibilityScheduleInstances,
-		roleAssignments,
-		roles,
-		servicePrincipalOwners,
-		servicePrincipals,
-		tenants,
-		users,
-	)
+	exitIfIncomplete(ctx, loggedErrors, hasDeadlineExceeded)
 }
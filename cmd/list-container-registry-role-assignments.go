@@ -18,18 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"path"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -45,7 +38,7 @@ var listContainerRegistryRoleAssignment = &cobra.Command{
 }
 
 func listContainerRegistryRoleAssignmentImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
@@ -56,77 +49,10 @@ func listContainerRegistryRoleAssignmentImpl(cmd *cobra.Command, args []string)
 	} else {
 		log.Info("collecting azure container registry role assignments...")
 		start := time.Now()
-		subscriptions := listSubscriptions(ctx, azClient)
-		stream := listContainerRegistryRoleAssignments(ctx, azClient, listContainerRegistries(ctx, azClient, subscriptions))
+		subscriptions := collector.ListSubscriptions(ctx, azClient)
+		stream := collector.ListContainerRegistryRoleAssignments(ctx, azClient, collector.ListContainerRegistries(ctx, azClient, subscriptions))
 		outputStream(ctx, stream)
 		duration := time.Since(start)
 		log.Info("collection completed", "duration", duration.String())
 	}
 }
-
-func listContainerRegistryRoleAssignments(ctx context.Context, client client.AzureClient, containerRegistries <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), containerRegistries) {
-			if containerRegistry, ok := result.(AzureWrapper).Data.(models.ContainerRegistry); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating container registry role assignments", "result", result)
-				return
-			} else {
-				ids <- containerRegistry.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					containerRegistryRoleAssignments = models.AzureRoleAssignments{
-						ObjectId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing role assignments for this container registry", "containerRegistryId", id)
-					} else {
-						roleDefinitionId := path.Base(item.Ok.Properties.RoleDefinitionId)
-
-						containerRegistryRoleAssignment := models.AzureRoleAssignment{
-							Assignee:         item.Ok,
-							ObjectId:         item.ParentId,
-							RoleDefinitionId: roleDefinitionId,
-						}
-						log.V(2).Info("found container registry role assignment", "containerRegistryRoleAssignment", containerRegistryRoleAssignment)
-						count++
-						containerRegistryRoleAssignments.RoleAssignments = append(containerRegistryRoleAssignments.RoleAssignments, containerRegistryRoleAssignment)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZContainerRegistryRoleAssignment,
-					Data: containerRegistryRoleAssignments,
-				}
-				log.V(1).Info("finished listing container registry role assignments", "containerRegistryId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all container registry role assignments")
-	}()
-
-	return out
-}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,78 +38,15 @@ var listDeviceOwnersCmd = &cobra.Command{
 }
 
 func listDeviceOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure device owners...")
 	start := time.Now()
-	stream := listDeviceOwners(ctx, azClient, listDevices(ctx, azClient))
+	stream := collector.ListDeviceOwners(ctx, azClient, collector.ListDevices(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listDeviceOwners(ctx context.Context, client client.AzureClient, devices <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), devices) {
-			if device, ok := result.(AzureWrapper).Data.(models.Device); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating device owners", "result", result)
-			} else {
-				ids <- device.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					data = models.DeviceOwners{
-						DeviceId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListAzureDeviceRegisteredOwners(ctx, id, false) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing owners for this device", "deviceId", id)
-					} else {
-						deviceOwner := models.DeviceOwner{
-							Owner:    item.Ok,
-							DeviceId: item.DeviceId,
-						}
-						log.V(2).Info("found device owner", "deviceOwner", deviceOwner)
-						count++
-						data.Owners = append(data.Owners, deviceOwner)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZDeviceOwner,
-					Data: data,
-				}
-				log.V(1).Info("finished listing device owners", "deviceId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all device owners")
-	}()
-
-	return out
-}
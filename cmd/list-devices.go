@@ -18,14 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -41,44 +38,15 @@ var listDevicesCmd = &cobra.Command{
 }
 
 func listDevicesCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory devices...")
 	start := time.Now()
-	stream := listDevices(ctx, azClient)
+	stream := collector.ListDevices(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listDevices(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureDevices(ctx, "", "", "", "", nil) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing devices")
-				return
-			} else {
-				log.V(2).Info("found device", "device", item)
-				count++
-				out <- AzureWrapper{
-					Kind: enums.KindAZDevice,
-					Data: models.Device{
-						Device:     item.Ok,
-						TenantId:   client.TenantInfo().TenantId,
-						TenantName: client.TenantInfo().DisplayName,
-					},
-				}
-			}
-		}
-		log.Info("finished listing all devices", "count", count)
-	}()
-
-	return out
-}
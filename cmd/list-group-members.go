@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,79 +38,15 @@ var listGroupMembersCmd = &cobra.Command{
 }
 
 func listGroupMembersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure group members...")
 	start := time.Now()
-	stream := listGroupMembers(ctx, azClient, listGroups(ctx, azClient))
+	stream := collector.ListGroupMembers(ctx, azClient, collector.ListGroups(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listGroupMembers(ctx context.Context, client client.AzureClient, groups <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), groups) {
-			if group, ok := result.(AzureWrapper).Data.(models.Group); !ok {
-				log.Error(fmt.Errorf("failed group type assertion"), "unable to continue enumerating group members", "result", result)
-				return
-			} else {
-				ids <- group.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					data = models.GroupMembers{
-						GroupId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListAzureADGroupMembers(ctx, id, "", "", "", nil) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing members for this group", "groupId", id)
-					} else {
-						groupMember := models.GroupMember{
-							Member:  item.Ok,
-							GroupId: item.ParentId,
-						}
-						log.V(2).Info("found group member", "groupMember", groupMember)
-						count++
-						data.Members = append(data.Members, groupMember)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZGroupMember,
-					Data: data,
-				}
-				log.V(1).Info("finished listing group memberships", "groupId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing members for all groups")
-	}()
-
-	return out
-}
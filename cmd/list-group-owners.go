@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,79 +38,15 @@ var listGroupOwnersCmd = &cobra.Command{
 }
 
 func listGroupOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure group owners...")
 	start := time.Now()
-	stream := listGroupOwners(ctx, azClient, listGroups(ctx, azClient))
+	stream := collector.ListGroupOwners(ctx, azClient, collector.ListGroups(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listGroupOwners(ctx context.Context, client client.AzureClient, groups <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), groups) {
-			if group, ok := result.(AzureWrapper).Data.(models.Group); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating group owners", "result", result)
-				return
-			} else {
-				ids <- group.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					groupOwners = models.GroupOwners{
-						GroupId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListAzureADGroupOwners(ctx, id, "", "", "", nil) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing owners for this group", "groupId", id)
-					} else {
-						groupOwner := models.GroupOwner{
-							Owner:   item.Ok,
-							GroupId: item.GroupId,
-						}
-						log.V(2).Info("found group owner", "groupOwner", groupOwner)
-						count++
-						groupOwners.Owners = append(groupOwners.Owners, groupOwner)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZGroupOwner,
-					Data: groupOwners,
-				}
-				log.V(1).Info("finished listing group owners", "groupId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all group owners")
-	}()
-
-	return out
-}
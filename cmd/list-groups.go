@@ -18,19 +18,18 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	listRootCmd.AddCommand(listGroupsCmd)
+	config.Init(listGroupsCmd, []config.Config{config.ResumeFromObjectId})
 }
 
 var listGroupsCmd = &cobra.Command{
@@ -41,45 +40,15 @@ var listGroupsCmd = &cobra.Command{
 }
 
 func listGroupsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory groups...")
 	start := time.Now()
-	stream := listGroups(ctx, azClient)
+	stream := collector.ListGroups(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listGroups(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureADGroups(ctx, "securityEnabled eq true", "", "", "", nil) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing groups")
-				return
-			} else {
-				log.V(2).Info("found group", "group", item)
-				count++
-				group := models.Group{
-					Group:      item.Ok,
-					TenantId:   client.TenantInfo().TenantId,
-					TenantName: client.TenantInfo().DisplayName,
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZGroup,
-					Data: group,
-				}
-			}
-		}
-		log.Info("finished listing all groups", "count", count)
-	}()
-
-	return out
-}
@@ -18,18 +18,14 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/bloodhoundad/azurehound/v2/enums"
-	kinds "github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
 	"github.com/spf13/cobra"
 )
 
@@ -46,78 +42,23 @@ func init() {
 }
 
 func listKeyVaultAccessPoliciesCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure key vault access policies...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
 	if filters, ok := config.KeyVaultAccessTypes.Value().([]enums.KeyVaultAccessType); !ok {
 		exit(fmt.Errorf("filter failed type assertion"))
 	} else {
 		if len(filters) > 0 {
 			log.Info("applying access type filters", "filters", filters)
 		}
-		stream := listKeyVaultAccessPolicies(ctx, azClient, listKeyVaults(ctx, azClient, subscriptions), filters)
+		stream := collector.ListKeyVaultAccessPolicies(ctx, azClient, collector.ListKeyVaults(ctx, azClient, subscriptions), filters)
 		outputStream(ctx, stream)
 		duration := time.Since(start)
 		log.Info("collection completed", "duration", duration.String())
 	}
 }
-
-func listKeyVaultAccessPolicies(ctx context.Context, client client.AzureClient, keyVaults <-chan interface{}, filters []enums.KeyVaultAccessType) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-
-		for result := range pipeline.OrDone(ctx.Done(), keyVaults) {
-			if keyVault, ok := result.(AzureWrapper).Data.(models.KeyVault); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating key vault access policies", "result", result)
-				return
-			} else {
-				for _, policy := range keyVault.Properties.AccessPolicies {
-					if len(filters) == 0 {
-						out <- AzureWrapper{
-							Kind: kinds.KindAZKeyVaultAccessPolicy,
-							Data: models.KeyVaultAccessPolicy{
-								KeyVaultId:        keyVault.Id,
-								AccessPolicyEntry: policy,
-							},
-						}
-					} else {
-						for _, filter := range filters {
-							permissions := func() []string {
-								switch filter {
-								case enums.GetCerts:
-									return policy.Permissions.Certificates
-								case enums.GetKeys:
-									return policy.Permissions.Keys
-								case enums.GetSecrets:
-									return policy.Permissions.Secrets
-								default:
-									log.Error(fmt.Errorf("unsupported key vault access type: %s", filter), "unable to apply key vault access policy filter")
-									return []string{}
-								}
-							}()
-							if contains(permissions, "Get") {
-								out <- AzureWrapper{
-									Kind: kinds.KindAZKeyVaultAccessPolicy,
-									Data: models.KeyVaultAccessPolicy{
-										KeyVaultId:        keyVault.Id,
-										AccessPolicyEntry: policy,
-									},
-								}
-								break
-							}
-						}
-					}
-				}
-			}
-		}
-	}()
-
-	return out
-}
@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,39 +38,18 @@ var listKeyVaultContributorsCmd = &cobra.Command{
 }
 
 func listKeyVaultContributorsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure key vault contributors...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	keyVaults := listKeyVaults(ctx, azClient, subscriptions)
-	kvRoleAssignments := listKeyVaultRoleAssignments(ctx, azClient, keyVaults)
-	stream := listKeyVaultContributors(ctx, kvRoleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	keyVaults := collector.ListKeyVaults(ctx, azClient, subscriptions)
+	kvRoleAssignments := collector.ListKeyVaultRoleAssignments(ctx, azClient, keyVaults)
+	stream := collector.ListKeyVaultContributors(ctx, kvRoleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listKeyVaultContributors(
-	ctx context.Context,
-	kvRoleAssignments <-chan azureWrapper[models.KeyVaultRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), kvRoleAssignments, func(ra azureWrapper[models.KeyVaultRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, kvRoleAssignmentFilter(constants.ContributorRoleID))
-
-		contributors := internal.Map(filteredAssignments, func(ra models.KeyVaultRoleAssignment) models.KeyVaultContributor {
-			return models.KeyVaultContributor{
-				Contributor: ra.RoleAssignment,
-				KeyVaultId:  ra.KeyVaultId,
-			}
-		})
-
-		return NewAzureWrapper(enums.KindAZKeyVaultContributor, models.KeyVaultContributors{
-			KeyVaultId:   ra.Data.KeyVaultId,
-			Contributors: contributors,
-		})
-	})
-}
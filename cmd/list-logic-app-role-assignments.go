@@ -18,18 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"path"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -45,7 +38,7 @@ var listLogicAppRoleAssignment = &cobra.Command{
 }
 
 func listLogicAppRoleAssignmentImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
@@ -56,77 +49,10 @@ func listLogicAppRoleAssignmentImpl(cmd *cobra.Command, args []string) {
 	} else {
 		log.Info("collecting azure logic app role assignments...")
 		start := time.Now()
-		subscriptions := listSubscriptions(ctx, azClient)
-		stream := listLogicAppRoleAssignments(ctx, azClient, listLogicApps(ctx, azClient, subscriptions))
+		subscriptions := collector.ListSubscriptions(ctx, azClient)
+		stream := collector.ListLogicAppRoleAssignments(ctx, azClient, collector.ListLogicApps(ctx, azClient, subscriptions))
 		outputStream(ctx, stream)
 		duration := time.Since(start)
 		log.Info("collection completed", "duration", duration.String())
 	}
 }
-
-func listLogicAppRoleAssignments(ctx context.Context, client client.AzureClient, logicapps <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), logicapps) {
-			if logicapp, ok := result.(AzureWrapper).Data.(models.LogicApp); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating logic app role assignments", "result", result)
-				return
-			} else {
-				ids <- logicapp.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					logicappRoleAssignments = models.AzureRoleAssignments{
-						ObjectId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing role assignments for this logic app", "logicappId", id)
-					} else {
-						roleDefinitionId := path.Base(item.Ok.Properties.RoleDefinitionId)
-
-						logicappRoleAssignment := models.AzureRoleAssignment{
-							Assignee:         item.Ok,
-							ObjectId:         item.ParentId,
-							RoleDefinitionId: roleDefinitionId,
-						}
-						log.V(2).Info("found logic app role assignment", "logicappRoleAssignment", logicappRoleAssignment)
-						count++
-						logicappRoleAssignments.RoleAssignments = append(logicappRoleAssignments.RoleAssignments, logicappRoleAssignment)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZLogicAppRoleAssignment,
-					Data: logicappRoleAssignments,
-				}
-				log.V(1).Info("finished listing logic app role assignments", "logicappId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all logic app role assignments")
-	}()
-
-	return out
-}
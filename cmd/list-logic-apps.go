@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +38,7 @@ var listLogicAppsCmd = &cobra.Command{
 }
 
 func listLogicAppsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
@@ -55,74 +49,9 @@ func listLogicAppsCmdImpl(cmd *cobra.Command, args []string) {
 	} else {
 		log.Info("collecting azure logic apps...")
 		start := time.Now()
-		stream := listLogicApps(ctx, azClient, listSubscriptions(ctx, azClient))
+		stream := collector.ListLogicApps(ctx, azClient, collector.ListSubscriptions(ctx, azClient))
 		outputStream(ctx, stream)
 		duration := time.Since(start)
 		log.Info("collection completed", "duration", duration.String())
 	}
 }
-
-func listLogicApps(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
-			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating logic apps", "result", result)
-				return
-			} else {
-				ids <- subscription.SubscriptionId
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				count := 0
-				// Azure only allows requesting 100 logic apps at a time. The previous
-				// value of math.MaxInt32 was causing issues and not collecting
-				// logic apps at all. This is not a great fix, since it requires proper
-				// pagination in case there are more than 100 logic apps, but it's better
-				// as an interim solution than it was before.
-				for item := range client.ListAzureLogicApps(ctx, id, "", 100) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing logic apps for this subscription", "subscriptionId", id)
-					} else {
-						resourceGroupId := item.Ok.ResourceGroupId()
-						logicapp := models.LogicApp{
-							LogicApp:        item.Ok,
-							SubscriptionId:  item.SubscriptionId,
-							ResourceGroupId: resourceGroupId,
-							TenantId:        client.TenantInfo().TenantId,
-						}
-						log.V(2).Info("found logicapp", "logicapp", logicapp)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZLogicApp,
-							Data: logicapp,
-						}
-					}
-				}
-				log.V(1).Info("finished listing logic apps", "subscriptionId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all logic apps")
-	}()
-
-	return out
-}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +38,7 @@ var listManagedClustersCmd = &cobra.Command{
 }
 
 func listManagedClustersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
@@ -55,69 +49,9 @@ func listManagedClustersCmdImpl(cmd *cobra.Command, args []string) {
 	} else {
 		log.Info("collecting azure managed clusters...")
 		start := time.Now()
-		stream := listManagedClusters(ctx, azClient, listSubscriptions(ctx, azClient))
+		stream := collector.ListManagedClusters(ctx, azClient, collector.ListSubscriptions(ctx, azClient))
 		outputStream(ctx, stream)
 		duration := time.Since(start)
 		log.Info("collection completed", "duration", duration.String())
 	}
 }
-
-func listManagedClusters(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
-			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating managed clusters", "result", result)
-				return
-			} else {
-				ids <- subscription.SubscriptionId
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				count := 0
-				for item := range client.ListAzureManagedClusters(ctx, id, false) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing managed clusters for this subscription", "subscriptionId", id)
-					} else {
-						resourceGroupId := item.Ok.ResourceGroupId()
-						managedCluster := models.ManagedCluster{
-							ManagedCluster:  item.Ok,
-							SubscriptionId:  item.SubscriptionId,
-							ResourceGroupId: resourceGroupId,
-							TenantId:        client.TenantInfo().TenantId,
-						}
-						log.V(2).Info("found managed cluster", "managedCluster", managedCluster)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZManagedCluster,
-							Data: managedCluster,
-						}
-					}
-				}
-				log.V(1).Info("finished listing managed clusters", "subscriptionId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all managed clusters")
-	}()
-
-	return out
-}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,69 +38,15 @@ var listManagementGroupDescendantsCmd = &cobra.Command{
 }
 
 func listManagementGroupDescendantsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure management group descendants...")
 	start := time.Now()
-	stream := listManagementGroupDescendants(ctx, azClient, listManagementGroups(ctx, azClient))
+	stream := collector.ListManagementGroupDescendants(ctx, azClient, collector.ListManagementGroups(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listManagementGroupDescendants(ctx context.Context, client client.AzureClient, managementGroups <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), managementGroups) {
-			if managementGroup, ok := result.(AzureWrapper).Data.(models.ManagementGroup); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating management group descendants", "result", result)
-				return
-			} else {
-				ids <- managementGroup.Name
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				count := 0
-				for item := range client.ListAzureManagementGroupDescendants(ctx, id) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing descendants for this management group", "managementGroupId", id)
-					} else {
-						log.V(2).Info("found management group descendant", "type", item.Ok.Type, "id", item.Ok.Id, "parent", item.Ok.Properties.Parent.Id)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZManagementGroupDescendant,
-							Data: item.Ok,
-						}
-					}
-				}
-				log.V(1).Info("finished listing management group descendants", "managementGroupId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all management group descendants")
-	}()
-
-	return out
-}
@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,36 +38,17 @@ var listManagementGroupOwnersCmd = &cobra.Command{
 }
 
 func listManagementGroupOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure management group owners...")
 	start := time.Now()
-	managementGroups := listManagementGroups(ctx, azClient)
-	roleAssignments := listManagementGroupRoleAssignments(ctx, azClient, managementGroups)
-	stream := listManagementGroupOwners(ctx, roleAssignments)
+	managementGroups := collector.ListManagementGroups(ctx, azClient)
+	roleAssignments := collector.ListManagementGroupRoleAssignments(ctx, azClient, managementGroups)
+	stream := collector.ListManagementGroupOwners(ctx, roleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listManagementGroupOwners(
-	ctx context.Context,
-	roleAssignments <-chan azureWrapper[models.ManagementGroupRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.ManagementGroupRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, mgmtGroupRoleAssignmentFilter(constants.OwnerRoleID))
-		owners := internal.Map(filteredAssignments, func(ra models.ManagementGroupRoleAssignment) models.ManagementGroupOwner {
-			return models.ManagementGroupOwner{
-				Owner:             ra.RoleAssignment,
-				ManagementGroupId: ra.ManagementGroupId,
-			}
-		})
-		return NewAzureWrapper(enums.KindAZManagementGroupOwner, models.ManagementGroupOwners{
-			ManagementGroupId: ra.Data.ManagementGroupId,
-			Owners:            owners,
-		})
-	})
-}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,80 +38,16 @@ var listManagementGroupRoleAssignmentsCmd = &cobra.Command{
 }
 
 func listManagementGroupRoleAssignmentsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure management group role assignments...")
 	start := time.Now()
-	managementGroups := listManagementGroups(ctx, azClient)
-	stream := listManagementGroupRoleAssignments(ctx, azClient, managementGroups)
+	managementGroups := collector.ListManagementGroups(ctx, azClient)
+	stream := collector.ListManagementGroupRoleAssignments(ctx, azClient, managementGroups)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listManagementGroupRoleAssignments(ctx context.Context, client client.AzureClient, managementGroups <-chan interface{}) <-chan azureWrapper[models.ManagementGroupRoleAssignments] {
-	var (
-		out     = make(chan azureWrapper[models.ManagementGroupRoleAssignments])
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), managementGroups) {
-			if managementGroup, ok := result.(AzureWrapper).Data.(models.ManagementGroup); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating management group role assignments", "result", result)
-				return
-			} else {
-				ids <- managementGroup.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					managementGroupRoleAssignments = models.ManagementGroupRoleAssignments{
-						ManagementGroupId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListRoleAssignmentsForResource(ctx, id, "atScope()") {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing role assignments for this managementGroup", "managementGroupId", id)
-					} else {
-						managementGroupRoleAssignment := models.ManagementGroupRoleAssignment{
-							ManagementGroupId: item.ParentId,
-							RoleAssignment:    item.Ok,
-						}
-						log.V(2).Info("found managementGroup role assignment", "managementGroupRoleAssignment", managementGroupRoleAssignment)
-						count++
-						managementGroupRoleAssignments.RoleAssignments = append(managementGroupRoleAssignments.RoleAssignments, managementGroupRoleAssignment)
-					}
-				}
-				out <- NewAzureWrapper(
-					enums.KindAZManagementGroupRoleAssignment,
-					managementGroupRoleAssignments,
-				)
-				log.V(1).Info("finished listing managementGroup role assignments", "managementGroupId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all management group role assignments")
-	}()
-
-	return out
-}
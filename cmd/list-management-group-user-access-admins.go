@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,36 +38,17 @@ var listManagementGroupUserAccessAdminsCmd = &cobra.Command{
 }
 
 func listManagementGroupUserAccessAdminsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure management group user access admins...")
 	start := time.Now()
-	managementGroups := listManagementGroups(ctx, azClient)
-	roleAssignments := listManagementGroupRoleAssignments(ctx, azClient, managementGroups)
-	stream := listManagementGroupUserAccessAdmins(ctx, roleAssignments)
+	managementGroups := collector.ListManagementGroups(ctx, azClient)
+	roleAssignments := collector.ListManagementGroupRoleAssignments(ctx, azClient, managementGroups)
+	stream := collector.ListManagementGroupUserAccessAdmins(ctx, roleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listManagementGroupUserAccessAdmins(
-	ctx context.Context,
-	roleAssignments <-chan azureWrapper[models.ManagementGroupRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.ManagementGroupRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, mgmtGroupRoleAssignmentFilter(constants.UserAccessAdminRoleID))
-		uaas := internal.Map(filteredAssignments, func(ra models.ManagementGroupRoleAssignment) models.ManagementGroupUserAccessAdmin {
-			return models.ManagementGroupUserAccessAdmin{
-				UserAccessAdmin:   ra.RoleAssignment,
-				ManagementGroupId: ra.ManagementGroupId,
-			}
-		})
-		return NewAzureWrapper(enums.KindAZManagementGroupUserAccessAdmin, models.ManagementGroupUserAccessAdmins{
-			ManagementGroupId: ra.Data.ManagementGroupId,
-			UserAccessAdmins:  uaas,
-		})
-	})
-}
@@ -18,15 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/config"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -42,46 +38,15 @@ var listManagementGroupsCmd = &cobra.Command{
 }
 
 func listManagementGroupsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory management groups...")
 	start := time.Now()
-	stream := listManagementGroups(ctx, azClient)
+	stream := collector.ListManagementGroups(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listManagementGroups(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureManagementGroups(ctx) {
-			if item.Error != nil {
-				log.Info("warning: unable to process azure management groups; either the organization has no management groups or azurehound does not have the reader role on the root management group.")
-				return
-			} else if len(config.AzMgmtGroupId.Value().([]string)) == 0 || contains(config.AzMgmtGroupId.Value().([]string), item.Ok.Name) {
-				log.V(2).Info("found management group", "managementGroup", item)
-				count++
-				mgmtGroup := models.ManagementGroup{
-					ManagementGroup: item.Ok,
-					TenantId:        client.TenantInfo().TenantId,
-					TenantName:      client.TenantInfo().DisplayName,
-				}
-
-				out <- AzureWrapper{
-					Kind: enums.KindAZManagementGroup,
-					Data: mgmtGroup,
-				}
-			}
-		}
-		log.Info("finished listing all management groups", "count", count)
-	}()
-
-	return out
-}
@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	listRootCmd.AddCommand(listOwnerRelationshipsCmd)
+}
+
+var listOwnerRelationshipsCmd = &cobra.Command{
+	Use:          "owner-relationships",
+	Long:         "Lists Azure AD App and Service Principal Owner Relationships",
+	Run:          listOwnerRelationshipsCmdImpl,
+	SilenceUsage: true,
+}
+
+func listOwnerRelationshipsCmdImpl(cmd *cobra.Command, args []string) {
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
+	defer gracefulShutdown(stop)
+
+	log.V(1).Info("testing connections")
+	azClient := connectAndCreateClient()
+	log.Info("collecting azure app and service principal owner relationships...")
+	start := time.Now()
+	owners := pipeline.Mux(ctx.Done(),
+		pipeline.ToAny(ctx.Done(), collector.ListAppOwners(ctx, azClient, collector.ListApps(ctx, azClient))),
+		collector.ListServicePrincipalOwners(ctx, azClient, collector.ListServicePrincipals(ctx, azClient)),
+	)
+	stream := collector.ListOwnerRelationships(ctx, owners)
+	outputStream(ctx, stream)
+	duration := time.Since(start)
+	log.Info("collection completed", "duration", duration.String())
+}
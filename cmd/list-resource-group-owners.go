@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,39 +38,18 @@ var listResourceGroupOwnersCmd = &cobra.Command{
 }
 
 func listResourceGroupOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure resource group owners...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	resourceGroups := listResourceGroups(ctx, azClient, subscriptions)
-	roleAssignments := listResourceGroupRoleAssignments(ctx, azClient, resourceGroups)
-	stream := listResourceGroupOwners(ctx, roleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	resourceGroups := collector.ListResourceGroups(ctx, azClient, subscriptions)
+	roleAssignments := collector.ListResourceGroupRoleAssignments(ctx, azClient, resourceGroups)
+	stream := collector.ListResourceGroupOwners(ctx, roleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listResourceGroupOwners(
-	ctx context.Context,
-	roleAssignments <-chan azureWrapper[models.ResourceGroupRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.ResourceGroupRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, rgRoleAssignmentFilter(constants.OwnerRoleID))
-
-		owners := internal.Map(filteredAssignments, func(ra models.ResourceGroupRoleAssignment) models.ResourceGroupOwner {
-			return models.ResourceGroupOwner{
-				Owner:           ra.RoleAssignment,
-				ResourceGroupId: ra.ResourceGroupId,
-			}
-		})
-
-		return NewAzureWrapper(enums.KindAZResourceGroupOwner, models.ResourceGroupOwners{
-			ResourceGroupId: ra.Data.ResourceGroupId,
-			Owners:          owners,
-		})
-	})
-}
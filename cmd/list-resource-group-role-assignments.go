@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,78 +38,17 @@ var listResourceGroupRoleAssignmentsCmd = &cobra.Command{
 }
 
 func listResourceGroupRoleAssignmentsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure resource group role assignments...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	resourceGroups := listResourceGroups(ctx, azClient, subscriptions)
-	stream := listResourceGroupRoleAssignments(ctx, azClient, resourceGroups)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	resourceGroups := collector.ListResourceGroups(ctx, azClient, subscriptions)
+	stream := collector.ListResourceGroupRoleAssignments(ctx, azClient, resourceGroups)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listResourceGroupRoleAssignments(ctx context.Context, client client.AzureClient, resourceGroups <-chan interface{}) <-chan azureWrapper[models.ResourceGroupRoleAssignments] {
-	var (
-		out     = make(chan azureWrapper[models.ResourceGroupRoleAssignments])
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), resourceGroups) {
-			if resourceGroup, ok := result.(AzureWrapper).Data.(models.ResourceGroup); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating resource group role assignments", "result", result)
-				return
-			} else {
-				ids <- resourceGroup.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					resourceGroupRoleAssignments = models.ResourceGroupRoleAssignments{
-						ResourceGroupId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing role assignments for this resourceGroup", "resourceGroupId", id)
-					} else {
-						resourceGroupRoleAssignment := models.ResourceGroupRoleAssignment{
-							ResourceGroupId: item.ParentId,
-							RoleAssignment:  item.Ok,
-						}
-						log.V(2).Info("found resourceGroup role assignment", "resourceGroupRoleAssignment", resourceGroupRoleAssignment)
-						count++
-						resourceGroupRoleAssignments.RoleAssignments = append(resourceGroupRoleAssignments.RoleAssignments, resourceGroupRoleAssignment)
-					}
-				}
-				out <- NewAzureWrapper(enums.KindAZResourceGroupRoleAssignment, resourceGroupRoleAssignments)
-				log.V(1).Info("finished listing resourceGroup role assignments", "resourceGroupId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all resource group role assignments")
-	}()
-
-	return out
-}
@@ -18,14 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -41,44 +38,15 @@ var listRolesCmd = &cobra.Command{
 }
 
 func listRolesCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory roles...")
 	start := time.Now()
-	stream := listRoles(ctx, azClient)
+	stream := collector.ListRoles(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listRoles(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureADRoles(ctx, "", "") {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing roles")
-				return
-			} else {
-				log.V(2).Info("found role", "role", item)
-				count++
-				out <- AzureWrapper{
-					Kind: enums.KindAZRole,
-					Data: models.Role{
-						Role:       item.Ok,
-						TenantId:   client.TenantInfo().TenantId,
-						TenantName: client.TenantInfo().DisplayName,
-					},
-				}
-			}
-		}
-		log.Info("finished listing all roles", "count", count)
-	}()
-
-	return out
-}
@@ -19,19 +19,25 @@ package cmd
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"time"
 
 	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/bloodhoundad/azurehound/v2/config"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/logger"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
 	"github.com/spf13/cobra"
 )
 
 func init() {
-	config.Init(listRootCmd, append(config.AzureConfig, config.OutputFile))
+	config.Init(listRootCmd, append(config.AzureConfig, config.OutputFile, config.OutputFormat, config.Compress, config.Sorted, config.SortedMaxItems, config.SplitOutput, config.Zip, config.ZipChunkSize, config.Neo4jUri, config.Neo4jUser, config.Neo4jPassword, config.Neo4jBatchSize, config.ListUpload, config.IncludeKinds, config.DirectUpload, config.UploadUrl, config.DeleteAfterUpload, config.OutputSink, config.OutputSinkUrl, config.OutputSinkHeaders, config.OutputSinkBatchSize, config.OutputBlob, config.OutputBlobAccountKey, config.OutputBlobMaxRetries, config.IngestCanary, config.StrictPermissions, config.CheckPermissions, config.WorkDir, config.Ledger, config.Checkpoint, config.Sample, config.AuthMethods, config.ExcludeDisabled, config.ExcludeSubscriptionClass, config.Since, config.UseResourceGraph, config.CacheDir, config.CacheTTL, config.EnrichUsers, config.EnrichKey, config.EnrichColumns, config.InactiveDeviceDays, config.RecentCredentialDays, config.RedactPii, config.RedactSalt))
 	rootCmd.AddCommand(listRootCmd)
 }
 
@@ -39,13 +45,260 @@ var listRootCmd = &cobra.Command{
 	Use:               "list",
 	Short:             "Lists Azure Objects",
 	Run:               listCmdImpl,
-	PersistentPreRunE: persistentPreRunE,
+	PersistentPreRunE: listPersistentPreRunE,
 	SilenceUsage:      true,
 }
 
+// list never talks to BloodHound Enterprise unless --upload is set; let users who have BHE settings configured know
+// that is the case so an absent upload is not a surprise
+func listPersistentPreRunE(cmd *cobra.Command, args []string) error {
+	if err := persistentPreRunE(cmd, args); err != nil {
+		return err
+	} else if err := setupCheckpoint(cmd); err != nil {
+		return err
+	} else if err := setupSample(cmd); err != nil {
+		return err
+	} else if err := validateSorted(); err != nil {
+		return err
+	}
+
+	if _, err := includedKinds(); err != nil {
+		return err
+	}
+
+	var (
+		upload       = config.ListUpload.Value().(bool)
+		directUpload = config.DirectUpload.Value().(bool)
+		outputFile   = config.OutputFile.Value().(string)
+		splitDir     = config.SplitOutput.Value().(string)
+		zipFile      = config.Zip.Value().(string)
+		neo4jUri     = config.Neo4jUri.Value().(string)
+		format       = config.OutputFormat.Value().(string)
+		bheUrl       = config.BHEUrl.Value().(string)
+		uploadUrl    = config.UploadUrl.Value().(string)
+	)
+
+	if splitDir != "" && zipFile != "" {
+		return fmt.Errorf("--split-output cannot be combined with --zip")
+	} else if directUpload && (splitDir != "" || zipFile != "") {
+		return fmt.Errorf("--direct-upload cannot be combined with --split-output or --zip")
+	} else if neo4jUri != "" && (splitDir != "" || zipFile != "" || directUpload) {
+		return fmt.Errorf("--neo4j-uri cannot be combined with --split-output, --zip or --direct-upload")
+	} else if err := validateOutputSink(outputFile, format, uploadUrl, upload, splitDir != "" || zipFile != "" || neo4jUri != "" || directUpload); err != nil {
+		return err
+	} else if err := validateUploadUrl(uploadUrl, outputFile, zipFile, splitDir != "" || neo4jUri != "" || directUpload); err != nil {
+		return err
+	} else if splitDir != "" {
+		return validateOutputMode(config.SplitOutput, outputFile, format, upload)
+	} else if zipFile != "" {
+		return validateZipOutput(outputFile, format, upload)
+	} else if neo4jUri != "" {
+		return validateNeo4jOutput(neo4jUri, outputFile, format, upload)
+	} else if err := compressOutput(outputFile); err != nil {
+		return err
+	} else if directUpload {
+		if err := validateDirectUpload(outputFile, upload, format, bheUrl); err != nil {
+			return err
+		}
+		return uploadIngestCanary(cmd.Context())
+	} else if !upload {
+		if bheUrl != "" {
+			log.Info("note: bloodhound enterprise settings are configured but the list command does not upload data; use --upload to upload the output file or the start command to run as a service", "instance", bheUrl)
+		}
+		return nil
+	} else if sinks.IsStdout(outputFile) {
+		return fmt.Errorf("--upload requires an output file to be set with --output")
+	} else if format != enums.OutputFormatJson {
+		return fmt.Errorf("--upload requires the %s output format", enums.OutputFormatJson)
+	} else if bheUrl == "" || config.BHETokenId.Value().(string) == "" || config.BHEToken.Value().(string) == "" {
+		return fmt.Errorf("--upload requires the bloodhound enterprise instance, token id and token to be configured")
+	} else if _, err := parseBHEUrl(bheUrl); err != nil {
+		return fmt.Errorf("invalid bloodhound enterprise instance url: %w", err)
+	} else {
+		return uploadIngestCanary(cmd.Context())
+	}
+}
+
+// uploadIngestCanary sends the ingest canary for --upload and --direct-upload when --ingest-canary is set. Collection
+// has not started yet so a rejected canary costs nothing.
+func uploadIngestCanary(ctx context.Context) error {
+	if !config.IngestCanary.Value().(bool) {
+		return nil
+	} else if bheInstance, bheClient, err := newUploadClient(); err != nil {
+		return err
+	} else {
+		return runIngestCanary(ctx, *bheInstance, bheClient)
+	}
+}
+
+// compressOutput adds the compressed suffix to the output file when --compress is set so the file name reflects its
+// contents. Output written to stdout has no name and is compressed as is.
+func compressOutput(outputFile string) error {
+	if !config.Compress.Value().(bool) || sinks.IsStdout(outputFile) {
+		return nil
+	} else if !sinks.IsCompressed(outputFile) {
+		log.Info("note: adding compressed suffix to output file", "output", outputFile+sinks.CompressedSuffix)
+		config.OutputFile.Set(outputFile + sinks.CompressedSuffix)
+	}
+	return nil
+}
+
+// validateSorted warns --sorted holds the collection in memory since that is easily overlooked on a large tenant
+func validateSorted() error {
+	if !config.Sorted.Value().(bool) {
+		return nil
+	} else if maxItems := config.SortedMaxItems.Value().(int); maxItems < 1 {
+		return fmt.Errorf("--sorted-max-items must be at least 1")
+	} else {
+		log.Info("warning: --sorted holds the collection in memory until it completes; kinds with more items are written unsorted", "sortedMaxItems", maxItems)
+		return nil
+	}
+}
+
+// validateUploadUrl requires a single output file to upload, which is either the zip archive or --output
+func validateUploadUrl(uploadUrl, outputFile, zipFile string, noOutputFile bool) error {
+	if uploadUrl == "" {
+		if config.DeleteAfterUpload.Value().(bool) {
+			return fmt.Errorf("--delete-after-upload requires --upload-url")
+		}
+		return nil
+	} else if noOutputFile {
+		return fmt.Errorf("--upload-url cannot be combined with --split-output, --neo4j-uri or --direct-upload")
+	} else if zipFile == "" && sinks.IsStdout(outputFile) {
+		return fmt.Errorf("--upload-url requires an output file to be set with --output")
+	} else if err := sinks.ValidateUploadUrl(uploadUrl); err != nil {
+		return fmt.Errorf("invalid --upload-url: %w", err)
+	} else {
+		return nil
+	}
+}
+
+// validateOutputSink checks the options of a stdout or http sink, neither of which writes an output file
+func validateOutputSink(outputFile, format, uploadUrl string, upload, otherMode bool) error {
+	var (
+		sink    = config.OutputSink.Value().(string)
+		blobUrl = config.OutputBlob.Value().(string)
+	)
+	if blobUrl != "" && sink == enums.OutputSinkFile {
+		config.OutputSink.Set(enums.OutputSinkBlob)
+		sink = enums.OutputSinkBlob
+	}
+
+	if sink == enums.OutputSinkFile {
+		return nil
+	} else if sink != enums.OutputSinkStdout && sink != enums.OutputSinkHttp && sink != enums.OutputSinkBlob {
+		return fmt.Errorf("unsupported output sink: %s", sink)
+	} else if blobUrl != "" && sink != enums.OutputSinkBlob {
+		return fmt.Errorf("--output-blob cannot be combined with --output-sink %s", sink)
+	} else if otherMode {
+		return fmt.Errorf("--output-sink %s cannot be combined with --split-output, --zip, --neo4j-uri or --direct-upload", sink)
+	} else if format != enums.OutputFormatJson {
+		return fmt.Errorf("--output-sink %s requires the %s output format", sink, enums.OutputFormatJson)
+	} else if outputFile != "" || upload || uploadUrl != "" {
+		return fmt.Errorf("--output-sink %s writes no output file and cannot be combined with --output, --upload or --upload-url", sink)
+	} else if sink == enums.OutputSinkStdout {
+		return nil
+	} else if sink == enums.OutputSinkBlob {
+		return validateBlobSink(blobUrl)
+	} else if config.Compress.Value().(bool) {
+		return fmt.Errorf("--output-sink %s cannot be combined with --compress", sink)
+	} else if config.OutputSinkBatchSize.Value().(int) < 1 {
+		return fmt.Errorf("--output-sink-batch-size must be at least 1")
+	} else if sinkUrl := config.OutputSinkUrl.Value().(string); sinkUrl == "" {
+		return fmt.Errorf("--output-sink %s requires --output-sink-url", sink)
+	} else if err := sinks.ValidateHTTPSinkUrl(sinkUrl); err != nil {
+		return fmt.Errorf("invalid --output-sink-url: %w", err)
+	} else if _, err := outputSinkHeader(); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+// validateBlobSink requires a credential for the upload. Tokens supplied for Graph and Resource Manager cannot
+// authorize requests to Azure Storage, so they need a shared access signature or the account key.
+func validateBlobSink(blobUrl string) error {
+	accountKey := config.OutputBlobAccountKey.Value().(string)
+	if blobUrl == "" {
+		return fmt.Errorf("--output-sink %s requires --output-blob", enums.OutputSinkBlob)
+	} else if err := sinks.ValidateBlobUrl(blobUrl); err != nil {
+		return fmt.Errorf("invalid --output-blob: %w", err)
+	} else if config.OutputBlobMaxRetries.Value().(int) < 0 {
+		return fmt.Errorf("--output-blob-max-retries cannot be negative")
+	} else if sas := sinks.HasSharedAccessSignature(blobUrl); sas && accountKey != "" {
+		return fmt.Errorf("--output-blob-account-key cannot be combined with a shared access signature")
+	} else if _, err := base64.StdEncoding.DecodeString(accountKey); err != nil {
+		return fmt.Errorf("invalid --output-blob-account-key: not base64 encoded")
+	} else if sas || accountKey != "" {
+		return nil
+	} else if config.JWT.Value().(string) != "" || config.GraphToken.Value().(string) != "" || config.ArmToken.Value().(string) != "" {
+		return fmt.Errorf("--output-blob requires a shared access signature or --output-blob-account-key when collecting with a supplied token")
+	} else {
+		return nil
+	}
+}
+
+// validateNeo4jOutput also rejects --compress since nothing is written to a file
+func validateNeo4jOutput(uri, outputFile, format string, upload bool) error {
+	if err := validateOutputMode(config.Neo4jUri, outputFile, format, upload); err != nil {
+		return err
+	} else if config.Compress.Value().(bool) {
+		return fmt.Errorf("--neo4j-uri cannot be combined with --compress")
+	} else if config.Neo4jBatchSize.Value().(int) < 1 {
+		return fmt.Errorf("--neo4j-batch-size must be at least 1")
+	} else if _, err := sinks.Neo4jCommitUrl(uri); err != nil {
+		return fmt.Errorf("invalid --neo4j-uri: %w", err)
+	} else {
+		return nil
+	}
+}
+
+// validateDirectUpload checks that the collection can be streamed to the BloodHound file upload API
+func validateDirectUpload(outputFile string, upload bool, format, bheUrl string) error {
+	if upload {
+		return fmt.Errorf("--direct-upload cannot be combined with --upload")
+	} else if outputFile == sinks.Stdout {
+		return fmt.Errorf("--direct-upload keeps a local copy of the collection and cannot write it to stdout")
+	} else if format != enums.OutputFormatJson {
+		return fmt.Errorf("--direct-upload requires the %s output format", enums.OutputFormatJson)
+	} else if bheUrl == "" || config.BHETokenId.Value().(string) == "" || config.BHEToken.Value().(string) == "" {
+		return fmt.Errorf("--direct-upload requires the bloodhound instance, token id and token to be configured")
+	} else if _, err := parseBHEUrl(bheUrl); err != nil {
+		return fmt.Errorf("invalid bloodhound instance url: %w", err)
+	} else {
+		return nil
+	}
+}
+
+// validateOutputMode rejects options that cannot be combined with an output mode that replaces --output
+func validateOutputMode(mode config.Config, outputFile, format string, upload bool) error {
+	if outputFile != "" {
+		return fmt.Errorf("--%s cannot be combined with --output", mode.Name)
+	} else if format != enums.OutputFormatJson {
+		return fmt.Errorf("--%s requires the %s output format", mode.Name, enums.OutputFormatJson)
+	} else if upload {
+		return fmt.Errorf("--%s cannot be combined with --upload", mode.Name)
+	} else {
+		return nil
+	}
+}
+
+// validateZipOutput also rejects --compress since the archive is already compressed
+func validateZipOutput(outputFile, format string, upload bool) error {
+	if err := validateOutputMode(config.Zip, outputFile, format, upload); err != nil {
+		return err
+	} else if config.Compress.Value().(bool) {
+		return fmt.Errorf("--zip cannot be combined with --compress; the archive is already compressed")
+	} else if size := config.ZipChunkSize.Value().(int); size < 1 {
+		return fmt.Errorf("invalid --%s: %d is not a positive size", config.ZipChunkSize.Name, size)
+	} else {
+		return nil
+	}
+}
+
 func listCmdImpl(cmd *cobra.Command, args []string) {
 	if len(args) > 0 {
-		exit(fmt.Errorf("unsupported subcommand: %v", args))
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("unsupported subcommand: %v", args))
 	}
 
 	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
@@ -53,18 +306,84 @@ func listCmdImpl(cmd *cobra.Command, args []string) {
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
+	warmUpPermissions(ctx, azClient, collector.FullScope)
+	loggedErrors := logger.ErrorCount()
 	log.Info("collecting azure objects...")
 	start := time.Now()
-	stream := listAll(ctx, azClient)
-	outputStream(ctx, stream)
+	ctx, span := tracing.Start(ctx, "collection", "tenantId", azClient.TenantInfo().TenantId)
+	collectionCtx, cancel := collectionContext(ctx)
+	defer cancel()
+	progress := trackProgress()
+	defer currentProgress.Store(nil)
+	reportProgressOnSignal(ctx, progress)
+	summary := newRunSummary()
+	options := collectionOptions()
+	options.Sample = sampleSize
+	options.Progress = progress
+	stream, counts := collectTenant(withRunSummary(collectionCtx, summary), azClient, options)
+	outputStream(ctx, progress.observe(ctx, stream))
+	hasDeadlineExceeded := deadlineExceeded(collectionCtx)
+	span.SetAttributes("deadlineExceeded", hasDeadlineExceeded)
+	span.End()
+	if ctx.Err() == nil {
+		summary.appendToLedger("list", azClient.TenantInfo().TenantId, start, hasDeadlineExceeded)
+	}
 	duration := time.Since(start)
-	log.Info("collection completed", "duration", duration.String())
+	collector.LogForbiddenSummary(log)
+	log.Info("collection completed", "duration", duration.String(), "resyncRestarts", client.ResyncRestarts(), "excludedDisabled", counts.ExcludedDisabled, "skippedUnchanged", counts.SkippedUnchanged, "enrichedUsers", atomic.LoadInt64(&enrichedUsers), "skippedForPermissions", collector.SkippedKinds())
+	if sampleSize > 0 {
+		log.Info("warning: this was a sampled collection and is not a complete view of the tenant", "sample", sampleSize, "sampledOut", counts.SampledOut)
+	}
+	exitIfIncomplete(ctx, loggedErrors, hasDeadlineExceeded)
 }
 
-func listAll(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	var (
-		azureAD = listAllAD(ctx, client)
-		azureRM = listAllRM(ctx, client)
-	)
-	return pipeline.Mux(ctx.Done(), azureAD, azureRM)
+// collectionOptions returns the options of the collectors as configured with the flags of the list command. --sample
+// only applies to list itself so it is left to the caller.
+func collectionOptions() collector.Options {
+	// validated by persistentPreRunE
+	excludedClasses, _ := excludedSubscriptionClasses()
+	kinds, _ := includedKinds()
+	return collector.Options{
+		Kinds:                      kinds,
+		ManagementGroupIds:         config.AzMgmtGroupId.Value().([]string),
+		SubscriptionIds:            config.AzSubId.Value().([]string),
+		ExcludeSubscriptionClasses: excludedClasses,
+		AuthMethods:                config.AuthMethods.Value().(bool),
+		ExcludeDisabled:            config.ExcludeDisabled.Value().(bool),
+		InactiveDeviceDays:         config.InactiveDeviceDays.Value().(int),
+		RecentCredentialDays:       config.RecentCredentialDays.Value().(int),
+		Since:                      changedSince,
+		Checkpoint:                 activeCheckpoint,
+		ResumeFromObjectId:         config.ResumeFromObjectId.Value().(string),
+		MissingPermissions:         missingPermissions(),
+		OnError:                    func(kind enums.Kind, err error) { serviceState.failed(err) },
+		Logger:                     log,
+	}
+}
+
+// withCollectionOptions returns a context under which the collectors of a single kind run with collectionOptions
+func withCollectionOptions(ctx context.Context) context.Context {
+	return collector.WithOptions(ctx, collectionOptions())
+}
+
+// collectTenant collects the tenant with options, enriching the users with --enrich-users, and returns the stream
+// along with the counts of the objects the collection left out. The enrichment comes before summarizeAndRedact so
+// users are summarized and redacted with their enriched attributes.
+func collectTenant(ctx context.Context, azClient client.AzureClient, options collector.Options) (<-chan interface{}, *collector.Counts) {
+	stream, counts := collector.Collect(ctx, azClient, options)
+	if enrichment != nil {
+		stream = enrichUsers(ctx, stream, enrichment)
+	}
+	return summarizeAndRedact(ctx, stream), counts
+}
+
+// summarizeAndRedact tallies stream into the run summary of ctx and then redacts it if --redact-pii is set. Redacted
+// items lose their model types, so the summary has to see them first to read the roles and their holders.
+func summarizeAndRedact(ctx context.Context, stream <-chan interface{}) <-chan interface{} {
+	stream = runSummaryOf(ctx).summarize(ctx, stream)
+	if config.RedactPii.Value().(bool) {
+		return redactPii(ctx, stream, []byte(config.RedactSalt.Value().(string)))
+	} else {
+		return stream
+	}
 }
@@ -0,0 +1,253 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+)
+
+func TestCompressOutput(t *testing.T) {
+	t.Cleanup(func() {
+		config.Compress.Set(false)
+		config.OutputFile.Set("")
+	})
+
+	config.Compress.Set(true)
+	for _, output := range []string{"", sinks.Stdout} {
+		config.OutputFile.Set(output)
+		if err := compressOutput(output); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if actual := config.OutputFile.Value().(string); actual != output {
+			t.Errorf("got %v, want %v", actual, output)
+		}
+	}
+
+	for _, output := range []string{"output.json", "output.json.gz"} {
+		config.OutputFile.Set(output)
+		if err := compressOutput(output); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if actual := config.OutputFile.Value().(string); actual != "output.json.gz" {
+			t.Errorf("got %v, want %v", actual, "output.json.gz")
+		}
+	}
+}
+
+func TestValidateOutputMode(t *testing.T) {
+	if err := validateOutputMode(config.SplitOutput, "", enums.OutputFormatJson, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		outputFile string
+		format     string
+		upload     bool
+	}{
+		{"output.json", enums.OutputFormatJson, false},
+		{"", enums.OutputFormatInventory, false},
+		{"", enums.OutputFormatJson, true},
+	} {
+		if err := validateOutputMode(config.SplitOutput, tc.outputFile, tc.format, tc.upload); err == nil {
+			t.Errorf("%+v: expected an error but did not receive one", tc)
+		}
+	}
+}
+
+func TestValidateZipOutput(t *testing.T) {
+	t.Cleanup(func() {
+		config.Compress.Set(false)
+		config.ZipChunkSize.Set(500)
+	})
+
+	if err := validateZipOutput("", enums.OutputFormatJson, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.Compress.Set(true)
+	if err := validateZipOutput("", enums.OutputFormatJson, false); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+
+	config.Compress.Set(false)
+	config.ZipChunkSize.Set(0)
+	if err := validateZipOutput("", enums.OutputFormatJson, false); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestValidateDirectUpload(t *testing.T) {
+	config.BHETokenId.Set("tokenId")
+	config.BHEToken.Set("token")
+	t.Cleanup(func() {
+		config.BHETokenId.Set("")
+		config.BHEToken.Set("")
+	})
+
+	if err := validateDirectUpload("", false, enums.OutputFormatJson, "https://bloodhound.example.com"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := validateDirectUpload("", true, enums.OutputFormatJson, "https://bloodhound.example.com"); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+
+	if err := validateDirectUpload("", false, enums.OutputFormatJson, ""); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+
+	if err := validateDirectUpload(sinks.Stdout, false, enums.OutputFormatJson, "https://bloodhound.example.com"); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestValidateNeo4jOutput(t *testing.T) {
+	t.Cleanup(func() {
+		config.Compress.Set(false)
+	})
+
+	if err := validateNeo4jOutput("http://localhost:7474", "", enums.OutputFormatJson, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, uri := range []string{"bolt://localhost:7687", "localhost:7474"} {
+		if err := validateNeo4jOutput(uri, "", enums.OutputFormatJson, false); err == nil {
+			t.Errorf("%s: expected an error but did not receive one", uri)
+		}
+	}
+
+	config.Compress.Set(true)
+	if err := validateNeo4jOutput("http://localhost:7474", "", enums.OutputFormatJson, false); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestValidateUploadUrl(t *testing.T) {
+	t.Cleanup(func() {
+		config.DeleteAfterUpload.Set(false)
+	})
+
+	const sasUrl = "https://account.blob.core.windows.net/container?sv=2021-08-06&sig=abc"
+	if err := validateUploadUrl(sasUrl, "output.json", "", false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if err := validateUploadUrl("s3://bucket/collections/", "", "output.zip", false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if err := validateUploadUrl(sasUrl, "", "", false); err == nil {
+		t.Error("expected an error uploading stdout but did not receive one")
+	} else if err := validateUploadUrl(sasUrl, "output.json", "", true); err == nil {
+		t.Error("expected an error without an output file but did not receive one")
+	} else if err := validateUploadUrl("https://example.com/output.json", "output.json", "", false); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+
+	config.DeleteAfterUpload.Set(true)
+	if err := validateUploadUrl("", "output.json", "", false); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestValidateOutputSink(t *testing.T) {
+	t.Cleanup(func() {
+		config.OutputSink.Set(enums.OutputSinkFile)
+		config.OutputSinkUrl.Set("")
+		config.OutputSinkHeaders.Set([]string{})
+	})
+
+	if err := validateOutputSink("output.json", enums.OutputFormatJson, "", true, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.OutputSink.Set(enums.OutputSinkStdout)
+	if err := validateOutputSink("", enums.OutputFormatJson, "", false, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if err := validateOutputSink("output.json", enums.OutputFormatJson, "", false, false); err == nil {
+		t.Error("expected an error with --output but did not receive one")
+	} else if err := validateOutputSink("", enums.OutputFormatNdjson, "", false, false); err == nil {
+		t.Error("expected an error with ndjson but did not receive one")
+	}
+
+	config.OutputSink.Set(enums.OutputSinkHttp)
+	if err := validateOutputSink("", enums.OutputFormatJson, "", false, false); err == nil {
+		t.Error("expected an error without --output-sink-url but did not receive one")
+	}
+
+	config.OutputSinkUrl.Set("https://collector.example.com/ingest")
+	config.OutputSinkHeaders.Set([]string{"Authorization: Bearer secret"})
+	if err := validateOutputSink("", enums.OutputFormatJson, "", false, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if err := validateOutputSink("", enums.OutputFormatJson, "", false, true); err == nil {
+		t.Error("expected an error with another output mode but did not receive one")
+	} else if header, _ := outputSinkHeader(); header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("got %v, want %v", header.Get("Authorization"), "Bearer secret")
+	}
+
+	config.OutputSinkHeaders.Set([]string{"Bearer secret"})
+	if err := validateOutputSink("", enums.OutputFormatJson, "", false, false); err == nil {
+		t.Error("expected an error with a malformed header but did not receive one")
+	} else if strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected the header value to be left out of the error: %v", err)
+	}
+}
+
+func TestValidateBlobSink(t *testing.T) {
+	t.Cleanup(func() {
+		config.OutputSink.Set(enums.OutputSinkFile)
+		config.OutputBlob.Set("")
+		config.OutputBlobAccountKey.Set("")
+		config.JWT.Set("")
+	})
+
+	const containerUrl = "https://account.blob.core.windows.net/collections"
+	config.OutputBlob.Set(containerUrl)
+	if err := validateOutputSink("", enums.OutputFormatJson, "", false, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if sink := config.OutputSink.Value(); sink != enums.OutputSinkBlob {
+		t.Errorf("got sink %v, want %v", sink, enums.OutputSinkBlob)
+	} else if err := validateOutputSink("output.json", enums.OutputFormatJson, "", false, false); err == nil {
+		t.Error("expected an error with --output but did not receive one")
+	}
+
+	config.OutputSink.Set(enums.OutputSinkHttp)
+	if err := validateOutputSink("", enums.OutputFormatJson, "", false, false); err == nil {
+		t.Error("expected an error with another sink but did not receive one")
+	}
+
+	for _, tc := range []struct {
+		url, accountKey, jwt string
+		valid                bool
+	}{
+		{containerUrl + "?sv=2021-08-06&sig=abc", "", "token", true},
+		{containerUrl, "a2V5", "token", true},
+		{containerUrl, "", "token", false},
+		{containerUrl + "?sig=abc", "a2V5", "", false},
+		{containerUrl, "not base64!", "", false},
+		{"https://account.blob.core.windows.net", "", "", false},
+		{"", "", "", false},
+	} {
+		config.OutputBlobAccountKey.Set(tc.accountKey)
+		config.JWT.Set(tc.jwt)
+		if err := validateBlobSink(tc.url); tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.url, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("%s: expected an error but did not receive one", tc.url)
+		}
+	}
+}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,79 +38,15 @@ var listServicePrincipalOwnersCmd = &cobra.Command{
 }
 
 func listServicePrincipalOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure service principal owners...")
 	start := time.Now()
-	stream := listServicePrincipalOwners(ctx, azClient, listServicePrincipals(ctx, azClient))
+	stream := collector.ListServicePrincipalOwners(ctx, azClient, collector.ListServicePrincipals(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listServicePrincipalOwners(ctx context.Context, client client.AzureClient, servicePrincipals <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), servicePrincipals) {
-			if servicePrincipal, ok := result.(AzureWrapper).Data.(models.ServicePrincipal); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating service principal owners", "result", result)
-				return
-			} else {
-				ids <- servicePrincipal.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					servicePrincipalOwners = models.ServicePrincipalOwners{
-						ServicePrincipalId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListAzureADServicePrincipalOwners(ctx, id, "", "", "", nil) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing owners for this service principal", "servicePrincipalId", id)
-					} else {
-						servicePrincipalOwner := models.ServicePrincipalOwner{
-							Owner:              item.Ok,
-							ServicePrincipalId: item.ServicePrincipalId,
-						}
-						log.V(2).Info("found service principal owner", "servicePrincipalOwner", servicePrincipalOwner)
-						count++
-						servicePrincipalOwners.Owners = append(servicePrincipalOwners.Owners, servicePrincipalOwner)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZServicePrincipalOwner,
-					Data: servicePrincipalOwners,
-				}
-				log.V(1).Info("finished listing service principal owners", "servicePrincipalId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all service principal owners")
-	}()
-
-	return out
-}
@@ -18,19 +18,18 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	listRootCmd.AddCommand(listServicePrincipalsCmd)
+	config.Init(listServicePrincipalsCmd, []config.Config{config.ResumeFromObjectId})
 }
 
 var listServicePrincipalsCmd = &cobra.Command{
@@ -41,44 +40,15 @@ var listServicePrincipalsCmd = &cobra.Command{
 }
 
 func listServicePrincipalsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory service principals...")
 	start := time.Now()
-	stream := listServicePrincipals(ctx, azClient)
+	stream := collector.ListServicePrincipals(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listServicePrincipals(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureADServicePrincipals(ctx, "", "", "", "", nil) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing service principals")
-				return
-			} else {
-				log.V(2).Info("found service principal", "servicePrincipal", item)
-				count++
-				out <- AzureWrapper{
-					Kind: enums.KindAZServicePrincipal,
-					Data: models.ServicePrincipal{
-						ServicePrincipal: item.Ok,
-						TenantId:         client.TenantInfo().TenantId,
-						TenantName:       client.TenantInfo().DisplayName,
-					},
-				}
-			}
-		}
-		log.Info("finished listing all service principals", "count", count)
-	}()
-
-	return out
-}
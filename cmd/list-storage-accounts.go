@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,77 +38,15 @@ var listStorageAccountsCmd = &cobra.Command{
 }
 
 func listStorageAccountsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure storage accounts...")
 	start := time.Now()
-	stream := listStorageAccounts(ctx, azClient, listSubscriptions(ctx, azClient))
+	stream := collector.ListStorageAccounts(ctx, azClient, collector.ListSubscriptions(ctx, azClient))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listStorageAccounts(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
-			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating storage accounts", "result", result)
-				return
-			} else {
-				ids <- subscription.SubscriptionId
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				count := 0
-				for item := range client.ListAzureStorageAccounts(ctx, id) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing storage accounts for this subscription", "subscriptionId", id)
-					} else {
-						resourceGroupId := item.Ok.ResourceGroupId()
-						resourceGroupName := item.Ok.ResourceGroupName()
-						storageAccount := models.StorageAccount{
-							StorageAccount:    item.Ok,
-							SubscriptionId:    item.SubscriptionId,
-							ResourceGroupId:   resourceGroupId,
-							ResourceGroupName: resourceGroupName,
-							TenantId:          client.TenantInfo().TenantId,
-						}
-						log.V(2).Info("found storage account", "storageAccount", storageAccount)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZStorageAccount,
-							Data: storageAccount,
-						}
-					}
-				}
-				log.V(1).Info("finished listing storage accounts", "subscriptionId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all storage accounts")
-	}()
-
-	return out
-}
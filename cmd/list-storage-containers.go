@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,85 +38,17 @@ var listStorageContainersCmd = &cobra.Command{
 }
 
 func listStorageContainersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure storage containers...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	storageAccounts := listStorageAccounts(ctx, azClient, subscriptions)
-	stream := listStorageContainers(ctx, azClient, storageAccounts)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	storageAccounts := collector.ListStorageAccounts(ctx, azClient, subscriptions)
+	stream := collector.ListStorageContainers(ctx, azClient, storageAccounts)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listStorageContainers(ctx context.Context, client client.AzureClient, storageAccounts <-chan interface{}) <-chan interface{} {
-	var (
-		out = make(chan interface{})
-		ids = make(chan interface{})
-		// The original size of the demuxxer cascaded into error messages for a lot of collection steps.
-		// Decreasing the demuxxer size only here is sufficient to prevent the cascade
-		// The error message with higher values for size is
-		// "The request was throttled."
-		// See issue #7: https://github.com/bloodhoundad/azurehound/issues/7
-		streams = pipeline.Demux(ctx.Done(), ids, 2)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-		for result := range pipeline.OrDone(ctx.Done(), storageAccounts) {
-			if storageAccount, ok := result.(AzureWrapper).Data.(models.StorageAccount); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating storage containers", "result", result)
-				return
-			} else {
-				ids <- storageAccount
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for stAccount := range stream {
-				count := 0
-				for item := range client.ListAzureStorageContainers(ctx, stAccount.(models.StorageAccount).SubscriptionId, stAccount.(models.StorageAccount).ResourceGroupName, stAccount.(models.StorageAccount).Name, "", "deleted", "") {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing storage containers for this subscription", "subscriptionId", stAccount.(models.StorageAccount).SubscriptionId, "storageAccountName", stAccount.(models.StorageAccount).Name)
-					} else {
-						resourceGroupId := item.Ok.ResourceGroupId()
-						resourceGroupName := item.Ok.ResourceGroupName()
-						storageContainer := models.StorageContainer{
-							StorageContainer:  item.Ok,
-							StorageAccountId:  stAccount.(models.StorageAccount).StorageAccount.Id,
-							SubscriptionId:    item.SubscriptionId,
-							ResourceGroupId:   resourceGroupId,
-							ResourceGroupName: resourceGroupName,
-							TenantId:          client.TenantInfo().TenantId,
-						}
-						log.V(2).Info("found storage container", "storageContainer", storageContainer)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZStorageContainer,
-							Data: storageContainer,
-						}
-					}
-					log.V(1).Info("finished listing storage containers", "subscriptionId", stAccount.(models.StorageAccount).SubscriptionId, "count", count)
-				}
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all storage containers")
-	}()
-
-	return out
-}
@@ -18,18 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"path"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -45,59 +38,17 @@ var listSubscriptionOwnersCmd = &cobra.Command{
 }
 
 func listSubscriptionOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure subscription owners...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	roleAssignments := listSubscriptionRoleAssignments(ctx, azClient, subscriptions)
-	stream := listSubscriptionOwners(ctx, azClient, roleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	roleAssignments := collector.ListSubscriptionRoleAssignments(ctx, azClient, subscriptions)
+	stream := collector.ListSubscriptionOwners(ctx, azClient, roleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listSubscriptionOwners(ctx context.Context, client client.AzureClient, roleAssignments <-chan interface{}) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-
-		for result := range pipeline.OrDone(ctx.Done(), roleAssignments) {
-			if roleAssignments, ok := result.(AzureWrapper).Data.(models.SubscriptionRoleAssignments); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating subscription owners", "result", result)
-				return
-			} else {
-				var (
-					subscriptionOwners = models.SubscriptionOwners{
-						SubscriptionId: roleAssignments.SubscriptionId,
-					}
-					count = 0
-				)
-				for _, item := range roleAssignments.RoleAssignments {
-					roleDefinitionId := path.Base(item.RoleAssignment.Properties.RoleDefinitionId)
-
-					if roleDefinitionId == constants.OwnerRoleID {
-						subscriptionOwner := models.SubscriptionOwner{
-							Owner:          item.RoleAssignment,
-							SubscriptionId: item.SubscriptionId,
-						}
-						log.V(2).Info("found subscription owner", "subscriptionOwner", subscriptionOwner)
-						count++
-						subscriptionOwners.Owners = append(subscriptionOwners.Owners, subscriptionOwner)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZSubscriptionOwner,
-					Data: subscriptionOwners,
-				}
-				log.V(1).Info("finished listing subscription owners", "subscriptionId", roleAssignments.SubscriptionId, "count", count)
-			}
-		}
-	}()
-
-	return out
-}
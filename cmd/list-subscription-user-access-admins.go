@@ -18,18 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"path"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -45,60 +38,17 @@ var listSubscriptionUserAccessAdminsCmd = &cobra.Command{
 }
 
 func listSubscriptionUserAccessAdminsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure subscription user access admins...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	roleAssignments := listSubscriptionRoleAssignments(ctx, azClient, subscriptions)
-	stream := listSubscriptionUserAccessAdmins(ctx, azClient, roleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	roleAssignments := collector.ListSubscriptionRoleAssignments(ctx, azClient, subscriptions)
+	stream := collector.ListSubscriptionUserAccessAdmins(ctx, azClient, roleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listSubscriptionUserAccessAdmins(ctx context.Context, client client.AzureClient, vmRoleAssignments <-chan interface{}) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-
-		for result := range pipeline.OrDone(ctx.Done(), vmRoleAssignments) {
-			if roleAssignments, ok := result.(AzureWrapper).Data.(models.SubscriptionRoleAssignments); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating subscription user access admins", "result", result)
-				return
-			} else {
-				var (
-					subscriptionUserAccessAdmins = models.SubscriptionUserAccessAdmins{
-						SubscriptionId: roleAssignments.SubscriptionId,
-					}
-					count = 0
-				)
-				for _, item := range roleAssignments.RoleAssignments {
-					roleDefinitionId := path.Base(item.RoleAssignment.Properties.RoleDefinitionId)
-
-					if roleDefinitionId == constants.UserAccessAdminRoleID {
-						subscriptionUserAccessAdmin := models.SubscriptionUserAccessAdmin{
-							UserAccessAdmin: item.RoleAssignment,
-							SubscriptionId:  item.SubscriptionId,
-						}
-						log.V(2).Info("found subscription user access admin", "subscriptionUserAccessAdmin", subscriptionUserAccessAdmin)
-						count++
-						subscriptionUserAccessAdmins.UserAccessAdmins = append(subscriptionUserAccessAdmins.UserAccessAdmins, subscriptionUserAccessAdmin)
-					}
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZSubscriptionUserAccessAdmin,
-					Data: subscriptionUserAccessAdmins,
-				}
-				log.V(1).Info("finished listing subscription user access admins", "subscriptionId", roleAssignments.SubscriptionId, "count", count)
-			}
-		}
-		log.Info("finished listing all subscription user access admins")
-	}()
-
-	return out
-}
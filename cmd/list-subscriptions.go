@@ -18,16 +18,13 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/models/azure"
-
-	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/bloodhoundad/azurehound/v2/enums"
 	"github.com/spf13/cobra"
@@ -45,65 +42,38 @@ var listSubscriptionsCmd = &cobra.Command{
 }
 
 func listSubscriptionsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory subscriptions...")
 	start := time.Now()
-	stream := listSubscriptions(ctx, azClient)
+	stream := collector.ListSubscriptions(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
 
-func listSubscriptions(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		var (
-			count                = 0
-			selectedSubIds       = config.AzSubId.Value().([]string)
-			selectedMgmtGroupIds = config.AzMgmtGroupId.Value().([]string)
-			filterOnSubs         = len(selectedSubIds) != 0 || len(selectedMgmtGroupIds) != 0
-		)
-
-		if len(selectedMgmtGroupIds) != 0 {
-			descendantChannel := listManagementGroupDescendants(ctx, client, listManagementGroups(ctx, client))
-			for i := range descendantChannel {
-				if item, ok := i.(AzureWrapper).Data.(azure.DescendantInfo); !ok {
-					log.Error(fmt.Errorf("failed type assertion"), "unable to continue evaluating management group descendants", "result", i)
-					return
-				} else if item.Type == "Microsoft.Management/managementGroups/subscriptions" {
-					selectedSubIds = append(selectedSubIds, item.Name)
-				}
-			}
+// excludedSubscriptionClasses returns the subscription classes configured with --exclude-subscription-class. Classes
+// are matched case-insensitively so e.g. devtest,sandbox may be used.
+func excludedSubscriptionClasses() ([]enums.SubscriptionClass, error) {
+	var classes []enums.SubscriptionClass
+	for _, value := range config.ExcludeSubscriptionClass.Value().([]string) {
+		if class, ok := subscriptionClass(value); !ok {
+			return nil, fmt.Errorf("invalid --%s: unsupported subscription class %q", config.ExcludeSubscriptionClass.Name, value)
+		} else {
+			classes = append(classes, class)
 		}
-		uniqueSubIds := unique(selectedSubIds)
+	}
+	return classes, nil
+}
 
-		for item := range client.ListAzureSubscriptions(ctx) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing subscriptions")
-				return
-			} else if !filterOnSubs || contains(uniqueSubIds, item.Ok.SubscriptionId) {
-				log.V(2).Info("found subscription", "subscription", item)
-				count++
-				// the embedded struct's values override top-level properties so TenantId
-				// needs to be explicitly set.
-				data := models.Subscription{
-					Subscription: item.Ok,
-				}
-				data.TenantId = client.TenantInfo().TenantId
-				out <- AzureWrapper{
-					Kind: enums.KindAZSubscription,
-					Data: data,
-				}
-			}
+func subscriptionClass(value string) (enums.SubscriptionClass, bool) {
+	for _, class := range enums.SubscriptionClasses() {
+		if strings.EqualFold(class, value) {
+			return class, true
 		}
-		log.Info("finished listing all subscriptions", "count", count)
-	}()
-
-	return out
+	}
+	return "", false
 }
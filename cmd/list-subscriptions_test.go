@@ -18,51 +18,30 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
+	"reflect"
 	"testing"
 
-	"github.com/bloodhoundad/azurehound/v2/client/mocks"
-	"github.com/bloodhoundad/azurehound/v2/models/azure"
-	"github.com/golang/mock/gomock"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
 )
 
-func init() {
-	setupLogger()
-}
-
-func TestListSubscriptions(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-	ctx := context.Background()
-
-	mockClient := mocks.NewMockAzureClient(ctrl)
-	mockChannel := make(chan azure.SubscriptionResult)
-	mockTenant := azure.Tenant{}
-	mockError := fmt.Errorf("I'm an error")
-	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
-	mockClient.EXPECT().ListAzureSubscriptions(gomock.Any()).Return(mockChannel)
+func TestExcludedSubscriptionClasses(t *testing.T) {
+	config.ExcludeSubscriptionClass.Set([]string{"devtest", "Sandbox"})
+	t.Cleanup(func() { config.ExcludeSubscriptionClass.Set([]string{}) })
 
-	go func() {
-		defer close(mockChannel)
-		mockChannel <- azure.SubscriptionResult{
-			Ok: azure.Subscription{},
-		}
-		mockChannel <- azure.SubscriptionResult{
-			Error: mockError,
-		}
-		mockChannel <- azure.SubscriptionResult{
-			Ok: azure.Subscription{},
-		}
-	}()
-
-	channel := listSubscriptions(ctx, mockClient)
-	result := <-channel
-	if _, ok := result.(AzureWrapper); !ok {
-		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	want := []enums.SubscriptionClass{enums.SubscriptionClassDevTest, enums.SubscriptionClassSandbox}
+	if classes, err := excludedSubscriptionClasses(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if !reflect.DeepEqual(classes, want) {
+		t.Errorf("got %v, want %v", classes, want)
 	}
+}
+
+func TestExcludedSubscriptionClassesInvalid(t *testing.T) {
+	config.ExcludeSubscriptionClass.Set([]string{"sandbox", "personal"})
+	t.Cleanup(func() { config.ExcludeSubscriptionClass.Set([]string{}) })
 
-	if _, ok := <-channel; ok {
-		t.Error("expected channel to close from an error result but it did not")
+	if _, err := excludedSubscriptionClasses(); err == nil {
+		t.Error("expected an error but did not receive one")
 	}
 }
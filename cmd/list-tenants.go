@@ -18,14 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -41,56 +38,15 @@ var listTenantsCmd = &cobra.Command{
 }
 
 func listTenantsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory tenants...")
 	start := time.Now()
-	stream := listTenants(ctx, azClient)
+	stream := collector.ListTenants(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listTenants(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-
-		// Send the fully hydrated tenant that is being collected
-		collectedTenant := client.TenantInfo()
-		out <- AzureWrapper{
-			Kind: enums.KindAZTenant,
-			Data: models.Tenant{
-				Tenant:    collectedTenant,
-				Collected: true,
-			},
-		}
-		count := 1
-		for item := range client.ListAzureADTenants(ctx, true) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing tenants")
-				return
-			} else {
-				log.V(2).Info("found tenant", "tenant", item)
-				count++
-
-				// Send the remaining tenant trusts
-				if item.Ok.TenantId != collectedTenant.TenantId {
-					out <- AzureWrapper{
-						Kind: enums.KindAZTenant,
-						Data: models.Tenant{
-							Tenant: item.Ok,
-						},
-					}
-				}
-			}
-		}
-		log.Info("finished listing all tenants", "count", count)
-	}()
-
-	return out
-}
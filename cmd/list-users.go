@@ -18,19 +18,18 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	listRootCmd.AddCommand(listUsersCmd)
+	config.Init(listUsersCmd, []config.Config{config.ResumeFromObjectId})
 }
 
 var listUsersCmd = &cobra.Command{
@@ -41,45 +40,15 @@ var listUsersCmd = &cobra.Command{
 }
 
 func listUsersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure active directory users...")
 	start := time.Now()
-	stream := listUsers(ctx, azClient)
+	stream := collector.ListUsers(ctx, azClient)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listUsers(ctx context.Context, client client.AzureClient) <-chan interface{} {
-	out := make(chan interface{})
-
-	go func() {
-		defer close(out)
-		count := 0
-		for item := range client.ListAzureADUsers(ctx, "", "", "", nil) {
-			if item.Error != nil {
-				log.Error(item.Error, "unable to continue processing users")
-				return
-			} else {
-				log.V(2).Info("found user", "user", item)
-				count++
-				user := models.User{
-					User:       item.Ok,
-					TenantId:   client.TenantInfo().TenantId,
-					TenantName: client.TenantInfo().DisplayName,
-				}
-				out <- AzureWrapper{
-					Kind: enums.KindAZUser,
-					Data: user,
-				}
-			}
-		}
-		log.Info("finished listing all users", "count", count)
-	}()
-
-	return out
-}
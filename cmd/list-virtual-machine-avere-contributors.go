@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,37 +38,18 @@ var listVirtualMachineAvereContributorsCmd = &cobra.Command{
 }
 
 func listVirtualMachineAvereContributorsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure virtual machine averecontributors...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	vms := listVirtualMachines(ctx, azClient, subscriptions)
-	vmRoleAssignments := listVirtualMachineRoleAssignments(ctx, azClient, vms)
-	stream := listVirtualMachineAvereContributors(ctx, vmRoleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	vms := collector.ListVirtualMachines(ctx, azClient, subscriptions)
+	vmRoleAssignments := collector.ListVirtualMachineRoleAssignments(ctx, azClient, vms)
+	stream := collector.ListVirtualMachineAvereContributors(ctx, vmRoleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listVirtualMachineAvereContributors(
-	ctx context.Context,
-	roleAssignments <-chan azureWrapper[models.VirtualMachineRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.VirtualMachineRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, vmRoleAssignmentFilter(constants.AvereContributorRoleID))
-		avereContributors := internal.Map(filteredAssignments, func(ra models.VirtualMachineRoleAssignment) models.VirtualMachineAvereContributor {
-			return models.VirtualMachineAvereContributor{
-				VirtualMachineId: ra.VirtualMachineId,
-				AvereContributor: ra.RoleAssignment,
-			}
-		})
-		return NewAzureWrapper(enums.KindAZVMAvereContributor, models.VirtualMachineAvereContributors{
-			VirtualMachineId:  ra.Data.VirtualMachineId,
-			AvereContributors: avereContributors,
-		})
-	})
-}
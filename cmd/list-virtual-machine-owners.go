@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,37 +38,18 @@ var listVirtualMachineOwnersCmd = &cobra.Command{
 }
 
 func listVirtualMachineOwnersCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure virtual machine owners...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	vms := listVirtualMachines(ctx, azClient, subscriptions)
-	vmRoleAssignments := listVirtualMachineRoleAssignments(ctx, azClient, vms)
-	stream := listVirtualMachineOwners(ctx, vmRoleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	vms := collector.ListVirtualMachines(ctx, azClient, subscriptions)
+	vmRoleAssignments := collector.ListVirtualMachineRoleAssignments(ctx, azClient, vms)
+	stream := collector.ListVirtualMachineOwners(ctx, vmRoleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listVirtualMachineOwners(
-	ctx context.Context,
-	roleAssignments <-chan azureWrapper[models.VirtualMachineRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.VirtualMachineRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, vmRoleAssignmentFilter(constants.OwnerRoleID))
-		owners := internal.Map(filteredAssignments, func(ra models.VirtualMachineRoleAssignment) models.VirtualMachineOwner {
-			return models.VirtualMachineOwner{
-				VirtualMachineId: ra.VirtualMachineId,
-				Owner:            ra.RoleAssignment,
-			}
-		})
-		return NewAzureWrapper(enums.KindAZVMOwner, models.VirtualMachineOwners{
-			VirtualMachineId: ra.Data.VirtualMachineId,
-			Owners:           owners,
-		})
-	})
-}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,77 +38,16 @@ var listVirtualMachineRoleAssignmentsCmd = &cobra.Command{
 }
 
 func listVirtualMachineRoleAssignmentsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure virtual machine role assignments...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	stream := listVirtualMachineRoleAssignments(ctx, azClient, listVirtualMachines(ctx, azClient, subscriptions))
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	stream := collector.ListVirtualMachineRoleAssignments(ctx, azClient, collector.ListVirtualMachines(ctx, azClient, subscriptions))
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listVirtualMachineRoleAssignments(ctx context.Context, client client.AzureClient, virtualMachines <-chan interface{}) <-chan azureWrapper[models.VirtualMachineRoleAssignments] {
-	var (
-		out     = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-
-		for result := range pipeline.OrDone(ctx.Done(), virtualMachines) {
-			if virtualMachine, ok := result.(AzureWrapper).Data.(models.VirtualMachine); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating virtual machine role assignments", "result", result)
-				return
-			} else {
-				ids <- virtualMachine.Id
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				var (
-					virtualMachineRoleAssignments = models.VirtualMachineRoleAssignments{
-						VirtualMachineId: id,
-					}
-					count = 0
-				)
-				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing role assignments for this virtual machine", "virtualMachineId", id)
-					} else {
-						virtualMachineRoleAssignment := models.VirtualMachineRoleAssignment{
-							VirtualMachineId: item.ParentId,
-							RoleAssignment:   item.Ok,
-						}
-						log.V(2).Info("found virtual machine role assignment", "virtualMachineRoleAssignment", virtualMachineRoleAssignment)
-						count++
-						virtualMachineRoleAssignments.RoleAssignments = append(virtualMachineRoleAssignments.RoleAssignments, virtualMachineRoleAssignment)
-					}
-				}
-				out <- NewAzureWrapper(enums.KindAZVMRoleAssignment, virtualMachineRoleAssignments)
-				log.V(1).Info("finished listing virtual machine role assignments", "virtualMachineId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all virtual machine role assignments")
-	}()
-
-	return out
-}
@@ -18,16 +18,11 @@
 package cmd
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/constants"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/internal"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -43,37 +38,18 @@ var listVirtualMachineUserAccessAdminsCmd = &cobra.Command{
 }
 
 func listVirtualMachineUserAccessAdminsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
 	azClient := connectAndCreateClient()
 	log.Info("collecting azure virtual machine user access admins...")
 	start := time.Now()
-	subscriptions := listSubscriptions(ctx, azClient)
-	vms := listVirtualMachines(ctx, azClient, subscriptions)
-	vmRoleAssignments := listVirtualMachineRoleAssignments(ctx, azClient, vms)
-	stream := listVirtualMachineUserAccessAdmins(ctx, vmRoleAssignments)
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	vms := collector.ListVirtualMachines(ctx, azClient, subscriptions)
+	vmRoleAssignments := collector.ListVirtualMachineRoleAssignments(ctx, azClient, vms)
+	stream := collector.ListVirtualMachineUserAccessAdmins(ctx, vmRoleAssignments)
 	outputStream(ctx, stream)
 	duration := time.Since(start)
 	log.Info("collection completed", "duration", duration.String())
 }
-
-func listVirtualMachineUserAccessAdmins(
-	ctx context.Context,
-	roleAssignments <-chan azureWrapper[models.VirtualMachineRoleAssignments],
-) <-chan any {
-	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.VirtualMachineRoleAssignments]) any {
-		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, vmRoleAssignmentFilter(constants.UserAccessAdminRoleID))
-		uaas := internal.Map(filteredAssignments, func(ra models.VirtualMachineRoleAssignment) models.VirtualMachineUserAccessAdmin {
-			return models.VirtualMachineUserAccessAdmin{
-				VirtualMachineId: ra.VirtualMachineId,
-				UserAccessAdmin:  ra.RoleAssignment,
-			}
-		})
-		return NewAzureWrapper(enums.KindAZVMUserAccessAdmin, models.VirtualMachineUserAccessAdmins{
-			VirtualMachineId: ra.Data.VirtualMachineId,
-			UserAccessAdmins: uaas,
-		})
-	})
-}
@@ -0,0 +1,53 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	listRootCmd.AddCommand(listVMScaleSetManagedIdentitiesCmd)
+}
+
+var listVMScaleSetManagedIdentitiesCmd = &cobra.Command{
+	Use:          "vm-scale-set-managed-identities",
+	Long:         "Lists Azure VM Scale Set Managed Identities",
+	Run:          listVMScaleSetManagedIdentitiesCmdImpl,
+	SilenceUsage: true,
+}
+
+func listVMScaleSetManagedIdentitiesCmdImpl(cmd *cobra.Command, args []string) {
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
+	defer gracefulShutdown(stop)
+
+	log.V(1).Info("testing connections")
+	azClient := connectAndCreateClient()
+	log.Info("collecting azure virtual machine scale set managed identities...")
+	start := time.Now()
+	subscriptions := collector.ListSubscriptions(ctx, azClient)
+	stream := collector.ListVMScaleSetManagedIdentities(ctx, collector.ListVMScaleSets(ctx, azClient, subscriptions))
+	outputStream(ctx, stream)
+	duration := time.Since(start)
+	log.Info("collection completed", "duration", duration.String())
+}
@@ -18,17 +18,11 @@
 package cmd
 
 import (
-	"context"
-	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 
-	"github.com/bloodhoundad/azurehound/v2/client"
-	"github.com/bloodhoundad/azurehound/v2/enums"
-	"github.com/bloodhoundad/azurehound/v2/models"
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +38,7 @@ var listVMScaleSetsCmd = &cobra.Command{
 }
 
 func listVMScaleSetsCmdImpl(cmd *cobra.Command, args []string) {
-	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(withCollectionOptions(cmd.Context()), os.Interrupt, os.Kill)
 	defer gracefulShutdown(stop)
 
 	log.V(1).Info("testing connections")
@@ -55,69 +49,9 @@ func listVMScaleSetsCmdImpl(cmd *cobra.Command, args []string) {
 	} else {
 		log.Info("collecting azure virtual machine scale sets...")
 		start := time.Now()
-		stream := listVMScaleSets(ctx, azClient, listSubscriptions(ctx, azClient))
+		stream := collector.ListVMScaleSets(ctx, azClient, collector.ListSubscriptions(ctx, azClient))
 		outputStream(ctx, stream)
 		duration := time.Since(start)
 		log.Info("collection completed", "duration", duration.String())
 	}
 }
-
-func listVMScaleSets(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
-	var (
-		out     = make(chan interface{})
-		ids     = make(chan string)
-		streams = pipeline.Demux(ctx.Done(), ids, 25)
-		wg      sync.WaitGroup
-	)
-
-	go func() {
-		defer close(ids)
-		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
-			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
-				log.Error(fmt.Errorf("failed type assertion"), "unable to continue enumerating virtual machine scale sets", "result", result)
-				return
-			} else {
-				ids <- subscription.SubscriptionId
-			}
-		}
-	}()
-
-	wg.Add(len(streams))
-	for i := range streams {
-		stream := streams[i]
-		go func() {
-			defer wg.Done()
-			for id := range stream {
-				count := 0
-				for item := range client.ListAzureVMScaleSets(ctx, id, false) {
-					if item.Error != nil {
-						log.Error(item.Error, "unable to continue processing virtual machine scale sets for this subscription", "subscriptionId", id)
-					} else {
-						resourceGroupId := item.Ok.ResourceGroupId()
-						vmScaleSet := models.VMScaleSet{
-							VMScaleSet:      item.Ok,
-							SubscriptionId:  item.SubscriptionId,
-							ResourceGroupId: resourceGroupId,
-							TenantId:        client.TenantInfo().TenantId,
-						}
-						log.V(2).Info("found virtual machine scale set", "vmScaleSet", vmScaleSet)
-						count++
-						out <- AzureWrapper{
-							Kind: enums.KindAZVMScaleSet,
-							Data: vmScaleSet,
-						}
-					}
-				}
-				log.V(1).Info("finished listing virtual machine scale sets", "subscriptionId", id, "count", count)
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-		log.Info("finished listing all virtual machine scale sets")
-	}()
-
-	return out
-}
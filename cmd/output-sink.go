@@ -0,0 +1,121 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	client_config "github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+)
+
+// the longest wait between retries of an --output-blob request
+const outputBlobMaxBackoff = time.Minute
+
+// azureClientConfig is the configuration the Azure client was created with, whose credential --output-blob signs in
+// with when it has no other
+var azureClientConfig client_config.Config
+
+// newOutputSink opens the sink selected by --output-sink for a json collection
+func newOutputSink(ctx context.Context) (sinks.OutputSink, error) {
+	if sink := config.OutputSink.Value().(string); sink == enums.OutputSinkHttp {
+		return newHTTPOutputSink(ctx)
+	} else if sink == enums.OutputSinkBlob {
+		return newBlobOutputSink(ctx)
+	} else if path := config.OutputFile.Value().(string); sink == enums.OutputSinkFile && !sinks.IsStdout(path) {
+		return sinks.NewFileSink(ctx, path)
+	} else {
+		return sinks.NewConsoleSink(ctx, config.Compress.Value().(bool))
+	}
+}
+
+// newHTTPOutputSink retries failed requests as requests to Azure are retried
+func newHTTPOutputSink(ctx context.Context) (sinks.OutputSink, error) {
+	// already validated by listPersistentPreRunE
+	header, _ := outputSinkHeader()
+	maxBackoff, _ := durationValue(config.RetryMaxBackoff)
+	sinkConfig := sinks.HTTPSinkConfig{
+		RemoteConfig: sinks.RemoteConfig{
+			MaxRetries: config.MaxRetries.Value().(int),
+			MaxBackoff: maxBackoff,
+			OnRetry: func(err error, backoff time.Duration) {
+				log.Info("warning: posting to the output sink failed; retrying", "error", err.Error(), "backoff", backoff.String())
+			},
+		},
+		Url:       config.OutputSinkUrl.Value().(string),
+		Header:    header,
+		BatchSize: config.OutputSinkBatchSize.Value().(int),
+	}
+
+	log.Info("posting collected data to the output sink", "url", sinks.RedactUrl(sinkConfig.Url), "batchSize", sinkConfig.BatchSize)
+	return sinks.NewHTTPSink(ctx, sinkConfig)
+}
+
+// newBlobOutputSink signs in to Azure Storage with the credential used for collection unless the upload is authorized
+// by a shared access signature or the account key. Uploads are retried on their own schedule so a slow storage
+// account doesn't eat into the retries of collection.
+func newBlobOutputSink(ctx context.Context) (sinks.OutputSink, error) {
+	sinkConfig := sinks.BlobSinkConfig{
+		RemoteConfig: sinks.RemoteConfig{
+			MaxRetries: config.OutputBlobMaxRetries.Value().(int),
+			MaxBackoff: outputBlobMaxBackoff,
+			OnRetry: func(err error, backoff time.Duration) {
+				log.Info("warning: uploading to azure blob storage failed; retrying", "error", err.Error(), "backoff", backoff.String())
+			},
+		},
+		Url:        config.OutputBlob.Value().(string),
+		Name:       fmt.Sprintf("azurehound-%d.json", time.Now().Unix()),
+		AccountKey: config.OutputBlobAccountKey.Value().(string),
+		Compress:   config.Compress.Value().(bool),
+	}
+	if sinkConfig.Compress {
+		sinkConfig.Name += sinks.CompressedSuffix
+	}
+
+	if sinkConfig.AccountKey == "" && !sinks.HasSharedAccessSignature(sinkConfig.Url) {
+		if source, err := rest.NewTokenSource(constants.StorageResourceUrl, azureClientConfig); err != nil {
+			return nil, err
+		} else {
+			sinkConfig.Token = source.Authorization
+		}
+	}
+
+	log.Info("uploading collected data to azure blob storage", "url", sinks.RedactUrl(sinkConfig.Url))
+	return sinks.NewBlobSink(ctx, sinkConfig)
+}
+
+// outputSinkHeader parses --output-sink-header; values are left out of errors since they are often credentials
+func outputSinkHeader() (http.Header, error) {
+	header := http.Header{}
+	for i, value := range config.OutputSinkHeaders.Value().([]string) {
+		if name, value, ok := strings.Cut(value, ":"); !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("--output-sink-header %d is not of the form \"Name: value\"", i+1)
+		} else {
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	return header, nil
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+// BloodHound Enterprise rejects signed requests whose RequestDate is too far from its own clock
+const maxClockSkew = 5 * time.Minute
+
+var ErrPreflightFailed = errors.New("bloodhound enterprise preflight failed")
+
+// preflight verifies that the BloodHound Enterprise instance is reachable and accepts this client's credentials
+// before the start loop begins. Each step logs a pass or an actionable failure; the first failure is returned.
+//
+// httpClient is used for the unsigned reachability and clock checks, bheClient for the signed request.
+func preflight(ctx context.Context, bheUrl url.URL, httpClient *http.Client, bheClient *http.Client) error {
+	var (
+		host     = bheUrl.Hostname()
+		proxyUrl = config.RedactedProxyUrl()
+	)
+
+	log.Info("running bloodhound enterprise preflight checks", "instance", bheUrl.String())
+
+	// DNS resolution is performed by the proxy when one is configured
+	if proxyUrl != "" {
+		log.Info("preflight: skipping dns resolution; requests are sent through the configured proxy", "proxy", proxyUrl)
+	} else if addrs, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		log.Error(err, fmt.Sprintf("preflight: dns resolution failed; unable to resolve %s, verify the instance url and this host's dns settings", host))
+		return fmt.Errorf("%w: dns resolution: %w", ErrPreflightFailed, err)
+	} else {
+		log.Info("preflight: dns resolution passed", "host", host, "addresses", addrs)
+	}
+
+	if addr, err := dial(bheUrl.String()); err != nil {
+		log.Error(err, fmt.Sprintf("preflight: tcp connection failed; unable to connect to %s, verify that outbound traffic to the instance is allowed by firewalls and proxies", bheUrl.Host))
+		return fmt.Errorf("%w: tcp connection: %w", ErrPreflightFailed, err)
+	} else {
+		log.Info("preflight: tcp connection passed", "localAddress", addr)
+	}
+
+	if skew, err := checkClockSkew(ctx, bheUrl, httpClient); err != nil {
+		if isTLSError(err) {
+			log.Error(err, "preflight: tls handshake failed; the instance certificate is not trusted by this host, verify the system certificate store and any tls inspecting proxies")
+			return fmt.Errorf("%w: tls handshake: %w", ErrPreflightFailed, err)
+		} else {
+			log.Error(err, "preflight: unable to reach the instance over http; verify the instance url")
+			return fmt.Errorf("%w: http request: %w", ErrPreflightFailed, err)
+		}
+	} else if skew > maxClockSkew || skew < -maxClockSkew {
+		err := fmt.Errorf("clock skew of %s exceeds tolerance of %s", skew.Round(time.Second), maxClockSkew)
+		log.Error(err, fmt.Sprintf("preflight: clock check failed; %s; signatures will be rejected, synchronize this host's clock", err))
+		return fmt.Errorf("%w: %v", ErrPreflightFailed, err)
+	} else {
+		log.Info("preflight: tls and clock checks passed", "skew", skew.Round(time.Second).String())
+	}
+
+	if err := checkApiVersion(ctx, bheUrl, httpClient); err != nil {
+		log.Error(err, "preflight: api check failed")
+		return fmt.Errorf("%w: api check: %w", ErrPreflightFailed, err)
+	} else {
+		log.Info("preflight: api check passed")
+	}
+
+	if err := checkSignedRequest(ctx, bheUrl, bheClient); err != nil {
+		log.Error(err, "preflight: signed request failed")
+		return fmt.Errorf("%w: signed request: %w", ErrPreflightFailed, err)
+	} else {
+		log.Info("preflight: signed request passed")
+	}
+
+	return nil
+}
+
+// checkClockSkew makes an unsigned request to the instance and compares the server's Date header to the local
+// clock. A positive skew means the local clock is ahead of the server.
+func checkClockSkew(ctx context.Context, bheUrl url.URL, httpClient *http.Client) (time.Duration, error) {
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, bheUrl.String(), nil); err != nil {
+		return 0, err
+	} else if res, err := httpClient.Do(req); err != nil {
+		return 0, err
+	} else {
+		defer res.Body.Close()
+		if date := res.Header.Get("Date"); date == "" {
+			return 0, fmt.Errorf("response from %s did not include a Date header", bheUrl.String())
+		} else if serverTime, err := http.ParseTime(date); err != nil {
+			return 0, fmt.Errorf("unable to parse Date header %q: %w", date, err)
+		} else {
+			return time.Since(serverTime), nil
+		}
+	}
+}
+
+// checkApiVersion makes an unsigned request to the version endpoint to verify that the api is served under the
+// instance url, which is how a missing or mistyped path prefix shows up.
+func checkApiVersion(ctx context.Context, bheUrl url.URL, httpClient *http.Client) error {
+	endpoint := bheEndpoint(bheUrl, "/api/version")
+	if req, err := rest.NewRequest(ctx, http.MethodGet, endpoint, nil, nil, nil); err != nil {
+		return err
+	} else if res, err := httpClient.Do(req); err != nil {
+		return err
+	} else {
+		defer res.Body.Close()
+		switch {
+		case res.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("%s: %s was not found, verify that the instance url includes the path prefix bloodhound enterprise is served under, if any", res.Status, endpoint)
+		case res.StatusCode >= http.StatusInternalServerError:
+			return fmt.Errorf("received unexpected response code from %v: %s", endpoint, res.Status)
+		default:
+			// the version endpoint may require authentication, which is verified by the signed request
+			return nil
+		}
+	}
+}
+
+func checkSignedRequest(ctx context.Context, bheUrl url.URL, bheClient *http.Client) error {
+	endpoint := bheEndpoint(bheUrl, "/api/v1/clients/availabletasks")
+	if req, err := rest.NewRequest(ctx, http.MethodGet, endpoint, nil, nil, nil); err != nil {
+		return err
+	} else if res, err := bheClient.Do(req); err != nil {
+		return err
+	} else {
+		defer res.Body.Close()
+		switch {
+		case res.StatusCode == http.StatusUnauthorized:
+			return fmt.Errorf("%w, %s: the token id or token was rejected, verify them or generate a new token for this client in bloodhound enterprise", rest.ErrUnauthorized, res.Status)
+		case res.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("%w, %s: the token is not authorized for client operations, verify that it belongs to an azurehound client", rest.ErrForbidden, res.Status)
+		case res.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("%s: %s was not found, verify that the instance url points at bloodhound enterprise", res.Status, endpoint)
+		case res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest:
+			return fmt.Errorf("received unexpected response code from %v: %s", endpoint, res.Status)
+		default:
+			return nil
+		}
+	}
+}
+
+func isTLSError(err error) bool {
+	var (
+		certErr      *tls.CertificateVerificationError
+		unknownAuth  x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordHdrErr tls.RecordHeaderError
+	)
+	return errors.As(err, &certErr) ||
+		errors.As(err, &unknownAuth) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &recordHdrErr)
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	setupLogger()
+}
+
+func newPreflightServer(date func() time.Time, tasksStatus int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date().UTC().Format(http.TimeFormat))
+		if r.URL.Path == "/api/v1/clients/availabletasks" {
+			w.WriteHeader(tasksStatus)
+			w.Write([]byte("[]"))
+		}
+	}))
+}
+
+func TestPreflight(t *testing.T) {
+	server := newPreflightServer(time.Now, http.StatusOK)
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := preflight(context.Background(), *bheUrl, server.Client(), server.Client()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPreflightClockSkew(t *testing.T) {
+	server := newPreflightServer(func() time.Time { return time.Now().Add(-12 * time.Minute) }, http.StatusOK)
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := preflight(context.Background(), *bheUrl, server.Client(), server.Client()); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !errors.Is(err, ErrPreflightFailed) {
+		t.Errorf("got %v, want %v", err, ErrPreflightFailed)
+	} else if !strings.Contains(err.Error(), "clock skew of 12m") {
+		t.Errorf("expected a clock skew diagnostic, got %v", err)
+	}
+}
+
+func TestPreflightUnauthorized(t *testing.T) {
+	server := newPreflightServer(time.Now, http.StatusUnauthorized)
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := preflight(context.Background(), *bheUrl, server.Client(), server.Client()); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "token id or token was rejected") {
+		t.Errorf("expected a credentials diagnostic, got %v", err)
+	} else if code := exitCode(ExitCodeFailure, err); code != ExitCodeAuthFailure {
+		t.Errorf("got exit code %v, want %v", code, ExitCodeAuthFailure)
+	}
+}
+
+func TestPreflightUntrustedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// the default client does not trust the test server's certificate
+	bheUrl, _ := url.Parse(server.URL)
+	if err := preflight(context.Background(), *bheUrl, &http.Client{}, server.Client()); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "tls handshake") {
+		t.Errorf("expected a tls diagnostic, got %v", err)
+	}
+}
+
+func TestPreflightUnreachable(t *testing.T) {
+	server := newPreflightServer(time.Now, http.StatusOK)
+	bheUrl, _ := url.Parse(server.URL)
+	server.Close()
+
+	if err := preflight(context.Background(), *bheUrl, http.DefaultClient, http.DefaultClient); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "tcp connection") {
+		t.Errorf("expected a tcp diagnostic, got %v", err)
+	} else if code := exitCode(ExitCodeFailure, err); code != ExitCodeNetworkFailure {
+		t.Errorf("got exit code %v, want %v", code, ExitCodeNetworkFailure)
+	}
+}
+
+func TestPreflightPathPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		switch r.URL.Path {
+		case "/bloodhound", "/bloodhound/api/version":
+		case "/bloodhound/api/v1/clients/availabletasks":
+			w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	if bheUrl, err := parseBHEUrl(server.URL + "/bloodhound/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := preflight(context.Background(), *bheUrl, server.Client(), server.Client()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// without the prefix the api is not found
+	if bheUrl, err := parseBHEUrl(server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := preflight(context.Background(), *bheUrl, server.Client(), server.Client()); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "/api/version was not found") {
+		t.Errorf("expected a path prefix diagnostic, got %v", err)
+	}
+}
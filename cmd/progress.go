@@ -0,0 +1,184 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// the progress of the running collection, if it reports any
+var currentProgress atomic.Pointer[runProgress]
+
+// runProgress tracks a collection run for the status snapshot printed when the process receives one of
+// progressSignals and for the progress reported to BloodHound Enterprise. A nil progress ignores every call.
+type runProgress struct {
+	started     time.Time
+	batchesSent int64
+	mutex       sync.Mutex
+	counts      map[enums.Kind]int
+	active      map[string]activeCollector
+}
+
+type activeCollector struct {
+	started time.Time
+	stage   string
+}
+
+// trackProgress starts tracking the progress of a collection run; collectors started after it is called report to it
+func trackProgress() *runProgress {
+	progress := &runProgress{
+		started: time.Now(),
+		counts:  map[enums.Kind]int{},
+		active:  map[string]activeCollector{},
+	}
+	currentProgress.Store(progress)
+	return progress
+}
+
+// observe counts the items of stream by kind as they pass through
+func (s *runProgress) observe(ctx context.Context, stream <-chan interface{}) <-chan interface{} {
+	if s == nil {
+		return stream
+	}
+	return pipeline.Map(ctx.Done(), stream, func(item interface{}) interface{} {
+		if wrapper, ok := item.(collector.Wrapper); ok {
+			s.mutex.Lock()
+			s.counts[wrapper.Untyped().Kind]++
+			s.mutex.Unlock()
+		}
+		return item
+	})
+}
+
+func (s *runProgress) CollectorStarted(name, stage string) {
+	if s != nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.active[name] = activeCollector{started: time.Now(), stage: stage}
+	}
+}
+
+func (s *runProgress) CollectorStopped(name string) {
+	if s != nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.active, name)
+	}
+}
+
+// batchSent counts an ingest batch that reached BloodHound Enterprise
+func (s *runProgress) batchSent() {
+	if s != nil {
+		atomic.AddInt64(&s.batchesSent, 1)
+	}
+}
+
+// report returns the progress of the run in the form it is reported to BloodHound Enterprise
+func (s *runProgress) report() models.JobProgressRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var (
+		request = models.JobProgressRequest{
+			Counts:      map[string]int{},
+			Stages:      []string{},
+			BatchesSent: int(atomic.LoadInt64(&s.batchesSent)),
+		}
+		stages = map[string]struct{}{}
+	)
+	for kind, count := range s.counts {
+		request.Counts[string(kind)] = count
+		request.Total += count
+	}
+	for _, collector := range s.active {
+		if _, ok := stages[collector.stage]; !ok {
+			stages[collector.stage] = struct{}{}
+			request.Stages = append(request.Stages, collector.stage)
+		}
+	}
+	sort.Strings(request.Stages)
+	return request
+}
+
+// write prints a snapshot of the run: how long it has been running, the objects collected so far by kind and the
+// collectors whose streams have not been drained yet
+func (s *runProgress) write(w io.Writer, now time.Time) error {
+	s.mutex.Lock()
+	var (
+		total     = 0
+		kinds     = make([]enums.Kind, 0, len(s.counts))
+		collector = make([]string, 0, len(s.active))
+		table     = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	)
+	for kind, count := range s.counts {
+		kinds = append(kinds, kind)
+		total += count
+	}
+	for name := range s.active {
+		collector = append(collector, name)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	sort.Strings(collector)
+
+	fmt.Fprintf(table, "collection running for %s, %d objects collected\n", now.Sub(s.started).Round(time.Second), total)
+	for _, kind := range kinds {
+		fmt.Fprintf(table, "  %s\t%d\n", kind, s.counts[kind])
+	}
+	fmt.Fprintf(table, "%d active collectors\n", len(collector))
+	for _, name := range collector {
+		fmt.Fprintf(table, "  %s\trunning for %s\n", name, now.Sub(s.active[name].started).Round(time.Second))
+	}
+	s.mutex.Unlock()
+	return table.Flush()
+}
+
+// reportProgressOnSignal prints a snapshot of progress to stderr each time the process receives one of
+// progressSignals, until ctx is done. The signals don't stop the collection.
+func reportProgressOnSignal(ctx context.Context, progress *runProgress) {
+	if len(progressSignals) == 0 {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, progressSignals...)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-signals:
+				progress.write(os.Stderr, time.Now())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+func TestRunProgress(t *testing.T) {
+	progress := trackProgress()
+	t.Cleanup(func() { currentProgress.Store(nil) })
+
+	var (
+		ctx   = context.Background()
+		in    = make(chan interface{})
+		users = progress.observe(ctx, in)
+	)
+	progress.CollectorStarted("users", "ad objects")
+	progress.CollectorStarted("subscription-role-assignments", "role assignments")
+
+	go func() {
+		in <- collector.AzureWrapper{Kind: enums.KindAZUser}
+		in <- collector.AzureWrapper{Kind: enums.KindAZUser}
+	}()
+	<-users
+	<-users
+
+	var out bytes.Buffer
+	if err := progress.write(&out, progress.started.Add(90*time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if actual := out.String(); !strings.Contains(actual, "running for 1m30s, 2 objects collected") || !strings.Contains(actual, "AZUser") || !strings.Contains(actual, "2 active collectors") || !strings.Contains(actual, "  users") {
+		t.Errorf("unexpected status:\n%s", actual)
+	} else if report := progress.report(); report.Total != 2 || strings.Join(report.Stages, ",") != "ad objects,role assignments" {
+		t.Errorf("unexpected progress report: %+v", report)
+	}
+
+	close(in)
+	for range users {
+	}
+	progress.CollectorStopped("users")
+
+	out.Reset()
+	if err := progress.write(&out, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if actual := out.String(); !strings.Contains(actual, "1 active collectors") || strings.Contains(actual, "  users") {
+		t.Errorf("unexpected status:\n%s", actual)
+	}
+}
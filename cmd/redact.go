@@ -0,0 +1,186 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// The salt is a secret; without one the hash of a known user principal name could simply be looked up
+func validateRedactPii() error {
+	if config.RedactPii.Value().(bool) && config.RedactSalt.Value().(string) == "" {
+		return fmt.Errorf("--%s requires --%s", config.RedactPii.Name, config.RedactSalt.Name)
+	} else {
+		return nil
+	}
+}
+
+// Fields holding the names of people, which --redact-pii hashes wherever they are found
+var redactedNames = map[string]bool{
+	"displayName":              true,
+	"givenName":                true,
+	"mailNickname":             true,
+	"onPremisesSamAccountName": true,
+	"principalDisplayName":     true,
+	"principalIdDisplayName":   true,
+	"samAccountName":           true,
+	"surname":                  true,
+}
+
+// Fields holding user principal names and mail addresses, optionally prefixed by an address type e.g. SMTP:
+var redactedAddresses = map[string]bool{
+	"imAddresses":                 true,
+	"mail":                        true,
+	"onPremisesUserPrincipalName": true,
+	"otherMails":                  true,
+	"proxyAddresses":              true,
+	"userPrincipalName":           true,
+}
+
+// The names of role definitions describe permissions rather than people and are needed to make sense of the graph
+var unredactedKinds = map[enums.Kind]bool{
+	enums.KindAZRole: true,
+}
+
+// redactPii replaces the personally identifiable fields of each item with a hash salted with salt. Hashes are stable
+// for a salt so runs can be compared, and ids are left as they are so relationships between objects are preserved.
+func redactPii(ctx context.Context, in <-chan interface{}, salt []byte) <-chan interface{} {
+	redactor := piiRedactor{salt: salt}
+	redacted := pipeline.Map(ctx.Done(), in, func(item interface{}) interface{} {
+		if redacted, err := redactor.redact(item); err != nil {
+			// an item that can't be redacted is dropped rather than written as it is
+			log.Error(err, "unable to redact item, skipping", "kind", itemKind(item))
+			return nil
+		} else {
+			return redacted
+		}
+	})
+	return pipeline.Filter(ctx.Done(), redacted, func(item interface{}) bool { return item != nil })
+}
+
+func itemKind(item interface{}) enums.Kind {
+	if wrapper, ok := item.(collector.Wrapper); ok {
+		return wrapper.Untyped().Kind
+	} else {
+		return ""
+	}
+}
+
+type piiRedactor struct {
+	salt []byte
+}
+
+// redact returns a copy of a wrapped item whose data has been redacted; anything else is returned as it is
+func (s piiRedactor) redact(item interface{}) (interface{}, error) {
+	var wrapper struct {
+		Kind enums.Kind      `json:"kind"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	if _, ok := item.(collector.Wrapper); !ok {
+		return item, nil
+	} else if unredactedKinds[itemKind(item)] {
+		return item, nil
+	} else if data, err := json.Marshal(item); err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(wrapper.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	} else if data, err := json.Marshal(s.walk(value, "")); err != nil {
+		return nil, err
+	} else {
+		return collector.AzureWrapper{Kind: wrapper.Kind, Data: json.RawMessage(data)}, nil
+	}
+}
+
+// walk redacts every value nested in value, where field is the name of the field value was found in
+func (s piiRedactor) walk(value interface{}, field string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = s.walk(child, key)
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = s.walk(child, field)
+		}
+		return value
+	case string:
+		if value == "" {
+			return value
+		} else if redactedNames[field] {
+			return s.hash(value)
+		} else if redactedAddresses[field] {
+			return s.address(value)
+		} else {
+			return value
+		}
+	default:
+		return value
+	}
+}
+
+// address hashes the local part of a user principal name or mail address, keeping any address type prefix, the
+// domain and the #EXT# marker of guest user principal names. Addresses are case-insensitive so they are hashed lower
+// cased.
+func (s piiRedactor) address(value string) string {
+	prefix := ""
+	if i := strings.Index(value, ":"); i >= 0 && !strings.Contains(value[:i], "@") {
+		prefix, value = value[:i+1], value[i+1:]
+	}
+
+	var (
+		at     = strings.LastIndex(value, "@")
+		local  = value
+		domain = ""
+		marker = ""
+	)
+	if at >= 0 {
+		local, domain = value[:at], value[at:]
+	}
+	if guest := strings.Index(local, "#EXT#"); guest >= 0 {
+		local, marker = local[:guest], local[guest:]
+	}
+
+	return prefix + s.hash(strings.ToLower(local)) + marker + domain
+}
+
+func (s piiRedactor) hash(value string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(value))
+	return "redacted-" + hex.EncodeToString(mac.Sum(nil)[:12])
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func init() {
+	setupLogger()
+}
+
+func TestRedactPii(t *testing.T) {
+	var (
+		ctx = context.Background()
+		in  = make(chan interface{}, 4)
+	)
+	in <- collector.NewAzureWrapper(enums.KindAZUser, models.User{
+		User: azure.User{
+			DirectoryObject:   azure.DirectoryObject{Id: "user-id"},
+			DisplayName:       "Jane Doe",
+			UserPrincipalName: "jdoe@contoso.com",
+			Mail:              "JDoe@contoso.com",
+			ProxyAddresses:    []string{"SMTP:jdoe@contoso.com"},
+		},
+		TenantId: "tenant-id",
+	})
+	in <- collector.AzureWrapper{Kind: enums.KindAZGroupMember, Data: map[string]interface{}{
+		"groupId": "group-id",
+		"members": []interface{}{map[string]interface{}{"groupId": "group-id", "member": map[string]interface{}{"id": "guest-id", "userPrincipalName": "jane_fabrikam.com#EXT#@contoso.com", "displayName": "Jane Doe"}}},
+	}}
+	in <- collector.AzureWrapper{Kind: enums.KindAZRole, Data: map[string]interface{}{"id": "role-id", "displayName": "Global Administrator"}}
+	in <- "not wrapped"
+	close(in)
+
+	var items []string
+	for item := range redactPii(ctx, in, []byte("salt")) {
+		if bytes, err := json.Marshal(item); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else {
+			items = append(items, string(bytes))
+		}
+	}
+
+	redactor := piiRedactor{salt: []byte("salt")}
+	var user struct {
+		Data struct {
+			Id                string   `json:"id"`
+			DisplayName       string   `json:"displayName"`
+			UserPrincipalName string   `json:"userPrincipalName"`
+			Mail              string   `json:"mail"`
+			ProxyAddresses    []string `json:"proxyAddresses"`
+			TenantId          string   `json:"tenantId"`
+		} `json:"data"`
+	}
+	if len(items) != 4 {
+		t.Fatalf("got %v items, want 4", len(items))
+	} else if err := json.Unmarshal([]byte(items[0]), &user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if user.Data.Id != "user-id" || user.Data.TenantId != "tenant-id" {
+		t.Errorf("expected ids to be preserved: %+v", user.Data)
+	} else if user.Data.DisplayName != redactor.hash("Jane Doe") {
+		t.Errorf("got display name %v, want %v", user.Data.DisplayName, redactor.hash("Jane Doe"))
+	} else if want := redactor.hash("jdoe") + "@contoso.com"; user.Data.UserPrincipalName != want || user.Data.Mail != want {
+		t.Errorf("got %v and %v, want %v", user.Data.UserPrincipalName, user.Data.Mail, want)
+	} else if want := "SMTP:" + redactor.hash("jdoe") + "@contoso.com"; len(user.Data.ProxyAddresses) != 1 || user.Data.ProxyAddresses[0] != want {
+		t.Errorf("got %v, want %v", user.Data.ProxyAddresses, want)
+	}
+
+	if want := redactor.hash("jane_fabrikam.com") + "#EXT#@contoso.com"; !strings.Contains(items[1], want) || !strings.Contains(items[1], "guest-id") {
+		t.Errorf("expected nested members to be redacted: %s", items[1])
+	} else if strings.Contains(items[1], "Jane Doe") || strings.Contains(items[1], "fabrikam") {
+		t.Errorf("expected no personal data to remain: %s", items[1])
+	} else if !strings.Contains(items[2], "Global Administrator") {
+		t.Errorf("expected role names to be preserved: %s", items[2])
+	} else if items[3] != `"not wrapped"` {
+		t.Errorf("got %v, want %v", items[3], `"not wrapped"`)
+	}
+}
+
+func TestRedactPiiHash(t *testing.T) {
+	var (
+		redactor = piiRedactor{salt: []byte("salt")}
+		other    = piiRedactor{salt: []byte("other")}
+	)
+
+	if redactor.hash("jdoe") != redactor.hash("jdoe") {
+		t.Error("expected hashes to be stable for a salt")
+	} else if redactor.hash("jdoe") == other.hash("jdoe") {
+		t.Error("expected hashes to differ between salts")
+	} else if redactor.address("JDoe@Contoso.com") != redactor.hash("jdoe")+"@Contoso.com" {
+		t.Errorf("got %v, want %v", redactor.address("JDoe@Contoso.com"), redactor.hash("jdoe")+"@Contoso.com")
+	}
+}
+
+func TestValidateRedactPii(t *testing.T) {
+	t.Cleanup(func() {
+		config.RedactPii.Set(false)
+		config.RedactSalt.Set("")
+	})
+
+	config.RedactPii.Set(true)
+	if err := validateRedactPii(); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+
+	config.RedactSalt.Set("salt")
+	if err := validateRedactPii(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -31,7 +31,8 @@ var (
 		Long:    constants.Description,
 		Version: constants.Version,
 	}
-	log logr.Logger
+	// discards everything until the command or an embedding program sets up logging
+	log = logr.Discard()
 )
 
 func init() {
@@ -0,0 +1,47 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/spf13/cobra"
+)
+
+// sampleSize is the --sample limit of objects collected of each kind, or 0 when the collection is complete
+var sampleSize int
+
+// setupSample only allows --sample on list itself, which collects everything through collectTenant; a sampled
+// collection can't be resumed into a complete one so it doesn't record a checkpoint either
+func setupSample(cmd *cobra.Command) error {
+	sampleSize = 0
+	if sample := config.Sample.Value().(int); sample == 0 {
+		return nil
+	} else if sample < 0 {
+		return fmt.Errorf("--%s must not be negative", config.Sample.Name)
+	} else if cmd.Parent() != rootCmd {
+		return fmt.Errorf("--%s only applies to the list command", config.Sample.Name)
+	} else if config.Checkpoint.Value().(string) != "" {
+		return fmt.Errorf("--%s cannot be combined with --%s", config.Sample.Name, config.Checkpoint.Name)
+	} else {
+		sampleSize = sample
+		log.Info("warning: --sample stops collecting each kind of object after the sample size; the output is marked as sampled and is not a complete collection of the tenant", "sample", sample)
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+func TestSetupSample(t *testing.T) {
+	t.Cleanup(func() {
+		config.Sample.Set(0)
+		config.Checkpoint.Set("")
+		sampleSize = 0
+	})
+
+	config.Sample.Set(5)
+	if err := setupSample(listRootCmd); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if sampleSize != 5 {
+		t.Errorf("got sample size %v, want %v", sampleSize, 5)
+	}
+
+	if err := setupSample(listUsersCmd); err == nil {
+		t.Error("expected --sample on a list subcommand to be rejected")
+	} else if sampleSize != 0 {
+		t.Errorf("got sample size %v, want %v", sampleSize, 0)
+	}
+
+	config.Checkpoint.Set("state.json")
+	if err := setupSample(listRootCmd); err == nil {
+		t.Error("expected --sample with --checkpoint to be rejected")
+	}
+
+	config.Checkpoint.Set("")
+	config.Sample.Set(-1)
+	if err := setupSample(listRootCmd); err == nil {
+		t.Error("expected a negative sample to be rejected")
+	}
+}
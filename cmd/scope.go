@@ -0,0 +1,76 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+// taskScope returns the scope requested by a BloodHound Enterprise task; a part the task does not mention is collected
+// so tasks of instances that predate scoped collection still collect everything
+func taskScope(task models.ClientTask) collector.Scope {
+	scope := collector.FullScope
+	if task.AzureADCollection != nil {
+		scope.AzureAD = *task.AzureADCollection
+	}
+	if task.AzureRMCollection != nil {
+		scope.AzureRM = *task.AzureRMCollection
+	}
+	return scope
+}
+
+// taskKinds returns the kinds requested by a BloodHound Enterprise task, or nil if the task doesn't narrow collection
+// to particular kinds. Kinds this version of AzureHound doesn't know are returned separately so they can be reported.
+func taskKinds(task models.ClientTask) (kinds []enums.Kind, unknown []string) {
+	if task.CollectionKinds == nil {
+		return nil, nil
+	}
+
+	known := make(map[enums.Kind]bool)
+	for _, kind := range enums.Kinds() {
+		known[kind] = true
+	}
+
+	kinds = []enums.Kind{}
+	for _, name := range task.CollectionKinds {
+		if kind := enums.Kind(name); known[kind] {
+			kinds = append(kinds, kind)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	return kinds, unknown
+}
+
+// kindNames returns the sorted names of kinds without duplicates
+func kindNames(kinds []enums.Kind) []string {
+	unique := map[enums.Kind]bool{}
+	names := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		if !unique[kind] {
+			unique[kind] = true
+			names = append(names, string(kind))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	client_config "github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func TestTaskScope(t *testing.T) {
+	var (
+		yes = true
+		no  = false
+	)
+
+	if scope := taskScope(models.ClientTask{}); scope != collector.FullScope {
+		t.Errorf("got %+v, want %+v", scope, collector.FullScope)
+	}
+	if scope := taskScope(models.ClientTask{AzureRMCollection: &no}); scope != (collector.Scope{AzureAD: true}) {
+		t.Errorf("got %+v, want only entra id objects", scope)
+	}
+	if scope := taskScope(models.ClientTask{AzureADCollection: &no, AzureRMCollection: &yes}); scope != (collector.Scope{AzureRM: true}) {
+		t.Errorf("got %+v, want only azure resources", scope)
+	}
+
+	var task models.ClientTask
+	if err := json.Unmarshal([]byte(`{"id":1,"azure_ad_collection":true,"azure_rm_collection":false}`), &task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if scope := taskScope(task); scope != (collector.Scope{AzureAD: true}) {
+		t.Errorf("got %+v, want only entra id objects", scope)
+	}
+}
+
+func TestTaskKinds(t *testing.T) {
+	if kinds, unknown := taskKinds(models.ClientTask{}); kinds != nil || unknown != nil {
+		t.Errorf("got %v and %v, want every kind collected", kinds, unknown)
+	}
+	if kinds, unknown := taskKinds(models.ClientTask{CollectionKinds: []string{}}); kinds == nil || len(kinds) != 0 || unknown != nil {
+		t.Errorf("got %v and %v, want no kinds collected", kinds, unknown)
+	}
+
+	kinds, unknown := taskKinds(models.ClientTask{CollectionKinds: []string{"AZGroupMember", "AZVM", "AZWidget"}})
+	if len(kinds) != 2 || kinds[0] != enums.KindAZGroupMember || kinds[1] != enums.KindAZVM {
+		t.Errorf("got kinds %v, want [AZGroupMember AZVM]", kinds)
+	} else if len(unknown) != 1 || unknown[0] != "AZWidget" {
+		t.Errorf("got unknown kinds %v, want [AZWidget]", unknown)
+	}
+
+	if scope := collector.FullScope.Narrow([]enums.Kind{enums.KindAZUser}); scope != (collector.Scope{AzureAD: true}) {
+		t.Errorf("got %+v, want only entra id objects", scope)
+	} else if scope := collector.FullScope.Narrow([]enums.Kind{enums.KindAZVM}); scope != (collector.Scope{AzureRM: true}) {
+		t.Errorf("got %+v, want only azure resources", scope)
+	} else if scope := (collector.Scope{AzureRM: true}).Narrow([]enums.Kind{enums.KindAZUser}); scope != (collector.Scope{}) {
+		t.Errorf("got %+v, want nothing", scope)
+	}
+}
+
+func TestRunTaskAzureADOnly(t *testing.T) {
+	var graphRequests, resourceManagerRequests, ingested int64
+
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&graphRequests, 1)
+		if r.URL.Path == "/v1.0/organization" {
+			w.Write([]byte(`{"value":[{"id":"tenant","displayName":"Tenant"}]}`))
+		} else {
+			w.Write([]byte(`{"value":[]}`))
+		}
+	}))
+	defer graph.Close()
+
+	resourceManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&resourceManagerRequests, 1)
+		t.Errorf("unexpected resource manager request: %s", r.URL.Path)
+		w.Write([]byte(`{"value":[]}`))
+	}))
+	defer resourceManager.Close()
+
+	bhe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/ingest" {
+			var body struct {
+				Data []json.RawMessage `json:"data"`
+			}
+			decodeIngestRequest(r, &body)
+			atomic.AddInt64(&ingested, int64(len(body.Data)))
+			w.WriteHeader(http.StatusAccepted)
+		} else {
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer bhe.Close()
+
+	expires := float64(time.Now().Add(time.Hour).Unix())
+	azClient, err := client.NewClient(client_config.Config{
+		Graph:      graph.URL,
+		GraphToken: testToken(t, map[string]interface{}{"aud": graph.URL, "tid": "tenant", "exp": expires}),
+		Management: resourceManager.URL,
+		ArmToken:   testToken(t, map[string]interface{}{"aud": resourceManager.URL + "/", "tid": "tenant", "exp": expires}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var (
+		no        = false
+		task      = models.ClientTask{Id: 1, AzureRMCollection: &no}
+		bheUrl, _ = url.Parse(bhe.URL)
+		collect   = func(ctx context.Context, options collector.Options) <-chan interface{} {
+			stream, _ := collectTenant(ctx, azClient, options)
+			return stream
+		}
+		batchConfig = pipeline.AdaptiveBatchConfig[interface{}]{MinItems: 1, MaxItems: 256, MaxTimeout: 10 * time.Millisecond}
+	)
+	runTask(context.Background(), *bheUrl, bhe.Client(), task, "tenant", collect, batchConfig, time.Hour)
+
+	if atomic.LoadInt64(&resourceManagerRequests) != 0 {
+		t.Errorf("got %v resource manager requests, want none", resourceManagerRequests)
+	} else if atomic.LoadInt64(&graphRequests) < 2 {
+		t.Error("expected entra id objects to be collected")
+	} else if atomic.LoadInt64(&ingested) == 0 {
+		t.Error("expected the collected tenant to be ingested")
+	}
+}
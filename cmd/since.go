@@ -0,0 +1,43 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+// The point in time set with --since, or the zero time when every ARM resource is collected
+var changedSince time.Time
+
+func loadSince() error {
+	changedSince = time.Time{}
+	if value := config.Since.Value().(string); value == "" {
+		return nil
+	} else if since, err := time.Parse(time.RFC3339, value); err != nil {
+		return fmt.Errorf("--%s expects an RFC 3339 timestamp such as 2024-01-02T15:04:05Z but got %q", config.Since.Name, value)
+	} else if since.After(time.Now()) {
+		return fmt.Errorf("--%s %s is in the future", config.Since.Name, value)
+	} else {
+		log.Info("note: only writing ARM resources changed since this time; role assignments and other relationships of unchanged resources are skipped with them", "since", since.Format(time.RFC3339))
+		changedSince = since
+		return nil
+	}
+}
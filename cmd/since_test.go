@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+func init() {
+	setupLogger()
+}
+
+func TestLoadSince(t *testing.T) {
+	t.Cleanup(func() {
+		config.Since.Set("")
+		changedSince = time.Time{}
+	})
+
+	for _, value := range []string{"yesterday", "2024-01-02", time.Now().Add(time.Hour).Format(time.RFC3339)} {
+		config.Since.Set(value)
+		if err := loadSince(); err == nil {
+			t.Errorf("%s: expected an error but did not receive one", value)
+		}
+	}
+
+	config.Since.Set("2024-01-02T15:04:05+01:00")
+	if err := loadSince(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if want := time.Date(2024, 1, 2, 14, 4, 5, 0, time.UTC); !changedSince.Equal(want) {
+		t.Errorf("got %v, want %v", changedSince, want)
+	}
+
+	config.Since.Set("")
+	if err := loadSince(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if !changedSince.IsZero() {
+		t.Errorf("got %v, want the zero time", changedSince)
+	}
+}
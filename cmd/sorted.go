@@ -0,0 +1,110 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+type sortedItem struct {
+	id   string
+	json string
+}
+
+// sortKey returns the kind and object id of a JSON formatted wrapper. Items without an id, such as the members of a
+// group, sort by their JSON, which is just as deterministic.
+func sortKey(item string) (string, string) {
+	var wrapper struct {
+		Kind string `json:"kind"`
+		Data struct {
+			Id string `json:"id"`
+		} `json:"data"`
+	}
+	// an item that isn't a wrapper of an object still sorts by its JSON, within an empty kind
+	json.Unmarshal([]byte(item), &wrapper)
+	return wrapper.Kind, wrapper.Data.Id
+}
+
+// sortOutput holds the JSON formatted wrappers of stream until it closes and then emits them kind by kind, in order
+// of kind, each kind sorted by object id. Once more than maxItems items of a kind are held, those and the rest of that
+// kind are emitted unsorted as they arrive so a huge kind doesn't exhaust memory.
+func sortOutput(ctx context.Context, stream <-chan string, maxItems int) <-chan string {
+	out := make(chan string)
+
+	send := func(item string) bool {
+		select {
+		case out <- item:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		var (
+			kinds     = map[string][]sortedItem{}
+			unsorted  = map[string]struct{}{}
+			kindNames []string
+		)
+
+		for item := range stream {
+			kind, id := sortKey(item)
+			if _, ok := unsorted[kind]; ok {
+				if !send(item) {
+					return
+				}
+			} else if kinds[kind] = append(kinds[kind], sortedItem{id: id, json: item}); len(kinds[kind]) > maxItems {
+				log.Info("note: too many items to sort; writing the rest of this kind unsorted", "kind", kind, "sortedMaxItems", maxItems)
+				unsorted[kind] = struct{}{}
+				for _, held := range kinds[kind] {
+					if !send(held.json) {
+						return
+					}
+				}
+				delete(kinds, kind)
+			}
+		}
+
+		for kind := range kinds {
+			kindNames = append(kindNames, kind)
+		}
+		sort.Strings(kindNames)
+
+		for _, kind := range kindNames {
+			items := kinds[kind]
+			sort.Slice(items, func(i, j int) bool {
+				if items[i].id != items[j].id {
+					return items[i].id < items[j].id
+				}
+				return items[i].json < items[j].json
+			})
+			for _, item := range items {
+				if !send(item.json) {
+					return
+				}
+			}
+			delete(kinds, kind)
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+func init() {
+	setupLogger()
+}
+
+func collectSorted(items []string, maxItems int) []string {
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, item := range items {
+			in <- item
+		}
+	}()
+
+	var out []string
+	for item := range sortOutput(context.Background(), in, maxItems) {
+		out = append(out, item)
+	}
+	return out
+}
+
+func TestSortOutput(t *testing.T) {
+	items := []string{
+		`{"kind":"AZUser","data":{"id":"3"}}`,
+		`{"kind":"AZGroup","data":{"id":"2"}}`,
+		`{"kind":"AZUser","data":{"id":"1"}}`,
+		`{"kind":"AZGroupMember","data":{"groupId":"b"}}`,
+		`{"kind":"AZGroup","data":{"id":"1"}}`,
+		`{"kind":"AZGroupMember","data":{"groupId":"a"}}`,
+	}
+	want := []string{
+		`{"kind":"AZGroup","data":{"id":"1"}}`,
+		`{"kind":"AZGroup","data":{"id":"2"}}`,
+		`{"kind":"AZGroupMember","data":{"groupId":"a"}}`,
+		`{"kind":"AZGroupMember","data":{"groupId":"b"}}`,
+		`{"kind":"AZUser","data":{"id":"1"}}`,
+		`{"kind":"AZUser","data":{"id":"3"}}`,
+	}
+
+	if actual := collectSorted(items, 10); strings.Join(actual, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", actual, want)
+	}
+}
+
+func TestSortOutputMaxItems(t *testing.T) {
+	items := []string{
+		`{"kind":"AZUser","data":{"id":"3"}}`,
+		`{"kind":"AZGroup","data":{"id":"2"}}`,
+		`{"kind":"AZUser","data":{"id":"2"}}`,
+		`{"kind":"AZUser","data":{"id":"1"}}`,
+		`{"kind":"AZGroup","data":{"id":"1"}}`,
+	}
+	// users exceed the limit, so they are written in the order they arrived ahead of the sorted groups
+	want := []string{
+		`{"kind":"AZUser","data":{"id":"3"}}`,
+		`{"kind":"AZUser","data":{"id":"2"}}`,
+		`{"kind":"AZUser","data":{"id":"1"}}`,
+		`{"kind":"AZGroup","data":{"id":"1"}}`,
+		`{"kind":"AZGroup","data":{"id":"2"}}`,
+	}
+
+	if actual := collectSorted(items, 2); strings.Join(actual, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", actual, want)
+	}
+}
+
+func TestValidateSorted(t *testing.T) {
+	t.Cleanup(func() {
+		config.Sorted.Set(false)
+		config.SortedMaxItems.Set(config.SortedMaxItems.Default)
+	})
+
+	config.Sorted.Set(true)
+	if err := validateSorted(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.SortedMaxItems.Set(0)
+	if err := validateSorted(); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+const (
+	spoolExt      = ".json"
+	spoolInflight = ".inflight"
+	spoolRejected = ".rejected"
+)
+
+var (
+	// spoolMutex serializes changes to the spool directory so evictions never race a replay
+	spoolMutex    sync.Mutex
+	spoolSequence int64
+)
+
+// ingestSpool keeps ingest batches that couldn't be sent on disk, one file per batch named so that sorting the names
+// sorts the batches oldest first. Files are written under a temporary name and renamed into place, and renamed aside
+// while being replayed, so a crash never leaves a partial batch to be replayed or a batch replayed twice at once.
+type ingestSpool struct {
+	dir      string
+	maxBytes int64
+}
+
+type spoolFile struct {
+	name string
+	size int64
+}
+
+func ingestSpoolConfig() (ingestSpool, error) {
+	var (
+		dir      = config.IngestSpool.Value().(string)
+		maxBytes = config.IngestSpoolMaxBytes.Value().(int)
+	)
+
+	if dir == "" {
+		return ingestSpool{}, nil
+	} else if maxBytes < 1 {
+		return ingestSpool{}, fmt.Errorf("invalid --%s: %d is not positive", config.IngestSpoolMaxBytes.Name, maxBytes)
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		return ingestSpool{}, fmt.Errorf("unable to create --%s: %w", config.IngestSpool.Name, err)
+	} else {
+		return ingestSpool{dir: dir, maxBytes: int64(maxBytes)}, nil
+	}
+}
+
+func (s ingestSpool) enabled() bool {
+	return s.dir != ""
+}
+
+// spoolable reports whether a failed batch should be spooled: the instance stayed busy or couldn't be reached. Batches
+// the instance rejected would be rejected again, and batches of a cancelled job are abandoned with it.
+func spoolable(ctx context.Context, err error) bool {
+	var urlErr *url.Error
+	if ctx.Err() != nil {
+		return false
+	} else {
+		return errors.Is(err, ErrExceededRetryLimit) || errors.As(err, &urlErr)
+	}
+}
+
+// store writes a batch to the spool, evicting the oldest batches if it would exceed the byte budget
+func (s ingestSpool) store(data []interface{}) (string, error) {
+	body, err := json.Marshal(models.IngestRequest{
+		Meta: models.Meta{Type: "azure", Version: 5, Count: len(data)},
+		Data: data,
+	})
+	if err != nil {
+		return "", err
+	} else if int64(len(body)) > s.maxBytes {
+		return "", fmt.Errorf("batch of %d bytes exceeds --%s", len(body), config.IngestSpoolMaxBytes.Name)
+	}
+
+	spoolMutex.Lock()
+	defer spoolMutex.Unlock()
+
+	if evicted, err := s.evict(s.maxBytes - int64(len(body))); err != nil {
+		return "", err
+	} else if evicted > 0 {
+		log.Info("warning: evicted the oldest spooled ingest batches to stay within the spool budget", "evicted", evicted, "maxBytes", s.maxBytes)
+	}
+
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), atomic.AddInt64(&spoolSequence, 1)%1000000, spoolExt)
+	if file, err := os.CreateTemp(s.dir, ".spool-*"); err != nil {
+		return "", err
+	} else if _, err := file.Write(body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	} else if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	} else if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	} else if err := os.Rename(file.Name(), filepath.Join(s.dir, name)); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	} else {
+		return name, nil
+	}
+}
+
+// files lists the spooled batches oldest first, including rejected ones, which still count against the budget
+func (s ingestSpool) files() ([]spoolFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []spoolFile{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		} else if !strings.HasSuffix(name, spoolExt) && !strings.HasSuffix(name, spoolExt+spoolRejected) && !strings.HasSuffix(name, spoolExt+spoolInflight) {
+			continue
+		} else if info, err := entry.Info(); err != nil {
+			return nil, err
+		} else {
+			files = append(files, spoolFile{name: name, size: info.Size()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// evict removes the oldest batches until the spool holds at most budget bytes, returning how many were removed
+func (s ingestSpool) evict(budget int64) (int, error) {
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+
+	evicted := 0
+	for _, file := range files {
+		if total <= budget {
+			break
+		} else if strings.HasSuffix(file.name, spoolInflight) {
+			// being replayed; it is removed once sent
+			continue
+		} else if err := os.Remove(filepath.Join(s.dir, file.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return evicted, err
+		} else {
+			total -= file.size
+			evicted++
+		}
+	}
+	return evicted, nil
+}
+
+// replay resends the spooled batches oldest first. A batch is renamed aside while it is sent and removed once
+// accepted; a batch the instance rejects is kept with a .rejected suffix and not resent. Replay stops at the first
+// batch that can't be sent, keeping it and the batches after it for the next job.
+func (s ingestSpool) replay(ctx context.Context, send func(ctx context.Context, data []interface{}) error) (int, error) {
+	spoolMutex.Lock()
+	files, err := s.files()
+	spoolMutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, file := range files {
+		name := file.name
+		if strings.HasSuffix(name, spoolRejected) {
+			continue
+		} else if strings.HasSuffix(name, spoolInflight) {
+			// left behind by a collector that stopped mid-replay, so it may have been ingested already
+			name = strings.TrimSuffix(name, spoolInflight)
+		}
+
+		var (
+			path     = filepath.Join(s.dir, name)
+			inflight = path + spoolInflight
+		)
+
+		if file.name == name {
+			spoolMutex.Lock()
+			err := os.Rename(path, inflight)
+			spoolMutex.Unlock()
+			if errors.Is(err, os.ErrNotExist) {
+				// evicted since it was listed
+				continue
+			} else if err != nil {
+				return replayed, err
+			}
+		}
+
+		var body struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		if content, err := os.ReadFile(inflight); err != nil {
+			return replayed, err
+		} else if err := json.Unmarshal(content, &body); err != nil {
+			log.Error(err, "unable to read spooled ingest batch, setting it aside", "file", name)
+			if err := os.Rename(inflight, path+spoolRejected); err != nil {
+				return replayed, err
+			}
+			continue
+		}
+
+		data := make([]interface{}, len(body.Data))
+		for i := range body.Data {
+			data[i] = body.Data[i]
+		}
+
+		if err := send(ctx, data); err == nil {
+			if err := os.Remove(inflight); err != nil {
+				return replayed, err
+			}
+			replayed++
+		} else if spoolable(ctx, err) || ctx.Err() != nil {
+			if renameErr := os.Rename(inflight, path); renameErr != nil {
+				return replayed, renameErr
+			}
+			return replayed, err
+		} else {
+			log.Error(err, "bloodhound enterprise rejected a spooled ingest batch, setting it aside", "file", name)
+			if err := os.Rename(inflight, path+spoolRejected); err != nil {
+				return replayed, err
+			}
+		}
+	}
+	return replayed, nil
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+func spoolNames(t *testing.T, spool ingestSpool) []string {
+	files, err := spool.files()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.name)
+	}
+	return names
+}
+
+func TestIngestSpoolEviction(t *testing.T) {
+	var (
+		spool = ingestSpool{dir: t.TempDir(), maxBytes: 1200}
+		batch = []interface{}{json.RawMessage(`"` + strings.Repeat("a", 300) + `"`)}
+		names []string
+	)
+
+	for i := 0; i < 4; i++ {
+		if name, err := spool.store(batch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else {
+			names = append(names, name)
+		}
+	}
+
+	// each batch is a little under 400 bytes, so only the newest three fit
+	if actual := strings.Join(spoolNames(t, spool), ","); actual != strings.Join(names[1:], ",") {
+		t.Errorf("got %v, want %v", actual, names[1:])
+	}
+
+	if _, err := spool.store([]interface{}{json.RawMessage(`"` + strings.Repeat("a", 2048) + `"`)}); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if len(spoolNames(t, spool)) != 3 {
+		t.Error("expected a batch larger than the budget to leave the spool as is")
+	}
+}
+
+func TestIngestSpoolReplay(t *testing.T) {
+	spool := ingestSpool{dir: t.TempDir(), maxBytes: 1 << 20}
+	for _, item := range []string{`"1"`, `"2"`, `"3"`, `"4"`} {
+		if _, err := spool.store([]interface{}{json.RawMessage(item)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var sent []string
+	send := func(ctx context.Context, data []interface{}) error {
+		item := string(data[0].(json.RawMessage))
+		sent = append(sent, item)
+		switch item {
+		case `"2"`:
+			return errors.New("received unexpected response code: 400 Bad Request")
+		case `"3"`:
+			return ErrExceededRetryLimit
+		default:
+			return nil
+		}
+	}
+
+	// the rejected batch is set aside and replay stops at the batch that couldn't be sent
+	if replayed, err := spool.replay(context.Background(), send); !errors.Is(err, ErrExceededRetryLimit) {
+		t.Errorf("got %v, want %v", err, ErrExceededRetryLimit)
+	} else if replayed != 1 {
+		t.Errorf("got %v replayed, want 1", replayed)
+	} else if actual := strings.Join(sent, ","); actual != `"1","2","3"` {
+		t.Errorf("got %v, want %v", actual, `"1","2","3"`)
+	}
+
+	names := spoolNames(t, spool)
+	if len(names) != 3 || !strings.HasSuffix(names[0], spoolRejected) || !strings.HasSuffix(names[1], spoolExt) || !strings.HasSuffix(names[2], spoolExt) {
+		t.Errorf("unexpected spool contents: %v", names)
+	}
+
+	sent = nil
+	if replayed, err := spool.replay(context.Background(), func(ctx context.Context, data []interface{}) error {
+		sent = append(sent, string(data[0].(json.RawMessage)))
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if replayed != 2 {
+		t.Errorf("got %v replayed, want 2", replayed)
+	} else if actual := strings.Join(sent, ","); actual != `"3","4"` {
+		t.Errorf("got %v, want %v", actual, `"3","4"`)
+	} else if names := spoolNames(t, spool); len(names) != 1 {
+		t.Errorf("unexpected spool contents: %v", names)
+	}
+}
+
+func TestIngestSpoolReplayInflight(t *testing.T) {
+	spool := ingestSpool{dir: t.TempDir(), maxBytes: 1 << 20}
+	if name, err := spool.store([]interface{}{json.RawMessage(`"1"`)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := os.Rename(filepath.Join(spool.dir, name), filepath.Join(spool.dir, name+spoolInflight)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a batch left in flight by a collector that stopped mid-replay is resent
+	if replayed, err := spool.replay(context.Background(), func(ctx context.Context, data []interface{}) error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if replayed != 1 {
+		t.Errorf("got %v replayed, want 1", replayed)
+	} else if names := spoolNames(t, spool); len(names) != 0 {
+		t.Errorf("unexpected spool contents: %v", names)
+	}
+}
+
+func TestIngestSpoolsUnsentBatches(t *testing.T) {
+	var (
+		mutex     sync.Mutex
+		available bool
+		ingested  []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		decodeIngestRequest(r, &body)
+		for _, item := range body.Data {
+			ingested = append(ingested, string(item))
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Cleanup(func() {
+		config.IngestMaxRetries.Set(3)
+		config.IngestSpool.Set("")
+	})
+
+	dir := t.TempDir()
+	config.IngestMaxRetries.Set(0)
+	config.IngestSpool.Set(dir)
+	bheUrl, _ := url.Parse(server.URL)
+
+	batches := func(items ...string) <-chan []interface{} {
+		out := make(chan []interface{}, len(items))
+		for _, item := range items {
+			out <- []interface{}{json.RawMessage(item)}
+		}
+		close(out)
+		return out
+	}
+
+	if stats := ingest(context.Background(), *bheUrl, server.Client(), batches(`"1"`, `"2"`)); stats.spooled != 2 || stats.dropped != 0 {
+		t.Errorf("got %+v, want 2 spooled batches", stats)
+	} else if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("got %v spooled batches, want 2", len(entries))
+	}
+
+	available = true
+	if stats := ingest(context.Background(), *bheUrl, server.Client(), batches(`"3"`)); stats.hasErrors() {
+		t.Errorf("unexpected ingest errors: %v", stats)
+	} else if actual := strings.Join(ingested, ","); actual != `"1","2","3"` {
+		t.Errorf("got %v, want %v", actual, `"1","2","3"`)
+	} else if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("got %v spooled batches, want 0", len(entries))
+	}
+}
@@ -18,25 +18,35 @@
 package cmd
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"runtime"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/bloodhoundad/azurehound/v2/constants"
 	"github.com/bloodhoundad/azurehound/v2/models"
 	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -45,11 +55,16 @@ const (
 )
 
 var (
-	ErrExceededRetryLimit = errors.New("exceeded max retry limit for ingest batch, proceeding with next batch...")
+	ErrExceededRetryLimit  = errors.New("exceeded max retry limit for ingest batch, proceeding with next batch...")
+	ErrIngestRejected      = errors.New("received unexpected response code")
+	ErrTenantOwned         = errors.New("another client owns this tenant")
+	ErrJobCancelled        = errors.New("the job was cancelled in bloodhound enterprise")
+	ErrProgressUnsupported = errors.New("bloodhound enterprise does not accept job progress")
 )
 
 func init() {
 	configs := append(config.AzureConfig, config.BloodHoundEnterpriseConfig...)
+	configs = append(configs, config.AuthMethods, config.ExcludeDisabled, config.ExcludeSubscriptionClass, config.EnrichUsers, config.EnrichKey, config.EnrichColumns, config.InactiveDeviceDays, config.RecentCredentialDays, config.RedactPii, config.RedactSalt, config.IngestCanary, config.StrictPermissions, config.Ledger, config.StartOnce, config.OnceTimeout, config.StatusAddr, config.UploadLogs)
 	config.Init(startCmd, configs)
 	rootCmd.AddCommand(startCmd)
 }
@@ -78,147 +93,936 @@ func start(ctx context.Context) {
 	}()
 	defer gracefulShutdown(stop)
 
+	intervals, err := startIntervals()
+	if err != nil {
+		exitWithCode(ExitCodeConfigError, err)
+	}
+	batchConfig, err := ingestBatchConfig()
+	if err != nil {
+		exitWithCode(ExitCodeConfigError, err)
+	}
+	once, err := startOnce()
+	if err != nil {
+		exitWithCode(ExitCodeConfigError, err)
+	}
+	if _, err := ingestRetryConfig(); err != nil {
+		exitWithCode(ExitCodeConfigError, err)
+	}
+	if _, err := ingestSpoolConfig(); err != nil {
+		exitWithCode(ExitCodeConfigError, err)
+	}
+	if _, err := ingestConcurrency(); err != nil {
+		exitWithCode(ExitCodeConfigError, err)
+	}
+	if config.CacheDir.Value().(string) != "" {
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("--%s is for development and testing only and can't be used with start", config.CacheDir.Name))
+	}
+	if config.UploadLogs.Value().(bool) {
+		captureJobLogs()
+	}
+	if addr := config.StatusAddr.Value().(string); addr != "" {
+		if listener, err := net.Listen("tcp", addr); err != nil {
+			exitWithCode(ExitCodeConfigError, fmt.Errorf("invalid --%s: %w", config.StatusAddr.Name, err))
+		} else {
+			defer serveStatus(ctx, listener, serviceState)()
+		}
+	}
+
+	// BloodHound Enterprise is checked first, ingest canary included, so a rejected ingest aborts before the Azure client
+	// is built and any Azure API calls are made
 	log.V(1).Info("testing connections")
-	if azClient := connectAndCreateClient(); azClient == nil {
+	if bheInstance, err := parseBHEUrl(config.BHEUrl.Value().(string)); err != nil {
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("unable to parse BHE url: %w", err))
+	} else if bheClient, err := newSigningHttpClient(BHEAuthSignature, config.BHETokenId.Value().(string), config.BHEToken.Value().(string), config.ProxyUrl()); err != nil {
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("failed to create new signing HTTP client: %w", err))
+	} else if httpClient, err := newBHEHttpClient(config.ProxyUrl()); err != nil {
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("failed to create new HTTP client: %w", err))
+	} else if err := preflight(ctx, *bheInstance, httpClient, bheClient); err != nil {
+		exit(err)
+	} else if err := runIngestCanary(ctx, *bheInstance, bheClient); err != nil {
+		exit(err)
+	} else if azClient := connectAndCreateClient(); azClient == nil {
 		exit(fmt.Errorf("azClient is unexpectedly nil"))
-	} else if bheInstance, err := url.Parse(config.BHEUrl.Value().(string)); err != nil {
-		exit(fmt.Errorf("unable to parse BHE url: %w", err))
-	} else if bheClient, err := newSigningHttpClient(BHEAuthSignature, config.BHETokenId.Value().(string), config.BHEToken.Value().(string), config.Proxy.Value().(string)); err != nil {
-		exit(fmt.Errorf("failed to create new signing HTTP client: %w", err))
-	} else if err := updateClient(ctx, *bheInstance, bheClient); err != nil {
+	} else if err := checkCollectorPermissions(ctx, os.Stderr, azClient, collector.FullScope, config.StrictPermissions.Value().(bool)); err != nil {
+		exit(err)
+	} else if err := updateClient(ctx, *bheInstance, bheClient, azClient.TenantInfo().TenantId); err != nil {
 		exit(fmt.Errorf("failed to update client: %w", err))
 	} else {
+		serviceState.contacted(nil)
 		log.Info("connected successfully! waiting for tasks...")
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-
-		var (
-			currentTask *models.ClientTask
-		)
-
-		for {
-			select {
-			case <-ticker.C:
-				if currentTask != nil {
-					log.V(1).Info("collection in progress...", "jobId", currentTask.Id)
-					if err := checkin(ctx, *bheInstance, bheClient); err != nil {
-						log.Error(err, "bloodhound enterprise service checkin failed")
-					}
-				} else {
-					go func() {
-						log.V(2).Info("checking for available collection tasks")
-						if availableTasks, err := getAvailableTasks(ctx, *bheInstance, bheClient); err != nil {
-							log.Error(err, "unable to fetch available tasks for azurehound")
-						} else {
-
-							// Get only the tasks that have reached their execution time
-							executableTasks := []models.ClientTask{}
-							now := time.Now()
-							for _, task := range availableTasks {
-								if task.ExectionTime.Before(now) || task.ExectionTime.Equal(now) {
-									executableTasks = append(executableTasks, task)
-								}
-							}
-
-							// Sort tasks in ascending order by execution time
-							sort.Slice(executableTasks, func(i, j int) bool {
-								return executableTasks[i].ExectionTime.Before(executableTasks[j].ExectionTime)
-							})
-
-							if len(executableTasks) == 0 {
-								log.V(2).Info("there are no tasks for azurehound to complete at this time")
-							} else {
-
-								// Notify BHE instance of task start
-								currentTask = &executableTasks[0]
-								if err := startTask(ctx, *bheInstance, bheClient, currentTask.Id); err != nil {
-									log.Error(err, "failed to start task, will retry on next heartbeat")
-									currentTask = nil
-									return
-								}
-
-								start := time.Now()
-
-								// Batch data out for ingestion
-								stream := listAll(ctx, azClient)
-								batches := pipeline.Batch(ctx.Done(), stream, 256, 10*time.Second)
-								hasIngestErr := ingest(ctx, *bheInstance, bheClient, batches)
-
-								// Notify BHE instance of task end
-								duration := time.Since(start)
-
-								message := "Collection completed successfully"
-								if hasIngestErr {
-									message = "Collection completed with errors during ingest"
-
-								}
-								if err := endTask(ctx, *bheInstance, bheClient, models.JobStatusComplete, message); err != nil {
-									log.Error(err, "failed to end task")
-								} else {
-									log.Info(message, "id", currentTask.Id, "duration", duration.String())
-								}
-
-								currentTask = nil
-							}
-						}
-					}()
-				}
-			case <-ctx.Done():
+
+		runner := taskRunner{
+			bheUrl:    *bheInstance,
+			bheClient: bheClient,
+			tenantId:  azClient.TenantInfo().TenantId,
+			collect: func(ctx context.Context, options collector.Options) <-chan interface{} {
+				stream, _ := collectTenant(ctx, azClient, options)
+				return stream
+			},
+			batchConfig: batchConfig,
+			intervals:   intervals,
+			once:        once,
+		}
+		if status, ran := runner.run(ctx); once != nil {
+			if code := onceExitCode(ctx, status, ran); code != 0 {
+				exitWithCode(code, nil)
+			}
+		}
+	}
+}
+
+// startOnceConfig configures start --once
+type startOnceConfig struct {
+	// how long to wait for a task to become available; zero waits until one does
+	timeout time.Duration
+}
+
+// startOnce returns the --once configuration, or nil when start runs as a service
+func startOnce() (*startOnceConfig, error) {
+	if !config.StartOnce.Value().(bool) {
+		return nil, nil
+	} else if timeout, err := durationValue(config.OnceTimeout); err != nil {
+		return nil, err
+	} else if timeout < 0 {
+		return nil, fmt.Errorf("invalid --%s: %s is negative", config.OnceTimeout.Name, timeout)
+	} else {
+		return &startOnceConfig{timeout: timeout}, nil
+	}
+}
+
+// onceExitCode returns the exit code of start --once for the status of the task it ran, if it ran one: 0 for a
+// complete job, ExitCodePartialCollection for a partially complete one, ExitCodeUploadFailure when ingest failed and
+// ExitCodeNoTask when no task became available in time
+func onceExitCode(ctx context.Context, status models.JobStatus, ran bool) int {
+	if ctx.Err() != nil {
+		return ExitCodeAborted
+	} else if !ran {
+		return ExitCodeNoTask
+	}
+
+	switch status {
+	case models.JobStatusComplete:
+		return 0
+	case models.JobStatusPartiallyComplete:
+		return ExitCodePartialCollection
+	case models.JobStatusFailed:
+		return ExitCodeUploadFailure
+	default:
+		return ExitCodeFailure
+	}
+}
+
+// taskRunner polls BloodHound Enterprise for collection tasks and runs them one at a time
+type taskRunner struct {
+	bheUrl      url.URL
+	bheClient   *http.Client
+	tenantId    string
+	collect     func(ctx context.Context, options collector.Options) <-chan interface{}
+	batchConfig pipeline.AdaptiveBatchConfig[interface{}]
+	intervals   startIntervalConfig
+	// set to run a single task and return
+	once *startOnceConfig
+
+	// only used by the goroutine calling run
+	declined map[int]struct{}
+	// a task that couldn't be started, which is tried again before any other task
+	claimed int
+	backoff pollBackoff
+	ran     bool
+	status  models.JobStatus
+}
+
+// run polls for tasks until ctx is done. Polling, claiming and collecting a task all happen on the calling goroutine,
+// so a slow poll or a long collection delays the next poll rather than overlapping it. With once set it returns after
+// the first task that runs, with its status, or once the timeout passes without one; the timeout only bounds the
+// wait, never a running task.
+func (s *taskRunner) run(ctx context.Context) (models.JobStatus, bool) {
+	s.declined = map[int]struct{}{}
+	s.backoff = pollBackoff{base: s.intervals.poll, max: s.intervals.pollMax, current: s.intervals.poll}
+
+	var (
+		firstPoll = startupJitter(s.intervals.poll)
+		giveUp    <-chan time.Time
+	)
+	if s.once != nil {
+		// a scheduled run has nothing to stagger and polls straight away
+		firstPoll = 0
+		if s.once.timeout > 0 {
+			timeout := time.NewTimer(s.once.timeout)
+			defer timeout.Stop()
+			giveUp = timeout.C
+		}
+	}
+
+	polls := time.NewTimer(firstPoll)
+	defer polls.Stop()
+
+	for {
+		select {
+		case <-polls.C:
+			delay := s.poll(ctx)
+			if s.once != nil && s.ran {
+				return s.status, true
+			}
+			log.V(2).Info("scheduled next check for collection tasks", "in", delay.String())
+			polls.Reset(delay)
+		case <-giveUp:
+			log.Info("warning: no collection task became available, giving up", "timeout", s.once.timeout.String())
+			return s.status, false
+		case <-ctx.Done():
+			return s.status, s.ran
+		}
+	}
+}
+
+// poll runs the next executable task, if there is one, to completion and returns how long to wait until the next poll
+func (s *taskRunner) poll(ctx context.Context) time.Duration {
+	log.V(2).Info("checking for available collection tasks")
+	availableTasks, err := getAvailableTasks(ctx, s.bheUrl, s.bheClient)
+	serviceState.contacted(err)
+	if err != nil {
+		log.Error(err, "unable to fetch available tasks for azurehound")
+		serviceState.failed(err)
+		return s.backoff.next(true, 0)
+	}
+
+	nextTaskIn := nextExecutionIn(availableTasks, time.Now())
+	executableTasks := []models.ClientTask{}
+	for _, task := range getExecutableTasks(availableTasks, time.Now()) {
+		if _, declined := s.declined[task.Id]; !declined {
+			executableTasks = append(executableTasks, task)
+		}
+	}
+
+	if len(executableTasks) == 0 {
+		log.V(2).Info("there are no tasks for azurehound to complete at this time")
+		return s.backoff.next(true, nextTaskIn)
+	}
+
+	// Notify BHE instance of task start
+	task := nextTask(executableTasks, s.claimed)
+	s.claimed = 0
+	if err := retryBHERequest(ctx, "start task", func() error {
+		return startTask(ctx, s.bheUrl, s.bheClient, task.Id, s.tenantId)
+	}); errors.Is(err, ErrTenantOwned) {
+		log.Info("warning: declining collection task; another client is already collecting this tenant. make sure only one collector is deployed per tenant", "id", task.Id, "tenantId", s.tenantId, "reason", err.Error())
+		s.declined[task.Id] = struct{}{}
+	} else if err != nil {
+		log.Error(err, "failed to start task, will retry on next poll", "id", task.Id)
+		serviceState.failed(err)
+		s.claimed = task.Id
+	} else {
+		s.status = runTask(ctx, s.bheUrl, s.bheClient, task, s.tenantId, s.collect, s.batchConfig, s.intervals.checkin)
+		s.ran = true
+	}
+	return s.backoff.next(false, nextTaskIn)
+}
+
+// nextTask returns the task to start next: the claimed task if it is still executable, otherwise the first
+func nextTask(executableTasks []models.ClientTask, claimed int) models.ClientTask {
+	for _, task := range executableTasks {
+		if task.Id == claimed {
+			return task
+		}
+	}
+	return executableTasks[0]
+}
+
+// runTask collects the part of the tenant requested by a started task, ingests the results and ends the job. While the task runs it checks
+// in with the instance every checkinInterval; if the job is cancelled or deleted in BloodHound Enterprise, collection
+// and ingest stop and the job is ended as canceled. It returns the status the job was ended with.
+func runTask(ctx context.Context, bheUrl url.URL, bheClient *http.Client, task models.ClientTask, tenantId string, collect func(ctx context.Context, options collector.Options) <-chan interface{}, batchConfig pipeline.AdaptiveBatchConfig[interface{}], checkinInterval time.Duration) models.JobStatus {
+	start := time.Now()
+	collector.ResetKinds()
+	jobLogs.Reset()
+
+	progress := trackProgress()
+	defer currentProgress.Store(nil)
+	serviceState.jobStarted(task.Id)
+	defer serviceState.jobEnded()
+
+	jobCtx, cancelJob := context.WithCancelCause(ctx)
+	defer cancelJob(nil)
+	go watchJob(jobCtx, bheUrl, bheClient, task.Id, checkinInterval, cancelJob, progress)
+
+	scope := taskScope(task)
+	kinds, unknownKinds := taskKinds(task)
+	if len(unknownKinds) > 0 {
+		log.Info("warning: ignoring object kinds requested by the task that this version of azurehound doesn't collect", "id", task.Id, "kinds", unknownKinds)
+	}
+	if kinds != nil {
+		scope = scope.Narrow(kinds)
+	}
+	if scope == (collector.Scope{}) {
+		log.Info("warning: the task requests neither entra id objects nor azure resources; nothing will be collected", "id", task.Id)
+	} else if scope != collector.FullScope {
+		log.Info("collecting the part of the tenant requested by the task", "id", task.Id, "azureAD", scope.AzureAD, "azureRM", scope.AzureRM)
+	}
+
+	// Batch data out for ingestion
+	taskCtx, span := tracing.Start(jobCtx, "collection task", "taskId", task.Id, "tenantId", tenantId)
+	options := collectionOptions()
+	options.Scope, options.Kinds, options.Progress = &scope, kinds, progress
+	if kinds != nil {
+		log.Info("collecting the object kinds requested by the task", "id", task.Id, "kinds", kindNames(kinds))
+	}
+	collectionCtx, cancel := collectionContext(taskCtx)
+	summary := newRunSummary()
+	stream := progress.observe(ctx, collect(withRunSummary(collectionCtx, summary), options))
+	batches := pipeline.AdaptiveBatch(ctx.Done(), marshalForIngest(ctx, stream), batchConfig)
+	ingestStats := ingest(taskCtx, bheUrl, bheClient, batches)
+	hasDeadlineExceeded := deadlineExceeded(collectionCtx)
+	cancel()
+
+	cancelled := errors.Is(context.Cause(jobCtx), ErrJobCancelled)
+	if cancelled {
+		// ingest stopped with the job; drain what the collectors already sent so they can exit
+		for range batches {
+		}
+	}
+	span.SetAttributes("ingestErrors", ingestStats.hasErrors(), "deadlineExceeded", hasDeadlineExceeded, "cancelled", cancelled)
+	span.End()
+
+	// Notify BHE instance of task end
+	duration := time.Since(start)
+	collector.LogForbiddenSummary(log)
+
+	status, message := collectionOutcome(ingestStats, hasDeadlineExceeded)
+	if cancelled {
+		status, message = models.JobStatusCanceled, "Collection cancelled from BloodHound Enterprise"
+	}
+	message += jobLogSummary(jobLogs)
+	if err := retryBHERequest(ctx, "end task", func() error {
+		return endTask(ctx, bheUrl, bheClient, status, message)
+	}); err != nil && cancelled {
+		// a deleted job has nothing left to end
+		log.V(1).Info("unable to end the cancelled task", "id", task.Id, "reason", err.Error())
+	} else if err != nil {
+		log.Error(err, "failed to end task")
+		serviceState.failed(err)
+	} else {
+		log.Info(message, "id", task.Id, "duration", duration.String())
+	}
+	if status == models.JobStatusFailed || status == models.JobStatusPartiallyComplete {
+		serviceState.failed(errors.New(message))
+	}
+	summary.appendToLedger("start", tenantId, start, hasDeadlineExceeded)
+	return status
+}
+
+// watchJob checks in with BloodHound Enterprise every interval until ctx is done, cancelling the job with
+// ErrJobCancelled once the instance reports it is no longer running. Each checkin is followed by a progress report;
+// instances that don't accept them are not sent any more for the rest of the job.
+func watchJob(ctx context.Context, bheUrl url.URL, bheClient *http.Client, jobId int, interval time.Duration, cancel context.CancelCauseFunc, progress *runProgress) {
+	var (
+		checkins       = time.NewTicker(interval)
+		reportProgress = progress != nil
+	)
+	defer checkins.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-checkins.C:
+			log.V(1).Info("collection in progress...", "jobId", jobId)
+			err := checkin(ctx, bheUrl, bheClient, jobId)
+			if err == nil || errors.Is(err, ErrJobCancelled) {
+				// the instance answered even when the job is gone
+				serviceState.contacted(nil)
+			} else if ctx.Err() == nil {
+				serviceState.contacted(err)
+			}
+			if errors.Is(err, ErrJobCancelled) {
+				log.Info("warning: stopping collection; the job is no longer running in bloodhound enterprise", "jobId", jobId, "reason", err.Error())
+				cancel(err)
 				return
+			} else if err != nil && ctx.Err() == nil {
+				log.Error(err, "bloodhound enterprise service checkin failed")
+				serviceState.failed(err)
+			} else if !reportProgress {
+				continue
+			} else if err := sendProgress(ctx, bheUrl, bheClient, progress.report()); errors.Is(err, ErrProgressUnsupported) {
+				log.V(1).Info("bloodhound enterprise does not accept job progress, no longer reporting it for this job", "jobId", jobId, "reason", err.Error())
+				reportProgress = false
+			} else if err != nil && ctx.Err() == nil {
+				log.V(1).Info("unable to report job progress", "jobId", jobId, "reason", err.Error())
 			}
 		}
 	}
 }
 
-func ingest(ctx context.Context, bheUrl url.URL, bheClient *http.Client, in <-chan []interface{}) bool {
-	endpoint := bheUrl.ResolveReference(&url.URL{Path: "/api/v2/ingest"})
+// no cadence of the start command may be shorter than this, sparing the instance a typo like 5ms
+const startMinInterval = time.Second
+
+type startIntervalConfig struct {
+	checkin time.Duration
+	poll    time.Duration
+	pollMax time.Duration
+}
+
+func startIntervals() (startIntervalConfig, error) {
+	var intervals startIntervalConfig
+	if checkin, err := durationValue(config.CheckinInterval); err != nil {
+		return intervals, err
+	} else if poll, err := durationValue(config.TaskPollInterval); err != nil {
+		return intervals, err
+	} else if pollMax, err := durationValue(config.PollMaxInterval); err != nil {
+		return intervals, err
+	} else if checkin < startMinInterval {
+		return intervals, fmt.Errorf("invalid --%s: %s is less than %s", config.CheckinInterval.Name, checkin, startMinInterval)
+	} else if poll < startMinInterval {
+		return intervals, fmt.Errorf("invalid --%s: %s is less than %s", config.TaskPollInterval.Name, poll, startMinInterval)
+	} else {
+		if pollMax < poll {
+			pollMax = poll
+		}
+		return startIntervalConfig{checkin: checkin, poll: poll, pollMax: pollMax}, nil
+	}
+}
+
+// startupJitter returns how long to wait before the first poll, a random share of the poll interval, so collectors
+// started together, e.g. by a fleet deployment, don't poll in lockstep
+func startupJitter(poll time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(poll)))
+}
+
+// pollBackoff spaces out the polls of an idle agent so a large fleet of them doesn't load the instance needlessly
+type pollBackoff struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+// next returns how long to wait before polling again. Every poll that finds no task to run doubles the wait up to
+// max, and a poll that found one resets it. A task that hasn't reached its execution time yet caps the wait so it
+// isn't started late.
+func (s *pollBackoff) next(idle bool, nextTaskIn time.Duration) time.Duration {
+	if !idle {
+		s.current = s.base
+	} else if s.current *= 2; s.current > s.max {
+		s.current = s.max
+	}
+
+	if delay := s.current; nextTaskIn > 0 && nextTaskIn < delay {
+		if nextTaskIn < s.base {
+			return s.base
+		}
+		return nextTaskIn
+	} else {
+		return delay
+	}
+}
+
+// nextExecutionIn returns how long until the earliest task that hasn't reached its execution time, or zero if there
+// is none
+func nextExecutionIn(availableTasks []models.ClientTask, now time.Time) time.Duration {
+	var next time.Duration
+	for _, task := range availableTasks {
+		if until := task.ExectionTime.Sub(now); until > 0 && (next == 0 || until < next) {
+			next = until
+		}
+	}
+	return next
+}
+
+// getExecutableTasks returns only the tasks that have reached their execution time, sorted in ascending order by
+// execution time
+func getExecutableTasks(availableTasks []models.ClientTask, now time.Time) []models.ClientTask {
+	executableTasks := []models.ClientTask{}
+	for _, task := range availableTasks {
+		if task.ExectionTime.Before(now) || task.ExectionTime.Equal(now) {
+			executableTasks = append(executableTasks, task)
+		}
+	}
+
+	sort.Slice(executableTasks, func(i, j int) bool {
+		return executableTasks[i].ExectionTime.Before(executableTasks[j].ExectionTime)
+	})
+	return executableTasks
+}
+
+// ingestStats accounts for the batches of an ingest job that didn't reach BloodHound Enterprise
+type ingestStats struct {
+	dropped      int  // skipped because the instance stayed busy or unreachable and couldn't be spooled
+	skipped      int  // rejected by the instance and skipped with --ingest-skip-bad-batch
+	spooled      int  // kept in --ingest-spool to be resent at the start of the next job
+	replayFailed bool // batches spooled by earlier jobs still couldn't be resent
+	aborted      bool // ingest stopped at an unrecoverable error, dropping the rest of the job
+}
+
+func (s ingestStats) hasErrors() bool {
+	return s.dropped > 0 || s.skipped > 0 || s.spooled > 0 || s.replayFailed || s.aborted
+}
+
+// String describes what was lost, e.g. "2 batches dropped, 1 batch spooled for the next job"
+func (s ingestStats) String() string {
+	plural := func(count int, noun string) string {
+		if count == 1 {
+			return fmt.Sprintf("%d %s", count, noun)
+		} else {
+			return fmt.Sprintf("%d %ses", count, noun)
+		}
+	}
+
+	parts := []string{}
+	if s.aborted {
+		parts = append(parts, "stopped at an unrecoverable error")
+	}
+	if s.dropped > 0 {
+		parts = append(parts, plural(s.dropped, "batch")+" dropped")
+	}
+	if s.skipped > 0 {
+		parts = append(parts, plural(s.skipped, "batch")+" rejected and skipped")
+	}
+	if s.spooled > 0 {
+		parts = append(parts, plural(s.spooled, "batch")+" spooled for the next job")
+	}
+	if s.replayFailed {
+		parts = append(parts, "batches spooled by earlier jobs could not be resent")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ingest sends the batches of in to BloodHound Enterprise with --ingest-concurrency workers. The endpoint doesn't
+// depend on the order batches arrive in; ingest returns once every worker has finished its in-flight batch.
+func ingest(ctx context.Context, bheUrl url.URL, bheClient *http.Client, in <-chan []interface{}) ingestStats {
+	endpoint := bheEndpoint(bheUrl, "/api/v2/ingest")
 
 	var (
-		hasErrors           = false
-		maxRetries          = 3
+		stats               ingestStats
 		unrecoverableErrMsg = fmt.Sprintf("ending current ingest job due to unrecoverable error while requesting %v", endpoint)
+		skipBadBatch        = config.IngestSkipBadBatch.Value().(bool)
+	)
+
+	spool, err := ingestSpoolConfig()
+	if err != nil {
+		log.Error(err, unrecoverableErrMsg)
+		stats.aborted = true
+		return stats
+	}
+	concurrency, err := ingestConcurrency()
+	if err != nil {
+		log.Error(err, unrecoverableErrMsg)
+		stats.aborted = true
+		return stats
+	}
+	if spool.enabled() && config.ReplaySpool.Value().(bool) {
+		// batches left over from earlier jobs go first so the instance sees data in the order it was collected
+		send := func(ctx context.Context, data []interface{}) error {
+			return ingestBatch(ctx, endpoint, bheClient, data)
+		}
+		if replayed, err := spool.replay(ctx, send); err != nil {
+			log.Error(err, "unable to resend all spooled ingest batches, keeping the rest for the next job", "replayed", replayed)
+			stats.replayFailed = true
+		} else if replayed > 0 {
+			log.Info("resent spooled ingest batches", "replayed", replayed)
+		}
+	}
+
+	// an unrecoverable error in one worker stops the others
+	workerCtx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	// the workers share one stream rather than demuxing it, so a worker that stops never holds up a batch
+	var (
+		mutex   sync.Mutex
+		wg      sync.WaitGroup
+		batches = pipeline.OrDone(workerCtx.Done(), in)
 	)
 
-	for data := range pipeline.OrDone(ctx.Done(), in) {
-		body := models.IngestRequest{
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for data := range batches {
+				batchCtx, span := tracing.Start(workerCtx, "ingest batch", "count", len(data), "bytes", ingestSize(data))
+				err := ingestBatch(batchCtx, endpoint, bheClient, data)
+				span.SetError(err)
+				span.End()
+				if err == nil {
+					currentProgress.Load().batchSent()
+					continue
+				}
+
+				mutex.Lock()
+				if spool.enabled() && spoolable(workerCtx, err) {
+					if name, spoolErr := spool.store(data); spoolErr != nil {
+						log.Error(spoolErr, "unable to spool ingest batch, dropping it", "reason", err.Error(), "count", len(data))
+						stats.dropped++
+					} else {
+						log.Info("warning: spooled ingest batch to resend at the start of the next job", "reason", err.Error(), "file", name, "count", len(data))
+						stats.spooled++
+					}
+				} else if errors.Is(err, ErrExceededRetryLimit) {
+					log.Error(err, "")
+					stats.dropped++
+				} else if skipBadBatch && errors.Is(err, ErrIngestRejected) && workerCtx.Err() == nil {
+					log.Error(err, "skipping ingest batch rejected by bloodhound enterprise; its objects are missing from this job", "count", len(data), "bytes", ingestSize(data))
+					stats.skipped++
+				} else if workerCtx.Err() != nil {
+					// the job was cancelled, azurehound is shutting down or another worker stopped ingest, so there
+					// is nothing left to ingest into
+					mutex.Unlock()
+					return
+				} else {
+					log.Error(err, unrecoverableErrMsg)
+					stats.aborted = true
+					abort()
+					mutex.Unlock()
+					return
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	if stats.skipped > 0 {
+		log.Info("warning: ingest batches were rejected by bloodhound enterprise and skipped; the job is missing their objects", "skipped", stats.skipped)
+	}
+	return stats
+}
+
+// ingestConcurrency returns the number of batches ingest sends at once
+func ingestConcurrency() (int, error) {
+	if concurrency := config.IngestConcurrency.Value().(int); concurrency < 1 || concurrency > ingestConcurrencyLimit {
+		return 0, fmt.Errorf("invalid --%s: %d is not between 1 and %d", config.IngestConcurrency.Name, concurrency, ingestConcurrencyLimit)
+	} else {
+		return concurrency, nil
+	}
+}
+
+const (
+	ingestBatchMinItems    = 64
+	ingestBatchMaxItems    = 10000
+	ingestBatchTargetBytes = 2 * 1024 * 1024
+	ingestBatchMaxBytes    = 4 * 1024 * 1024
+	ingestBatchMinTimeout  = time.Second
+	ingestBatchMaxTimeout  = 5 * time.Minute
+	ingestConcurrencyLimit = 16
+)
+
+// ingestBatchConfig sizes ingest batches by their serialized size, growing them up to --batch-size while collection
+// outpaces ingest
+func ingestBatchConfig() (pipeline.AdaptiveBatchConfig[interface{}], error) {
+	var (
+		cfg       pipeline.AdaptiveBatchConfig[interface{}]
+		batchSize = config.BatchSize.Value().(int)
+		minItems  = ingestBatchMinItems
+	)
+
+	if timeout, err := durationValue(config.BatchFlushInterval); err != nil {
+		return cfg, err
+	} else if batchSize < 1 || batchSize > ingestBatchMaxItems {
+		return cfg, fmt.Errorf("invalid --%s: %d is not between 1 and %d", config.BatchSize.Name, batchSize, ingestBatchMaxItems)
+	} else if timeout < ingestBatchMinTimeout || timeout > ingestBatchMaxTimeout {
+		return cfg, fmt.Errorf("invalid --%s: %s is not between %s and %s", config.BatchFlushInterval.Name, timeout, ingestBatchMinTimeout, ingestBatchMaxTimeout)
+	} else {
+		if batchSize < minItems {
+			minItems = batchSize
+		}
+		return pipeline.AdaptiveBatchConfig[interface{}]{
+			MinItems:    minItems,
+			MaxItems:    batchSize,
+			TargetBytes: ingestBatchTargetBytes,
+			MaxBytes:    ingestBatchMaxBytes,
+			Size:        ingestItemSize,
+			MaxTimeout:  timeout,
+			OnFlush: func(stats pipeline.BatchStats) {
+				log.V(2).Info("batched data for ingest", "count", stats.Items, "bytes", stats.Bytes, "limit", stats.Limit, "queued", stats.Queue, "nextLimit", stats.Next)
+				serviceState.batched(stats)
+			},
+		}, nil
+	}
+}
+
+// marshalForIngest stamps and serializes each item once up front so batches can be sized by their serialized size
+func marshalForIngest(ctx context.Context, in <-chan interface{}) <-chan interface{} {
+	return pipeline.Map(ctx.Done(), in, func(item interface{}) interface{} {
+		if wrapper, ok := item.(collector.Wrapper); ok {
+			item = wrapper.Stamp(time.Now())
+		}
+
+		if bytes, err := json.Marshal(item); err != nil {
+			log.Error(err, "unable to serialize item for ingest", "item", item)
+			return item
+		} else {
+			return json.RawMessage(bytes)
+		}
+	})
+}
+
+// ingestItemSize returns the serialized size of an item serialized by marshalForIngest or read from a collection file
+func ingestItemSize(item interface{}) int {
+	if raw, ok := item.(json.RawMessage); ok {
+		return len(raw)
+	} else {
+		return 0
+	}
+}
+
+func ingestSize(data []interface{}) int {
+	size := 0
+	for _, item := range data {
+		size += ingestItemSize(item)
+	}
+	return size
+}
+
+// ingestBatch sends a batch of data to BloodHound Enterprise, retrying when the instance responds with one of
+// --ingest-retry-status. ErrExceededRetryLimit is returned if it keeps doing so and ErrIngestRejected if it responds
+// with any other error; any other error means the ingest job can't continue.
+func ingestBatch(ctx context.Context, endpoint *url.URL, bheClient *http.Client, data []interface{}) error {
+	var (
+		body = models.IngestRequest{
 			Meta: models.Meta{
-				Type: "azure",
+				Type:    "azure",
+				Version: 5,
+				Count:   len(data),
 			},
 			Data: data,
 		}
+	)
 
-		headers := make(map[string]string)
+	// the instance is only asked to wait for the batch to be processed if it doesn't ignore the request
+	headers := make(map[string]string)
+	if knownIngestCapabilities().CompletionMarkers {
 		headers["Prefer"] = "wait=60"
+	}
 
-		if req, err := rest.NewRequest(ctx, "POST", endpoint, body, nil, headers); err != nil {
-			log.Error(err, unrecoverableErrMsg)
-			return true
-		} else {
-			for retry := 0; retry < maxRetries; retry++ {
-				//No retries on regular err cases, only on HTTP 504 Gateway Timeout and HTTP 503 Service Unavailable
-				if response, err := bheClient.Do(req); err != nil {
-					log.Error(err, unrecoverableErrMsg)
-					return true
-				} else if response.StatusCode == http.StatusGatewayTimeout || response.StatusCode == http.StatusServiceUnavailable {
-					backoff := math.Pow(5, float64(retry+1))
-					time.Sleep(time.Second * time.Duration(backoff))
-					if retry == maxRetries-1 {
-						log.Error(ErrExceededRetryLimit, "")
-						hasErrors = true
-					}
-					continue
-				} else if response.StatusCode != http.StatusAccepted {
-					if bodyBytes, err := io.ReadAll(response.Body); err != nil {
-						log.Error(fmt.Errorf("received unexpected response code from %v: %s; failure reading response body", endpoint, response.Status), unrecoverableErrMsg)
-					} else {
-						log.Error(fmt.Errorf("received unexpected response code from %v: %s %s", req.URL, response.Status, bodyBytes), unrecoverableErrMsg)
+	compress := ingestCompression()
+	if retries, err := ingestRetryConfig(); err != nil {
+		return err
+	} else if req, err := newIngestRequest(ctx, endpoint, body, headers, compress); err != nil {
+		return err
+	} else {
+		for retry := 0; retry <= retries.max; retry++ {
+			// Reusing http.Request requires rewinding the request body back to a working state
+			if retry > 0 {
+				if err := rest.RewindBody(req); err != nil {
+					return err
+				}
+			}
+
+			// No retries on regular err cases, only while the instance is busy or throttling: by default HTTP 429 Too Many
+			// Requests, HTTP 503 Service Unavailable and HTTP 504 Gateway Timeout
+			if response, err := bheClient.Do(req); err != nil {
+				return err
+			} else if retries.retryable(response.StatusCode) {
+				response.Body.Close()
+				if retry < retries.max {
+					backoff := retries.backoff(retry, response.Header.Get("Retry-After"), time.Now())
+					log.V(1).Info("bloodhound enterprise is busy, retrying ingest batch", "status", response.Status, "retry", retry+1, "backoff", backoff.String())
+					if err := sleep(ctx, backoff); err != nil {
+						return err
 					}
-					return true
 				}
+				continue
+			} else if response.StatusCode == http.StatusUnsupportedMediaType && compress {
+				response.Body.Close()
+				rejectIngestCompression("the instance responded with " + response.Status)
+				return ingestBatch(ctx, endpoint, bheClient, data)
+			} else if response.StatusCode != http.StatusAccepted {
+				if bodyBytes, err := io.ReadAll(response.Body); err != nil {
+					return fmt.Errorf("%w from %v: %s; failure reading response body", ErrIngestRejected, endpoint, response.Status)
+				} else {
+					return fmt.Errorf("%w from %v: %s %s", ErrIngestRejected, req.URL, response.Status, bodyBytes)
+				}
+			} else {
+				return nil
+			}
+		}
+		return ErrExceededRetryLimit
+	}
+}
+
+const ingestMaxRetriesLimit = 10
+
+// ingestRetries bounds how often a busy or throttling instance is retried and how long is waited between attempts
+type ingestRetries struct {
+	max        int
+	maxBackoff time.Duration
+	statuses   map[int]struct{}
+}
+
+func ingestRetryConfig() (ingestRetries, error) {
+	maxRetries := config.IngestMaxRetries.Value().(int)
+	if maxBackoff, err := durationValue(config.IngestRetryMaxBackoff); err != nil {
+		return ingestRetries{}, err
+	} else if maxRetries < 0 || maxRetries > ingestMaxRetriesLimit {
+		return ingestRetries{}, fmt.Errorf("invalid --%s: %d is not between 0 and %d", config.IngestMaxRetries.Name, maxRetries, ingestMaxRetriesLimit)
+	} else if maxBackoff < 0 {
+		return ingestRetries{}, fmt.Errorf("invalid --%s: %s is negative", config.IngestRetryMaxBackoff.Name, maxBackoff)
+	} else if statuses, err := ingestRetryStatuses(); err != nil {
+		return ingestRetries{}, err
+	} else {
+		return ingestRetries{max: maxRetries, maxBackoff: maxBackoff, statuses: statuses}, nil
+	}
+}
+
+// ingestRetryStatuses returns the status codes of --ingest-retry-status. Only 429 Too Many Requests and server errors
+// may be retried; any other client error would be rejected again.
+func ingestRetryStatuses() (map[int]struct{}, error) {
+	statuses := map[int]struct{}{}
+	for _, value := range config.IngestRetryStatus.Value().([]string) {
+		if status, err := strconv.Atoi(strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %q is not a status code", config.IngestRetryStatus.Name, value)
+		} else if status != http.StatusTooManyRequests && (status < 500 || status > 599) {
+			return nil, fmt.Errorf("invalid --%s: %d can't be retried, only 429 and 5xx status codes can", config.IngestRetryStatus.Name, status)
+		} else {
+			statuses[status] = struct{}{}
+		}
+	}
+	return statuses, nil
+}
+
+func (s ingestRetries) retryable(status int) bool {
+	_, ok := s.statuses[status]
+	return ok
+}
+
+// backoff returns how long to wait before the given retry. The wait the instance asked for with Retry-After is used
+// as is; otherwise the 5 second exponential backoff is jittered so collectors throttled together don't retry together.
+// Both are bounded by the maximum backoff.
+func (s ingestRetries) backoff(retry int, retryAfter string, now time.Time) time.Duration {
+	if backoff, ok := parseRetryAfter(retryAfter, now); ok {
+		return s.bound(backoff)
+	} else {
+		backoff := s.bound(time.Second * time.Duration(math.Pow(5, float64(retry+1))))
+		return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	}
+}
+
+func (s ingestRetries) bound(backoff time.Duration) time.Duration {
+	if s.maxBackoff > 0 && backoff > s.maxBackoff {
+		return s.maxBackoff
+	} else {
+		return backoff
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	} else if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	} else if date, err := http.ParseTime(value); err != nil {
+		return 0, false
+	} else if date.Before(now) {
+		return 0, true
+	} else {
+		return date.Sub(now), true
+	}
+}
+
+// sleep pauses for the given duration, returning early with the context's error if it is cancelled first
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ingestCompressionRejected is set once BloodHound Enterprise is found not to accept compressed ingest requests, so
+// the rest are sent uncompressed
+var ingestCompressionRejected atomic.Bool
+
+func ingestCompression() bool {
+	return config.IngestCompression.Value().(bool) && !ingestCompressionRejected.Load() && knownIngestCapabilities().accepts("gzip")
+}
+
+func rejectIngestCompression(reason string) {
+	if !ingestCompressionRejected.Swap(true) {
+		log.Info("note: sending ingest requests uncompressed; BloodHound Enterprise did not accept gzip compressed requests", "reason", reason)
+	}
+}
+
+// newIngestRequest encodes body as JSON, gzip compressing it when compress is set. The signing transport signs the
+// body as it is sent, so a compressed request is signed over the compressed bytes.
+func newIngestRequest(ctx context.Context, endpoint *url.URL, body interface{}, headers map[string]string, compress bool) (*http.Request, error) {
+	if !compress {
+		return rest.NewRequest(ctx, http.MethodPost, endpoint, body, nil, headers)
+	}
+
+	var (
+		data   bytes.Buffer
+		writer = gzip.NewWriter(&data)
+	)
+	if err := json.NewEncoder(writer).Encode(body); err != nil {
+		return nil, err
+	} else if err := writer.Close(); err != nil {
+		return nil, err
+	} else if req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), &data); err != nil {
+		return nil, err
+	} else {
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", constants.UserAgent())
+		return req, nil
+	}
+}
+
+// the retries of a request to start or end a task that failed for a reason that is likely to pass, and the backoff
+// before the first of them, which doubles with each one
+var (
+	bheRequestRetries = 3
+	bheRequestBackoff = 2 * time.Second
+)
+
+// retryBHERequest calls request until it succeeds, fails with an error that isn't transient or runs out of retries,
+// and returns its last error
+func retryBHERequest(ctx context.Context, name string, request func() error) error {
+	backoff := bheRequestBackoff
+	for retry := 0; ; retry++ {
+		if err := request(); err == nil || retry >= bheRequestRetries || !transientBHEError(ctx, err) {
+			return err
+		} else {
+			log.V(1).Info("bloodhound enterprise request failed, retrying", "request", name, "retry", retry+1, "backoff", backoff.String(), "reason", err.Error())
+			if err := sleep(ctx, backoff); err != nil {
+				return err
 			}
+			backoff *= 2
 		}
 	}
-	return hasErrors
+}
+
+// transientBHEError reports whether err is likely to pass: the instance couldn't be reached, was busy or failed with
+// a server error. Other client errors would fail again.
+func transientBHEError(ctx context.Context, err error) bool {
+	var (
+		resErr bheResponseError
+		urlErr *url.Error
+	)
+	if ctx.Err() != nil {
+		return false
+	} else if errors.As(err, &resErr) {
+		return resErr.StatusCode == http.StatusTooManyRequests || resErr.StatusCode >= http.StatusInternalServerError
+	} else {
+		return errors.As(err, &urlErr)
+	}
+}
+
+// bheResponseError is returned by do when BloodHound Enterprise responds with an unsuccessful status code
+type bheResponseError struct {
+	StatusCode int
+	message    string
+}
+
+func (s bheResponseError) Error() string {
+	return s.message
 }
 
 // TODO: create/use a proper bloodhound client
@@ -229,81 +1033,121 @@ func do(bheClient *http.Client, req *http.Request) (*http.Response, error) {
 		var body json.RawMessage
 		defer res.Body.Close()
 		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
-			return nil, fmt.Errorf("received unexpected response code from %v: %s; failure reading response body", req.URL, res.Status)
+			return nil, bheResponseError{res.StatusCode, fmt.Sprintf("received unexpected response code from %v: %s; failure reading response body", req.URL, res.Status)}
 		} else {
-			return nil, fmt.Errorf("received unexpected response code from %v: %s %s", req.URL, res.Status, body)
+			return nil, bheResponseError{res.StatusCode, fmt.Sprintf("received unexpected response code from %v: %s %s", req.URL, res.Status, body)}
 		}
 	} else {
 		return res, nil
 	}
 }
 
+// getAvailableTasks lists every task available to this client, following the pagination of newer instances
 func getAvailableTasks(ctx context.Context, bheUrl url.URL, bheClient *http.Client) ([]models.ClientTask, error) {
+	return getBHEList[models.ClientTask](ctx, bheUrl, bheClient, "/api/v1/clients/availabletasks")
+}
+
+// checkin tells BloodHound Enterprise the job is still being collected. Instances respond with 404 Not Found once the
+// job is deleted and report a canceled status, or another job, once an operator cancels it; both are reported as
+// ErrJobCancelled. Responses without a job are taken to mean the job is still running.
+func checkin(ctx context.Context, bheUrl url.URL, bheClient *http.Client, jobId int) error {
 	var (
-		endpoint = bheUrl.ResolveReference(&url.URL{Path: "/api/v1/clients/availabletasks"})
-		response []models.ClientTask
+		endpoint = bheEndpoint(bheUrl, "/api/v2/jobs/current")
+		job      *models.ClientTask
+		resErr   bheResponseError
 	)
 
 	if req, err := rest.NewRequest(ctx, "GET", endpoint, nil, nil, nil); err != nil {
-		return nil, err
-	} else if res, err := do(bheClient, req); err != nil {
-		return nil, err
-	} else if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return nil, err
+		return err
+	} else if res, err := do(bheClient, req); errors.As(err, &resErr) && resErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %v", ErrJobCancelled, err)
+	} else if err != nil {
+		return err
 	} else {
-		return response, nil
+		defer res.Body.Close()
+		if _, err := decodeBHEResponse(res.Body, &job); err != nil || job == nil {
+			return nil
+		} else if job.Id != 0 && job.Id != jobId {
+			return fmt.Errorf("%w: job %d is no longer the current job, found job %d", ErrJobCancelled, jobId, job.Id)
+		} else if models.JobStatus(job.Status) == models.JobStatusCanceled {
+			return fmt.Errorf("%w: job %d was cancelled", ErrJobCancelled, jobId)
+		} else {
+			return nil
+		}
 	}
 }
 
-func checkin(ctx context.Context, bheUrl url.URL, bheClient *http.Client) error {
-	endpoint := bheUrl.ResolveReference(&url.URL{Path: "/api/v2/jobs/current"})
+// sendProgress reports the progress of the current job to BloodHound Enterprise. Instances that predate progress
+// reports respond with 404 Not Found or 405 Method Not Allowed, which is reported as ErrProgressUnsupported.
+func sendProgress(ctx context.Context, bheUrl url.URL, bheClient *http.Client, progress models.JobProgressRequest) error {
+	var (
+		endpoint = bheEndpoint(bheUrl, "/api/v2/jobs/current/progress")
+		resErr   bheResponseError
+	)
 
-	if req, err := rest.NewRequest(ctx, "GET", endpoint, nil, nil, nil); err != nil {
+	if req, err := rest.NewRequest(ctx, "POST", endpoint, progress, nil, nil); err != nil {
 		return err
-	} else if _, err := do(bheClient, req); err != nil {
+	} else if res, err := do(bheClient, req); errors.As(err, &resErr) && (resErr.StatusCode == http.StatusNotFound || resErr.StatusCode == http.StatusMethodNotAllowed) {
+		return fmt.Errorf("%w: %v", ErrProgressUnsupported, err)
+	} else if err != nil {
 		return err
 	} else {
+		res.Body.Close()
 		return nil
 	}
 }
 
-func startTask(ctx context.Context, bheUrl url.URL, bheClient *http.Client, taskId int) error {
+// startTask notifies BloodHound Enterprise that collection of the tenant has begun. Instances that track which client
+// collects a tenant respond with 409 Conflict when another client owns it, which is reported as ErrTenantOwned.
+func startTask(ctx context.Context, bheUrl url.URL, bheClient *http.Client, taskId int, tenantId string) error {
 	log.Info("beginning collection task", "id", taskId)
 	var (
-		endpoint = bheUrl.ResolveReference(&url.URL{Path: "/api/v1/clients/starttask"})
-		body     = map[string]int{
-			"id": taskId,
+		endpoint = bheEndpoint(bheUrl, "/api/v1/clients/starttask")
+		body     = models.StartTaskRequest{
+			Id:       taskId,
+			TenantId: tenantId,
 		}
+		resErr bheResponseError
 	)
 
 	if req, err := rest.NewRequest(ctx, "POST", endpoint, body, nil, nil); err != nil {
 		return err
-	} else if _, err := do(bheClient, req); err != nil {
+	} else if _, err := do(bheClient, req); errors.As(err, &resErr) && resErr.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %v", ErrTenantOwned, err)
+	} else if err != nil {
 		return err
 	} else {
 		return nil
 	}
 }
 
+// endTask notifies BloodHound Enterprise that the current job ended with the given status. Instances that predate the
+// partially complete status reject it with 400 Bad Request, in which case the job is ended as complete; the message
+// still describes what is missing.
 func endTask(ctx context.Context, bheUrl url.URL, bheClient *http.Client, status models.JobStatus, message string) error {
-	endpoint := bheUrl.ResolveReference(&url.URL{Path: "/api/v2/jobs/end"})
-
-	body := models.CompleteJobRequest{
-		Status:  status.String(),
-		Message: message,
-	}
+	var (
+		endpoint = bheEndpoint(bheUrl, "/api/v2/jobs/end")
+		body     = models.CompleteJobRequest{
+			Status:  status.String(),
+			Message: message,
+		}
+		resErr bheResponseError
+	)
 
 	if req, err := rest.NewRequest(ctx, "POST", endpoint, body, nil, nil); err != nil {
 		return err
-	} else if _, err := do(bheClient, req); err != nil {
+	} else if _, err := do(bheClient, req); errors.As(err, &resErr) && resErr.StatusCode == http.StatusBadRequest && status == models.JobStatusPartiallyComplete {
+		log.V(1).Info("bloodhound enterprise does not support the partially complete job status, ending the job as complete", "reason", err.Error())
+		return endTask(ctx, bheUrl, bheClient, models.JobStatusComplete, message)
+	} else if err != nil {
 		return err
 	} else {
 		return nil
 	}
 }
 
-func updateClient(ctx context.Context, bheUrl url.URL, bheClient *http.Client) error {
-	endpoint := bheUrl.ResolveReference(&url.URL{Path: "/api/v1/clients/update"})
+func updateClient(ctx context.Context, bheUrl url.URL, bheClient *http.Client, tenantId string) error {
+	endpoint := bheEndpoint(bheUrl, "/api/v1/clients/update")
 	if addr, err := dial(bheUrl.String()); err != nil {
 		return err
 	} else {
@@ -314,6 +1158,7 @@ func updateClient(ctx context.Context, bheUrl url.URL, bheClient *http.Client) e
 			Address:  addr,
 			Hostname: hostname,
 			Version:  constants.Version,
+			TenantId: tenantId,
 		}
 
 		log.V(2).Info("updating client info", "info", body)
@@ -0,0 +1,1197 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func TestUpdateClientReportsTenant(t *testing.T) {
+	var body models.UpdateClientRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := updateClient(context.Background(), *bheUrl, server.Client(), "tenant"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if body.TenantId != "tenant" {
+		t.Errorf("got %v, want %v", body.TenantId, "tenant")
+	}
+}
+
+func TestStartTask(t *testing.T) {
+	var body models.StartTaskRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	// instances that don't track tenant ownership accept the task as before
+	bheUrl, _ := url.Parse(server.URL)
+	if err := startTask(context.Background(), *bheUrl, server.Client(), 1, "tenant"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if body.Id != 1 || body.TenantId != "tenant" {
+		t.Errorf("unexpected request: %+v", body)
+	}
+}
+
+func TestEndTaskPartiallyCompleteUnsupported(t *testing.T) {
+	var statuses []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body models.CompleteJobRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		statuses = append(statuses, body.Status)
+		if body.Status == models.JobStatusPartiallyComplete.String() {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"invalid status"}]}`))
+		} else {
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	// instances that predate the partially complete status end the job as complete instead
+	bheUrl, _ := url.Parse(server.URL)
+	if err := endTask(context.Background(), *bheUrl, server.Client(), models.JobStatusPartiallyComplete, "partial"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if actual := strings.Join(statuses, ","); actual != "PARTIALLYCOMPLETE,COMPLETE" {
+		t.Errorf("got %v, want %v", actual, "PARTIALLYCOMPLETE,COMPLETE")
+	}
+}
+
+func TestStartTaskTenantOwned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"errors":[{"message":"tenant is collected by another client"}]}`))
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := startTask(context.Background(), *bheUrl, server.Client(), 1, "tenant"); !errors.Is(err, ErrTenantOwned) {
+		t.Errorf("got %v, want %v", err, ErrTenantOwned)
+	}
+
+	// uploads decline the task the same way
+	if err := runUploadTask(context.Background(), *bheUrl, server.Client(), models.ClientTask{Id: 1}, "tenant", nil, pipeline.AdaptiveBatchConfig[interface{}]{}); !errors.Is(err, ErrTenantOwned) {
+		t.Errorf("got %v, want %v", err, ErrTenantOwned)
+	}
+}
+
+// decodeIngestRequest decodes the body of an ingest request into v, decompressing it when it is gzip compressed
+func decodeIngestRequest(r *http.Request, v interface{}) error {
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		return json.NewDecoder(r.Body).Decode(v)
+	} else if reader, err := gzip.NewReader(r.Body); err != nil {
+		return err
+	} else {
+		defer reader.Close()
+		return json.NewDecoder(reader).Decode(v)
+	}
+}
+
+func TestIngestBatchCount(t *testing.T) {
+	var body struct {
+		Meta models.Meta       `json:"meta"`
+		Data []json.RawMessage `json:"data"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decodeIngestRequest(r, &body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	data := []interface{}{json.RawMessage(`{"kind":"AZUser"}`), json.RawMessage(`{"kind":"AZGroup"}`)}
+	if err := ingestBatch(context.Background(), endpoint, server.Client(), data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if body.Meta.Count != len(body.Data) || body.Meta.Count != 2 {
+		t.Errorf("got a count of %v for %v items, want 2", body.Meta.Count, len(body.Data))
+	} else if body.Meta.Type != "azure" || body.Meta.Version != 5 {
+		t.Errorf("unexpected meta: %+v", body.Meta)
+	}
+}
+
+func TestIngestBatchCompression(t *testing.T) {
+	var (
+		encodings []string
+		signed    []bool
+		count     int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body models.IngestRequest
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		signed = append(signed, r.Header.Get("Signature") != "")
+		if err := decodeIngestRequest(r, &body); err != nil {
+			t.Errorf("unable to decode ingest request: %v", err)
+		}
+		count = body.Meta.Count
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Cleanup(func() {
+		config.IngestCompression.Set(true)
+		ingestCompressionRejected.Store(false)
+	})
+
+	bheClient, err := newSigningHttpClient(BHEAuthSignature, "tokenId", "token", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	data := []interface{}{json.RawMessage(`{"kind":"AZUser"}`)}
+	if err := ingestBatch(context.Background(), endpoint, bheClient, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config.IngestCompression.Set(false)
+	if err := ingestBatch(context.Background(), endpoint, bheClient, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if strings.Join(encodings, ",") != "gzip," {
+		t.Errorf("got content encodings %q, want %q", encodings, []string{"gzip", ""})
+	} else if !signed[0] || !signed[1] || count != 1 {
+		t.Errorf("expected signed requests of 1 item, got signed %v and a count of %v", signed, count)
+	}
+}
+
+func TestIngestBatchCompressionRejected(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	t.Cleanup(func() { ingestCompressionRejected.Store(false) })
+
+	// the rejected batch is resent uncompressed, as are the batches after it
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	for i := 0; i < 2; i++ {
+		if err := ingestBatch(context.Background(), endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if strings.Join(encodings, ",") != "gzip,," {
+		t.Errorf("got content encodings %q, want %q", encodings, []string{"gzip", "", ""})
+	}
+}
+
+func TestIngestBatchRetries(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		statuses = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusAccepted}
+		requests int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var body models.IngestRequest
+		if err := decodeIngestRequest(r, &body); err != nil || body.Meta.Count != 1 {
+			t.Errorf("got a count of %v after retry %v: %v", body.Meta.Count, requests, err)
+		}
+		if requests == 0 {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(statuses[requests%len(statuses)])
+		requests++
+	}))
+	defer server.Close()
+	t.Cleanup(func() {
+		config.IngestMaxRetries.Set(3)
+		config.IngestRetryMaxBackoff.Set("2m")
+	})
+
+	config.IngestRetryMaxBackoff.Set("10ms")
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	if err := ingestBatch(context.Background(), endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if requests != 4 {
+		t.Errorf("got %v requests, want 4", requests)
+	}
+
+	requests = 0
+	config.IngestMaxRetries.Set(1)
+	if err := ingestBatch(context.Background(), endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); !errors.Is(err, ErrExceededRetryLimit) {
+		t.Errorf("got %v, want %v", err, ErrExceededRetryLimit)
+	} else if requests != 2 {
+		t.Errorf("got %v requests, want 2", requests)
+	}
+}
+
+func TestIngestBatchRetryRewindsBody(t *testing.T) {
+	var (
+		mutex  sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var body json.RawMessage
+		if err := decodeIngestRequest(r, &body); err != nil {
+			t.Errorf("unable to decode ingest request %v: %v", len(bodies), err)
+		}
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	t.Cleanup(func() {
+		config.IngestCompression.Set(true)
+		config.IngestRetryMaxBackoff.Set("2m")
+	})
+	config.IngestRetryMaxBackoff.Set("10ms")
+
+	signingClient, err := newSigningHttpClient(BHEAuthSignature, "tokenId", "token", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var (
+		endpoint, _ = url.Parse(server.URL + "/api/v2/ingest")
+		data        = []interface{}{json.RawMessage(`{"kind":"AZUser","data":{"id":"1"}}`)}
+		want, _     = json.Marshal(models.IngestRequest{Meta: models.Meta{Type: "azure", Version: 5, Count: 1}, Data: data})
+	)
+	for _, compress := range []bool{true, false} {
+		for name, bheClient := range map[string]*http.Client{"unsigned": server.Client(), "signed": signingClient} {
+			bodies = nil
+			config.IngestCompression.Set(compress)
+			if err := ingestBatch(context.Background(), endpoint, bheClient, data); err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			} else if len(bodies) != 3 {
+				t.Fatalf("%s: got %v requests, want 3", name, len(bodies))
+			}
+			for i, body := range bodies {
+				if body != string(want) {
+					t.Errorf("%s, compressed %v: got body %q for attempt %v, want %q", name, compress, body, i+1, want)
+				}
+			}
+		}
+	}
+}
+
+func TestIngestBatchRetryCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	if err := ingestBatch(ctx, endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	} else if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("backoff took %v to return after cancellation", elapsed)
+	}
+}
+
+func TestIngestRetryBackoff(t *testing.T) {
+	var (
+		now     = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		retries = ingestRetries{max: 3, maxBackoff: time.Minute}
+	)
+
+	for header, want := range map[string]time.Duration{
+		"30":                            30 * time.Second,
+		"600":                           time.Minute,
+		"Tue, 02 Jan 2024 15:04:25 GMT": 20 * time.Second,
+		"Tue, 02 Jan 2024 15:00:00 GMT": 0,
+	} {
+		if backoff := retries.backoff(0, header, now); backoff != want {
+			t.Errorf("%s: got %v, want %v", header, backoff, want)
+		}
+	}
+
+	// without a usable Retry-After the exponential backoff is jittered between half and all of it
+	for retry, want := range []time.Duration{5 * time.Second, 25 * time.Second, time.Minute} {
+		for _, header := range []string{"", "-1", "soon"} {
+			if backoff := retries.backoff(retry, header, now); backoff < want/2 || backoff > want {
+				t.Errorf("retry %v: got %v, want between %v and %v", retry, backoff, want/2, want)
+			}
+		}
+	}
+}
+
+func TestIngestRetryConfig(t *testing.T) {
+	t.Cleanup(func() {
+		config.IngestMaxRetries.Set(3)
+		config.IngestRetryMaxBackoff.Set("2m")
+	})
+
+	if retries, err := ingestRetryConfig(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if retries.max != 3 || retries.maxBackoff != 2*time.Minute {
+		t.Errorf("unexpected defaults: %+v", retries)
+	}
+
+	for _, value := range []int{-1, 11} {
+		config.IngestMaxRetries.Set(value)
+		if _, err := ingestRetryConfig(); err == nil {
+			t.Errorf("%v: expected an error but did not receive one", value)
+		}
+	}
+
+	config.IngestMaxRetries.Set(3)
+	for _, value := range []string{"-1s", "soon"} {
+		config.IngestRetryMaxBackoff.Set(value)
+		if _, err := ingestRetryConfig(); err == nil {
+			t.Errorf("%v: expected an error but did not receive one", value)
+		}
+	}
+}
+
+func TestIngestRetryStatuses(t *testing.T) {
+	t.Cleanup(func() { config.IngestRetryStatus.Set([]string{"429", "503", "504"}) })
+
+	if retries, err := ingestRetryConfig(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !retries.retryable(http.StatusServiceUnavailable) || retries.retryable(http.StatusInternalServerError) {
+		t.Errorf("unexpected default statuses: %v", retries.statuses)
+	}
+
+	config.IngestRetryStatus.Set([]string{"429", "500", "503"})
+	if retries, err := ingestRetryConfig(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !retries.retryable(http.StatusInternalServerError) || retries.retryable(http.StatusGatewayTimeout) {
+		t.Errorf("unexpected statuses: %v", retries.statuses)
+	}
+
+	for _, value := range []string{"400", "404", "202", "5xx"} {
+		config.IngestRetryStatus.Set([]string{value})
+		if _, err := ingestRetryConfig(); err == nil {
+			t.Errorf("%v: expected an error but did not receive one", value)
+		}
+	}
+}
+
+func TestIngestBatchRetryServerError(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	t.Cleanup(func() {
+		config.IngestRetryStatus.Set([]string{"429", "503", "504"})
+		config.IngestRetryMaxBackoff.Set("2m")
+	})
+	config.IngestRetryMaxBackoff.Set("10ms")
+
+	endpoint, _ := url.Parse(server.URL + "/api/v2/ingest")
+	if err := ingestBatch(context.Background(), endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); !errors.Is(err, ErrIngestRejected) {
+		t.Errorf("got %v, want %v", err, ErrIngestRejected)
+	} else if requests != 1 {
+		t.Errorf("got %v requests, want 1", requests)
+	}
+
+	atomic.StoreInt64(&requests, 0)
+	config.IngestRetryStatus.Set([]string{"500"})
+	if err := ingestBatch(context.Background(), endpoint, server.Client(), []interface{}{json.RawMessage(`{}`)}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if requests != 2 {
+		t.Errorf("got %v requests, want 2", requests)
+	}
+}
+
+func TestIngestConcurrency(t *testing.T) {
+	var (
+		mutex            sync.Mutex
+		inflight, peak   int
+		ingested         int
+		release          = make(chan struct{})
+		concurrencyLimit = 4
+	)
+
+	// each request waits until as many are in flight as there are workers, so a sequential ingest would never finish
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		decodeIngestRequest(r, &body)
+
+		mutex.Lock()
+		inflight++
+		if inflight > peak {
+			peak = inflight
+		}
+		if inflight == concurrencyLimit {
+			close(release)
+		}
+		mutex.Unlock()
+
+		<-release
+		mutex.Lock()
+		inflight--
+		ingested += len(body.Data)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Cleanup(func() { config.IngestConcurrency.Set(1) })
+	config.IngestConcurrency.Set(concurrencyLimit)
+
+	batches := make(chan []interface{}, 8)
+	for i := 0; i < 8; i++ {
+		batches <- []interface{}{json.RawMessage(`"item"`)}
+	}
+	close(batches)
+
+	bheUrl, _ := url.Parse(server.URL)
+	if stats := ingest(context.Background(), *bheUrl, server.Client(), batches); stats.hasErrors() {
+		t.Errorf("unexpected ingest errors: %v", stats)
+	} else if ingested != 8 {
+		t.Errorf("got %v ingested items, want 8", ingested)
+	} else if peak != concurrencyLimit {
+		t.Errorf("got %v concurrent requests, want %v", peak, concurrencyLimit)
+	}
+}
+
+func TestIngestConcurrencyAborted(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	t.Cleanup(func() { config.IngestConcurrency.Set(1) })
+	config.IngestConcurrency.Set(2)
+
+	// the batches never end, so ingest only returns if an unrecoverable error stops every worker
+	var (
+		batches = make(chan []interface{})
+		done    = make(chan struct{})
+	)
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case batches <- []interface{}{json.RawMessage(`"item"`)}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if stats := ingest(context.Background(), *bheUrl, server.Client(), batches); !stats.aborted {
+		t.Errorf("got %+v, want aborted", stats)
+	} else if count := atomic.LoadInt64(&requests); count > 2 {
+		t.Errorf("got %v requests, want at most one per worker", count)
+	}
+
+	for _, value := range []int{0, 17} {
+		config.IngestConcurrency.Set(value)
+		if _, err := ingestConcurrency(); err == nil {
+			t.Errorf("%v: expected an error but did not receive one", value)
+		}
+	}
+}
+
+func TestIngestSkipBadBatch(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		ingested int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Data []string `json:"data"`
+		}
+		decodeIngestRequest(r, &body)
+		if len(body.Data) == 1 && body.Data[0] == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			mutex.Lock()
+			ingested += len(body.Data)
+			mutex.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	t.Cleanup(func() { config.IngestSkipBadBatch.Set(false) })
+
+	newBatches := func() <-chan []interface{} {
+		batches := make(chan []interface{}, 3)
+		batches <- []interface{}{json.RawMessage(`"good"`)}
+		batches <- []interface{}{json.RawMessage(`"bad"`)}
+		batches <- []interface{}{json.RawMessage(`"good"`)}
+		close(batches)
+		return batches
+	}
+
+	bheUrl, _ := url.Parse(server.URL)
+	if stats := ingest(context.Background(), *bheUrl, server.Client(), newBatches()); !stats.aborted {
+		t.Errorf("got %+v, want aborted without --%s", stats, config.IngestSkipBadBatch.Name)
+	}
+
+	ingested = 0
+	config.IngestSkipBadBatch.Set(true)
+	if stats := ingest(context.Background(), *bheUrl, server.Client(), newBatches()); stats.aborted || stats.skipped != 1 {
+		t.Errorf("got %+v, want one skipped batch", stats)
+	} else if ingested != 2 {
+		t.Errorf("got %v ingested items, want 2", ingested)
+	} else if status, _ := collectionOutcome(stats, false); status != models.JobStatusPartiallyComplete {
+		t.Errorf("got %v, want %v", status, models.JobStatusPartiallyComplete)
+	}
+}
+
+func TestPollBackoff(t *testing.T) {
+	backoff := pollBackoff{base: 5 * time.Second, max: 40 * time.Second, current: 5 * time.Second}
+
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 40 * time.Second} {
+		if delay := backoff.next(true, 0); delay != want {
+			t.Errorf("got %v, want %v", delay, want)
+		}
+	}
+
+	// a scheduled task caps the wait without resetting the backoff
+	if delay := backoff.next(true, 15*time.Second); delay != 15*time.Second {
+		t.Errorf("got %v, want %v", delay, 15*time.Second)
+	} else if delay := backoff.next(true, time.Second); delay != 5*time.Second {
+		t.Errorf("got %v, want %v", delay, 5*time.Second)
+	} else if delay := backoff.next(true, 0); delay != 40*time.Second {
+		t.Errorf("got %v, want %v", delay, 40*time.Second)
+	}
+
+	if delay := backoff.next(false, 0); delay != 5*time.Second {
+		t.Errorf("got %v, want %v", delay, 5*time.Second)
+	}
+}
+
+func TestNextExecutionIn(t *testing.T) {
+	now := time.Now()
+	tasks := []models.ClientTask{
+		{Id: 1, ExectionTime: now.Add(-time.Minute)},
+		{Id: 2, ExectionTime: now.Add(time.Hour)},
+		{Id: 3, ExectionTime: now.Add(time.Minute)},
+	}
+
+	if actual := nextExecutionIn(tasks, now); actual != time.Minute {
+		t.Errorf("got %v, want %v", actual, time.Minute)
+	} else if actual := nextExecutionIn(tasks[:1], now); actual != 0 {
+		t.Errorf("got %v, want %v", actual, 0)
+	}
+}
+
+func TestStartIntervals(t *testing.T) {
+	t.Cleanup(func() {
+		config.CheckinInterval.Set(config.CheckinInterval.Default)
+		config.TaskPollInterval.Set(config.TaskPollInterval.Default)
+		config.PollMaxInterval.Set(config.PollMaxInterval.Default)
+	})
+
+	if intervals, err := startIntervals(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if intervals.checkin != 5*time.Second || intervals.poll != 5*time.Second {
+		t.Errorf("got %+v, want the 5 second defaults", intervals)
+	}
+
+	// a maximum below the poll interval disables the backoff
+	config.TaskPollInterval.Set("2m")
+	if intervals, err := startIntervals(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if intervals.pollMax != 2*time.Minute {
+		t.Errorf("got %v, want %v", intervals.pollMax, 2*time.Minute)
+	}
+
+	for _, cfg := range []config.Config{config.CheckinInterval, config.TaskPollInterval} {
+		for _, value := range []string{"", "500ms", "-1s"} {
+			cfg.Set(value)
+			if _, err := startIntervals(); err == nil {
+				t.Errorf("%s=%q: expected an error but did not receive one", cfg.Name, value)
+			}
+		}
+		cfg.Set(cfg.Default)
+	}
+}
+
+func TestStartupJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if jitter := startupJitter(5 * time.Second); jitter < 0 || jitter >= 5*time.Second {
+			t.Fatalf("got %v, want a jitter within the poll interval", jitter)
+		}
+	}
+}
+
+func TestIngestBatchConfig(t *testing.T) {
+	t.Cleanup(func() {
+		config.BatchSize.Set(config.BatchSize.Default)
+		config.BatchFlushInterval.Set(config.BatchFlushInterval.Default)
+	})
+
+	if cfg, err := ingestBatchConfig(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if cfg.MinItems != ingestBatchMinItems || cfg.MaxItems != 2048 || cfg.MaxTimeout != 10*time.Second || cfg.MaxBytes != ingestBatchMaxBytes {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+
+	// a batch size below the adaptive minimum fixes the size of every batch
+	config.BatchSize.Set(16)
+	config.BatchFlushInterval.Set("1m")
+	if cfg, err := ingestBatchConfig(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if cfg.MinItems != 16 || cfg.MaxItems != 16 || cfg.MaxTimeout != time.Minute {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	for _, invalid := range []struct {
+		size     int
+		interval string
+	}{{0, "10s"}, {ingestBatchMaxItems + 1, "10s"}, {256, "100ms"}, {256, "1h"}, {256, "soon"}} {
+		config.BatchSize.Set(invalid.size)
+		config.BatchFlushInterval.Set(invalid.interval)
+		if _, err := ingestBatchConfig(); err == nil {
+			t.Errorf("%d items, %s: expected an error but did not receive one", invalid.size, invalid.interval)
+		}
+	}
+}
+
+func TestRunTaskCancelled(t *testing.T) {
+	const checkinInterval = 50 * time.Millisecond
+	var (
+		mutex     sync.Mutex
+		cancelled bool
+		endJob    models.CompleteJobRequest
+	)
+
+	// the job is cancelled in the instance once the first batch is ingested
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v2/ingest":
+			cancelled = true
+			w.WriteHeader(http.StatusAccepted)
+		case "/api/v2/jobs/current":
+			status := models.JobStatusRunning
+			if cancelled {
+				status = models.JobStatusCanceled
+			}
+			json.NewEncoder(w).Encode(map[string]models.ClientTask{"data": {Id: 1, Status: int(status)}})
+		case "/api/v2/jobs/end":
+			json.NewDecoder(r.Body).Decode(&endJob)
+			w.Write([]byte("{}"))
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	var (
+		started = time.Now()
+		stopped time.Time
+		collect = func(ctx context.Context, options collector.Options) <-chan interface{} {
+			out := make(chan interface{})
+			go func() {
+				defer close(out)
+				defer func() { stopped = time.Now() }()
+				for {
+					select {
+					case out <- collector.AzureWrapper{Kind: enums.KindAZUser, Data: map[string]string{"id": "1"}}:
+						time.Sleep(time.Millisecond)
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return out
+		}
+		batchConfig = pipeline.AdaptiveBatchConfig[interface{}]{MinItems: 1, MaxItems: 10, MaxTimeout: 10 * time.Millisecond}
+	)
+
+	bheUrl, _ := url.Parse(server.URL)
+	runTask(context.Background(), *bheUrl, server.Client(), models.ClientTask{Id: 1}, "tenant", collect, batchConfig, checkinInterval)
+
+	// the first batch is ingested well before the first checkin, which sees the cancellation and stops collection
+	if stopped.IsZero() {
+		t.Fatal("collection did not stop")
+	} else if elapsed := stopped.Sub(started); elapsed > 2*checkinInterval {
+		t.Errorf("collection took %v to stop, want at most one checkin interval after cancellation", elapsed)
+	} else if endJob.Status != models.JobStatusCanceled.String() {
+		t.Errorf("got job status %v, want %v", endJob.Status, models.JobStatusCanceled.String())
+	}
+}
+
+func TestCheckin(t *testing.T) {
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if response == "" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"no job found"}]}`))
+		} else {
+			w.Write([]byte(response))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	for _, tc := range []struct {
+		response  string
+		cancelled bool
+	}{
+		{`{}`, false},
+		{`{"data":{"id":1,"status":1}}`, false},
+		{`{"data":{"id":1,"status":3}}`, true},
+		{`{"data":{"id":2,"status":1}}`, true},
+		{"", true},
+	} {
+		response = tc.response
+		if err := checkin(context.Background(), *bheUrl, server.Client(), 1); errors.Is(err, ErrJobCancelled) != tc.cancelled {
+			t.Errorf("%q: got %v, want cancelled %v", tc.response, err, tc.cancelled)
+		}
+	}
+}
+
+func TestWatchJobReportsProgress(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		reports  []models.JobProgressRequest
+		attempts int
+		ctx, end = context.WithCancel(context.Background())
+	)
+	defer end()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v2/jobs/current":
+			w.Write([]byte(`{"data":{"id":1,"status":1}}`))
+		case "/api/v2/jobs/current/progress":
+			var body models.JobProgressRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			reports = append(reports, body)
+			if len(reports) == 2 {
+				end()
+			}
+			w.Write([]byte("{}"))
+		default:
+			attempts++
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	progress := trackProgress()
+	t.Cleanup(func() { currentProgress.Store(nil) })
+	items := make(chan interface{}, 2)
+	items <- collector.AzureWrapper{Kind: enums.KindAZUser}
+	items <- collector.AzureWrapper{Kind: enums.KindAZGroup}
+	close(items)
+	for range progress.observe(ctx, items) {
+	}
+	progress.CollectorStarted("users", "ad objects")
+	progress.batchSent()
+
+	bheUrl, _ := url.Parse(server.URL)
+	watchJob(ctx, *bheUrl, server.Client(), 1, 10*time.Millisecond, func(error) {}, progress)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(reports) != 2 {
+		t.Fatalf("got %d progress reports, want 2", len(reports))
+	} else if report := reports[0]; report.Total != 2 || report.Counts[string(enums.KindAZUser)] != 1 || report.BatchesSent != 1 || strings.Join(report.Stages, ",") != "ad objects" {
+		t.Errorf("unexpected progress report: %+v", report)
+	} else if attempts != 0 {
+		t.Errorf("got %d unexpected requests", attempts)
+	}
+}
+
+func TestWatchJobProgressUnsupported(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		checkins int
+		reports  int
+		ctx, end = context.WithCancel(context.Background())
+	)
+	defer end()
+
+	// instances that predate progress reports are only sent the first one
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.URL.Path == "/api/v2/jobs/current/progress" {
+			reports++
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"not found"}]}`))
+		} else {
+			if checkins++; checkins == 3 {
+				end()
+			}
+			w.Write([]byte(`{"data":{"id":1,"status":1}}`))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	watchJob(ctx, *bheUrl, server.Client(), 1, 10*time.Millisecond, func(error) {}, &runProgress{counts: map[enums.Kind]int{}, active: map[string]activeCollector{}})
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if reports != 1 {
+		t.Errorf("got %d progress reports, want 1", reports)
+	}
+}
+
+func TestTaskRunnerSerializesPolls(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		inflight int
+		peak     int
+		requests []string
+	)
+
+	// every response is slow, so polls would overlap if each ran on its own goroutine
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inflight++
+		if inflight > peak {
+			peak = inflight
+		}
+		requests = append(requests, r.URL.Path)
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		switch r.URL.Path {
+		case "/api/v1/clients/availabletasks":
+			json.NewEncoder(w).Encode([]models.ClientTask{{Id: 1}})
+		default:
+			w.Write([]byte("{}"))
+		}
+
+		mutex.Lock()
+		inflight--
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	bheUrl, _ := url.Parse(server.URL)
+	runner := taskRunner{
+		bheUrl:    *bheUrl,
+		bheClient: server.Client(),
+		tenantId:  "tenant",
+		collect: func(ctx context.Context, options collector.Options) <-chan interface{} {
+			out := make(chan interface{})
+			close(out)
+			return out
+		},
+		batchConfig: pipeline.AdaptiveBatchConfig[interface{}]{MinItems: 1, MaxItems: 1, MaxTimeout: time.Millisecond},
+		intervals:   startIntervalConfig{checkin: time.Hour, poll: time.Millisecond, pollMax: time.Millisecond},
+	}
+	runner.run(ctx)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if peak != 1 {
+		t.Errorf("got %v concurrent requests, want 1", peak)
+	}
+
+	// each claimed task is ended before the next poll
+	var jobs []string
+	for _, path := range requests {
+		if path == "/api/v1/clients/starttask" || path == "/api/v2/jobs/end" {
+			jobs = append(jobs, path)
+		}
+	}
+	if len(jobs) < 2 {
+		t.Fatalf("got requests %v, want at least one task started and ended", requests)
+	}
+	for i, path := range jobs {
+		want := "/api/v1/clients/starttask"
+		if i%2 == 1 {
+			want = "/api/v2/jobs/end"
+		}
+		if path != want {
+			t.Fatalf("got %v, want tasks started and ended in turn", jobs)
+		}
+	}
+}
+
+func TestTaskRunnerOnce(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		started int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v1/clients/availabletasks":
+			json.NewEncoder(w).Encode([]models.ClientTask{{Id: 1}})
+		case "/api/v1/clients/starttask":
+			started++
+			w.Write([]byte("{}"))
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	runner := taskRunner{
+		bheUrl:    *bheUrl,
+		bheClient: server.Client(),
+		tenantId:  "tenant",
+		collect: func(ctx context.Context, options collector.Options) <-chan interface{} {
+			out := make(chan interface{})
+			close(out)
+			return out
+		},
+		batchConfig: pipeline.AdaptiveBatchConfig[interface{}]{MinItems: 1, MaxItems: 1, MaxTimeout: time.Millisecond},
+		intervals:   startIntervalConfig{checkin: time.Hour, poll: time.Hour, pollMax: time.Hour},
+		once:        &startOnceConfig{timeout: time.Minute},
+	}
+
+	// polls straight away rather than after the startup jitter and returns once the task ran
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if status, ran := runner.run(context.Background()); !ran {
+			t.Error("expected a task to run")
+		} else if status != models.JobStatusComplete {
+			t.Errorf("got status %v, want %v", status, models.JobStatusComplete)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after the task")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if started != 1 {
+		t.Errorf("got %v tasks started, want 1", started)
+	}
+}
+
+func TestTaskRunnerOnceTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/clients/availabletasks" {
+			w.Write([]byte("[]"))
+		} else {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	runner := taskRunner{
+		bheUrl:    *bheUrl,
+		bheClient: server.Client(),
+		tenantId:  "tenant",
+		intervals: startIntervalConfig{checkin: time.Hour, poll: 10 * time.Millisecond, pollMax: 10 * time.Millisecond},
+		once:      &startOnceConfig{timeout: 50 * time.Millisecond},
+	}
+
+	started := time.Now()
+	if _, ran := runner.run(context.Background()); ran {
+		t.Error("expected no task to run")
+	} else if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("waited %v for a task, want about the timeout", elapsed)
+	}
+}
+
+func TestOnceExitCode(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		ctx    context.Context
+		status models.JobStatus
+		ran    bool
+		want   int
+	}{
+		{context.Background(), models.JobStatusComplete, true, 0},
+		{context.Background(), models.JobStatusPartiallyComplete, true, ExitCodePartialCollection},
+		{context.Background(), models.JobStatusFailed, true, ExitCodeUploadFailure},
+		{context.Background(), models.JobStatusCanceled, true, ExitCodeFailure},
+		{context.Background(), models.JobStatusComplete, false, ExitCodeNoTask},
+		{cancelled, models.JobStatusComplete, true, ExitCodeAborted},
+	}
+	for _, c := range cases {
+		if code := onceExitCode(c.ctx, c.status, c.ran); code != c.want {
+			t.Errorf("status %v, ran %v: got exit code %v, want %v", c.status, c.ran, code, c.want)
+		}
+	}
+}
+
+func TestStartOnce(t *testing.T) {
+	t.Cleanup(func() {
+		config.StartOnce.Set(config.StartOnce.Default)
+		config.OnceTimeout.Set(config.OnceTimeout.Default)
+	})
+
+	if once, err := startOnce(); err != nil || once != nil {
+		t.Errorf("got %v, %v, want no --once configuration", once, err)
+	}
+
+	config.StartOnce.Set(true)
+	if once, err := startOnce(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if once == nil || once.timeout != 10*time.Minute {
+		t.Errorf("got %+v, want the 10 minute default timeout", once)
+	}
+
+	config.OnceTimeout.Set("-1s")
+	if _, err := startOnce(); err == nil {
+		t.Error("expected a negative timeout to be rejected")
+	}
+}
+
+func TestTaskRunnerRetriesStartTask(t *testing.T) {
+	var (
+		mutex     sync.Mutex
+		attempts  = map[int]int{}
+		started   []int
+		ended     int
+		firstPoll = true
+	)
+	t.Cleanup(func() { bheRequestBackoff = 2 * time.Second })
+	bheRequestBackoff = time.Millisecond
+
+	// task 1 fails to start more often than a single poll retries it, and a newer task 2 shows up meanwhile
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.URL.Path {
+		case "/api/v1/clients/availabletasks":
+			if firstPoll {
+				firstPoll = false
+				json.NewEncoder(w).Encode([]models.ClientTask{{Id: 1}})
+			} else {
+				json.NewEncoder(w).Encode([]models.ClientTask{{Id: 2}, {Id: 1}})
+			}
+		case "/api/v1/clients/starttask":
+			var body models.StartTaskRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			if attempts[body.Id]++; attempts[body.Id] <= bheRequestRetries+2 {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(`{"errors":[{"message":"bad gateway"}]}`))
+			} else {
+				started = append(started, body.Id)
+				w.Write([]byte("{}"))
+			}
+		case "/api/v2/jobs/end":
+			if ended++; ended == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("{}"))
+			} else {
+				w.Write([]byte("{}"))
+			}
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	runner := taskRunner{
+		bheUrl:    *bheUrl,
+		bheClient: server.Client(),
+		tenantId:  "tenant",
+		collect: func(ctx context.Context, options collector.Options) <-chan interface{} {
+			out := make(chan interface{})
+			close(out)
+			return out
+		},
+		batchConfig: pipeline.AdaptiveBatchConfig[interface{}]{MinItems: 1, MaxItems: 1, MaxTimeout: time.Millisecond},
+		intervals:   startIntervalConfig{checkin: time.Hour, poll: time.Millisecond, pollMax: time.Millisecond},
+		once:        &startOnceConfig{timeout: 5 * time.Second},
+	}
+	status, ran := runner.run(context.Background())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !ran || status != models.JobStatusComplete {
+		t.Errorf("got %v, %v, want a complete task", status, ran)
+	} else if len(started) != 1 || started[0] != 1 {
+		t.Errorf("got started tasks %v, want task 1 exactly once", started)
+	} else if attempts[2] != 0 {
+		t.Errorf("got %v attempts to start task 2, want none while task 1 is claimed", attempts[2])
+	} else if ended != 2 {
+		t.Errorf("got %v requests to end the task, want 2", ended)
+	}
+}
+
+func TestTransientBHEError(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		transient bool
+	}{
+		{bheResponseError{StatusCode: http.StatusBadGateway}, true},
+		{bheResponseError{StatusCode: http.StatusTooManyRequests}, true},
+		{bheResponseError{StatusCode: http.StatusBadRequest}, false},
+		{fmt.Errorf("%w: %v", ErrTenantOwned, bheResponseError{StatusCode: http.StatusConflict}), false},
+		{fmt.Errorf("failed to request: %w", &url.Error{Op: "Post", URL: "https://bhe", Err: errors.New("connection reset")}), true},
+		{errors.New("unable to encode request"), false},
+	} {
+		if got := transientBHEError(context.Background(), tc.err); got != tc.transient {
+			t.Errorf("%v: got %v, want %v", tc.err, got, tc.transient)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if transientBHEError(ctx, bheResponseError{StatusCode: http.StatusBadGateway}) {
+		t.Error("expected no retries once the context is done")
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// the state of the start service served on --status-addr
+var serviceState = &serviceStatus{started: time.Now()}
+
+// serviceStatus tracks what the start service is doing for its status endpoints
+type serviceStatus struct {
+	mutex        sync.Mutex
+	started      time.Time
+	bheReachable bool
+	lastCheckin  time.Time
+	jobId        int
+	lastError    string
+	lastErrorAt  time.Time
+	batches      int
+	lastBatch    pipeline.BatchStats
+}
+
+// contacted records the outcome of a request to BloodHound Enterprise; successful ones count as a checkin
+func (s *serviceStatus) contacted(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.bheReachable = err == nil
+	if err == nil {
+		s.lastCheckin = time.Now()
+	}
+}
+
+// failed records err as the last error of the service
+func (s *serviceStatus) failed(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = redactSecrets(err.Error())
+	s.lastErrorAt = time.Now()
+}
+
+func (s *serviceStatus) jobStarted(jobId int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobId = jobId
+	s.batches = 0
+	s.lastBatch = pipeline.BatchStats{}
+}
+
+// batched records a batch sent for ingest by the running job, so the sizes the adaptive batcher chooses can be followed
+func (s *serviceStatus) batched(stats pipeline.BatchStats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.batches++
+	s.lastBatch = stats
+}
+
+func (s *serviceStatus) jobEnded() {
+	s.jobStarted(0)
+}
+
+type healthResponse struct {
+	Status       string     `json:"status"`
+	BHEReachable bool       `json:"bheReachable"`
+	LastCheckin  *time.Time `json:"lastCheckin,omitempty"`
+	Uptime       string     `json:"uptime"`
+}
+
+type statusResponse struct {
+	JobId     int                        `json:"jobId,omitempty"`
+	Progress  *models.JobProgressRequest `json:"progress,omitempty"`
+	Ingest    *ingestStatus              `json:"ingest,omitempty"`
+	LastError *statusError               `json:"lastError,omitempty"`
+}
+
+// ingestStatus describes the batches the running job has sent for ingest
+type ingestStatus struct {
+	Batches       int `json:"batches"`
+	LastItems     int `json:"lastItems"`
+	LastBytes     int `json:"lastBytes"`
+	ItemLimit     int `json:"itemLimit"`
+	NextItemLimit int `json:"nextItemLimit"`
+}
+
+type statusError struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// health reports whether BloodHound Enterprise answered the last request made to it, and when a request last got
+// through
+func (s *serviceStatus) health(now time.Time) (healthResponse, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	response := healthResponse{
+		Status:       "ok",
+		BHEReachable: s.bheReachable,
+		Uptime:       now.Sub(s.started).Round(time.Second).String(),
+	}
+	if !s.lastCheckin.IsZero() {
+		lastCheckin := s.lastCheckin
+		response.LastCheckin = &lastCheckin
+	}
+	if !s.bheReachable {
+		response.Status = "unavailable"
+	}
+	return response, s.bheReachable
+}
+
+// status reports the running job, the objects it has collected so far by kind, the batches it has sent for ingest and
+// the last error of the service
+func (s *serviceStatus) status() statusResponse {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	response := statusResponse{JobId: s.jobId}
+	if progress := currentProgress.Load(); progress != nil && s.jobId != 0 {
+		report := progress.report()
+		response.Progress = &report
+	}
+	if s.jobId != 0 && s.batches > 0 {
+		response.Ingest = &ingestStatus{
+			Batches:       s.batches,
+			LastItems:     s.lastBatch.Items,
+			LastBytes:     s.lastBatch.Bytes,
+			ItemLimit:     s.lastBatch.Limit,
+			NextItemLimit: s.lastBatch.Next,
+		}
+	}
+	if s.lastError != "" {
+		response.LastError = &statusError{Message: s.lastError, Time: s.lastErrorAt}
+	}
+	return response
+}
+
+// statusHandler serves /healthz, which responds 503 Service Unavailable while BloodHound Enterprise can't be
+// reached, and /status
+func statusHandler(state *serviceStatus) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if response, ok := state.health(time.Now()); !ok {
+			writeStatusJSON(w, r, http.StatusServiceUnavailable, response)
+		} else {
+			writeStatusJSON(w, r, http.StatusOK, response)
+		}
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeStatusJSON(w, r, http.StatusOK, state.status())
+	})
+	return mux
+}
+
+func writeStatusJSON(w http.ResponseWriter, r *http.Request, code int, body interface{}) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+// serveStatus serves the status endpoints on listener until ctx is done or the returned func is called, which waits
+// for the server to shut down
+func serveStatus(ctx context.Context, listener net.Listener, state *serviceStatus) func() {
+	var (
+		server = &http.Server{
+			Handler:           statusHandler(state),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		once sync.Once
+		done = make(chan struct{})
+	)
+
+	shutdown := func() {
+		once.Do(func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				log.V(1).Info("unable to shut down the status server cleanly", "reason", err.Error())
+				server.Close()
+			}
+		})
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err, "status server stopped")
+		}
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			shutdown()
+		case <-done:
+		}
+	}()
+
+	log.Info("serving status", "addr", listener.Addr().String())
+	return shutdown
+}
+
+// the configs whose values are never served, in case one ends up in an error message
+var secretConfigs = []config.Config{
+	config.GraphToken,
+	config.ArmToken,
+	config.ProxyPassword,
+	config.RefreshToken,
+	config.AzSecret,
+	config.AzKeyPass,
+	config.AzCertPfxPassword,
+	config.AzPassword,
+	config.TokenCachePassphrase,
+	config.BHEToken,
+	config.Neo4jPassword,
+	config.OutputBlobAccountKey,
+	config.BHEClientKeyPass,
+	config.RedactSalt,
+}
+
+// credentials in urls, e.g. a proxy's
+var urlUserinfo = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]+@`)
+
+// redactSecrets replaces the url credentials and configured secrets in msg
+func redactSecrets(msg string) string {
+	msg = urlUserinfo.ReplaceAllString(msg, "${1}xxxxx@")
+	for _, cfg := range secretConfigs {
+		if secret, ok := cfg.Value().(string); ok && secret != "" {
+			msg = strings.ReplaceAll(msg, secret, "xxxxx")
+		}
+	}
+	return msg
+}
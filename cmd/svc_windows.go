@@ -44,8 +44,14 @@ func (s *azurehoundSvc) Init(env svc.Environment) error {
 	} else {
 		log = *logr
 
-		config.LoadValues(nil, config.Options())
-		config.SetAzureDefaults()
+		if err := config.LoadValues(nil, config.Options()); err != nil {
+			return err
+		}
+		if err := config.SetAzureDefaults(); err != nil {
+			return err
+		} else if err := config.ValidateProxy(); err != nil {
+			return err
+		}
 
 		if config.ConfigFileUsed() != "" {
 			log.V(1).Info(fmt.Sprintf("Config File: %v", config.ConfigFileUsed()))
@@ -55,6 +61,15 @@ func (s *azurehoundSvc) Init(env svc.Environment) error {
 			log.V(1).Info(fmt.Sprintf("Log File: %v", config.LogFile.Value()))
 		}
 
+		if err := loadUserEnrichment(); err != nil {
+			return fmt.Errorf("unable to load --%s: %w", config.EnrichUsers.Name, err)
+		}
+		if err := validateRedactPii(); err != nil {
+			return err
+		}
+		if err := loadSince(); err != nil {
+			return err
+		}
 		return nil
 	}
 }
@@ -0,0 +1,140 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+const (
+	// How often a held lock is touched to show its collector is still running
+	tenantLockHeartbeat = time.Minute
+
+	// How long a lock may go without a heartbeat before another collector may take it over, e.g. after a crash
+	tenantLockStale = 5 * time.Minute
+)
+
+var (
+	ErrTenantLocked = errors.New("another collector is already collecting this tenant")
+
+	releaseTenantLock = func() {}
+)
+
+type tenantLockInfo struct {
+	Hostname string    `json:"hostname"`
+	Pid      int       `json:"pid"`
+	TenantId string    `json:"tenantId"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// lockTenant takes the tenant lock in the configured work directory, if any. The lock is released by gracefulShutdown
+// or when exiting on an error. Only the list commands register --work-dir, so it may not be set at all.
+func lockTenant(tenantId string) error {
+	if workDir, _ := config.WorkDir.Value().(string); workDir == "" {
+		return nil
+	} else if release, err := acquireTenantLock(workDir, tenantId); err != nil {
+		return err
+	} else {
+		releaseTenantLock = release
+		return nil
+	}
+}
+
+// acquireTenantLock is a best-effort guard against two collectors sharing a work directory collecting the same tenant
+// at the same time. The lock is a file that is kept fresh while held; a lock that has gone stale is taken over.
+func acquireTenantLock(dir, tenantId string) (func(), error) {
+	path := filepath.Join(dir, fmt.Sprintf("azurehound-%s.lock", strings.ToLower(tenantId)))
+
+	hostname, _ := os.Hostname()
+	info := tenantLockInfo{
+		Hostname: hostname,
+		Pid:      os.Getpid(),
+		TenantId: tenantId,
+		Acquired: time.Now().UTC(),
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create work directory: %w", err)
+	} else if err := createTenantLock(path, info); errors.Is(err, os.ErrExist) {
+		if stat, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("unable to inspect tenant lock: %w", err)
+		} else if age := time.Since(stat.ModTime()); age < tenantLockStale {
+			var holder tenantLockInfo
+			if data, err := os.ReadFile(path); err == nil {
+				json.Unmarshal(data, &holder)
+			}
+			return nil, fmt.Errorf("%w: held by %s (pid %d) since %s; if that collector is no longer running, remove %s or wait %s for the lock to expire", ErrTenantLocked, holder.Hostname, holder.Pid, holder.Acquired.Format(time.RFC3339), path, (tenantLockStale - age).Round(time.Second))
+		} else if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("unable to remove stale tenant lock: %w", err)
+		} else if err := createTenantLock(path, info); err != nil {
+			return nil, fmt.Errorf("unable to acquire tenant lock: %w", err)
+		} else {
+			log.Info("warning: took over a stale tenant lock", "path", path, "age", age.Round(time.Second).String())
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to acquire tenant lock: %w", err)
+	}
+
+	log.V(1).Info("acquired tenant lock", "path", path)
+
+	var (
+		done = make(chan struct{})
+		once sync.Once
+	)
+	go func() {
+		ticker := time.NewTicker(tenantLockHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if err := os.Chtimes(path, now, now); err != nil {
+					log.Error(err, "unable to refresh tenant lock", "path", path)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			close(done)
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Error(err, "unable to release tenant lock", "path", path)
+			}
+		})
+	}, nil
+}
+
+func createTenantLock(path string, info tenantLockInfo) error {
+	if file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err != nil {
+		return err
+	} else {
+		defer file.Close()
+		return json.NewEncoder(file).Encode(info)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireTenantLock(t *testing.T) {
+	dir := t.TempDir()
+
+	release, err := acquireTenantLock(dir, "Tenant")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// tenant ids are compared case-insensitively
+	if _, err := acquireTenantLock(dir, "tenant"); !errors.Is(err, ErrTenantLocked) {
+		t.Errorf("got %v, want %v", err, ErrTenantLocked)
+	}
+
+	if other, err := acquireTenantLock(dir, "other"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else {
+		other()
+	}
+
+	release()
+	release()
+	if again, err := acquireTenantLock(dir, "tenant"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else {
+		again()
+	}
+}
+
+func TestAcquireTenantLockStale(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "azurehound-tenant.lock")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := time.Now().Add(-2 * tenantLockStale)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	if release, err := acquireTenantLock(dir, "tenant"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else {
+		release()
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected the lock to be removed on release, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
+)
+
+// enableTracing starts exporting traces when an OTLP endpoint is configured
+func enableTracing() error {
+	endpoint := config.OTLPEndpoint.Value().(string)
+	if endpoint == "" {
+		return nil
+	}
+
+	samplePercent := config.TraceSamplePercent.Value().(int)
+	if samplePercent < 0 || samplePercent > 100 {
+		return fmt.Errorf("invalid trace sample percent %d: must be between 0 and 100", samplePercent)
+	}
+
+	// the exporter gets its own client so that exporting spans doesn't record spans of its own
+	if client, err := rest.NewHTTPClient(config.ProxyUrl()); err != nil {
+		return err
+	} else if exporter, err := tracing.NewOTLPExporter(endpoint, client); err != nil {
+		return err
+	} else {
+		client.Timeout = 30 * time.Second
+		tracing.Enable(exporter, samplePercent)
+		log.V(1).Info(fmt.Sprintf("Exporting traces to %s", endpoint), "samplePercent", samplePercent)
+		return nil
+	}
+}
+
+// shutdownTracing flushes the spans that haven't been exported yet
+func shutdownTracing() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tracing.Shutdown(ctx); err != nil {
+		log.Info(fmt.Sprintf("warning: unable to export traces: %v", err))
+	}
+}
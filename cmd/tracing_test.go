@@ -0,0 +1,162 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	client_config "github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/collector"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
+)
+
+func init() {
+	setupLogger()
+}
+
+func testToken(t *testing.T, claims map[string]interface{}) string {
+	if payload, err := json.Marshal(claims); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+	}
+}
+
+func TestTraceCollectionRun(t *testing.T) {
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value":[{"id":"tenant","displayName":"Tenant"}]}`))
+	}))
+	defer graph.Close()
+
+	resourceManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subscriptions":
+			w.Write([]byte(`{"value":[{"id":"/subscriptions/sub","subscriptionId":"sub","tenantId":"tenant"}]}`))
+		case "/subscriptions/sub/providers/Microsoft.Compute/virtualMachines":
+			w.Write([]byte(`{"value":[{"id":"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm","name":"vm"}]}`))
+		default:
+			w.Write([]byte(`{"value":[]}`))
+		}
+	}))
+	defer resourceManager.Close()
+
+	var (
+		mutex    sync.Mutex
+		ingested int
+	)
+	bhe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		decodeIngestRequest(r, &body)
+		mutex.Lock()
+		ingested += len(body.Data)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer bhe.Close()
+
+	expires := float64(time.Now().Add(time.Hour).Unix())
+	azClient, err := client.NewClient(client_config.Config{
+		Graph:      graph.URL,
+		GraphToken: testToken(t, map[string]interface{}{"aud": graph.URL, "tid": "tenant", "exp": expires}),
+		Management: resourceManager.URL,
+		ArmToken:   testToken(t, map[string]interface{}{"aud": resourceManager.URL + "/", "tid": "tenant", "exp": expires}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorder := &tracing.Recorder{}
+	tracing.Enable(recorder, 100)
+	defer tracing.Shutdown(context.Background())
+
+	var (
+		bheUrl, _   = url.Parse(bhe.URL)
+		bheClient   = &http.Client{Transport: tracing.Transport{Base: http.DefaultTransport}}
+		ctx, root   = tracing.Start(context.Background(), "collection")
+		stream, _   = collector.Collect(ctx, azClient, collector.Options{Scope: &collector.Scope{AzureRM: true}})
+		batches     = pipeline.Batch(ctx.Done(), stream, 256, 10*time.Second)
+		ingestStats = ingest(ctx, *bheUrl, bheClient, batches)
+	)
+	root.End()
+
+	if ingestStats.hasErrors() {
+		t.Fatal("unexpected ingest error")
+	}
+
+	var (
+		spans  = recorder.Spans()
+		byName = map[string]tracing.SpanData{}
+		byId   = map[[8]byte]tracing.SpanData{}
+	)
+	for _, span := range spans {
+		byName[span.Name] = span
+		byId[span.SpanId] = span
+		if span.TraceId != spans[len(spans)-1].TraceId {
+			t.Errorf("span %s has trace id %x, want %x", span.Name, span.TraceId, spans[len(spans)-1].TraceId)
+		}
+	}
+
+	parent := func(name string) string {
+		if span, ok := byName[name]; !ok {
+			t.Errorf("missing span %s", name)
+			return ""
+		} else {
+			return byId[span.ParentSpanId].Name
+		}
+	}
+
+	vmPath := "HTTP GET /subscriptions/{name}/providers/Microsoft.Compute/virtualMachines"
+	wantParents := map[string]string{
+		"collect subscriptions":    "collection",
+		"collect virtual-machines": "collection",
+		"list virtual machines":    "collect virtual-machines",
+		vmPath:                     "list virtual machines",
+		"ingest batch":             "collection",
+		"HTTP POST /api/v2/ingest": "ingest batch",
+	}
+	for name, want := range wantParents {
+		if got := parent(name); got != want {
+			t.Errorf("got parent %q for span %s, want %q", got, name, want)
+		}
+	}
+
+	if root := byName["collection"]; root.ParentSpanId != [8]byte{} {
+		t.Error("expected the collection span to be the root")
+	} else if got := byName["collect virtual-machines"].Attribute("count"); got != 1 {
+		t.Errorf("got virtual machine count %v, want 1", got)
+	} else if got := byName["list virtual machines"].Attribute("subscriptionId"); got != "sub" {
+		t.Errorf("got subscription id %v, want sub", got)
+	} else if got := byName[vmPath].Attribute("http.status_code"); got != http.StatusOK {
+		t.Errorf("got status code %v, want %v", got, http.StatusOK)
+	} else if got := byName["ingest batch"].Attribute("count"); got != ingested {
+		t.Errorf("got ingest batch count %v, want %v", got, ingested)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/sinks"
+)
+
+// uploadFile uploads a file previously written by the list command to the configured BloodHound Enterprise instance
+func uploadFile(ctx context.Context, filePath string) error {
+	if bheInstance, bheClient, err := newUploadClient(); err != nil {
+		return err
+	} else if file, err := sinks.OpenFile(filePath); err != nil {
+		return err
+	} else {
+		defer file.Close()
+		return upload(ctx, *bheInstance, bheClient, config.AzTenant.Value().(string), file)
+	}
+}
+
+// newUploadClient returns the configured BloodHound Enterprise instance and a client that signs requests to it
+func newUploadClient() (*url.URL, *http.Client, error) {
+	if bheInstance, err := parseBHEUrl(config.BHEUrl.Value().(string)); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse BHE url: %w", err)
+	} else if bheClient, err := newSigningHttpClient(BHEAuthSignature, config.BHETokenId.Value().(string), config.BHEToken.Value().(string), config.ProxyUrl()); err != nil {
+		return nil, nil, fmt.Errorf("failed to create new signing HTTP client: %w", err)
+	} else {
+		return bheInstance, bheClient, nil
+	}
+}
+
+// upload runs a BloodHound Enterprise job for the next executable collection task and ingests the contents of r
+// in batches, the same way the start command ingests a live collection
+func upload(ctx context.Context, bheUrl url.URL, bheClient *http.Client, tenantId string, r io.Reader) error {
+	log.Info("uploading collected data to bloodhound enterprise...")
+	if batchConfig, err := ingestBatchConfig(); err != nil {
+		return err
+	} else if _, err := ingestRetryConfig(); err != nil {
+		return err
+	} else if _, err := ingestConcurrency(); err != nil {
+		return err
+	} else if err := updateClient(ctx, bheUrl, bheClient, tenantId); err != nil {
+		return fmt.Errorf("failed to update client: %w", err)
+	} else if availableTasks, err := getAvailableTasks(ctx, bheUrl, bheClient); err != nil {
+		return fmt.Errorf("unable to fetch available tasks for azurehound: %w", err)
+	} else if executableTasks := getExecutableTasks(availableTasks, time.Now()); len(executableTasks) == 0 {
+		return fmt.Errorf("there are no tasks for azurehound to complete at this time")
+	} else {
+		return runUploadTask(ctx, bheUrl, bheClient, executableTasks[0], tenantId, r, batchConfig)
+	}
+}
+
+func runUploadTask(ctx context.Context, bheUrl url.URL, bheClient *http.Client, task models.ClientTask, tenantId string, r io.Reader, batchConfig pipeline.AdaptiveBatchConfig[interface{}]) error {
+	if err := startTask(ctx, bheUrl, bheClient, task.Id, tenantId); err != nil {
+		return fmt.Errorf("failed to start task: %w", err)
+	}
+
+	start := time.Now()
+
+	// Batch data out for ingestion; ingest may stop early on an unrecoverable error so the reader must be cancelled
+	// once it returns
+	readCtx, cancel := context.WithCancel(ctx)
+	stream, errs := readDataFile(readCtx, r)
+	batches := pipeline.AdaptiveBatch(readCtx.Done(), stream, batchConfig)
+	ingestStats := ingest(ctx, bheUrl, bheClient, batches)
+	cancel()
+	readErr := <-errs
+	if ingestStats.aborted && errors.Is(readErr, context.Canceled) {
+		readErr = nil
+	}
+
+	// Notify BHE instance of task end
+	duration := time.Since(start)
+
+	var (
+		status    = models.JobStatusComplete
+		message   = "Upload completed successfully"
+		uploadErr error
+	)
+	if readErr != nil {
+		status = models.JobStatusFailed
+		message = "Upload failed while reading the output file"
+		uploadErr = readErr
+	} else if ingestStats.aborted {
+		status = models.JobStatusFailed
+		message = "Upload failed; ingest " + ingestStats.String()
+		uploadErr = fmt.Errorf("one or more batches failed to ingest")
+	} else if ingestStats.hasErrors() {
+		status = models.JobStatusPartiallyComplete
+		message = "Upload partially completed; ingest " + ingestStats.String()
+		uploadErr = fmt.Errorf("one or more batches failed to ingest")
+	}
+
+	if err := endTask(ctx, bheUrl, bheClient, status, message); err != nil {
+		return fmt.Errorf("failed to end task: %w", err)
+	} else {
+		log.Info(message, "id", task.Id, "duration", duration.String())
+		return uploadErr
+	}
+}
+
+// readDataFile streams the items in the data array of a file written by sinks.WriteToFile. Any error encountered is
+// sent on the returned error channel after the item stream is closed. Reading stops between reads of r once ctx is
+// done, even in the middle of a large item.
+func readDataFile(ctx context.Context, r io.Reader) (<-chan interface{}, <-chan error) {
+	var (
+		out  = make(chan interface{})
+		errs = make(chan error, 1)
+	)
+
+	go func() {
+		defer close(errs)
+		errs <- func() error {
+			defer close(out)
+
+			decoder := json.NewDecoder(rest.NewContextReader(ctx, r))
+			if err := expectDelim(decoder, '{'); err != nil {
+				return err
+			}
+
+			for decoder.More() {
+				if token, err := decoder.Token(); err != nil {
+					return err
+				} else if key, ok := token.(string); !ok {
+					return fmt.Errorf("unexpected token in output file: %v", token)
+				} else if key != "data" {
+					var skip json.RawMessage
+					if err := decoder.Decode(&skip); err != nil {
+						return err
+					}
+				} else if err := expectDelim(decoder, '['); err != nil {
+					return err
+				} else {
+					for decoder.More() {
+						var item json.RawMessage
+						if err := decoder.Decode(&item); err != nil {
+							return err
+						}
+
+						select {
+						case out <- item:
+						case <-ctx.Done():
+							return rest.Aborted(ctx)
+						}
+					}
+
+					if err := expectDelim(decoder, ']'); err != nil {
+						return err
+					}
+				}
+			}
+			return expectDelim(decoder, '}')
+		}()
+	}()
+
+	return out, errs
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if token != delim {
+		return fmt.Errorf("malformed output file: expected %v but found %v", delim, token)
+	} else {
+		return nil
+	}
+}
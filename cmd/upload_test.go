@@ -0,0 +1,247 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func init() {
+	setupLogger()
+}
+
+const testDataFile = `{
+	"data": [
+		{"kind":"AZUser","data":{"id":"1"}},
+		{"kind":"AZUser","data":{"id":"2"}},
+		{"kind":"AZGroup","data":{"id":"3"}}
+	],
+	"meta": {"type":"azure","version":5,"count":3}
+}
+`
+
+func TestReadDataFile(t *testing.T) {
+	ctx := context.Background()
+	stream, errs := readDataFile(ctx, strings.NewReader(testDataFile))
+
+	count := 0
+	for item := range stream {
+		if _, ok := item.(json.RawMessage); !ok {
+			t.Errorf("failed type assertion: got %T, want %T", item, json.RawMessage{})
+		}
+		count++
+	}
+
+	if err := <-errs; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if count != 3 {
+		t.Errorf("got %v items, want 3", count)
+	}
+}
+
+func TestReadDataFileMalformed(t *testing.T) {
+	ctx := context.Background()
+	stream, errs := readDataFile(ctx, strings.NewReader(`{"data": [{"kind":"AZUser"}`))
+
+	for range stream {
+	}
+
+	if err := <-errs; err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestReadDataFileCancelled(t *testing.T) {
+	var (
+		ctx, cancel    = context.WithCancel(context.Background())
+		reader, writer = io.Pipe()
+	)
+	defer cancel()
+	defer reader.Close()
+
+	// a single item that never ends so the read is cancelled mid-decode
+	go func() {
+		writer.Write([]byte(`{"data": [{"kind":"AZUser","data":{"description":"`))
+		for {
+			if _, err := writer.Write([]byte(strings.Repeat("a", 4096))); err != nil {
+				return
+			}
+		}
+	}()
+
+	stream, errs := readDataFile(ctx, reader)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	cancelled := time.Now()
+
+	for range stream {
+	}
+
+	select {
+	case err := <-errs:
+		if elapsed := time.Since(cancelled); elapsed > 250*time.Millisecond {
+			t.Errorf("read took %v to return after cancellation", elapsed)
+		}
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, rest.ErrAborted) {
+			t.Errorf("got %v, want %v", err, rest.ErrAborted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read did not return after cancellation")
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests []string
+		ingested int
+		endJob   models.CompleteJobRequest
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, r.URL.Path)
+
+		switch r.URL.Path {
+		case "/api/v1/clients/availabletasks":
+			json.NewEncoder(w).Encode([]models.ClientTask{
+				{Id: 2, ExectionTime: time.Now().Add(time.Hour)},
+				{Id: 1, ExectionTime: time.Now().Add(-time.Hour)},
+			})
+		case "/api/v2/ingest":
+			var body struct {
+				Data []json.RawMessage `json:"data"`
+			}
+			decodeIngestRequest(r, &body)
+			ingested += len(body.Data)
+			w.WriteHeader(http.StatusAccepted)
+		case "/api/v2/jobs/end":
+			json.NewDecoder(r.Body).Decode(&endJob)
+			w.Write([]byte("{}"))
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := upload(context.Background(), *bheUrl, server.Client(), "tenant", strings.NewReader(testDataFile)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"/api/v1/clients/update", "/api/v1/clients/availabletasks", "/api/v1/clients/starttask", "/api/v2/ingest", "/api/v2/jobs/end"}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("got requests %v, want %v", requests, want)
+	} else if ingested != 3 {
+		t.Errorf("got %v ingested items, want 3", ingested)
+	} else if endJob.Status != models.JobStatusComplete.String() {
+		t.Errorf("got job status %v, want %v", endJob.Status, models.JobStatusComplete.String())
+	}
+}
+
+func TestUploadIngestFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/clients/availabletasks":
+			json.NewEncoder(w).Encode([]models.ClientTask{{Id: 1}})
+		case "/api/v2/ingest":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := upload(context.Background(), *bheUrl, server.Client(), "tenant", strings.NewReader(testDataFile)); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestUploadNoTasks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/clients/availabletasks" {
+			w.Write([]byte("[]"))
+		} else {
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	bheUrl, _ := url.Parse(server.URL)
+	if err := upload(context.Background(), *bheUrl, server.Client(), "tenant", strings.NewReader(testDataFile)); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestUploadPathPrefix(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, r.URL.Path)
+
+		switch r.URL.Path {
+		case "/bloodhound/api/v1/clients/availabletasks":
+			json.NewEncoder(w).Encode([]models.ClientTask{{Id: 1}})
+		case "/bloodhound/api/v2/ingest":
+			w.WriteHeader(http.StatusAccepted)
+		case "/bloodhound/api/v1/clients/update", "/bloodhound/api/v1/clients/starttask", "/bloodhound/api/v2/jobs/end":
+			w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	want := []string{
+		"/bloodhound/api/v1/clients/update",
+		"/bloodhound/api/v1/clients/availabletasks",
+		"/bloodhound/api/v1/clients/starttask",
+		"/bloodhound/api/v2/ingest",
+		"/bloodhound/api/v2/jobs/end",
+	}
+	for _, prefix := range []string{"/bloodhound", "/bloodhound/"} {
+		requests = nil
+		if bheUrl, err := parseBHEUrl(server.URL + prefix); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := upload(context.Background(), *bheUrl, server.Client(), "tenant", strings.NewReader(testDataFile)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", prefix, err)
+		} else if strings.Join(requests, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got requests %v, want %v", prefix, requests, want)
+		}
+	}
+}
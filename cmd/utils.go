@@ -24,7 +24,9 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
@@ -32,27 +34,129 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/bloodhoundad/azurehound/v2/client"
 	client_config "github.com/bloodhoundad/azurehound/v2/client/config"
 	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/collector"
 	"github.com/bloodhoundad/azurehound/v2/config"
 	"github.com/bloodhoundad/azurehound/v2/enums"
 	"github.com/bloodhoundad/azurehound/v2/logger"
-	"github.com/bloodhoundad/azurehound/v2/models"
 	"github.com/bloodhoundad/azurehound/v2/pipeline"
 	"github.com/bloodhoundad/azurehound/v2/sinks"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/proxy"
 )
 
+const (
+	// ExitCodeFailure indicates that collection failed for a reason not covered by a more specific exit code
+	ExitCodeFailure int = 1
+	// ExitCodeUploadFailure indicates that BloodHound Enterprise did not accept collected data: collection succeeded and
+	// the output file was written but uploading it failed, or the instance rejected the ingest canary
+	ExitCodeUploadFailure int = 2
+	// ExitCodeConfigError indicates that the command was given invalid flags, config values or input files
+	ExitCodeConfigError int = 3
+	// ExitCodeAuthFailure indicates that Azure or BloodHound Enterprise rejected the configured credentials
+	ExitCodeAuthFailure int = 4
+	// ExitCodeNetworkFailure indicates that Azure or BloodHound Enterprise could not be resolved or reached
+	ExitCodeNetworkFailure int = 5
+	// ExitCodePartialCollection indicates that collection finished and its output was written but some objects were
+	// not collected because requests failed, permissions were missing or the deadline was reached
+	ExitCodePartialCollection int = 6
+	// ExitCodeNoTask indicates that start --once found no collection task to run before --once-timeout
+	ExitCodeNoTask int = 7
+	// ExitCodeMissingPermissions indicates that the credential was accepted but lacks permissions the enabled
+	// collectors need, with --strict-permissions or --check-permissions
+	ExitCodeMissingPermissions int = 8
+	// ExitCodeAborted indicates that the command was interrupted before it finished
+	ExitCodeAborted int = 130
+)
+
 func exit(err error) {
-	log.Error(err, "encountered unrecoverable error")
+	exitWithCode(ExitCodeFailure, err)
+}
+
+func exitWithCode(code int, err error) {
+	releaseTenantLock()
+	shutdownTracing()
+	if err != nil {
+		log.Error(err, "encountered unrecoverable error")
+	}
 	log.GetSink()
-	os.Exit(1)
+	os.Exit(exitCode(code, err))
+}
+
+// validateTenant requires --tenant for the commands that take it, unless the credential source resolves the tenant
+// itself: the signed in Azure CLI session, the managed identity of the host or supplied tokens
+func validateTenant(cmd *cobra.Command) error {
+	if cmd == nil || cmd.Flags().Lookup(config.AzTenant.Name) == nil || config.AzTenant.Value().(string) != "" {
+		return nil
+	} else if config.AzUseAzureCli.Value().(bool) || config.AzUseManagedIdentity.Value().(bool) || config.GraphToken.Value().(string) != "" || config.ArmToken.Value().(string) != "" {
+		return nil
+	} else {
+		return fmt.Errorf("--%s is required unless --%s, --%s, --%s or --%s is set", config.AzTenant.Name, config.AzUseAzureCli.Name, config.AzUseManagedIdentity.Name, config.GraphToken.Name, config.ArmToken.Name)
+	}
+}
+
+// exitCode returns ExitCodeAborted in place of code when err was caused by the command being interrupted. A generic
+// ExitCodeFailure is narrowed to the exit code of the cause of err when it is a rejected credential, an unreachable
+// host, missing permissions or a rejected ingest canary.
+func exitCode(code int, err error) int {
+	if errors.Is(err, context.Canceled) {
+		return ExitCodeAborted
+	} else if code != ExitCodeFailure {
+		return code
+	} else if errors.Is(err, ErrMissingPermissions) {
+		return ExitCodeMissingPermissions
+	} else if isAuthError(err) {
+		return ExitCodeAuthFailure
+	} else if isNetworkError(err) {
+		return ExitCodeNetworkFailure
+	} else if errors.Is(err, ErrCanaryFailed) {
+		return ExitCodeUploadFailure
+	} else {
+		return code
+	}
+}
+
+// exitIfIncomplete exits with ExitCodeAborted if the collection was interrupted, or with ExitCodePartialCollection if it
+// logged errors, skipped kinds it lacked the permissions for or reached its deadline, so that automation can tell an
+// incomplete collection from a complete one. The output has been written either way.
+func exitIfIncomplete(ctx context.Context, loggedErrors int64, deadlineExceeded bool) {
+	var (
+		errorCount = logger.ErrorCount() - loggedErrors
+		skipped    = collector.SkippedKinds()
+	)
+	if ctx.Err() != nil {
+		exitWithCode(ExitCodeAborted, nil)
+	} else if errorCount > 0 || len(skipped) > 0 || deadlineExceeded {
+		log.Info("warning: collection is incomplete; some objects were not collected", "errors", errorCount, "skippedForPermissions", skipped, "deadlineExceeded", deadlineExceeded)
+		exitWithCode(ExitCodePartialCollection, nil)
+	}
+}
+
+func isAuthError(err error) bool {
+	return errors.Is(err, rest.ErrAuthenticationFailed) || errors.Is(err, rest.ErrUnauthorized) || errors.Is(err, rest.ErrForbidden) || errors.Is(err, rest.ErrTokenExpired)
+}
+
+// isNetworkError reports whether err was caused by a host that could not be resolved or connected to, or by a request
+// that timed out. The --deadline expiring is not a network failure.
+func isNetworkError(err error) bool {
+	var (
+		opErr  *net.OpError
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	} else {
+		return errors.As(err, &opErr) || errors.As(err, &dnsErr) || (errors.As(err, &netErr) && netErr.Timeout())
+	}
 }
 
 func persistentPreRunE(cmd *cobra.Command, args []string) error {
@@ -63,8 +167,26 @@ func persistentPreRunE(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	config.LoadValues(cmd, config.Options())
-	config.SetAzureDefaults()
+	if err := config.LoadValues(cmd, config.Options()); err != nil {
+		return err
+	}
+	if err := config.SetAzureDefaults(); err != nil {
+		return err
+	} else if _, err := durationValue(config.HTTPTimeout); err != nil {
+		return err
+	} else if retries := config.MaxRetries.Value().(int); retries < 0 {
+		return fmt.Errorf("invalid --%s: %d is negative", config.MaxRetries.Name, retries)
+	} else if _, err := durationValue(config.RetryMaxBackoff); err != nil {
+		return err
+	} else if _, err := durationValue(config.Deadline); err != nil {
+		return err
+	} else if _, err := excludedSubscriptionClasses(); err != nil {
+		return err
+	} else if err := config.ValidateProxy(); err != nil {
+		return err
+	} else if err := validateTenant(cmd); err != nil {
+		return err
+	}
 
 	if logr, err := logger.GetLogger(); err != nil {
 		return err
@@ -79,16 +201,63 @@ func persistentPreRunE(cmd *cobra.Command, args []string) error {
 			log.V(1).Info(fmt.Sprintf("Log File: %v", config.LogFile.Value()))
 		}
 
-		return nil
+		if err := loadUserEnrichment(); err != nil {
+			return fmt.Errorf("unable to load --%s: %w", config.EnrichUsers.Name, err)
+		}
+		if err := validateRedactPii(); err != nil {
+			return err
+		}
+		if err := loadSince(); err != nil {
+			return err
+		}
+		return enableTracing()
 	}
 }
 
 func gracefulShutdown(stop context.CancelFunc) {
 	stop()
+	releaseTenantLock()
+	shutdownTracing()
 	fmt.Fprintln(os.Stderr, "\nshutting down gracefully, press ctrl+c again to force")
 	// TODO timeout context
 }
 
+// durationValue returns the value of a duration config. Durations are configured as strings such as 90s or 2h30m;
+// an empty value is zero, which disables the timeout or deadline it configures.
+func durationValue(cfg config.Config) (time.Duration, error) {
+	if value, ok := cfg.Value().(string); !ok {
+		return 0, fmt.Errorf("invalid --%s: %v is not a duration", cfg.Name, cfg.Value())
+	} else if value == "" {
+		return 0, nil
+	} else if duration, err := time.ParseDuration(value); err != nil {
+		return 0, fmt.Errorf("invalid --%s: %w", cfg.Name, err)
+	} else if duration < 0 {
+		return 0, fmt.Errorf("invalid --%s: %s is negative", cfg.Name, value)
+	} else {
+		return duration, nil
+	}
+}
+
+// collectionContext returns the context a collection run is bound to, which is cancelled once the configured
+// --deadline passes. Output must be written with the parent context so what was collected is still flushed.
+func collectionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, _ := durationValue(config.Deadline); deadline > 0 {
+		return context.WithTimeout(ctx, deadline)
+	} else {
+		return context.WithCancel(ctx)
+	}
+}
+
+// deadlineExceeded reports whether a collection run was cut short by --deadline
+func deadlineExceeded(collectionCtx context.Context) bool {
+	if errors.Is(collectionCtx.Err(), context.DeadlineExceeded) {
+		log.Info("warning: the collection deadline was reached before collection completed; the output only contains the data collected until then", "deadline", config.Deadline.Value())
+		return true
+	} else {
+		return false
+	}
+}
+
 func testConnections() error {
 	if _, err := dial(config.AzAuthUrl.Value().(string)); err != nil {
 		return fmt.Errorf("unable to connect to %s: %w", config.AzAuthUrl.Value(), err)
@@ -171,10 +340,10 @@ func (s proxyDialer) Dial(network string, addr string) (net.Conn, error) {
 }
 
 func getDialer() (proxy.Dialer, error) {
-	if proxyUrl := config.Proxy.Value().(string); proxyUrl == "" {
+	if proxyUrl := config.ProxyUrl(); proxyUrl == "" {
 		return proxy.Direct, nil
 	} else if url, err := url.Parse(proxyUrl); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to parse proxy url")
 	} else if url.Scheme == "https" {
 		return proxy.FromURL(url, httpsDialer{})
 	} else {
@@ -210,17 +379,57 @@ func dial(targetUrl string) (string, error) {
 	}
 }
 
+// newAzureClient connects to the configured tenant through collector.New, the way programs embedding the collector
+// package do
 func newAzureClient() (client.AzureClient, error) {
+	if config, err := newAzureClientConfig(); err != nil {
+		return nil, err
+	} else {
+		azureClientConfig = config
+		if azCollector, err := collector.New(config); err != nil {
+			return nil, err
+		} else {
+			return azCollector.Client(), nil
+		}
+	}
+}
+
+// newAzureClientConfig resolves the configured credential and endpoints into the configuration of an Azure client,
+// signing in with a device code when one is requested
+func newAzureClientConfig() (client_config.Config, error) {
+	httpTimeout, err := durationValue(config.HTTPTimeout)
+	if err != nil {
+		return client_config.Config{}, err
+	}
+
+	retryMaxBackoff, err := durationValue(config.RetryMaxBackoff)
+	if err != nil {
+		return client_config.Config{}, err
+	}
+
+	cacheDir := config.CacheDir.Value().(string)
+	cacheTTL, err := durationValue(config.CacheTTL)
+	if err != nil {
+		return client_config.Config{}, err
+	} else if cacheDir != "" && cacheTTL == 0 {
+		return client_config.Config{}, fmt.Errorf("invalid --%s: must be positive when --%s is set", config.CacheTTL.Name, config.CacheDir.Name)
+	} else if cacheDir != "" {
+		log.Info("warning: serving Graph and ARM responses from --cache-dir, which is meant for development and testing only; cached responses may be stale and must not be used for production collection", "cacheDir", cacheDir, "ttl", cacheTTL.String())
+	}
+
 	var (
 		certFile   = config.AzCert.Value()
 		keyFile    = config.AzKey.Value()
+		keyPass    = config.AzKeyPass.Value().(string)
+		pfxFile    = config.AzCertPfx.Value().(string)
+		deviceCode = config.AzDeviceCode.Value().(bool)
 		clientCert string
 		clientKey  string
 	)
 
 	if file, ok := certFile.(string); ok && file != "" {
 		if content, err := ioutil.ReadFile(certFile.(string)); err != nil {
-			return nil, fmt.Errorf("unable to read provided certificate: %w", err)
+			return client_config.Config{}, fmt.Errorf("unable to read provided certificate: %w", err)
 		} else {
 			clientCert = string(content)
 		}
@@ -229,43 +438,203 @@ func newAzureClient() (client.AzureClient, error) {
 
 	if file, ok := keyFile.(string); ok && file != "" {
 		if content, err := ioutil.ReadFile(keyFile.(string)); err != nil {
-			return nil, fmt.Errorf("unable to read provided key file: %w", err)
+			return client_config.Config{}, fmt.Errorf("unable to read provided key file: %w", err)
 		} else {
 			clientKey = string(content)
 		}
 	}
 
+	if pfxFile != "" {
+		if clientCert != "" || clientKey != "" {
+			return client_config.Config{}, fmt.Errorf("--certificate-pfx cannot be combined with --cert and --key")
+		} else if content, err := os.ReadFile(pfxFile); err != nil {
+			return client_config.Config{}, fmt.Errorf("unable to read provided certificate pfx: %w", err)
+		} else if clientCert, clientKey, err = rest.ParsePKCS12(content, config.AzCertPfxPassword.Value().(string)); err != nil {
+			return client_config.Config{}, fmt.Errorf("unable to parse provided certificate pfx %s: %w", pfxFile, err)
+		} else {
+			// the key is decrypted while parsing the bundle
+			keyPass = ""
+		}
+	}
+
 	config := client_config.Config{
-		ApplicationId:  config.AzAppId.Value().(string),
-		Authority:      config.AzAuthUrl.Value().(string),
-		ClientSecret:   config.AzSecret.Value().(string),
-		ClientCert:     clientCert,
-		ClientKey:      clientKey,
-		ClientKeyPass:  config.AzKeyPass.Value().(string),
-		Graph:          config.AzGraphUrl.Value().(string),
-		JWT:            config.JWT.Value().(string),
-		Management:     config.AzMgmtUrl.Value().(string),
-		MgmtGroupId:    config.AzMgmtGroupId.Value().([]string),
-		Password:       config.AzPassword.Value().(string),
-		ProxyUrl:       config.Proxy.Value().(string),
-		RefreshToken:   config.RefreshToken.Value().(string),
-		Region:         config.AzRegion.Value().(string),
-		SubscriptionId: config.AzSubId.Value().([]string),
-		Tenant:         config.AzTenant.Value().(string),
-		Username:       config.AzUsername.Value().(string),
-	}
-	return client.NewClient(config)
+		ApplicationId:           config.AzAppId.Value().(string),
+		Authority:               config.AzAuthUrl.Value().(string),
+		AuthorityOverride:       config.AzAuthorityUrlOverride.Value().(string),
+		AzureCli:                config.AzUseAzureCli.Value().(bool),
+		CacheDir:                cacheDir,
+		CacheTTL:                cacheTTL,
+		ClientAssertion:         config.AzClientAssertion.Value().(string),
+		ClientAssertionFile:     config.AzClientAssertionFile.Value().(string),
+		ClientSecret:            config.AzSecret.Value().(string),
+		ClientCert:              clientCert,
+		ClientKey:               clientKey,
+		ClientKeyPass:           keyPass,
+		Graph:                   config.AzGraphUrl.Value().(string),
+		GraphOverride:           config.AzGraphUrlOverride.Value().(string),
+		GraphToken:              config.GraphToken.Value().(string),
+		HTTPTimeout:             httpTimeout,
+		ArmToken:                config.ArmToken.Value().(string),
+		JWT:                     config.JWT.Value().(string),
+		Management:              config.AzMgmtUrl.Value().(string),
+		ManagementOverride:      config.AzMgmtUrlOverride.Value().(string),
+		MgmtGroupId:             config.AzMgmtGroupId.Value().([]string),
+		ManagedIdentity:         config.AzUseManagedIdentity.Value().(bool),
+		ManagedIdentityClientId: config.AzManagedIdentityClientId.Value().(string),
+		MaxRetries:              config.MaxRetries.Value().(int),
+		Password:                config.AzPassword.Value().(string),
+		ProxyUrl:                config.ProxyUrl(),
+		RefreshToken:            config.RefreshToken.Value().(string),
+		Region:                  config.AzRegion.Value().(string),
+		RetryMaxBackoff:         retryMaxBackoff,
+		SubscriptionId:          config.AzSubId.Value().([]string),
+		Tenant:                  config.AzTenant.Value().(string),
+		TokenCache:              config.TokenCache.Value().(string),
+		TokenCachePassphrase:    config.TokenCachePassphrase.Value().(string),
+		Username:                config.AzUsername.Value().(string),
+	}
+	client.SetLogger(log)
+
+	if err := validateClientAssertion(config); err != nil {
+		return client_config.Config{}, err
+	}
+
+	if deviceCode && config.TokenCache != "" {
+		if refreshToken, err := rest.CachedRefreshToken(config); err != nil {
+			return client_config.Config{}, err
+		} else if refreshToken != "" {
+			log.V(1).Info("signing in with the refresh token from the token cache instead of a device code")
+			config.RefreshToken = refreshToken
+			deviceCode = false
+		}
+	}
+
+	if deviceCode {
+		// the command's context isn't available yet, so honor interrupts while waiting for the user to sign in
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		defer stop()
+		if refreshToken, err := rest.DeviceCodeRefreshToken(ctx, config, printDeviceCode); err != nil {
+			return client_config.Config{}, fmt.Errorf("device code %w: %w", rest.ErrAuthenticationFailed, err)
+		} else {
+			config.RefreshToken = refreshToken
+		}
+	}
+
+	return config, nil
 }
 
-func newSigningHttpClient(signature, tokenId, token, proxyUrl string) (*http.Client, error) {
+// validateClientAssertion rejects credential combinations that leave it ambiguous which credential authenticates the
+// application; a client assertion takes the place of a secret or certificate.
+func validateClientAssertion(config client_config.Config) error {
+	if config.ClientAssertion == "" && config.ClientAssertionFile == "" {
+		return nil
+	} else if config.ClientAssertion != "" && config.ClientAssertionFile != "" {
+		return fmt.Errorf("--client-assertion and --client-assertion-file cannot be combined")
+	} else if config.ClientSecret != "" {
+		return fmt.Errorf("--secret cannot be combined with a client assertion")
+	} else if config.ClientCert != "" || config.ClientKey != "" {
+		return fmt.Errorf("a certificate cannot be combined with a client assertion")
+	} else if config.ApplicationId == "" {
+		return fmt.Errorf("a client assertion requires the application id to be set with --app")
+	} else {
+		return nil
+	}
+}
+
+func printDeviceCode(code rest.DeviceCode) {
+	if code.Message != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n\n", code.Message)
+	} else {
+		fmt.Fprintf(os.Stderr, "\nTo sign in, open %s and enter the code %s\n\n", code.VerificationUri, code.UserCode)
+	}
+}
+
+// parseBHEUrl parses the configured BloodHound Enterprise instance url. Instances may be served under a path prefix,
+// which is kept without its trailing slash so bheEndpoint can join api paths to it. Query strings and fragments are
+// never sent to the instance, so they are dropped with a warning.
+func parseBHEUrl(value string) (*url.URL, error) {
+	if bheUrl, err := url.Parse(value); err != nil {
+		return nil, err
+	} else if bheUrl.Scheme != "http" && bheUrl.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q, expected http or https", bheUrl.Scheme)
+	} else if bheUrl.Host == "" {
+		return nil, fmt.Errorf("%s does not include a host", value)
+	} else {
+		if bheUrl.RawQuery != "" || bheUrl.Fragment != "" {
+			log.Info("warning: ignoring the query string and fragment of the bloodhound enterprise instance url", "instance", value)
+			bheUrl.RawQuery = ""
+			bheUrl.ForceQuery = false
+			bheUrl.Fragment = ""
+			bheUrl.RawFragment = ""
+		}
+		bheUrl.Path = strings.TrimSuffix(bheUrl.Path, "/")
+		bheUrl.RawPath = strings.TrimSuffix(bheUrl.RawPath, "/")
+		return bheUrl, nil
+	}
+}
+
+// bheEndpoint returns the url of a BloodHound Enterprise api path. The path is joined to the instance url rather than
+// resolved against it, which would replace any path prefix the instance is served under.
+func bheEndpoint(bheUrl url.URL, path string) *url.URL {
+	return bheUrl.JoinPath(path)
+}
+
+// newBHEHttpClient returns an http.Client for talking to BloodHound Enterprise, applying the BHE specific TLS
+// settings. The Azure clients are unaffected since they only talk to public Microsoft endpoints.
+func newBHEHttpClient(proxyUrl string) (*http.Client, error) {
 	if client, err := rest.NewHTTPClient(proxyUrl); err != nil {
 		return nil, err
+	} else if transport, ok := client.Transport.(*http.Transport); !ok {
+		return nil, fmt.Errorf("unexpected transport type: %T", client.Transport)
+	} else if err := setBHETLSConfig(transport.TLSClientConfig); err != nil {
+		return nil, err
 	} else {
-		client.Transport = signingTransport{
-			base:      client.Transport,
-			tokenId:   tokenId,
-			token:     token,
-			signature: signature,
+		return client, nil
+	}
+}
+
+func setBHETLSConfig(tlsConfig *tls.Config) error {
+	if caCert := config.BHECACert.Value().(string); caCert != "" {
+		if pool, err := x509.SystemCertPool(); err != nil {
+			return fmt.Errorf("unable to load system certificate pool: %w", err)
+		} else if pem, err := os.ReadFile(caCert); err != nil {
+			return fmt.Errorf("unable to read bloodhound enterprise ca bundle: %w", err)
+		} else if ok := pool.AppendCertsFromPEM(pem); !ok {
+			return fmt.Errorf("no pem encoded certificates found in bloodhound enterprise ca bundle %s", caCert)
+		} else {
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	if certFile, keyFile := config.BHEClientCert.Value().(string), config.BHEClientKey.Value().(string); certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("--bhe-client-cert and --bhe-client-key must be set together")
+		} else if cert, err := newClientCertificate(certFile, keyFile, config.BHEClientKeyPass.Value().(string)); err != nil {
+			return err
+		} else {
+			tlsConfig.GetClientCertificate = cert.get
+		}
+	}
+
+	if config.BHEInsecure.Value().(bool) {
+		log.Info("WARNING: bloodhound enterprise certificate verification is disabled (--bhe-insecure); connections to the instance can be intercepted. do not use this outside of a lab")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	return nil
+}
+
+func newSigningHttpClient(signature, tokenId, token, proxyUrl string) (*http.Client, error) {
+	if client, err := newBHEHttpClient(proxyUrl); err != nil {
+		return nil, err
+	} else {
+		client.Transport = tracing.Transport{
+			Base: signingTransport{
+				base:      client.Transport,
+				tokenId:   tokenId,
+				token:     token,
+				signature: signature,
+				clock:     &signingClock{},
+			},
 		}
 		return client, nil
 	}
@@ -276,10 +645,45 @@ type signingTransport struct {
 	tokenId   string
 	token     string
 	signature string
+	clock     *signingClock
 }
 
 func (s signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// body; the request is left untouched, so a caller retrying it rewinds its body the same way for every transport
+	body := &bytes.Buffer{}
+	if req.Body != nil {
+		defer req.Body.Close()
+		if _, err := body.ReadFrom(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	signedAt := s.clock.now()
+	if clone, err := s.sign(req, body.Bytes(), signedAt); err != nil {
+		return nil, err
+	} else if res, err := s.base.RoundTrip(clone); err != nil {
+		return nil, err
+	} else if !s.clock.adjust(res, signedAt) {
+		return res, nil
+	} else {
+		// the signature was rejected because of this host's clock, so the request is signed again once with the
+		// server's time
+		res.Body.Close()
+		if clone, err := s.sign(req, body.Bytes(), s.clock.now()); err != nil {
+			return nil, err
+		} else {
+			return s.base.RoundTrip(clone)
+		}
+	}
+}
+
+func (s signingTransport) sign(req *http.Request, body []byte, now time.Time) (*http.Request, error) {
 	clone := req.Clone(req.Context())
+	if len(body) != 0 {
+		clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	} else if req.Body != nil {
+		clone.Body = http.NoBody
+	}
 
 	// token
 	digester := hmac.New(sha256.New, []byte(s.token))
@@ -290,25 +694,15 @@ func (s signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	}
 
 	// datetime
-	datetime := time.Now().Format(time.RFC3339)
+	datetime := now.Format(time.RFC3339)
 	digester = hmac.New(sha256.New, digester.Sum(nil))
 	if _, err := digester.Write([]byte(datetime[:13])); err != nil {
 		return nil, err
 	}
 
 	// body
-	body := &bytes.Buffer{}
 	digester = hmac.New(sha256.New, digester.Sum(nil))
-	if req.Body != nil {
-		defer req.Body.Close()
-		if contentLength, err := body.ReadFrom(req.Body); err != nil {
-			return nil, err
-		} else if contentLength != 0 {
-			req.Body = ioutil.NopCloser(bytes.NewReader(body.Bytes()))
-			clone.Body = ioutil.NopCloser(bytes.NewReader(body.Bytes()))
-		}
-	}
-	if _, err := digester.Write(body.Bytes()); err != nil {
+	if _, err := digester.Write(body); err != nil {
 		return nil, err
 	}
 
@@ -318,28 +712,7 @@ func (s signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	clone.Header.Set("RequestDate", datetime)
 	clone.Header.Set("Signature", base64.StdEncoding.EncodeToString(signature))
 
-	return s.base.RoundTrip(clone)
-}
-
-func contains[T comparable](collection []T, value T) bool {
-	for _, item := range collection {
-		if item == value {
-			return true
-		}
-	}
-	return false
-}
-
-func unique(collection []string) []string {
-	keys := make(map[string]bool)
-	list := []string{}
-	for _, item := range collection {
-		if _, found := keys[item]; !found {
-			keys[item] = true
-			list = append(list, item)
-		}
-	}
-	return list
+	return clone, nil
 }
 
 func stat(path string) (string, fs.FileInfo, error) {
@@ -373,66 +746,183 @@ func setupLogger() {
 	}
 }
 
-// deprecated: use azureWrapper instead
-type AzureWrapper struct {
-	Kind enums.Kind  `json:"kind"`
-	Data interface{} `json:"data"`
+// stampCollected stamps each wrapped item of stream with the time it reached the output
+func stampCollected[T any](ctx context.Context, stream <-chan T) <-chan any {
+	return pipeline.Map(ctx.Done(), stream, func(item T) any {
+		if wrapper, ok := any(item).(collector.Wrapper); ok {
+			return wrapper.Stamp(time.Now())
+		} else {
+			return item
+		}
+	})
 }
 
-type azureWrapper[T any] struct {
-	Kind enums.Kind `json:"kind"`
-	Data T          `json:"data"`
-}
+func outputStream[T any](ctx context.Context, stream <-chan T) {
+	ctx = sinks.WithCollectionInfo(ctx, sinks.CollectionInfo{TenantId: collectionTenantId, Start: time.Now(), Sample: sampleSize})
+
+	var formatted <-chan string
+	if config.Sorted.Value().(bool) {
+		// per-object timestamps would make every line differ between runs
+		formatted = sortOutput(ctx, pipeline.FormatJson(ctx.Done(), stream), config.SortedMaxItems.Value().(int))
+	} else {
+		formatted = pipeline.FormatJson(ctx.Done(), stampCollected(ctx, stream))
+	}
 
-func NewAzureWrapper[T any](kind enums.Kind, data T) azureWrapper[T] {
-	return azureWrapper[T]{
-		Kind: kind,
-		Data: data,
+	if format := config.OutputFormat.Value().(string); format == enums.OutputFormatInventory {
+		outputInventory(ctx, formatted)
+	} else if format == enums.OutputFormatNdjson {
+		outputNdjson(ctx, formatted)
+	} else if format != enums.OutputFormatJson {
+		exitWithCode(ExitCodeConfigError, fmt.Errorf("unsupported output format: %s", format))
+	} else if dir := config.SplitOutput.Value().(string); dir != "" {
+		outputSplit(ctx, dir, formatted)
+	} else if path := config.Zip.Value().(string); path != "" {
+		outputZip(ctx, path, formatted)
+	} else if uri := config.Neo4jUri.Value().(string); uri != "" {
+		outputNeo4j(ctx, uri, formatted)
+	} else if config.DirectUpload.Value().(bool) {
+		outputDirectUpload(ctx, formatted)
+	} else if sink, err := newOutputSink(ctx); err != nil {
+		exit(fmt.Errorf("failed to open the %s output sink: %w", config.OutputSink.Value(), err))
+	} else if err := sinks.WriteToSink(ctx, sink, formatted); err != nil {
+		exit(fmt.Errorf("failed to write stream to the %s output sink: %w", config.OutputSink.Value(), err))
+	} else if path := config.OutputFile.Value().(string); config.ListUpload.Value().(bool) {
+		// --upload is only allowed with a file sink writing to --output
+		if err := uploadFile(ctx, path); err != nil {
+			exitWithCode(ExitCodeUploadFailure, fmt.Errorf("collection succeeded but failed to upload %s: %w", path, err))
+		}
+	}
+
+	if uploadUrl := config.UploadUrl.Value().(string); uploadUrl != "" {
+		outputToUrl(ctx, uploadUrl)
 	}
+	activeCheckpoint.Finish()
 }
 
-func outputStream[T any](ctx context.Context, stream <-chan T) {
-	formatted := pipeline.FormatJson(ctx.Done(), stream)
-	if path := config.OutputFile.Value().(string); path != "" {
-		if err := sinks.WriteToFile(ctx, path, formatted); err != nil {
-			exit(fmt.Errorf("failed to write stream to file: %w", err))
+func outputSplit(ctx context.Context, dir string, stream <-chan string) {
+	if counts, err := sinks.WriteSplitToDir(ctx, dir, config.Compress.Value().(bool), stream); err != nil {
+		exit(fmt.Errorf("failed to write split output: %w", err))
+	} else {
+		log.V(1).Info("wrote split output", "dir", dir, "files", len(counts))
+		for name, count := range counts {
+			log.V(2).Info("wrote output file", "file", name, "count", count)
 		}
+	}
+}
+
+func outputZip(ctx context.Context, path string, stream <-chan string) {
+	chunkSize := int64(config.ZipChunkSize.Value().(int)) * 1024 * 1024
+	if chunks, err := sinks.WriteZipToFile(ctx, path, chunkSize, stream); err != nil {
+		exit(fmt.Errorf("failed to write zip archive: %w", err))
 	} else {
-		sinks.WriteToConsole(ctx, formatted)
+		log.V(1).Info("wrote zip archive", "path", path, "files", chunks)
 	}
 }
 
-func kvRoleAssignmentFilter(roleId string) func(models.KeyVaultRoleAssignment) bool {
-	return func(ra models.KeyVaultRoleAssignment) bool {
-		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+// outputNeo4j writes the collection into Neo4j, retrying failed transactions as requests to Azure are retried
+func outputNeo4j(ctx context.Context, uri string, stream <-chan string) {
+	// already validated by persistentPreRunE
+	maxBackoff, _ := durationValue(config.RetryMaxBackoff)
+	neo4jConfig := sinks.Neo4jConfig{
+		Uri:        uri,
+		User:       config.Neo4jUser.Value().(string),
+		Password:   config.Neo4jPassword.Value().(string),
+		BatchSize:  config.Neo4jBatchSize.Value().(int),
+		MaxRetries: config.MaxRetries.Value().(int),
+		MaxBackoff: maxBackoff,
+		OnRetry: func(err error, backoff time.Duration) {
+			log.Info("warning: neo4j transaction failed; retrying", "error", err.Error(), "backoff", backoff.String())
+		},
+	}
+
+	if counts, err := sinks.WriteToNeo4j(ctx, neo4jConfig, stream); err != nil {
+		exit(fmt.Errorf("failed to write to neo4j after writing %d nodes and %d edges: %w", counts.Nodes, counts.Edges, err))
+	} else {
+		log.Info("wrote collection to neo4j", "nodes", counts.Nodes, "edges", counts.Edges, "skipped", counts.Skipped)
 	}
 }
 
-func vmRoleAssignmentFilter(roleId string) func(models.VirtualMachineRoleAssignment) bool {
-	return func(ra models.VirtualMachineRoleAssignment) bool {
-		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+// outputToUrl uploads the output file, or zip archive, to uploadUrl once it has been written, retrying failed uploads
+// as requests to Azure are retried
+func outputToUrl(ctx context.Context, uploadUrl string) {
+	path := config.Zip.Value().(string)
+	if path == "" {
+		path = config.OutputFile.Value().(string)
+	}
+
+	// already validated by persistentPreRunE
+	maxBackoff, _ := durationValue(config.RetryMaxBackoff)
+	remoteConfig := sinks.RemoteConfig{
+		MaxRetries: config.MaxRetries.Value().(int),
+		MaxBackoff: maxBackoff,
+		OnRetry: func(err error, backoff time.Duration) {
+			log.Info("warning: upload failed; retrying", "error", err.Error(), "backoff", backoff.String())
+		},
+	}
+
+	log.Info("uploading output file", "path", path, "destination", sinks.RedactUrl(uploadUrl))
+	if destination, err := sinks.UploadFileToUrl(ctx, remoteConfig, path, uploadUrl); err != nil {
+		exitWithCode(ExitCodeUploadFailure, fmt.Errorf("collection succeeded but failed to upload %s: %w", path, err))
+	} else {
+		log.Info("uploaded output file", "path", path, "destination", destination)
+		if config.DeleteAfterUpload.Value().(bool) {
+			if err := os.Remove(path); err != nil {
+				log.Error(err, "unable to delete uploaded output file", "path", path)
+			} else {
+				log.V(1).Info("deleted uploaded output file", "path", path)
+			}
+		}
 	}
 }
 
-func rgRoleAssignmentFilter(roleId string) func(models.ResourceGroupRoleAssignment) bool {
-	return func(ra models.ResourceGroupRoleAssignment) bool {
-		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+func outputInventory(ctx context.Context, stream <-chan string) {
+	var (
+		excluded int
+		err      error
+	)
+
+	if path := config.OutputFile.Value().(string); !sinks.IsStdout(path) {
+		excluded, err = sinks.WriteInventoryToFile(ctx, path, stream)
+	} else {
+		excluded, err = sinks.WriteInventoryToConsole(ctx, config.Compress.Value().(bool), stream)
+	}
+
+	if err != nil {
+		exit(fmt.Errorf("failed to write inventory: %w", err))
+	} else if excluded > 0 {
+		log.Info("inventory only includes Azure Resource Manager objects; Azure AD objects and role assignments were excluded", "excluded", excluded)
 	}
 }
 
-func mgmtGroupRoleAssignmentFilter(roleId string) func(models.ManagementGroupRoleAssignment) bool {
-	return func(ra models.ManagementGroupRoleAssignment) bool {
-		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+func outputNdjson(ctx context.Context, stream <-chan string) {
+	var err error
+	if path := config.OutputFile.Value().(string); !sinks.IsStdout(path) {
+		err = sinks.WriteNdjsonToFile(ctx, path, stream)
+	} else {
+		err = sinks.WriteNdjsonToConsole(ctx, config.Compress.Value().(bool), stream)
+	}
+
+	if err != nil {
+		exit(fmt.Errorf("failed to write stream: %w", err))
 	}
 }
 
+// collectionTenantId is the tenant connected to by connectAndCreateClient, recorded in the meta footer of the output
+var collectionTenantId string
+
 func connectAndCreateClient() client.AzureClient {
 	log.V(1).Info("testing connections")
 	if err := testConnections(); err != nil {
-		exit(fmt.Errorf("failed to test connections: %w", err))
+		exitWithCode(ExitCodeNetworkFailure, fmt.Errorf("failed to test connections: %w", err))
 	} else if azClient, err := newAzureClient(); err != nil {
 		exit(fmt.Errorf("failed to create new Azure client: %w", err))
+	} else if err := lockTenant(azClient.TenantInfo().TenantId); err != nil {
+		exit(err)
 	} else {
+		collectionTenantId = azClient.TenantInfo().TenantId
+		if config.UseResourceGraph.Value().(bool) {
+			return client.NewResourceGraphClient(azClient, config.AzSubId.Value().([]string), config.AzMgmtGroupId.Value().([]string))
+		}
 		return azClient
 	}
 
@@ -0,0 +1,341 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/go-logr/logr"
+)
+
+const checkpointVersion = 1
+
+type checkpointFile struct {
+	Version  int                          `json:"version"`
+	TenantId string                       `json:"tenantId"`
+	Kinds    map[enums.Kind]client.Cursor `json:"kinds"`
+}
+
+// Checkpoint records where the enumeration of each checkpointed object type got to. It is saved after every page so
+// a collection that is interrupted, or dies, resumes from the last page it reached instead of from the start.
+//
+// Next to it, the objects file of each checkpointed type keeps what the collectors reading that type need of every
+// object collected so far, so a resumed run can replay the objects it skips to those collectors.
+type Checkpoint struct {
+	path    string
+	log     logr.Logger
+	mutex   sync.Mutex
+	file    checkpointFile
+	objects map[enums.Kind]*os.File
+	// The size of the objects file of each type being resumed when the checkpoint was loaded
+	replay map[enums.Kind]int64
+}
+
+// LoadCheckpoint reads the checkpoint at path, or starts a new one if there is none yet. The checkpoint logs to
+// logger.
+func LoadCheckpoint(path string, logger logr.Logger) (*Checkpoint, error) {
+	if logger.GetSink() == nil {
+		logger = logr.Discard()
+	}
+	state := &Checkpoint{
+		path:    path,
+		log:     logger,
+		file:    checkpointFile{Version: checkpointVersion, Kinds: map[enums.Kind]client.Cursor{}},
+		objects: map[enums.Kind]*os.File{},
+		replay:  map[enums.Kind]int64{},
+	}
+
+	if data, err := os.ReadFile(path); errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read checkpoint: %w", err)
+	} else if err := json.Unmarshal(data, &state.file); err != nil {
+		return nil, fmt.Errorf("unable to read checkpoint %s: %w", path, err)
+	} else if state.file.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d in %s", state.file.Version, path)
+	} else {
+		if state.file.Kinds == nil {
+			state.file.Kinds = map[enums.Kind]client.Cursor{}
+		}
+		for kind := range state.file.Kinds {
+			if info, err := os.Stat(state.objectsPath(kind)); err == nil {
+				state.replay[kind] = info.Size()
+			}
+		}
+		logger.Info("note: resuming collection from checkpoint", "checkpoint", path)
+		return state, nil
+	}
+}
+
+// cursor returns where the enumeration of kind got to. A checkpoint belongs to the tenant it was first used for.
+func (s *Checkpoint) cursor(kind enums.Kind, tenantId string) (client.Cursor, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file.TenantId == "" {
+		s.file.TenantId = tenantId
+	} else if !strings.EqualFold(s.file.TenantId, tenantId) {
+		return client.Cursor{}, fmt.Errorf("checkpoint %s belongs to tenant %s, not %s", s.path, s.file.TenantId, tenantId)
+	}
+	return s.file.Kinds[kind], nil
+}
+
+// record returns a function saving the progress of the enumeration of kind
+func (s *Checkpoint) record(kind enums.Kind) func(client.Cursor) {
+	return func(cursor client.Cursor) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.file.Kinds[kind] = cursor
+		if err := s.save(); err != nil {
+			s.log.Error(err, "unable to save checkpoint", "checkpoint", s.path)
+		}
+	}
+}
+
+// save replaces the checkpoint file as a whole so a collector dying mid-write doesn't leave it truncated
+func (s *Checkpoint) save() error {
+	if data, err := json.Marshal(s.file); err != nil {
+		return err
+	} else if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return err
+	} else if file, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp"); err != nil {
+		return err
+	} else if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	} else if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	} else if err := os.Rename(file.Name(), s.path); err != nil {
+		os.Remove(file.Name())
+		return err
+	} else {
+		return nil
+	}
+}
+
+// objectsPath returns the path of the objects file of kind
+func (s *Checkpoint) objectsPath(kind enums.Kind) string {
+	return fmt.Sprintf("%s.%s.ndjson", s.path, kind)
+}
+
+// collected appends the properties of obj read by the collectors depending on kind, see minimalSelect, to the objects
+// file of kind. The file is started over unless kind is being resumed.
+func (s *Checkpoint) collected(kind enums.Kind, obj interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, ok := s.objects[kind]
+	if !ok {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if _, resumed := s.file.Kinds[kind]; resumed {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+			s.log.Error(err, "unable to record collected objects", "checkpoint", s.path, "kind", kind)
+		} else if file, err = os.OpenFile(s.objectsPath(kind), flags, 0644); err != nil {
+			s.log.Error(err, "unable to record collected objects", "checkpoint", s.path, "kind", kind)
+		}
+		s.objects[kind] = file
+	}
+	if file == nil {
+		return
+	}
+
+	var properties map[string]json.RawMessage
+	if data, err := json.Marshal(obj); err != nil {
+		s.log.Error(err, "unable to record collected object", "checkpoint", s.path, "kind", kind)
+	} else if err := json.Unmarshal(data, &properties); err != nil {
+		s.log.Error(err, "unable to record collected object", "checkpoint", s.path, "kind", kind)
+	} else {
+		minimal := map[string]json.RawMessage{}
+		for _, name := range minimalSelect[kind] {
+			if value, ok := properties[name]; ok {
+				minimal[name] = value
+			}
+		}
+		if data, err := json.Marshal(minimal); err != nil {
+			s.log.Error(err, "unable to record collected object", "checkpoint", s.path, "kind", kind)
+		} else if _, err := file.Write(append(data, '\n')); err != nil {
+			s.log.Error(err, "unable to record collected object", "checkpoint", s.path, "kind", kind)
+		}
+	}
+}
+
+// replayed streams the objects of kind collected before the checkpoint was loaded, as the collector of kind would
+// have. They are only meant for the collectors depending on kind, the output of the interrupted run already has them.
+func (s *Checkpoint) replayed(ctx context.Context, kind enums.Kind) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		size, ok := s.replay[kind]
+		if !ok {
+			return
+		}
+		file, err := os.Open(s.objectsPath(kind))
+		if err != nil {
+			logOf(ctx).Error(err, "unable to replay collected objects", "checkpoint", s.path, "kind", kind)
+			return
+		}
+		defer file.Close()
+
+		// Objects appended by this run are streamed by the collector of kind itself
+		count := 0
+		decoder := json.NewDecoder(io.LimitReader(file, size))
+		for {
+			var (
+				data interface{}
+				err  error
+			)
+			switch kind {
+			case enums.KindAZGroup:
+				var group models.Group
+				err = decoder.Decode(&group)
+				data = group
+			case enums.KindAZServicePrincipal:
+				var servicePrincipal models.ServicePrincipal
+				err = decoder.Decode(&servicePrincipal)
+				data = servicePrincipal
+			case enums.KindAZUser:
+				var user models.User
+				err = decoder.Decode(&user)
+				data = user
+			default:
+				return
+			}
+
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				// The interrupted run may have died mid-write, the objects up to here are all there is
+				logOf(ctx).Error(err, "unable to replay collected objects", "checkpoint", s.path, "kind", kind)
+				break
+			}
+			select {
+			case out <- AzureWrapper{Kind: kind, Data: data}:
+				count++
+			case <-ctx.Done():
+				return
+			}
+		}
+		logOf(ctx).Info("note: replaying objects collected before resuming checkpoint", "kind", kind, "count", count)
+	}()
+
+	return out
+}
+
+// Finish removes the checkpoint once every checkpointed object type has been collected. A checkpoint with anything
+// left to collect is kept for the next run.
+func (s *Checkpoint) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for kind, file := range s.objects {
+		if file != nil {
+			file.Close()
+		}
+		delete(s.objects, kind)
+	}
+	for kind, cursor := range s.file.Kinds {
+		if !cursor.Complete {
+			s.log.Info("note: collection is incomplete, keeping checkpoint to resume from", "checkpoint", s.path, "kind", kind)
+			return
+		}
+	}
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.log.Error(err, "unable to remove checkpoint", "checkpoint", s.path)
+	}
+	for kind := range s.file.Kinds {
+		if err := os.Remove(s.objectsPath(kind)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.log.Error(err, "unable to remove checkpoint", "checkpoint", s.objectsPath(kind))
+		}
+	}
+}
+
+// withReplayed returns the stream a collector depending on kind reads: in, along with the objects of kind collected
+// before resuming from the checkpoint, if any
+func withReplayed(ctx context.Context, kind enums.Kind, in <-chan interface{}) <-chan interface{} {
+	if checkpoint := optionsOf(ctx).Checkpoint; checkpoint == nil {
+		return in
+	} else if _, ok := checkpoint.replay[kind]; !ok {
+		return in
+	} else {
+		return pipeline.Mux(ctx.Done(), checkpoint.replayed(ctx, kind), in)
+	}
+}
+
+// resumeContext returns the context to enumerate kind under, resuming from Options.Checkpoint or
+// Options.ResumeFromObjectId. It returns false when kind is not to be enumerated, because the checkpoint shows it was
+// already collected or belongs to another tenant.
+func resumeContext(ctx context.Context, kind enums.Kind, tenantId string) (context.Context, bool) {
+	var (
+		options = optionsOf(ctx)
+		start   client.Cursor
+		record  func(client.Cursor)
+	)
+
+	if options.Checkpoint != nil {
+		if cursor, err := options.Checkpoint.cursor(kind, tenantId); err != nil {
+			collectionError(ctx, kind, err, "unable to resume from checkpoint")
+			return ctx, false
+		} else if cursor.Complete {
+			logOf(ctx).Info("note: skipping object type already collected according to checkpoint", "kind", kind)
+			return ctx, false
+		} else {
+			start = cursor
+			record = options.Checkpoint.record(kind)
+		}
+	}
+
+	if id := options.ResumeFromObjectId; id != "" {
+		start = client.Cursor{LastId: id}
+	}
+
+	if start == (client.Cursor{}) && record == nil {
+		return ctx, true
+	} else {
+		if start != (client.Cursor{}) {
+			logOf(ctx).Info("note: resuming collection", "kind", kind, "lastId", start.LastId)
+		}
+		return client.WithCursor(ctx, start, record), true
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/go-logr/logr"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	state, err := LoadCheckpoint(path, logr.Discard())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if cursor, err := state.cursor(enums.KindAZUser, "Tenant"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if cursor != (client.Cursor{}) {
+		t.Errorf("got %v, want an empty cursor", cursor)
+	}
+
+	want := client.Cursor{NextLink: "https://graph.microsoft.com/v1.0/users?$skiptoken=a", LastId: "2"}
+	state.record(enums.KindAZUser)(want)
+	state.record(enums.KindAZGroup)(client.Cursor{Complete: true})
+
+	if resumed, err := LoadCheckpoint(path, logr.Discard()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := resumed.cursor(enums.KindAZUser, "other"); err == nil {
+		t.Error("expected an error resuming another tenant but did not receive one")
+	} else if cursor, err := resumed.cursor(enums.KindAZUser, "tenant"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if cursor != want {
+		t.Errorf("got %v, want %v", cursor, want)
+	} else if cursor, _ := resumed.cursor(enums.KindAZGroup, "tenant"); !cursor.Complete {
+		t.Error("expected groups to be complete")
+	}
+
+	// incomplete checkpoints are kept for the next run
+	state.Finish()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	state.record(enums.KindAZUser)(client.Cursor{Complete: true})
+	state.Finish()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want %v", err, os.ErrNotExist)
+	}
+
+	var none *Checkpoint
+	none.Finish()
+}
+
+func TestCheckpointReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	ctx := context.Background()
+
+	state, err := LoadCheckpoint(path, logr.Discard())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"1", "2"} {
+		state.collected(enums.KindAZGroup, models.Group{
+			Group:    azure.Group{DirectoryObject: azure.DirectoryObject{Id: id}, DisplayName: "group " + id},
+			TenantId: "tenant",
+		})
+	}
+	state.record(enums.KindAZGroup)(client.Cursor{Complete: true})
+	state.record(enums.KindAZUser)(client.Cursor{LastId: "1"})
+	state.Finish()
+
+	resumed, err := LoadCheckpoint(path, logr.Discard())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// objects collected after resuming are streamed by their collector, not replayed
+	resumed.collected(enums.KindAZGroup, models.Group{Group: azure.Group{DirectoryObject: azure.DirectoryObject{Id: "3"}}})
+
+	var ids []string
+	for item := range resumed.replayed(ctx, enums.KindAZGroup) {
+		if group := item.(AzureWrapper).Data.(models.Group); group.DisplayName != "" {
+			t.Errorf("got %s, want only the properties read from groups", group.DisplayName)
+		} else {
+			ids = append(ids, group.Id)
+		}
+	}
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("got %v, want [1 2]", ids)
+	}
+	if _, ok := <-resumed.replayed(ctx, enums.KindAZUser); ok {
+		t.Error("expected no users to replay")
+	}
+
+	resumed.record(enums.KindAZUser)(client.Cursor{Complete: true})
+	resumed.Finish()
+	if _, err := os.Stat(resumed.objectsPath(enums.KindAZGroup)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestLoadCheckpointInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"malformed.json": `{"version":`,
+		"version.json":   `{"version":2}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		} else if _, err := LoadCheckpoint(path, logr.Discard()); err == nil {
+			t.Errorf("%s: expected an error but did not receive one", name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// Counts are the objects a collection left out
+type Counts struct {
+	// the users and devices left out by Options.ExcludeDisabled
+	ExcludedDisabled int64
+	// the Azure resources left out by Options.Since
+	SkippedUnchanged int64
+	// the objects left out by Options.Sample beyond those the collectors stopped listing at
+	SampledOut int64
+}
+
+// Collect collects the tenant azClient is connected to with options and returns the wrappers of the collected objects,
+// AzureWrapper or those of NewAzureWrapper, as they are collected, along with the counts of the objects it left out.
+// The channel is closed once collection completes or ctx is done; the counts are final once it is.
+func Collect(ctx context.Context, azClient client.AzureClient, options Options) (<-chan interface{}, *Counts) {
+	if options.Kinds != nil {
+		scope := FullScope
+		if options.Scope != nil {
+			scope = *options.Scope
+		}
+		scope = scope.Narrow(options.Kinds)
+		options.Scope = &scope
+	}
+	if options.Sample > 0 {
+		ctx = client.WithLimit(ctx, options.Sample)
+	}
+	ctx = WithOptions(ctx, options)
+
+	var (
+		scope   = collectionScopeOf(ctx)
+		streams []<-chan interface{}
+	)
+
+	if scope.AzureAD {
+		streams = append(streams, listAllAD(ctx, azClient))
+	}
+	if scope.AzureRM {
+		streams = append(streams, listAllRM(ctx, azClient))
+	}
+	stream := filterKinds(ctx, pipeline.Mux(ctx.Done(), streams...))
+
+	if options.Sample > 0 {
+		stream = sampleKinds(ctx, stream, options.Sample)
+	}
+
+	if options.ExcludeDisabled {
+		stream = excludeDisabledPrincipals(ctx, stream, time.Now().AddDate(0, 0, -options.InactiveDeviceDays))
+	}
+	return stream, countsOf(ctx)
+}
+
+// excludeDisabledPrincipals drops disabled users and devices that have not signed in since activeSince. Graph can't
+// filter on these reliably so they are fetched and filtered here; excluded items are counted in the Counts of ctx.
+func excludeDisabledPrincipals(ctx context.Context, in <-chan interface{}, activeSince time.Time) <-chan interface{} {
+	counts := countsOf(ctx)
+	return pipeline.Filter(ctx.Done(), in, func(item interface{}) bool {
+		var include = true
+		if wrapper, ok := item.(AzureWrapper); !ok {
+			return true
+		} else if user, ok := wrapper.Data.(models.User); ok {
+			include = user.AccountEnabled
+		} else if device, ok := wrapper.Data.(models.Device); ok {
+			lastSignIn, err := time.Parse(time.RFC3339, device.ApproximateLastSignInDateTime)
+			include = err == nil && !lastSignIn.Before(activeSince)
+		}
+
+		if !include {
+			atomic.AddInt64(&counts.ExcludedDisabled, 1)
+		}
+		return include
+	})
+}
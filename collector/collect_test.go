@@ -0,0 +1,93 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/logger"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/go-logr/logr"
+)
+
+func TestExcludeDisabledPrincipals(t *testing.T) {
+	var (
+		now         = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+		activeSince = now.AddDate(0, 0, -90)
+		in          = make(chan interface{})
+		user        = func(id string, enabled bool) AzureWrapper {
+			return AzureWrapper{Kind: enums.KindAZUser, Data: models.User{User: azure.User{DirectoryObject: azure.DirectoryObject{Id: id}, AccountEnabled: enabled}}}
+		}
+		device = func(id, lastSignIn string) AzureWrapper {
+			return AzureWrapper{Kind: enums.KindAZDevice, Data: models.Device{Device: azure.Device{DirectoryObject: azure.DirectoryObject{Id: id}, ApproximateLastSignInDateTime: lastSignIn}}}
+		}
+	)
+
+	go func() {
+		defer close(in)
+		in <- user("enabled", true)
+		in <- user("disabled", false)
+		in <- device("active", "2023-05-01T00:00:00Z")
+		in <- device("inactive", "2022-01-01T00:00:00Z")
+		in <- device("never", "")
+		in <- AzureWrapper{Kind: enums.KindAZGroup, Data: models.Group{}}
+	}()
+
+	var (
+		ctx  = WithOptions(context.Background(), Options{})
+		kept []enums.Kind
+	)
+	for item := range excludeDisabledPrincipals(ctx, in, activeSince) {
+		kept = append(kept, item.(AzureWrapper).Kind)
+	}
+
+	want := []enums.Kind{enums.KindAZUser, enums.KindAZDevice, enums.KindAZGroup}
+	if len(kept) != len(want) {
+		t.Fatalf("got %v, want %v", kept, want)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Errorf("got %v, want %v", kept, want)
+		}
+	}
+
+	if excluded := countsOf(ctx).ExcludedDisabled; excluded != 3 {
+		t.Errorf("got %v excluded, want %v", excluded, 3)
+	}
+}
+
+// testLogger logs the collectors under test the way the azurehound commands do. No command registers the logging
+// flags here, so the verbosity is set to its default.
+func testLogger() logr.Logger {
+	config.VerbosityLevel.Set(config.VerbosityLevel.Default)
+	if logger, err := logger.GetLogger(); err != nil {
+		panic(err)
+	} else {
+		return *logger
+	}
+}
+
+// testContext returns a context under which the collectors under test run with the zero Options, logging to testLogger
+func testContext() context.Context {
+	return WithOptions(context.Background(), Options{Logger: testLogger()})
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/go-logr/logr"
+)
+
+// The number of errors that left each object kind incomplete during the current collection
+var failedKinds sync.Map
+
+// The number of requests denied for insufficient permissions for each object kind during the current collection
+var forbiddenKinds sync.Map
+
+// collectionError logs an error that stopped part of the collection of kind, e.g. the listing of a subscription, and
+// counts it against the kind so it is reported by FailedKinds, and passes it to Options.OnError. Only the first
+// permission error of a kind is logged as an error; the rest are counted and summarized by LogForbiddenSummary when
+// collection ends.
+func collectionError(ctx context.Context, kind enums.Kind, err error, msg string, keysAndValues ...interface{}) {
+	count, _ := failedKinds.LoadOrStore(kind, new(int64))
+	atomic.AddInt64(count.(*int64), 1)
+	if onError := optionsOf(ctx).OnError; onError != nil {
+		onError(kind, err)
+	}
+
+	if errors.Is(err, rest.ErrForbidden) {
+		forbidden, _ := forbiddenKinds.LoadOrStore(kind, new(int64))
+		if atomic.AddInt64(forbidden.(*int64), 1) > 1 {
+			logOf(ctx).WithCallDepth(1).V(1).Info(msg, append(keysAndValues, "error", err.Error())...)
+			return
+		}
+		keysAndValues = append(keysAndValues, "note", "further permission errors of this kind are summarized when collection ends")
+	}
+	logOf(ctx).WithCallDepth(1).Error(err, msg, keysAndValues...)
+}
+
+// LogForbiddenSummary logs a warning for each object kind that had requests denied for insufficient permissions
+// during the current collection to logger, with the number of denied requests and the permission most likely missing
+func LogForbiddenSummary(logger logr.Logger) {
+	kinds := []string{}
+	forbiddenKinds.Range(func(key, _ interface{}) bool {
+		kinds = append(kinds, string(key.(enums.Kind)))
+		return true
+	})
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		value, _ := forbiddenKinds.Load(enums.Kind(kind))
+		count := atomic.LoadInt64(value.(*int64))
+		if permission, ok := RequiredPermissions[enums.Kind(kind)]; ok {
+			logger.Info("warning: requests were denied for insufficient permissions; azurehound likely requires the "+permission+" permission to collect them", "kind", kind, "denied", count)
+		} else {
+			logger.Info("warning: requests were denied for insufficient permissions; azurehound likely requires the Reader role on the affected subscriptions to collect them", "kind", kind, "denied", count)
+		}
+	}
+}
+
+// FailedKinds returns the object kinds with errors during the current collection along with their error
+// counts, e.g. "AZVM (2 errors)", sorted by kind
+func FailedKinds() []string {
+	kinds := []string{}
+	failedKinds.Range(func(key, value interface{}) bool {
+		if count := atomic.LoadInt64(value.(*int64)); count == 1 {
+			kinds = append(kinds, fmt.Sprintf("%s (1 error)", key))
+		} else {
+			kinds = append(kinds, fmt.Sprintf("%s (%d errors)", key, count))
+		}
+		return true
+	})
+	sort.Strings(kinds)
+	return kinds
+}
+
+// ResetKinds forgets the object kinds that FailedKinds and SkippedKinds report, for starting another collection
+func ResetKinds() {
+	resetFailedKinds()
+	resetSkippedKinds()
+}
+
+func resetFailedKinds() {
+	failedKinds.Range(func(key, _ interface{}) bool {
+		failedKinds.Delete(key)
+		return true
+	})
+	forbiddenKinds.Range(func(key, _ interface{}) bool {
+		forbiddenKinds.Delete(key)
+		return true
+	})
+}
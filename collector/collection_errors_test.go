@@ -0,0 +1,65 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/logger"
+)
+
+func TestCollectionErrorForbidden(t *testing.T) {
+	resetFailedKinds()
+	t.Cleanup(resetFailedKinds)
+
+	var (
+		reported int
+		ctx      = WithOptions(context.Background(), Options{OnError: func(kind enums.Kind, err error) { reported++ }, Logger: testLogger()})
+		logged   = logger.ErrorCount()
+	)
+	for i := 0; i < 3; i++ {
+		collectionError(ctx, enums.KindAZVMRoleAssignment, fmt.Errorf("%w: AuthorizationFailed", rest.ErrForbidden), "unable to continue processing role assignments for this virtual machine")
+	}
+	collectionError(ctx, enums.KindAZVM, errors.New("timeout"), "unable to continue processing virtual machines for this subscription")
+
+	// only the first permission error of a kind is logged as an error
+	if errors := logger.ErrorCount() - logged; errors != 2 {
+		t.Errorf("got %v logged errors, want 2", errors)
+	} else if reported != 4 {
+		t.Errorf("got %v errors reported to OnError, want 4", reported)
+	} else if kinds := FailedKinds(); strings.Join(kinds, ",") != "AZVM (1 error),AZVMRoleAssignment (3 errors)" {
+		t.Errorf("got failed kinds %v", kinds)
+	} else if count, ok := forbiddenKinds.Load(enums.KindAZVMRoleAssignment); !ok || atomic.LoadInt64(count.(*int64)) != 3 {
+		t.Error("expected 3 denied requests to be counted")
+	} else if _, ok := forbiddenKinds.Load(enums.KindAZVM); ok {
+		t.Error("expected errors other than permission errors not to be counted as denied")
+	}
+
+	LogForbiddenSummary(testLogger())
+	resetFailedKinds()
+	if _, ok := forbiddenKinds.Load(enums.KindAZVMRoleAssignment); ok {
+		t.Error("expected denied requests to be reset")
+	}
+}
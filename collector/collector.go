@@ -0,0 +1,91 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package collector collects an Azure tenant the same way the azurehound list command does, for programs that embed
+// AzureHound. Connect with New, or NewWithClient for a client built with the client package, and read the collected
+// objects from Stream:
+//
+//	c, err := collector.New(config.Config{ApplicationId: "...", ClientSecret: "...", Tenant: "contoso.onmicrosoft.com"})
+//	...
+//	for object := range c.Stream(ctx, collector.Options{ExcludeDisabled: true, InactiveDeviceDays: 90}) {
+//		...
+//	}
+//
+// The objects are those of the data array of an azurehound output file; the models package has their types. Collect
+// and the collectors of single kinds, e.g. ListUsers, return the wrappers the azurehound commands write instead.
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// Object is a collected object along with its kind, e.g. a models.User of kind enums.KindAZUser
+type Object struct {
+	Kind enums.Kind  `json:"kind"`
+	Data interface{} `json:"data"`
+}
+
+type Collector struct {
+	client client.AzureClient
+}
+
+// New authenticates with the tenant described by cfg and returns a collector for it
+func New(cfg config.Config) (*Collector, error) {
+	if azClient, err := client.NewClient(cfg); err != nil {
+		return nil, err
+	} else {
+		return NewWithClient(azClient), nil
+	}
+}
+
+// NewWithClient returns a collector for the tenant azClient is connected to
+func NewWithClient(azClient client.AzureClient) *Collector {
+	return &Collector{client: azClient}
+}
+
+// Client returns the client the collector collects with
+func (s *Collector) Client() client.AzureClient {
+	return s.client
+}
+
+// Stream collects the tenant with options and returns its objects as they are collected. The channel is closed once
+// collection completes or ctx is done. Objects that could not be collected are logged and left out rather than ending
+// the stream.
+func (s *Collector) Stream(ctx context.Context, options Options) <-chan Object {
+	out := make(chan Object)
+
+	go func() {
+		defer close(out)
+		stream, _ := Collect(ctx, s.client, options)
+		for item := range pipeline.OrDone(ctx.Done(), stream) {
+			if wrapper, ok := item.(Wrapper); ok {
+				untyped := wrapper.Untyped()
+				select {
+				case out <- Object{Kind: untyped.Kind, Data: untyped.Data}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
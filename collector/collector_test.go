@@ -0,0 +1,142 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func testToken(t *testing.T, claims map[string]interface{}) string {
+	if payload, err := json.Marshal(claims); err != nil {
+		t.Fatal(err)
+		return ""
+	} else {
+		return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+	}
+}
+
+func TestStream(t *testing.T) {
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0/organization":
+			w.Write([]byte(`{"value":[{"id":"tenant","displayName":"Tenant"}]}`))
+		case "/v1.0/users":
+			w.Write([]byte(`{"value":[{"id":"user","displayName":"User"}]}`))
+		default:
+			w.Write([]byte(`{"value":[]}`))
+		}
+	}))
+	defer graph.Close()
+
+	resourceManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value":[]}`))
+	}))
+	defer resourceManager.Close()
+
+	expires := float64(time.Now().Add(time.Hour).Unix())
+	collector, err := New(config.Config{
+		Graph:      graph.URL,
+		GraphToken: testToken(t, map[string]interface{}{"aud": graph.URL, "tid": "tenant", "exp": expires}),
+		Management: resourceManager.URL,
+		ArmToken:   testToken(t, map[string]interface{}{"aud": resourceManager.URL + "/", "tid": "tenant", "exp": expires}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if tenantId := collector.Client().TenantInfo().TenantId; tenantId != "tenant" {
+		t.Errorf("got tenant %v, want %v", tenantId, "tenant")
+	}
+
+	var (
+		users  []models.User
+		tenant *models.Tenant
+	)
+	for object := range collector.Stream(context.Background(), Options{}) {
+		switch data := object.Data.(type) {
+		case models.User:
+			if object.Kind != enums.KindAZUser {
+				t.Errorf("got kind %v for a user, want %v", object.Kind, enums.KindAZUser)
+			}
+			users = append(users, data)
+		case models.Tenant:
+			if data.Collected {
+				tenant = &data
+			}
+		}
+	}
+
+	if len(users) != 1 || users[0].Id != "user" || users[0].TenantId != "tenant" {
+		t.Errorf("got users %+v, want the one user of the tenant", users)
+	} else if tenant == nil {
+		t.Error("expected the collected tenant")
+	}
+}
+
+func TestStreamCancelled(t *testing.T) {
+	// every request hangs until the test ends, so only cancellation ends the stream
+	block := make(chan struct{})
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.0/organization" {
+			w.Write([]byte(`{"value":[{"id":"tenant","displayName":"Tenant"}]}`))
+		} else {
+			<-block
+		}
+	}))
+	defer graph.Close()
+	defer close(block)
+
+	expires := float64(time.Now().Add(time.Hour).Unix())
+	collector, err := New(config.Config{
+		Graph:      graph.URL,
+		GraphToken: testToken(t, map[string]interface{}{"aud": graph.URL, "tid": "tenant", "exp": expires}),
+		Management: graph.URL,
+		ArmToken:   testToken(t, map[string]interface{}{"aud": graph.URL + "/", "tid": "tenant", "exp": expires}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := collector.Stream(ctx, Options{})
+	cancel()
+
+	select {
+	case <-drain(stream):
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not closed after cancellation")
+	}
+}
+
+func drain(stream <-chan Object) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range stream {
+		}
+	}()
+	return done
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListAppOwners(ctx context.Context, client client.AzureClient, apps <-chan azureWrapper[models.App]) <-chan azureWrapper[models.AppOwners] {
+	var (
+		out     = make(chan azureWrapper[models.AppOwners])
+		streams = pipeline.Demux(ctx.Done(), apps, 25)
+		wg      sync.WaitGroup
+	)
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for app := range stream {
+				if skipCollector(ctx, enums.KindAZAppOwner) {
+					continue
+				}
+				var (
+					data = models.AppOwners{
+						AppId: app.Data.AppId,
+					}
+					count = 0
+				)
+				for item := range client.ListAzureADAppOwners(ctx, app.Data.Id, "", "", "", nil) {
+					if skipForbidden(ctx, item.Error, enums.KindAZAppOwner) {
+						logOf(ctx).V(1).Info("skipping owners for this app due to insufficient permissions", "appId", app.Data.AppId)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZAppOwner, item.Error, "unable to continue processing owners for this app", "appId", app.Data.AppId)
+					} else {
+						appOwner := models.AppOwner{
+							Owner: item.Ok,
+							AppId: item.AppId,
+						}
+						logOf(ctx).V(2).Info("found app owner", "appOwner", appOwner)
+						count++
+						data.Owners = append(data.Owners, appOwner)
+					}
+				}
+
+				out <- NewAzureWrapper(
+					enums.KindAZAppOwner,
+					data,
+				)
+				logOf(ctx).V(1).Info("finished listing app owners", "appId", app.Data.AppId, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all app owners")
+	}()
+
+	return out
+}
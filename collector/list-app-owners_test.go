@@ -15,10 +15,9 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package cmd
+package collector
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -30,14 +29,10 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
-func init() {
-	setupLogger()
-}
-
 func TestListAppOwners(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	ctx := context.Background()
+	ctx := testContext()
 
 	mockClient := mocks.NewMockAzureClient(ctrl)
 
@@ -50,7 +45,7 @@ func TestListAppOwners(t *testing.T) {
 	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
 	mockClient.EXPECT().ListAzureADAppOwners(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockAppOwnerChannel).Times(1)
 	mockClient.EXPECT().ListAzureADAppOwners(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockAppOwnerChannel2).Times(1)
-	channel := listAppOwners(ctx, mockClient, mockAppsChannel)
+	channel := ListAppOwners(ctx, mockClient, mockAppsChannel)
 
 	go func() {
 		defer close(mockAppsChannel)
@@ -0,0 +1,96 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListAppRoleAssignments(ctx context.Context, client client.AzureClient, servicePrincipals <-chan interface{}) <-chan interface{} {
+	var (
+		out         = make(chan interface{})
+		filteredSPs = make(chan models.ServicePrincipal)
+		streams     = pipeline.Demux(ctx.Done(), filteredSPs, 25)
+		wg          sync.WaitGroup
+	)
+
+	go func() {
+		defer close(filteredSPs)
+
+		for result := range pipeline.OrDone(ctx.Done(), servicePrincipals) {
+			if servicePrincipal, ok := result.(AzureWrapper).Data.(models.ServicePrincipal); !ok {
+				collectionError(ctx, enums.KindAZAppRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating app role assignments", "result", result)
+				return
+			} else {
+				if len(servicePrincipal.AppRoles) != 0 {
+					filteredSPs <- servicePrincipal
+				}
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for servicePrincipal := range stream {
+				if skipCollector(ctx, enums.KindAZAppRoleAssignment) {
+					continue
+				}
+				var (
+					count = 0
+				)
+				for item := range client.ListAzureADAppRoleAssignments(ctx, servicePrincipal.Id, "", "", "", "", nil) {
+					if skipForbidden(ctx, item.Error, enums.KindAZAppRoleAssignment) {
+						logOf(ctx).V(1).Info("skipping app role assignments for this service principal due to insufficient permissions", "servicePrincipalId", servicePrincipal)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZAppRoleAssignment, item.Error, "unable to continue processing app role assignments for this service principal", "servicePrincipalId", servicePrincipal)
+					} else {
+						logOf(ctx).V(2).Info("found app role assignment", "roleAssignments", item)
+						count++
+						out <- AzureWrapper{
+							Kind: enums.KindAZAppRoleAssignment,
+							Data: models.AppRoleAssignment{
+								AppRoleAssignment: item.Ok,
+								AppId:             servicePrincipal.AppId,
+								TenantId:          client.TenantInfo().TenantId,
+							},
+						}
+					}
+				}
+				logOf(ctx).V(1).Info("finished listing app role assignments", "appId", servicePrincipal.AppId, "servicePrincipalId", servicePrincipal.Id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all app role assignments")
+	}()
+
+	return out
+}
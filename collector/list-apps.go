@@ -0,0 +1,68 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func ListApps(ctx context.Context, client client.AzureClient) <-chan azureWrapper[models.App] {
+	out := make(chan azureWrapper[models.App])
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZApp) {
+			return
+		}
+		count := 0
+		for item := range client.ListAzureADApps(ctx, "", "", "", "", collectorSelect(ctx, enums.KindAZApp, nil)) {
+			if skipForbidden(ctx, item.Error, enums.KindAZApp) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZApp, item.Error, "unable to continue processing applications")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found application", "app", item)
+				count++
+				var (
+					keyCredentials      = models.NewKeyCredentials(item.Ok.KeyCredentials)
+					passwordCredentials = models.NewPasswordCredentials(item.Ok.PasswordCredentials)
+				)
+				out <- NewAzureWrapper(
+					enums.KindAZApp,
+					models.App{
+						Application:         item.Ok,
+						KeyCredentials:      keyCredentials,
+						PasswordCredentials: passwordCredentials,
+						CredentialHygiene:   models.NewCredentialHygiene(keyCredentials, passwordCredentials, time.Now(), optionsOf(ctx).RecentCredentialDays),
+						TenantId:            client.TenantInfo().TenantId,
+						TenantName:          client.TenantInfo().DisplayName,
+					},
+				)
+			}
+		}
+		logOf(ctx).Info("finished listing all apps", "count", count)
+	}()
+
+	return out
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/gofrs/uuid"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListApps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.ApplicationResult)
+	mockTenant := azure.Tenant{}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADApps(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.ApplicationResult{
+			Ok: azure.Application{},
+		}
+		mockChannel <- azure.ApplicationResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.ApplicationResult{
+			Ok: azure.Application{},
+		}
+	}()
+
+	channel := ListApps(ctx, mockClient)
+	<-channel
+	if _, ok := <-channel; ok {
+		t.Error("expected channel to close from an error result but it did not")
+	}
+}
+
+func TestListAppsCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.ApplicationResult)
+	mockTenant := azure.Tenant{}
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADApps(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	keyId := uuid.Must(uuid.NewV4())
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.ApplicationResult{
+			Ok: azure.Application{
+				KeyCredentials: []azure.KeyCredential{{
+					DisplayName:   "cert",
+					EndDateTime:   "2030-01-01T00:00:00Z",
+					Key:           []byte("keymaterial"),
+					KeyId:         keyId,
+					StartDateTime: "2020-01-01T00:00:00Z",
+					Type:          "Symmetric",
+				}},
+				PasswordCredentials: []azure.PasswordCredential{{
+					DisplayName: "secret",
+					EndDateTime: "2030-01-01T00:00:00Z",
+					Hint:        "abc",
+					KeyId:       keyId,
+					SecretText:  "abcdefghijklmnop",
+				}},
+			},
+		}
+	}()
+
+	channel := ListApps(ctx, mockClient)
+	batch := []interface{}{}
+	for item := range channel {
+		batch = append(batch, item)
+	}
+
+	var decoded struct {
+		Data []azureWrapper[struct {
+			KeyCredentials      []models.KeyCredential      `json:"keyCredentials"`
+			PasswordCredentials []models.PasswordCredential `json:"passwordCredentials"`
+		}] `json:"data"`
+	}
+	if bytes, err := json.Marshal(models.IngestRequest{Data: batch}); err != nil {
+		t.Fatalf("failed to marshal ingest request: %v", err)
+	} else if strings.Contains(string(bytes), `"key":`) || strings.Contains(string(bytes), `"secretText":`) || strings.Contains(string(bytes), `"hint":`) {
+		t.Errorf("ingest request contains secret material: %s", bytes)
+	} else if err := json.Unmarshal(bytes, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal ingest request: %v", err)
+	} else if len(decoded.Data) != 1 {
+		t.Fatalf("got %v, want %v", len(decoded.Data), 1)
+	} else if keys := decoded.Data[0].Data.KeyCredentials; len(keys) != 1 || keys[0].KeyId != keyId || keys[0].EndDateTime != "2030-01-01T00:00:00Z" || keys[0].Type != "Symmetric" {
+		t.Errorf("got %+v, want key credential %v", keys, keyId)
+	} else if passwords := decoded.Data[0].Data.PasswordCredentials; len(passwords) != 1 || passwords[0].KeyId != keyId || passwords[0].DisplayName != "secret" {
+		t.Errorf("got %+v, want password credential %v", passwords, keyId)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListAutomationAccountRoleAssignments(ctx context.Context, client client.AzureClient, automationAccounts <-chan interface{}) <-chan interface{} {
+	automationAccounts = skipInput(ctx, enums.KindAZAutomationAccountRoleAssignment, automationAccounts)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), automationAccounts) {
+			if automationAccount, ok := result.(AzureWrapper).Data.(models.AutomationAccount); !ok {
+				collectionError(ctx, enums.KindAZAutomationAccountRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating automation account role assignments", "result", result)
+				return
+			} else {
+				ids <- automationAccount.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				var (
+					automationAccountRoleAssignments = models.AzureRoleAssignments{
+						ObjectId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZAutomationAccountRoleAssignment, item.Error, "unable to continue processing role assignments for this automation account", "automationAccountId", id)
+					} else {
+						roleDefinitionId := path.Base(item.Ok.Properties.RoleDefinitionId)
+
+						automationAccountRoleAssignment := models.AzureRoleAssignment{
+							Assignee:         item.Ok,
+							ObjectId:         item.ParentId,
+							RoleDefinitionId: roleDefinitionId,
+						}
+						logOf(ctx).V(2).Info("found automation account role assignment", "automationAccountRoleAssignment", automationAccountRoleAssignment)
+						count++
+						automationAccountRoleAssignments.RoleAssignments = append(automationAccountRoleAssignments.RoleAssignments, automationAccountRoleAssignment)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZAutomationAccountRoleAssignment,
+					Data: automationAccountRoleAssignments,
+				}
+				logOf(ctx).V(1).Info("finished listing automation account role assignments", "automationAccountId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all automation account role assignments")
+	}()
+
+	return out
+}
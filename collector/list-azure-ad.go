@@ -0,0 +1,204 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func listAllAD(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	ctx = withCollectionStage(ctx, stageAzureAD)
+	var (
+		devices  = make(chan interface{})
+		devices2 = make(chan interface{})
+		devices3 = make(chan interface{})
+
+		groups  = make(chan interface{})
+		groups2 = make(chan interface{})
+		groups3 = make(chan interface{})
+		groups4 = make(chan interface{})
+
+		roles  = make(chan interface{})
+		roles2 = make(chan interface{})
+		roles3 = make(chan interface{})
+
+		servicePrincipals  = make(chan interface{})
+		servicePrincipals2 = make(chan interface{})
+		servicePrincipals3 = make(chan interface{})
+
+		appOwners               = make(chan interface{})
+		appOwners2              = make(chan interface{})
+		servicePrincipalOwners  = make(chan interface{})
+		servicePrincipalOwners2 = make(chan interface{})
+
+		tenants = make(chan interface{})
+	)
+
+	// Enumerate Apps, AppOwners and AppMembers
+	appChans := pipeline.TeeFixed(ctx.Done(), traceCollector(ctx, "apps", func(ctx context.Context) <-chan azureWrapper[models.App] {
+		return ListApps(ctx, client)
+	}), 2)
+	apps := pipeline.ToAny(ctx.Done(), appChans[0])
+	pipeline.Tee(ctx.Done(), pipeline.ToAny(ctx.Done(), traceCollector(ctx, "app-owners", func(ctx context.Context) <-chan azureWrapper[models.AppOwners] {
+		return ListAppOwners(ctx, client, appChans[1])
+	})), appOwners, appOwners2)
+
+	// Enumerate Devices, DeviceOwners and DeviceUsers
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "devices", func(ctx context.Context) <-chan interface{} {
+		return ListDevices(ctx, client)
+	}), devices, devices2, devices3)
+	deviceOwners := traceCollector(ctx, "device-owners", func(ctx context.Context) <-chan interface{} {
+		return ListDeviceOwners(ctx, client, devices2)
+	})
+	deviceUsers := traceCollector(ctx, "device-users", func(ctx context.Context) <-chan interface{} {
+		return ListDeviceUsers(ctx, client, devices3)
+	})
+
+	// Enumerate Groups, GroupOwners and GroupMembers
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "groups", func(ctx context.Context) <-chan interface{} {
+		return ListGroups(ctx, client)
+	}), groups, groups2, groups3, groups4)
+	groupOwners := traceCollector(ctx, "group-owners", func(ctx context.Context) <-chan interface{} {
+		return ListGroupOwners(ctx, client, withReplayed(ctx, enums.KindAZGroup, groups2))
+	})
+	groupMembers := traceCollector(ctx, "group-members", func(ctx context.Context) <-chan interface{} {
+		return ListGroupMembers(ctx, client, withReplayed(ctx, enums.KindAZGroup, groups3))
+	})
+
+	// Enumerate Groups Eligibility Schedule Instances
+	groupEligibilityScheduleInstances := traceCollector(ctx, "group-eligibility-schedule-instances", func(ctx context.Context) <-chan interface{} {
+		return ListGroupEligibilityScheduleInstances(ctx, client, withReplayed(ctx, enums.KindAZGroup, groups4))
+	})
+
+	// Enumerate ServicePrincipals and ServicePrincipalOwners
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "service-principals", func(ctx context.Context) <-chan interface{} {
+		return ListServicePrincipals(ctx, client)
+	}), servicePrincipals, servicePrincipals2, servicePrincipals3)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "service-principal-owners", func(ctx context.Context) <-chan interface{} {
+		return ListServicePrincipalOwners(ctx, client, withReplayed(ctx, enums.KindAZServicePrincipal, servicePrincipals2))
+	}), servicePrincipalOwners, servicePrincipalOwners2)
+
+	// Enumerate Owner Relationships of Apps and ServicePrincipals
+	ownerRelationships := ListOwnerRelationships(ctx, pipeline.Mux(ctx.Done(), appOwners2, servicePrincipalOwners2))
+
+	// Enumerate Tenants
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "tenants", func(ctx context.Context) <-chan interface{} {
+		return ListTenants(ctx, client)
+	}), tenants)
+
+	// Enumerate Tenant Settings
+	tenantSettings := traceCollector(ctx, "tenant-settings", func(ctx context.Context) <-chan interface{} {
+		return ListTenantSettings(ctx, client)
+	})
+
+	// Enumerate Users, and their Authentication Methods with Options.AuthMethods
+	users := traceCollector(ctx, "users", func(ctx context.Context) <-chan interface{} {
+		return ListUsers(ctx, client)
+	})
+	var userAuthenticationMethods <-chan interface{}
+	if optionsOf(ctx).AuthMethods {
+		var (
+			users1 = make(chan interface{})
+			users2 = make(chan interface{})
+		)
+		pipeline.Tee(ctx.Done(), users, users1, users2)
+		users = users1
+		userAuthenticationMethods = traceCollector(ctx, "user-auth-methods", func(ctx context.Context) <-chan interface{} {
+			return ListUserAuthenticationMethods(ctx, client, withReplayed(ctx, enums.KindAZUser, users2))
+		})
+	}
+
+	// Enumerate Roles and RoleAssignments
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "roles", func(ctx context.Context) <-chan interface{} {
+		return ListRoles(ctx, client)
+	}), roles, roles2, roles3)
+	roleAssignments := traceCollector(ctx, "role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListRoleAssignments(ctx, client, roles2)
+	})
+
+	// Enumerate Roles Eligibility Schedule Instances
+	roleEligibilityScheduleInstances := traceCollector(ctx, "role-eligibility-schedule-instances", func(ctx context.Context) <-chan interface{} {
+		return ListRoleEligibilityScheduleInstances(ctx, client, roles3)
+	})
+
+	// Enumerate AppRoleAssignments
+	appRoleAssignments := traceCollector(ctx, "app-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListAppRoleAssignments(ctx, client, withReplayed(ctx, enums.KindAZServicePrincipal, servicePrincipals3))
+	})
+
+	// Enumerate Cross-Tenant Access Policy
+	crossTenantAccessPolicy := traceCollector(ctx, "cross-tenant-access-policy", func(ctx context.Context) <-chan interface{} {
+		return ListCrossTenantAccessPolicy(ctx, client)
+	})
+
+	// Enumerate Delegated Admin Relationships
+	delegatedAdminRelationships := traceCollector(ctx, "delegated-admin-relationships", func(ctx context.Context) <-chan interface{} {
+		return ListDelegatedAdminRelationships(ctx, client)
+	})
+
+	// Enumerate Group Lifecycle Policies
+	groupLifecyclePolicies := traceCollector(ctx, "group-lifecycle-policies", func(ctx context.Context) <-chan interface{} {
+		return ListGroupLifecyclePolicies(ctx, client)
+	})
+
+	// Enumerate Named Locations
+	namedLocations := traceCollector(ctx, "named-locations", func(ctx context.Context) <-chan interface{} {
+		return ListNamedLocations(ctx, client)
+	})
+
+	// Enumerate OAuth2 Permission Grants
+	oauth2PermissionGrants := traceCollector(ctx, "oauth2-permission-grants", func(ctx context.Context) <-chan interface{} {
+		return ListOAuth2PermissionGrants(ctx, client)
+	})
+
+	streams := []<-chan interface{}{
+		appOwners,
+		appRoleAssignments,
+		apps,
+		crossTenantAccessPolicy,
+		delegatedAdminRelationships,
+		deviceOwners,
+		deviceUsers,
+		devices,
+		groupEligibilityScheduleInstances,
+		groupLifecyclePolicies,
+		groupMembers,
+		groupOwners,
+		groups,
+		namedLocations,
+		oauth2PermissionGrants,
+		ownerRelationships,
+		roleEligibilityScheduleInstances,
+		roleAssignments,
+		roles,
+		servicePrincipalOwners,
+		servicePrincipals,
+		tenantSettings,
+		tenants,
+		users,
+	}
+	if userAuthenticationMethods != nil {
+		streams = append(streams, userAuthenticationMethods)
+	}
+	return pipeline.Mux(ctx.Done(), streams...)
+}
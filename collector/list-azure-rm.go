@@ -0,0 +1,300 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func listAllRM(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	ctx = withCollectionStage(ctx, stageAzureRM)
+	var (
+		functionApps  = make(chan interface{})
+		functionApps2 = make(chan interface{})
+
+		webApps  = make(chan interface{})
+		webApps2 = make(chan interface{})
+
+		automationAccounts  = make(chan interface{})
+		automationAccounts2 = make(chan interface{})
+
+		containerRegistries  = make(chan interface{})
+		containerRegistries2 = make(chan interface{})
+
+		logicApps  = make(chan interface{})
+		logicApps2 = make(chan interface{})
+		logicApps3 = make(chan interface{})
+		logicApps4 = make(chan interface{})
+
+		managedClusters  = make(chan interface{})
+		managedClusters2 = make(chan interface{})
+
+		vmScaleSets  = make(chan interface{})
+		vmScaleSets2 = make(chan interface{})
+		vmScaleSets3 = make(chan interface{})
+
+		keyVaults                = make(chan interface{})
+		keyVaults2               = make(chan interface{})
+		keyVaults3               = make(chan interface{})
+		keyVaultRoleAssignments1 = make(chan azureWrapper[models.KeyVaultRoleAssignments])
+		keyVaultRoleAssignments2 = make(chan azureWrapper[models.KeyVaultRoleAssignments])
+		keyVaultRoleAssignments3 = make(chan azureWrapper[models.KeyVaultRoleAssignments])
+		keyVaultRoleAssignments4 = make(chan azureWrapper[models.KeyVaultRoleAssignments])
+
+		mgmtGroups                = make(chan interface{})
+		mgmtGroups2               = make(chan interface{})
+		mgmtGroups3               = make(chan interface{})
+		mgmtGroupRoleAssignments1 = make(chan azureWrapper[models.ManagementGroupRoleAssignments])
+		mgmtGroupRoleAssignments2 = make(chan azureWrapper[models.ManagementGroupRoleAssignments])
+
+		resourceGroups                = make(chan interface{})
+		resourceGroups2               = make(chan interface{})
+		resourceGroupRoleAssignments1 = make(chan azureWrapper[models.ResourceGroupRoleAssignments])
+		resourceGroupRoleAssignments2 = make(chan azureWrapper[models.ResourceGroupRoleAssignments])
+
+		subscriptions                = make(chan interface{})
+		subscriptions2               = make(chan interface{})
+		subscriptions3               = make(chan interface{})
+		subscriptions4               = make(chan interface{})
+		subscriptions5               = make(chan interface{})
+		subscriptions6               = make(chan interface{})
+		subscriptions7               = make(chan interface{})
+		subscriptions8               = make(chan interface{})
+		subscriptions9               = make(chan interface{})
+		subscriptions10              = make(chan interface{})
+		subscriptions11              = make(chan interface{})
+		subscriptions12              = make(chan interface{})
+		subscriptions13              = make(chan interface{})
+		subscriptionRoleAssignments1 = make(chan interface{})
+		subscriptionRoleAssignments2 = make(chan interface{})
+
+		virtualMachines                = make(chan interface{})
+		virtualMachines2               = make(chan interface{})
+		virtualMachines3               = make(chan interface{})
+		virtualMachineRoleAssignments1 = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
+		virtualMachineRoleAssignments2 = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
+		virtualMachineRoleAssignments3 = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
+		virtualMachineRoleAssignments4 = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
+		virtualMachineRoleAssignments5 = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
+	)
+
+	var changes *resourceChanges
+	if since := optionsOf(ctx).Since; !since.IsZero() {
+		changes = newResourceChanges(client, since)
+	}
+
+	// Enumerate entities
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "management-groups", func(ctx context.Context) <-chan interface{} {
+		return ListManagementGroups(ctx, client)
+	}), mgmtGroups, mgmtGroups2, mgmtGroups3)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "subscriptions", func(ctx context.Context) <-chan interface{} {
+		return ListSubscriptions(ctx, client)
+	}),
+		subscriptions,
+		subscriptions2,
+		subscriptions3,
+		subscriptions4,
+		subscriptions5,
+		subscriptions6,
+		subscriptions7,
+		subscriptions8,
+		subscriptions9,
+		subscriptions10,
+		subscriptions11,
+		subscriptions12,
+		subscriptions13,
+	)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "resource-groups", func(ctx context.Context) <-chan interface{} {
+		return ListResourceGroups(ctx, client, subscriptions2)
+	}), resourceGroups, resourceGroups2)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "key-vaults", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListKeyVaults(ctx, client, subscriptions3))
+	}), keyVaults, keyVaults2, keyVaults3)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "virtual-machines", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListVirtualMachines(ctx, client, subscriptions4))
+	}), virtualMachines, virtualMachines2, virtualMachines3)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "function-apps", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListFunctionApps(ctx, client, subscriptions6))
+	}), functionApps, functionApps2)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "web-apps", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListWebApps(ctx, client, subscriptions7))
+	}), webApps, webApps2)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "automation-accounts", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListAutomationAccounts(ctx, client, subscriptions8))
+	}), automationAccounts, automationAccounts2)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "container-registries", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListContainerRegistries(ctx, client, subscriptions9))
+	}), containerRegistries, containerRegistries2)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "logic-apps", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListLogicApps(ctx, client, subscriptions10))
+	}), logicApps, logicApps2, logicApps3, logicApps4)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "managed-clusters", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListManagedClusters(ctx, client, subscriptions11))
+	}), managedClusters, managedClusters2)
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "vm-scale-sets", func(ctx context.Context) <-chan interface{} {
+		return onlyChanged(ctx, changes, ListVMScaleSets(ctx, client, subscriptions12))
+	}), vmScaleSets, vmScaleSets2, vmScaleSets3)
+
+	// Enumerate Relationships
+	// ManagementGroups: Descendants, Owners and UserAccessAdmins
+	mgmtGroupDescendants := traceCollector(ctx, "management-group-descendants", func(ctx context.Context) <-chan interface{} {
+		return ListManagementGroupDescendants(ctx, client, mgmtGroups2)
+	})
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "management-group-role-assignments", func(ctx context.Context) <-chan azureWrapper[models.ManagementGroupRoleAssignments] {
+		return ListManagementGroupRoleAssignments(ctx, client, mgmtGroups3)
+	}), mgmtGroupRoleAssignments1, mgmtGroupRoleAssignments2)
+	mgmtGroupOwners := ListManagementGroupOwners(ctx, mgmtGroupRoleAssignments1)
+	mgmtGroupUserAccessAdmins := ListManagementGroupUserAccessAdmins(ctx, mgmtGroupRoleAssignments2)
+
+	// Subscriptions: Owners and UserAccessAdmins
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "subscription-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListSubscriptionRoleAssignments(ctx, client, subscriptions5)
+	}), subscriptionRoleAssignments1, subscriptionRoleAssignments2)
+	subscriptionOwners := traceCollector(ctx, "subscription-owners", func(ctx context.Context) <-chan interface{} {
+		return ListSubscriptionOwners(ctx, client, subscriptionRoleAssignments1)
+	})
+	subscriptionUserAccessAdmins := traceCollector(ctx, "subscription-user-access-admins", func(ctx context.Context) <-chan interface{} {
+		return ListSubscriptionUserAccessAdmins(ctx, client, subscriptionRoleAssignments2)
+	})
+
+	// ResourceGroups: Owners and UserAccessAdmins
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "resource-group-role-assignments", func(ctx context.Context) <-chan azureWrapper[models.ResourceGroupRoleAssignments] {
+		return ListResourceGroupRoleAssignments(ctx, client, resourceGroups2)
+	}), resourceGroupRoleAssignments1, resourceGroupRoleAssignments2)
+	resourceGroupOwners := ListResourceGroupOwners(ctx, resourceGroupRoleAssignments1)
+	resourceGroupUserAccessAdmins := ListResourceGroupUserAccessAdmins(ctx, resourceGroupRoleAssignments2)
+
+	// KeyVaults: AccessPolicies, Owners, UserAccessAdmins, Contributors and KVContributors
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "key-vault-role-assignments", func(ctx context.Context) <-chan azureWrapper[models.KeyVaultRoleAssignments] {
+		return ListKeyVaultRoleAssignments(ctx, client, keyVaults2)
+	}), keyVaultRoleAssignments1, keyVaultRoleAssignments2, keyVaultRoleAssignments3, keyVaultRoleAssignments4)
+	keyVaultAccessPolicies := traceCollector(ctx, "key-vault-access-policies", func(ctx context.Context) <-chan interface{} {
+		return ListKeyVaultAccessPolicies(ctx, client, keyVaults3, []enums.KeyVaultAccessType{enums.GetCerts, enums.GetKeys, enums.GetCerts})
+	})
+	keyVaultOwners := ListKeyVaultOwners(ctx, keyVaultRoleAssignments1)
+	keyVaultUserAccessAdmins := ListKeyVaultUserAccessAdmins(ctx, keyVaultRoleAssignments2)
+	keyVaultContributors := ListKeyVaultContributors(ctx, keyVaultRoleAssignments3)
+	keyVaultKVContributors := ListKeyVaultKVContributors(ctx, keyVaultRoleAssignments4)
+
+	// VirtualMachines: Owners, AvereContributors, Contributors, AdminLogins and UserAccessAdmins
+	pipeline.Tee(ctx.Done(), traceCollector(ctx, "virtual-machine-role-assignments", func(ctx context.Context) <-chan azureWrapper[models.VirtualMachineRoleAssignments] {
+		return ListVirtualMachineRoleAssignments(ctx, client, virtualMachines2)
+	}), virtualMachineRoleAssignments1, virtualMachineRoleAssignments2, virtualMachineRoleAssignments3, virtualMachineRoleAssignments4, virtualMachineRoleAssignments5)
+	virtualMachineOwners := ListVirtualMachineOwners(ctx, virtualMachineRoleAssignments1)
+	virtualMachineAvereContributors := ListVirtualMachineAvereContributors(ctx, virtualMachineRoleAssignments2)
+	virtualMachineContributors := ListVirtualMachineContributors(ctx, virtualMachineRoleAssignments3)
+	virtualMachineAdminLogins := ListVirtualMachineAdminLogins(ctx, virtualMachineRoleAssignments4)
+	virtualMachineUserAccessAdmins := ListVirtualMachineUserAccessAdmins(ctx, virtualMachineRoleAssignments5)
+
+	// Enumerate Function App Role Assignments
+	functionAppRoleAssignments := traceCollector(ctx, "function-app-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListFunctionAppRoleAssignments(ctx, client, functionApps2)
+	})
+
+	// Enumerate Web App Role Assignments
+	webAppRoleAssignments := traceCollector(ctx, "web-app-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListWebAppRoleAssignments(ctx, client, webApps2)
+	})
+
+	// Enumerate Automation Account Role Assignments
+	automationAccountRoleAssignments := traceCollector(ctx, "automation-account-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListAutomationAccountRoleAssignments(ctx, client, automationAccounts2)
+	})
+
+	// Enumerate Container Registry Role Assignments
+	containerRegistryRoleAssignments := traceCollector(ctx, "container-registry-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListContainerRegistryRoleAssignments(ctx, client, containerRegistries2)
+	})
+
+	// Enumerate Logic Apps Role Assignments
+	logicAppRoleAssignments := traceCollector(ctx, "logic-app-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListLogicAppRoleAssignments(ctx, client, logicApps2)
+	})
+
+	// Enumerate Managed Cluster Role Assignments
+	managedClusterRoleAssignments := traceCollector(ctx, "managed-cluster-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListManagedClusterRoleAssignments(ctx, client, managedClusters2)
+	})
+
+	// Enumerate VM Scale Set Role Assignments
+	vmScaleSetRoleAssignments := traceCollector(ctx, "vm-scale-set-role-assignments", func(ctx context.Context) <-chan interface{} {
+		return ListVMScaleSetRoleAssignments(ctx, client, vmScaleSets2)
+	})
+
+	// Enumerate VM, VM Scale Set and Logic App Managed Identities
+	virtualMachineManagedIdentities := ListVirtualMachineManagedIdentities(ctx, virtualMachines3)
+	vmScaleSetManagedIdentities := ListVMScaleSetManagedIdentities(ctx, vmScaleSets3)
+	logicAppManagedIdentities := ListLogicAppManagedIdentities(ctx, logicApps3)
+
+	// Enumerate Logic App API Connections
+	logicAppConnections := ListLogicAppConnections(ctx, logicApps4)
+
+	// Enumerate Lighthouse Delegations
+	lighthouseDelegations := traceCollector(ctx, "lighthouse-delegations", func(ctx context.Context) <-chan interface{} {
+		return ListLighthouseDelegations(ctx, client, subscriptions13)
+	})
+
+	return pipeline.Mux(ctx.Done(),
+		automationAccounts,
+		automationAccountRoleAssignments,
+		containerRegistries,
+		containerRegistryRoleAssignments,
+		functionApps,
+		functionAppRoleAssignments,
+		keyVaultAccessPolicies,
+		keyVaultContributors,
+		keyVaultKVContributors,
+		keyVaultOwners,
+		keyVaultUserAccessAdmins,
+		keyVaults,
+		lighthouseDelegations,
+		logicApps,
+		logicAppConnections,
+		logicAppManagedIdentities,
+		logicAppRoleAssignments,
+		managedClusters,
+		managedClusterRoleAssignments,
+		mgmtGroupDescendants,
+		mgmtGroupOwners,
+		mgmtGroupUserAccessAdmins,
+		mgmtGroups,
+		resourceGroupOwners,
+		resourceGroupUserAccessAdmins,
+		resourceGroups,
+		subscriptionOwners,
+		subscriptionUserAccessAdmins,
+		subscriptions,
+		virtualMachineAdminLogins,
+		virtualMachineAvereContributors,
+		virtualMachineContributors,
+		virtualMachineManagedIdentities,
+		virtualMachineOwners,
+		virtualMachineUserAccessAdmins,
+		virtualMachines,
+		vmScaleSets,
+		vmScaleSetManagedIdentities,
+		vmScaleSetRoleAssignments,
+		webApps,
+		webAppRoleAssignments,
+	)
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
+)
+
+func ListContainerRegistries(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
+	subscriptions = skipInput(ctx, enums.KindAZContainerRegistry, subscriptions)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
+			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
+				collectionError(ctx, enums.KindAZContainerRegistry, fmt.Errorf("failed type assertion"), "unable to continue enumerating container registries", "result", result)
+				return
+			} else {
+				ids <- subscription.SubscriptionId
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				subscriptionCtx, span := tracing.Start(ctx, "list container registries", "subscriptionId", id)
+				count := 0
+				for item := range client.ListAzureContainerRegistries(subscriptionCtx, id) {
+					if item.Error != nil {
+						span.SetError(item.Error)
+						collectionError(ctx, enums.KindAZContainerRegistry, item.Error, "unable to continue processing container registries for this subscription", "subscriptionId", id)
+					} else {
+						resourceGroupId := item.Ok.ResourceGroupId()
+						containerRegistry := models.ContainerRegistry{
+							ContainerRegistry: item.Ok,
+							SubscriptionId:    item.SubscriptionId,
+							ResourceGroupId:   resourceGroupId,
+							TenantId:          client.TenantInfo().TenantId,
+						}
+						logOf(ctx).V(2).Info("found container registry", "containerRegistry", containerRegistry)
+						count++
+						out <- AzureWrapper{
+							Kind: enums.KindAZContainerRegistry,
+							Data: containerRegistry,
+						}
+					}
+				}
+				logOf(ctx).V(1).Info("finished listing container registries", "subscriptionId", id, "count", count)
+				span.SetAttributes("count", count)
+				span.End()
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all container registries")
+	}()
+
+	return out
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListContainerRegistryRoleAssignments(ctx context.Context, client client.AzureClient, containerRegistries <-chan interface{}) <-chan interface{} {
+	containerRegistries = skipInput(ctx, enums.KindAZContainerRegistryRoleAssignment, containerRegistries)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), containerRegistries) {
+			if containerRegistry, ok := result.(AzureWrapper).Data.(models.ContainerRegistry); !ok {
+				collectionError(ctx, enums.KindAZContainerRegistryRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating container registry role assignments", "result", result)
+				return
+			} else {
+				ids <- containerRegistry.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				var (
+					containerRegistryRoleAssignments = models.AzureRoleAssignments{
+						ObjectId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZContainerRegistryRoleAssignment, item.Error, "unable to continue processing role assignments for this container registry", "containerRegistryId", id)
+					} else {
+						roleDefinitionId := path.Base(item.Ok.Properties.RoleDefinitionId)
+
+						containerRegistryRoleAssignment := models.AzureRoleAssignment{
+							Assignee:         item.Ok,
+							ObjectId:         item.ParentId,
+							RoleDefinitionId: roleDefinitionId,
+						}
+						logOf(ctx).V(2).Info("found container registry role assignment", "containerRegistryRoleAssignment", containerRegistryRoleAssignment)
+						count++
+						containerRegistryRoleAssignments.RoleAssignments = append(containerRegistryRoleAssignments.RoleAssignments, containerRegistryRoleAssignment)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZContainerRegistryRoleAssignment,
+					Data: containerRegistryRoleAssignments,
+				}
+				logOf(ctx).V(1).Info("finished listing container registry role assignments", "containerRegistryId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all container registry role assignments")
+	}()
+
+	return out
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// ListCrossTenantAccessPolicy emits a single object with the default and partner cross-tenant access settings of the
+// tenant. A tenant without partner settings gets just its defaults; a part that can't be read is left out rather than
+// failing the other.
+func ListCrossTenantAccessPolicy(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZCrossTenantAccessPolicy) {
+			return
+		}
+
+		var (
+			defaults *azure.CrossTenantAccessPolicyConfigurationDefault
+			// nil when the partner settings can't be read, as opposed to there being none
+			partners = []azure.CrossTenantAccessPolicyConfigurationPartner{}
+		)
+
+		if result, err := client.GetAzureADCrossTenantAccessPolicyDefault(ctx); err == nil {
+			defaults = result
+		} else if !skipForbidden(ctx, err, enums.KindAZCrossTenantAccessPolicy) {
+			collectionError(ctx, enums.KindAZCrossTenantAccessPolicy, err, "unable to collect default cross-tenant access settings")
+		}
+
+		for item := range client.ListAzureADCrossTenantAccessPolicyPartners(ctx) {
+			if skipForbidden(ctx, item.Error, enums.KindAZCrossTenantAccessPolicy) {
+				partners = nil
+				break
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZCrossTenantAccessPolicy, item.Error, "unable to continue processing partner cross-tenant access settings")
+				partners = nil
+				break
+			} else {
+				logOf(ctx).V(2).Info("found partner cross-tenant access settings", "partner", item)
+				partners = append(partners, item.Ok)
+			}
+		}
+
+		if rest.Aborted(ctx) != nil || (defaults == nil && partners == nil) {
+			return
+		}
+
+		out <- AzureWrapper{
+			Kind: enums.KindAZCrossTenantAccessPolicy,
+			Data: models.NewCrossTenantAccessPolicy(defaults, partners, client.TenantInfo().TenantId, client.TenantInfo().DisplayName),
+		}
+		logOf(ctx).Info("finished listing cross-tenant access policy", "partners", len(partners))
+	}()
+
+	return out
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+const testCrossTenantAccessPolicyDefault = `{
+	"isServiceDefault": false,
+	"inboundTrust": {
+		"isMfaAccepted": true,
+		"isCompliantDeviceAccepted": false,
+		"isHybridAzureADJoinedDeviceAccepted": false
+	},
+	"automaticUserConsentSettings": {"inboundAllowed": false, "outboundAllowed": false},
+	"b2bCollaborationInbound": {
+		"usersAndGroups": {"accessType": "allowed", "targets": [{"target": "AllUsers", "targetType": "user"}]},
+		"applications": {"accessType": "allowed", "targets": [{"target": "AllApplications", "targetType": "application"}]}
+	}
+}`
+
+func TestListCrossTenantAccessPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	var defaults azure.CrossTenantAccessPolicyConfigurationDefault
+	if err := json.Unmarshal([]byte(testCrossTenantAccessPolicyDefault), &defaults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.CrossTenantAccessPolicyConfigurationPartnerResult)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{TenantId: "tenant"}).AnyTimes()
+	mockClient.EXPECT().GetAzureADCrossTenantAccessPolicyDefault(gomock.Any()).Return(&defaults, nil)
+	mockClient.EXPECT().ListAzureADCrossTenantAccessPolicyPartners(gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		// the first partner inherits the default trust, the second has its own
+		mockChannel <- azure.CrossTenantAccessPolicyConfigurationPartnerResult{
+			Ok: azure.CrossTenantAccessPolicyConfigurationPartner{TenantId: "partner1"},
+		}
+		mockChannel <- azure.CrossTenantAccessPolicyConfigurationPartnerResult{
+			Ok: azure.CrossTenantAccessPolicyConfigurationPartner{
+				TenantId:                     "partner2",
+				InboundTrust:                 &azure.CrossTenantAccessPolicyInboundTrust{IsCompliantDeviceAccepted: true},
+				AutomaticUserConsentSettings: &azure.InboundOutboundPolicyConfiguration{InboundAllowed: true},
+			},
+		}
+	}()
+
+	var policies []models.CrossTenantAccessPolicy
+	for result := range ListCrossTenantAccessPolicy(ctx, mockClient) {
+		if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.CrossTenantAccessPolicy); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", wrapper.Data, models.CrossTenantAccessPolicy{})
+		} else {
+			policies = append(policies, data)
+		}
+	}
+
+	if len(policies) != 1 {
+		t.Fatalf("got %v cross-tenant access policies, want 1", len(policies))
+	}
+
+	result := policies[0]
+	if result.Default == nil || result.TenantId != "tenant" || len(result.Partners) != 2 {
+		t.Errorf("got %+v, want the default and partner settings of the tenant", result)
+	} else if len(result.PartnerTrust) != 2 {
+		t.Fatalf("got %+v, want the trust of both partners", result.PartnerTrust)
+	} else if trust := result.PartnerTrust[0]; !trust.MfaAccepted || trust.CompliantDeviceAccepted {
+		t.Errorf("got %+v, want the default trust to be inherited", trust)
+	} else if trust := result.PartnerTrust[1]; trust.MfaAccepted || !trust.CompliantDeviceAccepted || !trust.AutomaticRedemptionInbound {
+		t.Errorf("got %+v, want the partner's own trust", trust)
+	}
+}
+
+func TestListCrossTenantAccessPolicyNoPartners(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+	t.Cleanup(resetSkippedKinds)
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.CrossTenantAccessPolicyConfigurationPartnerResult)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	mockClient.EXPECT().GetAzureADCrossTenantAccessPolicyDefault(gomock.Any()).Return(&azure.CrossTenantAccessPolicyConfigurationDefault{IsServiceDefault: true}, nil).Times(2)
+	mockClient.EXPECT().ListAzureADCrossTenantAccessPolicyPartners(gomock.Any()).Return(mockChannel)
+	close(mockChannel)
+
+	for result := range ListCrossTenantAccessPolicy(ctx, mockClient) {
+		data := result.(AzureWrapper).Data.(models.CrossTenantAccessPolicy)
+		if data.Default == nil || data.Partners == nil || len(data.Partners) != 0 || data.PartnerTrust == nil {
+			t.Errorf("got %+v, want just the default policy", data)
+		} else if bytes, _ := json.Marshal(data); !strings.Contains(string(bytes), `"partners":[]`) {
+			t.Errorf("got %s, want an empty list of partners", bytes)
+		}
+	}
+
+	// partners that can't be read leave the defaults to be emitted on their own
+	forbidden := make(chan azure.CrossTenantAccessPolicyConfigurationPartnerResult, 1)
+	forbidden <- azure.CrossTenantAccessPolicyConfigurationPartnerResult{Error: fmt.Errorf("%w: Authorization_RequestDenied", rest.ErrForbidden)}
+	close(forbidden)
+	mockClient.EXPECT().ListAzureADCrossTenantAccessPolicyPartners(gomock.Any()).Return(forbidden)
+
+	count := 0
+	for result := range ListCrossTenantAccessPolicy(ctx, mockClient) {
+		count++
+		if data := result.(AzureWrapper).Data.(models.CrossTenantAccessPolicy); data.Partners != nil || data.PartnerTrust != nil {
+			t.Errorf("got %+v, want no partner settings", data)
+		}
+	}
+	if count != 1 {
+		t.Errorf("got %v cross-tenant access policies, want 1", count)
+	}
+}
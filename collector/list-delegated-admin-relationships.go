@@ -0,0 +1,79 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func ListDelegatedAdminRelationships(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZDelegatedAdminRelationship) {
+			return
+		}
+		count := 0
+		for item := range client.ListAzureADDelegatedAdminRelationships(ctx, "") {
+			if skipForbidden(ctx, item.Error, enums.KindAZDelegatedAdminRelationship) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZDelegatedAdminRelationship, item.Error, "unable to continue processing delegated admin relationships")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found delegated admin relationship", "relationship", item)
+				count++
+				out <- AzureWrapper{
+					Kind: enums.KindAZDelegatedAdminRelationship,
+					Data: models.DelegatedAdminRelationship{
+						DelegatedAdminRelationship: item.Ok,
+						AccessAssignments:          ListDelegatedAdminAccessAssignments(ctx, client, item.Ok.Id),
+						TenantId:                   client.TenantInfo().TenantId,
+						TenantName:                 client.TenantInfo().DisplayName,
+					},
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all delegated admin relationships", "count", count)
+	}()
+
+	return out
+}
+
+func ListDelegatedAdminAccessAssignments(ctx context.Context, client client.AzureClient, relationshipId string) []azure.DelegatedAdminAccessAssignment {
+	assignments := []azure.DelegatedAdminAccessAssignment{}
+	for item := range client.ListAzureADDelegatedAdminAccessAssignments(ctx, relationshipId) {
+		if errors.Is(item.Error, rest.ErrForbidden) {
+			logOf(ctx).Info("warning: unable to list access assignments for delegated admin relationship", "relationshipId", relationshipId, "error", item.Error.Error())
+		} else if item.Error != nil {
+			collectionError(ctx, enums.KindAZDelegatedAdminRelationship, item.Error, "unable to continue processing access assignments for this delegated admin relationship", "relationshipId", relationshipId)
+		} else {
+			logOf(ctx).V(2).Info("found delegated admin access assignment", "assignment", item)
+			assignments = append(assignments, item.Ok)
+		}
+	}
+	return assignments
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListDelegatedAdminRelationships(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.DelegatedAdminRelationshipResult)
+	mockAssignmentChannel := make(chan azure.DelegatedAdminAccessAssignmentResult)
+	mockTenant := azure.Tenant{}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADDelegatedAdminRelationships(gomock.Any(), gomock.Any()).Return(mockChannel)
+	mockClient.EXPECT().ListAzureADDelegatedAdminAccessAssignments(gomock.Any(), "relationship").Return(mockAssignmentChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.DelegatedAdminRelationshipResult{
+			Ok: azure.DelegatedAdminRelationship{Entity: azure.Entity{Id: "relationship"}},
+		}
+		mockChannel <- azure.DelegatedAdminRelationshipResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.DelegatedAdminRelationshipResult{
+			Ok: azure.DelegatedAdminRelationship{},
+		}
+	}()
+
+	go func() {
+		defer close(mockAssignmentChannel)
+		mockAssignmentChannel <- azure.DelegatedAdminAccessAssignmentResult{
+			Ok: azure.DelegatedAdminAccessAssignment{Entity: azure.Entity{Id: "assignment"}},
+		}
+		mockAssignmentChannel <- azure.DelegatedAdminAccessAssignmentResult{
+			Error: mockError,
+		}
+	}()
+
+	channel := ListDelegatedAdminRelationships(ctx, mockClient)
+	result := <-channel
+	if wrapper, ok := result.(AzureWrapper); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	} else if data, ok := wrapper.Data.(models.DelegatedAdminRelationship); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", wrapper.Data, models.DelegatedAdminRelationship{})
+	} else if len(data.AccessAssignments) != 1 {
+		t.Errorf("got %v access assignments, want 1", len(data.AccessAssignments))
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("expected channel to close from an error result but it did not")
+	}
+}
+
+func TestListDelegatedAdminRelationshipsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.DelegatedAdminRelationshipResult)
+	mockClient.EXPECT().ListAzureADDelegatedAdminRelationships(gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.DelegatedAdminRelationshipResult{
+			Error: fmt.Errorf("%w: map[error:map[code:Authorization_RequestDenied]]", rest.ErrForbidden),
+		}
+	}()
+
+	channel := ListDelegatedAdminRelationships(ctx, mockClient)
+	if result, ok := <-channel; ok {
+		t.Errorf("expected channel to close without results but got %v", result)
+	}
+}
@@ -15,10 +15,9 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package cmd
+package collector
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -29,14 +28,10 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
-func init() {
-	setupLogger()
-}
-
 func TestListDeviceOwners(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	ctx := context.Background()
+	ctx := testContext()
 
 	mockClient := mocks.NewMockAzureClient(ctrl)
 
@@ -49,7 +44,7 @@ func TestListDeviceOwners(t *testing.T) {
 	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
 	mockClient.EXPECT().ListAzureDeviceRegisteredOwners(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockDeviceOwnerChannel).Times(1)
 	mockClient.EXPECT().ListAzureDeviceRegisteredOwners(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockDeviceOwnerChannel2).Times(1)
-	channel := listDeviceOwners(ctx, mockClient, mockDevicesChannel)
+	channel := ListDeviceOwners(ctx, mockClient, mockDevicesChannel)
 
 	go func() {
 		defer close(mockDevicesChannel)
@@ -0,0 +1,97 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListDeviceUsers(ctx context.Context, client client.AzureClient, devices <-chan interface{}) <-chan interface{} {
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), devices) {
+			if device, ok := result.(AzureWrapper).Data.(models.Device); !ok {
+				collectionError(ctx, enums.KindAZDeviceUser, fmt.Errorf("failed type assertion"), "unable to continue enumerating device registered users", "result", result)
+			} else {
+				ids <- device.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				if skipCollector(ctx, enums.KindAZDeviceUser) {
+					continue
+				}
+				var (
+					data = models.DeviceUsers{
+						DeviceId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListAzureDeviceRegisteredUsers(ctx, id) {
+					if skipForbidden(ctx, item.Error, enums.KindAZDeviceUser) {
+						logOf(ctx).V(1).Info("skipping registered users for this device due to insufficient permissions", "deviceId", id)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZDeviceUser, item.Error, "unable to continue processing registered users for this device", "deviceId", id)
+					} else {
+						deviceUser := models.DeviceUser{
+							User:     item.Ok,
+							DeviceId: item.DeviceId,
+						}
+						logOf(ctx).V(2).Info("found device registered user", "deviceUser", deviceUser)
+						count++
+						data.Users = append(data.Users, deviceUser)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZDeviceUser,
+					Data: data,
+				}
+				logOf(ctx).V(1).Info("finished listing device registered users", "deviceId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all device registered users")
+	}()
+
+	return out
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListDeviceUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+
+	mockDevicesChannel := make(chan interface{})
+	mockDeviceUserChannel := make(chan azure.DeviceRegisteredUserResult)
+	mockDeviceUserChannel2 := make(chan azure.DeviceRegisteredUserResult)
+
+	mockTenant := azure.Tenant{}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureDeviceRegisteredUsers(gomock.Any(), gomock.Any()).Return(mockDeviceUserChannel).Times(1)
+	mockClient.EXPECT().ListAzureDeviceRegisteredUsers(gomock.Any(), gomock.Any()).Return(mockDeviceUserChannel2).Times(1)
+	channel := ListDeviceUsers(ctx, mockClient, mockDevicesChannel)
+
+	go func() {
+		defer close(mockDevicesChannel)
+		mockDevicesChannel <- AzureWrapper{
+			Data: models.Device{},
+		}
+		mockDevicesChannel <- AzureWrapper{
+			Data: models.Device{},
+		}
+	}()
+	go func() {
+		defer close(mockDeviceUserChannel)
+		mockDeviceUserChannel <- azure.DeviceRegisteredUserResult{
+			Ok: json.RawMessage{},
+		}
+		mockDeviceUserChannel <- azure.DeviceRegisteredUserResult{
+			Ok: json.RawMessage{},
+		}
+	}()
+	go func() {
+		defer close(mockDeviceUserChannel2)
+		mockDeviceUserChannel2 <- azure.DeviceRegisteredUserResult{
+			Ok: json.RawMessage{},
+		}
+		mockDeviceUserChannel2 <- azure.DeviceRegisteredUserResult{
+			Error: mockError,
+		}
+	}()
+
+	if result, ok := <-channel; !ok {
+		t.Fatalf("failed to receive from channel")
+	} else if wrapper, ok := result.(AzureWrapper); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	} else if data, ok := wrapper.Data.(models.DeviceUsers); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", wrapper.Data, models.DeviceUsers{})
+	} else if len(data.Users) != 2 {
+		t.Errorf("got %v, want %v", len(data.Users), 2)
+	}
+
+	if result, ok := <-channel; !ok {
+		t.Fatalf("failed to receive from channel")
+	} else if wrapper, ok := result.(AzureWrapper); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	} else if data, ok := wrapper.Data.(models.DeviceUsers); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", wrapper.Data, models.DeviceUsers{})
+	} else if len(data.Users) != 1 {
+		t.Errorf("got %v, want %v", len(data.Users), 2)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+// deviceSelect includes the hybrid join properties needed to correlate devices with on-premises computer objects, some
+// of which graph only returns when explicitly selected.
+var deviceSelect = []string{
+	"id",
+	"accountEnabled",
+	"alternativeSecurityIds",
+	"approximateLastSignInDateTime",
+	"complianceExpirationDateTime",
+	"deviceId",
+	"deviceMetadata",
+	"deviceVersion",
+	"displayName",
+	"isCompliant",
+	"isManaged",
+	"manufacturer",
+	"mdmAppId",
+	"model",
+	"onPremisesLastSyncDateTime",
+	"onPremisesSecurityIdentifier",
+	"onPremisesSyncEnabled",
+	"operatingSystem",
+	"operatingSystemVersion",
+	"physicalIds",
+	"profileType",
+	"systemLabels",
+	"trustType",
+}
+
+func ListDevices(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZDevice) {
+			return
+		}
+		count := 0
+		for item := range client.ListAzureDevices(ctx, "", "", "", "", collectorSelect(ctx, enums.KindAZDevice, deviceSelect)) {
+			if skipForbidden(ctx, item.Error, enums.KindAZDevice) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZDevice, item.Error, "unable to continue processing devices")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found device", "device", item)
+				count++
+				out <- AzureWrapper{
+					Kind: enums.KindAZDevice,
+					Data: models.Device{
+						Device:     item.Ok,
+						TenantId:   client.TenantInfo().TenantId,
+						TenantName: client.TenantInfo().DisplayName,
+					},
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all devices", "count", count)
+	}()
+
+	return out
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestListDevices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.DeviceResult)
+	mockTenant := azure.Tenant{}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureDevices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.DeviceResult{
+			Ok: azure.Device{},
+		}
+		mockChannel <- azure.DeviceResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.DeviceResult{
+			Ok: azure.Device{},
+		}
+	}()
+
+	channel := ListDevices(ctx, mockClient)
+	result := <-channel
+	if _, ok := result.(AzureWrapper); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("expected channel to close from an error result but it did not")
+	}
+}
+
+func TestListDevicesHybrid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	var (
+		fixturePath = filepath.Join("testdata", "devices.hybrid.json")
+		goldenPath  = filepath.Join("testdata", "devices.golden.json")
+		devices     azure.DeviceList
+	)
+	if fixture, err := os.ReadFile(fixturePath); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(fixture, &devices); err != nil {
+		t.Fatal(err)
+	}
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.DeviceResult)
+	mockTenant := azure.Tenant{TenantId: "tenant-id", DisplayName: "tenant"}
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureDevices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), deviceSelect).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		for _, device := range devices.Value {
+			mockChannel <- azure.DeviceResult{Ok: device}
+		}
+	}()
+
+	var results []interface{}
+	for result := range ListDevices(ctx, mockClient) {
+		results = append(results, result)
+	}
+
+	if data, err := json.MarshalIndent(results, "", "  "); err != nil {
+		t.Fatal(err)
+	} else if *update {
+		if err := os.WriteFile(goldenPath, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	} else if golden, err := os.ReadFile(goldenPath); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(append(data, '\n'), golden) {
+		t.Errorf("devices do not match %s\ngot:\n%s\nwant:\n%s", goldenPath, data, golden)
+	}
+}
+
+func TestListDevicesForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+	resetSkippedKinds()
+	defer resetSkippedKinds()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.DeviceResult)
+	mockClient.EXPECT().ListAzureDevices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.DeviceResult{
+			Error: fmt.Errorf("%w: map[error:map[code:Authorization_RequestDenied]]", rest.ErrForbidden),
+		}
+	}()
+
+	channel := ListDevices(ctx, mockClient)
+	if result, ok := <-channel; ok {
+		t.Errorf("expected channel to close without results but got %v", result)
+	} else if skipped := SkippedKinds(); len(skipped) != 1 || skipped[0] != string(enums.KindAZDevice) {
+		t.Errorf("got skipped kinds %v, want %v", skipped, []string{string(enums.KindAZDevice)})
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListFunctionAppRoleAssignments(ctx context.Context, client client.AzureClient, functionApps <-chan interface{}) <-chan interface{} {
+	functionApps = skipInput(ctx, enums.KindAZFunctionAppRoleAssignment, functionApps)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), functionApps) {
+			if functionApp, ok := result.(AzureWrapper).Data.(models.FunctionApp); !ok {
+				collectionError(ctx, enums.KindAZFunctionAppRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating function app role assignments", "result", result)
+				return
+			} else {
+				ids <- functionApp.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				var (
+					functionAppRoleAssignments = models.AzureRoleAssignments{
+						ObjectId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZFunctionAppRoleAssignment, item.Error, "unable to continue processing role assignments for this function app", "functionAppId", id)
+					} else {
+						roleDefinitionId := path.Base(item.Ok.Properties.RoleDefinitionId)
+
+						functionAppRoleAssignment := models.AzureRoleAssignment{
+							Assignee:         item.Ok,
+							ObjectId:         item.ParentId,
+							RoleDefinitionId: roleDefinitionId,
+						}
+						logOf(ctx).V(2).Info("Found function app role asignment", "functionAppRoleAssignment", functionAppRoleAssignment)
+						count++
+						functionAppRoleAssignments.RoleAssignments = append(functionAppRoleAssignments.RoleAssignments, functionAppRoleAssignment)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZFunctionAppRoleAssignment,
+					Data: functionAppRoleAssignments,
+				}
+				logOf(ctx).V(1).Info("finished listing function app role assignments", "functionAppId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all function app role assignments")
+	}()
+
+	return out
+}
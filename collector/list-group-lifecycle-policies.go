@@ -0,0 +1,62 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+// ListGroupLifecyclePolicies lists the group expiration policy of the tenant; tenants that haven't configured one
+// emit nothing
+func ListGroupLifecyclePolicies(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZGroupLifecyclePolicy) {
+			return
+		}
+		count := 0
+		for item := range client.ListAzureADGroupLifecyclePolicies(ctx) {
+			if skipForbidden(ctx, item.Error, enums.KindAZGroupLifecyclePolicy) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZGroupLifecyclePolicy, item.Error, "unable to continue processing group lifecycle policies")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found group lifecycle policy", "groupLifecyclePolicy", item)
+				count++
+				out <- AzureWrapper{
+					Kind: enums.KindAZGroupLifecyclePolicy,
+					Data: models.GroupLifecyclePolicy{
+						GroupLifecyclePolicy: item.Ok,
+						TenantId:             client.TenantInfo().TenantId,
+						TenantName:           client.TenantInfo().DisplayName,
+					},
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all group lifecycle policies", "count", count)
+	}()
+
+	return out
+}
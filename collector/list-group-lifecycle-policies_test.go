@@ -0,0 +1,83 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+const testGroupLifecyclePolicies = `{"value": [
+	{"id": "policy", "groupLifetimeInDays": 180, "managedGroupTypes": "Selected", "alternateNotificationEmails": "admin@contoso.com;security@contoso.com"}
+]}`
+
+func TestListGroupLifecyclePolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	var list azure.GroupLifecyclePolicyList
+	if err := json.Unmarshal([]byte(testGroupLifecyclePolicies), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.GroupLifecyclePolicyResult)
+	mockTenant := azure.Tenant{TenantId: "tenant"}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADGroupLifecyclePolicies(gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.GroupLifecyclePolicyResult{
+			Ok: list.Value[0],
+		}
+		mockChannel <- azure.GroupLifecyclePolicyResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.GroupLifecyclePolicyResult{
+			Ok: list.Value[0],
+		}
+	}()
+
+	channel := ListGroupLifecyclePolicies(ctx, mockClient)
+
+	var policies []models.GroupLifecyclePolicy
+	for result := range channel {
+		if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.GroupLifecyclePolicy); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", wrapper.Data, models.GroupLifecyclePolicy{})
+		} else {
+			policies = append(policies, data)
+		}
+	}
+
+	if len(policies) != 1 {
+		t.Fatalf("got %v policies, want 1 before the error", len(policies))
+	} else if policy := policies[0]; policy.GroupLifetimeInDays != 180 || policy.ManagedGroupTypes != "Selected" || policy.AlternateNotificationEmails != "admin@contoso.com;security@contoso.com" || policy.TenantId != "tenant" {
+		t.Errorf("got %+v, want the policy of the tenant", policy)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListGroupMembers(ctx context.Context, client client.AzureClient, groups <-chan interface{}) <-chan interface{} {
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), groups) {
+			if group, ok := result.(AzureWrapper).Data.(models.Group); !ok {
+				collectionError(ctx, enums.KindAZGroupMember, fmt.Errorf("failed group type assertion"), "unable to continue enumerating group members", "result", result)
+				return
+			} else {
+				ids <- group.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				if skipCollector(ctx, enums.KindAZGroupMember) {
+					continue
+				}
+				var (
+					data = models.GroupMembers{
+						GroupId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListAzureADGroupMembers(ctx, id, "", "", "", nil) {
+					if skipForbidden(ctx, item.Error, enums.KindAZGroupMember) {
+						logOf(ctx).V(1).Info("skipping members for this group due to insufficient permissions", "groupId", id)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZGroupMember, item.Error, "unable to continue processing members for this group", "groupId", id)
+					} else {
+						groupMember := models.GroupMember{
+							Member:  item.Ok,
+							GroupId: item.ParentId,
+						}
+						logOf(ctx).V(2).Info("found group member", "groupMember", groupMember)
+						count++
+						data.Members = append(data.Members, groupMember)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZGroupMember,
+					Data: data,
+				}
+				logOf(ctx).V(1).Info("finished listing group memberships", "groupId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing members for all groups")
+	}()
+
+	return out
+}
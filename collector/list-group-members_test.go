@@ -15,10 +15,9 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package cmd
+package collector
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -29,14 +28,10 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
-func init() {
-	setupLogger()
-}
-
 func TestListGroupMembers(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	ctx := context.Background()
+	ctx := testContext()
 
 	mockClient := mocks.NewMockAzureClient(ctrl)
 
@@ -49,7 +44,7 @@ func TestListGroupMembers(t *testing.T) {
 	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
 	mockClient.EXPECT().ListAzureADGroupMembers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockGroupMemberChannel).Times(1)
 	mockClient.EXPECT().ListAzureADGroupMembers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockGroupMemberChannel2).Times(1)
-	channel := listGroupMembers(ctx, mockClient, mockGroupsChannel)
+	channel := ListGroupMembers(ctx, mockClient, mockGroupsChannel)
 
 	go func() {
 		defer close(mockGroupsChannel)
@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListGroupOwners(ctx context.Context, client client.AzureClient, groups <-chan interface{}) <-chan interface{} {
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), groups) {
+			if group, ok := result.(AzureWrapper).Data.(models.Group); !ok {
+				collectionError(ctx, enums.KindAZGroupOwner, fmt.Errorf("failed type assertion"), "unable to continue enumerating group owners", "result", result)
+				return
+			} else {
+				ids <- group.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				if skipCollector(ctx, enums.KindAZGroupOwner) {
+					continue
+				}
+				var (
+					groupOwners = models.GroupOwners{
+						GroupId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListAzureADGroupOwners(ctx, id, "", "", "", nil) {
+					if skipForbidden(ctx, item.Error, enums.KindAZGroupOwner) {
+						logOf(ctx).V(1).Info("skipping owners for this group due to insufficient permissions", "groupId", id)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZGroupOwner, item.Error, "unable to continue processing owners for this group", "groupId", id)
+					} else {
+						groupOwner := models.GroupOwner{
+							Owner:   item.Ok,
+							GroupId: item.GroupId,
+						}
+						logOf(ctx).V(2).Info("found group owner", "groupOwner", groupOwner)
+						count++
+						groupOwners.Owners = append(groupOwners.Owners, groupOwner)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZGroupOwner,
+					Data: groupOwners,
+				}
+				logOf(ctx).V(1).Info("finished listing group owners", "groupId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all group owners")
+	}()
+
+	return out
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func ListGroups(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZGroup) {
+			return
+		}
+		listCtx, ok := resumeContext(ctx, enums.KindAZGroup, client.TenantInfo().TenantId)
+		if !ok {
+			return
+		}
+
+		count := 0
+		for item := range client.ListAzureADGroups(listCtx, "securityEnabled eq true", "", "", "", collectorSelect(ctx, enums.KindAZGroup, nil)) {
+			if skipForbidden(ctx, item.Error, enums.KindAZGroup) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZGroup, item.Error, "unable to continue processing groups")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found group", "group", item)
+				count++
+				group := models.Group{
+					Group:      item.Ok,
+					TenantId:   client.TenantInfo().TenantId,
+					TenantName: client.TenantInfo().DisplayName,
+				}
+				optionsOf(ctx).Checkpoint.collected(enums.KindAZGroup, group)
+				out <- AzureWrapper{
+					Kind: enums.KindAZGroup,
+					Data: group,
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all groups", "count", count)
+	}()
+
+	return out
+}
@@ -15,10 +15,9 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package cmd
+package collector
 
 import (
-	"context"
 	"fmt"
 	"testing"
 
@@ -27,15 +26,11 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
-func init() {
-	setupLogger()
-}
-
 func TestListGroups(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ctx := context.Background()
+	ctx := testContext()
 
 	mockClient := mocks.NewMockAzureClient(ctrl)
 	mockChannel := make(chan azure.GroupResult)
@@ -57,7 +52,7 @@ func TestListGroups(t *testing.T) {
 		}
 	}()
 
-	channel := listGroups(ctx, mockClient)
+	channel := ListGroups(ctx, mockClient)
 	result := <-channel
 	if _, ok := result.(AzureWrapper); !ok {
 		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
@@ -0,0 +1,86 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	kinds "github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListKeyVaultAccessPolicies(ctx context.Context, client client.AzureClient, keyVaults <-chan interface{}, filters []enums.KeyVaultAccessType) <-chan interface{} {
+	keyVaults = skipInput(ctx, enums.KindAZKeyVaultAccessPolicy, keyVaults)
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		for result := range pipeline.OrDone(ctx.Done(), keyVaults) {
+			if keyVault, ok := result.(AzureWrapper).Data.(models.KeyVault); !ok {
+				collectionError(ctx, kinds.KindAZKeyVaultAccessPolicy, fmt.Errorf("failed type assertion"), "unable to continue enumerating key vault access policies", "result", result)
+				return
+			} else {
+				for _, policy := range keyVault.Properties.AccessPolicies {
+					if len(filters) == 0 {
+						out <- AzureWrapper{
+							Kind: kinds.KindAZKeyVaultAccessPolicy,
+							Data: models.KeyVaultAccessPolicy{
+								KeyVaultId:        keyVault.Id,
+								AccessPolicyEntry: policy,
+							},
+						}
+					} else {
+						for _, filter := range filters {
+							permissions := func() []string {
+								switch filter {
+								case enums.GetCerts:
+									return policy.Permissions.Certificates
+								case enums.GetKeys:
+									return policy.Permissions.Keys
+								case enums.GetSecrets:
+									return policy.Permissions.Secrets
+								default:
+									collectionError(ctx, kinds.KindAZKeyVaultAccessPolicy, fmt.Errorf("unsupported key vault access type: %s", filter), "unable to apply key vault access policy filter")
+									return []string{}
+								}
+							}()
+							if internal.Contains(permissions, "Get") {
+								out <- AzureWrapper{
+									Kind: kinds.KindAZKeyVaultAccessPolicy,
+									Data: models.KeyVaultAccessPolicy{
+										KeyVaultId:        keyVault.Id,
+										AccessPolicyEntry: policy,
+									},
+								}
+								break
+							}
+						}
+					}
+				}
+			}
+		}
+	}()
+
+	return out
+}
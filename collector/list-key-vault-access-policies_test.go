@@ -15,10 +15,9 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package cmd
+package collector
 
 import (
-	"context"
 	"testing"
 
 	"github.com/bloodhoundad/azurehound/v2/client/mocks"
@@ -27,21 +26,17 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
-func init() {
-	setupLogger()
-}
-
 func TestListKeyVaultAccessPolicies(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	ctx := context.Background()
+	ctx := testContext()
 
 	mockClient := mocks.NewMockAzureClient(ctrl)
 
 	mockKeyVaultsChannel := make(chan interface{})
 	mockTenant := azure.Tenant{}
 	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
-	channel := listKeyVaultAccessPolicies(ctx, mockClient, mockKeyVaultsChannel, nil)
+	channel := ListKeyVaultAccessPolicies(ctx, mockClient, mockKeyVaultsChannel, nil)
 
 	go func() {
 		defer close(mockKeyVaultsChannel)
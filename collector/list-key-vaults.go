@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
+)
+
+func ListKeyVaults(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
+	subscriptions = skipInput(ctx, enums.KindAZKeyVault, subscriptions)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
+			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
+				collectionError(ctx, enums.KindAZKeyVault, fmt.Errorf("failed type assertion"), "unable to continue enumerating key vaults", "result", result)
+				return
+			} else {
+				ids <- subscription.SubscriptionId
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				subscriptionCtx, span := tracing.Start(ctx, "list key vaults", "subscriptionId", id)
+				count := 0
+				for item := range client.ListAzureKeyVaults(subscriptionCtx, id, 999) {
+					if item.Error != nil {
+						span.SetError(item.Error)
+						collectionError(ctx, enums.KindAZKeyVault, item.Error, "unable to continue processing key vaults for this subscription", "subscriptionId", id)
+					} else {
+						resourceGroup := item.Ok.ResourceGroupId()
+						// the embedded struct's values override top-level properties so TenantId
+						// needs to be explicitly set.
+						keyVault := models.KeyVault{
+							KeyVault:       item.Ok,
+							SubscriptionId: item.SubscriptionId,
+							ResourceGroup:  resourceGroup,
+							TenantId:       item.Ok.Properties.TenantId,
+						}
+						logOf(ctx).V(2).Info("found key vault", "keyVault", keyVault)
+						count++
+						out <- AzureWrapper{
+							Kind: enums.KindAZKeyVault,
+							Data: keyVault,
+						}
+					}
+				}
+				logOf(ctx).V(1).Info("finished listing key vaults", "subscriptionId", id, "count", count)
+				span.SetAttributes("count", count)
+				span.End()
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all key vaults")
+	}()
+
+	return out
+}
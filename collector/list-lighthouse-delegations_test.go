@@ -0,0 +1,95 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListLighthouseDelegations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+
+	mockSubscriptionsChannel := make(chan interface{})
+	mockRegistrationAssignmentChannel := make(chan azure.RegistrationAssignmentResult)
+	mockRegistrationAssignmentChannel2 := make(chan azure.RegistrationAssignmentResult)
+
+	mockTenant := azure.Tenant{TenantId: "managee"}
+	mockError := fmt.Errorf("I'm an error")
+	mockAssignment := azure.RegistrationAssignment{}
+	mockAssignment.Properties.RegistrationDefinition.Properties.ManagedByTenantId = "managedBy"
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureRegistrationAssignments(gomock.Any(), gomock.Any()).Return(mockRegistrationAssignmentChannel).Times(1)
+	mockClient.EXPECT().ListAzureRegistrationAssignments(gomock.Any(), gomock.Any()).Return(mockRegistrationAssignmentChannel2).Times(1)
+	channel := ListLighthouseDelegations(ctx, mockClient, mockSubscriptionsChannel)
+
+	go func() {
+		defer close(mockSubscriptionsChannel)
+		mockSubscriptionsChannel <- AzureWrapper{
+			Data: models.Subscription{},
+		}
+		mockSubscriptionsChannel <- AzureWrapper{
+			Data: models.Subscription{},
+		}
+	}()
+	go func() {
+		defer close(mockRegistrationAssignmentChannel)
+		mockRegistrationAssignmentChannel <- azure.RegistrationAssignmentResult{
+			Ok: mockAssignment,
+		}
+		mockRegistrationAssignmentChannel <- azure.RegistrationAssignmentResult{
+			Ok: mockAssignment,
+		}
+	}()
+	go func() {
+		defer close(mockRegistrationAssignmentChannel2)
+		mockRegistrationAssignmentChannel2 <- azure.RegistrationAssignmentResult{
+			Ok: mockAssignment,
+		}
+		mockRegistrationAssignmentChannel2 <- azure.RegistrationAssignmentResult{
+			Error: mockError,
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		if result, ok := <-channel; !ok {
+			t.Fatalf("failed to receive from channel")
+		} else if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.LighthouseDelegation); !ok {
+			t.Errorf("failed type assertion: got %T, want %T", wrapper.Data, models.LighthouseDelegation{})
+		} else if data.ManagedByTenantId != "managedBy" {
+			t.Errorf("got %v, want %v", data.ManagedByTenantId, "managedBy")
+		} else if data.TenantId != "managee" {
+			t.Errorf("got %v, want %v", data.TenantId, "managee")
+		}
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("should not have recieved from channel")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// ListLogicAppConnections links each logic app to the API connections its workflow uses, by resource id. The
+// connections are read from the logic app itself, so no further requests are made. Logic apps without connections
+// are skipped.
+func ListLogicAppConnections(ctx context.Context, logicApps <-chan interface{}) <-chan interface{} {
+	logicApps = skipInput(ctx, enums.KindAZLogicAppConnection, logicApps)
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		for result := range pipeline.OrDone(ctx.Done(), logicApps) {
+			if logicApp, ok := result.(AzureWrapper).Data.(models.LogicApp); !ok {
+				collectionError(ctx, enums.KindAZLogicAppConnection, fmt.Errorf("failed type assertion"), "unable to continue enumerating logic app connections", "result", result)
+				return
+			} else if connections := models.NewLogicAppConnections(logicApp.LogicApp); len(connections) > 0 {
+				logOf(ctx).V(2).Info("found logic app connections", "logicAppId", logicApp.Id, "count", len(connections))
+				out <- NewAzureWrapper(enums.KindAZLogicAppConnection, models.LogicAppConnections{
+					Connections: connections,
+					LogicAppId:  logicApp.Id,
+				})
+			}
+		}
+		logOf(ctx).Info("finished listing all logic app connections")
+	}()
+
+	return out
+}
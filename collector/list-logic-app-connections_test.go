@@ -0,0 +1,68 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func TestListLogicAppConnections(t *testing.T) {
+	ctx := testContext()
+
+	mockLogicAppsChannel := make(chan interface{})
+	channel := ListLogicAppConnections(ctx, mockLogicAppsChannel)
+
+	logicApp := azure.LogicApp{Entity: azure.Entity{Id: "app"}}
+	logicApp.Properties.Parameters = map[string]azure.LogicAppParameter{
+		"$connections": {Value: map[string]interface{}{
+			"office365": map[string]interface{}{"connectionId": "/connections/office365", "id": "/managedApis/office365"},
+		}},
+	}
+
+	go func() {
+		defer close(mockLogicAppsChannel)
+		mockLogicAppsChannel <- AzureWrapper{
+			Kind: enums.KindAZLogicApp,
+			Data: models.LogicApp{LogicApp: logicApp},
+		}
+		mockLogicAppsChannel <- AzureWrapper{
+			Kind: enums.KindAZLogicApp,
+			Data: models.LogicApp{},
+		}
+	}()
+
+	if result, ok := <-channel; !ok {
+		t.Fatalf("failed to receive from channel")
+	} else if wrapper, ok := result.(azureWrapper[models.LogicAppConnections]); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, azureWrapper[models.LogicAppConnections]{})
+	} else if wrapper.Kind != enums.KindAZLogicAppConnection {
+		t.Errorf("got kind %v, want %v", wrapper.Kind, enums.KindAZLogicAppConnection)
+	} else if data := wrapper.Data; data.LogicAppId != "app" || len(data.Connections) != 1 {
+		t.Errorf("unexpected logic app connections: %+v", data)
+	} else if connection := data.Connections[0]; connection.Name != "office365" || connection.ConnectionId != "/connections/office365" || connection.ApiId != "/managedApis/office365" {
+		t.Errorf("unexpected connection: %+v", connection)
+	}
+
+	if result, ok := <-channel; ok {
+		t.Errorf("expected channel to close but got %v", result)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// ListLogicAppManagedIdentities links each logic app to the managed identities its workflow runs as. Logic apps
+// without a managed identity are skipped.
+func ListLogicAppManagedIdentities(ctx context.Context, logicApps <-chan interface{}) <-chan interface{} {
+	logicApps = skipInput(ctx, enums.KindAZLogicAppManagedIdentity, logicApps)
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		for result := range pipeline.OrDone(ctx.Done(), logicApps) {
+			if logicApp, ok := result.(AzureWrapper).Data.(models.LogicApp); !ok {
+				collectionError(ctx, enums.KindAZLogicAppManagedIdentity, fmt.Errorf("failed type assertion"), "unable to continue enumerating logic app managed identities", "result", result)
+				return
+			} else if identities := models.NewManagedIdentities(logicApp.Identity); len(identities) > 0 {
+				logOf(ctx).V(2).Info("found logic app managed identities", "logicAppId", logicApp.Id, "count", len(identities))
+				out <- NewAzureWrapper(enums.KindAZLogicAppManagedIdentity, models.LogicAppManagedIdentities{
+					LogicAppId:        logicApp.Id,
+					ManagedIdentities: identities,
+				})
+			}
+		}
+		logOf(ctx).Info("finished listing all logic app managed identities")
+	}()
+
+	return out
+}
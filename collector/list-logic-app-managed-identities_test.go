@@ -0,0 +1,62 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func TestListLogicAppManagedIdentities(t *testing.T) {
+	ctx := testContext()
+
+	mockLogicAppsChannel := make(chan interface{})
+	channel := ListLogicAppManagedIdentities(ctx, mockLogicAppsChannel)
+
+	go func() {
+		defer close(mockLogicAppsChannel)
+		mockLogicAppsChannel <- AzureWrapper{
+			Kind: enums.KindAZLogicApp,
+			Data: models.LogicApp{LogicApp: azure.LogicApp{
+				Entity:   azure.Entity{Id: "app"},
+				Identity: azure.ManagedIdentity{PrincipalId: "system", Type: enums.IdentitySystemAssigned},
+			}},
+		}
+		mockLogicAppsChannel <- AzureWrapper{
+			Kind: enums.KindAZLogicApp,
+			Data: models.LogicApp{},
+		}
+	}()
+
+	if result, ok := <-channel; !ok {
+		t.Fatalf("failed to receive from channel")
+	} else if wrapper, ok := result.(azureWrapper[models.LogicAppManagedIdentities]); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, azureWrapper[models.LogicAppManagedIdentities]{})
+	} else if wrapper.Kind != enums.KindAZLogicAppManagedIdentity {
+		t.Errorf("got kind %v, want %v", wrapper.Kind, enums.KindAZLogicAppManagedIdentity)
+	} else if data := wrapper.Data; data.LogicAppId != "app" || len(data.ManagedIdentities) != 1 || data.ManagedIdentities[0].PrincipalId != "system" {
+		t.Errorf("unexpected logic app managed identities: %+v", data)
+	}
+
+	if result, ok := <-channel; ok {
+		t.Errorf("expected channel to close but got %v", result)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"github.com/bloodhoundad/azurehound/v2/tracing"
+)
+
+func ListLogicApps(ctx context.Context, client client.AzureClient, subscriptions <-chan interface{}) <-chan interface{} {
+	subscriptions = skipInput(ctx, enums.KindAZLogicApp, subscriptions)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+		for result := range pipeline.OrDone(ctx.Done(), subscriptions) {
+			if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
+				collectionError(ctx, enums.KindAZLogicApp, fmt.Errorf("failed type assertion"), "unable to continue enumerating logic apps", "result", result)
+				return
+			} else {
+				ids <- subscription.SubscriptionId
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				subscriptionCtx, span := tracing.Start(ctx, "list logic apps", "subscriptionId", id)
+				count := 0
+				// Azure only allows requesting 100 logic apps at a time. The previous
+				// value of math.MaxInt32 was causing issues and not collecting
+				// logic apps at all. This is not a great fix, since it requires proper
+				// pagination in case there are more than 100 logic apps, but it's better
+				// as an interim solution than it was before.
+				for item := range client.ListAzureLogicApps(subscriptionCtx, id, "", 100) {
+					if item.Error != nil {
+						span.SetError(item.Error)
+						collectionError(ctx, enums.KindAZLogicApp, item.Error, "unable to continue processing logic apps for this subscription", "subscriptionId", id)
+					} else {
+						resourceGroupId := item.Ok.ResourceGroupId()
+						logicapp := models.LogicApp{
+							LogicApp:        item.Ok,
+							SubscriptionId:  item.SubscriptionId,
+							ResourceGroupId: resourceGroupId,
+							TenantId:        client.TenantInfo().TenantId,
+						}
+						logOf(ctx).V(2).Info("found logicapp", "logicapp", logicapp)
+						count++
+						out <- AzureWrapper{
+							Kind: enums.KindAZLogicApp,
+							Data: logicapp,
+						}
+					}
+				}
+				logOf(ctx).V(1).Info("finished listing logic apps", "subscriptionId", id, "count", count)
+				span.SetAttributes("count", count)
+				span.End()
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all logic apps")
+	}()
+
+	return out
+}
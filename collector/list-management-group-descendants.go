@@ -0,0 +1,84 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListManagementGroupDescendants(ctx context.Context, client client.AzureClient, managementGroups <-chan interface{}) <-chan interface{} {
+	managementGroups = skipInput(ctx, enums.KindAZManagementGroupDescendant, managementGroups)
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), managementGroups) {
+			if managementGroup, ok := result.(AzureWrapper).Data.(models.ManagementGroup); !ok {
+				collectionError(ctx, enums.KindAZManagementGroupDescendant, fmt.Errorf("failed type assertion"), "unable to continue enumerating management group descendants", "result", result)
+				return
+			} else {
+				ids <- managementGroup.Name
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				count := 0
+				for item := range client.ListAzureManagementGroupDescendants(ctx, id) {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZManagementGroupDescendant, item.Error, "unable to continue processing descendants for this management group", "managementGroupId", id)
+					} else {
+						logOf(ctx).V(2).Info("found management group descendant", "type", item.Ok.Type, "id", item.Ok.Id, "parent", item.Ok.Properties.Parent.Id)
+						count++
+						out <- AzureWrapper{
+							Kind: enums.KindAZManagementGroupDescendant,
+							Data: item.Ok,
+						}
+					}
+				}
+				logOf(ctx).V(1).Info("finished listing management group descendants", "managementGroupId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all management group descendants")
+	}()
+
+	return out
+}
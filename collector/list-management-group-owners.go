@@ -0,0 +1,48 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListManagementGroupOwners(
+	ctx context.Context,
+	roleAssignments <-chan azureWrapper[models.ManagementGroupRoleAssignments],
+) <-chan any {
+	roleAssignments = skipInput(ctx, enums.KindAZManagementGroupOwner, roleAssignments)
+	return pipeline.Map(ctx.Done(), roleAssignments, func(ra azureWrapper[models.ManagementGroupRoleAssignments]) any {
+		filteredAssignments := internal.Filter(ra.Data.RoleAssignments, mgmtGroupRoleAssignmentFilter(constants.OwnerRoleID))
+		owners := internal.Map(filteredAssignments, func(ra models.ManagementGroupRoleAssignment) models.ManagementGroupOwner {
+			return models.ManagementGroupOwner{
+				Owner:             ra.RoleAssignment,
+				ManagementGroupId: ra.ManagementGroupId,
+			}
+		})
+		return NewAzureWrapper(enums.KindAZManagementGroupOwner, models.ManagementGroupOwners{
+			ManagementGroupId: ra.Data.ManagementGroupId,
+			Owners:            owners,
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListManagementGroupRoleAssignments(ctx context.Context, client client.AzureClient, managementGroups <-chan interface{}) <-chan azureWrapper[models.ManagementGroupRoleAssignments] {
+	managementGroups = skipInput(ctx, enums.KindAZManagementGroupRoleAssignment, managementGroups)
+	var (
+		out     = make(chan azureWrapper[models.ManagementGroupRoleAssignments])
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), managementGroups) {
+			if managementGroup, ok := result.(AzureWrapper).Data.(models.ManagementGroup); !ok {
+				collectionError(ctx, enums.KindAZManagementGroupRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating management group role assignments", "result", result)
+				return
+			} else {
+				ids <- managementGroup.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				var (
+					managementGroupRoleAssignments = models.ManagementGroupRoleAssignments{
+						ManagementGroupId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListRoleAssignmentsForResource(ctx, id, "atScope()") {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZManagementGroupRoleAssignment, item.Error, "unable to continue processing role assignments for this managementGroup", "managementGroupId", id)
+					} else {
+						managementGroupRoleAssignment := models.ManagementGroupRoleAssignment{
+							ManagementGroupId: item.ParentId,
+							RoleAssignment:    item.Ok,
+						}
+						logOf(ctx).V(2).Info("found managementGroup role assignment", "managementGroupRoleAssignment", managementGroupRoleAssignment)
+						count++
+						managementGroupRoleAssignments.RoleAssignments = append(managementGroupRoleAssignments.RoleAssignments, managementGroupRoleAssignment)
+					}
+				}
+				out <- NewAzureWrapper(
+					enums.KindAZManagementGroupRoleAssignment,
+					managementGroupRoleAssignments,
+				)
+				logOf(ctx).V(1).Info("finished listing managementGroup role assignments", "managementGroupId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all management group role assignments")
+	}()
+
+	return out
+}
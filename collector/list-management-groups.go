@@ -0,0 +1,64 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func ListManagementGroups(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZManagementGroup) {
+			return
+		}
+		var (
+			count       = 0
+			selectedIds = optionsOf(ctx).ManagementGroupIds
+		)
+		for item := range client.ListAzureManagementGroups(ctx) {
+			if item.Error != nil {
+				logOf(ctx).Info("warning: unable to process azure management groups; either the organization has no management groups or azurehound does not have the reader role on the root management group.")
+				return
+			} else if len(selectedIds) == 0 || internal.Contains(selectedIds, item.Ok.Name) {
+				logOf(ctx).V(2).Info("found management group", "managementGroup", item)
+				count++
+				mgmtGroup := models.ManagementGroup{
+					ManagementGroup: item.Ok,
+					TenantId:        client.TenantInfo().TenantId,
+					TenantName:      client.TenantInfo().DisplayName,
+				}
+
+				out <- AzureWrapper{
+					Kind: enums.KindAZManagementGroup,
+					Data: mgmtGroup,
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all management groups", "count", count)
+	}()
+
+	return out
+}
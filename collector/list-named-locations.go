@@ -0,0 +1,63 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func ListNamedLocations(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZNamedLocation) {
+			return
+		}
+		count := 0
+		for item := range client.ListAzureADNamedLocations(ctx, "") {
+			if skipForbidden(ctx, item.Error, enums.KindAZNamedLocation) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZNamedLocation, item.Error, "unable to continue processing named locations")
+				return
+			} else if item.Ok.Type != azure.NamedLocationTypeIp && item.Ok.Type != azure.NamedLocationTypeCountry {
+				logOf(ctx).V(1).Info("skipping named location of unsupported type", "id", item.Ok.Id, "type", item.Ok.Type)
+			} else {
+				logOf(ctx).V(2).Info("found named location", "namedLocation", item)
+				count++
+				out <- AzureWrapper{
+					Kind: enums.KindAZNamedLocation,
+					Data: models.NamedLocation{
+						NamedLocation: item.Ok,
+						TenantId:      client.TenantInfo().TenantId,
+						TenantName:    client.TenantInfo().DisplayName,
+					},
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all named locations", "count", count)
+	}()
+
+	return out
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+const testNamedLocations = `{"value": [
+	{"@odata.type": "#microsoft.graph.ipNamedLocation", "id": "ip", "displayName": "Office", "isTrusted": true, "ipRanges": [{"@odata.type": "#microsoft.graph.iPv4CidrRange", "cidrAddress": "203.0.113.0/24"}]},
+	{"@odata.type": "#microsoft.graph.countryNamedLocation", "id": "country", "displayName": "Blocked", "countriesAndRegions": ["KP", "RU"], "countryLookupMethod": "clientIpAddress", "includeUnknownCountriesAndRegions": false},
+	{"@odata.type": "#microsoft.graph.namedLocation", "id": "unknown"}
+]}`
+
+func TestListNamedLocations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	var list azure.NamedLocationList
+	if err := json.Unmarshal([]byte(testNamedLocations), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.NamedLocationResult)
+	mockTenant := azure.Tenant{}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADNamedLocations(gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		for _, location := range list.Value {
+			mockChannel <- azure.NamedLocationResult{
+				Ok: location,
+			}
+		}
+		mockChannel <- azure.NamedLocationResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.NamedLocationResult{
+			Ok: list.Value[0],
+		}
+	}()
+
+	channel := ListNamedLocations(ctx, mockClient)
+
+	var locations []models.NamedLocation
+	for result := range channel {
+		if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.NamedLocation); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", wrapper.Data, models.NamedLocation{})
+		} else {
+			locations = append(locations, data)
+		}
+	}
+
+	if len(locations) != 2 {
+		t.Fatalf("got %v named locations, want 2 before the error and without the unsupported type", len(locations))
+	}
+
+	ip, country := locations[0], locations[1]
+	if ip.IsTrusted == nil || !*ip.IsTrusted || len(ip.IpRanges) != 1 || ip.IpRanges[0].CidrAddress != "203.0.113.0/24" {
+		t.Errorf("got %+v, want a trusted ip location with one range", ip.NamedLocation)
+	} else if strings.Join(country.CountriesAndRegions, ",") != "KP,RU" || country.IncludeUnknownCountriesAndRegions == nil || country.IsTrusted != nil {
+		t.Errorf("got %+v, want a country location", country.NamedLocation)
+	}
+
+	// only the fields of each variant are emitted
+	if bytes, err := json.Marshal(country); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if strings.Contains(string(bytes), "isTrusted") || strings.Contains(string(bytes), "ipRanges") {
+		t.Errorf("got %s, want no ip location fields on a country location", bytes)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func ListOAuth2PermissionGrants(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZOAuth2PermissionGrant) {
+			return
+		}
+		var (
+			count      = 0
+			tenantWide = 0
+		)
+		for item := range client.ListAzureADOAuth2PermissionGrants(ctx, "") {
+			if skipForbidden(ctx, item.Error, enums.KindAZOAuth2PermissionGrant) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZOAuth2PermissionGrant, item.Error, "unable to continue processing oauth2 permission grants")
+				return
+			} else {
+				grant := models.NewOAuth2PermissionGrant(item.Ok, client.TenantInfo())
+				logOf(ctx).V(2).Info("found oauth2 permission grant", "oauth2PermissionGrant", grant)
+				count++
+				if grant.TenantWide {
+					tenantWide++
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZOAuth2PermissionGrant,
+					Data: grant,
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all oauth2 permission grants", "count", count, "tenantWide", tenantWide)
+	}()
+
+	return out
+}
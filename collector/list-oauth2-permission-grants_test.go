@@ -0,0 +1,94 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+const testOAuth2PermissionGrants = `{"value": [
+	{"id": "tenant", "clientId": "client", "consentType": "AllPrincipals", "principalId": null, "resourceId": "graph", "scope": "openid User.Read.All Mail.Read"},
+	{"id": "user", "clientId": "client", "consentType": "Principal", "principalId": "alice", "resourceId": "graph", "scope": " User.Read "}
+]}`
+
+func TestListOAuth2PermissionGrants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	var list azure.OAuth2PermissionGrantList
+	if err := json.Unmarshal([]byte(testOAuth2PermissionGrants), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.OAuth2PermissionGrantResult)
+	mockTenant := azure.Tenant{TenantId: "tenant-id"}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADOAuth2PermissionGrants(gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		for _, grant := range list.Value {
+			mockChannel <- azure.OAuth2PermissionGrantResult{
+				Ok: grant,
+			}
+		}
+		mockChannel <- azure.OAuth2PermissionGrantResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.OAuth2PermissionGrantResult{
+			Ok: list.Value[0],
+		}
+	}()
+
+	channel := ListOAuth2PermissionGrants(ctx, mockClient)
+
+	var grants []models.OAuth2PermissionGrant
+	for result := range channel {
+		if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.OAuth2PermissionGrant); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", wrapper.Data, models.OAuth2PermissionGrant{})
+		} else {
+			grants = append(grants, data)
+		}
+	}
+
+	if len(grants) != 2 {
+		t.Fatalf("got %v oauth2 permission grants, want 2 before the error", len(grants))
+	}
+
+	tenantWide, user := grants[0], grants[1]
+	if !tenantWide.TenantWide || tenantWide.PrincipalId != "" || strings.Join(tenantWide.Scopes, ",") != "openid,User.Read.All,Mail.Read" {
+		t.Errorf("got %+v, want a tenant-wide grant with three scopes", tenantWide)
+	} else if user.TenantWide || user.PrincipalId != "alice" || strings.Join(user.Scopes, ",") != "User.Read" {
+		t.Errorf("got %+v, want a grant for a single user", user)
+	} else if tenantWide.TenantId != "tenant-id" {
+		t.Errorf("got %v, want %v", tenantWide.TenantId, "tenant-id")
+	}
+}
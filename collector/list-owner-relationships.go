@@ -0,0 +1,81 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// ListOwnerRelationships emits an owner relationship for every owner of the apps and service principals listed by
+// ListAppOwners and ListServicePrincipalOwners. Owners that can't be parsed are logged and skipped.
+func ListOwnerRelationships(ctx context.Context, owners <-chan interface{}) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		emit := func(owner models.OwnerRelationship) bool {
+			select {
+			case out <- NewAzureWrapper(enums.KindAZOwnerRelationship, owner):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		count := 0
+		for result := range pipeline.OrDone(ctx.Done(), owners) {
+			var relationships []models.OwnerRelationship
+			switch result := result.(type) {
+			case azureWrapper[models.AppOwners]:
+				for _, owner := range result.Data.Owners {
+					if relationship, err := models.NewOwnerRelationship(owner.Owner, result.Data.AppId, enums.KindAZApp); err != nil {
+						collectionError(ctx, enums.KindAZOwnerRelationship, err, "unable to parse app owner", "appId", result.Data.AppId)
+					} else {
+						relationships = append(relationships, relationship)
+					}
+				}
+			case AzureWrapper:
+				if servicePrincipalOwners, ok := result.Data.(models.ServicePrincipalOwners); ok {
+					for _, owner := range servicePrincipalOwners.Owners {
+						if relationship, err := models.NewOwnerRelationship(owner.Owner, servicePrincipalOwners.ServicePrincipalId, enums.KindAZServicePrincipal); err != nil {
+							collectionError(ctx, enums.KindAZOwnerRelationship, err, "unable to parse service principal owner", "servicePrincipalId", servicePrincipalOwners.ServicePrincipalId)
+						} else {
+							relationships = append(relationships, relationship)
+						}
+					}
+				}
+			}
+
+			for _, relationship := range relationships {
+				logOf(ctx).V(2).Info("found owner relationship", "ownerRelationship", relationship)
+				if !emit(relationship) {
+					return
+				}
+				count++
+			}
+		}
+		logOf(ctx).Info("finished listing all owner relationships", "count", count)
+	}()
+
+	return out
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func TestListOwnerRelationships(t *testing.T) {
+	ctx := testContext()
+
+	mockOwnersChannel := make(chan interface{})
+	channel := ListOwnerRelationships(ctx, mockOwnersChannel)
+
+	go func() {
+		defer close(mockOwnersChannel)
+		mockOwnersChannel <- NewAzureWrapper(enums.KindAZAppOwner, models.AppOwners{
+			AppId: "app",
+			Owners: []models.AppOwner{
+				{AppId: "app", Owner: json.RawMessage(`{"@odata.type":"#microsoft.graph.user","id":"user"}`)},
+				{AppId: "app", Owner: json.RawMessage(`{"@odata.type":"#microsoft.graph.servicePrincipal"}`)},
+			},
+		})
+		mockOwnersChannel <- AzureWrapper{
+			Kind: enums.KindAZServicePrincipalOwner,
+			Data: models.ServicePrincipalOwners{
+				ServicePrincipalId: "sp",
+				Owners: []models.ServicePrincipalOwner{
+					{ServicePrincipalId: "sp", Owner: json.RawMessage(`{"@odata.type":"#microsoft.graph.servicePrincipal","id":"owner-sp"}`)},
+				},
+			},
+		}
+	}()
+
+	want := []models.OwnerRelationship{
+		{OwnerId: "user", OwnerType: "#microsoft.graph.user", TargetId: "app", TargetKind: enums.KindAZApp},
+		{OwnerId: "owner-sp", OwnerType: "#microsoft.graph.servicePrincipal", TargetId: "sp", TargetKind: enums.KindAZServicePrincipal},
+	}
+	for _, expected := range want {
+		if result, ok := <-channel; !ok {
+			t.Fatalf("failed to receive from channel")
+		} else if wrapper, ok := result.(azureWrapper[models.OwnerRelationship]); !ok {
+			t.Errorf("failed type assertion: got %T, want %T", result, azureWrapper[models.OwnerRelationship]{})
+		} else if wrapper.Kind != enums.KindAZOwnerRelationship {
+			t.Errorf("got kind %v, want %v", wrapper.Kind, enums.KindAZOwnerRelationship)
+		} else if wrapper.Data != expected {
+			t.Errorf("got %+v, want %+v", wrapper.Data, expected)
+		}
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("should not have recieved from channel")
+	}
+}
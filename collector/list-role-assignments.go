@@ -0,0 +1,96 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListRoleAssignments(ctx context.Context, client client.AzureClient, roles <-chan interface{}) <-chan interface{} {
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), roles) {
+			if role, ok := result.(AzureWrapper).Data.(models.Role); !ok {
+				collectionError(ctx, enums.KindAZRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating role assignments", "result", result)
+				return
+			} else {
+				ids <- role.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				if skipCollector(ctx, enums.KindAZRoleAssignment) {
+					continue
+				}
+				var (
+					roleAssignments = models.RoleAssignments{
+						RoleDefinitionId: id,
+						TenantId:         client.TenantInfo().TenantId,
+					}
+					count  = 0
+					filter = fmt.Sprintf("roleDefinitionId eq '%s'", id)
+				)
+				for item := range client.ListAzureADRoleAssignments(ctx, filter, "", "", "", nil) {
+					if skipForbidden(ctx, item.Error, enums.KindAZRoleAssignment) {
+						logOf(ctx).V(1).Info("skipping role assignments for this role due to insufficient permissions", "roleDefinitionId", id)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZRoleAssignment, item.Error, "unable to continue processing role assignments for this role", "roleDefinitionId", id)
+					} else {
+						logOf(ctx).V(2).Info("found role assignment", "roleAssignments", item)
+						count++
+						roleAssignments.RoleAssignments = append(roleAssignments.RoleAssignments, item.Ok)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZRoleAssignment,
+					Data: roleAssignments,
+				}
+				logOf(ctx).V(1).Info("finished listing role assignments", "roleDefinitionId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all role assignments")
+	}()
+
+	return out
+}
@@ -15,11 +15,12 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package cmd
+package collector
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/bloodhoundad/azurehound/v2/client/mocks"
@@ -27,14 +28,10 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
-func init() {
-	setupLogger()
-}
-
 func TestListRoles(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	ctx := context.Background()
+	ctx := testContext()
 
 	mockClient := mocks.NewMockAzureClient(ctrl)
 	mockChannel := make(chan azure.RoleResult)
@@ -56,7 +53,7 @@ func TestListRoles(t *testing.T) {
 		}
 	}()
 
-	channel := listRoles(ctx, mockClient)
+	channel := ListRoles(ctx, mockClient)
 	result := <-channel
 	if _, ok := result.(AzureWrapper); !ok {
 		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
@@ -66,3 +63,45 @@ func TestListRoles(t *testing.T) {
 		t.Error("expected channel to close from an error result but it did not")
 	}
 }
+
+func TestListRolesCustomRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.RoleResult)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	mockClient.EXPECT().ListAzureADRoles(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.RoleResult{
+			Ok: azure.Role{
+				DirectoryObject: azure.DirectoryObject{Id: "role"},
+				DisplayName:     "App Credential Manager",
+				IsEnabled:       true,
+				TemplateId:      "template",
+				RolePermissions: []azure.RolePermission{
+					{AllowedResourceActions: []string{"microsoft.directory/applications/credentials/update"}},
+				},
+			},
+		}
+	}()
+
+	result := <-ListRoles(ctx, mockClient)
+	if data, err := json.Marshal(result); err != nil {
+		t.Fatal(err)
+	} else {
+		// custom roles have to be distinguishable from built-in ones by their output alone
+		for _, want := range []string{
+			`"isBuiltIn":false`,
+			`"templateId":"template"`,
+			`"allowedResourceActions":["microsoft.directory/applications/credentials/update"]`,
+		} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("expected %s in %s", want, data)
+			}
+		}
+	}
+}
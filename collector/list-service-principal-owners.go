@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListServicePrincipalOwners(ctx context.Context, client client.AzureClient, servicePrincipals <-chan interface{}) <-chan interface{} {
+	var (
+		out     = make(chan interface{})
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), servicePrincipals) {
+			if servicePrincipal, ok := result.(AzureWrapper).Data.(models.ServicePrincipal); !ok {
+				collectionError(ctx, enums.KindAZServicePrincipalOwner, fmt.Errorf("failed type assertion"), "unable to continue enumerating service principal owners", "result", result)
+				return
+			} else {
+				ids <- servicePrincipal.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				if skipCollector(ctx, enums.KindAZServicePrincipalOwner) {
+					continue
+				}
+				var (
+					servicePrincipalOwners = models.ServicePrincipalOwners{
+						ServicePrincipalId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListAzureADServicePrincipalOwners(ctx, id, "", "", "", nil) {
+					if skipForbidden(ctx, item.Error, enums.KindAZServicePrincipalOwner) {
+						logOf(ctx).V(1).Info("skipping owners for this service principal due to insufficient permissions", "servicePrincipalId", id)
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZServicePrincipalOwner, item.Error, "unable to continue processing owners for this service principal", "servicePrincipalId", id)
+					} else {
+						servicePrincipalOwner := models.ServicePrincipalOwner{
+							Owner:              item.Ok,
+							ServicePrincipalId: item.ServicePrincipalId,
+						}
+						logOf(ctx).V(2).Info("found service principal owner", "servicePrincipalOwner", servicePrincipalOwner)
+						count++
+						servicePrincipalOwners.Owners = append(servicePrincipalOwners.Owners, servicePrincipalOwner)
+					}
+				}
+				out <- AzureWrapper{
+					Kind: enums.KindAZServicePrincipalOwner,
+					Data: servicePrincipalOwners,
+				}
+				logOf(ctx).V(1).Info("finished listing service principal owners", "servicePrincipalId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all service principal owners")
+	}()
+
+	return out
+}
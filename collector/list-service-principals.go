@@ -0,0 +1,74 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func ListServicePrincipals(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZServicePrincipal) {
+			return
+		}
+		listCtx, ok := resumeContext(ctx, enums.KindAZServicePrincipal, client.TenantInfo().TenantId)
+		if !ok {
+			return
+		}
+
+		count := 0
+		for item := range client.ListAzureADServicePrincipals(listCtx, "", "", "", "", collectorSelect(ctx, enums.KindAZServicePrincipal, nil)) {
+			if skipForbidden(ctx, item.Error, enums.KindAZServicePrincipal) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZServicePrincipal, item.Error, "unable to continue processing service principals")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found service principal", "servicePrincipal", item)
+				count++
+				var (
+					keyCredentials      = models.NewKeyCredentials(item.Ok.KeyCredentials)
+					passwordCredentials = models.NewPasswordCredentials(item.Ok.PasswordCredentials)
+				)
+				optionsOf(ctx).Checkpoint.collected(enums.KindAZServicePrincipal, item.Ok)
+				out <- AzureWrapper{
+					Kind: enums.KindAZServicePrincipal,
+					Data: models.ServicePrincipal{
+						ServicePrincipal:    item.Ok,
+						KeyCredentials:      keyCredentials,
+						PasswordCredentials: passwordCredentials,
+						CredentialHygiene:   models.NewCredentialHygiene(keyCredentials, passwordCredentials, time.Now(), optionsOf(ctx).RecentCredentialDays),
+						TenantId:            client.TenantInfo().TenantId,
+						TenantName:          client.TenantInfo().DisplayName,
+					},
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all service principals", "count", count)
+	}()
+
+	return out
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListStorageContainers(ctx context.Context, client client.AzureClient, storageAccounts <-chan interface{}) <-chan interface{} {
+	var (
+		out = make(chan interface{})
+		ids = make(chan interface{})
+		// The original size of the demuxxer cascaded into error messages for a lot of collection steps.
+		// Decreasing the demuxxer size only here is sufficient to prevent the cascade
+		// The error message with higher values for size is
+		// "The request was throttled."
+		// See issue #7: https://github.com/bloodhoundad/azurehound/issues/7
+		streams = pipeline.Demux(ctx.Done(), ids, 2)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+		for result := range pipeline.OrDone(ctx.Done(), storageAccounts) {
+			if storageAccount, ok := result.(AzureWrapper).Data.(models.StorageAccount); !ok {
+				collectionError(ctx, enums.KindAZStorageContainer, fmt.Errorf("failed type assertion"), "unable to continue enumerating storage containers", "result", result)
+				return
+			} else {
+				ids <- storageAccount
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for stAccount := range stream {
+				count := 0
+				for item := range client.ListAzureStorageContainers(ctx, stAccount.(models.StorageAccount).SubscriptionId, stAccount.(models.StorageAccount).ResourceGroupName, stAccount.(models.StorageAccount).Name, "", "deleted", "") {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZStorageContainer, item.Error, "unable to continue processing storage containers for this subscription", "subscriptionId", stAccount.(models.StorageAccount).SubscriptionId, "storageAccountName", stAccount.(models.StorageAccount).Name)
+					} else {
+						resourceGroupId := item.Ok.ResourceGroupId()
+						resourceGroupName := item.Ok.ResourceGroupName()
+						storageContainer := models.StorageContainer{
+							StorageContainer:  item.Ok,
+							StorageAccountId:  stAccount.(models.StorageAccount).StorageAccount.Id,
+							SubscriptionId:    item.SubscriptionId,
+							ResourceGroupId:   resourceGroupId,
+							ResourceGroupName: resourceGroupName,
+							TenantId:          client.TenantInfo().TenantId,
+						}
+						logOf(ctx).V(2).Info("found storage container", "storageContainer", storageContainer)
+						count++
+						out <- AzureWrapper{
+							Kind: enums.KindAZStorageContainer,
+							Data: storageContainer,
+						}
+					}
+					logOf(ctx).V(1).Info("finished listing storage containers", "subscriptionId", stAccount.(models.StorageAccount).SubscriptionId, "count", count)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all storage containers")
+	}()
+
+	return out
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/internal"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+func ListSubscriptions(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZSubscription) {
+			return
+		}
+		var (
+			options              = optionsOf(ctx)
+			count                = 0
+			excluded             = 0
+			selectedSubIds       = options.SubscriptionIds
+			selectedMgmtGroupIds = options.ManagementGroupIds
+			excludedClasses      = options.ExcludeSubscriptionClasses
+			filterOnSubs         = len(selectedSubIds) != 0 || len(selectedMgmtGroupIds) != 0
+		)
+
+		if len(selectedMgmtGroupIds) != 0 {
+			descendantChannel := ListManagementGroupDescendants(ctx, client, ListManagementGroups(ctx, client))
+			for i := range descendantChannel {
+				if item, ok := i.(AzureWrapper).Data.(azure.DescendantInfo); !ok {
+					collectionError(ctx, enums.KindAZSubscription, fmt.Errorf("failed type assertion"), "unable to continue evaluating management group descendants", "result", i)
+					return
+				} else if item.Type == "Microsoft.Management/managementGroups/subscriptions" {
+					selectedSubIds = append(selectedSubIds, item.Name)
+				}
+			}
+		}
+		uniqueSubIds := unique(selectedSubIds)
+
+		for item := range client.ListAzureSubscriptions(ctx) {
+			if item.Error != nil {
+				collectionError(ctx, enums.KindAZSubscription, item.Error, "unable to continue processing subscriptions")
+				return
+			} else if !filterOnSubs || internal.Contains(uniqueSubIds, item.Ok.SubscriptionId) {
+				// the embedded struct's values override top-level properties so TenantId
+				// needs to be explicitly set.
+				data := models.Subscription{
+					Subscription:      item.Ok,
+					SubscriptionClass: models.NewSubscriptionClass(item.Ok.SubscriptionPolicies.QuotaId),
+				}
+				data.TenantId = client.TenantInfo().TenantId
+
+				if internal.Contains(excludedClasses, data.SubscriptionClass) {
+					logOf(ctx).V(1).Info("skipping subscription", "subscriptionId", data.SubscriptionId, "subscriptionClass", data.SubscriptionClass, "quotaId", data.SubscriptionPolicies.QuotaId)
+					excluded++
+					continue
+				}
+
+				logOf(ctx).V(2).Info("found subscription", "subscription", item)
+				count++
+				out <- AzureWrapper{
+					Kind: enums.KindAZSubscription,
+					Data: data,
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all subscriptions", "count", count, "excludedByClass", excluded)
+	}()
+
+	return out
+}
@@ -0,0 +1,141 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListSubscriptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.SubscriptionResult)
+	mockTenant := azure.Tenant{}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureSubscriptions(gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.SubscriptionResult{
+			Ok: azure.Subscription{},
+		}
+		mockChannel <- azure.SubscriptionResult{
+			Error: mockError,
+		}
+		mockChannel <- azure.SubscriptionResult{
+			Ok: azure.Subscription{},
+		}
+	}()
+
+	channel := ListSubscriptions(ctx, mockClient)
+	result := <-channel
+	if _, ok := result.(AzureWrapper); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("expected channel to close from an error result but it did not")
+	}
+}
+
+func TestListSubscriptionsClass(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	subscription := func(id, quotaId string) azure.SubscriptionResult {
+		return azure.SubscriptionResult{
+			Ok: azure.Subscription{
+				SubscriptionId:       id,
+				SubscriptionPolicies: azure.SubscriptionPolicies{QuotaId: quotaId},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		exclude []enums.SubscriptionClass
+		want    map[string]enums.SubscriptionClass
+	}{
+		{
+			name: "no filter",
+			want: map[string]enums.SubscriptionClass{
+				"prod":    enums.SubscriptionClassProduction,
+				"devtest": enums.SubscriptionClassDevTest,
+				"sandbox": enums.SubscriptionClassSandbox,
+				"other":   enums.SubscriptionClassUnknown,
+			},
+		},
+		{
+			name:    "exclude devtest and sandbox",
+			exclude: []enums.SubscriptionClass{enums.SubscriptionClassDevTest, enums.SubscriptionClassSandbox},
+			want: map[string]enums.SubscriptionClass{
+				"prod":  enums.SubscriptionClassProduction,
+				"other": enums.SubscriptionClassUnknown,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := WithOptions(context.Background(), Options{ExcludeSubscriptionClasses: test.exclude})
+
+			mockClient := mocks.NewMockAzureClient(ctrl)
+			mockChannel := make(chan azure.SubscriptionResult)
+			mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+			mockClient.EXPECT().ListAzureSubscriptions(gomock.Any()).Return(mockChannel)
+
+			go func() {
+				defer close(mockChannel)
+				mockChannel <- subscription("prod", "EnterpriseAgreement_2014-09-01")
+				mockChannel <- subscription("devtest", "MSDNDevTest_2014-09-01")
+				mockChannel <- subscription("sandbox", "MSDN_2014-09-01")
+				mockChannel <- subscription("other", "")
+			}()
+
+			actual := map[string]enums.SubscriptionClass{}
+			for result := range ListSubscriptions(ctx, mockClient) {
+				if subscription, ok := result.(AzureWrapper).Data.(models.Subscription); !ok {
+					t.Fatalf("failed type assertion: got %T, want %T", result.(AzureWrapper).Data, models.Subscription{})
+				} else {
+					actual[subscription.SubscriptionId] = subscription.SubscriptionClass
+				}
+			}
+
+			if len(actual) != len(test.want) {
+				t.Errorf("got %v, want %v", actual, test.want)
+			}
+			for id, class := range test.want {
+				if actual[id] != class {
+					t.Errorf("%s: got %v, want %v", id, actual[id], class)
+				}
+			}
+		})
+	}
+}
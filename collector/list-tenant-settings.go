@@ -0,0 +1,98 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// organizationSelect leaves out the contact details and service plans of the organization, which say nothing about
+// what users are allowed to do
+var organizationSelect = []string{
+	"id",
+	"createdDateTime",
+	"displayName",
+	"onPremisesLastSyncDateTime",
+	"onPremisesSyncEnabled",
+	"tenantType",
+	"verifiedDomains",
+}
+
+// ListTenantSettings emits a single object with the organization, authorization policy and directory settings of the
+// tenant. A part that can't be read is left out rather than failing the others.
+func ListTenantSettings(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZTenantSettings) {
+			return
+		}
+
+		var (
+			organization *azure.Organization
+			policy       *azure.AuthorizationPolicy
+			// nil when the directory settings can't be read, as opposed to there being none
+			settings = []azure.GroupSetting{}
+		)
+
+		if result, err := client.GetAzureADOrganization(ctx, organizationSelect); err == nil {
+			organization = result
+		} else if !skipForbidden(ctx, err, enums.KindAZTenantSettings) {
+			collectionError(ctx, enums.KindAZTenantSettings, err, "unable to collect organization")
+		}
+
+		if result, err := client.GetAzureADAuthorizationPolicy(ctx); err == nil {
+			policy = result
+		} else if !skipForbidden(ctx, err, enums.KindAZTenantSettings) {
+			collectionError(ctx, enums.KindAZTenantSettings, err, "unable to collect authorization policy")
+		}
+
+		for item := range client.ListAzureADGroupSettings(ctx) {
+			if skipForbidden(ctx, item.Error, enums.KindAZTenantSettings) {
+				settings = nil
+				break
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZTenantSettings, item.Error, "unable to continue processing directory settings")
+				settings = nil
+				break
+			} else {
+				logOf(ctx).V(2).Info("found directory setting", "setting", item)
+				settings = append(settings, item.Ok)
+			}
+		}
+
+		if rest.Aborted(ctx) != nil || (organization == nil && policy == nil && settings == nil) {
+			return
+		}
+
+		out <- AzureWrapper{
+			Kind: enums.KindAZTenantSettings,
+			Data: models.NewTenantSettings(organization, policy, settings, client.TenantInfo().TenantId, client.TenantInfo().DisplayName),
+		}
+		logOf(ctx).Info("finished listing tenant settings")
+	}()
+
+	return out
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+const testAuthorizationPolicy = `{
+	"id": "authorizationPolicy",
+	"allowInvitesFrom": "everyone",
+	"guestUserRoleId": "10dae51f-b6af-4016-8d66-8c2a99b929b3",
+	"defaultUserRolePermissions": {
+		"allowedToCreateApps": true,
+		"allowedToCreateSecurityGroups": false,
+		"allowedToReadOtherUsers": true,
+		"permissionGrantPoliciesAssigned": [
+			"ManagePermissionGrantsForOwnedResource.microsoft-dynamically-managed-permissions-for-team",
+			"ManagePermissionGrantsForSelf.microsoft-user-default-low"
+		]
+	}
+}`
+
+func TestListTenantSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	var policy azure.AuthorizationPolicy
+	if err := json.Unmarshal([]byte(testAuthorizationPolicy), &policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.GroupSettingResult)
+	mockTenant := azure.Tenant{TenantId: "tenant"}
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().GetAzureADOrganization(gomock.Any(), gomock.Any()).Return(&azure.Organization{DisplayName: "Contoso"}, nil)
+	mockClient.EXPECT().GetAzureADAuthorizationPolicy(gomock.Any()).Return(&policy, nil)
+	mockClient.EXPECT().ListAzureADGroupSettings(gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.GroupSettingResult{
+			Ok: azure.GroupSetting{DisplayName: "Group.Unified", Values: []azure.SettingValue{{Name: "EnableGroupCreation", Value: "false"}}},
+		}
+	}()
+
+	var settings []models.TenantSettings
+	for result := range ListTenantSettings(ctx, mockClient) {
+		if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.TenantSettings); !ok {
+			t.Fatalf("failed type assertion: got %T, want %T", wrapper.Data, models.TenantSettings{})
+		} else {
+			settings = append(settings, data)
+		}
+	}
+
+	if len(settings) != 1 {
+		t.Fatalf("got %v tenant settings, want 1", len(settings))
+	}
+
+	result := settings[0]
+	if result.Organization == nil || result.Organization.DisplayName != "Contoso" || result.TenantId != "tenant" {
+		t.Errorf("got %+v, want the organization of the tenant", result)
+	} else if len(result.DirectorySettings) != 1 || result.DirectorySettings[0].Values[0].Name != "EnableGroupCreation" {
+		t.Errorf("got %+v, want one directory setting", result.DirectorySettings)
+	} else if result.UsersCanCreateApps == nil || !*result.UsersCanCreateApps {
+		t.Error("expected users to be able to create apps")
+	} else if result.UsersCanCreateSecurityGroups == nil || *result.UsersCanCreateSecurityGroups {
+		t.Error("expected users not to be able to create security groups")
+	} else if result.UserConsent != models.UserConsentLowImpact {
+		t.Errorf("got %v, want %v", result.UserConsent, models.UserConsentLowImpact)
+	} else if result.GuestAccess != models.GuestAccessLimited {
+		t.Errorf("got %v, want %v", result.GuestAccess, models.GuestAccessLimited)
+	}
+}
+
+func TestListTenantSettingsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+	t.Cleanup(resetSkippedKinds)
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	mockChannel := make(chan azure.GroupSettingResult)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	mockClient.EXPECT().GetAzureADOrganization(gomock.Any(), gomock.Any()).Return(&azure.Organization{}, nil)
+	mockClient.EXPECT().GetAzureADAuthorizationPolicy(gomock.Any()).Return(nil, fmt.Errorf("%w: Authorization_RequestDenied", rest.ErrForbidden))
+	mockClient.EXPECT().ListAzureADGroupSettings(gomock.Any()).Return(mockChannel)
+
+	go func() {
+		defer close(mockChannel)
+		mockChannel <- azure.GroupSettingResult{Error: fmt.Errorf("I'm an error")}
+	}()
+
+	count := 0
+	for result := range ListTenantSettings(ctx, mockClient) {
+		count++
+		data := result.(AzureWrapper).Data.(models.TenantSettings)
+		if data.AuthorizationPolicy != nil || data.UsersCanCreateApps != nil || data.UserConsent != "" {
+			t.Errorf("got %+v, want no authorization policy", data)
+		} else if data.DirectorySettings != nil {
+			t.Errorf("got %+v, want no directory settings", data.DirectorySettings)
+		}
+	}
+
+	if count != 1 {
+		t.Errorf("got %v tenant settings, want the organization to be emitted without the forbidden policy", count)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func ListTenants(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		// Send the fully hydrated tenant that is being collected
+		collectedTenant := client.TenantInfo()
+		out <- AzureWrapper{
+			Kind: enums.KindAZTenant,
+			Data: models.Tenant{
+				Tenant:    collectedTenant,
+				Collected: true,
+			},
+		}
+		count := 1
+		if !collectionScopeOf(ctx).AzureRM {
+			// the other tenants are listed by resource manager, which is out of scope
+			logOf(ctx).Info("finished listing all tenants", "count", count)
+			return
+		}
+		for item := range client.ListAzureADTenants(ctx, true) {
+			if skipForbidden(ctx, item.Error, enums.KindAZTenant) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZTenant, item.Error, "unable to continue processing tenants")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found tenant", "tenant", item)
+				count++
+
+				// Send the remaining tenant trusts
+				if item.Ok.TenantId != collectedTenant.TenantId {
+					out <- AzureWrapper{
+						Kind: enums.KindAZTenant,
+						Data: models.Tenant{
+							Tenant: item.Ok,
+						},
+					}
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all tenants", "count", count)
+	}()
+
+	return out
+}
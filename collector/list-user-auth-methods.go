@@ -0,0 +1,116 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// authMethodsConcurrency is kept low since every user costs a request and the endpoint is throttled well before the
+// rest of the graph api
+const authMethodsConcurrency = 4
+
+// ListUserAuthenticationMethods lists the authentication methods of each user with one request per user. A user is
+// only emitted once all of their methods were listed, since a partial list would overstate missing MFA. The first
+// permission error stops the requests for all remaining users; the permission is tenant wide.
+func ListUserAuthenticationMethods(ctx context.Context, client client.AzureClient, users <-chan interface{}) <-chan interface{} {
+	var (
+		out       = make(chan interface{})
+		ids       = make(chan string)
+		streams   = pipeline.Demux(ctx.Done(), ids, authMethodsConcurrency)
+		wg        sync.WaitGroup
+		forbidden atomic.Bool
+	)
+
+	go func() {
+		defer close(ids)
+
+		// users are read to the end even once forbidden so the collectors sharing them aren't held up
+		for result := range pipeline.OrDone(ctx.Done(), users) {
+			if user, ok := result.(AzureWrapper).Data.(models.User); !ok {
+				collectionError(ctx, enums.KindAZUserAuthenticationMethod, fmt.Errorf("failed type assertion"), "unable to continue enumerating user authentication methods", "result", result)
+				return
+			} else if !forbidden.Load() {
+				ids <- user.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				if forbidden.Load() || skipCollector(ctx, enums.KindAZUserAuthenticationMethod) {
+					continue
+				}
+
+				var (
+					userMethods = models.UserAuthenticationMethods{
+						Methods:  []models.UserAuthenticationMethod{},
+						UserId:   id,
+						TenantId: client.TenantInfo().TenantId,
+					}
+					complete = true
+				)
+				for item := range client.ListAzureADUserAuthenticationMethods(ctx, id) {
+					if skipForbidden(ctx, item.Error, enums.KindAZUserAuthenticationMethod) {
+						forbidden.Store(true)
+						complete = false
+					} else if item.Error != nil {
+						collectionError(ctx, enums.KindAZUserAuthenticationMethod, item.Error, "unable to continue processing authentication methods for this user", "userId", id)
+						complete = false
+					} else {
+						method := models.UserAuthenticationMethod{
+							AuthenticationMethod: item.Ok,
+							MethodType:           item.Ok.MethodType(),
+							UserId:               item.UserId,
+						}
+						logOf(ctx).V(2).Info("found user authentication method", "method", method)
+						userMethods.Methods = append(userMethods.Methods, method)
+					}
+				}
+
+				if complete {
+					out <- AzureWrapper{
+						Kind: enums.KindAZUserAuthenticationMethod,
+						Data: userMethods,
+					}
+					logOf(ctx).V(1).Info("finished listing user authentication methods", "userId", id, "count", len(userMethods.Methods))
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all user authentication methods")
+	}()
+
+	return out
+}
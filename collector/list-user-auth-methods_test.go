@@ -0,0 +1,146 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListUserAuthenticationMethods(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+
+	mockUsersChannel := make(chan interface{})
+	mockMethodsChannel := make(chan azure.UserAuthenticationMethodResult)
+	mockMethodsChannel2 := make(chan azure.UserAuthenticationMethodResult)
+
+	mockTenant := azure.Tenant{TenantId: "tenant"}
+	mockError := fmt.Errorf("I'm an error")
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureADUserAuthenticationMethods(gomock.Any(), "1").Return(mockMethodsChannel).Times(1)
+	mockClient.EXPECT().ListAzureADUserAuthenticationMethods(gomock.Any(), "2").Return(mockMethodsChannel2).Times(1)
+	channel := ListUserAuthenticationMethods(ctx, mockClient, mockUsersChannel)
+
+	go func() {
+		defer close(mockUsersChannel)
+		mockUsersChannel <- AzureWrapper{
+			Data: models.User{User: azure.User{DirectoryObject: azure.DirectoryObject{Id: "1"}}},
+		}
+		mockUsersChannel <- AzureWrapper{
+			Data: models.User{User: azure.User{DirectoryObject: azure.DirectoryObject{Id: "2"}}},
+		}
+	}()
+	go func() {
+		defer close(mockMethodsChannel)
+		mockMethodsChannel <- azure.UserAuthenticationMethodResult{
+			UserId: "1",
+			Ok:     azure.AuthenticationMethod{Id: "a", ODataType: "#microsoft.graph.fido2AuthenticationMethod"},
+		}
+		mockMethodsChannel <- azure.UserAuthenticationMethodResult{
+			UserId: "1",
+			Ok:     azure.AuthenticationMethod{Id: "b", ODataType: "#microsoft.graph.passwordAuthenticationMethod"},
+		}
+	}()
+	go func() {
+		defer close(mockMethodsChannel2)
+		mockMethodsChannel2 <- azure.UserAuthenticationMethodResult{
+			UserId: "2",
+			Ok:     azure.AuthenticationMethod{Id: "c", ODataType: "#microsoft.graph.phoneAuthenticationMethod"},
+		}
+		mockMethodsChannel2 <- azure.UserAuthenticationMethodResult{
+			UserId: "2",
+			Error:  mockError,
+		}
+	}()
+
+	// the second user's list is incomplete so only the first is emitted
+	if result, ok := <-channel; !ok {
+		t.Fatalf("failed to receive from channel")
+	} else if wrapper, ok := result.(AzureWrapper); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+	} else if wrapper.Kind != enums.KindAZUserAuthenticationMethod {
+		t.Errorf("got %v, want %v", wrapper.Kind, enums.KindAZUserAuthenticationMethod)
+	} else if data, ok := wrapper.Data.(models.UserAuthenticationMethods); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", wrapper.Data, models.UserAuthenticationMethods{})
+	} else if data.UserId != "1" || data.TenantId != "tenant" {
+		t.Errorf("got user %v in tenant %v, want user 1 in tenant tenant", data.UserId, data.TenantId)
+	} else if len(data.Methods) != 2 {
+		t.Errorf("got %v, want %v", len(data.Methods), 2)
+	} else if data.Methods[0].MethodType != "fido2" || data.Methods[1].MethodType != "password" {
+		t.Errorf("got method types %v and %v, want fido2 and password", data.Methods[0].MethodType, data.Methods[1].MethodType)
+	}
+
+	if result, ok := <-channel; ok {
+		t.Errorf("expected channel to close but got %v", result)
+	}
+}
+
+func TestListUserAuthenticationMethodsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+	resetSkippedKinds()
+	defer resetSkippedKinds()
+
+	var (
+		mockClient   = mocks.NewMockAzureClient(ctrl)
+		mockUsers    = make(chan interface{})
+		requests     int32
+		userCount    = 50
+		forbiddenErr = fmt.Errorf("%w: map[error:map[code:Authorization_RequestDenied]]", rest.ErrForbidden)
+	)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	mockClient.EXPECT().ListAzureADUserAuthenticationMethods(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, userId string) <-chan azure.UserAuthenticationMethodResult {
+		atomic.AddInt32(&requests, 1)
+		out := make(chan azure.UserAuthenticationMethodResult, 1)
+		out <- azure.UserAuthenticationMethodResult{UserId: userId, Error: forbiddenErr}
+		close(out)
+		return out
+	}).MinTimes(1)
+
+	go func() {
+		defer close(mockUsers)
+		for i := 0; i < userCount; i++ {
+			mockUsers <- AzureWrapper{
+				Data: models.User{User: azure.User{DirectoryObject: azure.DirectoryObject{Id: fmt.Sprint(i)}}},
+			}
+		}
+	}()
+
+	channel := ListUserAuthenticationMethods(ctx, mockClient, mockUsers)
+	if result, ok := <-channel; ok {
+		t.Errorf("expected channel to close without results but got %v", result)
+	} else if skipped := SkippedKinds(); len(skipped) != 1 || skipped[0] != string(enums.KindAZUserAuthenticationMethod) {
+		t.Errorf("got skipped kinds %v, want %v", skipped, []string{string(enums.KindAZUserAuthenticationMethod)})
+	} else if count := atomic.LoadInt32(&requests); count > authMethodsConcurrency {
+		t.Errorf("got %v requests after a permission error, want at most %v", count, authMethodsConcurrency)
+	}
+}
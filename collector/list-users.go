@@ -0,0 +1,83 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+// userSelect is the default graph user projection plus accountEnabled, which Options.ExcludeDisabled relies on
+var userSelect = []string{
+	"id",
+	"accountEnabled",
+	"businessPhones",
+	"displayName",
+	"givenName",
+	"jobTitle",
+	"mail",
+	"mobilePhone",
+	"officeLocation",
+	"preferredLanguage",
+	"surname",
+	"userPrincipalName",
+}
+
+func ListUsers(ctx context.Context, client client.AzureClient) <-chan interface{} {
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+		if skipCollector(ctx, enums.KindAZUser) {
+			return
+		}
+		listCtx, ok := resumeContext(ctx, enums.KindAZUser, client.TenantInfo().TenantId)
+		if !ok {
+			return
+		}
+
+		count := 0
+		for item := range client.ListAzureADUsers(listCtx, "", "", "", collectorSelect(ctx, enums.KindAZUser, userSelect)) {
+			if skipForbidden(ctx, item.Error, enums.KindAZUser) {
+				return
+			} else if item.Error != nil {
+				collectionError(ctx, enums.KindAZUser, item.Error, "unable to continue processing users")
+				return
+			} else {
+				logOf(ctx).V(2).Info("found user", "user", item)
+				count++
+				user := models.User{
+					User:       item.Ok,
+					TenantId:   client.TenantInfo().TenantId,
+					TenantName: client.TenantInfo().DisplayName,
+				}
+				optionsOf(ctx).Checkpoint.collected(enums.KindAZUser, user)
+				out <- AzureWrapper{
+					Kind: enums.KindAZUser,
+					Data: user,
+				}
+			}
+		}
+		logOf(ctx).Info("finished listing all users", "count", count)
+	}()
+
+	return out
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// ListVirtualMachineManagedIdentities links each virtual machine to the managed identities attached to it along with
+// its admin username and OS type. Virtual machines without a managed identity are skipped.
+func ListVirtualMachineManagedIdentities(ctx context.Context, virtualMachines <-chan interface{}) <-chan interface{} {
+	virtualMachines = skipInput(ctx, enums.KindAZVMManagedIdentity, virtualMachines)
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		for result := range pipeline.OrDone(ctx.Done(), virtualMachines) {
+			if virtualMachine, ok := result.(AzureWrapper).Data.(models.VirtualMachine); !ok {
+				collectionError(ctx, enums.KindAZVMManagedIdentity, fmt.Errorf("failed type assertion"), "unable to continue enumerating virtual machine managed identities", "result", result)
+				return
+			} else if identities := models.NewManagedIdentities(virtualMachine.Identity); len(identities) > 0 {
+				logOf(ctx).V(2).Info("found virtual machine managed identities", "virtualMachineId", virtualMachine.Id, "count", len(identities))
+				out <- NewAzureWrapper(enums.KindAZVMManagedIdentity, models.VirtualMachineManagedIdentities{
+					AdminUsername:     virtualMachine.Properties.OSProfile.AdminUsername,
+					ManagedIdentities: identities,
+					OSType:            virtualMachine.Properties.StorageProfile.OSDisk.OSType,
+					TenantId:          virtualMachine.TenantId,
+					VirtualMachineId:  virtualMachine.Id,
+				})
+			}
+		}
+		logOf(ctx).Info("finished listing all virtual machine managed identities")
+	}()
+
+	return out
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func TestListVirtualMachineManagedIdentities(t *testing.T) {
+	ctx := testContext()
+
+	mockVirtualMachinesChannel := make(chan interface{})
+	channel := ListVirtualMachineManagedIdentities(ctx, mockVirtualMachinesChannel)
+
+	go func() {
+		defer close(mockVirtualMachinesChannel)
+		mockVirtualMachinesChannel <- AzureWrapper{
+			Kind: enums.KindAZVM,
+			Data: models.VirtualMachine{
+				VirtualMachine: azure.VirtualMachine{
+					Entity: azure.Entity{Id: "vm"},
+					Identity: azure.ManagedIdentity{
+						PrincipalId: "system",
+						Type:        enums.IdentitySystemAssignedUserAssigned,
+						UserAssignedIdentities: map[string]azure.UserAssignedIdentity{
+							"/identities/b": {ClientId: "client-b", PrincipalId: "principal-b"},
+							"/identities/a": {ClientId: "client-a", PrincipalId: "principal-a"},
+						},
+					},
+					Properties: azure.VirtualMachineProperties{
+						OSProfile:      azure.OSProfile{AdminUsername: "azureuser"},
+						StorageProfile: azure.StorageProfile{OSDisk: azure.OSDisk{OSType: "Linux"}},
+					},
+				},
+				TenantId: "tenant",
+			},
+		}
+		mockVirtualMachinesChannel <- AzureWrapper{
+			Kind: enums.KindAZVM,
+			Data: models.VirtualMachine{},
+		}
+	}()
+
+	if result, ok := <-channel; !ok {
+		t.Fatalf("failed to receive from channel")
+	} else if wrapper, ok := result.(azureWrapper[models.VirtualMachineManagedIdentities]); !ok {
+		t.Errorf("failed type assertion: got %T, want %T", result, azureWrapper[models.VirtualMachineManagedIdentities]{})
+	} else if wrapper.Kind != enums.KindAZVMManagedIdentity {
+		t.Errorf("got kind %v, want %v", wrapper.Kind, enums.KindAZVMManagedIdentity)
+	} else if data := wrapper.Data; data.VirtualMachineId != "vm" || data.AdminUsername != "azureuser" || data.OSType != "Linux" || data.TenantId != "tenant" {
+		t.Errorf("unexpected virtual machine metadata: %+v", data)
+	} else if len(data.ManagedIdentities) != 3 {
+		t.Errorf("got %v managed identities, want 3", len(data.ManagedIdentities))
+	} else if system := data.ManagedIdentities[0]; system.Type != enums.IdentitySystemAssigned || system.PrincipalId != "system" {
+		t.Errorf("unexpected system assigned identity: %+v", system)
+	} else if userAssigned := data.ManagedIdentities[1]; userAssigned.Type != enums.IdentityUserAssigned || userAssigned.ResourceId != "/identities/a" || userAssigned.ClientId != "client-a" {
+		t.Errorf("unexpected user assigned identity: %+v", userAssigned)
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("should not have recieved from channel")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+func ListVirtualMachineRoleAssignments(ctx context.Context, client client.AzureClient, virtualMachines <-chan interface{}) <-chan azureWrapper[models.VirtualMachineRoleAssignments] {
+	virtualMachines = skipInput(ctx, enums.KindAZVMRoleAssignment, virtualMachines)
+	var (
+		out     = make(chan azureWrapper[models.VirtualMachineRoleAssignments])
+		ids     = make(chan string)
+		streams = pipeline.Demux(ctx.Done(), ids, 25)
+		wg      sync.WaitGroup
+	)
+
+	go func() {
+		defer close(ids)
+
+		for result := range pipeline.OrDone(ctx.Done(), virtualMachines) {
+			if virtualMachine, ok := result.(AzureWrapper).Data.(models.VirtualMachine); !ok {
+				collectionError(ctx, enums.KindAZVMRoleAssignment, fmt.Errorf("failed type assertion"), "unable to continue enumerating virtual machine role assignments", "result", result)
+				return
+			} else {
+				ids <- virtualMachine.Id
+			}
+		}
+	}()
+
+	wg.Add(len(streams))
+	for i := range streams {
+		stream := streams[i]
+		go func() {
+			defer wg.Done()
+			for id := range stream {
+				var (
+					virtualMachineRoleAssignments = models.VirtualMachineRoleAssignments{
+						VirtualMachineId: id,
+					}
+					count = 0
+				)
+				for item := range client.ListRoleAssignmentsForResource(ctx, id, "") {
+					if item.Error != nil {
+						collectionError(ctx, enums.KindAZVMRoleAssignment, item.Error, "unable to continue processing role assignments for this virtual machine", "virtualMachineId", id)
+					} else {
+						virtualMachineRoleAssignment := models.VirtualMachineRoleAssignment{
+							VirtualMachineId: item.ParentId,
+							RoleAssignment:   item.Ok,
+						}
+						logOf(ctx).V(2).Info("found virtual machine role assignment", "virtualMachineRoleAssignment", virtualMachineRoleAssignment)
+						count++
+						virtualMachineRoleAssignments.RoleAssignments = append(virtualMachineRoleAssignments.RoleAssignments, virtualMachineRoleAssignment)
+					}
+				}
+				out <- NewAzureWrapper(enums.KindAZVMRoleAssignment, virtualMachineRoleAssignments)
+				logOf(ctx).V(1).Info("finished listing virtual machine role assignments", "virtualMachineId", id, "count", count)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+		logOf(ctx).Info("finished listing all virtual machine role assignments")
+	}()
+
+	return out
+}
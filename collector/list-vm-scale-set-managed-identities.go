@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// ListVMScaleSetManagedIdentities links each virtual machine scale set to the managed identities attached to it along
+// with the admin username and OS type of its virtual machine profile. Scale sets without a managed identity are skipped.
+func ListVMScaleSetManagedIdentities(ctx context.Context, vmScaleSets <-chan interface{}) <-chan interface{} {
+	vmScaleSets = skipInput(ctx, enums.KindAZVMScaleSetManagedIdentity, vmScaleSets)
+	out := make(chan interface{})
+
+	go func() {
+		defer close(out)
+
+		for result := range pipeline.OrDone(ctx.Done(), vmScaleSets) {
+			if vmScaleSet, ok := result.(AzureWrapper).Data.(models.VMScaleSet); !ok {
+				collectionError(ctx, enums.KindAZVMScaleSetManagedIdentity, fmt.Errorf("failed type assertion"), "unable to continue enumerating virtual machine scale set managed identities", "result", result)
+				return
+			} else if identities := models.NewManagedIdentities(vmScaleSet.Identity); len(identities) > 0 {
+				logOf(ctx).V(2).Info("found virtual machine scale set managed identities", "vmScaleSetId", vmScaleSet.Id, "count", len(identities))
+				out <- NewAzureWrapper(enums.KindAZVMScaleSetManagedIdentity, models.VMScaleSetManagedIdentities{
+					AdminUsername:     vmScaleSet.Properties.VirtualMachineProfile.OSProfile.AdminUsername,
+					ManagedIdentities: identities,
+					OSType:            vmScaleSet.Properties.VirtualMachineProfile.StorageProfile.OSDisk.OSType,
+					TenantId:          vmScaleSet.TenantId,
+					VMScaleSetId:      vmScaleSet.Id,
+				})
+			}
+		}
+		logOf(ctx).Info("finished listing all virtual machine scale set managed identities")
+	}()
+
+	return out
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListWebApps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := testContext()
+
+	mockClient := mocks.NewMockAzureClient(ctrl)
+
+	mockSubscriptionsChannel := make(chan interface{})
+	mockWebAppChannel := make(chan azure.WebAppResult)
+
+	mockTenant := azure.Tenant{TenantId: "tenant"}
+	mockError := fmt.Errorf("I'm an error")
+	mockAuthenticated := azure.WebApp{Entity: azure.Entity{Id: "authenticated"}, Kind: "app"}
+	mockUnreadable := azure.WebApp{Entity: azure.Entity{Id: "unreadable"}, Kind: "app"}
+	mockFunctionApp := azure.WebApp{Entity: azure.Entity{Id: "function"}, Kind: "functionapp"}
+	mockAuthSettings := azure.WebAppAuthSettings{}
+	mockAuthSettings.Properties.Platform.Enabled = true
+	mockAuthSettings.Properties.GlobalValidation.RequireAuthentication = true
+	mockAuthSettings.Properties.GlobalValidation.UnauthenticatedClientAction = "RedirectToLoginPage"
+
+	mockClient.EXPECT().TenantInfo().Return(mockTenant).AnyTimes()
+	mockClient.EXPECT().ListAzureWebApps(gomock.Any(), gomock.Any()).Return(mockWebAppChannel).Times(1)
+	mockClient.EXPECT().GetAzureWebAppAuthSettings(gomock.Any(), mockAuthenticated.Id).Return(&mockAuthSettings, nil).Times(1)
+	mockClient.EXPECT().GetAzureWebAppAuthSettings(gomock.Any(), mockUnreadable.Id).Return(nil, mockError).Times(1)
+	channel := ListWebApps(ctx, mockClient, mockSubscriptionsChannel)
+
+	go func() {
+		defer close(mockSubscriptionsChannel)
+		mockSubscriptionsChannel <- AzureWrapper{
+			Data: models.Subscription{},
+		}
+	}()
+	go func() {
+		defer close(mockWebAppChannel)
+		mockWebAppChannel <- azure.WebAppResult{Ok: mockAuthenticated}
+		mockWebAppChannel <- azure.WebAppResult{Ok: mockFunctionApp}
+		mockWebAppChannel <- azure.WebAppResult{Ok: mockUnreadable}
+	}()
+
+	webApps := map[string]models.WebApp{}
+	for i := 0; i < 2; i++ {
+		if result, ok := <-channel; !ok {
+			t.Fatalf("failed to receive from channel")
+		} else if wrapper, ok := result.(AzureWrapper); !ok {
+			t.Errorf("failed type assertion: got %T, want %T", result, AzureWrapper{})
+		} else if data, ok := wrapper.Data.(models.WebApp); !ok {
+			t.Errorf("failed type assertion: got %T, want %T", wrapper.Data, models.WebApp{})
+		} else {
+			webApps[data.Id] = data
+		}
+	}
+
+	if _, ok := <-channel; ok {
+		t.Error("should not have recieved from channel")
+	}
+
+	if authentication := webApps[mockAuthenticated.Id].Authentication; authentication == nil || !authentication.Enabled || !authentication.RequireAuthentication || authentication.UnauthenticatedClientAction != "RedirectToLoginPage" {
+		t.Errorf("got authentication %+v, want enabled and required", authentication)
+	}
+	if authentication := webApps[mockUnreadable.Id].Authentication; authentication != nil {
+		t.Errorf("expected unreadable authentication settings to be omitted, got %+v", authentication)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/go-logr/logr"
+)
+
+// Options shape a collection the way the flags of the azurehound list command they mention do. The zero value
+// collects the whole tenant with none of those flags set.
+type Options struct {
+	// Scope narrows the collection to Entra ID objects or Azure Resource Manager resources; nil collects both
+	Scope *Scope
+	// Kinds narrows the collection to these object kinds; the collectors of the kinds they are read from still run but
+	// their own objects are left out. Nil collects every kind.
+	Kinds []enums.Kind
+	// ManagementGroupIds and SubscriptionIds narrow Azure Resource Manager collection to these management groups and
+	// subscriptions, see --mgmtgroup-id and --subscription-id
+	ManagementGroupIds []string
+	SubscriptionIds    []string
+	// ExcludeSubscriptionClasses leaves out the subscriptions of these classes, see --exclude-subscription-class
+	ExcludeSubscriptionClasses []enums.SubscriptionClass
+	// AuthMethods also collects the authentication methods of every user, see --auth-methods
+	AuthMethods bool
+	// ExcludeDisabled leaves out disabled users and the devices that haven't signed in for InactiveDeviceDays, see
+	// --exclude-disabled and --inactive-device-days
+	ExcludeDisabled    bool
+	InactiveDeviceDays int
+	// RecentCredentialDays is how many days back an app or service principal credential counts as recently added, see
+	// --recent-credential-days
+	RecentCredentialDays int
+	// Since leaves out the Azure resources, and their relationships, that haven't changed since this time unless it is
+	// zero, see --since
+	Since time.Time
+	// Sample stops collecting each kind after this many objects unless it is 0, see --sample
+	Sample int
+	// Checkpoint records how far the checkpointed object kinds were collected and resumes from there, see --checkpoint
+	Checkpoint *Checkpoint
+	// ResumeFromObjectId resumes the checkpointed object kinds after this object, see --resume-from-object-id
+	ResumeFromObjectId string
+	// MissingPermissions are the kinds the credential lacks the Graph permission to list. Their collectors don't run and
+	// the kinds are reported by SkippedKinds.
+	MissingPermissions []enums.Kind
+	// Progress, if set, is told when each collector starts and once its stream is drained
+	Progress Progress
+	// OnError, if set, is called with every error that left part of the collection of a kind incomplete
+	OnError func(kind enums.Kind, err error)
+	// Logger is what the collectors log to; the zero Logger discards their logs
+	Logger logr.Logger
+}
+
+// Progress follows the collectors of a collection as they run
+type Progress interface {
+	// CollectorStarted is called with the name of a collector and the stage of the collection it is part of
+	CollectorStarted(name, stage string)
+	CollectorStopped(name string)
+}
+
+type optionsKey struct{}
+
+type countsKey struct{}
+
+// WithOptions returns a context under which the collectors run with options, counting what they leave out in a Counts
+// of their own. Collect sets them itself; this is for running the collectors of a single kind, e.g. ListUsers, with
+// anything other than the zero Options.
+func WithOptions(ctx context.Context, options Options) context.Context {
+	if options.Logger.GetSink() == nil {
+		options.Logger = logr.Discard()
+	}
+	ctx = context.WithValue(ctx, optionsKey{}, options)
+	ctx = context.WithValue(ctx, countsKey{}, &Counts{})
+	if options.Scope != nil {
+		ctx = withCollectionScope(ctx, *options.Scope)
+	}
+	if options.Kinds != nil {
+		ctx = withCollectionKinds(ctx, options.Kinds)
+	}
+	return ctx
+}
+
+// optionsOf returns the options the collectors run with under ctx
+func optionsOf(ctx context.Context) Options {
+	if options, ok := ctx.Value(optionsKey{}).(Options); ok {
+		return options
+	} else {
+		return Options{Logger: logr.Discard()}
+	}
+}
+
+// logOf returns the logger the collectors log to under ctx
+func logOf(ctx context.Context) logr.Logger {
+	return optionsOf(ctx).Logger
+}
+
+// countsOf returns the Counts the collectors count what they leave out in under ctx, or one nothing reads outside of
+// WithOptions
+func countsOf(ctx context.Context) *Counts {
+	if counts, ok := ctx.Value(countsKey{}).(*Counts); ok {
+		return counts
+	} else {
+		return &Counts{}
+	}
+}
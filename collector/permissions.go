@@ -0,0 +1,103 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/internal"
+)
+
+// The graph permission a credential most likely lacks when listing an object kind is forbidden
+var RequiredPermissions = map[enums.Kind]string{
+	enums.KindAZApp:                              "Application.Read.All",
+	enums.KindAZAppOwner:                         "Application.Read.All",
+	enums.KindAZAppRoleAssignment:                "Application.Read.All",
+	enums.KindAZCrossTenantAccessPolicy:          "Policy.Read.All",
+	enums.KindAZDelegatedAdminRelationship:       "DelegatedAdminRelationship.Read.All",
+	enums.KindAZDevice:                           "Device.Read.All",
+	enums.KindAZDeviceOwner:                      "Device.Read.All",
+	enums.KindAZDeviceUser:                       "Device.Read.All",
+	enums.KindAZGroup:                            "Group.Read.All",
+	enums.KindAZGroupEligibilityScheduleInstance: "PrivilegedEligibilitySchedule.Read.AzureADGroup",
+	enums.KindAZGroupLifecyclePolicy:             "Directory.Read.All",
+	enums.KindAZGroupMember:                      "GroupMember.Read.All",
+	enums.KindAZGroupOwner:                       "GroupMember.Read.All",
+	enums.KindAZNamedLocation:                    "Policy.Read.All",
+	enums.KindAZOAuth2PermissionGrant:            "DelegatedPermissionGrant.Read.All",
+	enums.KindAZRole:                             "RoleManagement.Read.Directory",
+	enums.KindAZRoleAssignment:                   "RoleManagement.Read.Directory",
+	enums.KindAZRoleEligibilityScheduleInstance:  "RoleEligibilitySchedule.Read.Directory",
+	enums.KindAZServicePrincipal:                 "Application.Read.All",
+	enums.KindAZServicePrincipalOwner:            "Application.Read.All",
+	enums.KindAZTenantSettings:                   "Policy.Read.All",
+	enums.KindAZUser:                             "User.Read.All",
+	enums.KindAZUserAuthenticationMethod:         "UserAuthenticationMethod.Read.All",
+}
+
+// The object kinds skipped during the current collection because the credential lacks the permission to list them
+var skippedKinds sync.Map
+
+// permissionSkipped reports whether kind is one of Options.MissingPermissions, in which case the caller should not
+// request it. Kinds skipped this way are reported with those skipped by skipForbidden.
+func permissionSkipped(ctx context.Context, kind enums.Kind) bool {
+	if !internal.Contains(optionsOf(ctx).MissingPermissions, kind) {
+		return false
+	}
+	skippedKinds.Store(kind, struct{}{})
+	return true
+}
+
+// skipForbidden reports whether err means the credential isn't permitted to list kind, in which case the caller should
+// stop collecting it and carry on with everything else. The first permission error for a kind is logged as a warning
+// naming the likely missing permission.
+func skipForbidden(ctx context.Context, err error, kind enums.Kind) bool {
+	if !errors.Is(err, rest.ErrForbidden) {
+		return false
+	} else if _, skipped := skippedKinds.LoadOrStore(kind, struct{}{}); !skipped {
+		if permission, ok := RequiredPermissions[kind]; ok {
+			logOf(ctx).Info("warning: skipping collection due to insufficient permissions; azurehound likely requires the "+permission+" permission to collect it", "kind", kind, "error", err.Error())
+		} else {
+			logOf(ctx).Info("warning: skipping collection due to insufficient permissions", "kind", kind, "error", err.Error())
+		}
+	}
+	return true
+}
+
+// SkippedKinds returns the sorted object kinds skipped during the current collection
+func SkippedKinds() []string {
+	kinds := []string{}
+	skippedKinds.Range(func(key, _ interface{}) bool {
+		kinds = append(kinds, string(key.(enums.Kind)))
+		return true
+	})
+	sort.Strings(kinds)
+	return kinds
+}
+
+func resetSkippedKinds() {
+	skippedKinds.Range(func(key, _ interface{}) bool {
+		skippedKinds.Delete(key)
+		return true
+	})
+}
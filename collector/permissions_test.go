@@ -0,0 +1,75 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/client/rest"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSkipForbidden(t *testing.T) {
+	resetSkippedKinds()
+	defer resetSkippedKinds()
+
+	forbidden := fmt.Errorf("%w: map[error:map[code:Authorization_RequestDenied]]", rest.ErrForbidden)
+
+	if skipForbidden(context.Background(), nil, enums.KindAZUser) {
+		t.Error("expected a nil error not to be skipped")
+	} else if skipForbidden(context.Background(), fmt.Errorf("I'm an error"), enums.KindAZUser) {
+		t.Error("expected a non-permission error not to be skipped")
+	} else if !skipForbidden(context.Background(), forbidden, enums.KindAZUser) {
+		t.Error("expected a permission error to be skipped")
+	} else if !skipForbidden(context.Background(), forbidden, enums.KindAZDevice) || !skipForbidden(context.Background(), forbidden, enums.KindAZDevice) {
+		t.Error("expected a permission error to be skipped")
+	}
+
+	want := []string{string(enums.KindAZDevice), string(enums.KindAZUser)}
+	if actual := SkippedKinds(); !reflect.DeepEqual(actual, want) {
+		t.Errorf("got %v, want %v", actual, want)
+	}
+
+	resetSkippedKinds()
+	if actual := SkippedKinds(); len(actual) != 0 {
+		t.Errorf("got %v, want no skipped kinds", actual)
+	}
+}
+
+func TestListUsersPermissionSkipped(t *testing.T) {
+	resetSkippedKinds()
+	defer resetSkippedKinds()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no listing is expected of the mock
+	mockClient := mocks.NewMockAzureClient(ctrl)
+	ctx := WithOptions(context.Background(), Options{MissingPermissions: []enums.Kind{enums.KindAZUser}})
+
+	if _, ok := <-ListUsers(ctx, mockClient); ok {
+		t.Error("expected channel to close without listing users")
+	} else if skipped := SkippedKinds(); !reflect.DeepEqual(skipped, []string{string(enums.KindAZUser)}) {
+		t.Errorf("got skipped kinds %v, want %v", skipped, enums.KindAZUser)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"strings"
+)
+
+// The stages of a collection reported to BloodHound Enterprise; collectors run concurrently so several can be current
+const (
+	stageAzureAD         = "ad objects"
+	stageAzureRM         = "subscriptions"
+	stageRoleAssignments = "role assignments"
+)
+
+type stageKey struct{}
+
+// withCollectionStage returns a context whose collectors report themselves as part of stage
+func withCollectionStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, stageKey{}, stage)
+}
+
+// collectorStage returns the stage of the named collector run with ctx; role assignments are a stage of their own
+// whichever part of the tenant they belong to
+func collectorStage(ctx context.Context, name string) string {
+	if strings.HasSuffix(name, "role-assignments") {
+		return stageRoleAssignments
+	} else if stage, ok := ctx.Value(stageKey{}).(string); ok {
+		return stage
+	} else {
+		return stageAzureAD
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// sampleKinds passes at most limit items of each kind. Collectors already stop paging at the limit but some emit a
+// kind once per parent object, e.g. role assignments of every subscription, so the stream is capped as well.
+func sampleKinds(ctx context.Context, in <-chan interface{}, limit int) <-chan interface{} {
+	var (
+		counts = map[enums.Kind]int{}
+		run    = countsOf(ctx)
+	)
+	return pipeline.Filter(ctx.Done(), in, func(item interface{}) bool {
+		if wrapper, ok := item.(kinded); !ok {
+			return true
+		} else if counts[wrapper.kind()]++; counts[wrapper.kind()] <= limit {
+			return true
+		} else {
+			atomic.AddInt64(&run.SampledOut, 1)
+			return false
+		}
+	})
+}
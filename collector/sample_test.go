@@ -0,0 +1,57 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func TestSampleKinds(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		for i := 0; i < 3; i++ {
+			in <- AzureWrapper{Kind: enums.KindAZUser, Data: models.User{}}
+			in <- AzureWrapper{Kind: enums.KindAZGroup, Data: models.Group{}}
+		}
+		in <- "not kinded"
+	}()
+
+	ctx := WithOptions(context.Background(), Options{})
+	counts := map[enums.Kind]int{}
+	others := 0
+	for item := range sampleKinds(ctx, in, 2) {
+		if wrapper, ok := item.(AzureWrapper); ok {
+			counts[wrapper.Kind]++
+		} else {
+			others++
+		}
+	}
+
+	if counts[enums.KindAZUser] != 2 || counts[enums.KindAZGroup] != 2 {
+		t.Errorf("got %v, want 2 of each kind", counts)
+	} else if others != 1 {
+		t.Errorf("got %v other items, want 1", others)
+	} else if dropped := countsOf(ctx).SampledOut; dropped != 2 {
+		t.Errorf("got %v dropped, want %v", dropped, 2)
+	}
+}
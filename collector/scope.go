@@ -0,0 +1,260 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// Scope is the part of a tenant a collection enumerates: Entra ID objects, Azure Resource Manager resources or both
+type Scope struct {
+	AzureAD bool
+	AzureRM bool
+}
+
+// FullScope enumerates the whole tenant
+var FullScope = Scope{AzureAD: true, AzureRM: true}
+
+type scopeKey struct{}
+
+// withCollectionScope returns a context under which Collect only enumerates scope
+func withCollectionScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// collectionScopeOf returns the scope of the collection run with ctx, which is everything unless narrowed with
+// withCollectionScope
+func collectionScopeOf(ctx context.Context) Scope {
+	if scope, ok := ctx.Value(scopeKey{}).(Scope); ok {
+		return scope
+	} else {
+		return FullScope
+	}
+}
+
+// azureADKinds are the kinds AzureAD collects; every other kind comes from Azure Resource Manager
+var azureADKinds = map[enums.Kind]bool{
+	enums.KindAZApp:                              true,
+	enums.KindAZAppOwner:                         true,
+	enums.KindAZAppRoleAssignment:                true,
+	enums.KindAZCrossTenantAccessPolicy:          true,
+	enums.KindAZDelegatedAdminRelationship:       true,
+	enums.KindAZDevice:                           true,
+	enums.KindAZDeviceOwner:                      true,
+	enums.KindAZDeviceUser:                       true,
+	enums.KindAZGroup:                            true,
+	enums.KindAZGroupEligibilityScheduleInstance: true,
+	enums.KindAZGroupLifecyclePolicy:             true,
+	enums.KindAZGroupMember:                      true,
+	enums.KindAZGroupOwner:                       true,
+	enums.KindAZNamedLocation:                    true,
+	enums.KindAZOAuth2PermissionGrant:            true,
+	enums.KindAZOwnerRelationship:                true,
+	enums.KindAZRole:                             true,
+	enums.KindAZRoleAssignment:                   true,
+	enums.KindAZRoleEligibilityScheduleInstance:  true,
+	enums.KindAZServicePrincipal:                 true,
+	enums.KindAZServicePrincipalOwner:            true,
+	enums.KindAZTenant:                           true,
+	enums.KindAZTenantSettings:                   true,
+	enums.KindAZUser:                             true,
+	enums.KindAZUserAuthenticationMethod:         true,
+}
+
+// Narrow narrows the scope to the parts of the tenant that hold kinds
+func (s Scope) Narrow(kinds []enums.Kind) Scope {
+	var narrowed Scope
+	for _, kind := range kinds {
+		if azureADKinds[kind] {
+			narrowed.AzureAD = true
+		} else {
+			narrowed.AzureRM = true
+		}
+	}
+	return Scope{AzureAD: s.AzureAD && narrowed.AzureAD, AzureRM: s.AzureRM && narrowed.AzureRM}
+}
+
+// collectionKinds are the kinds selected for a collection and the kinds whose collectors have to run to produce them
+type collectionKinds struct {
+	selected map[enums.Kind]bool
+	needed   map[enums.Kind]bool
+	// whether subscriptions are selected by the management groups they descend from
+	byManagementGroup bool
+}
+
+type kindsKey struct{}
+
+// withCollectionKinds returns a context under which Collect only emits kinds. The collectors of the kinds they are
+// read from, e.g. groups for group members, still run but their own items are dropped unless selected too.
+func withCollectionKinds(ctx context.Context, kinds []enums.Kind) context.Context {
+	selection := collectionKinds{
+		selected:          make(map[enums.Kind]bool),
+		needed:            make(map[enums.Kind]bool),
+		byManagementGroup: len(optionsOf(ctx).ManagementGroupIds) != 0,
+	}
+	for _, kind := range kinds {
+		selection.selected[kind] = true
+		selection.need(kind)
+	}
+	return context.WithValue(ctx, kindsKey{}, selection)
+}
+
+func (s collectionKinds) need(kind enums.Kind) {
+	s.needed[kind] = true
+	if kind == enums.KindAZOwnerRelationship {
+		s.need(enums.KindAZAppOwner)
+		s.need(enums.KindAZServicePrincipalOwner)
+	} else if parent, ok := ParentKinds[kind]; ok {
+		s.need(parent)
+	} else if parent, ok := resourceCollectorParents[kind]; ok {
+		s.need(parent)
+	} else if kind == enums.KindAZSubscription && s.byManagementGroup {
+		// the subscriptions are looked up among the descendants of Options.ManagementGroupIds
+		s.need(enums.KindAZManagementGroupDescendant)
+	}
+}
+
+// The kind an azure resource collector enumerates its objects from
+var resourceCollectorParents = map[enums.Kind]enums.Kind{
+	enums.KindAZAutomationAccount:               enums.KindAZSubscription,
+	enums.KindAZAutomationAccountRoleAssignment: enums.KindAZAutomationAccount,
+	enums.KindAZContainerRegistry:               enums.KindAZSubscription,
+	enums.KindAZContainerRegistryRoleAssignment: enums.KindAZContainerRegistry,
+	enums.KindAZFunctionApp:                     enums.KindAZSubscription,
+	enums.KindAZFunctionAppRoleAssignment:       enums.KindAZFunctionApp,
+	enums.KindAZKeyVault:                        enums.KindAZSubscription,
+	enums.KindAZKeyVaultAccessPolicy:            enums.KindAZKeyVault,
+	enums.KindAZKeyVaultContributor:             enums.KindAZKeyVaultRoleAssignment,
+	enums.KindAZKeyVaultKVContributor:           enums.KindAZKeyVaultRoleAssignment,
+	enums.KindAZKeyVaultOwner:                   enums.KindAZKeyVaultRoleAssignment,
+	enums.KindAZKeyVaultRoleAssignment:          enums.KindAZKeyVault,
+	enums.KindAZKeyVaultUserAccessAdmin:         enums.KindAZKeyVaultRoleAssignment,
+	enums.KindAZLighthouseDelegation:            enums.KindAZSubscription,
+	enums.KindAZLogicApp:                        enums.KindAZSubscription,
+	enums.KindAZLogicAppConnection:              enums.KindAZLogicApp,
+	enums.KindAZLogicAppManagedIdentity:         enums.KindAZLogicApp,
+	enums.KindAZLogicAppRoleAssignment:          enums.KindAZLogicApp,
+	enums.KindAZManagedCluster:                  enums.KindAZSubscription,
+	enums.KindAZManagedClusterRoleAssignment:    enums.KindAZManagedCluster,
+	enums.KindAZManagementGroupDescendant:       enums.KindAZManagementGroup,
+	enums.KindAZManagementGroupOwner:            enums.KindAZManagementGroupRoleAssignment,
+	enums.KindAZManagementGroupRoleAssignment:   enums.KindAZManagementGroup,
+	enums.KindAZManagementGroupUserAccessAdmin:  enums.KindAZManagementGroupRoleAssignment,
+	enums.KindAZResourceGroup:                   enums.KindAZSubscription,
+	enums.KindAZResourceGroupOwner:              enums.KindAZResourceGroupRoleAssignment,
+	enums.KindAZResourceGroupRoleAssignment:     enums.KindAZResourceGroup,
+	enums.KindAZResourceGroupUserAccessAdmin:    enums.KindAZResourceGroupRoleAssignment,
+	enums.KindAZSubscriptionOwner:               enums.KindAZSubscriptionRoleAssignment,
+	enums.KindAZSubscriptionRoleAssignment:      enums.KindAZSubscription,
+	enums.KindAZSubscriptionUserAccessAdmin:     enums.KindAZSubscriptionRoleAssignment,
+	enums.KindAZVM:                              enums.KindAZSubscription,
+	enums.KindAZVMAdminLogin:                    enums.KindAZVMRoleAssignment,
+	enums.KindAZVMAvereContributor:              enums.KindAZVMRoleAssignment,
+	enums.KindAZVMContributor:                   enums.KindAZVMRoleAssignment,
+	enums.KindAZVMManagedIdentity:               enums.KindAZVM,
+	enums.KindAZVMOwner:                         enums.KindAZVMRoleAssignment,
+	enums.KindAZVMRoleAssignment:                enums.KindAZVM,
+	enums.KindAZVMScaleSet:                      enums.KindAZSubscription,
+	enums.KindAZVMScaleSetManagedIdentity:       enums.KindAZVMScaleSet,
+	enums.KindAZVMScaleSetRoleAssignment:        enums.KindAZVMScaleSet,
+	enums.KindAZVMUserAccessAdmin:               enums.KindAZVMRoleAssignment,
+	enums.KindAZWebApp:                          enums.KindAZSubscription,
+	enums.KindAZWebAppRoleAssignment:            enums.KindAZWebApp,
+}
+
+// collectionKindsOf returns the kinds selected for the collection run with ctx, or false if every kind is collected
+func collectionKindsOf(ctx context.Context) (collectionKinds, bool) {
+	selection, ok := ctx.Value(kindsKey{}).(collectionKinds)
+	return selection, ok
+}
+
+// skipCollector reports whether the collector of kind should not run under ctx, either because neither kind nor
+// anything read from it was selected with withCollectionKinds or because the Graph token lacks the permission to
+// list it
+func skipCollector(ctx context.Context, kind enums.Kind) bool {
+	if selection, ok := collectionKindsOf(ctx); ok && !selection.needed[kind] {
+		return true
+	}
+	return permissionSkipped(ctx, kind)
+}
+
+// The properties the collector of each kind lists when its objects are only read by the collectors of the kinds
+// selected with withCollectionKinds: their ids and whatever those collectors read from them. The objects are dropped
+// by filterKinds so nothing else sees these partial objects. Collectors without an entry list their objects in full.
+var minimalSelect = map[enums.Kind][]string{
+	enums.KindAZApp:              {"id", "appId"},
+	enums.KindAZDevice:           {"id"},
+	enums.KindAZGroup:            {"id"},
+	enums.KindAZServicePrincipal: {"id", "appId", "appRoles"},
+	enums.KindAZUser:             {"id"},
+}
+
+// collectorSelect returns the properties the collector of kind lists under ctx: its minimal projection if kind is only
+// collected for the kinds read from it, otherwise selectCols
+func collectorSelect(ctx context.Context, kind enums.Kind, selectCols []string) []string {
+	if selection, ok := collectionKindsOf(ctx); ok && !selection.selected[kind] {
+		if minimal, ok := minimalSelect[kind]; ok {
+			return minimal
+		}
+	}
+	return selectCols
+}
+
+// skipInput returns in unless the collector of kind is skipped under ctx, in which case it returns a stream that
+// closes once in is drained, so the collector sees nothing to collect for while the collectors teed from in still
+// get everything
+func skipInput[T any](ctx context.Context, kind enums.Kind, in <-chan T) <-chan T {
+	if !skipCollector(ctx, kind) {
+		return in
+	}
+	return pipeline.Filter(ctx.Done(), in, func(T) bool { return false })
+}
+
+// filterKinds drops the items of kinds that weren't selected for the collection run with ctx
+func filterKinds(ctx context.Context, in <-chan interface{}) <-chan interface{} {
+	selection, ok := collectionKindsOf(ctx)
+	if !ok {
+		return in
+	}
+	return pipeline.Filter(ctx.Done(), in, func(item interface{}) bool {
+		if wrapper, ok := item.(kinded); !ok {
+			return true
+		} else {
+			return selection.selected[wrapper.kind()]
+		}
+	})
+}
+
+// ParentKinds are the Entra ID kinds the collectors of other kinds enumerate their objects from, which leaves those
+// collectors nothing to collect when the parent kind is skipped
+var ParentKinds = map[enums.Kind]enums.Kind{
+	enums.KindAZAppOwner:                         enums.KindAZApp,
+	enums.KindAZAppRoleAssignment:                enums.KindAZServicePrincipal,
+	enums.KindAZDeviceOwner:                      enums.KindAZDevice,
+	enums.KindAZDeviceUser:                       enums.KindAZDevice,
+	enums.KindAZGroupEligibilityScheduleInstance: enums.KindAZGroup,
+	enums.KindAZGroupMember:                      enums.KindAZGroup,
+	enums.KindAZGroupOwner:                       enums.KindAZGroup,
+	enums.KindAZRoleAssignment:                   enums.KindAZRole,
+	enums.KindAZRoleEligibilityScheduleInstance:  enums.KindAZRole,
+	enums.KindAZServicePrincipalOwner:            enums.KindAZServicePrincipal,
+	enums.KindAZUserAuthenticationMethod:         enums.KindAZUser,
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCollectionKinds(t *testing.T) {
+	ctx := withCollectionKinds(context.Background(), []enums.Kind{enums.KindAZGroupMember, enums.KindAZOwnerRelationship})
+
+	for kind, skip := range map[enums.Kind]bool{
+		enums.KindAZGroupMember:           false,
+		enums.KindAZGroup:                 false,
+		enums.KindAZAppOwner:              false,
+		enums.KindAZApp:                   false,
+		enums.KindAZServicePrincipalOwner: false,
+		enums.KindAZUser:                  true,
+		enums.KindAZGroupOwner:            true,
+	} {
+		if got := skipCollector(ctx, kind); got != skip {
+			t.Errorf("%s: got skip %v, want %v", kind, got, skip)
+		}
+	}
+	if skipCollector(context.Background(), enums.KindAZUser) {
+		t.Error("expected every collector to run without selected kinds")
+	}
+
+	in := make(chan interface{}, 5)
+	in <- AzureWrapper{Kind: enums.KindAZGroup}
+	in <- AzureWrapper{Kind: enums.KindAZGroupMember}
+	in <- NewAzureWrapper(enums.KindAZKeyVaultOwner, models.KeyVaultOwners{})
+	in <- NewAzureWrapper(enums.KindAZAppOwner, models.AppOwners{})
+	in <- "not wrapped"
+	close(in)
+
+	var kinds []interface{}
+	for item := range filterKinds(ctx, in) {
+		if wrapper, ok := item.(kinded); ok {
+			kinds = append(kinds, wrapper.kind())
+		} else {
+			kinds = append(kinds, item)
+		}
+	}
+	if len(kinds) != 2 || kinds[0] != enums.KindAZGroupMember || kinds[1] != "not wrapped" {
+		t.Errorf("got %v, want the group member and the unwrapped item", kinds)
+	}
+}
+
+func TestCollectionKindsResourceManager(t *testing.T) {
+	ctx := withCollectionKinds(context.Background(), []enums.Kind{enums.KindAZKeyVaultOwner})
+
+	for kind, skip := range map[enums.Kind]bool{
+		enums.KindAZKeyVaultOwner:          false,
+		enums.KindAZKeyVaultRoleAssignment: false,
+		enums.KindAZKeyVault:               false,
+		enums.KindAZSubscription:           false,
+		enums.KindAZKeyVaultAccessPolicy:   true,
+		enums.KindAZVM:                     true,
+		enums.KindAZManagementGroup:        true,
+	} {
+		if got := skipCollector(ctx, kind); got != skip {
+			t.Errorf("%s: got skip %v, want %v", kind, got, skip)
+		}
+	}
+
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		in <- AzureWrapper{Kind: enums.KindAZVM}
+		in <- AzureWrapper{Kind: enums.KindAZVM}
+	}()
+	for item := range skipInput(ctx, enums.KindAZVMRoleAssignment, in) {
+		t.Errorf("expected a skipped collector to get no input, got %v", item)
+	}
+	if _, ok := <-in; ok {
+		t.Error("expected the input of a skipped collector to be drained")
+	}
+}
+
+func TestCollectionKindsMinimal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx        = withCollectionKinds(context.Background(), []enums.Kind{enums.KindAZGroupMember})
+		mockClient = mocks.NewMockAzureClient(ctrl)
+		groups     = make(chan azure.GroupResult)
+	)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	// users aren't read by group members, so they aren't listed at all
+	mockClient.EXPECT().ListAzureADUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockClient.EXPECT().ListAzureADGroups(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), []string{"id"}).Return(groups)
+	close(groups)
+
+	if parent := ParentKinds[enums.KindAZGroupMember]; parent != enums.KindAZGroup {
+		t.Errorf("got %v as the parent of group members, want %v", parent, enums.KindAZGroup)
+	}
+	for range ListUsers(ctx, mockClient) {
+	}
+	for range ListGroups(ctx, mockClient) {
+	}
+
+	if got := collectorSelect(ctx, enums.KindAZGroup, nil); len(got) != 1 || got[0] != "id" {
+		t.Errorf("got %v, want groups listed by id only", got)
+	} else if got := collectorSelect(context.Background(), enums.KindAZUser, userSelect); len(got) != len(userSelect) {
+		t.Errorf("got %v, want every user property without selected kinds", got)
+	} else if got := collectorSelect(withCollectionKinds(context.Background(), []enums.Kind{enums.KindAZUser}), enums.KindAZUser, userSelect); len(got) != len(userSelect) {
+		t.Errorf("got %v, want every property of a selected kind", got)
+	} else if got := collectorSelect(ctx, enums.KindAZRole, nil); got != nil {
+		t.Errorf("got %v, want roles, which don't support it, listed as usual", got)
+	}
+}
+
+func TestCollectionKindsMinimalUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx        = withCollectionKinds(context.Background(), []enums.Kind{enums.KindAZUserAuthenticationMethod})
+		mockClient = mocks.NewMockAzureClient(ctrl)
+		users      = make(chan azure.UserResult, 1)
+	)
+	mockClient.EXPECT().TenantInfo().Return(azure.Tenant{}).AnyTimes()
+	mockClient.EXPECT().ListAzureADUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), []string{"id"}).Return(users)
+	users <- azure.UserResult{Ok: azure.User{DirectoryObject: azure.DirectoryObject{Id: "user-id"}}}
+	close(users)
+
+	count := 0
+	for range ListUsers(ctx, mockClient) {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("got %v users, want 1 for the authentication methods collector", count)
+	}
+}
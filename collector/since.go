@@ -0,0 +1,144 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// changedResourceId returns the id of the ARM resources whose type reports a change time. Subscriptions, resource
+// groups, management groups and role assignments don't and are always collected in full.
+func changedResourceId(item interface{}) (string, bool) {
+	wrapper, ok := item.(AzureWrapper)
+	if !ok {
+		return "", false
+	}
+
+	switch data := wrapper.Data.(type) {
+	case models.AutomationAccount:
+		return data.Id, true
+	case models.ContainerRegistry:
+		return data.Id, true
+	case models.FunctionApp:
+		return data.Id, true
+	case models.KeyVault:
+		return data.Id, true
+	case models.LogicApp:
+		return data.Id, true
+	case models.ManagedCluster:
+		return data.Id, true
+	case models.VirtualMachine:
+		return data.Id, true
+	case models.VMScaleSet:
+		return data.Id, true
+	case models.WebApp:
+		return data.Id, true
+	default:
+		return "", false
+	}
+}
+
+// resourceChanges lists the resources of each subscription with their change times the first time one of them is
+// looked up and remembers the ones unchanged since a point in time. Resources are only ever skipped when ARM reported
+// them as unchanged; missing change times and failed listings fall back to collecting everything.
+type resourceChanges struct {
+	client        client.AzureClient
+	since         time.Time
+	mutex         sync.Mutex
+	subscriptions map[string]*subscriptionChanges
+}
+
+type subscriptionChanges struct {
+	once      sync.Once
+	unchanged map[string]bool
+}
+
+func newResourceChanges(client client.AzureClient, since time.Time) *resourceChanges {
+	return &resourceChanges{
+		client:        client,
+		since:         since,
+		subscriptions: make(map[string]*subscriptionChanges),
+	}
+}
+
+func (s *resourceChanges) isUnchanged(ctx context.Context, resourceId string) bool {
+	// /subscriptions/{subscriptionId}/resourceGroups/...
+	parts := strings.Split(resourceId, "/")
+	if len(parts) < 3 || !strings.EqualFold(parts[1], "subscriptions") {
+		return false
+	}
+	subscriptionId := strings.ToLower(parts[2])
+
+	s.mutex.Lock()
+	subscription, ok := s.subscriptions[subscriptionId]
+	if !ok {
+		subscription = &subscriptionChanges{}
+		s.subscriptions[subscriptionId] = subscription
+	}
+	s.mutex.Unlock()
+
+	subscription.once.Do(func() {
+		subscription.unchanged = s.listUnchanged(ctx, subscriptionId)
+	})
+	return subscription.unchanged[strings.ToLower(resourceId)]
+}
+
+func (s *resourceChanges) listUnchanged(ctx context.Context, subscriptionId string) map[string]bool {
+	unchanged := make(map[string]bool)
+	for item := range s.client.ListAzureResources(ctx, subscriptionId, "changedTime") {
+		if item.Error != nil {
+			logOf(ctx).Error(item.Error, "unable to list resource change times, collecting all resources of this subscription", "subscriptionId", subscriptionId)
+			return nil
+		} else if item.Ok.ChangedTime == "" {
+			continue
+		} else if changed, err := time.Parse(time.RFC3339, item.Ok.ChangedTime); err != nil {
+			logOf(ctx).V(1).Info("unable to parse resource change time", "resourceId", item.Ok.Id, "changedTime", item.Ok.ChangedTime)
+		} else if changed.Before(s.since) {
+			unchanged[strings.ToLower(item.Ok.Id)] = true
+		}
+	}
+	logOf(ctx).V(1).Info("finished listing resource change times", "subscriptionId", subscriptionId, "unchanged", len(unchanged))
+	return unchanged
+}
+
+// onlyChanged drops the ARM resources that did not change since Options.Since, before their relationships are
+// collected.
+func onlyChanged(ctx context.Context, changes *resourceChanges, in <-chan interface{}) <-chan interface{} {
+	if changes == nil {
+		return in
+	}
+	counts := countsOf(ctx)
+	return pipeline.Filter(ctx.Done(), in, func(item interface{}) bool {
+		if id, ok := changedResourceId(item); !ok {
+			return true
+		} else if changes.isUnchanged(ctx, id) {
+			atomic.AddInt64(&counts.SkippedUnchanged, 1)
+			return false
+		} else {
+			return true
+		}
+	})
+}
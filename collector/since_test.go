@@ -0,0 +1,81 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/client/mocks"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/golang/mock/gomock"
+)
+
+func TestOnlyChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := WithOptions(context.Background(), Options{})
+
+	var (
+		mockClient = mocks.NewMockAzureClient(ctrl)
+		since      = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		resources  = make(chan azure.GenericResourceResult, 3)
+		failed     = make(chan azure.GenericResourceResult, 1)
+		in         = make(chan interface{}, 6)
+	)
+
+	resources <- azure.GenericResourceResult{Ok: azure.GenericResource{Entity: azure.Entity{Id: "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/old"}, ChangedTime: "2024-01-01T12:00:00.1234567Z"}}
+	resources <- azure.GenericResourceResult{Ok: azure.GenericResource{Entity: azure.Entity{Id: "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/new"}, ChangedTime: "2024-01-03T12:00:00Z"}}
+	resources <- azure.GenericResourceResult{Ok: azure.GenericResource{Entity: azure.Entity{Id: "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"}}}
+	close(resources)
+	failed <- azure.GenericResourceResult{Error: fmt.Errorf("I'm an error")}
+	close(failed)
+
+	mockClient.EXPECT().ListAzureResources(gomock.Any(), "sub1", "changedTime").Return(resources).Times(1)
+	mockClient.EXPECT().ListAzureResources(gomock.Any(), "sub2", "changedTime").Return(failed).Times(1)
+
+	in <- AzureWrapper{Kind: enums.KindAZKeyVault, Data: models.KeyVault{KeyVault: azure.KeyVault{Entity: azure.Entity{Id: "/subscriptions/SUB1/resourceGroups/RG/providers/Microsoft.KeyVault/vaults/OLD"}}}}
+	in <- AzureWrapper{Kind: enums.KindAZKeyVault, Data: models.KeyVault{KeyVault: azure.KeyVault{Entity: azure.Entity{Id: "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/new"}}}}
+	in <- AzureWrapper{Kind: enums.KindAZVM, Data: models.VirtualMachine{VirtualMachine: azure.VirtualMachine{Entity: azure.Entity{Id: "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"}}}}
+	in <- AzureWrapper{Kind: enums.KindAZKeyVault, Data: models.KeyVault{KeyVault: azure.KeyVault{Entity: azure.Entity{Id: "/subscriptions/sub2/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/old"}}}}
+	in <- AzureWrapper{Kind: enums.KindAZSubscription, Data: models.Subscription{}}
+	in <- AzureWrapper{Kind: enums.KindAZKeyVault, Data: models.KeyVault{KeyVault: azure.KeyVault{Entity: azure.Entity{Id: "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/old"}}}}
+	close(in)
+
+	count := 0
+	for range onlyChanged(ctx, newResourceChanges(mockClient, since), in) {
+		count++
+	}
+
+	if count != 4 {
+		t.Errorf("got %v items, want 4", count)
+	} else if skipped := countsOf(ctx).SkippedUnchanged; skipped != 2 {
+		t.Errorf("got %v skipped resources, want 2", skipped)
+	}
+}
+
+func TestOnlyChangedDisabled(t *testing.T) {
+	in := make(chan interface{})
+	if out := onlyChanged(context.Background(), nil, in); out != in {
+		t.Error("expected the input stream to be returned unchanged")
+	}
+}
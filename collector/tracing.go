@@ -0,0 +1,65 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/bloodhoundad/azurehound/v2/tracing"
+)
+
+// traceCollector runs collect under a span for the collector of the given kind. The span ends once the collector's
+// stream is drained and records the number of items it produced. The collector is also reported to Options.Progress,
+// if set, as running until then.
+func traceCollector[T any](ctx context.Context, kind string, collect func(ctx context.Context) <-chan T) <-chan T {
+	progress := optionsOf(ctx).Progress
+	if !tracing.Enabled() && progress == nil {
+		return collect(ctx)
+	}
+
+	var (
+		spanCtx, span = tracing.Start(ctx, "collect "+kind, "kind", kind)
+		in            = collect(spanCtx)
+		out           = make(chan T)
+	)
+
+	if progress != nil {
+		progress.CollectorStarted(kind, collectorStage(ctx, kind))
+	}
+	go func() {
+		defer close(out)
+		defer span.End()
+		if progress != nil {
+			defer progress.CollectorStopped(kind)
+		}
+
+		count := 0
+		for item := range in {
+			select {
+			case out <- item:
+				count++
+			case <-ctx.Done():
+				span.SetAttributes("count", count)
+				span.SetError(ctx.Err())
+				return
+			}
+		}
+		span.SetAttributes("count", count)
+	}()
+	return out
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestTraceCollectorDisabled(t *testing.T) {
+	ctx := context.Background()
+	in := make(chan interface{})
+	if out := traceCollector(ctx, "users", func(ctx context.Context) <-chan interface{} { return in }); out != (<-chan interface{})(in) {
+		t.Error("expected the collector stream to be returned as-is while tracing is disabled")
+	}
+}
+
+// recordedProgress records the collectors it is told about as running, with their stage, until they stop
+type recordedProgress struct {
+	mutex   sync.Mutex
+	running map[string]string
+}
+
+func (s *recordedProgress) CollectorStarted(name, stage string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running[name] = stage
+}
+
+func (s *recordedProgress) CollectorStopped(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.running, name)
+}
+
+func (s *recordedProgress) stage(name string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stage, ok := s.running[name]
+	return stage, ok
+}
+
+func TestTraceCollectorProgress(t *testing.T) {
+	var (
+		progress = &recordedProgress{running: map[string]string{}}
+		ctx      = WithOptions(context.Background(), Options{Progress: progress})
+		in       = make(chan interface{})
+		users    = traceCollector(ctx, "users", func(ctx context.Context) <-chan interface{} { return in })
+		pending  = make(chan interface{})
+		_        = traceCollector(withCollectionStage(ctx, stageAzureRM), "subscription-role-assignments", func(ctx context.Context) <-chan interface{} { return pending })
+	)
+	defer close(pending)
+
+	if stage, ok := progress.stage("users"); !ok || stage != stageAzureAD {
+		t.Errorf("got stage %q, want users running in %q", stage, stageAzureAD)
+	} else if stage, ok := progress.stage("subscription-role-assignments"); !ok || stage != stageRoleAssignments {
+		t.Errorf("got stage %q, want role assignments running in %q", stage, stageRoleAssignments)
+	}
+
+	close(in)
+	for range users {
+	}
+	if _, ok := progress.stage("users"); ok {
+		t.Error("expected users to stop once their stream was drained")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collector
+
+import (
+	"path"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/constants"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+func unique(collection []string) []string {
+	keys := make(map[string]bool)
+	list := []string{}
+	for _, item := range collection {
+		if _, found := keys[item]; !found {
+			keys[item] = true
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// deprecated: use azureWrapper instead
+type AzureWrapper struct {
+	Kind             enums.Kind  `json:"kind"`
+	Data             interface{} `json:"data"`
+	CollectedAt      string      `json:"collectedAt,omitempty"`
+	CollectorVersion string      `json:"collectorVersion,omitempty"`
+}
+
+type azureWrapper[T any] struct {
+	Kind             enums.Kind `json:"kind"`
+	Data             T          `json:"data"`
+	CollectedAt      string     `json:"collectedAt,omitempty"`
+	CollectorVersion string     `json:"collectorVersion,omitempty"`
+}
+
+// Wrapper is implemented by the wrappers of collected objects that the collectors send
+type Wrapper interface {
+	// Stamp returns a copy of the wrapper recording when it was collected and by which version of azurehound
+	Stamp(collectedAt time.Time) any
+	// Untyped returns the wrapper with its data as an interface{}
+	Untyped() AzureWrapper
+}
+
+// kinded is a Wrapper whose kind can be read without copying its data
+type kinded interface {
+	Wrapper
+	kind() enums.Kind
+}
+
+func (s AzureWrapper) kind() enums.Kind {
+	return s.Kind
+}
+
+func (s AzureWrapper) Untyped() AzureWrapper {
+	return s
+}
+
+func (s AzureWrapper) Stamp(collectedAt time.Time) any {
+	s.CollectedAt = collectedAt.UTC().Format(time.RFC3339)
+	s.CollectorVersion = constants.Version
+	return s
+}
+
+func (s azureWrapper[T]) kind() enums.Kind {
+	return s.Kind
+}
+
+func (s azureWrapper[T]) Untyped() AzureWrapper {
+	return AzureWrapper{Kind: s.Kind, Data: s.Data, CollectedAt: s.CollectedAt, CollectorVersion: s.CollectorVersion}
+}
+
+func (s azureWrapper[T]) Stamp(collectedAt time.Time) any {
+	s.CollectedAt = collectedAt.UTC().Format(time.RFC3339)
+	s.CollectorVersion = constants.Version
+	return s
+}
+
+func NewAzureWrapper[T any](kind enums.Kind, data T) azureWrapper[T] {
+	return azureWrapper[T]{
+		Kind: kind,
+		Data: data,
+	}
+}
+
+func kvRoleAssignmentFilter(roleId string) func(models.KeyVaultRoleAssignment) bool {
+	return func(ra models.KeyVaultRoleAssignment) bool {
+		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+	}
+}
+
+func vmRoleAssignmentFilter(roleId string) func(models.VirtualMachineRoleAssignment) bool {
+	return func(ra models.VirtualMachineRoleAssignment) bool {
+		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+	}
+}
+
+func rgRoleAssignmentFilter(roleId string) func(models.ResourceGroupRoleAssignment) bool {
+	return func(ra models.ResourceGroupRoleAssignment) bool {
+		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+	}
+}
+
+func mgmtGroupRoleAssignmentFilter(roleId string) func(models.ManagementGroupRoleAssignment) bool {
+	return func(ra models.ManagementGroupRoleAssignment) bool {
+		return path.Base(ra.RoleAssignment.Properties.RoleDefinitionId) == roleId
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -58,8 +59,12 @@ type Options struct {
 	EnvPrefix   string
 }
 
+// The names of all registered configs, across commands; anything else in a config file is a mistake
+var known = map[string]struct{}{}
+
 func Init(cmd *cobra.Command, configs []Config) {
 	for _, config := range configs {
+		known[config.Name] = struct{}{}
 		viper.SetDefault(config.Name, config.Default)
 		if cmd != nil {
 			if config.Persistent {
@@ -90,7 +95,10 @@ func setFlag(config Config, flagSet *pflag.FlagSet, markRequired func(string) er
 	}
 }
 
-func LoadValues(cmd *cobra.Command, options Options) {
+// LoadValues resolves config values with the precedence flag > environment variable > config file > default. The
+// config file may be JSON, YAML or TOML; keys in it that don't match a registered config are reported as an error so
+// typos don't go unnoticed.
+func LoadValues(cmd *cobra.Command, options Options) error {
 	if cmd != nil {
 		viper.BindPFlags(cmd.Flags())
 	}
@@ -117,8 +125,10 @@ func LoadValues(cmd *cobra.Command, options Options) {
 		case viper.ConfigFileNotFoundError, *os.PathError:
 			fmt.Fprintf(os.Stderr, "No configuration file located at %s\n", options.ConfigFile)
 		default:
-			fmt.Fprintf(os.Stderr, "Unable to read config file: %s\n", err)
+			return fmt.Errorf("unable to read config file: %w", err)
 		}
+	} else if err := validateKeys(viper.ConfigFileUsed(), options.ConfigType); err != nil {
+		return err
 	}
 
 	if cmd != nil {
@@ -135,6 +145,32 @@ func LoadValues(cmd *cobra.Command, options Options) {
 			}
 		})
 	}
+	return nil
+}
+
+func validateKeys(configFile string, configType string) error {
+	file := viper.New()
+	file.SetConfigFile(configFile)
+	if configType != "" {
+		file.SetConfigType(configType)
+	}
+	if err := file.ReadInConfig(); err != nil {
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+
+	unknown := []string{}
+	for _, key := range file.AllKeys() {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys in config file %s: %s", configFile, strings.Join(unknown, ", "))
+	} else {
+		return nil
+	}
 }
 
 func setConfigSearchPaths(name string, extension string, paths []string) {
@@ -18,9 +18,13 @@
 package internal
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -50,10 +54,52 @@ var (
 		Use: "test",
 		Run: func(cmd *cobra.Command, args []string) {},
 	}
+
+	quxConfig = Config{
+		Name:       "qux",
+		Usage:      "configure qux",
+		Persistent: true,
+		Default:    "default",
+	}
+
+	quuxConfig = Config{
+		Name:       "quux",
+		Usage:      "configure quux",
+		Persistent: true,
+		Default:    "default",
+	}
+
+	corgeConfig = Config{
+		Name:       "corge",
+		Usage:      "configure corge",
+		Persistent: true,
+		Default:    "default",
+	}
+
+	graultConfig = Config{
+		Name:    "grault",
+		Usage:   "configure grault",
+		Default: 0,
+	}
+
+	fileCmd = cobra.Command{
+		Use: "file",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
 )
 
 func init() {
 	Init(&cmd, []Config{fooConfig, barConfig, bazConfig})
+	Init(&fileCmd, []Config{quxConfig, quuxConfig, corgeConfig})
+	Init(nil, []Config{graultConfig})
+}
+
+func writeConfigFile(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+	return path
 }
 
 func TestFooConfig(t *testing.T) {
@@ -97,3 +143,48 @@ func TestBazConfig(t *testing.T) {
 		t.Errorf("got %v, want %v\n", actual, true)
 	}
 }
+
+func TestLoadValuesPrecedence(t *testing.T) {
+	configFile := writeConfigFile(t, "precedence.yaml", "qux: file\nquux: file\ncorge: file\n")
+	t.Setenv("TEST_QUUX", "env")
+	t.Setenv("TEST_CORGE", "env")
+	fileCmd.ParseFlags([]string{"--corge", "flag"})
+
+	if err := LoadValues(&fileCmd, Options{ConfigFile: configFile, EnvPrefix: "TEST"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if actual := quxConfig.Value(); actual != "file" {
+		t.Errorf("got %v, want %v", actual, "file")
+	} else if actual := quuxConfig.Value(); actual != "env" {
+		t.Errorf("got %v, want %v", actual, "env")
+	} else if actual := corgeConfig.Value(); actual != "flag" {
+		t.Errorf("got %v, want %v", actual, "flag")
+	}
+}
+
+func TestLoadValuesTOML(t *testing.T) {
+	configFile := writeConfigFile(t, "settings.toml", "grault = 42\n")
+
+	if err := LoadValues(nil, Options{ConfigFile: configFile, EnvPrefix: "TEST"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if actual := viper.GetInt(graultConfig.Name); actual != 42 {
+		t.Errorf("got %v, want %v", actual, 42)
+	}
+}
+
+func TestLoadValuesUnknownKeys(t *testing.T) {
+	configFile := writeConfigFile(t, "typo.yaml", "qux: file\nquxx: typo\n")
+
+	if err := LoadValues(nil, Options{ConfigFile: configFile, EnvPrefix: "TEST"}); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "quxx") {
+		t.Errorf("expected error to name the unknown key: %v", err)
+	}
+}
+
+func TestLoadValuesMalformed(t *testing.T) {
+	configFile := writeConfigFile(t, "malformed.yaml", "qux: [file\n")
+
+	if err := LoadValues(nil, Options{ConfigFile: configFile, EnvPrefix: "TEST"}); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
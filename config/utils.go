@@ -29,7 +29,40 @@ import (
 var Init = config.Init
 var LoadValues = config.LoadValues
 
-func SetAzureDefaults() {
+func SetAzureDefaults() error {
+	if cloud := AzCloud.Value().(string); cloud != "" {
+		if region, err := client.CloudRegion(cloud); err != nil {
+			return err
+		} else if current := AzRegion.Value().(string); current != constants.Cloud && current != region {
+			return fmt.Errorf("--cloud %s conflicts with --region %s", cloud, current)
+		} else {
+			// the endpoints of the cloud take precedence over any configured in a config file
+			AzRegion.Set(region)
+			AzAuthUrl.Set(client.AuthorityUrl(region, ""))
+			AzGraphUrl.Set(client.GraphUrl(region, ""))
+			AzMgmtUrl.Set(client.ResourceManagerUrl(region, ""))
+		}
+	}
+
+	// endpoint overrides take precedence over the presets of the cloud or region
+	overrides := []struct {
+		override Config
+		url      Config
+	}{
+		{AzAuthorityUrlOverride, AzAuthUrl},
+		{AzGraphUrlOverride, AzGraphUrl},
+		{AzMgmtUrlOverride, AzMgmtUrl},
+	}
+	for _, endpoint := range overrides {
+		if value := endpoint.override.Value().(string); value == "" {
+			continue
+		} else if err := ValidateEndpointUrl(value); err != nil {
+			return fmt.Errorf("invalid --%s: %w", endpoint.override.Name, err)
+		} else {
+			endpoint.url.Set(value)
+		}
+	}
+
 	if AzAuthUrl.Value() == "" {
 		region := AzRegion.Value().(string)
 		url := client.AuthorityUrl(region, constants.AzureCloud().ActiveDirectoryAuthority)
@@ -47,6 +80,54 @@ func SetAzureDefaults() {
 		url := client.ResourceManagerUrl(region, constants.AzureCloud().ResourceManagerUrl)
 		AzMgmtUrl.Set(url)
 	}
+	return nil
+}
+
+// ProxyUrl returns the proxy url along with the credentials set by --proxy-username and --proxy-password, which are
+// sent to the proxy as basic auth. The result contains the password so it must never be logged; see RedactedProxyUrl.
+func ProxyUrl() string {
+	var (
+		proxyUrl = Proxy.Value().(string)
+		username = ProxyUsername.Value().(string)
+		password = ProxyPassword.Value().(string)
+	)
+
+	if proxyUrl == "" || (username == "" && password == "") {
+		return proxyUrl
+	} else if parsedURL, err := url.Parse(proxyUrl); err != nil {
+		return proxyUrl
+	} else {
+		parsedURL.User = url.UserPassword(username, password)
+		return parsedURL.String()
+	}
+}
+
+// RedactedProxyUrl returns the proxy url with any password replaced so that it can be logged
+func RedactedProxyUrl() string {
+	if proxyUrl := Proxy.Value().(string); proxyUrl == "" {
+		return ""
+	} else if parsedURL, err := url.Parse(proxyUrl); err != nil {
+		return "invalid url"
+	} else {
+		return parsedURL.Redacted()
+	}
+}
+
+// ValidateProxy verifies the configured proxy url without including it in the error, since it may contain
+// credentials
+func ValidateProxy() error {
+	if proxyUrl := Proxy.Value().(string); proxyUrl == "" {
+		if ProxyUsername.Value().(string) != "" || ProxyPassword.Value().(string) != "" {
+			return fmt.Errorf("--%s and --%s require --%s", ProxyUsername.Name, ProxyPassword.Name, Proxy.Name)
+		}
+		return nil
+	} else if parsedURL, err := url.Parse(proxyUrl); err != nil {
+		return fmt.Errorf("invalid --%s: unable to parse url", Proxy.Name)
+	} else if parsedURL.Host == "" {
+		return fmt.Errorf("invalid --%s: missing host", Proxy.Name)
+	} else {
+		return nil
+	}
 }
 
 func ValidateURL(input string) error {
@@ -67,3 +148,17 @@ func Options() config.Options {
 		EnvPrefix:   EnvPrefix,
 	}
 }
+
+// ValidateEndpointUrl verifies that an endpoint URL is an absolute https URL; tokens are sent to these endpoints so
+// they must never be reached over plain http.
+func ValidateEndpointUrl(input string) error {
+	if parsedURL, err := url.Parse(input); err != nil {
+		return err
+	} else if parsedURL.Scheme != "https" {
+		return fmt.Errorf("%s is not an https URL", input)
+	} else if parsedURL.Host == "" {
+		return fmt.Errorf("%s does not include a host", input)
+	} else {
+		return nil
+	}
+}
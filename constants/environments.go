@@ -26,6 +26,14 @@ const (
 	USGovL5 string = "usgovl5"
 )
 
+// Azure cloud environments
+const (
+	CloudChina    string = "china"
+	CloudPublic   string = "public"
+	CloudUSGov    string = "usgov"
+	CloudUSGovDoD string = "usgovdod"
+)
+
 type Environment struct {
 	ActiveDirectoryAuthority string
 	MicrosoftGraphUrl        string
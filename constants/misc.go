@@ -41,4 +41,19 @@ func UserAgent() string {
 const (
 	GraphApiBetaVersion string = "beta"
 	GraphApiVersion     string = "v1.0"
+
+	// The resource of Azure Storage, which is the same in every Azure cloud
+	StorageResourceUrl string = "https://storage.azure.com"
+)
+
+// Managed Identity
+const (
+	// The Azure Instance Metadata Service (IMDS) token endpoint available to VMs and VM scale sets
+	IMDSTokenUrl        string = "http://169.254.169.254/metadata/identity/oauth2/token"
+	IMDSTokenApiVersion string = "2018-02-01"
+
+	// App Service, Functions and Container Apps expose a local token endpoint through these environment variables
+	IdentityEndpointEnv        string = "IDENTITY_ENDPOINT"
+	IdentityHeaderEnv          string = "IDENTITY_HEADER"
+	IdentityEndpointApiVersion string = "2019-08-01"
 )
@@ -25,8 +25,10 @@ const (
 	KindAZAppOwner                         Kind = "AZAppOwner"
 	KindAZDevice                           Kind = "AZDevice"
 	KindAZDeviceOwner                      Kind = "AZDeviceOwner"
+	KindAZDeviceUser                       Kind = "AZDeviceUser"
 	KindAZGroup                            Kind = "AZGroup"
 	KindAZGroupEligibilityScheduleInstance Kind = "AZGroupEligibilityScheduleInstance"
+	KindAZGroupLifecyclePolicy             Kind = "AZGroupLifecyclePolicy"
 	KindAZGroupMember                      Kind = "AZGroupMember"
 	KindAZGroupOwner                       Kind = "AZGroupOwner"
 	KindAZKeyVault                         Kind = "AZKeyVault"
@@ -72,6 +74,8 @@ const (
 	KindAZAutomationAccountRoleAssignment  Kind = "AZAutomationAccountRoleAssignment"
 	KindAZLogicApp                         Kind = "AZLogicApp"
 	KindAZLogicAppRoleAssignment           Kind = "AZLogicAppRoleAssignment"
+	KindAZLogicAppManagedIdentity          Kind = "AZLogicAppManagedIdentity"
+	KindAZLogicAppConnection               Kind = "AZLogicAppConnection"
 	KindAZFunctionApp                      Kind = "AZFunctionApp"
 	KindAZFunctionAppRoleAssignment        Kind = "AZFunctionAppRoleAssignment"
 	KindAZContainerRegistry                Kind = "AZContainerRegistry"
@@ -82,4 +86,95 @@ const (
 	KindAZManagedClusterRoleAssignment     Kind = "AZManagedClusterRoleAssignment"
 	KindAZVMScaleSet                       Kind = "AZVMScaleSet"
 	KindAZVMScaleSetRoleAssignment         Kind = "AZVMScaleSetRoleAssignment"
+	KindAZVMManagedIdentity                Kind = "AZVMManagedIdentity"
+	KindAZVMScaleSetManagedIdentity        Kind = "AZVMScaleSetManagedIdentity"
+	KindAZLighthouseDelegation             Kind = "AZLighthouseDelegation"
+	KindAZDelegatedAdminRelationship       Kind = "AZDelegatedAdminRelationship"
+	KindAZNamedLocation                    Kind = "AZNamedLocation"
+	KindAZOwnerRelationship                Kind = "AZOwnerRelationship"
+	KindAZOAuth2PermissionGrant            Kind = "AZOAuth2PermissionGrant"
+	KindAZTenantSettings                   Kind = "AZTenantSettings"
+	KindAZCrossTenantAccessPolicy          Kind = "AZCrossTenantAccessPolicy"
+	KindAZUserAuthenticationMethod         Kind = "AZUserAuthenticationMethod"
 )
+
+func Kinds() []Kind {
+	return []Kind{
+		KindAZApp,
+		KindAZAppMember,
+		KindAZAppOwner,
+		KindAZDevice,
+		KindAZDeviceOwner,
+		KindAZDeviceUser,
+		KindAZGroup,
+		KindAZGroupEligibilityScheduleInstance,
+		KindAZGroupLifecyclePolicy,
+		KindAZGroupMember,
+		KindAZGroupOwner,
+		KindAZKeyVault,
+		KindAZKeyVaultAccessPolicy,
+		KindAZKeyVaultContributor,
+		KindAZKeyVaultKVContributor,
+		KindAZKeyVaultOwner,
+		KindAZKeyVaultRoleAssignment,
+		KindAZKeyVaultUserAccessAdmin,
+		KindAZManagementGroup,
+		KindAZManagementGroupRoleAssignment,
+		KindAZManagementGroupOwner,
+		KindAZManagementGroupDescendant,
+		KindAZManagementGroupUserAccessAdmin,
+		KindAZResourceGroup,
+		KindAZResourceGroupRoleAssignment,
+		KindAZResourceGroupOwner,
+		KindAZResourceGroupUserAccessAdmin,
+		KindAZRole,
+		KindAZRoleAssignment,
+		KindAZRoleEligibilityScheduleInstance,
+		KindAZServicePrincipal,
+		KindAZServicePrincipalOwner,
+		KindAZSubscription,
+		KindAZSubscriptionRoleAssignment,
+		KindAZSubscriptionOwner,
+		KindAZSubscriptionUserAccessAdmin,
+		KindAZTenant,
+		KindAZUser,
+		KindAZVM,
+		KindAZVMAdminLogin,
+		KindAZVMAvereContributor,
+		KindAZVMContributor,
+		KindAZVMOwner,
+		KindAZVMRoleAssignment,
+		KindAZVMUserAccessAdmin,
+		KindAZVMVMContributor,
+		KindAZAppRoleAssignment,
+		KindAZStorageAccount,
+		KindAZStorageAccountRoleAssignment,
+		KindAZStorageContainer,
+		KindAZAutomationAccount,
+		KindAZAutomationAccountRoleAssignment,
+		KindAZLogicApp,
+		KindAZLogicAppRoleAssignment,
+		KindAZLogicAppManagedIdentity,
+		KindAZLogicAppConnection,
+		KindAZFunctionApp,
+		KindAZFunctionAppRoleAssignment,
+		KindAZContainerRegistry,
+		KindAZContainerRegistryRoleAssignment,
+		KindAZWebApp,
+		KindAZWebAppRoleAssignment,
+		KindAZManagedCluster,
+		KindAZManagedClusterRoleAssignment,
+		KindAZVMScaleSet,
+		KindAZVMScaleSetRoleAssignment,
+		KindAZVMManagedIdentity,
+		KindAZVMScaleSetManagedIdentity,
+		KindAZLighthouseDelegation,
+		KindAZDelegatedAdminRelationship,
+		KindAZNamedLocation,
+		KindAZOwnerRelationship,
+		KindAZOAuth2PermissionGrant,
+		KindAZTenantSettings,
+		KindAZCrossTenantAccessPolicy,
+		KindAZUserAuthenticationMethod,
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package enums
+
+// SubscriptionClass classifies a subscription by the offer it was created under, derived from its quota id
+type SubscriptionClass = string
+
+const (
+	SubscriptionClassDevTest    SubscriptionClass = "DevTest"
+	SubscriptionClassProduction SubscriptionClass = "Production"
+	SubscriptionClassSandbox    SubscriptionClass = "Sandbox"
+	SubscriptionClassUnknown    SubscriptionClass = "Unknown"
+)
+
+func SubscriptionClasses() []SubscriptionClass {
+	return []SubscriptionClass{
+		SubscriptionClassDevTest,
+		SubscriptionClassProduction,
+		SubscriptionClassSandbox,
+		SubscriptionClassUnknown,
+	}
+}
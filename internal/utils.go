@@ -17,3 +17,12 @@ func Filter[T any](collection []T, fn func(T) bool) []T {
 	}
 	return out
 }
+
+func Contains[T comparable](collection []T, value T) bool {
+	for _, item := range collection {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Record is a warning or error kept by a Buffer
+type Record struct {
+	Time    time.Time
+	Error   bool
+	Message string
+	Err     string
+	Values  []interface{}
+}
+
+// String formats the record on one line, e.g. `error: unable to list users: forbidden (tenantId=...)`
+func (s Record) String() string {
+	var builder strings.Builder
+	if s.Error {
+		builder.WriteString("error: ")
+	}
+	builder.WriteString(s.Message)
+	if s.Err != "" {
+		if s.Message != "" {
+			builder.WriteString(": ")
+		}
+		builder.WriteString(s.Err)
+	}
+	for i := 0; i+1 < len(s.Values); i += 2 {
+		if i == 0 {
+			builder.WriteString(" (")
+		} else {
+			builder.WriteString(", ")
+		}
+		fmt.Fprintf(&builder, "%v=%v", s.Values[i], s.Values[i+1])
+		if i+3 >= len(s.Values) {
+			builder.WriteString(")")
+		}
+	}
+	return builder.String()
+}
+
+// Buffer keeps the most recent warnings and errors logged through a logger returned by WithBuffer, dropping the
+// oldest once it holds size records. A nil buffer keeps nothing.
+type Buffer struct {
+	mutex    sync.Mutex
+	records  []Record
+	next     int
+	full     bool
+	errors   int
+	warnings int
+}
+
+func NewBuffer(size int) *Buffer {
+	return &Buffer{records: make([]Record, size)}
+}
+
+func (s *Buffer) add(record Record) {
+	if s == nil || len(s.records) == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if record.Error {
+		s.errors++
+	} else {
+		s.warnings++
+	}
+	s.records[s.next] = record
+	s.next = (s.next + 1) % len(s.records)
+	s.full = s.full || s.next == 0
+}
+
+// Records returns the records kept, oldest first
+func (s *Buffer) Records() []Record {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.full {
+		return append(append([]Record{}, s.records[s.next:]...), s.records[:s.next]...)
+	} else {
+		return append([]Record{}, s.records[:s.next]...)
+	}
+}
+
+// Counts returns the number of errors and warnings logged since the buffer was created or reset, including those
+// dropped from it
+func (s *Buffer) Counts() (int, int) {
+	if s == nil {
+		return 0, 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.errors, s.warnings
+}
+
+// Reset empties the buffer
+func (s *Buffer) Reset() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.records {
+		s.records[i] = Record{}
+	}
+	s.next, s.full, s.errors, s.warnings = 0, false, 0, 0
+}
+
+// WithBuffer returns a logger that logs to logger and keeps its errors, and the messages logged as warnings, i.e.
+// starting with "warning", in buffer. Verbosity doesn't apply to the buffer; warnings are kept whether or not they
+// are logged.
+func WithBuffer(logger logr.Logger, buffer *Buffer) logr.Logger {
+	return logr.New(&bufferSink{sink: logger.GetSink(), buffer: buffer})
+}
+
+type bufferSink struct {
+	sink   logr.LogSink
+	buffer *Buffer
+	values []interface{}
+}
+
+func isWarning(msg string) bool {
+	return len(msg) >= len("warning") && strings.EqualFold(msg[:len("warning")], "warning")
+}
+
+func (s *bufferSink) Init(info logr.RuntimeInfo) {
+	// the wrapped sink is a frame further from the caller
+	info.CallDepth++
+	s.sink.Init(info)
+}
+
+func (s *bufferSink) Enabled(level int) bool {
+	// warnings are kept even when they aren't logged
+	return true
+}
+
+func (s *bufferSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if isWarning(msg) {
+		s.buffer.add(Record{Time: time.Now(), Message: msg, Values: s.with(keysAndValues)})
+	}
+	if s.sink.Enabled(level) {
+		s.sink.Info(level, msg, keysAndValues...)
+	}
+}
+
+func (s *bufferSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	record := Record{Time: time.Now(), Error: true, Message: msg, Values: s.with(keysAndValues)}
+	if err != nil {
+		record.Err = err.Error()
+	}
+	s.buffer.add(record)
+	s.sink.Error(err, msg, keysAndValues...)
+}
+
+func (s *bufferSink) with(keysAndValues []interface{}) []interface{} {
+	if len(s.values) == 0 {
+		return keysAndValues
+	}
+	return append(append([]interface{}{}, s.values...), keysAndValues...)
+}
+
+func (s *bufferSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &bufferSink{sink: s.sink.WithValues(keysAndValues...), buffer: s.buffer, values: s.with(keysAndValues)}
+}
+
+func (s *bufferSink) WithName(name string) logr.LogSink {
+	return &bufferSink{sink: s.sink.WithName(name), buffer: s.buffer, values: s.values}
+}
+
+func (s *bufferSink) WithCallDepth(depth int) logr.LogSink {
+	if sink, ok := s.sink.(logr.CallDepthLogSink); ok {
+		return &bufferSink{sink: sink.WithCallDepth(depth), buffer: s.buffer, values: s.values}
+	} else {
+		return s
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/logger/internal"
+)
+
+func TestWithBuffer(t *testing.T) {
+	var (
+		writer = &bytes.Buffer{}
+		buffer = NewBuffer(3)
+		log    = WithBuffer(internal.NewLogger(internal.Options{Structured: true, Writers: []io.Writer{writer}}), buffer)
+	)
+
+	log.Info("collecting users")
+	log.V(2).Info("warning: hidden at this verbosity")
+	log.WithValues("tenantId", "tenant").Error(errors.New("forbidden"), "unable to list users", "retry", 1)
+
+	if records := buffer.Records(); len(records) != 2 {
+		t.Fatalf("got %v records, want 2: %v", len(records), records)
+	} else if records[0].Error || records[0].Message != "warning: hidden at this verbosity" {
+		t.Errorf("got %+v, want the warning", records[0])
+	} else if got, want := records[1].String(), "error: unable to list users: forbidden (tenantId=tenant, retry=1)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if output := writer.String(); !strings.Contains(output, "collecting users") || !strings.Contains(output, "unable to list users") {
+		t.Errorf("expected the logs to be written: %s", output)
+	} else if strings.Contains(output, "hidden at this verbosity") {
+		t.Errorf("expected the verbosity to apply to the written logs: %s", output)
+	}
+}
+
+func TestBufferDropsOldest(t *testing.T) {
+	buffer := NewBuffer(2)
+	log := WithBuffer(internal.NewLogger(internal.Options{Writers: []io.Writer{io.Discard}}), buffer)
+	for _, msg := range []string{"one", "two", "three"} {
+		log.Error(nil, msg)
+	}
+	log.Info("Warning: four")
+
+	if records := buffer.Records(); len(records) != 2 || records[0].Message != "three" || records[1].Message != "Warning: four" {
+		t.Errorf("got %v, want the two most recent records", records)
+	} else if errorCount, warningCount := buffer.Counts(); errorCount != 3 || warningCount != 1 {
+		t.Errorf("got %v errors and %v warnings, want 3 and 1", errorCount, warningCount)
+	}
+
+	buffer.Reset()
+	if records := buffer.Records(); len(records) != 0 {
+		t.Errorf("got %v, want no records after a reset", records)
+	} else if errorCount, warningCount := buffer.Counts(); errorCount != 0 || warningCount != 0 {
+		t.Errorf("got %v errors and %v warnings after a reset, want none", errorCount, warningCount)
+	}
+
+	var nilBuffer *Buffer
+	nilBuffer.Reset()
+	if records := nilBuffer.Records(); records != nil {
+		t.Errorf("got %v, want no records from a nil buffer", records)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -40,6 +41,14 @@ const (
 	MinInfoLevel int = 0
 )
 
+// The number of errors logged by all loggers
+var errorCount int64
+
+// ErrorCount returns the number of errors logged since the process started
+func ErrorCount() int64 {
+	return atomic.LoadInt64(&errorCount)
+}
+
 type logSink struct {
 	logger    *zerolog.Logger
 	name      string
@@ -102,6 +111,7 @@ func (s logSink) Enabled(level int) bool {
 // Error logs an error, with the given message and key/value pairs as
 // context. See logr.Logger.Error for more details.
 func (s logSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	atomic.AddInt64(&errorCount, 1)
 	logEvent := s.logger.Error().Err(err)
 	s.log(logEvent, msg, keysAndValues)
 }
@@ -30,9 +30,14 @@ import (
 )
 
 func setupLogger() (*logr.Logger, error) {
+	structured, err := structuredLogs()
+	if err != nil {
+		return nil, err
+	}
+
 	options := logger.Options{
 		Level:      config.VerbosityLevel.Value().(int),
-		Structured: config.JsonLogs.Value().(bool),
+		Structured: structured,
 		Colors:     true,
 		Writers:    []io.Writer{os.Stderr},
 	}
@@ -35,10 +35,9 @@ func setupLogger() (*logr.Logger, error) {
 	var (
 		logr    logr.Logger
 		options = logger.Options{
-			Level:      config.VerbosityLevel.Value().(int),
-			Structured: config.JsonLogs.Value().(bool),
-			Colors:     false,
-			Writers:    []io.Writer{os.Stderr},
+			Level:   config.VerbosityLevel.Value().(int),
+			Colors:  false,
+			Writers: []io.Writer{os.Stderr},
 		}
 	)
 
@@ -50,7 +49,15 @@ func setupLogger() (*logr.Logger, error) {
 	// XXX: This is gross, however, reading in the config file when starting the process as a windows service before
 	// initializing the eventLogWriter causes the program to panic. It doesn't make sense as to why it does that but
 	// this call will have to remain here until we can figure out what's going on.
-	config.LoadValues(nil, config.Options())
+	if err := config.LoadValues(nil, config.Options()); err != nil {
+		return nil, err
+	}
+
+	if structured, err := structuredLogs(); err != nil {
+		return nil, err
+	} else {
+		options.Structured = structured
+	}
 
 	// emit logs to file if configured
 	if fileLogWriter := getFileLogLevelWriter(); fileLogWriter != nil {
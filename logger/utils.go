@@ -18,10 +18,13 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"os"
 
 	"github.com/bloodhoundad/azurehound/v2/config"
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/logger/internal"
 	"github.com/go-logr/logr"
 )
 
@@ -42,6 +45,24 @@ func getFileLogLevelWriter() io.Writer {
 	}
 }
 
+// structuredLogs reports whether logs should be emitted as JSON lines. The deprecated --json flag is still honored.
+func structuredLogs() (bool, error) {
+	if jsonLogs, ok := config.JsonLogs.Value().(bool); ok && jsonLogs {
+		return true, nil
+	} else if format, ok := config.LogFormat.Value().(string); !ok || format == "" || format == enums.LogFormatText {
+		return false, nil
+	} else if format == enums.LogFormatJson {
+		return true, nil
+	} else {
+		return false, fmt.Errorf("unsupported log format: %s", format)
+	}
+}
+
+// ErrorCount returns the number of errors logged since the process started
+func ErrorCount() int64 {
+	return internal.ErrorCount()
+}
+
 func GetLogger() (*logr.Logger, error) {
 	if log != nil {
 		return log, nil
@@ -0,0 +1,55 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/config"
+)
+
+func TestStructuredLogs(t *testing.T) {
+	tests := []struct {
+		name       string
+		jsonLogs   bool
+		logFormat  string
+		structured bool
+		err        bool
+	}{
+		{name: "default", logFormat: "", structured: false},
+		{name: "text", logFormat: "text", structured: false},
+		{name: "json", logFormat: "json", structured: true},
+		{name: "deprecated json flag", jsonLogs: true, logFormat: "text", structured: true},
+		{name: "unsupported", logFormat: "xml", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.JsonLogs.Set(test.jsonLogs)
+			config.LogFormat.Set(test.logFormat)
+
+			if structured, err := structuredLogs(); test.err && err == nil {
+				t.Error("expected an error but did not receive one")
+			} else if !test.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if structured != test.structured {
+				t.Errorf("got %v, want %v", structured, test.structured)
+			}
+		})
+	}
+}
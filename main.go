@@ -30,5 +30,8 @@ import (
 
 func main() {
 	fmt.Fprintf(os.Stderr, "%s %s\n%s\n\n", constants.DisplayName, constants.Version, constants.AuthorRef)
-	cmd.Execute()
+	// commands exit themselves once they run, so an error here is a flag or config value that was rejected
+	if err := cmd.Execute(); err != nil {
+		os.Exit(cmd.ExitCodeConfigError)
+	}
 }
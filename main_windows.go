@@ -19,6 +19,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"golang.org/x/sys/windows/svc"
 
@@ -35,7 +36,8 @@ func main() {
 		if err := cmd.StartWindowsService(); err != nil {
 			panic(err)
 		}
-	} else {
-		cmd.Execute()
+	} else if err := cmd.Execute(); err != nil {
+		// commands exit themselves once they run, so an error here is a flag or config value that was rejected
+		os.Exit(cmd.ExitCodeConfigError)
 	}
 }
@@ -23,6 +23,9 @@ import (
 
 type App struct {
 	azure.Application
-	TenantId   string `json:"tenantId"`
-	TenantName string `json:"tenantName"`
+	CredentialHygiene
+	KeyCredentials      []KeyCredential      `json:"keyCredentials"`
+	PasswordCredentials []PasswordCredential `json:"passwordCredentials"`
+	TenantId            string               `json:"tenantId"`
+	TenantName          string               `json:"tenantName"`
 }
@@ -0,0 +1,59 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+import "strings"
+
+// AuthenticationMethod is a method a user has registered to sign in or to satisfy multi-factor authentication, such
+// as a FIDO2 key, the Microsoft Authenticator app, a phone or a password. Phone numbers and other contact details of a
+// method are not collected.
+type AuthenticationMethod struct {
+	Id string `json:"id"`
+
+	// The kind of method, e.g. #microsoft.graph.fido2AuthenticationMethod
+	ODataType string `json:"@odata.type"`
+
+	// The date and time the method was registered; not reported for every kind of method
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+
+	// The name of the device or key the method is registered on, for Microsoft Authenticator, FIDO2 and Windows Hello
+	// for Business methods
+	DisplayName string `json:"displayName,omitempty"`
+
+	// The manufacturer and model of a FIDO2 key
+	Model string `json:"model,omitempty"`
+
+	// The type of a phone method: mobile, alternateMobile or office
+	PhoneType string `json:"phoneType,omitempty"`
+}
+
+// MethodType returns the kind of method without its namespace and suffix, e.g. fido2 for a FIDO2 key
+func (s AuthenticationMethod) MethodType() string {
+	return strings.TrimSuffix(strings.TrimPrefix(s.ODataType, "#microsoft.graph."), "AuthenticationMethod")
+}
+
+type AuthenticationMethodList struct {
+	Value    []AuthenticationMethod `json:"value"`
+	NextLink string                 `json:"@odata.nextLink,omitempty"`
+}
+
+type UserAuthenticationMethodResult struct {
+	UserId string
+	Error  error
+	Ok     AuthenticationMethod
+}
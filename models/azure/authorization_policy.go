@@ -0,0 +1,76 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// The tenant-wide policy that controls what the default user role and guests are allowed to do.
+// See https://learn.microsoft.com/en-us/graph/api/resources/authorizationpolicy
+type AuthorizationPolicy struct {
+	Entity
+
+	// Whether users can sign up for email based subscriptions.
+	AllowedToSignUpEmailBasedSubscriptions bool `json:"allowedToSignUpEmailBasedSubscriptions"`
+
+	// Whether users can use the self-service password reset feature.
+	AllowedToUseSSPR bool `json:"allowedToUseSSPR"`
+
+	// Whether a user can join the tenant by email validation.
+	AllowEmailVerifiedUsersToJoinOrganization bool `json:"allowEmailVerifiedUsersToJoinOrganization"`
+
+	// Who can invite external users to the organization.
+	// Possible values: none, adminsAndGuestInviters, adminsGuestInvitersAndAllMembers, everyone
+	AllowInvitesFrom string `json:"allowInvitesFrom,omitempty"`
+
+	// Whether user consent for risky apps is allowed.
+	AllowUserConsentForRiskyApps *bool `json:"allowUserConsentForRiskyApps,omitempty"`
+
+	// Whether the legacy MSOnline PowerShell module is blocked.
+	BlockMsolPowerShell *bool `json:"blockMsolPowerShell,omitempty"`
+
+	// The permissions granted to the default user role.
+	DefaultUserRolePermissions DefaultUserRolePermissions `json:"defaultUserRolePermissions"`
+
+	// The description of the policy.
+	Description string `json:"description,omitempty"`
+
+	// The display name of the policy.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// The id of the role granted to guest users, which determines how much of the directory they can read.
+	GuestUserRoleId string `json:"guestUserRoleId,omitempty"`
+}
+
+type DefaultUserRolePermissions struct {
+	// Whether the default user role can create applications.
+	AllowedToCreateApps bool `json:"allowedToCreateApps"`
+
+	// Whether the default user role can create security groups.
+	AllowedToCreateSecurityGroups bool `json:"allowedToCreateSecurityGroups"`
+
+	// Whether the default user role can create tenants.
+	AllowedToCreateTenants *bool `json:"allowedToCreateTenants,omitempty"`
+
+	// Whether users can read the BitLocker keys of devices they own.
+	AllowedToReadBitlockerKeysForOwnedDevice *bool `json:"allowedToReadBitlockerKeysForOwnedDevice,omitempty"`
+
+	// Whether the default user role can read other users.
+	AllowedToReadOtherUsers bool `json:"allowedToReadOtherUsers"`
+
+	// The ids of the permission grant policies assigned to the default user role, which determine what users may
+	// consent to on their own behalf. No policies means user consent is disabled.
+	PermissionGrantPoliciesAssigned []string `json:"permissionGrantPoliciesAssigned"`
+}
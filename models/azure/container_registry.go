@@ -23,11 +23,27 @@ import "strings"
 type ContainerRegistry struct {
 	Entity
 
-	Identity ManagedIdentity   `json:"identity,omitempty"`
-	Location string            `json:"location,omitempty"`
-	Name     string            `json:"name,omitempty"`
-	Tags     map[string]string `json:"tags,omitempty"`
-	Type     string            `json:"type,omitempty"`
+	Identity   ManagedIdentity             `json:"identity,omitempty"`
+	Location   string                      `json:"location,omitempty"`
+	Name       string                      `json:"name,omitempty"`
+	Properties ContainerRegistryProperties `json:"properties,omitempty"`
+	Tags       map[string]string           `json:"tags,omitempty"`
+	Type       string                      `json:"type,omitempty"`
+}
+
+// Mapped according to https://learn.microsoft.com/en-us/rest/api/containerregistry/registries/get?tabs=HTTP#registry
+type ContainerRegistryProperties struct {
+	// Whether the admin user, whose password grants push and pull to the registry, is enabled
+	AdminUserEnabled bool `json:"adminUserEnabled"`
+
+	// Whether images can be pulled without authenticating
+	AnonymousPullEnabled bool `json:"anonymousPullEnabled"`
+
+	// The URL of the registry
+	LoginServer string `json:"loginServer,omitempty"`
+
+	// Whether the registry can be reached from public networks
+	PublicNetworkAccess string `json:"publicNetworkAccess,omitempty"`
 }
 
 func (s ContainerRegistry) ResourceGroupName() string {
@@ -0,0 +1,102 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// The default cross-tenant access settings of the tenant, which apply to every external tenant without settings of
+// its own. See https://learn.microsoft.com/en-us/graph/api/resources/crosstenantaccesspolicyconfigurationdefault
+type CrossTenantAccessPolicyConfigurationDefault struct {
+	// Whether the settings are the defaults of the service, i.e. have never been changed.
+	IsServiceDefault bool `json:"isServiceDefault"`
+
+	// Which claims of users from external tenants are trusted.
+	InboundTrust *CrossTenantAccessPolicyInboundTrust `json:"inboundTrust,omitempty"`
+
+	// Whether invitations are redeemed automatically, without users having to consent.
+	AutomaticUserConsentSettings *InboundOutboundPolicyConfiguration `json:"automaticUserConsentSettings,omitempty"`
+
+	// Who may collaborate with the tenant as B2B guests, and who from the tenant may be a guest elsewhere.
+	B2BCollaborationInbound  *CrossTenantAccessPolicyB2BSetting `json:"b2bCollaborationInbound,omitempty"`
+	B2BCollaborationOutbound *CrossTenantAccessPolicyB2BSetting `json:"b2bCollaborationOutbound,omitempty"`
+
+	// Who may access the tenant through B2B direct connect, and who from the tenant may access others.
+	B2BDirectConnectInbound  *CrossTenantAccessPolicyB2BSetting `json:"b2bDirectConnectInbound,omitempty"`
+	B2BDirectConnectOutbound *CrossTenantAccessPolicyB2BSetting `json:"b2bDirectConnectOutbound,omitempty"`
+}
+
+// The cross-tenant access settings of the tenant for a partner tenant. Settings that are null are inherited from the
+// default settings. See https://learn.microsoft.com/en-us/graph/api/resources/crosstenantaccesspolicyconfigurationpartner
+type CrossTenantAccessPolicyConfigurationPartner struct {
+	// The id of the partner tenant.
+	TenantId string `json:"tenantId"`
+
+	// Whether the partner is a cloud service provider of the tenant.
+	IsServiceProvider *bool `json:"isServiceProvider,omitempty"`
+
+	// Whether the partner is in the same multitenant organization as the tenant.
+	IsInMultiTenantOrganization *bool `json:"isInMultiTenantOrganization,omitempty"`
+
+	InboundTrust                 *CrossTenantAccessPolicyInboundTrust `json:"inboundTrust,omitempty"`
+	AutomaticUserConsentSettings *InboundOutboundPolicyConfiguration  `json:"automaticUserConsentSettings,omitempty"`
+	B2BCollaborationInbound      *CrossTenantAccessPolicyB2BSetting   `json:"b2bCollaborationInbound,omitempty"`
+	B2BCollaborationOutbound     *CrossTenantAccessPolicyB2BSetting   `json:"b2bCollaborationOutbound,omitempty"`
+	B2BDirectConnectInbound      *CrossTenantAccessPolicyB2BSetting   `json:"b2bDirectConnectInbound,omitempty"`
+	B2BDirectConnectOutbound     *CrossTenantAccessPolicyB2BSetting   `json:"b2bDirectConnectOutbound,omitempty"`
+}
+
+// Which claims made by the home tenant of an external user are accepted by Conditional Access in place of the
+// tenant's own checks.
+type CrossTenantAccessPolicyInboundTrust struct {
+	IsMfaAccepted                       bool `json:"isMfaAccepted"`
+	IsCompliantDeviceAccepted           bool `json:"isCompliantDeviceAccepted"`
+	IsHybridAzureADJoinedDeviceAccepted bool `json:"isHybridAzureADJoinedDeviceAccepted"`
+}
+
+type InboundOutboundPolicyConfiguration struct {
+	InboundAllowed  bool `json:"inboundAllowed"`
+	OutboundAllowed bool `json:"outboundAllowed"`
+}
+
+// The users, groups and applications a B2B setting allows or blocks.
+type CrossTenantAccessPolicyB2BSetting struct {
+	UsersAndGroups *CrossTenantAccessPolicyTargetConfiguration `json:"usersAndGroups,omitempty"`
+	Applications   *CrossTenantAccessPolicyTargetConfiguration `json:"applications,omitempty"`
+}
+
+type CrossTenantAccessPolicyTargetConfiguration struct {
+	// Either allowed or blocked.
+	AccessType string                          `json:"accessType,omitempty"`
+	Targets    []CrossTenantAccessPolicyTarget `json:"targets"`
+}
+
+type CrossTenantAccessPolicyTarget struct {
+	// The id of the user, group or application, or AllUsers or AllApplications.
+	Target string `json:"target"`
+
+	// One of user, group or application.
+	TargetType string `json:"targetType"`
+}
+
+type CrossTenantAccessPolicyConfigurationPartnerList struct {
+	NextLink string                                        `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []CrossTenantAccessPolicyConfigurationPartner `json:"value"`                     // A list of partner configurations.
+}
+
+type CrossTenantAccessPolicyConfigurationPartnerResult struct {
+	Error error
+	Ok    CrossTenantAccessPolicyConfigurationPartner
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// A granular delegated admin privileges (GDAP) relationship between a partner tenant and a customer tenant.
+type DelegatedAdminRelationship struct {
+	Entity
+
+	// The access details of the relationship; the Azure AD roles the partner is granted in the customer tenant.
+	AccessDetails DelegatedAdminAccessDetails `json:"accessDetails"`
+
+	// The date and time at which the relationship became active.
+	ActivatedDateTime string `json:"activatedDateTime,omitempty"`
+
+	// The duration by which the validity of the relationship is automatically extended, in ISO 8601 format.
+	AutoExtendDuration string `json:"autoExtendDuration,omitempty"`
+
+	// The date and time at which the relationship was created.
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+
+	// The display name and tenant id of the customer tenant.
+	Customer DelegatedAdminRelationshipCustomerParticipant `json:"customer"`
+
+	// The display name of the relationship.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// The duration of the relationship in ISO 8601 format.
+	Duration string `json:"duration,omitempty"`
+
+	// The date and time at which the relationship expires.
+	EndDateTime string `json:"endDateTime,omitempty"`
+
+	// The date and time at which the relationship was last modified.
+	LastModifiedDateTime string `json:"lastModifiedDateTime,omitempty"`
+
+	// The status of the relationship.
+	// Possible values: activating, active, approvalPending, approved, created, expired, expiring, terminated,
+	// terminating, terminationRequested
+	Status string `json:"status,omitempty"`
+}
+
+type DelegatedAdminRelationshipCustomerParticipant struct {
+	DisplayName string `json:"displayName,omitempty"`
+	TenantId    string `json:"tenantId"`
+}
+
+type DelegatedAdminAccessDetails struct {
+	UnifiedRoles []DelegatedAdminUnifiedRole `json:"unifiedRoles"`
+}
+
+type DelegatedAdminUnifiedRole struct {
+	// The unique identifier of the Azure AD role definition.
+	RoleDefinitionId string `json:"roleDefinitionId"`
+}
+
+// Maps a partner security group to a subset of the roles granted by a delegated admin relationship.
+type DelegatedAdminAccessAssignment struct {
+	Entity
+
+	// The security group in the partner tenant that is granted access.
+	AccessContainer DelegatedAdminAccessContainer `json:"accessContainer"`
+
+	// The roles granted to the access container.
+	AccessDetails DelegatedAdminAccessDetails `json:"accessDetails"`
+
+	// The date and time at which the access assignment was created.
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+
+	// The date and time at which the access assignment was last modified.
+	LastModifiedDateTime string `json:"lastModifiedDateTime,omitempty"`
+
+	// The status of the access assignment.
+	// Possible values: pending, active, deleting, deleted, error
+	Status string `json:"status,omitempty"`
+}
+
+type DelegatedAdminAccessContainer struct {
+	// The object id of the security group in the partner tenant.
+	AccessContainerId string `json:"accessContainerId"`
+
+	// The type of the access container. Possible values: securityGroup
+	AccessContainerType string `json:"accessContainerType"`
+}
+
+type DelegatedAdminRelationshipList struct {
+	NextLink string                       `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []DelegatedAdminRelationship `json:"value"`                     // A list of delegated admin relationships.
+}
+
+type DelegatedAdminRelationshipResult struct {
+	Error error
+	Ok    DelegatedAdminRelationship
+}
+
+type DelegatedAdminAccessAssignmentList struct {
+	NextLink string                           `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []DelegatedAdminAccessAssignment `json:"value"`                     // A list of access assignments.
+}
+
+type DelegatedAdminAccessAssignmentResult struct {
+	RelationshipId string
+	Error          error
+	Ok             DelegatedAdminAccessAssignment
+}
@@ -48,9 +48,10 @@ type Device struct {
 	// For example, midnight UTC on Jan 1, 2014 is 2014-01-01T00:00:00Z. Read-only.
 	ComplianceExpirationDateTime string `json:"complianceExpirationDateTime,omitempty"`
 
-	// Unique identifier set by Azure Device Registration Service at the time of registration.
+	// Unique identifier set by Azure Device Registration Service at the time of registration. This is distinct from the
+	// object id and is the identifier stamped on the device itself.
 	// Supports $filter (eq, ne, NOT, startsWith).
-	DeviceId string `json:"deviceId,omitempty"`
+	DeviceId string `json:"deviceId"`
 
 	// For internal use only. Set to null.
 	DeviceMetadata string `json:"deviceMetadata,omitempty"`
@@ -104,7 +105,14 @@ type Device struct {
 	// directory (default).
 	// Read-only.
 	// Supports $filter (eq, ne, NOT, in).
-	OnPremisesSyncEnabled bool `json:"onPremisesSyncEnabled,omitempty"`
+	OnPremisesSyncEnabled *bool `json:"onPremisesSyncEnabled,omitempty"`
+
+	// The on-premises security identifier (SID) for the device that was synchronized from on-premises to the cloud.
+	// Only set for hybrid joined devices.
+	// Read-only.
+	// Returned only on $select.
+	// Supports $filter (eq).
+	OnPremisesSecurityIdentifier string `json:"onPremisesSecurityIdentifier"`
 
 	// The type of operating system on the device.
 	// Required.
@@ -148,3 +156,9 @@ type DeviceRegisteredOwnerResult struct {
 	Error    error
 	Ok       json.RawMessage
 }
+
+type DeviceRegisteredUserResult struct {
+	DeviceId string
+	Error    error
+	Ok       json.RawMessage
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// GenericResource is the summary of any ARM resource returned when listing the resources of a subscription.
+type GenericResource struct {
+	Entity
+
+	// The time the resource was last changed in RFC 3339 format. Only returned when requested with $expand.
+	ChangedTime string `json:"changedTime,omitempty"`
+
+	// The time the resource was created in RFC 3339 format. Only returned when requested with $expand.
+	CreatedTime string `json:"createdTime,omitempty"`
+
+	// Resource location.
+	Location string `json:"location,omitempty"`
+
+	// Resource name.
+	Name string `json:"name,omitempty"`
+
+	// Resource type.
+	Type string `json:"type,omitempty"`
+}
+
+type GenericResourceList struct {
+	NextLink string            `json:"nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []GenericResource `json:"value"`              // A list of resources.
+}
+
+type GenericResourceResult struct {
+	SubscriptionId string
+	Error          error
+	Ok             GenericResource
+}
@@ -129,7 +129,8 @@ type Group struct {
 	// update the membership of such groups. For more, see Using a group to manage Azure AD role assignments
 	// Returned by default.
 	// Supports $filter (eq, ne, NOT).
-	IsAssignableToRole bool `json:"isAssignableToRole,omitempty"`
+	// Always serialized; members of a role-assignable group hold its directory roles, so false is as telling as true.
+	IsAssignableToRole bool `json:"isAssignableToRole"`
 
 	// Indicates whether the signed-in user is subscribed to receive email conversations.
 	// Default value is true.
@@ -152,7 +153,7 @@ type Group struct {
 	// Required.
 	// Returned by default.
 	// Supports $filter (eq, ne, NOT).
-	MailEnabled bool `json:"mailEnabled,omitempty"`
+	MailEnabled bool `json:"mailEnabled"`
 
 	// The mail alias for the group, unique in the organization.
 	// Maximum length is 64 characters.
@@ -258,7 +259,7 @@ type Group struct {
 	// Required.
 	// Returned by default.
 	// Supports $filter (eq, ne, NOT, in).
-	SecurityEnabled bool `json:"securityEnabled,omitempty"`
+	SecurityEnabled bool `json:"securityEnabled"`
 
 	// Security identifier of the group, used in Windows scenarios.
 	// Returned by default.
@@ -0,0 +1,43 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// A policy that expires Microsoft 365 groups which aren't renewed within its lifetime. A tenant has at most one.
+type GroupLifecyclePolicy struct {
+	Entity
+
+	// The email addresses notified of groups about to expire that have no owners, separated by semicolons.
+	AlternateNotificationEmails string `json:"alternateNotificationEmails,omitempty"`
+
+	// The number of days before a group expires unless it is renewed.
+	GroupLifetimeInDays int32 `json:"groupLifetimeInDays,omitempty"`
+
+	// The Microsoft 365 groups the policy applies to.
+	// Possible values: All, Selected, None
+	ManagedGroupTypes string `json:"managedGroupTypes,omitempty"`
+}
+
+type GroupLifecyclePolicyList struct {
+	NextLink string                 `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []GroupLifecyclePolicy `json:"value"`                     // A list of group lifecycle policies.
+}
+
+type GroupLifecyclePolicyResult struct {
+	Error error
+	Ok    GroupLifecyclePolicy
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// The directory settings of the tenant, such as the Group.Unified settings governing who can create Microsoft 365
+// groups and whether guests can be added to them. Each setting is an instance of a directory setting template.
+// See https://learn.microsoft.com/en-us/graph/api/resources/groupsetting
+type GroupSetting struct {
+	Entity
+
+	// The display name of the template the setting was created from.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// The id of the template the setting was created from.
+	TemplateId string `json:"templateId,omitempty"`
+
+	// The name value pairs of the setting; values the template defines that aren't set take the template default.
+	Values []SettingValue `json:"values"`
+}
+
+type SettingValue struct {
+	// The name of the setting as defined by the template.
+	Name string `json:"name"`
+
+	// The value of the setting.
+	Value string `json:"value"`
+}
+
+type GroupSettingList struct {
+	NextLink string         `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []GroupSetting `json:"value"`                     // A list of directory settings.
+}
+
+type GroupSettingResult struct {
+	Error error
+	Ok    GroupSetting
+}
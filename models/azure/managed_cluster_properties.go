@@ -17,8 +17,48 @@
 
 package azure
 
-// Properties of the vault
+// Properties of the managed cluster
 type ManagedClusterProperties struct {
+	// The Azure AD integration of the cluster; absent when the cluster does not authenticate with Azure AD
+	AadProfile *ManagedClusterAADProfile `json:"aadProfile,omitempty"`
+
+	// Whether local accounts, whose credentials bypass Azure AD, are disabled
+	DisableLocalAccounts bool `json:"disableLocalAccounts"`
+
+	// Whether Kubernetes RBAC is enabled
+	EnableRBAC bool `json:"enableRBAC"`
+
+	// The FQDN of the cluster's API server
+	Fqdn string `json:"fqdn,omitempty"`
+
+	// The identities used by the cluster's components, keyed by component e.g. kubeletidentity
+	IdentityProfile map[string]ManagedClusterIdentity `json:"identityProfile,omitempty"`
+
+	// The version of Kubernetes the cluster runs
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
 	// The name of the AzureRM Resource Group the Managed Cluster's Virtual Machine Scale Set resides
 	NodeResourceGroup string `json:"nodeResourceGroup"`
 }
+
+// Mapped according to https://learn.microsoft.com/en-us/rest/api/aks/managed-clusters/get?tabs=HTTP#managedclusteraadprofile
+type ManagedClusterAADProfile struct {
+	// The object ids of the Azure AD groups that are cluster admins
+	AdminGroupObjectIDs []string `json:"adminGroupObjectIDs,omitempty"`
+
+	// Whether Kubernetes authorization is decided by Azure role assignments
+	EnableAzureRBAC bool `json:"enableAzureRBAC"`
+
+	// Whether the AKS-managed Azure AD integration is enabled
+	Managed bool `json:"managed"`
+
+	// The tenant users of the cluster authenticate with
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// A user-assigned identity used by a component of the cluster
+type ManagedClusterIdentity struct {
+	ClientId   string `json:"clientId,omitempty"`
+	ObjectId   string `json:"objectId,omitempty"`
+	ResourceId string `json:"resourceId,omitempty"`
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+const (
+	NamedLocationTypeIp      = "#microsoft.graph.ipNamedLocation"
+	NamedLocationTypeCountry = "#microsoft.graph.countryNamedLocation"
+)
+
+// A named location used by conditional access policies. Graph returns either an ipNamedLocation or a
+// countryNamedLocation, identified by @odata.type; only the fields of that variant are set.
+type NamedLocation struct {
+	Entity
+
+	// The type of the named location.
+	// Possible values: #microsoft.graph.ipNamedLocation, #microsoft.graph.countryNamedLocation
+	Type string `json:"@odata.type"`
+
+	// The date and time at which the location was created.
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+
+	// The display name of the location.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// The date and time at which the location was last modified.
+	ModifiedDateTime string `json:"modifiedDateTime,omitempty"`
+
+	// ipNamedLocation: the IPv4 and IPv6 ranges, in CIDR notation, that make up the location.
+	IpRanges []IpRange `json:"ipRanges,omitempty"`
+
+	// ipNamedLocation: whether the location is marked as trusted.
+	IsTrusted *bool `json:"isTrusted,omitempty"`
+
+	// countryNamedLocation: the two-letter ISO 3166 country and region codes that make up the location.
+	CountriesAndRegions []string `json:"countriesAndRegions,omitempty"`
+
+	// countryNamedLocation: how the country of a sign in is determined.
+	// Possible values: clientIpAddress, authenticatorAppGps
+	CountryLookupMethod string `json:"countryLookupMethod,omitempty"`
+
+	// countryNamedLocation: whether sign ins from IP addresses that don't map to a country or region are included.
+	IncludeUnknownCountriesAndRegions *bool `json:"includeUnknownCountriesAndRegions,omitempty"`
+}
+
+type IpRange struct {
+	// The type of the range. Possible values: #microsoft.graph.iPv4CidrRange, #microsoft.graph.iPv6CidrRange
+	Type string `json:"@odata.type,omitempty"`
+
+	// The range in CIDR notation.
+	CidrAddress string `json:"cidrAddress"`
+}
+
+type NamedLocationList struct {
+	NextLink string          `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []NamedLocation `json:"value"`                     // A list of named locations.
+}
+
+type NamedLocationResult struct {
+	Error error
+	Ok    NamedLocation
+}
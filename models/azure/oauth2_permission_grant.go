@@ -0,0 +1,56 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+const (
+	ConsentTypeAllPrincipals = "AllPrincipals"
+	ConsentTypePrincipal     = "Principal"
+)
+
+// A delegated permission grant, allowing a client service principal to access a resource on behalf of a signed in
+// user with the listed scopes.
+type OAuth2PermissionGrant struct {
+	// The unique identifier of the grant.
+	Id string `json:"id"`
+
+	// The object id of the client service principal authorized to act on behalf of users.
+	ClientId string `json:"clientId"`
+
+	// Whether the client may impersonate all users or only the user identified by principalId.
+	// Possible values: AllPrincipals, Principal
+	ConsentType string `json:"consentType"`
+
+	// The id of the user the client may impersonate when consentType is Principal; null for AllPrincipals grants.
+	PrincipalId string `json:"principalId,omitempty"`
+
+	// The object id of the resource service principal the client is authorized to access.
+	ResourceId string `json:"resourceId"`
+
+	// A space-separated list of the delegated permissions granted, e.g. "openid User.Read Mail.Read".
+	Scope string `json:"scope"`
+}
+
+type OAuth2PermissionGrantList struct {
+	NextLink string                  `json:"@odata.nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []OAuth2PermissionGrant `json:"value"`                     // A list of delegated permission grants.
+}
+
+type OAuth2PermissionGrantResult struct {
+	Error error
+	Ok    OAuth2PermissionGrant
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package azure
+
+// Mapped according to https://learn.microsoft.com/en-us/rest/api/managedservices/registration-assignments/list
+type RegistrationAssignment struct {
+	Entity
+
+	Name       string                           `json:"name,omitempty"`
+	Type       string                           `json:"type,omitempty"`
+	Properties RegistrationAssignmentProperties `json:"properties,omitempty"`
+}
+
+type RegistrationAssignmentProperties struct {
+	// The current provisioning state of the registration assignment.
+	ProvisioningState string `json:"provisioningState,omitempty"`
+
+	// The registration definition associated with the registration assignment. Only populated when the assignment
+	// is requested with $expandRegistrationDefinition.
+	RegistrationDefinition RegistrationDefinition `json:"registrationDefinition,omitempty"`
+
+	// The fully qualified path of the registration definition.
+	RegistrationDefinitionId string `json:"registrationDefinitionId,omitempty"`
+}
+
+type RegistrationAssignmentList struct {
+	NextLink string                   `json:"nextLink,omitempty"` // The URL to use for getting the next set of values.
+	Value    []RegistrationAssignment `json:"value"`              // A list of registration assignments.
+}
+
+type RegistrationAssignmentResult struct {
+	SubscriptionId string
+	Error          error
+	Ok             RegistrationAssignment
+}
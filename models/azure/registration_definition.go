@@ -0,0 +1,90 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package azure
+
+// Mapped according to https://learn.microsoft.com/en-us/rest/api/managedservices/registration-definitions/get
+type RegistrationDefinition struct {
+	Entity
+
+	Name       string                           `json:"name,omitempty"`
+	Type       string                           `json:"type,omitempty"`
+	Properties RegistrationDefinitionProperties `json:"properties,omitempty"`
+}
+
+type RegistrationDefinitionProperties struct {
+	// The collection of authorization objects describing the access Azure Active Directory principals in the
+	// managedBy tenant will receive on the delegated resource in the managed tenant.
+	Authorizations []LighthouseAuthorization `json:"authorizations,omitempty"`
+
+	// The description of the registration definition.
+	Description string `json:"description,omitempty"`
+
+	// The collection of eligible authorization objects describing the just-in-time access Azure Active Directory
+	// principals in the managedBy tenant will receive on the delegated resource in the managed tenant.
+	EligibleAuthorizations []LighthouseEligibleAuthorization `json:"eligibleAuthorizations,omitempty"`
+
+	// The identifier of the managedBy tenant.
+	ManagedByTenantId string `json:"managedByTenantId,omitempty"`
+
+	// The name of the managedBy tenant.
+	ManagedByTenantName string `json:"managedByTenantName,omitempty"`
+
+	// The identifier of the managed tenant.
+	ManageeTenantId string `json:"manageeTenantId,omitempty"`
+
+	// The name of the managed tenant.
+	ManageeTenantName string `json:"manageeTenantName,omitempty"`
+
+	// The current provisioning state of the registration definition.
+	ProvisioningState string `json:"provisioningState,omitempty"`
+
+	// The name of the registration definition.
+	RegistrationDefinitionName string `json:"registrationDefinitionName,omitempty"`
+}
+
+// The Azure Active Directory principal identifier and Azure built-in role that describes the access the principal
+// will receive on the delegated resource in the managed tenant.
+type LighthouseAuthorization struct {
+	// The delegatedRoleDefinitionIds field is required when the roleDefinitionId refers to the User Access
+	// Administrator Role. It is the list of role definition ids which define all the permissions that the user in
+	// the authorization can assign to other principals.
+	DelegatedRoleDefinitionIds []string `json:"delegatedRoleDefinitionIds,omitempty"`
+
+	// The identifier of the Azure Active Directory principal.
+	PrincipalId string `json:"principalId"`
+
+	// The display name of the Azure Active Directory principal.
+	PrincipalIdDisplayName string `json:"principalIdDisplayName,omitempty"`
+
+	// The identifier of the Azure built-in role that defines the permissions that the Azure Active Directory
+	// principal will have on the projected scope.
+	RoleDefinitionId string `json:"roleDefinitionId"`
+}
+
+// The Azure Active Directory principal identifier, Azure built-in role, and just-in-time access policy that
+// describes the just-in-time access the principal will receive on the delegated resource in the managed tenant.
+type LighthouseEligibleAuthorization struct {
+	// The identifier of the Azure Active Directory principal.
+	PrincipalId string `json:"principalId"`
+
+	// The display name of the Azure Active Directory principal.
+	PrincipalIdDisplayName string `json:"principalIdDisplayName,omitempty"`
+
+	// The identifier of the Azure built-in role that defines the permissions that the Azure Active Directory
+	// principal will have on the projected scope.
+	RoleDefinitionId string `json:"roleDefinitionId"`
+}
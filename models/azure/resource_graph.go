@@ -0,0 +1,54 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+import "encoding/json"
+
+// ResourceGraphRequest is a Kusto query run against the resources of a set of subscriptions or management groups.
+type ResourceGraphRequest struct {
+	// The subscriptions the query is run against. At most 1000 may be given per request.
+	Subscriptions []string `json:"subscriptions,omitempty"`
+
+	// The management groups the query is run against, used instead of subscriptions.
+	ManagementGroups []string `json:"managementGroups,omitempty"`
+
+	// The Kusto query.
+	Query string `json:"query"`
+
+	// The paging and formatting options of the query.
+	Options ResourceGraphRequestOptions `json:"options"`
+}
+
+type ResourceGraphRequestOptions struct {
+	ResultFormat string `json:"resultFormat,omitempty"` // The format of the rows, objectArray returns one object per row.
+	SkipToken    string `json:"$skipToken,omitempty"`   // The token of the next page returned by the previous request.
+	Top          int32  `json:"$top,omitempty"`         // The number of rows per page, at most 1000.
+}
+
+type ResourceGraphResponse struct {
+	Count           int64             `json:"count"`                // The number of rows in this page.
+	TotalRecords    int64             `json:"totalRecords"`         // The number of rows matched by the query.
+	ResultTruncated string            `json:"resultTruncated"`      // Whether the rows were truncated because they couldn't be paged.
+	SkipToken       string            `json:"$skipToken,omitempty"` // The token of the next page.
+	Data            []json.RawMessage `json:"data"`                 // The rows of this page.
+}
+
+type ResourceGraphResult struct {
+	Error error
+	Ok    json.RawMessage
+}
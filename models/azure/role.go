@@ -37,13 +37,13 @@ type Role struct {
 	//
 	// Read-only
 	// Supports $filter (eq, in)
-	IsBuiltIn bool `json:"isBuiltIn,omitempty"`
+	IsBuiltIn bool `json:"isBuiltIn"`
 
 	// Flag indicating whether the role is enabled for assignment.
 	// If false the role is not available for assignment.
 	//
 	// Read-only when isBuiltIn is true
-	IsEnabled bool `json:"isEnabled,omitempty"`
+	IsEnabled bool `json:"isEnabled"`
 
 	// List of the scopes or permissions the role definition applies to.
 	// Currently only `/` is supported.
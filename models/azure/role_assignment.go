@@ -21,6 +21,10 @@ type RoleAssignmentPropertiesWithScope struct {
 	// The principal ID.
 	PrincipalId string `json:"principalId"`
 
+	// The principal type: User, Group, ServicePrincipal, ForeignGroup or Device. Resolved from the directory when the
+	// assignment doesn't report it.
+	PrincipalType string `json:"principalType"`
+
 	// The role definition ID.
 	RoleDefinitionId string `json:"roleDefinitionId"`
 
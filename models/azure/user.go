@@ -34,7 +34,7 @@ type User struct {
 	//
 	// Returned only on `$select`
 	// Supports `$filter` (eq,ne,NOT,in)
-	AccountEnabled bool `json:"accountEnabled,omitempty"`
+	AccountEnabled bool `json:"accountEnabled"`
 
 	// Sets the age group of the user.
 	//
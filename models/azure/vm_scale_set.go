@@ -22,14 +22,15 @@ import "strings"
 type VMScaleSet struct {
 	Entity
 
-	ExtendedLocation ExtendedLocation  `json:"extendedLocation,omitempty"`
-	Identity         ManagedIdentity   `json:"identity,omitempty"`
-	Location         string            `json:"location,omitempty"`
-	Name             string            `json:"name,omitempty"`
-	Plan             Plan              `json:"plan,omitempty"`
-	Tags             map[string]string `json:"tags,omitempty"`
-	Type             string            `json:"type,omitempty"`
-	Zones            []string          `json:"zones,omitempty"`
+	ExtendedLocation ExtendedLocation     `json:"extendedLocation,omitempty"`
+	Identity         ManagedIdentity      `json:"identity,omitempty"`
+	Location         string               `json:"location,omitempty"`
+	Name             string               `json:"name,omitempty"`
+	Plan             Plan                 `json:"plan,omitempty"`
+	Properties       VMScaleSetProperties `json:"properties,omitempty"`
+	Tags             map[string]string    `json:"tags,omitempty"`
+	Type             string               `json:"type,omitempty"`
+	Zones            []string             `json:"zones,omitempty"`
 }
 
 func (s VMScaleSet) ResourceGroupName() string {
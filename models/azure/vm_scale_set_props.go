@@ -0,0 +1,56 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package azure
+
+// Describes the properties of a Virtual Machine Scale Set.
+type VMScaleSetProperties struct {
+	OrchestrationMode     string              `json:"orchestrationMode,omitempty"`
+	ProvisioningState     string              `json:"provisioningState,omitempty"`
+	SinglePlacementGroup  bool                `json:"singlePlacementGroup,omitempty"`
+	UniqueId              string              `json:"uniqueId,omitempty"`
+	VirtualMachineProfile VMScaleSetVMProfile `json:"virtualMachineProfile,omitempty"`
+}
+
+// The profile the virtual machines of a scale set are created from
+type VMScaleSetVMProfile struct {
+	OSProfile      VMScaleSetOSProfile `json:"osProfile,omitempty"`
+	StorageProfile StorageProfile      `json:"storageProfile,omitempty"`
+	LicenseType    string              `json:"licenseType,omitempty"`
+}
+
+// Specifies the operating system settings for the virtual machines in a scale set.
+type VMScaleSetOSProfile struct {
+	// Specifies the name of the administrator account.
+	AdminUsername string `json:"adminUsername"`
+
+	// Specifies whether extension operations should be allowed on the virtual machines in the scale set.
+	AllowExtensionOperations bool `json:"allowExtensionOperations"`
+
+	// Specifies the computer name prefix for all of the virtual machines in the scale set. Computer name prefixes must
+	// be 1 to 15 characters long.
+	ComputerNamePrefix string `json:"computerNamePrefix"`
+
+	// Specifies the Linux operating system settings on the virtual machines in the scale set.
+	LinuxConfiguration LinuxConfiguration `json:"linuxConfiguration,omitempty"`
+
+	// Specifies set of certificates that should be installed onto the virtual machines in the scale set.
+	Secrets []VaultSecretGroup `json:"secrets"`
+
+	// Specifies Windows operating system settings on the virtual machines in the scale set.
+	WindowsConfiguration WindowsConfiguration `json:"windowsConfiguration"`
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+	"github.com/gofrs/uuid"
+)
+
+// KeyCredential is the metadata of an application or service principal key credential. The key material itself is
+// deliberately omitted.
+type KeyCredential struct {
+	DisplayName   string    `json:"displayName"`
+	EndDateTime   string    `json:"endDateTime"`
+	KeyId         uuid.UUID `json:"keyId"`
+	StartDateTime string    `json:"startDateTime"`
+	Type          string    `json:"type"`
+	Usage         string    `json:"usage"`
+}
+
+// PasswordCredential is the metadata of an application or service principal password credential. The secret and its
+// hint are deliberately omitted.
+type PasswordCredential struct {
+	DisplayName   string    `json:"displayName"`
+	EndDateTime   string    `json:"endDateTime"`
+	KeyId         uuid.UUID `json:"keyId"`
+	StartDateTime string    `json:"startDateTime"`
+}
+
+func NewKeyCredentials(credentials []azure.KeyCredential) []KeyCredential {
+	out := make([]KeyCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		out = append(out, KeyCredential{
+			DisplayName:   credential.DisplayName,
+			EndDateTime:   credential.EndDateTime,
+			KeyId:         credential.KeyId,
+			StartDateTime: credential.StartDateTime,
+			Type:          credential.Type,
+			Usage:         credential.Usage,
+		})
+	}
+	return out
+}
+
+func NewPasswordCredentials(credentials []azure.PasswordCredential) []PasswordCredential {
+	out := make([]PasswordCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		out = append(out, PasswordCredential{
+			DisplayName:   credential.DisplayName,
+			EndDateTime:   credential.EndDateTime,
+			KeyId:         credential.KeyId,
+			StartDateTime: credential.StartDateTime,
+		})
+	}
+	return out
+}
+
+// CredentialHygiene summarizes the credentials of an application or service principal so that credentials about to
+// expire (outage risk) or added very recently (possible persistence) stand out without inspecting each credential.
+// Credentials without a parseable date are counted but don't contribute to the dates.
+type CredentialHygiene struct {
+	CredentialCount                 int        `json:"credentialCount"`
+	NewestCredentialCreated         *time.Time `json:"newestCredentialCreated"`
+	SoonestCredentialExpiry         *time.Time `json:"soonestCredentialExpiry"`
+	RecentCredentialAddedWithinDays bool       `json:"recentCredentialAddedWithinDays"`
+}
+
+// NewCredentialHygiene computes the credential hygiene as of now. A credential counts as recently added if it was
+// created within recentDays of now.
+func NewCredentialHygiene(keys []KeyCredential, passwords []PasswordCredential, now time.Time, recentDays int) CredentialHygiene {
+	var (
+		hygiene = CredentialHygiene{CredentialCount: len(keys) + len(passwords)}
+		starts  = make([]string, 0, hygiene.CredentialCount)
+		ends    = make([]string, 0, hygiene.CredentialCount)
+	)
+
+	for _, credential := range keys {
+		starts = append(starts, credential.StartDateTime)
+		ends = append(ends, credential.EndDateTime)
+	}
+	for _, credential := range passwords {
+		starts = append(starts, credential.StartDateTime)
+		ends = append(ends, credential.EndDateTime)
+	}
+
+	for _, start := range starts {
+		if created, err := time.Parse(time.RFC3339, start); err == nil && (hygiene.NewestCredentialCreated == nil || created.After(*hygiene.NewestCredentialCreated)) {
+			hygiene.NewestCredentialCreated = &created
+		}
+	}
+	for _, end := range ends {
+		if expiry, err := time.Parse(time.RFC3339, end); err == nil && (hygiene.SoonestCredentialExpiry == nil || expiry.Before(*hygiene.SoonestCredentialExpiry)) {
+			hygiene.SoonestCredentialExpiry = &expiry
+		}
+	}
+
+	if hygiene.NewestCredentialCreated != nil {
+		hygiene.RecentCredentialAddedWithinDays = !hygiene.NewestCredentialCreated.Before(now.AddDate(0, 0, -recentDays))
+	}
+	return hygiene
+}
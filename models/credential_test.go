@@ -0,0 +1,108 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCredentialHygiene(t *testing.T) {
+	var (
+		now  = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+		date = func(value string) *time.Time {
+			parsed, _ := time.Parse(time.RFC3339, value)
+			return &parsed
+		}
+	)
+
+	tests := []struct {
+		name      string
+		keys      []KeyCredential
+		passwords []PasswordCredential
+		want      CredentialHygiene
+	}{
+		{
+			name: "no credentials",
+			want: CredentialHygiene{},
+		},
+		{
+			name:      "single secret expiring soon",
+			passwords: []PasswordCredential{{StartDateTime: "2022-06-15T00:00:00Z", EndDateTime: "2023-06-15T00:00:00Z"}},
+			want: CredentialHygiene{
+				CredentialCount:         1,
+				NewestCredentialCreated: date("2022-06-15T00:00:00Z"),
+				SoonestCredentialExpiry: date("2023-06-15T00:00:00Z"),
+			},
+		},
+		{
+			name:      "secret added recently",
+			keys:      []KeyCredential{{StartDateTime: "2021-01-01T00:00:00Z", EndDateTime: "2024-01-01T00:00:00Z"}},
+			passwords: []PasswordCredential{{StartDateTime: "2023-05-30T12:00:00.123Z", EndDateTime: "2025-05-30T12:00:00Z"}},
+			want: CredentialHygiene{
+				CredentialCount:                 2,
+				NewestCredentialCreated:         date("2023-05-30T12:00:00.123Z"),
+				SoonestCredentialExpiry:         date("2024-01-01T00:00:00Z"),
+				RecentCredentialAddedWithinDays: true,
+			},
+		},
+		{
+			name:      "created exactly at the recent boundary",
+			passwords: []PasswordCredential{{StartDateTime: "2023-05-25T00:00:00Z"}},
+			want: CredentialHygiene{
+				CredentialCount:                 1,
+				NewestCredentialCreated:         date("2023-05-25T00:00:00Z"),
+				RecentCredentialAddedWithinDays: true,
+			},
+		},
+		{
+			name:      "missing and malformed dates",
+			keys:      []KeyCredential{{}, {StartDateTime: "yesterday", EndDateTime: "tomorrow"}},
+			passwords: []PasswordCredential{{EndDateTime: "2023-07-01T00:00:00Z"}},
+			want: CredentialHygiene{
+				CredentialCount:         3,
+				SoonestCredentialExpiry: date("2023-07-01T00:00:00Z"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewCredentialHygiene(test.keys, test.passwords, now, 7)
+			if got.CredentialCount != test.want.CredentialCount {
+				t.Errorf("credentialCount: got %v, want %v", got.CredentialCount, test.want.CredentialCount)
+			}
+			if !equalTime(got.NewestCredentialCreated, test.want.NewestCredentialCreated) {
+				t.Errorf("newestCredentialCreated: got %v, want %v", got.NewestCredentialCreated, test.want.NewestCredentialCreated)
+			}
+			if !equalTime(got.SoonestCredentialExpiry, test.want.SoonestCredentialExpiry) {
+				t.Errorf("soonestCredentialExpiry: got %v, want %v", got.SoonestCredentialExpiry, test.want.SoonestCredentialExpiry)
+			}
+			if got.RecentCredentialAddedWithinDays != test.want.RecentCredentialAddedWithinDays {
+				t.Errorf("recentCredentialAddedWithinDays: got %v, want %v", got.RecentCredentialAddedWithinDays, test.want.RecentCredentialAddedWithinDays)
+			}
+		})
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "github.com/bloodhoundad/azurehound/v2/models/azure"
+
+// The cross-tenant access settings of a tenant, collected once per tenant: its default settings, the settings it has
+// for each partner tenant and the trust each partner effectively gets. Any part the credential isn't permitted to
+// read is left out.
+type CrossTenantAccessPolicy struct {
+	Default *azure.CrossTenantAccessPolicyConfigurationDefault `json:"default,omitempty"`
+	// The settings of partner tenants; empty when there are none and null when they couldn't be read
+	Partners []azure.CrossTenantAccessPolicyConfigurationPartner `json:"partners"`
+
+	// The trust extended to each partner tenant, taking the default settings wherever the partner has none of its own
+	PartnerTrust []CrossTenantPartnerTrust `json:"partnerTrust"`
+
+	TenantId   string `json:"tenantId"`
+	TenantName string `json:"tenantName"`
+}
+
+// The trust a tenant extends to a partner tenant. A trusted MFA or device claim made by the partner satisfies the
+// tenant's Conditional Access policies without the user being challenged again.
+type CrossTenantPartnerTrust struct {
+	TenantId string `json:"tenantId"`
+
+	MfaAccepted                bool `json:"mfaAccepted"`
+	CompliantDeviceAccepted    bool `json:"compliantDeviceAccepted"`
+	HybridJoinedDeviceAccepted bool `json:"hybridJoinedDeviceAccepted"`
+
+	// Whether invitations between the tenants are redeemed without users having to consent
+	AutomaticRedemptionInbound  bool `json:"automaticRedemptionInbound"`
+	AutomaticRedemptionOutbound bool `json:"automaticRedemptionOutbound"`
+}
+
+func NewCrossTenantAccessPolicy(defaults *azure.CrossTenantAccessPolicyConfigurationDefault, partners []azure.CrossTenantAccessPolicyConfigurationPartner, tenantId, tenantName string) CrossTenantAccessPolicy {
+	policy := CrossTenantAccessPolicy{
+		Default:    defaults,
+		Partners:   partners,
+		TenantId:   tenantId,
+		TenantName: tenantName,
+	}
+	if partners != nil {
+		policy.PartnerTrust = make([]CrossTenantPartnerTrust, 0, len(partners))
+	}
+
+	for _, partner := range partners {
+		var (
+			trust       = CrossTenantPartnerTrust{TenantId: partner.TenantId}
+			inbound     = partner.InboundTrust
+			redemptions = partner.AutomaticUserConsentSettings
+		)
+		if inbound == nil && defaults != nil {
+			inbound = defaults.InboundTrust
+		}
+		if redemptions == nil && defaults != nil {
+			redemptions = defaults.AutomaticUserConsentSettings
+		}
+
+		if inbound != nil {
+			trust.MfaAccepted = inbound.IsMfaAccepted
+			trust.CompliantDeviceAccepted = inbound.IsCompliantDeviceAccepted
+			trust.HybridJoinedDeviceAccepted = inbound.IsHybridAzureADJoinedDeviceAccepted
+		}
+		if redemptions != nil {
+			trust.AutomaticRedemptionInbound = redemptions.InboundAllowed
+			trust.AutomaticRedemptionOutbound = redemptions.OutboundAllowed
+		}
+		policy.PartnerTrust = append(policy.PartnerTrust, trust)
+	}
+	return policy
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+)
+
+// DeviceUser is a user registered to a device. Unlike a DeviceOwner, a registered user does not control the device
+// object in Azure AD; they are a user of the device itself.
+type DeviceUser struct {
+	User     json.RawMessage `json:"user"`
+	DeviceId string          `json:"deviceId"`
+}
+
+type DeviceUsers struct {
+	Users    []DeviceUser `json:"users"`
+	DeviceId string       `json:"deviceId"`
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/bloodhoundad/azurehound/v2/models/azure"
 )
 
+// Device is serialized with the object id as "id" and the device registration id as "deviceId"; hybrid joined devices
+// additionally carry their on-premises SID as "onPremisesSecurityIdentifier".
 type Device struct {
 	azure.Device
 	TenantId   string `json:"tenantId"`
@@ -21,8 +21,9 @@ import "github.com/bloodhoundad/azurehound/v2/models/azure"
 
 type FunctionApp struct {
 	azure.FunctionApp
-	SubscriptionId    string `json:"subscriptionId"`
-	ResourceGroupId   string `json:"resourceGroupId"`
-	ResourceGroupName string `json:"resourceGroupName"`
-	TenantId          string `json:"tenantId"`
+	Authentication    *WebAppAuthentication `json:"authentication,omitempty"`
+	SubscriptionId    string                `json:"subscriptionId"`
+	ResourceGroupId   string                `json:"resourceGroupId"`
+	ResourceGroupName string                `json:"resourceGroupName"`
+	TenantId          string                `json:"tenantId"`
 }
@@ -0,0 +1,73 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func TestGroupSerializesFlags(t *testing.T) {
+	group := Group{
+		Group: azure.Group{
+			GroupTypes:         []string{"DynamicMembership"},
+			IsAssignableToRole: true,
+			MailEnabled:        true,
+			MembershipRule:     `user.department -eq "IT"`,
+			SecurityEnabled:    true,
+		},
+		TenantId: "tenant",
+	}
+	group.Id = "group"
+
+	var actual map[string]interface{}
+	if bytes, err := json.Marshal(group); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.Unmarshal(bytes, &actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]interface{}{
+		"id":                 "group",
+		"isAssignableToRole": true,
+		"mailEnabled":        true,
+		"membershipRule":     `user.department -eq "IT"`,
+		"securityEnabled":    true,
+		"tenantId":           "tenant",
+	} {
+		if actual[key] != want {
+			t.Errorf("got %v for %s, want %v", actual[key], key, want)
+		}
+	}
+	if groupTypes, ok := actual["groupTypes"].([]interface{}); !ok || len(groupTypes) != 1 || groupTypes[0] != "DynamicMembership" {
+		t.Errorf("got %v for groupTypes, want [DynamicMembership]", actual["groupTypes"])
+	}
+
+	// false flags are kept so a group that isn't role assignable can be told apart from one collected without the flag
+	group.IsAssignableToRole, group.MailEnabled = false, false
+	actual = nil
+	if bytes, err := json.Marshal(group); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.Unmarshal(bytes, &actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if actual["isAssignableToRole"] != false || actual["mailEnabled"] != false {
+		t.Errorf("expected false flags to be serialized: %v", actual)
+	}
+}
@@ -26,4 +26,12 @@ type Meta struct {
 	Type    string `json:"type"`
 	Version int    `json:"version"`
 	Count   int    `json:"count"`
+
+	// Describe the collection the file was written by; omitted when unknown so older readers are unaffected
+	TenantId        string `json:"tenantId,omitempty"`
+	CollectionStart string `json:"collectionStart,omitempty"`
+	CollectionEnd   string `json:"collectionEnd,omitempty"`
+
+	// Sample is the --sample limit of objects of each kind; a sampled collection is not a complete view of the tenant
+	Sample int `json:"sample,omitempty"`
 }
@@ -17,6 +17,10 @@ const (
 	JobStatusTimedOut  JobStatus = 4
 	JobStatusFailed    JobStatus = 5
 	JobStatusIngesting JobStatus = 6
+	JobStatusAnalyzing JobStatus = 7
+
+	// Collection finished but some of the data it was meant to collect is missing
+	JobStatusPartiallyComplete JobStatus = 8
 )
 
 func (s JobStatus) String() string {
@@ -42,6 +46,12 @@ func (s JobStatus) String() string {
 	case JobStatusIngesting:
 		return "INGESTING"
 
+	case JobStatusAnalyzing:
+		return "ANALYZING"
+
+	case JobStatusPartiallyComplete:
+		return "PARTIALLYCOMPLETE"
+
 	default:
 		return "INVALIDSTATUS"
 	}
@@ -0,0 +1,99 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// the workflow parameter holding the API connections a logic app uses
+const logicAppConnectionsParameter = "$connections"
+
+// LogicAppConnections links a logic app to the API connections its workflow uses. Anything that can edit the logic
+// app can act through these connections with the credentials stored in them.
+type LogicAppConnections struct {
+	Connections []LogicAppConnection `json:"connections"`
+	LogicAppId  string               `json:"logicAppId"`
+}
+
+// LogicAppConnection is an API connection used by a logic app, by reference only; the credentials of a connection are
+// never returned with the logic app
+type LogicAppConnection struct {
+	// The name of the connection within the workflow
+	Name string `json:"name"`
+	// The resource id of the Microsoft.Web/connections resource
+	ConnectionId   string `json:"connectionId"`
+	ConnectionName string `json:"connectionName,omitempty"`
+	// The resource id of the managed API the connection is for, e.g. .../managedApis/office365
+	ApiId string `json:"apiId,omitempty"`
+	// How the connection authenticates, e.g. ManagedServiceIdentity; empty for connections that store credentials
+	AuthenticationType string `json:"authenticationType,omitempty"`
+	// The user-assigned identity a ManagedServiceIdentity connection authenticates as
+	Identity string `json:"identity,omitempty"`
+}
+
+type logicAppConnectionParameter struct {
+	ConnectionId         string `json:"connectionId"`
+	ConnectionName       string `json:"connectionName"`
+	Id                   string `json:"id"`
+	ConnectionProperties struct {
+		Authentication struct {
+			Type     string `json:"type"`
+			Identity string `json:"identity"`
+		} `json:"authentication"`
+	} `json:"connectionProperties"`
+}
+
+// NewLogicAppConnections lists the API connections of the $connections parameter of a logic app ordered by name. The
+// default value in the workflow definition is used when the parameter has no value.
+func NewLogicAppConnections(logicApp azure.LogicApp) []LogicAppConnection {
+	var value interface{}
+	if parameter, ok := logicApp.Properties.Parameters[logicAppConnectionsParameter]; ok && parameter.Value != nil {
+		value = parameter.Value
+	} else if parameter, ok := logicApp.Properties.Definition.Parameters[logicAppConnectionsParameter]; ok {
+		value = parameter.DefaultValue
+	}
+
+	var parameters map[string]logicAppConnectionParameter
+	if value == nil {
+		return []LogicAppConnection{}
+	} else if bytes, err := json.Marshal(value); err != nil {
+		return []LogicAppConnection{}
+	} else if err := json.Unmarshal(bytes, &parameters); err != nil {
+		return []LogicAppConnection{}
+	}
+
+	out := make([]LogicAppConnection, 0, len(parameters))
+	for name, parameter := range parameters {
+		if parameter.ConnectionId != "" {
+			out = append(out, LogicAppConnection{
+				Name:               name,
+				ConnectionId:       parameter.ConnectionId,
+				ConnectionName:     parameter.ConnectionName,
+				ApiId:              parameter.Id,
+				AuthenticationType: parameter.ConnectionProperties.Authentication.Type,
+				Identity:           parameter.ConnectionProperties.Authentication.Identity,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
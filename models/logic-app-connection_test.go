@@ -0,0 +1,83 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+const testLogicApp = `{
+	"id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Logic/workflows/app",
+	"properties": {
+		"parameters": {
+			"$connections": {
+				"value": {
+					"office365": {
+						"connectionId": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Web/connections/office365",
+						"connectionName": "office365",
+						"id": "/subscriptions/sub/providers/Microsoft.Web/locations/westus/managedApis/office365"
+					},
+					"keyvault": {
+						"connectionId": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Web/connections/keyvault",
+						"connectionName": "keyvault",
+						"connectionProperties": {
+							"authentication": {
+								"type": "ManagedServiceIdentity",
+								"identity": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id"
+							}
+						},
+						"id": "/subscriptions/sub/providers/Microsoft.Web/locations/westus/managedApis/keyvault"
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestNewLogicAppConnections(t *testing.T) {
+	var logicApp azure.LogicApp
+	if err := json.Unmarshal([]byte(testLogicApp), &logicApp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if connections := NewLogicAppConnections(logicApp); len(connections) != 2 {
+		t.Fatalf("got %v connections, want 2", len(connections))
+	} else if keyVault := connections[0]; keyVault.Name != "keyvault" || keyVault.AuthenticationType != "ManagedServiceIdentity" || keyVault.Identity == "" {
+		t.Errorf("unexpected managed identity connection: %+v", keyVault)
+	} else if office := connections[1]; office.ConnectionId != "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Web/connections/office365" || office.ApiId != "/subscriptions/sub/providers/Microsoft.Web/locations/westus/managedApis/office365" || office.AuthenticationType != "" {
+		t.Errorf("unexpected connection: %+v", office)
+	}
+
+	// logic apps deployed without parameter values keep their connections in the definition
+	definition := azure.LogicApp{}
+	definition.Properties.Definition.Parameters = map[string]azure.Parameter{
+		"$connections": {DefaultValue: map[string]interface{}{"sql": map[string]interface{}{"connectionId": "/connections/sql"}}},
+	}
+	if connections := NewLogicAppConnections(definition); len(connections) != 1 || connections[0].ConnectionId != "/connections/sql" {
+		t.Errorf("got %+v, want the connection of the definition", connections)
+	}
+
+	malformed := azure.LogicApp{}
+	malformed.Properties.Parameters = map[string]azure.LogicAppParameter{"$connections": {Value: "not an object"}}
+	if connections := NewLogicAppConnections(malformed); len(connections) != 0 {
+		t.Errorf("got %+v, want no connections", connections)
+	}
+}
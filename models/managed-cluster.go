@@ -24,4 +24,25 @@ type ManagedCluster struct {
 	SubscriptionId  string `json:"subscriptionId"`
 	ResourceGroupId string `json:"resourceGroupId"`
 	TenantId        string `json:"tenantId"`
+
+	// Whether users sign in to Kubernetes with Azure AD, either managed or legacy, which bridges Azure AD identities
+	// into the cluster
+	EntraIntegrated bool `json:"entraIntegrated"`
+	// Whether Kubernetes authorization is decided by Azure role assignments scoped to the cluster
+	AzureRbacEnabled bool `json:"azureRbacEnabled"`
+	// Whether local accounts, whose credentials grant cluster admin without Azure AD, can be used
+	LocalAccountsEnabled bool `json:"localAccountsEnabled"`
+}
+
+func NewManagedCluster(cluster azure.ManagedCluster, subscriptionId string, resourceGroupId string, tenantId string) ManagedCluster {
+	aad := cluster.Properties.AadProfile
+	return ManagedCluster{
+		ManagedCluster:       cluster,
+		SubscriptionId:       subscriptionId,
+		ResourceGroupId:      resourceGroupId,
+		TenantId:             tenantId,
+		EntraIntegrated:      aad != nil,
+		AzureRbacEnabled:     aad != nil && aad.Managed && aad.EnableAzureRBAC,
+		LocalAccountsEnabled: !cluster.Properties.DisableLocalAccounts,
+	}
 }
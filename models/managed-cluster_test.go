@@ -0,0 +1,46 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+func TestNewManagedCluster(t *testing.T) {
+	tests := []struct {
+		name                  string
+		properties            azure.ManagedClusterProperties
+		entra, rbac, accounts bool
+	}{
+		{"kubernetes only", azure.ManagedClusterProperties{EnableRBAC: true}, false, false, true},
+		{"legacy azure ad", azure.ManagedClusterProperties{AadProfile: &azure.ManagedClusterAADProfile{}}, true, false, true},
+		{"managed azure ad", azure.ManagedClusterProperties{AadProfile: &azure.ManagedClusterAADProfile{Managed: true}}, true, false, true},
+		{"azure rbac", azure.ManagedClusterProperties{AadProfile: &azure.ManagedClusterAADProfile{Managed: true, EnableAzureRBAC: true}, DisableLocalAccounts: true}, true, true, false},
+	}
+
+	for _, test := range tests {
+		cluster := NewManagedCluster(azure.ManagedCluster{Properties: test.properties}, "subscription", "group", "tenant")
+		if cluster.EntraIntegrated != test.entra || cluster.AzureRbacEnabled != test.rbac || cluster.LocalAccountsEnabled != test.accounts {
+			t.Errorf("%s: got %v, %v, %v, want %v, %v, %v", test.name, cluster.EntraIntegrated, cluster.AzureRbacEnabled, cluster.LocalAccountsEnabled, test.entra, test.rbac, test.accounts)
+		} else if cluster.SubscriptionId != "subscription" || cluster.ResourceGroupId != "group" || cluster.TenantId != "tenant" {
+			t.Errorf("%s: unexpected ids: %+v", test.name, cluster)
+		}
+	}
+}
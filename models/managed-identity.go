@@ -0,0 +1,63 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// ManagedIdentity is a managed identity attached to a resource. Anything running on the resource can request tokens
+// as the identity, so it inherits the identity's permissions.
+type ManagedIdentity struct {
+	ClientId    string         `json:"clientId,omitempty"`
+	PrincipalId string         `json:"principalId"`
+	ResourceId  string         `json:"resourceId,omitempty"` // The resource id of a user-assigned identity
+	Type        enums.Identity `json:"type"`
+}
+
+// NewManagedIdentities lists the system-assigned identity of a resource, if any, followed by its user-assigned
+// identities ordered by resource id
+func NewManagedIdentities(identity azure.ManagedIdentity) []ManagedIdentity {
+	out := make([]ManagedIdentity, 0, len(identity.UserAssignedIdentities)+1)
+	if identity.PrincipalId != "" {
+		out = append(out, ManagedIdentity{
+			PrincipalId: identity.PrincipalId,
+			Type:        enums.IdentitySystemAssigned,
+		})
+	}
+
+	resourceIds := make([]string, 0, len(identity.UserAssignedIdentities))
+	for resourceId := range identity.UserAssignedIdentities {
+		resourceIds = append(resourceIds, resourceId)
+	}
+	sort.Strings(resourceIds)
+
+	for _, resourceId := range resourceIds {
+		userAssigned := identity.UserAssignedIdentities[resourceId]
+		out = append(out, ManagedIdentity{
+			ClientId:    userAssigned.ClientId,
+			PrincipalId: userAssigned.PrincipalId,
+			ResourceId:  resourceId,
+			Type:        enums.IdentityUserAssigned,
+		})
+	}
+	return out
+}
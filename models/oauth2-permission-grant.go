@@ -0,0 +1,44 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+type OAuth2PermissionGrant struct {
+	azure.OAuth2PermissionGrant
+	// The individual scopes of the grant
+	Scopes []string `json:"scopes"`
+	// Whether the grant applies to every user in the tenant (AllPrincipals) rather than a single user (Principal)
+	TenantWide bool   `json:"tenantWide"`
+	TenantId   string `json:"tenantId"`
+	TenantName string `json:"tenantName"`
+}
+
+func NewOAuth2PermissionGrant(grant azure.OAuth2PermissionGrant, tenant azure.Tenant) OAuth2PermissionGrant {
+	return OAuth2PermissionGrant{
+		OAuth2PermissionGrant: grant,
+		Scopes:                strings.Fields(grant.Scope),
+		TenantWide:            grant.ConsentType == azure.ConsentTypeAllPrincipals,
+		TenantId:              tenant.TenantId,
+		TenantName:            tenant.DisplayName,
+	}
+}
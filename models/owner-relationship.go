@@ -0,0 +1,54 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// OwnerRelationship links an owner to the app or service principal it owns. Owners can add credentials to the objects
+// they own, so each ownership is emitted on its own along with the @odata.type of the owner (e.g.
+// #microsoft.graph.user or #microsoft.graph.servicePrincipal).
+type OwnerRelationship struct {
+	OwnerId    string     `json:"ownerId"`
+	OwnerType  string     `json:"ownerType"`
+	TargetId   string     `json:"targetId"`
+	TargetKind enums.Kind `json:"targetKind"`
+}
+
+// NewOwnerRelationship creates the relationship between the raw directory object returned by an owners endpoint and
+// the object it owns
+func NewOwnerRelationship(owner json.RawMessage, targetId string, targetKind enums.Kind) (OwnerRelationship, error) {
+	var object azure.DirectoryObject
+	if err := json.Unmarshal(owner, &object); err != nil {
+		return OwnerRelationship{}, err
+	} else if object.Id == "" {
+		return OwnerRelationship{}, fmt.Errorf("owner of %s has no id", targetId)
+	} else {
+		return OwnerRelationship{
+			OwnerId:    object.Id,
+			OwnerType:  object.Type,
+			TargetId:   targetId,
+			TargetKind: targetKind,
+		}, nil
+	}
+}
@@ -21,6 +21,9 @@ import "github.com/bloodhoundad/azurehound/v2/models/azure"
 
 type ServicePrincipal struct {
 	azure.ServicePrincipal
-	TenantId   string `json:"tenantId"`
-	TenantName string `json:"tenantName"`
+	CredentialHygiene
+	KeyCredentials      []KeyCredential      `json:"keyCredentials"`
+	PasswordCredentials []PasswordCredential `json:"passwordCredentials"`
+	TenantId            string               `json:"tenantId"`
+	TenantName          string               `json:"tenantName"`
 }
@@ -0,0 +1,62 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+// The classes of the quota ids of well-known Azure offers, keyed by lowercase quota id.
+// See https://learn.microsoft.com/en-us/azure/cost-management-billing/costs/understand-cost-mgt-data#supported-microsoft-azure-offers
+var subscriptionClasses = map[string]enums.SubscriptionClass{
+	// Paid offers that run production workloads
+	"azureinopen_2014-09-01":         enums.SubscriptionClassProduction,
+	"csp_2015-05-01":                 enums.SubscriptionClassProduction,
+	"csp_mg_2017-12-01":              enums.SubscriptionClassProduction,
+	"enterpriseagreement_2014-09-01": enums.SubscriptionClassProduction,
+	"internal_2014-09-01":            enums.SubscriptionClassProduction,
+	"payasyougo_2014-09-01":          enums.SubscriptionClassProduction,
+	"sponsored_2016-01-01":           enums.SubscriptionClassProduction,
+
+	// Discounted offers restricted to development and testing
+	"msdndevtest_2014-09-01": enums.SubscriptionClassDevTest,
+
+	// Credit based, trial and personal offers such as Visual Studio subscriber credits
+	"azureforstudents_2018-01-01":     enums.SubscriptionClassSandbox,
+	"azureforstudentsfree_2018-01-01": enums.SubscriptionClassSandbox,
+	"azurepass_2014-09-01":            enums.SubscriptionClassSandbox,
+	"bizspark_2014-09-01":             enums.SubscriptionClassSandbox,
+	"bizsparkplus_2014-09-01":         enums.SubscriptionClassSandbox,
+	"dreamspark_2015-02-01":           enums.SubscriptionClassSandbox,
+	"freetrial_2014-09-01":            enums.SubscriptionClassSandbox,
+	"lightweighttrial_2016-09-01":     enums.SubscriptionClassSandbox,
+	"mpn_2014-09-01":                  enums.SubscriptionClassSandbox,
+	"msdn_2014-09-01":                 enums.SubscriptionClassSandbox,
+}
+
+// NewSubscriptionClass classifies a subscription by its quota id; quota ids of offers that aren't well-known are
+// Unknown
+func NewSubscriptionClass(quotaId string) enums.SubscriptionClass {
+	if class, ok := subscriptionClasses[strings.ToLower(quotaId)]; ok {
+		return class
+	} else {
+		return enums.SubscriptionClassUnknown
+	}
+}
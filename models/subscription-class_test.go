@@ -0,0 +1,64 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+func TestNewSubscriptionClass(t *testing.T) {
+	tests := []struct {
+		quotaId string
+		want    enums.SubscriptionClass
+	}{
+		{"PayAsYouGo_2014-09-01", enums.SubscriptionClassProduction},
+		{"EnterpriseAgreement_2014-09-01", enums.SubscriptionClassProduction},
+		{"CSP_2015-05-01", enums.SubscriptionClassProduction},
+		{"MSDNDevTest_2014-09-01", enums.SubscriptionClassDevTest},
+		{"MSDN_2014-09-01", enums.SubscriptionClassSandbox},
+		{"MPN_2014-09-01", enums.SubscriptionClassSandbox},
+		{"FreeTrial_2014-09-01", enums.SubscriptionClassSandbox},
+		{"AzureForStudents_2018-01-01", enums.SubscriptionClassSandbox},
+		{"msdn_2014-09-01", enums.SubscriptionClassSandbox},
+		{"MSDN_2099-01-01", enums.SubscriptionClassUnknown},
+		{"", enums.SubscriptionClassUnknown},
+	}
+
+	for _, test := range tests {
+		if actual := NewSubscriptionClass(test.quotaId); actual != test.want {
+			t.Errorf("%q: got %v, want %v", test.quotaId, actual, test.want)
+		}
+	}
+}
+
+func TestSubscriptionClassesAreKnown(t *testing.T) {
+	known := map[enums.SubscriptionClass]bool{}
+	for _, class := range enums.SubscriptionClasses() {
+		known[class] = true
+	}
+
+	for quotaId, class := range subscriptionClasses {
+		if !known[class] {
+			t.Errorf("%s: unsupported subscription class %v", quotaId, class)
+		} else if class == enums.SubscriptionClassUnknown {
+			t.Errorf("%s: well-known quota ids should not be mapped to %v", quotaId, class)
+		}
+	}
+}
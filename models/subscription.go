@@ -17,9 +17,13 @@
 
 package models
 
-import "github.com/bloodhoundad/azurehound/v2/models/azure"
+import (
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
 
 type Subscription struct {
 	azure.Subscription
-	TenantId string `json:"tenantId"`
+	SubscriptionClass enums.SubscriptionClass `json:"subscriptionClass"`
+	TenantId          string                  `json:"tenantId"`
 }
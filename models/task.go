@@ -35,4 +35,11 @@ type ClientTask struct {
 	StartTime             time.Time `json:"start_time"`
 	Status                int       `json:"status"`
 	UpdatedAt             time.Time `json:"updated_at"`
+
+	// The parts of an Azure tenant to collect; instances that don't send them expect everything to be collected
+	AzureADCollection *bool `json:"azure_ad_collection,omitempty"`
+	AzureRMCollection *bool `json:"azure_rm_collection,omitempty"`
+
+	// The object kinds to collect, e.g. AZUser; instances that don't send them expect every kind to be collected
+	CollectionKinds []string `json:"collection_kinds,omitempty"`
 }
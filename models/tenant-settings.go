@@ -0,0 +1,108 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/models/azure"
+)
+
+// How much of the directory guest users can read, as set by the guest user role of the authorization policy
+const (
+	GuestAccessMember     = "member"
+	GuestAccessLimited    = "limited"
+	GuestAccessRestricted = "restricted"
+)
+
+// What users may consent to on their own behalf, as set by the permission grant policies of the default user role
+const (
+	UserConsentAll       = "all"
+	UserConsentLowImpact = "lowImpact"
+	UserConsentCustom    = "custom"
+	UserConsentDisabled  = "disabled"
+)
+
+var guestAccessRoles = map[string]string{
+	"a0b1b346-4d3e-4e8b-98f8-753987be4970": GuestAccessMember,
+	"10dae51f-b6af-4016-8d66-8c2a99b929b3": GuestAccessLimited,
+	"2af84b1e-32c8-42b7-82bc-daa82404023b": GuestAccessRestricted,
+}
+
+const (
+	userConsentPolicyPrefix    = "ManagePermissionGrantsForSelf."
+	userConsentPolicyLegacy    = userConsentPolicyPrefix + "microsoft-user-default-legacy"
+	userConsentPolicyLowImpact = userConsentPolicyPrefix + "microsoft-user-default-low"
+)
+
+// The tenant-wide settings that gate self-service escalation, collected once per tenant. Any part the credential
+// isn't permitted to read is left out.
+type TenantSettings struct {
+	Organization        *azure.Organization        `json:"organization,omitempty"`
+	AuthorizationPolicy *azure.AuthorizationPolicy `json:"authorizationPolicy,omitempty"`
+	// The directory settings of the tenant; null when they couldn't be read
+	DirectorySettings []azure.GroupSetting `json:"directorySettings"`
+
+	// Whether users can register applications, derived from the authorization policy
+	UsersCanCreateApps *bool `json:"usersCanCreateApps,omitempty"`
+
+	// Whether users can create security groups, derived from the authorization policy
+	UsersCanCreateSecurityGroups *bool `json:"usersCanCreateSecurityGroups,omitempty"`
+
+	// What users may consent to on their own behalf, derived from the authorization policy
+	UserConsent string `json:"userConsent,omitempty"`
+
+	// How much of the directory guest users can read, derived from the authorization policy
+	GuestAccess string `json:"guestAccess,omitempty"`
+
+	TenantId   string `json:"tenantId"`
+	TenantName string `json:"tenantName"`
+}
+
+func NewTenantSettings(organization *azure.Organization, policy *azure.AuthorizationPolicy, settings []azure.GroupSetting, tenantId, tenantName string) TenantSettings {
+	tenantSettings := TenantSettings{
+		Organization:        organization,
+		AuthorizationPolicy: policy,
+		DirectorySettings:   settings,
+		TenantId:            tenantId,
+		TenantName:          tenantName,
+	}
+	if policy != nil {
+		var (
+			permissions   = policy.DefaultUserRolePermissions
+			createApps    = permissions.AllowedToCreateApps
+			createGroups  = permissions.AllowedToCreateSecurityGroups
+			consentPolicy = UserConsentDisabled
+		)
+		for _, id := range permissions.PermissionGrantPoliciesAssigned {
+			if id == userConsentPolicyLegacy {
+				consentPolicy = UserConsentAll
+			} else if id == userConsentPolicyLowImpact && consentPolicy != UserConsentAll {
+				consentPolicy = UserConsentLowImpact
+			} else if strings.HasPrefix(id, userConsentPolicyPrefix) && consentPolicy == UserConsentDisabled {
+				consentPolicy = UserConsentCustom
+			}
+		}
+
+		tenantSettings.UsersCanCreateApps = &createApps
+		tenantSettings.UsersCanCreateSecurityGroups = &createGroups
+		tenantSettings.UserConsent = consentPolicy
+		tenantSettings.GuestAccess = guestAccessRoles[strings.ToLower(policy.GuestUserRoleId)]
+	}
+	return tenantSettings
+}
@@ -23,4 +23,5 @@ type UpdateClientRequest struct {
 	Hostname string `json:"hostname"`
 	Version  string `json:"version"`
 	UserSid  string `json:"usersid"`
+	TenantId string `json:"tenant_id,omitempty"`
 }
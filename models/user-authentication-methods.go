@@ -0,0 +1,35 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "github.com/bloodhoundad/azurehound/v2/models/azure"
+
+type UserAuthenticationMethod struct {
+	AuthenticationMethod azure.AuthenticationMethod `json:"authenticationMethod"`
+	// The kind of method without its namespace, e.g. fido2, microsoftAuthenticator, phone or password
+	MethodType string `json:"methodType"`
+	UserId     string `json:"userId"`
+}
+
+// UserAuthenticationMethods are the authentication methods a user has registered; a user without any methods other
+// than a password has not registered for multi-factor authentication
+type UserAuthenticationMethods struct {
+	Methods  []UserAuthenticationMethod `json:"methods"`
+	UserId   string                     `json:"userId"`
+	TenantId string                     `json:"tenantId"`
+}
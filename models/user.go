@@ -25,4 +25,6 @@ type User struct {
 	azure.User
 	TenantId   string `json:"tenantId"`
 	TenantName string `json:"tenantName"`
+	// Attributes joined from an external source by --enrich-users
+	Enrichment map[string]string `json:"enrichment,omitempty"`
 }
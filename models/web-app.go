@@ -21,8 +21,16 @@ import "github.com/bloodhoundad/azurehound/v2/models/azure"
 
 type WebApp struct {
 	azure.WebApp
-	SubscriptionId    string `json:"subscriptionId"`
-	ResourceGroupId   string `json:"resourceGroupId"`
-	ResourceGroupName string `json:"resourceGroupName"`
-	TenantId          string `json:"tenantId"`
+	Authentication    *WebAppAuthentication `json:"authentication,omitempty"`
+	SubscriptionId    string                `json:"subscriptionId"`
+	ResourceGroupId   string                `json:"resourceGroupId"`
+	ResourceGroupName string                `json:"resourceGroupName"`
+	TenantId          string                `json:"tenantId"`
+}
+
+// WebAppAuthentication is omitted when the authentication settings of a web or function app could not be read
+type WebAppAuthentication struct {
+	Enabled                     bool   `json:"enabled"`
+	RequireAuthentication       bool   `json:"requireAuthentication"`
+	UnauthenticatedClientAction string `json:"unauthenticatedClientAction,omitempty"`
 }
@@ -226,6 +226,149 @@ func Batch[D, T any](done <-chan D, in <-chan T, maxItems int, maxTimeout time.D
 	return out
 }
 
+// AdaptiveBatchConfig configures AdaptiveBatch
+type AdaptiveBatchConfig[T any] struct {
+	// The bounds of the item limit of a batch
+	MinItems int
+	MaxItems int
+
+	// A batch is flushed once the sizes of its items add up to TargetBytes, and before an item would take them over
+	// MaxBytes so that only an item larger than MaxBytes on its own can exceed it; both are ignored when Size is nil
+	TargetBytes int
+	MaxBytes    int
+	Size        func(T) int
+
+	// The longest a partial batch waits before it is flushed
+	MaxTimeout time.Duration
+
+	// OnFlush is called, when set, after each batch is sent
+	OnFlush func(BatchStats)
+}
+
+// BatchStats describes a batch sent by AdaptiveBatch
+type BatchStats struct {
+	Items int // The number of items in the batch
+	Bytes int // The size of the items in the batch
+	Limit int // The item limit the batch was collected under
+	Queue int // The number of items waiting to be batched once the batch was sent
+	Next  int // The item limit of the next batch
+}
+
+// AdaptiveBatch batches items like Batch but adjusts the item limit to the number of items waiting upstream. The limit
+// doubles, up to MaxItems, while items queue up faster than batches are consumed and halves, down to MinItems, when
+// the queue runs dry, so fast phases send fewer, larger batches and slow phases don't hold items back for long.
+func AdaptiveBatch[D, T any](done <-chan D, in <-chan T, cfg AdaptiveBatchConfig[T]) <-chan []T {
+	if cfg.MinItems < 1 {
+		cfg.MinItems = 1
+	}
+	if cfg.MaxItems < cfg.MinItems {
+		cfg.MaxItems = cfg.MinItems
+	}
+
+	var (
+		out = make(chan []T)
+		// the queue buffers the input so its depth can be measured
+		queue = make(chan T, cfg.MaxItems)
+	)
+
+	go func() {
+		defer close(queue)
+		for item := range OrDone(done, in) {
+			select {
+			case queue <- item:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+
+		var (
+			limit   = cfg.MinItems
+			timeout = time.After(cfg.MaxTimeout)
+			batch   []T
+			size    int
+		)
+
+		// flush sends the batch, reporting false if done closed before it was received
+		flush := func() bool {
+			select {
+			case out <- batch:
+			case <-done:
+				return false
+			}
+
+			stats := BatchStats{Items: len(batch), Bytes: size, Limit: limit, Queue: len(queue)}
+			if stats.Queue >= limit {
+				limit *= 2
+			} else if stats.Queue == 0 {
+				limit /= 2
+			}
+
+			if limit > cfg.MaxItems {
+				limit = cfg.MaxItems
+			} else if limit < cfg.MinItems {
+				limit = cfg.MinItems
+			}
+
+			stats.Next = limit
+			if cfg.OnFlush != nil {
+				cfg.OnFlush(stats)
+			}
+
+			batch = nil
+			size = 0
+			timeout = time.After(cfg.MaxTimeout)
+			return true
+		}
+
+		for {
+			select {
+			case <-done:
+				return
+			case item, ok := <-queue:
+				if !ok {
+					if len(batch) > 0 {
+						flush()
+					}
+					return
+				}
+
+				itemSize := 0
+				if cfg.Size != nil {
+					itemSize = cfg.Size(item)
+				}
+				if len(batch) > 0 && cfg.Size != nil && cfg.MaxBytes > 0 && size+itemSize > cfg.MaxBytes {
+					if !flush() {
+						return
+					}
+				}
+
+				batch = append(batch, item)
+				size += itemSize
+
+				if len(batch) >= limit || (cfg.Size != nil && cfg.TargetBytes > 0 && size >= cfg.TargetBytes) {
+					if !flush() {
+						return
+					}
+				}
+			case <-timeout:
+				if len(batch) > 0 {
+					if !flush() {
+						return
+					}
+				} else {
+					timeout = time.After(cfg.MaxTimeout)
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
 func FormatJson[D, T any](done <-chan D, in <-chan T) <-chan string {
 	out := make(chan string)
 
@@ -19,6 +19,8 @@ package pipeline_test
 
 import (
 	"fmt"
+	"math/rand"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -104,3 +106,227 @@ func TestDemux(t *testing.T) {
 	}
 
 }
+
+// TestAdaptiveBatchProperties produces and consumes at random rates and asserts that every item arrives exactly once,
+// in order, in batches within the configured bounds
+func TestAdaptiveBatchProperties(t *testing.T) {
+	for seed := int64(0); seed < 25; seed++ {
+		var (
+			random  = rand.New(rand.NewSource(seed))
+			count   = 200 + random.Intn(800)
+			produce = time.Duration(random.Intn(50)) * time.Microsecond
+			consume = time.Duration(random.Intn(50)) * time.Microsecond
+			in      = make(chan int)
+			done    = make(chan interface{})
+			cfg     = pipeline.AdaptiveBatchConfig[int]{
+				MinItems:    1 + random.Intn(8),
+				MaxItems:    16 + random.Intn(64),
+				TargetBytes: 100 + random.Intn(200),
+				Size:        func(int) int { return 4 },
+				MaxTimeout:  time.Millisecond,
+			}
+		)
+
+		go func() {
+			defer close(in)
+			for i := 0; i < count; i++ {
+				if random.Intn(4) == 0 {
+					time.Sleep(produce)
+				}
+				in <- i
+			}
+		}()
+
+		next := 0
+		for batch := range pipeline.AdaptiveBatch(done, in, cfg) {
+			if len(batch) == 0 || len(batch) > cfg.MaxItems || len(batch)*4 > cfg.TargetBytes+4 {
+				t.Errorf("seed %d: got a batch of %v items, want 1 to %v", seed, len(batch), cfg.MaxItems)
+			}
+			for _, item := range batch {
+				if item != next {
+					t.Fatalf("seed %d: got item %v, want %v", seed, item, next)
+				}
+				next++
+			}
+			time.Sleep(consume)
+		}
+
+		if next != count {
+			t.Errorf("seed %d: got %v items, want %v", seed, next, count)
+		}
+	}
+}
+
+func TestAdaptiveBatchGrowsAndShrinks(t *testing.T) {
+	var (
+		done  = make(chan interface{})
+		in    = make(chan int, 1000)
+		stats []pipeline.BatchStats
+		cfg   = pipeline.AdaptiveBatchConfig[int]{
+			MinItems:   4,
+			MaxItems:   64,
+			MaxTimeout: 5 * time.Millisecond,
+			OnFlush:    func(s pipeline.BatchStats) { stats = append(stats, s) },
+		}
+	)
+
+	// a burst of queued items followed by a trickle
+	for i := 0; i < 500; i++ {
+		in <- i
+	}
+	go func() {
+		defer close(in)
+		time.Sleep(20 * time.Millisecond)
+		for i := 0; i < 10; i++ {
+			in <- i
+			time.Sleep(2 * cfg.MaxTimeout)
+		}
+	}()
+
+	for range pipeline.AdaptiveBatch(done, in, cfg) {
+		// consume slowly while the burst is queued
+		time.Sleep(time.Millisecond)
+	}
+
+	largest := 0
+	for _, s := range stats {
+		if s.Items > largest {
+			largest = s.Items
+		}
+	}
+	if largest != cfg.MaxItems {
+		t.Errorf("got a largest batch of %v items, want %v", largest, cfg.MaxItems)
+	} else if last := stats[len(stats)-1]; last.Next != cfg.MinItems {
+		t.Errorf("got a final limit of %v, want %v", last.Next, cfg.MinItems)
+	}
+}
+
+func TestAdaptiveBatchTargetBytes(t *testing.T) {
+	var (
+		done = make(chan interface{})
+		in   = make(chan string)
+		cfg  = pipeline.AdaptiveBatchConfig[string]{
+			MinItems:    100,
+			MaxItems:    100,
+			TargetBytes: 10,
+			Size:        func(item string) int { return len(item) },
+			MaxTimeout:  time.Second,
+		}
+	)
+
+	go func() {
+		defer close(in)
+		for _, item := range []string{"aaaa", "bbbb", "cccc", "dddddddddddd", "e"} {
+			in <- item
+		}
+	}()
+
+	var lengths []int
+	for batch := range pipeline.AdaptiveBatch(done, in, cfg) {
+		lengths = append(lengths, len(batch))
+	}
+
+	if fmt.Sprint(lengths) != "[3 1 1]" {
+		t.Errorf("got batches of %v items, want [3 1 1]", lengths)
+	}
+}
+
+func TestAdaptiveBatchMaxBytes(t *testing.T) {
+	var (
+		done = make(chan interface{})
+		in   = make(chan string)
+		cfg  = pipeline.AdaptiveBatchConfig[string]{
+			MinItems:    100,
+			MaxItems:    100,
+			TargetBytes: 10,
+			MaxBytes:    10,
+			Size:        func(item string) int { return len(item) },
+			MaxTimeout:  time.Second,
+		}
+	)
+
+	go func() {
+		defer close(in)
+		for _, item := range []string{"aaaa", "bbbb", "cccc", "dddddddddddd", "e", "ffffffffff"} {
+			in <- item
+		}
+	}()
+
+	var lengths []int
+	for batch := range pipeline.AdaptiveBatch(done, in, cfg) {
+		lengths = append(lengths, len(batch))
+	}
+
+	// an item that would take a batch over MaxBytes starts the next one, and an item over it is sent on its own
+	if fmt.Sprint(lengths) != "[2 1 1 1 1]" {
+		t.Errorf("got batches of %v items, want [2 1 1 1 1]", lengths)
+	}
+}
+
+func TestAdaptiveBatchDoneWhileBlocked(t *testing.T) {
+	var (
+		done = make(chan interface{})
+		in   = make(chan int)
+		cfg  = pipeline.AdaptiveBatchConfig[int]{MinItems: 1, MaxItems: 1, MaxTimeout: time.Second}
+	)
+	defer close(in)
+
+	before := runtime.NumGoroutine()
+	pipeline.AdaptiveBatch(done, in, cfg)
+	// nothing reads the batch, as when ingest is aborted
+	in <- 1
+	close(done)
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("batcher did not stop once done closed")
+		}
+	}
+}
+
+// BenchmarkBatchRequests compares the number of batches, i.e. ingest requests, sent for the same volume of items by the
+// fixed and the adaptive batcher while the consumer is slower than the producer
+func BenchmarkBatchRequests(b *testing.B) {
+	const (
+		items    = 20000
+		itemSize = 512
+	)
+
+	run := func(b *testing.B, batch func(done chan interface{}, in <-chan int) <-chan []int) {
+		requests := 0
+		for n := 0; n < b.N; n++ {
+			var (
+				done = make(chan interface{})
+				in   = make(chan int)
+			)
+			go func() {
+				defer close(in)
+				for i := 0; i < items; i++ {
+					in <- i
+				}
+			}()
+			for range batch(done, in) {
+				requests++
+				time.Sleep(time.Millisecond)
+			}
+		}
+		b.ReportMetric(float64(requests)/float64(b.N), "requests/op")
+	}
+
+	b.Run("fixed", func(b *testing.B) {
+		run(b, func(done chan interface{}, in <-chan int) <-chan []int {
+			return pipeline.Batch(done, in, 256, 10*time.Second)
+		})
+	})
+	b.Run("adaptive", func(b *testing.B) {
+		run(b, func(done chan interface{}, in <-chan int) <-chan []int {
+			return pipeline.AdaptiveBatch(done, in, pipeline.AdaptiveBatchConfig[int]{
+				MinItems:    64,
+				MaxItems:    2048,
+				TargetBytes: 2 * 1024 * 1024,
+				Size:        func(int) int { return itemSize },
+				MaxTimeout:  10 * time.Second,
+			})
+		})
+	})
+}
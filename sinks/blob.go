@@ -0,0 +1,328 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BlobBlockSize is the size of each block staged by a blob sink, which is all of the output it holds in memory
+	BlobBlockSize = 8 * 1024 * 1024
+
+	// Azure Storage commits at most this many blocks to a blob
+	maxBlobBlocks = 50000
+
+	// the first version of the Blob service to accept bearer tokens
+	blobApiVersion = "2021-08-06"
+)
+
+// BlobSinkConfig configures NewBlobSink
+type BlobSinkConfig struct {
+	RemoteConfig
+	// Url is the container, or blob, to upload to. It carries a shared access signature unless AccountKey or Token
+	// is set.
+	Url string
+	// Name is the name of the blob when Url names a container
+	Name string
+	// AccountKey, when set, signs each request with the shared key of the storage account
+	AccountKey string
+	// Token, when set, returns the Authorization header value of each request, e.g. a bearer token for the identity
+	// that collected the tenant
+	Token func() (string, error)
+	// Compress gzip compresses the blob as it is uploaded
+	Compress bool
+}
+
+// ValidateBlobUrl checks rawUrl is an https URL of an Azure Blob Storage container, or of a blob in one
+func ValidateBlobUrl(rawUrl string) error {
+	if parsed, err := url.Parse(rawUrl); err != nil {
+		return err
+	} else if parsed.Scheme != "https" || !isBlobHost(parsed.Hostname()) {
+		return fmt.Errorf("%s is not an https://<account>.blob.core.windows.net/<container> URL", RedactUrl(rawUrl))
+	} else if strings.Trim(parsed.Path, "/") == "" {
+		return fmt.Errorf("%s has no container", RedactUrl(rawUrl))
+	} else {
+		return nil
+	}
+}
+
+// HasSharedAccessSignature reports whether rawUrl carries a shared access signature
+func HasSharedAccessSignature(rawUrl string) bool {
+	parsed, err := url.Parse(rawUrl)
+	return err == nil && parsed.Query().Has("sig")
+}
+
+// NewBlobSink returns a sink that uploads the collection to Azure Blob Storage as a block blob. The collection is
+// staged in blocks of BlobBlockSize as it is written, so it is never buffered in full, and the blob is committed once
+// the sink is closed. Each request is retried with backoff independently of the others when it fails or the block is
+// corrupted on the way.
+func NewBlobSink(ctx context.Context, config BlobSinkConfig) (OutputSink, error) {
+	if err := ValidateBlobUrl(config.Url); err != nil {
+		return nil, err
+	} else if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	parsed, _ := url.Parse(config.Url)
+	if !strings.Contains(strings.Trim(parsed.Path, "/"), "/") || strings.HasSuffix(parsed.Path, "/") {
+		parsed.Path = path.Join(parsed.Path, config.Name)
+	}
+	return newBlobSink(ctx, config, parsed.String(), BlobBlockSize)
+}
+
+func newBlobSink(ctx context.Context, config BlobSinkConfig, blobUrl string, blockSize int) (OutputSink, error) {
+	blob := &blobWriter{ctx: ctx, config: config, url: blobUrl, blockSize: blockSize}
+	if config.Compress {
+		return newCollectionSink(ctx, gzipFile{Writer: gzip.NewWriter(blob), file: blob})
+	} else {
+		return newCollectionSink(ctx, blob)
+	}
+}
+
+// blobWriter stages what is written to it as the blocks of a block blob, committing them when it is closed
+type blobWriter struct {
+	ctx       context.Context
+	config    BlobSinkConfig
+	url       string
+	blockSize int
+	block     bytes.Buffer
+	blocks    []string
+	err       error
+}
+
+func (s *blobWriter) Write(p []byte) (int, error) {
+	written := 0
+	for s.err == nil && written < len(p) {
+		n := len(p) - written
+		if space := s.blockSize - s.block.Len(); n > space {
+			n = space
+		}
+		s.block.Write(p[written : written+n])
+		if written += n; s.block.Len() == s.blockSize {
+			s.err = s.putBlock(s.ctx)
+		}
+	}
+	return written, s.err
+}
+
+// Close stages the last block and commits the blob, which is still done when the collection has been interrupted
+func (s *blobWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	ctx, cancel := shutdownContext(s.ctx)
+	defer cancel()
+	if err := s.putBlock(ctx); err != nil {
+		s.err = err
+	} else if err := s.putBlockList(ctx); err != nil {
+		s.err = fmt.Errorf("failed to commit %d blocks: %w", len(s.blocks), err)
+	}
+	return s.err
+}
+
+func (s *blobWriter) putBlock(ctx context.Context) error {
+	if s.block.Len() == 0 {
+		return nil
+	} else if len(s.blocks) >= maxBlobBlocks {
+		return fmt.Errorf("the output is larger than the %d blocks of %d bytes a blob can hold", maxBlobBlocks, s.blockSize)
+	}
+
+	var (
+		// the ids of a blob's blocks must all be the same length
+		id     = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", len(s.blocks))))
+		body   = s.block.Bytes()
+		digest = md5.Sum(body)
+		header = http.Header{}
+		query  = url.Values{"comp": {"block"}, "blockid": {id}}
+	)
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(digest[:]))
+	if err := retryRemote(ctx, s.config.RemoteConfig, func() error {
+		return s.send(ctx, query, header, body)
+	}); err != nil {
+		return fmt.Errorf("failed to stage block %d: %w", len(s.blocks), err)
+	} else {
+		s.blocks = append(s.blocks, id)
+		s.block.Reset()
+		return nil
+	}
+}
+
+func (s *blobWriter) putBlockList(ctx context.Context) error {
+	var (
+		body        bytes.Buffer
+		contentType = "application/json"
+	)
+	if s.config.Compress {
+		contentType = "application/gzip"
+	}
+
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, id := range s.blocks {
+		fmt.Fprintf(&body, "<Latest>%s</Latest>", id)
+	}
+	body.WriteString("</BlockList>")
+
+	var (
+		header = http.Header{}
+		query  = url.Values{"comp": {"blocklist"}}
+	)
+	header.Set("x-ms-blob-content-type", contentType)
+	return retryRemote(ctx, s.config.RemoteConfig, func() error {
+		return s.send(ctx, query, header, body.Bytes())
+	})
+}
+
+func (s *blobWriter) send(ctx context.Context, query url.Values, header http.Header, body []byte) error {
+	parsed, _ := url.Parse(s.url)
+	params := parsed.Query()
+	for name, values := range query {
+		params[name] = values
+	}
+	parsed.RawQuery = params.Encode()
+
+	if req, err := http.NewRequestWithContext(ctx, http.MethodPut, parsed.String(), bytes.NewReader(body)); err != nil {
+		return err
+	} else {
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("x-ms-version", blobApiVersion)
+		if err := s.authorize(req); err != nil {
+			return err
+		}
+
+		if res, err := s.config.Client.Do(req); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			// the error names the url, whose signature must not be logged
+			return fmt.Errorf("%w: request to %s failed", errRemoteTransient, RedactUrl(s.url))
+		} else {
+			defer res.Body.Close()
+			message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+			if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%w: %s", errRemoteTransient, res.Status)
+			} else if res.StatusCode == http.StatusBadRequest && strings.Contains(string(message), "Md5Mismatch") {
+				// the block was corrupted on the way, so sending it again may well succeed
+				return fmt.Errorf("%w: %s: %s", errRemoteTransient, res.Status, message)
+			} else if res.StatusCode >= http.StatusBadRequest {
+				return fmt.Errorf("azure storage responded %s: %s", res.Status, message)
+			} else {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *blobWriter) authorize(req *http.Request) error {
+	if s.config.AccountKey != "" {
+		return signSharedKey(req, s.config.AccountKey, time.Now())
+	} else if s.config.Token == nil {
+		// the shared access signature in the url authorizes the request
+		return nil
+	} else if authorization, err := s.config.Token(); err != nil {
+		return err
+	} else {
+		req.Header.Set("Authorization", authorization)
+		return nil
+	}
+}
+
+// signSharedKey authorizes req with the shared key of the storage account it is sent to
+func signSharedKey(req *http.Request, accountKey string, now time.Time) error {
+	account, _, _ := strings.Cut(req.URL.Hostname(), ".")
+	req.Header.Set("x-ms-date", now.UTC().Format(http.TimeFormat))
+
+	if key, err := base64.StdEncoding.DecodeString(accountKey); err != nil {
+		return fmt.Errorf("invalid account key: %w", err)
+	} else {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(sharedKeyStringToSign(req, account)))
+		req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", account, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+		return nil
+	}
+}
+
+// sharedKeyStringToSign is described at
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key#blob-queue-and-file-services-shared-key-authorization
+func sharedKeyStringToSign(req *http.Request, account string) string {
+	var (
+		contentLength string
+		headers       []string
+		params        []string
+		builder       strings.Builder
+	)
+
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	for _, value := range []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		req.Header.Get("Date"),
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	} {
+		builder.WriteString(value + "\n")
+	}
+
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name+":"+strings.TrimSpace(strings.Join(values, ",")))
+		}
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		builder.WriteString(header + "\n")
+	}
+
+	builder.WriteString("/" + account + req.URL.EscapedPath())
+	for name, values := range req.URL.Query() {
+		params = append(params, strings.ToLower(name)+":"+strings.Join(values, ","))
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		builder.WriteString("\n" + param)
+	}
+	return builder.String()
+}
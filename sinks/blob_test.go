@@ -0,0 +1,215 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blobServer stages blocks and commits them as Azure Storage does, failing the first request with 503
+type blobServer struct {
+	mutex    sync.Mutex
+	requests int
+	blocks   map[string][]byte
+	blob     []byte
+	header   http.Header
+	query    []string
+}
+
+func (s *blobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	s.header = r.Header
+	s.query = append(s.query, r.URL.RawQuery)
+	if s.requests++; s.requests == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else if r.Method != http.MethodPut || r.Header.Get("x-ms-version") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if query := r.URL.Query(); query.Get("comp") == "block" {
+		sum := md5.Sum(body)
+		if r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Md5Mismatch"))
+		} else {
+			s.blocks[query.Get("blockid")] = body
+			w.WriteHeader(http.StatusCreated)
+		}
+	} else if query.Get("comp") == "blocklist" {
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		if err := xml.Unmarshal(body, &list); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.blob = nil
+		for _, id := range list.Latest {
+			s.blob = append(s.blob, s.blocks[id]...)
+		}
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newBlobStream(count int) <-chan string {
+	stream := make(chan string, count)
+	for i := 0; i < count; i++ {
+		stream <- fmt.Sprintf(`{"kind":"AZUser","data":{"id":"%d"}}`, i)
+	}
+	close(stream)
+	return stream
+}
+
+func TestBlobSink(t *testing.T) {
+	blobs := &blobServer{blocks: map[string][]byte{}}
+	server := httptest.NewServer(blobs)
+	defer server.Close()
+
+	config := BlobSinkConfig{RemoteConfig: RemoteConfig{MaxRetries: 1, MaxBackoff: 1, Client: server.Client()}}
+	if sink, err := newBlobSink(context.Background(), config, server.URL+"/container/output.json?sig=abc", 64); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, newBlobStream(10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var collection struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if len(blobs.blocks) < 2 {
+		t.Errorf("got %v blocks, want the output staged in several", len(blobs.blocks))
+	} else if err := json.Unmarshal(blobs.blob, &collection); err != nil {
+		t.Fatalf("expected the blob to hold a single collection: %v", err)
+	} else if len(collection.Data) != 10 {
+		t.Errorf("got %v items, want 10", len(collection.Data))
+	} else if blobs.header.Get("x-ms-blob-content-type") != "application/json" {
+		t.Errorf("got content type %v, want %v", blobs.header.Get("x-ms-blob-content-type"), "application/json")
+	}
+
+	for _, query := range blobs.query {
+		if !strings.Contains(query, "sig=abc") {
+			t.Errorf("expected the shared access signature to be kept: %v", query)
+		}
+	}
+}
+
+func TestBlobSinkCompressed(t *testing.T) {
+	blobs := &blobServer{blocks: map[string][]byte{}}
+	server := httptest.NewServer(blobs)
+	defer server.Close()
+
+	config := BlobSinkConfig{RemoteConfig: RemoteConfig{MaxRetries: 1, MaxBackoff: 1, Client: server.Client()}, Compress: true}
+	if sink, err := newBlobSink(context.Background(), config, server.URL+"/container/output.json.gz?sig=abc", 16); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, newBlobStream(10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reader, err := gzip.NewReader(strings.NewReader(string(blobs.blob))); err != nil {
+		t.Fatalf("expected the blob to be gzip compressed: %v", err)
+	} else if content, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !json.Valid(content) {
+		t.Errorf("expected the blob to hold a single collection: %s", content)
+	}
+}
+
+func TestBlobSinkToken(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	config := BlobSinkConfig{
+		RemoteConfig: RemoteConfig{Client: server.Client()},
+		Token: func() (string, error) {
+			return "Bearer foo", nil
+		},
+	}
+	if sink, err := newBlobSink(context.Background(), config, server.URL+"/container/output.json", BlobBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, newBlobStream(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if authorization != "Bearer foo" {
+		t.Errorf("got %v, want %v", authorization, "Bearer foo")
+	}
+}
+
+func TestBlobSinkRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	config := BlobSinkConfig{RemoteConfig: RemoteConfig{MaxRetries: 2, MaxBackoff: 1, Client: server.Client()}}
+	if sink, err := newBlobSink(context.Background(), config, server.URL+"/container/output.json?sig=abc", BlobBlockSize); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, newBlobStream(1)); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestNewBlobSinkName(t *testing.T) {
+	for _, rawUrl := range []string{"https://account.blob.core.windows.net/container", "http://account.blob.core.windows.net/container", "https://example.com/container"} {
+		_, err := NewBlobSink(context.Background(), BlobSinkConfig{Url: rawUrl, Name: "output.json"})
+		if valid := strings.HasPrefix(rawUrl, "https://account"); valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", rawUrl, err)
+		} else if !valid && err == nil {
+			t.Errorf("%s: expected an error but did not receive one", rawUrl)
+		}
+	}
+}
+
+func TestSharedKeyStringToSign(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut, "https://account.blob.core.windows.net/container/output.json?comp=block&blockid=MDAwMDAwMDA%3D", strings.NewReader("hello"))
+	req.Header.Set("Content-MD5", "XUFAKrxLKna5cZ2REBfFkg==")
+	req.Header.Set("x-ms-version", "2021-08-06")
+	req.Header.Set("x-ms-date", "Mon, 01 Jan 2024 00:00:00 GMT")
+
+	want := "PUT\n\n\n5\nXUFAKrxLKna5cZ2REBfFkg==\n\n\n\n\n\n\n\n" +
+		"x-ms-date:Mon, 01 Jan 2024 00:00:00 GMT\nx-ms-version:2021-08-06\n" +
+		"/account/container/output.json\nblockid:MDAwMDAwMDA=\ncomp:block"
+	if got := sharedKeyStringToSign(req, "account"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := signSharedKey(req, base64.StdEncoding.EncodeToString([]byte("key")), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !strings.HasPrefix(req.Header.Get("Authorization"), "SharedKey account:") {
+		t.Errorf("got %v, want a shared key signature", req.Header.Get("Authorization"))
+	} else if err := signSharedKey(req, "not base64!", time.Now()); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
@@ -18,14 +18,47 @@
 package sinks
 
 import (
+	"compress/gzip"
 	"context"
-	"fmt"
-
-	"github.com/bloodhoundad/azurehound/v2/pipeline"
+	"io"
+	"os"
 )
 
-func WriteToConsole[T any](ctx context.Context, stream <-chan T) {
-	for item := range pipeline.OrDone(ctx.Done(), stream) {
-		fmt.Println(item)
+// Stdout is the output path that writes to standard output instead of a file
+const Stdout = "-"
+
+// stdout is where console output is written; logs go to stderr so it only ever carries collected data
+var stdout io.Writer = os.Stdout
+
+// IsStdout reports whether filePath selects standard output, either explicitly with Stdout or by being empty
+func IsStdout(filePath string) bool {
+	return filePath == "" || filePath == Stdout
+}
+
+// WriteToConsole writes stream to stdout as a collection, gzip compressing it when compress is set
+func WriteToConsole[T any](ctx context.Context, compress bool, stream <-chan T) error {
+	console := openConsole(compress)
+	if err := writeCollection(ctx, console, stream); err != nil {
+		console.Close()
+		return err
+	} else {
+		return console.Close()
+	}
+}
+
+// openConsole returns a writer for stdout whose Close flushes any compression but leaves stdout open
+func openConsole(compress bool) io.WriteCloser {
+	if compress {
+		return gzip.NewWriter(stdout)
+	} else {
+		return nopWriteCloser{stdout}
 	}
 }
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (s nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func captureStdout(t *testing.T) *bytes.Buffer {
+	var (
+		buffer   bytes.Buffer
+		original = stdout
+	)
+	stdout = &buffer
+	t.Cleanup(func() {
+		stdout = original
+	})
+	return &buffer
+}
+
+func newConsoleStream() <-chan string {
+	stream := make(chan string, 2)
+	stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+	stream <- `{"kind":"AZGroup","data":{"id":"2"}}`
+	close(stream)
+	return stream
+}
+
+func TestIsStdout(t *testing.T) {
+	for path, want := range map[string]bool{"": true, Stdout: true, "output.json": false, "-.json": false} {
+		if got := IsStdout(path); got != want {
+			t.Errorf("%q: got %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestWriteToConsole(t *testing.T) {
+	buffer := captureStdout(t)
+
+	var collection struct {
+		Data []json.RawMessage `json:"data"`
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	if err := WriteToConsole(context.Background(), false, newConsoleStream()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.Unmarshal(buffer.Bytes(), &collection); err != nil {
+		t.Fatalf("expected stdout to hold a single collection: %v", err)
+	} else if len(collection.Data) != 2 || collection.Meta.Count != 2 {
+		t.Errorf("got %v items and a count of %v, want 2", len(collection.Data), collection.Meta.Count)
+	}
+}
+
+func TestWriteNdjsonToConsoleCompressed(t *testing.T) {
+	buffer := captureStdout(t)
+
+	if err := WriteNdjsonToConsole(context.Background(), true, newConsoleStream()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if gz, err := gzip.NewReader(buffer); err != nil {
+		t.Fatalf("expected gzip compressed output: %v", err)
+	} else if data, err := io.ReadAll(gz); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 4 {
+		t.Errorf("got %v lines, want 4", len(lines))
+	}
+}
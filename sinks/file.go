@@ -18,47 +18,189 @@
 package sinks
 
 import (
+	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/bloodhoundad/azurehound/v2/models"
 	"github.com/bloodhoundad/azurehound/v2/pipeline"
 )
 
-func WriteToFile[T any](ctx context.Context, filePath string, stream <-chan T) error {
+// CompressedSuffix marks output files that are gzip compressed as they are written
+const CompressedSuffix = ".gz"
 
-	if file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666); err != nil {
+// IsCompressed reports whether the file at filePath is written gzip compressed
+func IsCompressed(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), CompressedSuffix)
+}
+
+// WriteToFile writes stream to filePath as a collection file, compressing it when IsCompressed(filePath). The footer
+// is written and the file closed once stream closes or ctx is done, so an interrupted collection still produces a
+// valid file.
+func WriteToFile[T any](ctx context.Context, filePath string, stream <-chan T) error {
+	if file, err := createFile(filePath); err != nil {
+		return err
+	} else if err := writeCollection(ctx, file, stream); err != nil {
+		file.Close()
 		return err
 	} else {
-		defer file.Close()
-
-		if _, err := file.WriteString("{\n\t\"data\": [\n"); err != nil {
-			return err
-		} else {
-			meta := models.Meta{
-				Type:    "azure",
-				Version: 5,
-				Count:   0,
-			}
+		return file.Close()
+	}
+}
 
-			format := "\t\t%v"
-			for item := range pipeline.OrDone(ctx.Done(), stream) {
-				if _, err := file.WriteString(fmt.Sprintf(format, item)); err != nil {
-					return err
-				}
-				meta.Count++
-				format = ",\n\t\t%v"
-			}
+// CollectionInfo describes the collection being written, for the meta footer of each collection file
+type CollectionInfo struct {
+	TenantId string
+	Start    time.Time
+	// Sample is the limit of objects of each kind when the collection is sampled, otherwise 0
+	Sample int
+}
 
-			if bytes, err := json.Marshal(meta); err != nil {
-				return err
-			} else if _, err := file.WriteString(fmt.Sprintf("\n\t],\n\t\"meta\": %s\n}\n", string(bytes))); err != nil {
+type collectionInfoKey struct{}
+
+// WithCollectionInfo returns a copy of ctx carrying info, which collection files written with it record in their meta
+// footer along with the time each one was completed
+func WithCollectionInfo(ctx context.Context, info CollectionInfo) context.Context {
+	return context.WithValue(ctx, collectionInfoKey{}, info)
+}
+
+func writeCollection[T any](ctx context.Context, w io.Writer, stream <-chan T) error {
+	if collection, err := newCollectionWriter(ctx, w); err != nil {
+		return err
+	} else {
+		for item := range pipeline.OrDone(ctx.Done(), stream) {
+			if err := collection.Write(item); err != nil {
 				return err
-			} else {
-				return nil
 			}
 		}
+		return collection.Close()
+	}
+}
+
+// collectionWriter writes the items of a collection file one at a time, keeping count for the meta footer
+type collectionWriter struct {
+	w      io.Writer
+	meta   models.Meta
+	format string
+}
+
+func newCollectionWriter(ctx context.Context, w io.Writer) (*collectionWriter, error) {
+	if _, err := io.WriteString(w, "{\n\t\"data\": [\n"); err != nil {
+		return nil, err
+	} else {
+		return &collectionWriter{
+			w:      w,
+			meta:   newMeta(ctx),
+			format: "\t\t%v",
+		}, nil
+	}
+}
+
+// newMeta returns the meta record of a collection with no items, describing the collection in ctx if there is one
+func newMeta(ctx context.Context) models.Meta {
+	meta := models.Meta{
+		Type:    "azure",
+		Version: 5,
+		Count:   0,
+	}
+	if info, ok := ctx.Value(collectionInfoKey{}).(CollectionInfo); ok {
+		meta.TenantId = info.TenantId
+		meta.Sample = info.Sample
+		if !info.Start.IsZero() {
+			meta.CollectionStart = info.Start.UTC().Format(time.RFC3339)
+		}
+	}
+	return meta
+}
+
+func (s *collectionWriter) Write(item any) error {
+	if _, err := fmt.Fprintf(s.w, s.format, item); err != nil {
+		return err
+	} else {
+		s.meta.Count++
+		s.format = ",\n\t\t%v"
+		return nil
+	}
+}
+
+// Close writes the footer; it does not close the underlying writer
+func (s *collectionWriter) Close() error {
+	if s.meta.CollectionStart != "" {
+		s.meta.CollectionEnd = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if bytes, err := json.Marshal(s.meta); err != nil {
+		return err
+	} else if _, err := fmt.Fprintf(s.w, "\n\t],\n\t\"meta\": %s\n}\n", string(bytes)); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+// OpenFile opens a collection file written by WriteToFile for reading, decompressing it when it is gzip compressed
+// regardless of its name
+func OpenFile(filePath string) (io.ReadCloser, error) {
+	if file, err := os.Open(filePath); err != nil {
+		return nil, err
+	} else if reader := bufio.NewReader(file); !isGzip(reader) {
+		return readCloser{Reader: reader, closers: []io.Closer{file}}, nil
+	} else if gz, err := gzip.NewReader(reader); err != nil {
+		file.Close()
+		return nil, err
+	} else {
+		return readCloser{Reader: gz, closers: []io.Closer{gz, file}}, nil
+	}
+}
+
+func isGzip(reader *bufio.Reader) bool {
+	magic, err := reader.Peek(2)
+	return err == nil && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (s readCloser) Close() error {
+	var err error
+	for _, closer := range s.closers {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// createFile creates or truncates filePath, wrapping it in a gzip writer when IsCompressed(filePath)
+func createFile(filePath string) (io.WriteCloser, error) {
+	if file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666); err != nil {
+		return nil, err
+	} else if !IsCompressed(filePath) {
+		return file, nil
+	} else {
+		return gzipFile{Writer: gzip.NewWriter(file), file: file}, nil
+	}
+}
+
+// gzipFile flushes the gzip trailer before closing the underlying file
+type gzipFile struct {
+	*gzip.Writer
+	file io.Closer
+}
+
+func (s gzipFile) Close() error {
+	if err := s.Writer.Close(); err != nil {
+		s.file.Close()
+		return err
+	} else {
+		return s.file.Close()
 	}
 }
@@ -0,0 +1,191 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/models"
+)
+
+type collectionFile struct {
+	Data []json.RawMessage `json:"data"`
+	Meta models.Meta       `json:"meta"`
+}
+
+func TestWriteToFileCompressed(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "output.json.gz")
+		stream = make(chan string, 2)
+	)
+	stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+	stream <- `{"kind":"AZUser","data":{"id":"2"}}`
+	close(stream)
+
+	if err := WriteToFile(context.Background(), path, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var collection collectionFile
+	if file, err := os.Open(path); err != nil {
+		t.Fatal(err)
+	} else if reader, err := gzip.NewReader(file); err != nil {
+		t.Fatalf("expected a gzip stream: %v", err)
+	} else if err := json.NewDecoder(reader).Decode(&collection); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(collection.Data) != 2 || collection.Meta.Count != 2 {
+		t.Errorf("got %v items and count %v, want 2", len(collection.Data), collection.Meta.Count)
+	}
+}
+
+func TestWriteToFileCollectionInfo(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "output.json")
+		stream = make(chan string)
+		start  = time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600))
+	)
+	close(stream)
+
+	read := func(t *testing.T) models.Meta {
+		var collection collectionFile
+		if content, err := os.ReadFile(path); err != nil {
+			t.Fatal(err)
+		} else if err := json.Unmarshal(content, &collection); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return collection.Meta
+	}
+
+	if err := WriteToFile(context.Background(), path, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if meta := read(t); meta.TenantId != "" || meta.CollectionStart != "" || meta.CollectionEnd != "" || meta.Sample != 0 {
+		t.Errorf("expected no collection info without one in the context: %+v", meta)
+	}
+
+	ctx := WithCollectionInfo(context.Background(), CollectionInfo{TenantId: "tenant", Start: start, Sample: 10})
+	if err := WriteToFile(ctx, path, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if meta := read(t); meta.TenantId != "tenant" || meta.CollectionStart != "2024-01-02T02:04:05Z" || meta.Sample != 10 {
+		t.Errorf("unexpected meta: %+v", meta)
+	} else if _, err := time.Parse(time.RFC3339, meta.CollectionEnd); err != nil {
+		t.Errorf("got collection end %q, want an RFC3339 timestamp", meta.CollectionEnd)
+	}
+}
+
+func TestWriteToFileCompressedCancelled(t *testing.T) {
+	var (
+		path        = filepath.Join(t.TempDir(), "output.json.gz")
+		stream      = make(chan string)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	go func() {
+		stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+		cancel()
+	}()
+
+	if err := WriteToFile(ctx, path, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// an interrupted collection must still be a complete gzip stream with a footer
+	var collection collectionFile
+	if reader, err := OpenFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.NewDecoder(reader).Decode(&collection); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := io.ReadAll(reader); err != nil {
+		t.Errorf("expected a complete gzip stream: %v", err)
+	} else if collection.Meta.Count != len(collection.Data) {
+		t.Errorf("got count %v, want %v", collection.Meta.Count, len(collection.Data))
+	} else if err := reader.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWriteToFileCount(t *testing.T) {
+	for _, interrupt := range []bool{false, true} {
+		var (
+			path        = filepath.Join(t.TempDir(), "output.json")
+			stream      = make(chan string)
+			ctx, cancel = context.WithCancel(context.Background())
+		)
+
+		go func() {
+			defer close(stream)
+			for i := 0; i < 100; i++ {
+				if interrupt && i == 50 {
+					cancel()
+					return
+				}
+				select {
+				case stream <- fmt.Sprintf(`{"kind":"AZUser","data":{"id":"%d"}}`, i):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		err := WriteToFile(ctx, path, stream)
+		cancel()
+
+		var collection collectionFile
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if content, err := os.ReadFile(path); err != nil {
+			t.Fatal(err)
+		} else if err := json.Unmarshal(content, &collection); err != nil {
+			t.Fatalf("interrupted %v: output is not valid json: %v", interrupt, err)
+		} else if collection.Meta.Count != len(collection.Data) {
+			t.Errorf("interrupted %v: got a count of %v for %v items", interrupt, collection.Meta.Count, len(collection.Data))
+		} else if !interrupt && collection.Meta.Count != 100 {
+			t.Errorf("got a count of %v, want 100", collection.Meta.Count)
+		}
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.json")
+	stream := make(chan string, 1)
+	stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+	close(stream)
+
+	if err := WriteToFile(context.Background(), path, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var collection collectionFile
+	if reader, err := OpenFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.NewDecoder(reader).Decode(&collection); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if collection.Meta.Count != 1 {
+		t.Errorf("got count %v, want 1", collection.Meta.Count)
+	} else {
+		reader.Close()
+	}
+}
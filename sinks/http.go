@@ -0,0 +1,150 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HTTPSinkConfig configures NewHTTPSink
+type HTTPSinkConfig struct {
+	RemoteConfig
+	Url string
+	// Header is added to every request, e.g. to authenticate with the endpoint
+	Header http.Header
+	// BatchSize is the number of items posted in each request
+	BatchSize int
+}
+
+// ValidateHTTPSinkUrl checks rawUrl is an http or https URL that batches can be posted to
+func ValidateHTTPSinkUrl(rawUrl string) error {
+	if parsed, err := url.Parse(rawUrl); err != nil {
+		return err
+	} else if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%s is not an http or https URL", RedactUrl(rawUrl))
+	} else if parsed.Host == "" {
+		return fmt.Errorf("%s has no host", RedactUrl(rawUrl))
+	} else {
+		return nil
+	}
+}
+
+// httpSink posts the collection in batches, each one a complete collection document with its own meta footer, so
+// the endpoint can ingest every request on its own
+type httpSink struct {
+	ctx    context.Context
+	config HTTPSinkConfig
+	batch  []string
+	posted int
+	err    error
+}
+
+// NewHTTPSink returns a sink that POSTs every config.BatchSize items to config.Url as a collection document. Failed
+// requests are retried with backoff when they are likely to succeed on a later attempt; once a batch cannot be posted
+// every further Write returns the error.
+func NewHTTPSink(ctx context.Context, config HTTPSinkConfig) (OutputSink, error) {
+	if err := ValidateHTTPSinkUrl(config.Url); err != nil {
+		return nil, err
+	} else if config.BatchSize < 1 {
+		return nil, fmt.Errorf("batch size must be at least 1")
+	} else if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &httpSink{ctx: ctx, config: config}, nil
+}
+
+func (s *httpSink) Write(item string) error {
+	if s.err != nil {
+		return s.err
+	} else if s.batch = append(s.batch, item); len(s.batch) < s.config.BatchSize {
+		return nil
+	} else {
+		return s.flush(s.ctx)
+	}
+}
+
+// Close posts the last batch, which is still sent when the collection has been interrupted
+func (s *httpSink) Close() error {
+	if s.err != nil {
+		return s.err
+	} else {
+		ctx, cancel := shutdownContext(s.ctx)
+		defer cancel()
+		return s.flush(ctx)
+	}
+}
+
+func (s *httpSink) flush(ctx context.Context) error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	if collection, err := newCollectionWriter(s.ctx, &body); err != nil {
+		s.err = err
+	} else {
+		for _, item := range s.batch {
+			collection.Write(item)
+		}
+		if err := collection.Close(); err != nil {
+			s.err = err
+		} else if err := retryRemote(ctx, s.config.RemoteConfig, func() error {
+			return s.post(ctx, body.Bytes())
+		}); err != nil {
+			s.err = fmt.Errorf("failed to post batch of %d items after posting %d: %w", len(s.batch), s.posted, err)
+		} else {
+			s.posted += len(s.batch)
+		}
+	}
+
+	s.batch = s.batch[:0]
+	return s.err
+}
+
+func (s *httpSink) post(ctx context.Context, body []byte) error {
+	if req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Url, bytes.NewReader(body)); err != nil {
+		return err
+	} else {
+		for name, values := range s.config.Header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		if res, err := s.config.Client.Do(req); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %v", errRemoteTransient, err)
+		} else {
+			defer res.Body.Close()
+			message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+			if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%w: %s", errRemoteTransient, res.Status)
+			} else if res.StatusCode >= http.StatusBadRequest {
+				return fmt.Errorf("endpoint responded %s: %s", res.Status, message)
+			} else {
+				return nil
+			}
+		}
+	}
+}
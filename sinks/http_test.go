@@ -0,0 +1,148 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type postedBatch struct {
+	Data []json.RawMessage `json:"data"`
+	Meta struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+}
+
+func TestValidateHTTPSinkUrl(t *testing.T) {
+	for rawUrl, valid := range map[string]bool{
+		"https://collector.example.com/ingest": true,
+		"http://localhost:8080":                true,
+		"ftp://collector.example.com":          false,
+		"https://":                             false,
+		"collector.example.com/ingest":         false,
+	} {
+		if err := ValidateHTTPSinkUrl(rawUrl); valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", rawUrl, err)
+		} else if !valid && err == nil {
+			t.Errorf("%s: expected an error but did not receive one", rawUrl)
+		}
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		attempts int
+		batches  []postedBatch
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		var batch postedBatch
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			batches = append(batches, batch)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	stream := make(chan string, 3)
+	stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+	stream <- `{"kind":"AZUser","data":{"id":"2"}}`
+	stream <- `{"kind":"AZGroup","data":{"id":"3"}}`
+	close(stream)
+
+	config := HTTPSinkConfig{
+		RemoteConfig: RemoteConfig{MaxRetries: 1, MaxBackoff: 1, Client: server.Client()},
+		Url:          server.URL,
+		Header:       http.Header{"Authorization": {"Bearer secret"}},
+		BatchSize:    2,
+	}
+	if sink, err := NewHTTPSink(context.Background(), config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if len(batches) != 2 {
+		t.Fatalf("got %v batches, want 2", len(batches))
+	} else if len(batches[0].Data) != 2 || batches[0].Meta.Count != 2 {
+		t.Errorf("got %v items and a count of %v in the first batch, want 2", len(batches[0].Data), batches[0].Meta.Count)
+	} else if len(batches[1].Data) != 1 || batches[1].Meta.Count != 1 {
+		t.Errorf("got %v items and a count of %v in the last batch, want 1", len(batches[1].Data), batches[1].Meta.Count)
+	}
+}
+
+func TestHTTPSinkRejected(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	config := HTTPSinkConfig{
+		RemoteConfig: RemoteConfig{MaxRetries: 2, MaxBackoff: 1, Client: server.Client()},
+		Url:          server.URL,
+		BatchSize:    1,
+	}
+	if sink, err := NewHTTPSink(context.Background(), config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, newConsoleStream()); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if attempts != 1 {
+		t.Errorf("got %v attempts, want 1 since the request was rejected", attempts)
+	}
+}
+
+func TestHTTPSinkFlushesWhenInterrupted(t *testing.T) {
+	posted := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch postedBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+		posted += len(batch.Data)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config := HTTPSinkConfig{
+		RemoteConfig: RemoteConfig{Client: server.Client()},
+		Url:          server.URL,
+		BatchSize:    10,
+	}
+	if sink, err := NewHTTPSink(ctx, config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := sink.Write(`{"kind":"AZUser","data":{"id":"1"}}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if cancel(); sink.Close() != nil {
+		t.Error("expected the last batch to be posted after the collection was interrupted")
+	} else if posted != 1 {
+		t.Errorf("got %v items posted, want 1", posted)
+	}
+}
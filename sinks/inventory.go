@@ -0,0 +1,374 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// The number of inventory entries held in memory before they are sorted and spilled to a temporary run
+const DefaultInventoryRunSize = 10000
+
+// ARM resource kinds included in the inventory along with the resource type reported when the object itself does
+// not carry one. Everything else, including all Azure AD kinds and role assignment edges, is excluded.
+var inventoryKinds = map[enums.Kind]string{
+	enums.KindAZAutomationAccount:    "Microsoft.Automation/automationAccounts",
+	enums.KindAZContainerRegistry:    "Microsoft.ContainerRegistry/registries",
+	enums.KindAZFunctionApp:          "Microsoft.Web/sites",
+	enums.KindAZKeyVault:             "Microsoft.KeyVault/vaults",
+	enums.KindAZLighthouseDelegation: "Microsoft.ManagedServices/registrationAssignments",
+	enums.KindAZLogicApp:             "Microsoft.Logic/workflows",
+	enums.KindAZManagedCluster:       "Microsoft.ContainerService/managedClusters",
+	enums.KindAZManagementGroup:      "Microsoft.Management/managementGroups",
+	enums.KindAZResourceGroup:        "Microsoft.Resources/resourceGroups",
+	enums.KindAZStorageAccount:       "Microsoft.Storage/storageAccounts",
+	enums.KindAZStorageContainer:     "Microsoft.Storage/storageAccounts/blobServices/containers",
+	enums.KindAZSubscription:         "Microsoft.Resources/subscriptions",
+	enums.KindAZVM:                   "Microsoft.Compute/virtualMachines",
+	enums.KindAZVMScaleSet:           "Microsoft.Compute/virtualMachineScaleSets",
+	enums.KindAZWebApp:               "Microsoft.Web/sites",
+}
+
+type InventoryItem struct {
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	Location     string          `json:"location,omitempty"`
+	Tags         json.RawMessage `json:"tags,omitempty"`
+	Identity     json.RawMessage `json:"identity,omitempty"`
+	Subscription string          `json:"subscription,omitempty"`
+}
+
+type inventoryWrapper struct {
+	Kind enums.Kind `json:"kind"`
+	Data struct {
+		Id             string          `json:"id"`
+		Type           string          `json:"type"`
+		Name           string          `json:"name"`
+		DisplayName    string          `json:"displayName"`
+		Location       string          `json:"location"`
+		Tags           json.RawMessage `json:"tags"`
+		Identity       json.RawMessage `json:"identity"`
+		SubscriptionId string          `json:"subscriptionId"`
+	} `json:"data"`
+}
+
+type inventoryEntry struct {
+	Id   string        `json:"id"`
+	Item InventoryItem `json:"item"`
+}
+
+// ARM resource ids are case-insensitive
+func (s inventoryEntry) key() string {
+	return strings.ToLower(s.Id)
+}
+
+// WriteInventoryToFile writes the ARM objects in stream to filePath as a single JSON object keyed by resource id.
+// It returns the number of objects that were excluded from the inventory.
+func WriteInventoryToFile[T any](ctx context.Context, filePath string, stream <-chan T) (int, error) {
+	if file, err := createFile(filePath); err != nil {
+		return 0, err
+	} else if excluded, err := WriteInventory(ctx, file, stream, DefaultInventoryRunSize); err != nil {
+		file.Close()
+		return excluded, err
+	} else {
+		return excluded, file.Close()
+	}
+}
+
+// WriteInventoryToConsole writes the inventory to stdout, gzip compressing it when compress is set
+func WriteInventoryToConsole[T any](ctx context.Context, compress bool, stream <-chan T) (int, error) {
+	console := openConsole(compress)
+	if excluded, err := WriteInventory(ctx, console, stream, DefaultInventoryRunSize); err != nil {
+		console.Close()
+		return excluded, err
+	} else {
+		return excluded, console.Close()
+	}
+}
+
+// WriteInventory consumes a stream of JSON formatted wrappers and writes the ARM objects to w as a single JSON object
+// keyed by resource id, sorted by id. At most runSize entries are held in memory; larger collections are spilled to
+// sorted temporary runs which are merged once the stream is exhausted. If the same id is seen more than once the
+// first occurrence wins. It returns the number of objects that were excluded from the inventory.
+func WriteInventory[T any](ctx context.Context, w io.Writer, stream <-chan T, runSize int) (int, error) {
+	if runSize < 1 {
+		runSize = DefaultInventoryRunSize
+	}
+
+	tempDir, err := os.MkdirTemp("", "azurehound-inventory-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	var (
+		excluded = 0
+		entries  = make([]inventoryEntry, 0, runSize)
+		runs     = []string{}
+	)
+
+	for item := range pipeline.OrDone(ctx.Done(), stream) {
+		if entry, ok, err := newInventoryEntry(fmt.Sprint(item)); err != nil {
+			return excluded, err
+		} else if !ok {
+			excluded++
+		} else if entries = append(entries, entry); len(entries) >= runSize {
+			if run, err := writeInventoryRun(tempDir, len(runs), entries); err != nil {
+				return excluded, err
+			} else {
+				runs = append(runs, run)
+				entries = entries[:0]
+			}
+		}
+	}
+
+	// spilled runs precede the in-memory remainder so that ties resolve to the earliest occurrence
+	sources := []inventorySource{}
+	for _, run := range runs {
+		if file, err := os.Open(run); err != nil {
+			return excluded, err
+		} else {
+			defer file.Close()
+			sources = append(sources, &runSource{reader: bufio.NewReader(file)})
+		}
+	}
+	sortInventoryEntries(entries)
+	sources = append(sources, &sliceSource{entries: entries})
+
+	return excluded, mergeInventory(w, sources)
+}
+
+func newInventoryEntry(raw string) (inventoryEntry, bool, error) {
+	var wrapper inventoryWrapper
+	if err := json.Unmarshal([]byte(raw), &wrapper); err != nil {
+		return inventoryEntry{}, false, fmt.Errorf("unable to read inventory object: %w", err)
+	} else if fallbackType, ok := inventoryKinds[wrapper.Kind]; !ok || wrapper.Data.Id == "" {
+		return inventoryEntry{}, false, nil
+	} else {
+		data := wrapper.Data
+		entry := inventoryEntry{
+			Id: data.Id,
+			Item: InventoryItem{
+				Type:         data.Type,
+				Name:         data.Name,
+				Location:     data.Location,
+				Subscription: data.SubscriptionId,
+			},
+		}
+
+		if entry.Item.Type == "" {
+			entry.Item.Type = fallbackType
+		}
+
+		if entry.Item.Name == "" {
+			entry.Item.Name = data.DisplayName
+		}
+
+		if !isEmptyJson(data.Tags) {
+			entry.Item.Tags = data.Tags
+		}
+
+		if hasIdentity(data.Identity) {
+			entry.Item.Identity = data.Identity
+		}
+
+		return entry, true, nil
+	}
+}
+
+func isEmptyJson(raw json.RawMessage) bool {
+	switch strings.TrimSpace(string(raw)) {
+	case "", "null", "{}", "[]":
+		return true
+	default:
+		return false
+	}
+}
+
+// Resources without a managed identity still serialize an identity object with an empty type
+func hasIdentity(raw json.RawMessage) bool {
+	var identity struct {
+		Type string `json:"type"`
+	}
+	if isEmptyJson(raw) {
+		return false
+	} else if err := json.Unmarshal(raw, &identity); err != nil {
+		return false
+	} else {
+		return identity.Type != "" && !strings.EqualFold(identity.Type, "None")
+	}
+}
+
+func sortInventoryEntries(entries []inventoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].key() < entries[j].key()
+	})
+}
+
+func writeInventoryRun(dir string, index int, entries []inventoryEntry) (string, error) {
+	sortInventoryEntries(entries)
+
+	path := filepath.Join(dir, fmt.Sprintf("run-%06d.jsonl", index))
+	if file, err := os.Create(path); err != nil {
+		return "", err
+	} else {
+		defer file.Close()
+
+		writer := bufio.NewWriter(file)
+		encoder := json.NewEncoder(writer)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return "", err
+			}
+		}
+		return path, writer.Flush()
+	}
+}
+
+type inventorySource interface {
+	next() (inventoryEntry, bool, error)
+}
+
+type sliceSource struct {
+	entries []inventoryEntry
+}
+
+func (s *sliceSource) next() (inventoryEntry, bool, error) {
+	if len(s.entries) == 0 {
+		return inventoryEntry{}, false, nil
+	} else {
+		entry := s.entries[0]
+		s.entries = s.entries[1:]
+		return entry, true, nil
+	}
+}
+
+type runSource struct {
+	reader *bufio.Reader
+}
+
+func (s *runSource) next() (inventoryEntry, bool, error) {
+	var entry inventoryEntry
+	if line, err := s.reader.ReadBytes('\n'); err == io.EOF && len(line) == 0 {
+		return entry, false, nil
+	} else if err != nil && err != io.EOF {
+		return entry, false, err
+	} else if err := json.Unmarshal(line, &entry); err != nil {
+		return entry, false, fmt.Errorf("unable to read inventory run: %w", err)
+	} else {
+		return entry, true, nil
+	}
+}
+
+type mergeItem struct {
+	entry  inventoryEntry
+	source int
+}
+
+// mergeHeap orders entries by id, breaking ties by source so the earliest occurrence of an id is popped first
+type mergeHeap []mergeItem
+
+func (s mergeHeap) Len() int { return len(s) }
+func (s mergeHeap) Less(i, j int) bool {
+	if a, b := s[i].entry.key(), s[j].entry.key(); a != b {
+		return a < b
+	} else {
+		return s[i].source < s[j].source
+	}
+}
+func (s mergeHeap) Swap(i, j int)       { s[i], s[j] = s[j], s[i] }
+func (s *mergeHeap) Push(x interface{}) { *s = append(*s, x.(mergeItem)) }
+func (s *mergeHeap) Pop() interface{} {
+	old := *s
+	item := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return item
+}
+
+func mergeInventory(w io.Writer, sources []inventorySource) error {
+	var (
+		writer  = bufio.NewWriter(w)
+		pending = &mergeHeap{}
+		lastKey = ""
+		count   = 0
+	)
+
+	for i, source := range sources {
+		if entry, ok, err := source.next(); err != nil {
+			return err
+		} else if ok {
+			heap.Push(pending, mergeItem{entry: entry, source: i})
+		}
+	}
+
+	if _, err := writer.WriteString("{"); err != nil {
+		return err
+	}
+
+	for pending.Len() > 0 {
+		item := heap.Pop(pending).(mergeItem)
+		if entry, ok, err := sources[item.source].next(); err != nil {
+			return err
+		} else if ok {
+			heap.Push(pending, mergeItem{entry: entry, source: item.source})
+		}
+
+		if key := item.entry.key(); count > 0 && key == lastKey {
+			continue
+		} else {
+			lastKey = key
+		}
+
+		separator := ",\n\t"
+		if count == 0 {
+			separator = "\n\t"
+		}
+
+		if id, err := json.Marshal(item.entry.Id); err != nil {
+			return err
+		} else if value, err := json.Marshal(item.entry.Item); err != nil {
+			return err
+		} else if _, err := writer.WriteString(fmt.Sprintf("%s%s: %s", separator, id, value)); err != nil {
+			return err
+		}
+		count++
+	}
+
+	closing := "\n}\n"
+	if count == 0 {
+		closing = "}\n"
+	}
+
+	if _, err := writer.WriteString(closing); err != nil {
+		return err
+	} else {
+		return writer.Flush()
+	}
+}
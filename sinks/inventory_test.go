@@ -0,0 +1,112 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func readFixture(t *testing.T, name string) []string {
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func streamOf(lines []string) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for _, line := range lines {
+			out <- line
+		}
+	}()
+	return out
+}
+
+func TestWriteInventory(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		lines      = readFixture(t, "inventory.input.jsonl")
+		goldenPath = filepath.Join("testdata", "inventory.golden.json")
+	)
+
+	// the result must not depend on how many temporary runs the input was spilled to
+	for _, runSize := range []int{1, 2, 3, len(lines), DefaultInventoryRunSize} {
+		t.Run(fmt.Sprintf("runSize=%d", runSize), func(t *testing.T) {
+			var buf bytes.Buffer
+			if excluded, err := WriteInventory(ctx, &buf, streamOf(lines), runSize); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if excluded != 3 {
+				t.Errorf("got %d excluded objects, want 3", excluded)
+			}
+
+			if *update && runSize == DefaultInventoryRunSize {
+				if err := os.WriteFile(goldenPath, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if golden, err := os.ReadFile(goldenPath); err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(buf.Bytes(), golden) {
+				t.Errorf("inventory does not match %s\ngot:\n%s\nwant:\n%s", goldenPath, buf.String(), golden)
+			}
+		})
+	}
+}
+
+func TestWriteInventoryEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if excluded, err := WriteInventory(context.Background(), &buf, streamOf(nil), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if excluded != 0 {
+		t.Errorf("got %d excluded objects, want 0", excluded)
+	} else if buf.String() != "{}\n" {
+		t.Errorf("got %q, want %q", buf.String(), "{}\n")
+	}
+}
+
+func TestWriteInventoryMalformed(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteInventory(context.Background(), &buf, streamOf([]string{"{"}), 2); err == nil {
+		t.Error("expected an error for malformed input")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// The version of the ledger record schema written by this build. Readers accept records of any version; fields a
+// record's version predates are left empty.
+const LedgerVersion = 1
+
+// LedgerRecord summarizes a completed collection run
+type LedgerRecord struct {
+	Version        int                `json:"version"`
+	RunId          string             `json:"runId"`
+	Command        string             `json:"command"`
+	TenantId       string             `json:"tenantId"`
+	Started        time.Time          `json:"started"`
+	Finished       time.Time          `json:"finished"`
+	Counts         map[string]int     `json:"counts"`
+	Coverage       LedgerCoverage     `json:"coverage"`
+	Errors         LedgerErrors       `json:"errors"`
+	TopRoleHolders []LedgerRoleHolder `json:"topRoleHolders"`
+}
+
+// Total returns the number of objects collected in the run
+func (s LedgerRecord) Total() int {
+	total := 0
+	for _, count := range s.Counts {
+		total += count
+	}
+	return total
+}
+
+// LedgerCoverage describes how much of the tenant a run was able to collect
+type LedgerCoverage struct {
+	Complete              bool     `json:"complete"`
+	DeadlineExceeded      bool     `json:"deadlineExceeded"`
+	SkippedForPermissions []string `json:"skippedForPermissions"`
+	// Sample is the --sample limit of objects of each kind, set when the run was sampled
+	Sample int `json:"sample,omitempty"`
+}
+
+type LedgerErrors struct {
+	Logged         int64 `json:"logged"`
+	ResyncRestarts int64 `json:"resyncRestarts"`
+}
+
+// LedgerRoleHolder is the number of distinct principals assigned a directory role
+type LedgerRoleHolder struct {
+	RoleId   string `json:"roleId"`
+	RoleName string `json:"roleName"`
+	Holders  int    `json:"holders"`
+}
+
+// AppendLedger appends record to the newline delimited ledger at filePath, creating it if needed. The file is locked
+// while the record is written in a single write, so records of overlapping runs never interleave.
+func AppendLedger(filePath string, record LedgerRecord) error {
+	if record.Version == 0 {
+		record.Version = LedgerVersion
+	}
+
+	if bytes, err := json.Marshal(record); err != nil {
+		return err
+	} else if file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	} else if err := lockFile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("unable to lock ledger: %w", err)
+	} else if _, err := file.Write(append(bytes, '\n')); err != nil {
+		unlockFile(file)
+		file.Close()
+		return err
+	} else if err := unlockFile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("unable to unlock ledger: %w", err)
+	} else {
+		return file.Close()
+	}
+}
+
+// ReadLedger reads the records of a ledger in the order they were appended. Lines that can't be read, e.g. because
+// a run was killed while appending, are skipped and counted in the returned number of invalid lines.
+func ReadLedger(r io.Reader) ([]LedgerRecord, int, error) {
+	var (
+		records []LedgerRecord
+		invalid = 0
+		reader  = bufio.NewReader(r)
+	)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var record LedgerRecord
+			if unmarshalErr := json.Unmarshal(line, &record); unmarshalErr != nil || record.Version < 1 {
+				invalid++
+			} else {
+				records = append(records, record)
+			}
+		}
+
+		if err == io.EOF {
+			return records, invalid, nil
+		} else if err != nil {
+			return records, invalid, err
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendLedgerConcurrent(t *testing.T) {
+	var (
+		path = filepath.Join(t.TempDir(), "ledger.ndjson")
+		wg   sync.WaitGroup
+		runs = 50
+	)
+
+	// large records make interleaved writes likely if appends weren't serialized
+	counts := map[string]int{}
+	for i := 0; i < 500; i++ {
+		counts[fmt.Sprintf("AZKind%d", i)] = i
+	}
+
+	wg.Add(runs)
+	for i := 0; i < runs; i++ {
+		go func(i int) {
+			defer wg.Done()
+			if err := AppendLedger(path, LedgerRecord{RunId: fmt.Sprint(i), Counts: counts}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if records, invalid, err := ReadLedger(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if invalid != 0 || len(records) != runs {
+		t.Errorf("got %v records and %v invalid lines, want %v records", len(records), invalid, runs)
+	} else if records[0].Version != LedgerVersion || len(records[0].Counts) != len(counts) {
+		t.Errorf("got version %v and %v counts, want %v and %v", records[0].Version, len(records[0].Counts), LedgerVersion, len(counts))
+	}
+}
+
+func TestReadLedgerMixedVersions(t *testing.T) {
+	ledger := strings.Join([]string{
+		`{"version":1,"runId":"a","started":"2024-01-01T00:00:00Z","finished":"2024-01-01T01:00:00Z","counts":{"AZUser":10}}`,
+		`{"version":2,"runId":"b","counts":{"AZUser":12},"newField":{"nested":true}}`,
+		`{"runId":"no version"}`,
+		`{"version":1,"runId":"trunc`,
+		`{"version":1,"runId":"c","counts":{"AZUser":14,"AZGroup":3}}`,
+	}, "\n")
+
+	if records, invalid, err := ReadLedger(strings.NewReader(ledger)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if invalid != 2 {
+		t.Errorf("got %v invalid lines, want 2", invalid)
+	} else if len(records) != 3 || records[1].Version != 2 || records[2].Total() != 17 {
+		t.Errorf("unexpected records: %+v", records)
+	} else if duration := records[0].Finished.Sub(records[0].Started); duration != time.Hour {
+		t.Errorf("got %v, want %v", duration, time.Hour)
+	}
+}
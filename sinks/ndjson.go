@@ -0,0 +1,109 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/models"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// NdjsonMeta is the first line of an NDJSON collection. The number of items isn't known until the collection ends so,
+// unlike the meta of a json collection, it carries no count or collection end.
+type NdjsonMeta struct {
+	Meta struct {
+		Type            string `json:"type"`
+		Version         int    `json:"version"`
+		TenantId        string `json:"tenantId,omitempty"`
+		CollectionStart string `json:"collectionStart,omitempty"`
+		Sample          int    `json:"sample,omitempty"`
+	} `json:"meta"`
+}
+
+// NdjsonTrailer is the last line of an NDJSON collection, written once the collection ends or is interrupted. It holds
+// the same meta record as a json collection, including the number of items written.
+type NdjsonTrailer struct {
+	Meta models.Meta `json:"meta"`
+}
+
+// WriteNdjsonToFile writes stream to filePath with one item per line, compressing it when IsCompressed(filePath)
+func WriteNdjsonToFile[T any](ctx context.Context, filePath string, stream <-chan T) error {
+	if file, err := createFile(filePath); err != nil {
+		return err
+	} else if err := WriteNdjson(ctx, file, stream); err != nil {
+		file.Close()
+		return err
+	} else {
+		return file.Close()
+	}
+}
+
+// WriteNdjsonToConsole writes stream to stdout with one item per line, gzip compressing it when compress is set
+func WriteNdjsonToConsole[T any](ctx context.Context, compress bool, stream <-chan T) error {
+	console := openConsole(compress)
+	if err := WriteNdjson(ctx, console, stream); err != nil {
+		console.Close()
+		return err
+	} else {
+		return console.Close()
+	}
+}
+
+// WriteNdjson writes the meta line followed by each item of stream on its own line as it arrives, and a trailer with
+// the count once stream closes or ctx is done. Every line written is a complete JSON document, so the lines written
+// before a collection is interrupted remain usable.
+func WriteNdjson[T any](ctx context.Context, w io.Writer, stream <-chan T) error {
+	var (
+		trailer = NdjsonTrailer{Meta: newMeta(ctx)}
+		meta    NdjsonMeta
+	)
+	meta.Meta.Type = trailer.Meta.Type
+	meta.Meta.Version = trailer.Meta.Version
+	meta.Meta.TenantId = trailer.Meta.TenantId
+	meta.Meta.CollectionStart = trailer.Meta.CollectionStart
+	meta.Meta.Sample = trailer.Meta.Sample
+
+	if bytes, err := json.Marshal(meta); err != nil {
+		return err
+	} else if _, err := fmt.Fprintf(w, "%s\n", bytes); err != nil {
+		return err
+	}
+
+	for item := range pipeline.OrDone(ctx.Done(), stream) {
+		if _, err := fmt.Fprintf(w, "%v\n", item); err != nil {
+			return err
+		}
+		trailer.Meta.Count++
+	}
+
+	if trailer.Meta.CollectionStart != "" {
+		trailer.Meta.CollectionEnd = time.Now().UTC().Format(time.RFC3339)
+	}
+	if bytes, err := json.Marshal(trailer); err != nil {
+		return err
+	} else if _, err := fmt.Fprintf(w, "%s\n", bytes); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
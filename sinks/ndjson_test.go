@@ -0,0 +1,103 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteNdjson(t *testing.T) {
+	var (
+		buffer bytes.Buffer
+		stream = make(chan string, 2)
+	)
+	stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+	stream <- `{"kind":"AZGroup","data":{"id":"2"}}`
+	close(stream)
+
+	if err := WriteNdjson(context.Background(), &buffer, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buffer)
+	lines := []string{}
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var (
+		meta    NdjsonMeta
+		trailer NdjsonTrailer
+	)
+	if len(lines) != 4 {
+		t.Fatalf("got %v lines, want 4", len(lines))
+	} else if err := json.Unmarshal([]byte(lines[0]), &meta); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if meta.Meta.Type != "azure" || meta.Meta.Version != 5 {
+		t.Errorf("unexpected meta: %+v", meta)
+	} else if lines[2] != `{"kind":"AZGroup","data":{"id":"2"}}` {
+		t.Errorf("got %v, want %v", lines[2], `{"kind":"AZGroup","data":{"id":"2"}}`)
+	} else if err := json.Unmarshal([]byte(lines[3]), &trailer); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if trailer.Meta.Type != "azure" || trailer.Meta.Count != 2 {
+		t.Errorf("unexpected trailer: %+v", trailer)
+	}
+}
+
+func TestWriteNdjsonCancelled(t *testing.T) {
+	var (
+		buffer      bytes.Buffer
+		stream      = make(chan string)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	go func() {
+		stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+		cancel()
+	}()
+
+	if err := WriteNdjson(ctx, &buffer, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// every line written before the interruption is a complete document, and the trailer counts the items among them
+	var (
+		scanner = bufio.NewScanner(&buffer)
+		lines   = []string{}
+		trailer NdjsonTrailer
+	)
+	for scanner.Scan() {
+		if !json.Valid(scanner.Bytes()) {
+			t.Errorf("invalid line: %s", scanner.Text())
+		}
+		lines = append(lines, scanner.Text())
+	}
+
+	if len(lines) < 2 {
+		t.Fatalf("got %v lines, want at least 2", len(lines))
+	} else if err := json.Unmarshal([]byte(lines[len(lines)-1]), &trailer); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if want := len(lines) - 2; trailer.Meta.Count != want {
+		t.Errorf("got a count of %v, want %v", trailer.Meta.Count, want)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+const (
+	DefaultNeo4jBatchSize = 1000
+	DefaultNeo4jDatabase  = "neo4j"
+)
+
+// Neo4jConfig configures WriteToNeo4j
+type Neo4jConfig struct {
+	Uri        string
+	User       string
+	Password   string
+	BatchSize  int
+	MaxRetries int
+	MaxBackoff time.Duration
+	Client     *http.Client
+	// OnRetry, when set, is called before a failed transaction is retried
+	OnRetry func(err error, backoff time.Duration)
+}
+
+// Neo4jCounts reports the nodes and edges written and the items whose kind has no graph mapping
+type Neo4jCounts struct {
+	Nodes   int
+	Edges   int
+	Skipped int
+}
+
+// Neo4jCommitUrl returns the transactional Cypher endpoint for uri, the HTTP address of a Neo4j server such as
+// http://localhost:7474. A path names the database, which otherwise defaults to neo4j.
+func Neo4jCommitUrl(uri string) (string, error) {
+	if parsed, err := url.Parse(uri); err != nil {
+		return "", err
+	} else if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("%s is not an http(s) address; use the HTTP endpoint of the server, e.g. http://localhost:7474", uri)
+	} else if parsed.Host == "" {
+		return "", fmt.Errorf("%s has no host", uri)
+	} else {
+		database := strings.Trim(parsed.Path, "/")
+		if database == "" {
+			database = DefaultNeo4jDatabase
+		}
+		parsed.Path = fmt.Sprintf("/db/%s/tx/commit", url.PathEscape(database))
+		return parsed.String(), nil
+	}
+}
+
+// WriteToNeo4j MERGEs the nodes and edges of stream, a stream of JSON formatted wrappers, into Neo4j. Each batch of
+// items is written in a single transaction; since every statement is a MERGE a batch that fails with a connection or
+// transient error is safely retried with backoff.
+func WriteToNeo4j(ctx context.Context, config Neo4jConfig, stream <-chan string) (Neo4jCounts, error) {
+	var counts Neo4jCounts
+
+	commitUrl, err := Neo4jCommitUrl(config.Uri)
+	if err != nil {
+		return counts, err
+	} else if config.BatchSize < 1 {
+		config.BatchSize = DefaultNeo4jBatchSize
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	for batch := range pipeline.Batch(ctx.Done(), stream, config.BatchSize, 10*time.Second) {
+		var (
+			nodes []graphNode
+			edges []graphEdge
+		)
+		for _, item := range batch {
+			if itemNodes, itemEdges, ok, err := toGraph(item); err != nil {
+				return counts, err
+			} else if !ok {
+				counts.Skipped++
+			} else {
+				nodes = append(nodes, itemNodes...)
+				edges = append(edges, itemEdges...)
+			}
+		}
+
+		if len(nodes) == 0 && len(edges) == 0 {
+			continue
+		} else if err := commitNeo4j(ctx, config, commitUrl, neo4jStatements(nodes, edges)); err != nil {
+			return counts, err
+		} else {
+			counts.Nodes += len(nodes)
+			counts.Edges += len(edges)
+		}
+	}
+	return counts, ctx.Err()
+}
+
+type neo4jStatement struct {
+	Statement  string         `json:"statement"`
+	Parameters map[string]any `json:"parameters"`
+}
+
+// neo4jStatements groups nodes by label and edges by kind into one UNWIND statement each since neither can be passed
+// as a parameter. Both only ever come from the fixed graph mappings. Nodes are written first so edges find them.
+func neo4jStatements(nodes []graphNode, edges []graphEdge) []neo4jStatement {
+	var (
+		nodeRows   = map[string][]any{}
+		edgeRows   = map[string][]any{}
+		statements []neo4jStatement
+	)
+	for _, node := range nodes {
+		nodeRows[node.Label] = append(nodeRows[node.Label], map[string]any{"objectid": node.ObjectId, "properties": node.Properties})
+	}
+	for _, edge := range edges {
+		edgeRows[edge.Kind] = append(edgeRows[edge.Kind], map[string]any{"source": edge.Source, "target": edge.Target})
+	}
+
+	for _, label := range sortedKeys(nodeRows) {
+		statements = append(statements, neo4jStatement{
+			Statement:  fmt.Sprintf("UNWIND $rows AS row MERGE (n:AZBase {objectid: row.objectid}) SET n += row.properties, n:%s", label),
+			Parameters: map[string]any{"rows": nodeRows[label]},
+		})
+	}
+	for _, kind := range sortedKeys(edgeRows) {
+		statements = append(statements, neo4jStatement{
+			Statement:  fmt.Sprintf("UNWIND $rows AS row MERGE (s:AZBase {objectid: row.source}) MERGE (t:AZBase {objectid: row.target}) MERGE (s)-[:%s]->(t)", kind),
+			Parameters: map[string]any{"rows": edgeRows[kind]},
+		})
+	}
+	return statements
+}
+
+func sortedKeys(rows map[string][]any) []string {
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// errNeo4jTransient marks failures that are worth retrying
+var errNeo4jTransient = errors.New("transient neo4j error")
+
+func commitNeo4j(ctx context.Context, config Neo4jConfig, commitUrl string, statements []neo4jStatement) error {
+	body, err := json.Marshal(map[string]any{"statements": statements})
+	if err != nil {
+		return err
+	}
+
+	for retry := 0; ; retry++ {
+		if err := postNeo4j(ctx, config, commitUrl, body); err == nil {
+			return nil
+		} else if !errors.Is(err, errNeo4jTransient) || retry >= config.MaxRetries || ctx.Err() != nil {
+			return err
+		} else {
+			backoff := time.Second * time.Duration(math.Pow(2, float64(retry+1)))
+			if config.MaxBackoff > 0 && backoff > config.MaxBackoff {
+				backoff = config.MaxBackoff
+			}
+			if config.OnRetry != nil {
+				config.OnRetry(err, backoff)
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+func postNeo4j(ctx context.Context, config Neo4jConfig, commitUrl string, body []byte) error {
+	var response struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if req, err := http.NewRequestWithContext(ctx, http.MethodPost, commitUrl, bytes.NewReader(body)); err != nil {
+		return err
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.SetBasicAuth(config.User, config.Password)
+
+		if res, err := config.Client.Do(req); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %v", errNeo4jTransient, err)
+		} else {
+			defer res.Body.Close()
+			if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%w: %s", errNeo4jTransient, res.Status)
+			} else if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+				return fmt.Errorf("neo4j rejected the credentials: %s", res.Status)
+			} else if res.StatusCode >= http.StatusBadRequest {
+				message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+				return fmt.Errorf("neo4j responded %s: %s", res.Status, message)
+			} else if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				return fmt.Errorf("unable to decode neo4j response: %w", err)
+			} else if len(response.Errors) == 0 {
+				return nil
+			} else if first := response.Errors[0]; strings.HasPrefix(first.Code, "Neo.TransientError.") {
+				return fmt.Errorf("%w: %s: %s", errNeo4jTransient, first.Code, first.Message)
+			} else {
+				return fmt.Errorf("%s: %s", first.Code, first.Message)
+			}
+		}
+	}
+}
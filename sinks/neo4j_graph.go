@@ -0,0 +1,205 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+)
+
+// graphNode is a node MERGEd on its objectid, labelled AZBase and with its kind
+type graphNode struct {
+	Label      string
+	ObjectId   string
+	Properties map[string]any
+}
+
+// graphEdge is a relationship MERGEd between two AZBase nodes, creating either end that has not been seen yet
+type graphEdge struct {
+	Kind   string
+	Source string
+	Target string
+}
+
+// nodeMapping describes how an entity kind becomes a node
+type nodeMapping struct {
+	// id lists the fields joined with "@" to form the objectid
+	id []string
+	// parent is the field holding the objectid of the node that contains this one, if any
+	parent string
+	// parentPrefix is prepended to the parent field, e.g. to turn a subscription id into its resource id
+	parentPrefix string
+}
+
+// edgeMapping describes how a relationship kind becomes edges
+type edgeMapping struct {
+	kind string
+	// entries is the array holding one relationship per entry, or empty when the item is a single relationship
+	entries string
+	// source is the dotted path of the source objectid within each entry
+	source string
+	// target lists the fields of the item joined with "@" to form the target objectid
+	target []string
+}
+
+// nodeMappings follows the labels BloodHound gives the Azure kinds; objectids are upper cased as BloodHound does
+var nodeMappings = map[enums.Kind]nodeMapping{
+	enums.KindAZApp:               {id: []string{"id"}},
+	enums.KindAZDevice:            {id: []string{"id"}},
+	enums.KindAZGroup:             {id: []string{"id"}},
+	enums.KindAZRole:              {id: []string{"id", "tenantId"}},
+	enums.KindAZServicePrincipal:  {id: []string{"id"}},
+	enums.KindAZTenant:            {id: []string{"tenantId"}},
+	enums.KindAZUser:              {id: []string{"id"}},
+	enums.KindAZManagementGroup:   {id: []string{"id"}},
+	enums.KindAZSubscription:      {id: []string{"id"}, parent: "tenantId"},
+	enums.KindAZResourceGroup:     {id: []string{"id"}, parent: "subscriptionId", parentPrefix: "/subscriptions/"},
+	enums.KindAZAutomationAccount: {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZContainerRegistry: {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZFunctionApp:       {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZKeyVault:          {id: []string{"id"}, parent: "resourceGroup"},
+	enums.KindAZLogicApp:          {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZManagedCluster:    {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZStorageAccount:    {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZVM:                {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZVMScaleSet:        {id: []string{"id"}, parent: "resourceGroupId"},
+	enums.KindAZWebApp:            {id: []string{"id"}, parent: "resourceGroupId"},
+}
+
+var edgeMappings = map[enums.Kind]edgeMapping{
+	enums.KindAZGroupMember:                    {"AZMemberOf", "members", "member.id", []string{"groupId"}},
+	enums.KindAZAppOwner:                       {"AZOwns", "owners", "owner.id", []string{"appId"}},
+	enums.KindAZDeviceOwner:                    {"AZOwns", "owners", "owner.id", []string{"deviceId"}},
+	enums.KindAZDeviceUser:                     {"AZRegisteredUserOf", "users", "user.id", []string{"deviceId"}},
+	enums.KindAZGroupOwner:                     {"AZOwns", "owners", "owner.id", []string{"groupId"}},
+	enums.KindAZServicePrincipalOwner:          {"AZOwns", "owners", "owner.id", []string{"servicePrincipalId"}},
+	enums.KindAZRoleAssignment:                 {"AZHasRole", "roleAssignments", "principalId", []string{"roleDefinitionId", "tenantId"}},
+	enums.KindAZManagementGroupDescendant:      {"AZContains", "", "properties.parent.id", []string{"id"}},
+	enums.KindAZKeyVaultOwner:                  {"AZOwns", "owners", "owner.properties.principalId", []string{"keyVaultId"}},
+	enums.KindAZManagementGroupOwner:           {"AZOwns", "owners", "owner.properties.principalId", []string{"managementGroupId"}},
+	enums.KindAZResourceGroupOwner:             {"AZOwns", "owners", "owner.properties.principalId", []string{"resourceGroupId"}},
+	enums.KindAZSubscriptionOwner:              {"AZOwns", "owners", "owner.properties.principalId", []string{"subscriptionId"}},
+	enums.KindAZVMOwner:                        {"AZOwns", "owners", "owner.properties.principalId", []string{"virtualMachineId"}},
+	enums.KindAZKeyVaultUserAccessAdmin:        {"AZUserAccessAdministrator", "userAccessAdmins", "userAccessAdmin.properties.principalId", []string{"keyVaultId"}},
+	enums.KindAZManagementGroupUserAccessAdmin: {"AZUserAccessAdministrator", "userAccessAdmins", "userAccessAdmin.properties.principalId", []string{"managementGroupId"}},
+	enums.KindAZResourceGroupUserAccessAdmin:   {"AZUserAccessAdministrator", "userAccessAdmins", "userAccessAdmin.properties.principalId", []string{"resourceGroupId"}},
+	enums.KindAZSubscriptionUserAccessAdmin:    {"AZUserAccessAdministrator", "userAccessAdmins", "userAccessAdmin.properties.principalId", []string{"subscriptionId"}},
+	enums.KindAZVMUserAccessAdmin:              {"AZUserAccessAdministrator", "userAccessAdmins", "userAccessAdmin.properties.principalId", []string{"virtualMachineId"}},
+	enums.KindAZKeyVaultContributor:            {"AZContributor", "contributors", "contributor.properties.principalId", []string{"keyVaultId"}},
+	enums.KindAZKeyVaultKVContributor:          {"AZKeyVaultContributor", "kvContributors", "kvContributor.properties.principalId", []string{"keyVaultId"}},
+	enums.KindAZVMAdminLogin:                   {"AZVMAdminLogin", "adminLogins", "adminLogin.properties.principalId", []string{"virtualMachineId"}},
+	enums.KindAZVMAvereContributor:             {"AZAvereContributor", "avereContributors", "avereContributor.properties.principalId", []string{"virtualMachineId"}},
+	enums.KindAZVMContributor:                  {"AZContributor", "contributors", "contributor.properties.principalId", []string{"virtualMachineId"}},
+	enums.KindAZVMVMContributor:                {"AZVMContributor", "vmContributors", "vmContributor.properties.principalId", []string{"virtualMachineId"}},
+}
+
+// toGraph converts a JSON formatted wrapper into the nodes and edges it describes. ok is false for kinds that have
+// no mapping.
+func toGraph(item string) (nodes []graphNode, edges []graphEdge, ok bool, err error) {
+	var wrapper struct {
+		Kind enums.Kind      `json:"kind"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(item), &wrapper); err != nil {
+		return nil, nil, false, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(wrapper.Data))
+	decoder.UseNumber()
+	var data map[string]any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, nil, false, fmt.Errorf("unable to decode %s: %w", wrapper.Kind, err)
+	}
+
+	if mapping, found := nodeMappings[wrapper.Kind]; found {
+		if objectId := joinFields(data, mapping.id); objectId != "" {
+			nodes = append(nodes, graphNode{Label: string(wrapper.Kind), ObjectId: objectId, Properties: nodeProperties(data, objectId)})
+			if parent := lookupString(data, mapping.parent); parent != "" {
+				edges = append(edges, graphEdge{Kind: "AZContains", Source: strings.ToUpper(mapping.parentPrefix + parent), Target: objectId})
+			}
+		}
+		return nodes, edges, true, nil
+	} else if mapping, found := edgeMappings[wrapper.Kind]; !found {
+		return nil, nil, false, nil
+	} else if target := joinFields(data, mapping.target); target == "" {
+		return nil, nil, true, nil
+	} else {
+		entries := []any{data}
+		if mapping.entries != "" {
+			entries, _ = data[mapping.entries].([]any)
+		}
+		for _, entry := range entries {
+			if source := strings.ToUpper(lookupString(entry, mapping.source)); source != "" {
+				edges = append(edges, graphEdge{Kind: mapping.kind, Source: source, Target: target})
+			}
+		}
+		return nil, edges, true, nil
+	}
+}
+
+// nodeProperties keeps the top level scalar fields of an entity with their names lower cased, as BloodHound names
+// node properties. Neo4j properties cannot hold maps, so nested values are left out.
+func nodeProperties(data map[string]any, objectId string) map[string]any {
+	properties := map[string]any{}
+	for key, value := range data {
+		switch value := value.(type) {
+		case string, bool:
+			properties[strings.ToLower(key)] = value
+		case json.Number:
+			if i, err := value.Int64(); err == nil {
+				properties[strings.ToLower(key)] = i
+			} else if f, err := value.Float64(); err == nil {
+				properties[strings.ToLower(key)] = f
+			}
+		}
+	}
+	properties["objectid"] = objectId
+	return properties
+}
+
+func joinFields(data map[string]any, fields []string) string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if value := lookupString(data, field); value == "" {
+			return ""
+		} else {
+			values = append(values, value)
+		}
+	}
+	return strings.ToUpper(strings.Join(values, "@"))
+}
+
+// lookupString follows a dotted path of object fields to a string value
+func lookupString(value any, path string) string {
+	if path == "" {
+		return ""
+	}
+	for _, field := range strings.Split(path, ".") {
+		if object, ok := value.(map[string]any); !ok {
+			return ""
+		} else {
+			value = object[field]
+		}
+	}
+	result, _ := value.(string)
+	return result
+}
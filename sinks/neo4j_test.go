@@ -0,0 +1,244 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNeo4jCommitUrl(t *testing.T) {
+	for uri, want := range map[string]string{
+		"http://localhost:7474":        "http://localhost:7474/db/neo4j/tx/commit",
+		"https://neo4j.example.com/":   "https://neo4j.example.com/db/neo4j/tx/commit",
+		"http://localhost:7474/tenant": "http://localhost:7474/db/tenant/tx/commit",
+	} {
+		if got, err := Neo4jCommitUrl(uri); err != nil {
+			t.Errorf("%s: unexpected error: %v", uri, err)
+		} else if got != want {
+			t.Errorf("%s: got %v, want %v", uri, got, want)
+		}
+	}
+
+	for _, uri := range []string{"bolt://localhost:7687", "localhost:7474", "http://"} {
+		if _, err := Neo4jCommitUrl(uri); err == nil {
+			t.Errorf("%s: expected an error but did not receive one", uri)
+		}
+	}
+}
+
+func TestToGraph(t *testing.T) {
+	for _, tc := range []struct {
+		item  string
+		nodes []string
+		edges []graphEdge
+		ok    bool
+	}{
+		{
+			item:  `{"kind":"AZUser","data":{"id":"u1","displayName":"Alice","accountEnabled":true,"tenantId":"t"}}`,
+			nodes: []string{"AZUser:U1"},
+			ok:    true,
+		},
+		{
+			item:  `{"kind":"AZResourceGroup","data":{"id":"/subscriptions/s/resourceGroups/rg","subscriptionId":"s"}}`,
+			nodes: []string{"AZResourceGroup:/SUBSCRIPTIONS/S/RESOURCEGROUPS/RG"},
+			edges: []graphEdge{{"AZContains", "/SUBSCRIPTIONS/S", "/SUBSCRIPTIONS/S/RESOURCEGROUPS/RG"}},
+			ok:    true,
+		},
+		{
+			item:  `{"kind":"AZGroupMember","data":{"groupId":"g","members":[{"member":{"id":"u1"},"groupId":"g"},{"member":{"id":"u2"},"groupId":"g"}]}}`,
+			edges: []graphEdge{{"AZMemberOf", "U1", "G"}, {"AZMemberOf", "U2", "G"}},
+			ok:    true,
+		},
+		{
+			item:  `{"kind":"AZSubscriptionOwner","data":{"subscriptionId":"/subscriptions/s","owners":[{"owner":{"properties":{"principalId":"sp"}}}]}}`,
+			edges: []graphEdge{{"AZOwns", "SP", "/SUBSCRIPTIONS/S"}},
+			ok:    true,
+		},
+		{
+			item:  `{"kind":"AZRoleAssignment","data":{"roleDefinitionId":"r","tenantId":"t","roleAssignments":[{"principalId":"u1"}]}}`,
+			edges: []graphEdge{{"AZHasRole", "U1", "R@T"}},
+			ok:    true,
+		},
+		{
+			item:  `{"kind":"AZManagementGroupDescendant","data":{"id":"/child","properties":{"parent":{"id":"/parent"}}}}`,
+			edges: []graphEdge{{"AZContains", "/PARENT", "/CHILD"}},
+			ok:    true,
+		},
+		{
+			item: `{"kind":"AZNamedLocation","data":{"id":"n"}}`,
+		},
+	} {
+		nodes, edges, ok, err := toGraph(tc.item)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.item, err)
+			continue
+		} else if ok != tc.ok {
+			t.Errorf("%s: got ok %v, want %v", tc.item, ok, tc.ok)
+		}
+
+		var gotNodes []string
+		for _, node := range nodes {
+			gotNodes = append(gotNodes, node.Label+":"+node.ObjectId)
+			if node.Properties["objectid"] != node.ObjectId {
+				t.Errorf("%s: expected the objectid property to be set", tc.item)
+			}
+		}
+		if strings.Join(gotNodes, ",") != strings.Join(tc.nodes, ",") {
+			t.Errorf("%s: got nodes %v, want %v", tc.item, gotNodes, tc.nodes)
+		} else if len(edges) != len(tc.edges) {
+			t.Errorf("%s: got edges %v, want %v", tc.item, edges, tc.edges)
+		} else {
+			for i := range edges {
+				if edges[i] != tc.edges[i] {
+					t.Errorf("%s: got edge %v, want %v", tc.item, edges[i], tc.edges[i])
+				}
+			}
+		}
+	}
+}
+
+func TestToGraphProperties(t *testing.T) {
+	nodes, _, _, err := toGraph(`{"kind":"AZUser","data":{"id":"u1","displayName":"Alice","accountEnabled":true,"onPremisesSyncEnabled":null,"passwordProfile":{"a":"b"},"lastSignIn":3}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if properties := nodes[0].Properties; properties["displayname"] != "Alice" || properties["accountenabled"] != true || properties["lastsignin"] != int64(3) {
+		t.Errorf("unexpected properties: %v", properties)
+	} else if _, ok := properties["passwordprofile"]; ok {
+		t.Error("expected nested values to be left out")
+	}
+}
+
+type neo4jServer struct {
+	mutex      sync.Mutex
+	failures   int
+	response   string
+	requests   int
+	statements []string
+	rows       int
+}
+
+func (s *neo4jServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests++
+
+	var body struct {
+		Statements []struct {
+			Statement  string `json:"statement"`
+			Parameters struct {
+				Rows []json.RawMessage `json:"rows"`
+			} `json:"parameters"`
+		} `json:"statements"`
+	}
+	if user, password, ok := r.BasicAuth(); !ok || user != "neo4j" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+	} else if r.URL.Path != "/db/neo4j/tx/commit" {
+		w.WriteHeader(http.StatusNotFound)
+	} else if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if s.response != "" {
+		w.Write([]byte(s.response))
+	} else {
+		for _, statement := range body.Statements {
+			s.statements = append(s.statements, statement.Statement)
+			s.rows += len(statement.Parameters.Rows)
+		}
+		w.Write([]byte(`{"results":[],"errors":[]}`))
+	}
+}
+
+func newNeo4jStream(items ...string) <-chan string {
+	stream := make(chan string, len(items))
+	for _, item := range items {
+		stream <- item
+	}
+	close(stream)
+	return stream
+}
+
+func TestWriteToNeo4j(t *testing.T) {
+	handler := &neo4jServer{failures: 1}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	var retries int
+	config := Neo4jConfig{
+		Uri:        server.URL,
+		User:       "neo4j",
+		Password:   "secret",
+		MaxRetries: 2,
+		MaxBackoff: time.Millisecond,
+		OnRetry:    func(error, time.Duration) { retries++ },
+	}
+	stream := newNeo4jStream(
+		`{"kind":"AZUser","data":{"id":"u1"}}`,
+		`{"kind":"AZGroup","data":{"id":"g"}}`,
+		`{"kind":"AZGroupMember","data":{"groupId":"g","members":[{"member":{"id":"u1"}}]}}`,
+		`{"kind":"AZNamedLocation","data":{"id":"n"}}`,
+	)
+
+	if counts, err := WriteToNeo4j(context.Background(), config, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if counts != (Neo4jCounts{Nodes: 2, Edges: 1, Skipped: 1}) {
+		t.Errorf("unexpected counts: %+v", counts)
+	} else if retries != 1 || handler.requests != 2 {
+		t.Errorf("got %v retries and %v requests, want 1 and 2", retries, handler.requests)
+	} else if len(handler.statements) != 3 || handler.rows != 3 {
+		t.Errorf("got %v statements with %v rows, want 3 and 3", len(handler.statements), handler.rows)
+	} else if !strings.Contains(handler.statements[0], "n:AZGroup") || !strings.Contains(handler.statements[2], "[:AZMemberOf]") {
+		t.Errorf("unexpected statements: %v", handler.statements)
+	}
+}
+
+func TestWriteToNeo4jStatementError(t *testing.T) {
+	handler := &neo4jServer{response: `{"results":[],"errors":[{"code":"Neo.ClientError.Statement.SyntaxError","message":"bad"}]}`}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	config := Neo4jConfig{Uri: server.URL, User: "neo4j", Password: "secret", MaxRetries: 2, MaxBackoff: time.Millisecond}
+	if _, err := WriteToNeo4j(context.Background(), config, newNeo4jStream(`{"kind":"AZUser","data":{"id":"u1"}}`)); err == nil {
+		t.Fatal("expected an error but did not receive one")
+	} else if !strings.Contains(err.Error(), "SyntaxError") {
+		t.Errorf("expected the neo4j error, got %v", err)
+	} else if handler.requests != 1 {
+		t.Errorf("got %v requests, want a client error not to be retried", handler.requests)
+	}
+}
+
+func TestWriteToNeo4jUnauthorized(t *testing.T) {
+	handler := &neo4jServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	config := Neo4jConfig{Uri: server.URL, User: "neo4j", Password: "wrong", MaxRetries: 2, MaxBackoff: time.Millisecond}
+	if _, err := WriteToNeo4j(context.Background(), config, newNeo4jStream(`{"kind":"AZUser","data":{"id":"u1"}}`)); err == nil {
+		t.Fatal("expected an error but did not receive one")
+	} else if handler.requests != 1 {
+		t.Errorf("got %v requests, want rejected credentials not to be retried", handler.requests)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The largest file uploaded in a single request, which both Azure Blob Storage and S3 cap at about 5 GB
+const MaxRemoteUploadSize = 5000 * 1024 * 1024
+
+// The hosts of Azure Blob Storage in each Azure cloud
+var blobHostSuffixes = []string{
+	".blob.core.windows.net",
+	".blob.core.usgovcloudapi.net",
+	".blob.core.chinacloudapi.cn",
+}
+
+// RemoteConfig configures UploadFileToUrl
+type RemoteConfig struct {
+	MaxRetries int
+	MaxBackoff time.Duration
+	Client     *http.Client
+	// OnRetry, when set, is called before a failed upload is retried
+	OnRetry func(err error, backoff time.Duration)
+}
+
+// ValidateUploadUrl checks rawUrl is either an Azure Blob Storage SAS URL or an s3://bucket/key URL
+func ValidateUploadUrl(rawUrl string) error {
+	if parsed, err := url.Parse(rawUrl); err != nil {
+		return err
+	} else if parsed.Scheme == "s3" {
+		if parsed.Host == "" {
+			return fmt.Errorf("%s has no bucket", RedactUrl(rawUrl))
+		}
+		return nil
+	} else if parsed.Scheme != "https" || !isBlobHost(parsed.Hostname()) {
+		return fmt.Errorf("%s is neither an https://<account>.blob.core.windows.net SAS URL nor an s3://bucket/key URL", RedactUrl(rawUrl))
+	} else if strings.Trim(parsed.Path, "/") == "" {
+		return fmt.Errorf("%s has no container", RedactUrl(rawUrl))
+	} else if !parsed.Query().Has("sig") {
+		return fmt.Errorf("%s has no shared access signature", RedactUrl(rawUrl))
+	} else {
+		return nil
+	}
+}
+
+// RedactUrl strips the query, which holds the signature of a SAS URL, so the URL can be logged
+func RedactUrl(rawUrl string) string {
+	if parsed, err := url.Parse(rawUrl); err != nil {
+		return "<invalid url>"
+	} else {
+		parsed.RawQuery = ""
+		parsed.User = nil
+		return parsed.String()
+	}
+}
+
+func isBlobHost(host string) bool {
+	for _, suffix := range blobHostSuffixes {
+		if strings.HasSuffix(strings.ToLower(host), suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadFileToUrl uploads the file at filePath to rawUrl, an Azure Blob Storage SAS URL or an s3://bucket/key URL
+// authenticated with the ambient AWS credentials. A URL naming a container, or a key ending in /, gets the name of
+// the file appended. The upload is checked against the MD5 digest of the file and retried with backoff when it fails
+// or the digests don't match. The URL the file was uploaded to is returned.
+func UploadFileToUrl(ctx context.Context, config RemoteConfig, filePath, rawUrl string) (string, error) {
+	if err := ValidateUploadUrl(rawUrl); err != nil {
+		return "", err
+	} else if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	if digest, err := digestFile(filePath); err != nil {
+		return "", err
+	} else if digest.size > MaxRemoteUploadSize {
+		return "", fmt.Errorf("%s is %d bytes, larger than the %d bytes that can be uploaded at once", filePath, digest.size, MaxRemoteUploadSize)
+	} else if target, err := newUploadTarget(ctx, config, rawUrl, filepath.Base(filePath)); err != nil {
+		return "", err
+	} else if err := uploadWithRetry(ctx, config, target, filePath, digest); err != nil {
+		return "", err
+	} else {
+		return RedactUrl(target.url), nil
+	}
+}
+
+// uploadTarget is where a file is uploaded to and how the service is told about, and reports, its digest
+type uploadTarget struct {
+	url    string
+	sign   func(req *http.Request, digest fileDigest) error
+	verify func(res *http.Response, digest fileDigest) error
+}
+
+func newUploadTarget(ctx context.Context, config RemoteConfig, rawUrl, name string) (uploadTarget, error) {
+	parsed, _ := url.Parse(rawUrl)
+	if parsed.Scheme == "s3" {
+		key := strings.TrimPrefix(parsed.Path, "/")
+		if key == "" || strings.HasSuffix(key, "/") {
+			key += name
+		}
+		if credentials, err := awsCredentials(ctx, config.Client); err != nil {
+			return uploadTarget{}, fmt.Errorf("unable to find AWS credentials: %w", err)
+		} else {
+			return s3Target(parsed.Host, key, awsRegion(), credentials), nil
+		}
+	} else {
+		if !strings.Contains(strings.Trim(parsed.Path, "/"), "/") || strings.HasSuffix(parsed.Path, "/") {
+			parsed.Path = path.Join(parsed.Path, name)
+		}
+		return blobTarget(parsed.String()), nil
+	}
+}
+
+// blobTarget puts the file as a block blob, which Azure Storage checks against Content-MD5
+func blobTarget(blobUrl string) uploadTarget {
+	return uploadTarget{
+		url: blobUrl,
+		sign: func(req *http.Request, digest fileDigest) error {
+			req.Header.Set("x-ms-blob-type", "BlockBlob")
+			req.Header.Set("x-ms-version", "2021-08-06")
+			req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(digest.md5))
+			return nil
+		},
+		verify: func(res *http.Response, digest fileDigest) error {
+			if reported := res.Header.Get("Content-MD5"); reported == "" {
+				return nil
+			} else if want := base64.StdEncoding.EncodeToString(digest.md5); reported != want {
+				return fmt.Errorf("%w: azure storage reported MD5 %s, want %s", errRemoteTransient, reported, want)
+			} else {
+				return nil
+			}
+		},
+	}
+}
+
+type fileDigest struct {
+	size   int64
+	md5    []byte
+	sha256 []byte
+}
+
+func digestFile(filePath string) (fileDigest, error) {
+	var (
+		md5Hash    = md5.New()
+		sha256Hash = sha256.New()
+	)
+
+	if file, err := os.Open(filePath); err != nil {
+		return fileDigest{}, err
+	} else {
+		defer file.Close()
+		if size, err := io.Copy(io.MultiWriter(md5Hash, sha256Hash), file); err != nil {
+			return fileDigest{}, err
+		} else {
+			return fileDigest{size: size, md5: md5Hash.Sum(nil), sha256: sha256Hash.Sum(nil)}, nil
+		}
+	}
+}
+
+// errRemoteTransient marks failures that are worth retrying
+var errRemoteTransient = errors.New("transient upload error")
+
+func uploadWithRetry(ctx context.Context, config RemoteConfig, target uploadTarget, filePath string, digest fileDigest) error {
+	return retryRemote(ctx, config, func() error {
+		return putFile(ctx, config, target, filePath, digest)
+	})
+}
+
+// retryRemote calls fn until it succeeds, fails with an error that is not errRemoteTransient or config.MaxRetries
+// retries have been made, backing off exponentially between attempts
+func retryRemote(ctx context.Context, config RemoteConfig, fn func() error) error {
+	for retry := 0; ; retry++ {
+		if err := fn(); err == nil {
+			return nil
+		} else if !errors.Is(err, errRemoteTransient) || retry >= config.MaxRetries || ctx.Err() != nil {
+			return err
+		} else {
+			backoff := time.Second * time.Duration(math.Pow(2, float64(retry+1)))
+			if config.MaxBackoff > 0 && backoff > config.MaxBackoff {
+				backoff = config.MaxBackoff
+			}
+			if config.OnRetry != nil {
+				config.OnRetry(err, backoff)
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+func putFile(ctx context.Context, config RemoteConfig, target uploadTarget, filePath string, digest fileDigest) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.url, file); err != nil {
+		return err
+	} else {
+		req.ContentLength = digest.size
+		req.Header.Set("Content-Type", "application/octet-stream")
+		if err := target.sign(req, digest); err != nil {
+			return err
+		}
+
+		if res, err := config.Client.Do(req); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %v", errRemoteTransient, err)
+		} else {
+			defer res.Body.Close()
+			message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+			if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%w: %s", errRemoteTransient, res.Status)
+			} else if res.StatusCode == http.StatusBadRequest && (strings.Contains(string(message), "Md5Mismatch") || strings.Contains(string(message), "BadDigest")) {
+				// the file was corrupted on the way, so sending it again may well succeed
+				return fmt.Errorf("%w: %s: %s", errRemoteTransient, res.Status, message)
+			} else if res.StatusCode >= http.StatusBadRequest {
+				return fmt.Errorf("upload responded %s: %s", res.Status, message)
+			} else {
+				return target.verify(res, digest)
+			}
+		}
+	}
+}
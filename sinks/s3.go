@@ -0,0 +1,327 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsDefaultRegion = "us-east-1"
+	ec2MetadataUrl   = "http://169.254.169.254"
+	ecsMetadataUrl   = "http://169.254.170.2"
+)
+
+// The link-local metadata endpoints are never reached through a proxy and don't answer at all outside of AWS
+var awsMetadataClient = &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{}}
+
+type awsCredentialSet struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func awsRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	} else if region := os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		return region
+	} else {
+		return awsDefaultRegion
+	}
+}
+
+// awsEndpoint returns the endpoint configured for service through the environment, if any
+func awsEndpoint(service string) string {
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_" + strings.ToUpper(service)); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/")
+	} else {
+		return strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/")
+	}
+}
+
+// awsCredentials finds the ambient AWS credentials the way the AWS SDKs do, short of shared config files: from the
+// environment, a web identity token such as that of an EKS service account, the ECS container credentials endpoint
+// and finally the EC2 instance metadata service.
+func awsCredentials(ctx context.Context, client *http.Client) (awsCredentialSet, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return awsCredentialSet{AccessKeyId: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	} else if tokenFile, roleArn := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && roleArn != "" {
+		return webIdentityCredentials(ctx, client, tokenFile, roleArn)
+	} else if fullUri, relativeUri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"), os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); fullUri != "" || relativeUri != "" {
+		if fullUri == "" {
+			fullUri = ecsMetadataUrl + relativeUri
+		}
+		return containerCredentials(ctx, fullUri)
+	} else if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return awsCredentialSet{}, fmt.Errorf("no credentials in the environment and the instance metadata service is disabled")
+	} else {
+		return instanceCredentials(ctx)
+	}
+}
+
+// awsMetadataCredentials is how the container and instance metadata endpoints report credentials
+type awsMetadataCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+}
+
+func containerCredentials(ctx context.Context, uri string) (awsCredentialSet, error) {
+	header := http.Header{}
+	if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+		header.Set("Authorization", token)
+	} else if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		if token, err := os.ReadFile(tokenFile); err != nil {
+			return awsCredentialSet{}, err
+		} else {
+			header.Set("Authorization", strings.TrimSpace(string(token)))
+		}
+	}
+
+	var credentials awsMetadataCredentials
+	if err := getAwsMetadata(ctx, uri, header, &credentials); err != nil {
+		return awsCredentialSet{}, fmt.Errorf("unable to get container credentials: %w", err)
+	} else {
+		return awsCredentialSet{AccessKeyId: credentials.AccessKeyId, SecretAccessKey: credentials.SecretAccessKey, SessionToken: credentials.Token}, nil
+	}
+}
+
+// instanceCredentials gets the credentials of the instance profile using a session token (IMDSv2)
+func instanceCredentials(ctx context.Context) (awsCredentialSet, error) {
+	var credentials awsMetadataCredentials
+
+	if req, err := http.NewRequestWithContext(ctx, http.MethodPut, ec2MetadataUrl+"/latest/api/token", nil); err != nil {
+		return awsCredentialSet{}, err
+	} else {
+		req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+		if res, err := awsMetadataClient.Do(req); err != nil {
+			return awsCredentialSet{}, fmt.Errorf("unable to reach the instance metadata service: %w", err)
+		} else {
+			defer res.Body.Close()
+			if token, err := io.ReadAll(res.Body); err != nil {
+				return awsCredentialSet{}, err
+			} else if res.StatusCode != http.StatusOK {
+				return awsCredentialSet{}, fmt.Errorf("instance metadata service responded %s", res.Status)
+			} else {
+				header := http.Header{"X-Aws-Ec2-Metadata-Token": []string{string(token)}}
+				if role, err := getAwsMetadataText(ctx, ec2MetadataUrl+"/latest/meta-data/iam/security-credentials/", header); err != nil {
+					return awsCredentialSet{}, fmt.Errorf("unable to find the instance profile: %w", err)
+				} else if err := getAwsMetadata(ctx, ec2MetadataUrl+"/latest/meta-data/iam/security-credentials/"+strings.TrimSpace(strings.SplitN(role, "\n", 2)[0]), header, &credentials); err != nil {
+					return awsCredentialSet{}, fmt.Errorf("unable to get instance profile credentials: %w", err)
+				} else {
+					return awsCredentialSet{AccessKeyId: credentials.AccessKeyId, SecretAccessKey: credentials.SecretAccessKey, SessionToken: credentials.Token}, nil
+				}
+			}
+		}
+	}
+}
+
+func getAwsMetadataText(ctx context.Context, uri string, header http.Header) (string, error) {
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil); err != nil {
+		return "", err
+	} else {
+		req.Header = header
+		if res, err := awsMetadataClient.Do(req); err != nil {
+			return "", err
+		} else {
+			defer res.Body.Close()
+			if body, err := io.ReadAll(io.LimitReader(res.Body, 64*1024)); err != nil {
+				return "", err
+			} else if res.StatusCode != http.StatusOK {
+				return "", fmt.Errorf("metadata endpoint responded %s", res.Status)
+			} else {
+				return string(body), nil
+			}
+		}
+	}
+}
+
+func getAwsMetadata(ctx context.Context, uri string, header http.Header, value any) error {
+	if body, err := getAwsMetadataText(ctx, uri, header); err != nil {
+		return err
+	} else {
+		return json.Unmarshal([]byte(body), value)
+	}
+}
+
+// webIdentityCredentials exchanges the web identity token for credentials of the role, a request that is itself
+// unsigned
+func webIdentityCredentials(ctx context.Context, client *http.Client, tokenFile, roleArn string) (awsCredentialSet, error) {
+	var response struct {
+		Credentials struct {
+			AccessKeyId     string
+			SecretAccessKey string
+			SessionToken    string
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "azurehound"
+	}
+
+	endpoint := awsEndpoint("sts")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", awsRegion())
+	}
+
+	if token, err := os.ReadFile(tokenFile); err != nil {
+		return awsCredentialSet{}, fmt.Errorf("unable to read web identity token: %w", err)
+	} else if req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/?"+url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"RoleArn":          {roleArn},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+		"Version":          {"2011-06-15"},
+	}.Encode(), nil); err != nil {
+		return awsCredentialSet{}, err
+	} else if res, err := client.Do(req); err != nil {
+		return awsCredentialSet{}, fmt.Errorf("unable to assume role with web identity: %w", err)
+	} else {
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+			return awsCredentialSet{}, fmt.Errorf("unable to assume role with web identity: %s: %s", res.Status, message)
+		} else if err := xml.NewDecoder(res.Body).Decode(&response); err != nil {
+			return awsCredentialSet{}, fmt.Errorf("unable to decode web identity credentials: %w", err)
+		} else {
+			return awsCredentialSet(response.Credentials), nil
+		}
+	}
+}
+
+// s3Target puts the file as a single object. S3 checks it against Content-MD5 and, for objects that aren't
+// encrypted with KMS, reports the MD5 digest as the ETag.
+func s3Target(bucket, key, region string, credentials awsCredentialSet) uploadTarget {
+	escapedKey := awsEscapePath(key)
+
+	var objectUrl string
+	if endpoint := awsEndpoint("s3"); endpoint != "" {
+		objectUrl = fmt.Sprintf("%s/%s/%s", endpoint, bucket, escapedKey)
+	} else if strings.Contains(bucket, ".") {
+		// virtual hosted buckets with dots in their name don't match the wildcard certificate
+		objectUrl = fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", region, bucket, escapedKey)
+	} else {
+		objectUrl = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, escapedKey)
+	}
+
+	return uploadTarget{
+		url: objectUrl,
+		sign: func(req *http.Request, digest fileDigest) error {
+			req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(digest.md5))
+			signAwsRequest(req, hex.EncodeToString(digest.sha256), region, "s3", credentials, time.Now().UTC())
+			return nil
+		},
+		verify: func(res *http.Response, digest fileDigest) error {
+			etag := strings.Trim(res.Header.Get("ETag"), `"`)
+			if want := hex.EncodeToString(digest.md5); len(etag) != len(want) || strings.EqualFold(etag, want) {
+				// ETags of KMS encrypted objects aren't digests; S3 has still checked Content-MD5
+				return nil
+			} else {
+				return fmt.Errorf("%w: s3 reported ETag %s, want %s", errRemoteTransient, etag, want)
+			}
+		},
+	}
+}
+
+// awsEscapePath escapes each segment of an object key the way Signature Version 4 expects
+func awsEscapePath(key string) string {
+	var builder strings.Builder
+	for _, b := range []byte(key) {
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') || strings.IndexByte("-._~/", b) >= 0 {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+// signAwsRequest adds a Signature Version 4 authorization header signing the host, the x-amz headers and
+// Content-MD5 of req. See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html
+func signAwsRequest(req *http.Request, payloadHash, region, service string, credentials awsCredentialSet, now time.Time) {
+	var (
+		amzDate = now.Format("20060102T150405Z")
+		date    = now.Format("20060102")
+		scope   = fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-amz-") || name == "content-md5" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, service)
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", credentials.AccessKeyId, scope, signedHeaders, signature))
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
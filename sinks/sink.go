@@ -0,0 +1,101 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// OutputSink is a destination for the items of a collection
+type OutputSink interface {
+	// Write adds item, a collected object formatted as json, to the collection
+	Write(item string) error
+	// Close flushes anything the sink has buffered and releases it; the collection is incomplete if Close fails
+	Close() error
+}
+
+// WriteToSink writes stream to sink and closes it once stream closes or ctx is done, so an interrupted collection is
+// still flushed. The first error writing to or closing the sink is returned.
+func WriteToSink(ctx context.Context, sink OutputSink, stream <-chan string) error {
+	for item := range pipeline.OrDone(ctx.Done(), stream) {
+		if err := sink.Write(item); err != nil {
+			sink.Close()
+			return err
+		}
+	}
+	return sink.Close()
+}
+
+// RemoteSinkShutdownTimeout bounds how long a remote sink may take to send what it has buffered once the collection
+// has been interrupted
+const RemoteSinkShutdownTimeout = 30 * time.Second
+
+// shutdownContext returns ctx, or a context allowing RemoteSinkShutdownTimeout to flush a sink once ctx is done
+func shutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return ctx, func() {}
+	} else {
+		return context.WithTimeout(context.Background(), RemoteSinkShutdownTimeout)
+	}
+}
+
+// collectionSink writes a collection file to w, closing w once the footer is written
+type collectionSink struct {
+	w          io.WriteCloser
+	collection *collectionWriter
+}
+
+func newCollectionSink(ctx context.Context, w io.WriteCloser) (OutputSink, error) {
+	if collection, err := newCollectionWriter(ctx, w); err != nil {
+		w.Close()
+		return nil, err
+	} else {
+		return &collectionSink{w: w, collection: collection}, nil
+	}
+}
+
+func (s *collectionSink) Write(item string) error {
+	return s.collection.Write(item)
+}
+
+func (s *collectionSink) Close() error {
+	if err := s.collection.Close(); err != nil {
+		s.w.Close()
+		return err
+	} else {
+		return s.w.Close()
+	}
+}
+
+// NewFileSink returns a sink writing a collection file to filePath, compressing it when IsCompressed(filePath)
+func NewFileSink(ctx context.Context, filePath string) (OutputSink, error) {
+	if file, err := createFile(filePath); err != nil {
+		return nil, err
+	} else {
+		return newCollectionSink(ctx, file)
+	}
+}
+
+// NewConsoleSink returns a sink writing the collection to stdout, gzip compressing it when compress is set
+func NewConsoleSink(ctx context.Context, compress bool) (OutputSink, error) {
+	return newCollectionSink(ctx, openConsole(compress))
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.json")
+	if sink, err := NewFileSink(context.Background(), path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(context.Background(), sink, newConsoleStream()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var collection struct {
+		Data []json.RawMessage `json:"data"`
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	if content, err := os.ReadFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := json.Unmarshal(content, &collection); err != nil {
+		t.Fatalf("expected the file to hold a single collection: %v", err)
+	} else if len(collection.Data) != 2 || collection.Meta.Count != 2 {
+		t.Errorf("got %v items and a count of %v, want 2", len(collection.Data), collection.Meta.Count)
+	}
+}
+
+func TestConsoleSinkInterrupted(t *testing.T) {
+	buffer := captureStdout(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the stream never closes so only the cancellation ends the collection
+	if sink, err := NewConsoleSink(ctx, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := WriteToSink(ctx, sink, make(chan string)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !json.Valid(buffer.Bytes()) {
+		t.Errorf("expected the interrupted collection to be closed: %s", buffer.String())
+	}
+}
+
+type failingSink struct {
+	closed bool
+}
+
+func (s *failingSink) Write(item string) error {
+	return errors.New("disk full")
+}
+
+func (s *failingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestWriteToSinkError(t *testing.T) {
+	sink := &failingSink{}
+	if err := WriteToSink(context.Background(), sink, newConsoleStream()); err == nil {
+		t.Error("expected an error but did not receive one")
+	} else if !sink.closed {
+		t.Error("expected the sink to be closed after a failed write")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bloodhoundad/azurehound/v2/enums"
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// SplitFileName returns the name of the file holding objects of kind in a split output directory, e.g. azusers.json
+func SplitFileName(kind enums.Kind, compress bool) string {
+	name := strings.ToLower(string(kind)) + "s.json"
+	if compress {
+		name += CompressedSuffix
+	}
+	return name
+}
+
+type splitFile struct {
+	file       io.WriteCloser
+	collection *collectionWriter
+}
+
+// WriteSplitToDir demultiplexes a stream of JSON formatted wrappers by kind, writing each kind to its own collection
+// file in dir with its own meta footer. Files are created the first time their kind is seen and every file is
+// finished once stream closes or ctx is done. It returns the number of objects written per file name.
+func WriteSplitToDir[T any](ctx context.Context, dir string, compress bool, stream <-chan T) (map[string]int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var (
+		files = map[enums.Kind]*splitFile{}
+		err   error
+	)
+
+	for item := range pipeline.OrDone(ctx.Done(), stream) {
+		if err = writeSplit(ctx, dir, compress, files, fmt.Sprint(item)); err != nil {
+			break
+		}
+	}
+
+	counts := map[string]int{}
+	for _, kind := range sortedKinds(files) {
+		if closeErr := files[kind].close(); err == nil {
+			err = closeErr
+		}
+		counts[SplitFileName(kind, compress)] = files[kind].collection.meta.Count
+	}
+	return counts, err
+}
+
+func writeSplit(ctx context.Context, dir string, compress bool, files map[enums.Kind]*splitFile, item string) error {
+	var wrapper struct {
+		Kind enums.Kind `json:"kind"`
+	}
+
+	if err := json.Unmarshal([]byte(item), &wrapper); err != nil {
+		return fmt.Errorf("unable to read object kind: %w", err)
+	} else if name := SplitFileName(wrapper.Kind, compress); wrapper.Kind == "" || filepath.Base(name) != name {
+		return fmt.Errorf("unable to split object with kind %q", wrapper.Kind)
+	} else if split, ok := files[wrapper.Kind]; ok {
+		return split.collection.Write(item)
+	} else if file, err := createFile(filepath.Join(dir, name)); err != nil {
+		return err
+	} else if collection, err := newCollectionWriter(ctx, file); err != nil {
+		file.Close()
+		return err
+	} else {
+		files[wrapper.Kind] = &splitFile{file: file, collection: collection}
+		return collection.Write(item)
+	}
+}
+
+func (s *splitFile) close() error {
+	if err := s.collection.Close(); err != nil {
+		s.file.Close()
+		return err
+	} else {
+		return s.file.Close()
+	}
+}
+
+func sortedKinds(files map[enums.Kind]*splitFile) []enums.Kind {
+	kinds := make([]enums.Kind, 0, len(files))
+	for kind := range files {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func readCollectionFile(t *testing.T, path string) collectionFile {
+	var collection collectionFile
+	if file, err := OpenFile(path); err != nil {
+		t.Fatal(err)
+	} else {
+		defer file.Close()
+		if err := json.NewDecoder(file).Decode(&collection); err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+	}
+	return collection
+}
+
+func TestWriteSplitToDir(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		var (
+			dir    = filepath.Join(t.TempDir(), "split")
+			stream = make(chan string, 4)
+		)
+		stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+		stream <- `{"kind":"AZGroup","data":{"id":"2"}}`
+		stream <- `{"kind":"AZUser","data":{"id":"3"}}`
+		stream <- `{"kind":"AZRoleAssignment","data":{"id":"4"}}`
+		close(stream)
+
+		counts, err := WriteSplitToDir(context.Background(), dir, compress, stream)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := map[string]int{"azusers.json": 2, "azgroups.json": 1, "azroleassignments.json": 1}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != len(want) || len(counts) != len(want) {
+			t.Errorf("got %v files, want %v", len(entries), len(want))
+		}
+		for name, count := range want {
+			if compress {
+				name += CompressedSuffix
+			}
+			if collection := readCollectionFile(t, filepath.Join(dir, name)); len(collection.Data) != count || collection.Meta.Count != count {
+				t.Errorf("%s: got %v items and count %v, want %v", name, len(collection.Data), collection.Meta.Count, count)
+			} else if counts[name] != count {
+				t.Errorf("%s: got reported count %v, want %v", name, counts[name], count)
+			}
+		}
+	}
+}
+
+func TestWriteSplitToDirCancelled(t *testing.T) {
+	var (
+		dir         = t.TempDir()
+		stream      = make(chan string)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	go func() {
+		stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+		stream <- `{"kind":"AZGroup","data":{"id":"2"}}`
+		cancel()
+	}()
+
+	if _, err := WriteSplitToDir(ctx, dir, true, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the group may or may not be seen before the cancellation but every file that was opened must be complete
+	var names []string
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		if collection := readCollectionFile(t, filepath.Join(dir, entry.Name())); collection.Meta.Count != 1 {
+			t.Errorf("%s: got count %v, want 1", entry.Name(), collection.Meta.Count)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 || names[len(names)-1] != "azusers.json.gz" {
+		t.Errorf("got files %v, want azusers.json.gz", strings.Join(names, ","))
+	}
+}
+
+func TestWriteSplitToDirInvalidKind(t *testing.T) {
+	for _, item := range []string{`{"data":{"id":"1"}}`, `{"kind":"../AZUser","data":{}}`, `not json`} {
+		stream := make(chan string, 1)
+		stream <- item
+		close(stream)
+
+		if _, err := WriteSplitToDir(context.Background(), t.TempDir(), false, stream); err == nil {
+			t.Errorf("%s: expected an error but did not receive one", item)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bloodhoundad/azurehound/v2/pipeline"
+)
+
+// The default maximum uncompressed size of each collection file in a zip archive
+const DefaultZipChunkSize = 500 * 1024 * 1024
+
+// reserved for the footer of each chunk so that a finished chunk stays within the maximum size
+const chunkFooterSize = 128
+
+// WriteZipToFile writes stream to a zip archive at filePath, splitting it into collection files of at most chunkSize
+// uncompressed bytes that are each valid on their own with their own meta footer. An item larger than chunkSize is
+// written to a chunk by itself. The archive is finished once stream closes or ctx is done. It returns the number of
+// collection files written.
+func WriteZipToFile[T any](ctx context.Context, filePath string, chunkSize int64, stream <-chan T) (int, error) {
+	if file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666); err != nil {
+		return 0, err
+	} else if chunks, err := WriteZip(ctx, file, chunkSize, stream); err != nil {
+		file.Close()
+		return chunks, err
+	} else {
+		return chunks, file.Close()
+	}
+}
+
+// WriteZip writes stream to w as a zip archive of collection files of at most chunkSize uncompressed bytes each
+func WriteZip[T any](ctx context.Context, w io.Writer, chunkSize int64, stream <-chan T) (int, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultZipChunkSize
+	}
+
+	var (
+		archive = zip.NewWriter(w)
+		chunk   *zipChunk
+		chunks  = 0
+	)
+
+	for item := range pipeline.OrDone(ctx.Done(), stream) {
+		formatted := fmt.Sprint(item)
+		if chunk != nil && chunk.collection.meta.Count > 0 && chunk.size+int64(len(formatted))+chunkFooterSize > chunkSize {
+			if err := chunk.collection.Close(); err != nil {
+				return chunks, err
+			}
+			chunk = nil
+		}
+
+		if chunk == nil {
+			chunks++
+			if entry, err := archive.Create(fmt.Sprintf("azurehound_%04d.json", chunks)); err != nil {
+				return chunks, err
+			} else if chunk, err = newZipChunk(ctx, entry); err != nil {
+				return chunks, err
+			}
+		}
+
+		if err := chunk.collection.Write(formatted); err != nil {
+			return chunks, err
+		}
+	}
+
+	if chunk != nil {
+		if err := chunk.collection.Close(); err != nil {
+			return chunks, err
+		}
+	}
+	return chunks, archive.Close()
+}
+
+// zipChunk counts the uncompressed bytes written to a collection file in a zip archive
+type zipChunk struct {
+	w          io.Writer
+	size       int64
+	collection *collectionWriter
+}
+
+func newZipChunk(ctx context.Context, w io.Writer) (*zipChunk, error) {
+	chunk := &zipChunk{w: w}
+	if collection, err := newCollectionWriter(ctx, chunk); err != nil {
+		return nil, err
+	} else {
+		chunk.collection = collection
+		return chunk, nil
+	}
+}
+
+func (s *zipChunk) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.size += int64(n)
+	return n, err
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sinks
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readZipChunks(t *testing.T, path string) ([]collectionFile, []int64) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer archive.Close()
+
+	var (
+		collections []collectionFile
+		sizes       []int64
+	)
+	for _, entry := range archive.File {
+		var collection collectionFile
+		if reader, err := entry.Open(); err != nil {
+			t.Fatal(err)
+		} else if bytes, err := io.ReadAll(reader); err != nil {
+			t.Fatal(err)
+		} else if err := json.Unmarshal(bytes, &collection); err != nil {
+			t.Fatalf("%s: expected a valid collection file: %v", entry.Name, err)
+		} else {
+			collections = append(collections, collection)
+			sizes = append(sizes, int64(len(bytes)))
+		}
+	}
+	return collections, sizes
+}
+
+func TestWriteZipToFile(t *testing.T) {
+	var (
+		path      = filepath.Join(t.TempDir(), "output.zip")
+		stream    = make(chan string, 50)
+		chunkSize = int64(512)
+	)
+	for i := 0; i < 50; i++ {
+		stream <- fmt.Sprintf(`{"kind":"AZUser","data":{"id":"%d"}}`, i)
+	}
+	close(stream)
+
+	chunks, err := WriteZipToFile(context.Background(), path, chunkSize, stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	collections, sizes := readZipChunks(t, path)
+	if len(collections) != chunks || chunks < 2 {
+		t.Fatalf("got %v files and %v reported chunks, want more than one", len(collections), chunks)
+	}
+
+	total := 0
+	for i, collection := range collections {
+		if len(collection.Data) != collection.Meta.Count {
+			t.Errorf("chunk %d: got %v items and count %v", i, len(collection.Data), collection.Meta.Count)
+		} else if sizes[i] > chunkSize {
+			t.Errorf("chunk %d: got %v bytes, want at most %v", i, sizes[i], chunkSize)
+		}
+		total += collection.Meta.Count
+	}
+	if total != 50 {
+		t.Errorf("got %v items, want 50", total)
+	}
+}
+
+func TestWriteZipOversizedItem(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "output.zip")
+		stream = make(chan string, 3)
+	)
+	stream <- `{"kind":"AZUser","data":{"id":"1"}}`
+	stream <- `{"kind":"AZUser","data":{"description":"` + strings.Repeat("a", 1024) + `"}}`
+	stream <- `{"kind":"AZUser","data":{"id":"3"}}`
+	close(stream)
+
+	if chunks, err := WriteZipToFile(context.Background(), path, 256, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if chunks != 3 {
+		t.Errorf("got %v chunks, want 3", chunks)
+	}
+
+	collections, _ := readZipChunks(t, path)
+	for i, collection := range collections {
+		if collection.Meta.Count != 1 {
+			t.Errorf("chunk %d: got count %v, want 1", i, collection.Meta.Count)
+		}
+	}
+}
+
+func TestWriteZipEmpty(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "output.zip")
+		stream = make(chan string)
+	)
+	close(stream)
+
+	if chunks, err := WriteZipToFile(context.Background(), path, 0, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if collections, _ := readZipChunks(t, path); chunks != 0 || len(collections) != 0 {
+		t.Errorf("got %v chunks, want 0", chunks)
+	}
+}
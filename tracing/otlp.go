@@ -0,0 +1,296 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bloodhoundad/azurehound/v2/constants"
+)
+
+const (
+	otlpBatchSize     = 512
+	otlpBufferSize    = 4096
+	otlpFlushInterval = 5 * time.Second
+)
+
+// OTLPExporter batches spans and sends them to an OpenTelemetry collector using OTLP over HTTP with JSON encoding.
+// Spans are dropped rather than blocking the collection when the collector can't keep up.
+type OTLPExporter struct {
+	endpoint string
+	client   *http.Client
+	spans    chan SpanData
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	dropped  int64
+	failed   int64
+	mutex    sync.Mutex
+	lastErr  error
+}
+
+// NewOTLPExporter returns an exporter sending spans to endpoint, e.g. http://localhost:4318. The traces path is
+// appended unless endpoint already has a path.
+func NewOTLPExporter(endpoint string, client *http.Client) (*OTLPExporter, error) {
+	if parsed, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid otlp endpoint: %w", err)
+	} else if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid otlp endpoint %s: the scheme must be http or https", endpoint)
+	} else {
+		if parsed.Path == "" || parsed.Path == "/" {
+			parsed.Path = "/v1/traces"
+		}
+
+		exporter := &OTLPExporter{
+			endpoint: parsed.String(),
+			client:   client,
+			spans:    make(chan SpanData, otlpBufferSize),
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+		go exporter.run()
+		return exporter, nil
+	}
+}
+
+func (s *OTLPExporter) Export(span SpanData) {
+	select {
+	case s.spans <- span:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+func (s *OTLPExporter) Shutdown(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if dropped := atomic.LoadInt64(&s.dropped); dropped > 0 {
+		return fmt.Errorf("dropped %d spans because the otlp exporter buffer was full", dropped)
+	} else if s.lastErr != nil {
+		return fmt.Errorf("failed to export %d spans: %w", atomic.LoadInt64(&s.failed), s.lastErr)
+	} else {
+		return nil
+	}
+}
+
+func (s *OTLPExporter) run() {
+	defer close(s.done)
+
+	var (
+		ticker = time.NewTicker(otlpFlushInterval)
+		batch  = make([]SpanData, 0, otlpBatchSize)
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case span := <-s.spans:
+			if batch = append(batch, span); len(batch) >= otlpBatchSize {
+				batch = s.flush(batch)
+			}
+		case <-ticker.C:
+			batch = s.flush(batch)
+		case <-s.stop:
+			for {
+				select {
+				case span := <-s.spans:
+					if batch = append(batch, span); len(batch) >= otlpBatchSize {
+						batch = s.flush(batch)
+					}
+				default:
+					s.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *OTLPExporter) flush(batch []SpanData) []SpanData {
+	if len(batch) == 0 {
+		return batch
+	} else if err := s.send(batch); err != nil {
+		atomic.AddInt64(&s.failed, int64(len(batch)))
+		s.mutex.Lock()
+		s.lastErr = err
+		s.mutex.Unlock()
+	}
+	return batch[:0]
+}
+
+func (s *OTLPExporter) send(batch []SpanData) error {
+	spans := make([]otlpSpan, len(batch))
+	for i, span := range batch {
+		spans[i] = newOTLPSpan(span)
+	}
+
+	request := otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{
+					newOTLPKeyValue("service.name", constants.Name),
+					newOTLPKeyValue("service.version", constants.Version),
+				},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: constants.Name, Version: constants.Version},
+				Spans: spans,
+			}},
+		}},
+	}
+
+	if body, err := json.Marshal(request); err != nil {
+		return err
+	} else if req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body)); err != nil {
+		return err
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+		if res, err := s.client.Do(req); err != nil {
+			return err
+		} else {
+			defer res.Body.Close()
+			if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+				return fmt.Errorf("unexpected response from %s: %s", s.endpoint, res.Status)
+			}
+			return nil
+		}
+	}
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// Integers are encoded as strings since they are 64 bit in OTLP
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func newOTLPSpan(span SpanData) otlpSpan {
+	result := otlpSpan{
+		TraceId:           hex.EncodeToString(span.TraceId[:]),
+		SpanId:            hex.EncodeToString(span.SpanId[:]),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		Status:            otlpStatus{Code: span.Status, Message: span.StatusMessage},
+	}
+
+	if span.ParentSpanId != [8]byte{} {
+		result.ParentSpanId = hex.EncodeToString(span.ParentSpanId[:])
+	}
+
+	for _, attribute := range span.Attributes {
+		result.Attributes = append(result.Attributes, newOTLPKeyValue(attribute.Key, attribute.Value))
+	}
+	return result
+}
+
+func newOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	var result otlpAnyValue
+	switch value := value.(type) {
+	case string:
+		result.StringValue = &value
+	case bool:
+		result.BoolValue = &value
+	case int:
+		result.IntValue = formatInt(int64(value))
+	case int32:
+		result.IntValue = formatInt(int64(value))
+	case int64:
+		result.IntValue = formatInt(value)
+	case float64:
+		result.DoubleValue = &value
+	case fmt.Stringer:
+		stringValue := value.String()
+		result.StringValue = &stringValue
+	default:
+		stringValue := strings.TrimSpace(fmt.Sprint(value))
+		result.StringValue = &stringValue
+	}
+	return otlpKeyValue{Key: key, Value: result}
+}
+
+func formatInt(value int64) *string {
+	result := strconv.FormatInt(value, 10)
+	return &result
+}
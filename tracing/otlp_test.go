@@ -0,0 +1,137 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNewOTLPExporterEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:4318":            "http://localhost:4318/v1/traces",
+		"https://collector.example.com/":   "https://collector.example.com/v1/traces",
+		"http://localhost:4318/custom/url": "http://localhost:4318/custom/url",
+	}
+
+	for endpoint, want := range tests {
+		if exporter, err := NewOTLPExporter(endpoint, http.DefaultClient); err != nil {
+			t.Errorf("unexpected error for %s: %v", endpoint, err)
+		} else {
+			exporter.Shutdown(context.Background())
+			if exporter.endpoint != want {
+				t.Errorf("got %s, want %s", exporter.endpoint, want)
+			}
+		}
+	}
+
+	if _, err := NewOTLPExporter("localhost:4318", http.DefaultClient); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests []otlpRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("got path %s, want /v1/traces", r.URL.Path)
+		} else if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got content type %s, want application/json", r.Header.Get("Content-Type"))
+		}
+
+		var request otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		requests = append(requests, request)
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	Enable(exporter, 100)
+
+	ctx, root := Start(context.Background(), "root", "kind", "users", "count", 42, "ok", true)
+	_, child := Start(ctx, "child")
+	child.End()
+	root.End()
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 1 || len(requests[0].ResourceSpans) != 1 || len(requests[0].ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected requests: %+v", requests)
+	}
+
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+
+	exportedChild, exportedRoot := spans[0], spans[1]
+	if len(exportedRoot.TraceId) != 32 || len(exportedRoot.SpanId) != 16 {
+		t.Errorf("unexpected ids: %s %s", exportedRoot.TraceId, exportedRoot.SpanId)
+	} else if exportedRoot.ParentSpanId != "" {
+		t.Errorf("got parent %s for the root span, want none", exportedRoot.ParentSpanId)
+	} else if exportedChild.ParentSpanId != exportedRoot.SpanId || exportedChild.TraceId != exportedRoot.TraceId {
+		t.Error("expected the child to reference the root span")
+	} else if exportedRoot.Kind != SpanKindInternal {
+		t.Errorf("got kind %v, want %v", exportedRoot.Kind, SpanKindInternal)
+	}
+
+	attributes := map[string]otlpAnyValue{}
+	for _, attribute := range exportedRoot.Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+	if value := attributes["kind"].StringValue; value == nil || *value != "users" {
+		t.Errorf("unexpected kind attribute: %+v", attributes["kind"])
+	} else if value := attributes["count"].IntValue; value == nil || *value != "42" {
+		t.Errorf("unexpected count attribute: %+v", attributes["count"])
+	} else if value := attributes["ok"].BoolValue; value == nil || !*value {
+		t.Errorf("unexpected ok attribute: %+v", attributes["ok"])
+	}
+}
+
+func TestOTLPExporterFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exporter.Export(SpanData{Name: "span"})
+
+	if err := exporter.Shutdown(context.Background()); err == nil {
+		t.Error("expected an error but did not receive one")
+	}
+}
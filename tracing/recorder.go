@@ -0,0 +1,46 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// Recorder is an Exporter that keeps every span in memory, which is mostly useful in tests
+type Recorder struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+func (s *Recorder) Export(span SpanData) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.spans = append(s.spans, span)
+}
+
+func (s *Recorder) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Spans returns the recorded spans in the order they ended
+func (s *Recorder) Spans() []SpanData {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]SpanData{}, s.spans...)
+}
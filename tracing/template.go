@@ -0,0 +1,62 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"regexp"
+	"strings"
+)
+
+var identifier = regexp.MustCompile(`^(?i:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{16,}|[0-9]+)$`)
+
+// Path segments that are followed by the name of a resource in Azure Resource Manager paths
+var namedSegments = map[string]bool{
+	"managementgroups": true,
+	"resourcegroups":   true,
+	"subscriptions":    true,
+	"tenants":          true,
+}
+
+// TemplatePath replaces the ids and resource names in a Microsoft Graph or Azure Resource Manager path with
+// placeholders, e.g. /subscriptions/{name}/resourceGroups/{name}/providers/Microsoft.Compute/virtualMachines/{name}
+func TemplatePath(path string) string {
+	var (
+		segments = strings.Split(path, "/")
+		provider = -1
+	)
+
+	for i, segment := range segments {
+		lower := strings.ToLower(segment)
+		if segment == "" {
+			continue
+		} else if lower == "providers" {
+			provider = i
+		} else if provider >= 0 && i > provider+1 && (i-provider)%2 == 1 {
+			// providers/{namespace}/{type}/{name}/{type}/{name}...
+			segments[i] = "{name}"
+		} else if i > 0 && namedSegments[strings.ToLower(segments[i-1])] {
+			segments[i] = "{name}"
+		} else if i+1 < len(segments) && strings.EqualFold(segments[i+1], "oauth2") {
+			// login endpoints are prefixed with the tenant id or domain
+			segments[i] = "{tenant}"
+		} else if identifier.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
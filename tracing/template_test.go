@@ -0,0 +1,39 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import "testing"
+
+func TestTemplatePath(t *testing.T) {
+	tests := map[string]string{
+		"/v1.0/users": "/v1.0/users",
+		"/v1.0/groups/5c30a0c5-bfc8-4d2a-8e5b-8e0f3b1c2a11/members":                                                       "/v1.0/groups/{id}/members",
+		"/beta/roleManagement/directory/roleDefinitions/62e90394-69f5-4237-9190-012177145e10":                             "/beta/roleManagement/directory/roleDefinitions/{id}",
+		"/tenant.onmicrosoft.com/oauth2/v2.0/token":                                                                       "/{tenant}/oauth2/v2.0/token",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines":                                "/subscriptions/{name}/resourceGroups/{name}/providers/Microsoft.Compute/virtualMachines",
+		"/subscriptions/sub/providers/Microsoft.Web/sites/app/providers/Microsoft.Authorization/roleAssignments":          "/subscriptions/{name}/providers/Microsoft.Web/sites/{name}/providers/Microsoft.Authorization/roleAssignments",
+		"/providers/Microsoft.Management/managementGroups/root/descendants":                                               "/providers/Microsoft.Management/managementGroups/{name}/descendants",
+		"/subscriptions/sub/providers/Microsoft.ContainerService/managedClusters/aks/agentPools/pool/machines/0123456789": "/subscriptions/{name}/providers/Microsoft.ContainerService/managedClusters/{name}/agentPools/{name}/machines/{name}",
+	}
+
+	for path, want := range tests {
+		if got := TemplatePath(path); got != want {
+			t.Errorf("got %s for %s, want %s", got, path, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tracing records spans for a collection run and exports them over OTLP. Tracing is disabled until Enable is
+// called; while disabled Start returns a nil *Span, whose methods are no-ops, so instrumented code costs a single
+// atomic load.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type SpanKind int
+
+// Span kinds as defined by OTLP
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindClient   SpanKind = 3
+)
+
+type StatusCode int
+
+// Status codes as defined by OTLP
+const (
+	StatusUnset StatusCode = 0
+	StatusOk    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanData is a finished span as handed to an Exporter
+type SpanData struct {
+	TraceId       [16]byte
+	SpanId        [8]byte
+	ParentSpanId  [8]byte
+	Name          string
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Attribute returns the value of the attribute with the given key, or nil if it isn't set
+func (s SpanData) Attribute(key string) interface{} {
+	for _, attribute := range s.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+type Exporter interface {
+	// Export is called once for every sampled span when it ends; it must not block
+	Export(span SpanData)
+
+	// Shutdown exports any buffered spans and releases the exporter
+	Shutdown(ctx context.Context) error
+}
+
+type tracer struct {
+	exporter  Exporter
+	threshold uint64
+}
+
+var current atomic.Pointer[tracer]
+
+// Enable starts recording spans, exporting a sampled share of traces to exporter. samplePercent is clamped to
+// [0, 100]; a trace is either sampled in its entirety or not at all.
+func Enable(exporter Exporter, samplePercent int) {
+	var threshold uint64
+	if samplePercent >= 100 {
+		threshold = math.MaxUint64
+	} else if samplePercent > 0 {
+		threshold = uint64(float64(math.MaxUint64) / 100 * float64(samplePercent))
+	}
+	current.Store(&tracer{exporter: exporter, threshold: threshold})
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Shutdown stops recording spans and flushes the exporter
+func Shutdown(ctx context.Context) error {
+	if tracer := current.Swap(nil); tracer == nil {
+		return nil
+	} else {
+		return tracer.exporter.Shutdown(ctx)
+	}
+}
+
+type spanKey struct{}
+
+// Span is an operation in progress. A nil *Span is valid and ignores every call, which is what Start returns while
+// tracing is disabled.
+type Span struct {
+	tracer  *tracer
+	sampled bool
+	mutex   sync.Mutex
+	ended   bool
+	data    SpanData
+}
+
+// Start starts a span as a child of the span in ctx, or as the root of a new trace if ctx has none. keysAndValues
+// are attributes given as alternating keys and values, the same way they are given to the logger.
+func Start(ctx context.Context, name string, keysAndValues ...interface{}) (context.Context, *Span) {
+	return start(ctx, name, SpanKindInternal, keysAndValues)
+}
+
+func start(ctx context.Context, name string, kind SpanKind, keysAndValues []interface{}) (context.Context, *Span) {
+	tracer := current.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: tracer,
+		data: SpanData{
+			Name:  name,
+			Kind:  kind,
+			Start: time.Now(),
+		},
+	}
+
+	var ids [24]byte
+	rand.Read(ids[:])
+	copy(span.data.SpanId[:], ids[16:])
+
+	if parent := FromContext(ctx); parent != nil {
+		span.data.TraceId = parent.data.TraceId
+		span.data.ParentSpanId = parent.data.SpanId
+		span.sampled = parent.sampled
+	} else {
+		copy(span.data.TraceId[:], ids[:16])
+		span.sampled = binary.BigEndian.Uint64(ids[8:16]) < tracer.threshold || tracer.threshold == math.MaxUint64
+	}
+
+	span.SetAttributes(keysAndValues...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span in ctx, or nil if there is none
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttributes records attributes given as alternating keys and values
+func (s *Span) SetAttributes(keysAndValues ...interface{}) {
+	if s == nil || !s.sampled {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		s.data.Attributes = append(s.data.Attributes, Attribute{Key: key, Value: keysAndValues[i+1]})
+	}
+}
+
+// SetError marks the span as failed with err; a nil err is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || !s.sampled || err == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+// End finishes the span and hands it to the exporter; calls after the first are ignored
+func (s *Span) End() {
+	if s == nil || !s.sampled {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mutex.Unlock()
+
+	s.tracer.exporter.Export(data)
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStartDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "span", "foo", "bar")
+	if span != nil {
+		t.Fatal("expected a nil span while tracing is disabled")
+	} else if FromContext(ctx) != nil {
+		t.Error("expected no span in the context while tracing is disabled")
+	}
+
+	// methods on a nil span are no-ops
+	span.SetAttributes("foo", "bar")
+	span.SetError(errors.New("error"))
+	span.End()
+}
+
+func TestStartHierarchy(t *testing.T) {
+	recorder := &Recorder{}
+	Enable(recorder, 100)
+	defer Shutdown(context.Background())
+
+	ctx, root := Start(context.Background(), "root", "count", 1)
+	_, child := Start(ctx, "child")
+	child.SetError(errors.New("failed"))
+	child.End()
+	child.End()
+	root.End()
+
+	if spans := recorder.Spans(); len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	} else if spans[0].Name != "child" || spans[1].Name != "root" {
+		t.Errorf("got spans %s and %s, want child and root", spans[0].Name, spans[1].Name)
+	} else if spans[0].TraceId != spans[1].TraceId {
+		t.Error("expected the child to share the trace id of its parent")
+	} else if spans[0].ParentSpanId != spans[1].SpanId {
+		t.Error("expected the child to reference its parent")
+	} else if spans[1].ParentSpanId != [8]byte{} {
+		t.Error("expected the root to have no parent")
+	} else if spans[0].Status != StatusError || spans[0].StatusMessage != "failed" {
+		t.Errorf("got status %v %q, want %v %q", spans[0].Status, spans[0].StatusMessage, StatusError, "failed")
+	} else if spans[1].Attribute("count") != 1 {
+		t.Errorf("got count %v, want 1", spans[1].Attribute("count"))
+	} else if spans[1].End.Before(spans[1].Start) {
+		t.Error("expected the span to end after it started")
+	}
+}
+
+func TestStartSampling(t *testing.T) {
+	recorder := &Recorder{}
+	Enable(recorder, 0)
+	defer Shutdown(context.Background())
+
+	for i := 0; i < 100; i++ {
+		ctx, root := Start(context.Background(), "root")
+		_, child := Start(ctx, "child")
+		child.End()
+		root.End()
+	}
+
+	if spans := recorder.Spans(); len(spans) != 0 {
+		t.Errorf("got %d spans, want 0", len(spans))
+	}
+
+	Enable(recorder, 50)
+	for i := 0; i < 1000; i++ {
+		ctx, root := Start(context.Background(), "root")
+		_, child := Start(ctx, "child")
+		child.End()
+		root.End()
+	}
+
+	// traces are sampled as a whole
+	if spans := recorder.Spans(); len(spans)%2 != 0 {
+		t.Errorf("got %d spans, want an even number", len(spans))
+	} else if traces := len(spans) / 2; traces < 350 || traces > 650 {
+		t.Errorf("got %d sampled traces, want about 500", traces)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport records a client span for every request sent through base. The span is named after the request method
+// and a templated path so that ids and resource names don't explode the number of distinct span names.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (s Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Enabled() {
+		return s.Base.RoundTrip(req)
+	}
+
+	path := TemplatePath(req.URL.Path)
+	_, span := start(req.Context(), fmt.Sprintf("HTTP %s %s", req.Method, path), SpanKindClient, []interface{}{
+		"http.method", req.Method,
+		"http.host", req.URL.Host,
+		"http.route", path,
+	})
+	defer span.End()
+
+	res, err := s.Base.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.SetAttributes("http.status_code", res.StatusCode)
+		if res.StatusCode >= http.StatusBadRequest {
+			span.SetError(fmt.Errorf("%s", res.Status))
+		}
+	}
+	return res, err
+}
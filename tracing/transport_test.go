@@ -0,0 +1,69 @@
+// Copyright (C) 2022 Specter Ops, Inc.
+//
+// This file is part of AzureHound.
+//
+// AzureHound is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// AzureHound is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport{Base: http.DefaultTransport}}
+
+	// nothing is recorded while tracing is disabled
+	if res, err := client.Get(server.URL + "/v1.0/users"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		res.Body.Close()
+	}
+
+	recorder := &Recorder{}
+	Enable(recorder, 100)
+	defer Shutdown(context.Background())
+
+	ctx, root := Start(context.Background(), "root")
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1.0/users/1234/memberOf", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if res, err := client.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		res.Body.Close()
+	}
+	root.End()
+
+	if spans := recorder.Spans(); len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	} else if span := spans[0]; span.Name != "HTTP GET /v1.0/users/{id}/memberOf" {
+		t.Errorf("got span %s, want %s", span.Name, "HTTP GET /v1.0/users/{id}/memberOf")
+	} else if span.Kind != SpanKindClient {
+		t.Errorf("got kind %v, want %v", span.Kind, SpanKindClient)
+	} else if span.ParentSpanId != spans[1].SpanId {
+		t.Error("expected the request span to be a child of the span in the request context")
+	} else if span.Attribute("http.status_code") != http.StatusForbidden {
+		t.Errorf("got status code %v, want %v", span.Attribute("http.status_code"), http.StatusForbidden)
+	} else if span.Status != StatusError {
+		t.Errorf("got status %v, want %v", span.Status, StatusError)
+	}
+}